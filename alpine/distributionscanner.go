@@ -15,7 +15,6 @@ import (
 	"github.com/quay/claircore"
 	"github.com/quay/claircore/indexer"
 	"github.com/quay/claircore/osrelease"
-	"github.com/quay/claircore/pkg/tarfs"
 )
 
 // Alpine linux has patch releases but their security database
@@ -25,13 +24,14 @@ import (
 
 const (
 	scannerName    = "alpine"
-	scannerVersion = "2"
+	scannerVersion = "3"
 	scannerKind    = "distribution"
 )
 
 const (
-	osReleasePath = `etc/os-release`
-	issuePath     = `etc/issue`
+	osReleasePath    = `etc/os-release`
+	issuePath        = `etc/issue`
+	repositoriesPath = `etc/apk/repositories`
 )
 
 var (
@@ -57,6 +57,9 @@ func (*DistributionScanner) Kind() string { return scannerKind }
 // Scan will inspect the layer for an os-release or lsb-release file
 // and perform a regex match for keywords indicating the associated alpine release
 //
+// If the apk repositories file names a branch, the reported distribution is
+// normalized to that branch, as that's what determines the installed packages.
+//
 // If neither file is found a (nil, nil) is returned.
 // If the files are found but all regexp fail to match an empty slice is returned.
 func (s *DistributionScanner) Scan(ctx context.Context, l *claircore.Layer) ([]*claircore.Distribution, error) {
@@ -67,15 +70,11 @@ func (s *DistributionScanner) Scan(ctx context.Context, l *claircore.Layer) ([]*
 		"layer", l.Hash.String())
 	zlog.Debug(ctx).Msg("start")
 	defer zlog.Debug(ctx).Msg("done")
-	rc, err := l.Reader()
-	if err != nil {
-		return nil, err
-	}
-	defer rc.Close()
-	sys, err := tarfs.New(rc)
+	sys, err := l.FS()
 	if err != nil {
 		return nil, err
 	}
+	defer sys.Close()
 	return s.scanFs(ctx, sys)
 }
 
@@ -150,5 +149,44 @@ func (*DistributionScanner) scanFs(ctx context.Context, sys fs.FS) (d []*clairco
 	return nil, nil
 
 Done:
+	switch r, ok, err := repositoriesBranch(sys); {
+	case err != nil:
+		return nil, err
+	case ok:
+		zlog.Debug(ctx).
+			Stringer("branch", r).
+			Msg("found branch in repositories file")
+		rd := r.Distribution()
+		d[0].PrettyName = rd.PrettyName
+		d[0].Version = rd.VersionID
+	}
 	return d, nil
 }
+
+// RepositoriesBranch reports the branch configured in the apk repositories
+// file, if any.
+//
+// Tagged repositories (lines starting with "@") are pinned extras and are
+// ignored. The first branch found wins.
+func repositoriesBranch(sys fs.FS) (release, bool, error) {
+	b, err := fs.ReadFile(sys, repositoriesPath)
+	switch {
+	case errors.Is(err, nil):
+	case errors.Is(err, fs.ErrNotExist):
+		return release{}, false, nil
+	default:
+		return release{}, false, err
+	}
+	for _, line := range strings.Split(string(b), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || line[0] == '#' || line[0] == '@' {
+			continue
+		}
+		for _, seg := range strings.Split(line, "/") {
+			if r, ok := parseBranch(seg); ok {
+				return r, true, nil
+			}
+		}
+	}
+	return release{}, false, nil
+}
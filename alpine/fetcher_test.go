@@ -4,6 +4,7 @@ import (
 	"context"
 	"net/http"
 	"net/http/httptest"
+	"sort"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
@@ -40,8 +41,11 @@ func TestFactory(t *testing.T) {
 	for i, u := range us {
 		got[i] = u.Name()
 	}
+	// The UpdaterSet doesn't keep any order.
+	sort.Strings(got)
 	want := []string{
 		"alpine-community-v3.10-updater",
+		"alpine-main-edge-updater",
 	}
 	if !cmp.Equal(got, want) {
 		t.Error(cmp.Diff(got, want))
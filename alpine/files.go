@@ -0,0 +1,61 @@
+package alpine
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+
+	"github.com/quay/zlog"
+)
+
+// OwnedFiles returns the files owned by the packages in the apk database in
+// "sys", mapped to the name of the owning package. Directories shared between
+// packages are attributed to one of them.
+//
+// The returned paths are relative to the root of "sys", like the paths used
+// with the io/fs package. A nil map is returned if there's no database.
+func OwnedFiles(ctx context.Context, sys fs.FS) (map[string]string, error) {
+	ctx = zlog.ContextWithValues(ctx, "component", "alpine/OwnedFiles")
+	b, err := fs.ReadFile(sys, installedFile)
+	switch {
+	case errors.Is(err, nil):
+	case errors.Is(err, fs.ErrNotExist):
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("alpine: unable to read database: %w", err)
+	}
+	// Every "P" line starts a package, every "F" line names a directory, and
+	// the "R" lines following it name the files in that directory.
+	out := make(map[string]string)
+	var name, dir string
+	s := bufio.NewScanner(bytes.NewReader(b))
+	for s.Scan() {
+		k, v, ok := strings.Cut(s.Text(), ":")
+		if !ok {
+			// Blank line between packages.
+			name, dir = "", ""
+			continue
+		}
+		switch k {
+		case "P":
+			name = v
+		case "F":
+			dir = v
+			out[dir] = name
+		case "R":
+			out[path.Join(dir, v)] = name
+		}
+	}
+	if err := s.Err(); err != nil {
+		return nil, fmt.Errorf("alpine: unable to read database: %w", err)
+	}
+	zlog.Debug(ctx).
+		Int("count", len(out)).
+		Msg("found owned files")
+	return out, nil
+}
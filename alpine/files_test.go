@@ -0,0 +1,43 @@
+package alpine
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/quay/zlog"
+)
+
+func TestOwnedFiles(t *testing.T) {
+	ctx := zlog.Test(context.Background(), t)
+	sys := fstest.MapFS{
+		installedFile: &fstest.MapFile{Data: []byte(`P:py3-requests
+V:2.28.2-r0
+F:usr/lib/python3.11/site-packages/requests-2.28.2.dist-info
+R:METADATA
+R:RECORD
+
+P:busybox
+V:1.36.0-r9
+R:rootfile
+F:bin
+R:busybox
+`)},
+	}
+	got, err := OwnedFiles(ctx, sys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"usr/lib/python3.11/site-packages/requests-2.28.2.dist-info":          "py3-requests",
+		"usr/lib/python3.11/site-packages/requests-2.28.2.dist-info/METADATA": "py3-requests",
+		"usr/lib/python3.11/site-packages/requests-2.28.2.dist-info/RECORD":   "py3-requests",
+		"rootfile":    "busybox",
+		"bin":         "busybox",
+		"bin/busybox": "busybox",
+	}
+	if !cmp.Equal(got, want) {
+		t.Error(cmp.Diff(got, want))
+	}
+}
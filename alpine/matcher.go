@@ -3,7 +3,6 @@ package alpine
 import (
 	"context"
 
-	version "github.com/knqyf263/go-apk-version"
 	"github.com/quay/claircore"
 	"github.com/quay/claircore/libvuln/driver"
 )
@@ -11,7 +10,10 @@ import (
 // Matcher implements driver.Matcher for Alpine containers.
 type Matcher struct{}
 
-var _ driver.Matcher = (*Matcher)(nil)
+var (
+	_ driver.Matcher = (*Matcher)(nil)
+	_ driver.Scoped  = (*Matcher)(nil)
+)
 
 // Name implements driver.Matcher.
 func (*Matcher) Name() string {
@@ -34,6 +36,14 @@ func (*Matcher) Filter(record *claircore.IndexRecord) bool {
 	}
 }
 
+// Scope implements driver.Scoped.
+func (*Matcher) Scope() driver.Scope {
+	return driver.Scope{
+		Distributions:     []string{distID},
+		DistributionNames: []string{distName},
+	}
+}
+
 // Query implements driver.Matcher.
 func (*Matcher) Query() []driver.MatchConstraint {
 	return []driver.MatchConstraint{
@@ -44,28 +54,23 @@ func (*Matcher) Query() []driver.MatchConstraint {
 }
 
 // Vulnerable implements driver.Matcher.
+//
+// Advisories are organized per branch, so a vulnerability is only considered
+// if it's from the same branch (e.g. "v3.18" or "edge") as the record.
 func (*Matcher) Vulnerable(ctx context.Context, record *claircore.IndexRecord, vuln *claircore.Vulnerability) (bool, error) {
-	v1, err := version.NewVersion(record.Package.Version)
-	if err != nil {
-		return false, nil
-	}
-
-	v2, err := version.NewVersion(vuln.FixedInVersion)
-	if err != nil {
-		return false, nil
+	if rb, ok := distBranch(record.Distribution); ok {
+		if vb, ok := distBranch(vuln.Dist); ok && vb != rb {
+			return false, nil
+		}
 	}
 
-	if vuln.FixedInVersion == "" {
+	switch {
+	case vuln.FixedInVersion == "":
 		return true, nil
-	}
-
-	if vuln.FixedInVersion == "0" {
+	case vuln.FixedInVersion == "0":
+		return false, nil
+	case !validVersion(record.Package.Version), !validVersion(vuln.FixedInVersion):
 		return false, nil
 	}
-
-	if v1.LessThan(v2) {
-		return true, nil
-	}
-
-	return false, nil
+	return compareVersion(record.Package.Version, vuln.FixedInVersion) < 0, nil
 }
@@ -0,0 +1,105 @@
+package alpine
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/quay/zlog"
+
+	"github.com/quay/claircore"
+)
+
+func TestMatcherBranch(t *testing.T) {
+	ctx := zlog.Test(context.Background(), t)
+	// Each fixture has the same package fixed at a different version.
+	var vulns []*claircore.Vulnerability
+	for _, r := range []release{{3, 18}, {3, 19}, edgeRelease} {
+		f, err := os.Open(filepath.Join("testdata", "matcher", r.String()+"-main.json"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		u := &updater{release: r, repo: "main"}
+		vs, err := u.Parse(ctx, f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		vulns = append(vulns, vs...)
+	}
+
+	// Dist returns a Distribution as reported by the DistributionScanner.
+	dist := func(r release) *claircore.Distribution {
+		d := r.Distribution()
+		return &claircore.Distribution{
+			DID:        d.DID,
+			Name:       d.Name,
+			Version:    d.VersionID,
+			PrettyName: d.PrettyName,
+		}
+	}
+	tt := []struct {
+		Name    string
+		Dist    *claircore.Distribution
+		Version string
+		Want    []string
+	}{
+		{
+			Name:    "v3.18 fixed",
+			Dist:    dist(release{3, 18}),
+			Version: "3.1.3-r0",
+			Want:    []string{},
+		},
+		{
+			Name:    "v3.19 vulnerable",
+			Dist:    dist(release{3, 19}),
+			Version: "3.1.3-r0",
+			Want:    []string{"alpine-main-v3.19-updater"},
+		},
+		{
+			Name:    "v3.19 fixed",
+			Dist:    dist(release{3, 19}),
+			Version: "3.1.4-r0",
+			Want:    []string{},
+		},
+		{
+			Name:    "edge vulnerable",
+			Dist:    dist(edgeRelease),
+			Version: "3.1.4-r0",
+			Want:    []string{"alpine-main-edge-updater"},
+		},
+	}
+	var m Matcher
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			ctx := zlog.Test(ctx, t)
+			r := &claircore.IndexRecord{
+				Package: &claircore.Package{
+					Name:    "openssl",
+					Version: tc.Version,
+					Source: &claircore.Package{
+						Name: "openssl",
+					},
+				},
+				Distribution: tc.Dist,
+			}
+			if !m.Filter(r) {
+				t.Fatal("record unexpectedly filtered")
+			}
+			got := []string{}
+			for _, v := range vulns {
+				ok, err := m.Vulnerable(ctx, r, v)
+				if err != nil {
+					t.Error(err)
+				}
+				if ok {
+					got = append(got, v.Updater)
+				}
+			}
+			if !cmp.Equal(got, tc.Want) {
+				t.Error(cmp.Diff(got, tc.Want))
+			}
+		})
+	}
+}
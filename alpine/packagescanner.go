@@ -11,7 +11,6 @@ import (
 
 	"github.com/quay/claircore"
 	"github.com/quay/claircore/indexer"
-	"github.com/quay/claircore/pkg/tarfs"
 )
 
 const (
@@ -59,14 +58,11 @@ func (*Scanner) Scan(ctx context.Context, layer *claircore.Layer) ([]*claircore.
 	zlog.Debug(ctx).Msg("start")
 	defer zlog.Debug(ctx).Msg("done")
 
-	rc, err := layer.Reader()
-	if err != nil {
-		return nil, err
-	}
-	sys, err := tarfs.New(rc)
+	sys, err := layer.FS()
 	if err != nil {
 		return nil, err
 	}
+	defer sys.Close()
 	b, err := fs.ReadFile(sys, installedFile)
 	switch {
 	case err == nil:
@@ -2,6 +2,8 @@ package alpine
 
 import (
 	"fmt"
+	"strconv"
+	"strings"
 	"sync"
 
 	"github.com/quay/claircore"
@@ -15,6 +17,9 @@ import (
 // release is a particular release of the Alpine linux distribution
 type release [2]int
 
+// EdgeRelease is the rolling development branch, "edge".
+var edgeRelease = release{-1, -1}
+
 // Common os-release fields applicable for *claircore.Distribution usage.
 const (
 	distName = "Alpine Linux"
@@ -28,14 +33,78 @@ func (r release) Distribution() *claircore.Distribution {
 	k := int64(r[0]<<32) | int64(r[1])
 	v, ok := relMap.Load(k)
 	if !ok {
-		v, _ = relMap.LoadOrStore(k, &claircore.Distribution{
+		d := &claircore.Distribution{
 			Name:       distName,
 			DID:        distID,
 			VersionID:  fmt.Sprintf("%d.%d", r[0], r[1]),
 			PrettyName: fmt.Sprintf("Alpine Linux v%d.%d", r[0], r[1]),
-		})
+		}
+		if r == edgeRelease {
+			d.VersionID = "edge"
+			d.PrettyName = "Alpine Linux edge"
+		}
+		v, _ = relMap.LoadOrStore(k, d)
 	}
 	return v.(*claircore.Distribution)
 }
 
-func (r release) String() string { return fmt.Sprintf("v%d.%d", r[0], r[1]) }
+func (r release) String() string {
+	if r == edgeRelease {
+		return "edge"
+	}
+	return fmt.Sprintf("v%d.%d", r[0], r[1])
+}
+
+// ParseBranch parses a branch name as it appears in repository URLs, e.g.
+// "v3.18" or "edge".
+func parseBranch(s string) (release, bool) {
+	if s == "edge" {
+		return edgeRelease, true
+	}
+	if !strings.HasPrefix(s, "v") {
+		return release{}, false
+	}
+	return parseVersion(s[1:], true)
+}
+
+// ParseVersion parses the major and minor numbers out of a version string. If
+// "exact" is set, no further components are allowed.
+func parseVersion(s string, exact bool) (release, bool) {
+	fs := strings.SplitN(s, ".", 3)
+	if len(fs) < 2 || (exact && len(fs) > 2) {
+		return release{}, false
+	}
+	var r release
+	for i := range r {
+		n, err := strconv.Atoi(fs[i])
+		if err != nil || n < 0 {
+			return release{}, false
+		}
+		r[i] = n
+	}
+	return r, true
+}
+
+// DistBranch reports the branch a Distribution belongs to.
+//
+// Both the Distributions created by this package's updaters and the ones
+// reported by the DistributionScanner are understood.
+func distBranch(d *claircore.Distribution) (release, bool) {
+	if d == nil {
+		return release{}, false
+	}
+	if i := strings.LastIndexByte(d.PrettyName, ' '); i != -1 {
+		if r, ok := parseBranch(d.PrettyName[i+1:]); ok {
+			return r, true
+		}
+	}
+	for _, v := range []string{d.VersionID, d.Version} {
+		if v == "edge" {
+			return edgeRelease, true
+		}
+		if r, ok := parseVersion(v, false); ok {
+			return r, true
+		}
+	}
+	return release{}, false
+}
@@ -38,6 +38,7 @@ var (
 // More explictly, it expects:
 // - a "last-update" file with opaque contents that change when any constituent database changes
 // - contiguously numbered directories with the name "v$maj.$min" starting with "maj" as "3" and "min" as at most "3"
+// - optionally, a directory named "edge" for the development branch
 // - JSON files inside those directories named "main.json" or "community.json"
 //
 // The [Configure] method must be called before the [UpdaterSet] method.
@@ -153,6 +154,26 @@ Major:
 			}
 		}
 	}
+	// The development branch isn't numbered, so check for it separately.
+	eu, err := f.base.Parse(edgeRelease.String() + "/")
+	if err != nil {
+		return s, fmt.Errorf("alpine: unable to construct request: %w", err)
+	}
+	ereq, err := http.NewRequestWithContext(ctx, http.MethodHead, eu.String(), nil)
+	if err != nil {
+		return s, fmt.Errorf("alpine: unable to construct request: %w", err)
+	}
+	zlog.Debug(ctx).
+		Stringer("url", eu).
+		Msg("checking edge release")
+	eres, err := f.c.Do(ereq)
+	if err != nil {
+		return s, fmt.Errorf("alpine: error requesting %q: %w", eu.String(), err)
+	}
+	eres.Body.Close()
+	if eres.StatusCode == http.StatusOK {
+		todo = append(todo, edgeRelease)
+	}
 	for _, r := range todo {
 		for _, n := range []string{`main`, `community`} {
 			u, err := f.base.Parse(path.Join(r.String(), n+".json"))
@@ -0,0 +1,207 @@
+package alpine
+
+// This is a port of the version comparison in apk-tools' "src/version.c".
+//
+// An apk version looks like:
+//
+//	digit{.digit}...{letter}{_suf{#}}...{-r#}
+//
+// Pre-release suffixes ("_alpha", "_beta", "_pre", "_rc") sort before the bare
+// release, while post-release suffixes ("_cvs", "_svn", "_git", "_hg", "_p")
+// sort after it. The "-r" revision is compared numerically.
+
+// Token is the kind of the next component of a version string.
+//
+// The order is significant: it's used when one version has more components
+// than the other.
+type token int
+
+const (
+	tokInvalid token = iota - 1
+	tokDigitOrZero
+	tokDigit
+	tokLetter
+	tokSuffix
+	tokSuffixNo
+	tokRevisionNo
+	tokEnd
+)
+
+var (
+	preSuffixes  = [...]string{"alpha", "beta", "pre", "rc"}
+	postSuffixes = [...]string{"cvs", "svn", "git", "hg", "p"}
+)
+
+// VersionScanner walks the components of a version string.
+type versionScanner struct {
+	s string
+	t token
+}
+
+func newVersionScanner(s string) versionScanner {
+	return versionScanner{s: s, t: tokDigit}
+}
+
+// Next consumes the component of the current kind, returns its value, and
+// advances the kind to the next component.
+func (v *versionScanner) next() int {
+	if len(v.s) == 0 {
+		v.t = tokEnd
+		return 0
+	}
+	n, i, nt := 0, 0, tokInvalid
+	switch v.t {
+	case tokDigitOrZero:
+		// Leading zero digits get a special treatment: more zeros sort
+		// earlier, and all sort before any non-zero number.
+		if v.s[0] == '0' {
+			for i < len(v.s) && v.s[i] == '0' {
+				i++
+			}
+			n = -i
+			// Apk proper always expects more digits here, which makes a
+			// component like the "0" in "1.0_rc" sort after "1.0". Only do
+			// that if there are actually more digits.
+			if i < len(v.s) && isDigit(v.s[i]) {
+				nt = tokDigit
+			}
+			break
+		}
+		fallthrough
+	case tokDigit, tokSuffixNo, tokRevisionNo:
+		for i < len(v.s) && isDigit(v.s[i]) {
+			n = n*10 + int(v.s[i]-'0')
+			i++
+		}
+	case tokLetter:
+		n = int(v.s[0])
+		i = 1
+	case tokSuffix:
+		found := false
+		for j, suf := range preSuffixes {
+			if len(v.s) >= len(suf) && v.s[:len(suf)] == suf {
+				n, i, found = j-len(preSuffixes), len(suf), true
+				break
+			}
+		}
+		if found {
+			break
+		}
+		for j, suf := range postSuffixes {
+			if len(v.s) >= len(suf) && v.s[:len(suf)] == suf {
+				n, i, found = j, len(suf), true
+				break
+			}
+		}
+		if found {
+			break
+		}
+		// Invalid suffix.
+		fallthrough
+	default:
+		v.t = tokInvalid
+		return -1
+	}
+	v.s = v.s[i:]
+	switch {
+	case len(v.s) == 0:
+		v.t = tokEnd
+	case nt != tokInvalid:
+		v.t = nt
+	default:
+		v.advance()
+	}
+	return n
+}
+
+// Advance determines the kind of the next component, consuming any
+// separator.
+func (v *versionScanner) advance() {
+	n := tokInvalid
+	c := v.s[0]
+	switch {
+	case (v.t == tokDigit || v.t == tokDigitOrZero) && isLower(c):
+		n = tokLetter
+	case v.t == tokLetter && isDigit(c):
+		n = tokDigit
+	case v.t == tokSuffix && isDigit(c):
+		n = tokSuffixNo
+	default:
+		switch c {
+		case '.':
+			n = tokDigitOrZero
+		case '_':
+			n = tokSuffix
+		case '-':
+			if len(v.s) > 1 && v.s[1] == 'r' {
+				n = tokRevisionNo
+				v.s = v.s[1:]
+			}
+		}
+		v.s = v.s[1:]
+	}
+	if n < v.t {
+		switch {
+		case n == tokDigitOrZero && v.t == tokDigit:
+		case n == tokSuffix && v.t == tokSuffixNo:
+		case n == tokDigit && v.t == tokLetter:
+		default:
+			n = tokInvalid
+		}
+	}
+	v.t = n
+}
+
+func isDigit(c byte) bool { return c >= '0' && c <= '9' }
+func isLower(c byte) bool { return c >= 'a' && c <= 'z' }
+
+// ValidVersion reports whether "s" is a well-formed apk version.
+func validVersion(s string) bool {
+	if s == "" {
+		return false
+	}
+	v := newVersionScanner(s)
+	for v.t != tokEnd && v.t != tokInvalid {
+		v.next()
+	}
+	return v.t == tokEnd
+}
+
+// CompareVersion compares two apk versions, returning -1, 0, or 1 if "a" is
+// less than, equal to, or greater than "b", respectively.
+func compareVersion(a, b string) int {
+	va, vb := newVersionScanner(a), newVersionScanner(b)
+	var na, nb int
+	for va.t == vb.t && va.t != tokEnd && va.t != tokInvalid && na == nb {
+		na = va.next()
+		nb = vb.next()
+	}
+	switch {
+	case na < nb:
+		return -1
+	case na > nb:
+		return 1
+	case va.t == vb.t:
+		return 0
+	}
+	// The leading components are equal, so the longer version is greater
+	// unless the remainder is a pre-release suffix.
+	//
+	// The kinds are saved before looking at any suffix: apk proper compares
+	// the kinds after consuming the suffix, which makes the first
+	// post-release suffix ("_cvs") compare equal to the bare release.
+	ta, tb := va.t, vb.t
+	if ta == tokSuffix && va.next() < 0 {
+		return -1
+	}
+	if tb == tokSuffix && vb.next() < 0 {
+		return 1
+	}
+	switch {
+	case ta > tb:
+		return -1
+	case ta < tb:
+		return 1
+	}
+	return 0
+}
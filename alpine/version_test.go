@@ -0,0 +1,155 @@
+package alpine
+
+import (
+	"strings"
+	"testing"
+)
+
+// Most of these are taken from apk-tools' "test/version.data"; the rest cover
+// suffix and revision ordering seen in Alpine advisories.
+var versionCompareVectors = []string{
+	"2.34 > 0.1.0_alpha",
+	"23_foo > 4_beta",
+	"1.0 < 1.0bc",
+	"0.1.0_alpha = 0.1.0_alpha",
+	"0.1.0_alpha < 0.1.3_alpha",
+	"0.1.3_alpha > 0.1.0_alpha",
+	"0.1.0_alpha2 > 0.1.0_alpha",
+	"0.1.0_alpha < 2.2.39-r1",
+	"2.2.39-r1 > 1.0.4-r3",
+	"1.0.4-r3 < 1.0.4-r4",
+	"1.0.4-r4 < 1.6",
+	"1.6 > 1.0.2",
+	"1.0.2 > 0.7-r1",
+	"0.7-r1 < 1.0.0",
+	"1.0.0 < 1.0.1",
+	"1.0.1 < 1.1",
+	"1.1 > 1.1_alpha1",
+	"1.1_alpha1 < 1.2.1",
+	"1.2.1 > 1.2",
+	"1.2 < 1.3_alpha",
+	"1.3_alpha < 1.3_alpha2",
+	"1.3_alpha2 < 1.3_alpha3",
+	"1.3_alpha8 > 0.6.0",
+	"0.6.0 < 0.6.1",
+	"0.6.1 < 0.7.0",
+	"0.7.0 < 0.8_beta1",
+	"0.8_beta1 < 0.8_beta2",
+	"0.8_beta4 < 4.8-r1",
+	"4.8-r1 > 3.10.18-r1",
+	"3.10.18-r1 > 2.3.0b-r1",
+	"2.3.0b-r1 < 2.3.0b-r2",
+	"2.3.0b-r2 < 2.3.0b-r3",
+	"2.3.0b-r3 < 2.3.0b-r4",
+	"2.3.0b-r4 > 0.12.1",
+	"0.12.1 < 0.12.2",
+	"0.12.2 < 0.12.3",
+	"0.12.3 > 0.12",
+	"0.12 < 0.13_beta1",
+	"0.13_beta1 < 0.13_beta2",
+	"0.13_beta2 < 0.13_beta3",
+	"0.13_beta3 < 0.13_beta4",
+	"0.13_beta4 < 0.13_beta5",
+	"0.13_beta5 > 0.9.12",
+	"0.9.12 < 0.9.13",
+	"0.9.13 > 0.9.12",
+	"1.0_alpha < 1.0_beta",
+	"1.0_beta < 1.0_pre",
+	"1.0_pre < 1.0_rc",
+	"1.0_rc < 1.0",
+	"1.0 < 1.0_cvs",
+	"1.0_cvs < 1.0_svn",
+	"1.0_svn < 1.0_git",
+	"1.0_git < 1.0_hg",
+	"1.0_hg < 1.0_p",
+	"1.0_rc1 < 1.0_rc2",
+	"1.0_rc2 < 1.0_rc10",
+	"1.0_p1 < 1.0_p2",
+	"1.0 < 1.0-r1",
+	"1.0-r1 < 1.0-r2",
+	"1.0-r9 < 1.0-r10",
+	"1.0_rc1-r1 < 1.0-r0",
+	"1.0_p1-r0 > 1.0-r9",
+	"1.0a < 1.0b",
+	"1.0 < 1.0a",
+	"1.01 < 1.1",
+	"1.001 < 1.01",
+	"1.0 = 1.0",
+	"1.0-r0 > 1.0",
+	"3.1.3-r0 < 3.1.4-r0",
+	"3.1.4-r0 < 3.1.4-r1",
+	"1.1.1t-r0 > 1.1.1s-r2",
+	"1.36.1-r2 < 1.36.1-r15",
+	"2.40.0_rc1-r0 < 2.40.0-r0",
+}
+
+func TestVersionCompare(t *testing.T) {
+	for _, line := range versionCompareVectors {
+		fs := strings.Fields(line)
+		if len(fs) != 3 {
+			t.Fatalf("bad vector: %q", line)
+		}
+		a, op, b := fs[0], fs[1], fs[2]
+		var want int
+		switch op {
+		case "<":
+			want = -1
+		case "=":
+			want = 0
+		case ">":
+			want = 1
+		default:
+			t.Fatalf("bad operator: %q", line)
+		}
+		t.Run(line, func(t *testing.T) {
+			if got := compareVersion(a, b); got != want {
+				t.Errorf("got: %d, want: %d", got, want)
+			}
+			// Check the reverse, as well.
+			if got := compareVersion(b, a); got != -want {
+				t.Errorf("reverse: got: %d, want: %d", got, -want)
+			}
+		})
+	}
+}
+
+func TestVersionValid(t *testing.T) {
+	tt := []struct {
+		In   string
+		Want bool
+	}{
+		{In: "1.0", Want: true},
+		{In: "1.0_alpha1-r2", Want: true},
+		{In: "1.0a_p3-r0", Want: true},
+		{In: "2.3.0b-r1", Want: true},
+		{In: "", Want: false},
+		{In: "1.0-", Want: false},
+		{In: "1.0-x1", Want: false},
+		{In: "1.0_foo", Want: false},
+		{In: "1.0 beta", Want: false},
+		{In: "1..0", Want: true},
+	}
+	for _, tc := range tt {
+		if got := validVersion(tc.In); got != tc.Want {
+			t.Errorf("%q: got: %v, want: %v", tc.In, got, tc.Want)
+		}
+	}
+}
+
+func FuzzVersionCompare(f *testing.F) {
+	for _, line := range versionCompareVectors {
+		fs := strings.Fields(line)
+		f.Add(fs[0], fs[2])
+	}
+	f.Fuzz(func(t *testing.T, a, b string) {
+		validVersion(a)
+		validVersion(b)
+		ab, ba := compareVersion(a, b), compareVersion(b, a)
+		if ab != -ba {
+			t.Errorf("asymmetric comparison: %q vs %q: %d, %d", a, b, ab, ba)
+		}
+		if got := compareVersion(a, a); got != 0 {
+			t.Errorf("%q: not equal to itself: %d", a, got)
+		}
+	})
+}
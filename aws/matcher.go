@@ -6,12 +6,17 @@ import (
 	version "github.com/knqyf263/go-rpm-version"
 
 	"github.com/quay/claircore"
+	"github.com/quay/claircore/internal/vercache"
 	"github.com/quay/claircore/libvuln/driver"
 )
 
 type Matcher struct{}
 
-var _ driver.Matcher = (*Matcher)(nil)
+var (
+	_ driver.Matcher              = (*Matcher)(nil)
+	_ driver.FixedVersionComparer = (*Matcher)(nil)
+	_ driver.Scoped               = (*Matcher)(nil)
+)
 
 func (*Matcher) Name() string {
 	return "aws-matcher"
@@ -32,6 +37,14 @@ func (*Matcher) Filter(record *claircore.IndexRecord) bool {
 	return false
 }
 
+// Scope implements driver.Scoped.
+func (*Matcher) Scope() driver.Scope {
+	return driver.Scope{
+		Distributions:     []string{ID},
+		DistributionNames: []string{linux1Dist.Name, linux2Dist.Name},
+	}
+}
+
 func (*Matcher) Query() []driver.MatchConstraint {
 	return []driver.MatchConstraint{
 		driver.DistributionDID,
@@ -39,8 +52,8 @@ func (*Matcher) Query() []driver.MatchConstraint {
 	}
 }
 
-func (*Matcher) Vulnerable(_ context.Context, record *claircore.IndexRecord, vuln *claircore.Vulnerability) (bool, error) {
-	pkgVer := version.NewVersion(record.Package.Version)
+func (*Matcher) Vulnerable(ctx context.Context, record *claircore.IndexRecord, vuln *claircore.Vulnerability) (bool, error) {
+	pkgVer := vercache.RPM(ctx, record.Package.Version)
 	var vulnVer version.Version
 	// Assume the vulnerability record we have is for the last known vulnerable
 	// version, so greater versions aren't vulnerable.
@@ -48,7 +61,7 @@ func (*Matcher) Vulnerable(_ context.Context, record *claircore.IndexRecord, vul
 	// But if it's explicitly marked as a fixed-in version, it's only vulnerable
 	// if less than that version.
 	if vuln.FixedInVersion != "" {
-		vulnVer = version.NewVersion(vuln.FixedInVersion)
+		vulnVer = vercache.RPM(ctx, vuln.FixedInVersion)
 		cmp = func(i int) bool { return i == version.LESS }
 	} else {
 		// If a vulnerability doesn't have FixedInVersion, assume it is unfixed.
@@ -57,3 +70,8 @@ func (*Matcher) Vulnerable(_ context.Context, record *claircore.IndexRecord, vul
 	// compare version and architecture
 	return cmp(pkgVer.Compare(vulnVer)) && vuln.ArchOperation.Cmp(record.Package.Arch, vuln.Package.Arch), nil
 }
+
+// CompareFixedVersions implements driver.FixedVersionComparer.
+func (*Matcher) CompareFixedVersions(ctx context.Context, a, b string) int {
+	return vercache.CompareRPM(ctx, a, b)
+}
@@ -0,0 +1,34 @@
+package configfile
+
+import (
+	"context"
+
+	"github.com/quay/claircore"
+	"github.com/quay/claircore/indexer"
+)
+
+type coalescer struct{}
+
+// Coalesce reports the recorded configuration files, keyed by path. A file
+// recorded in a later layer replaces the same path from earlier layers.
+func (c *coalescer) Coalesce(ctx context.Context, layerArtifacts []*indexer.LayerArtifacts) (*claircore.IndexReport, error) {
+	ir := &claircore.IndexReport{}
+	for _, l := range layerArtifacts {
+		for _, f := range l.Files {
+			if f.Kind != claircore.FileKindConfig {
+				continue
+			}
+			if ir.Files == nil {
+				ir.Files = make(map[string]claircore.File)
+			}
+			ir.Files[FileKey(f.Path)] = f
+		}
+	}
+	return ir, nil
+}
+
+// FileKey returns the key used for a configuration file in an IndexReport's
+// Files map.
+func FileKey(path string) string {
+	return string(claircore.FileKindConfig) + ":" + path
+}
@@ -0,0 +1,172 @@
+// Package configfile implements a file scanner that records configuration
+// files of interest, for later evaluation by policy engines.
+package configfile
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"strings"
+
+	"github.com/quay/zlog"
+
+	"github.com/quay/claircore"
+	"github.com/quay/claircore/indexer"
+)
+
+const (
+	scannerName    = "configfile"
+	scannerVersion = "1"
+	scannerKind    = "file"
+)
+
+// DefaultMaxSize is the largest file, in bytes, whose contents are recorded if
+// ScannerConfig.MaxSize is unset. Larger files only have their hash recorded.
+const DefaultMaxSize = 64 * 1024
+
+var (
+	_ indexer.FileScanner         = (*Scanner)(nil)
+	_ indexer.VersionedScanner    = (*Scanner)(nil)
+	_ indexer.ConfigurableScanner = (*Scanner)(nil)
+)
+
+// ScannerConfig is the struct used to configure a Scanner.
+//
+// Layer results are cached by scanner version, so layers already scanned are
+// not re-examined when the configuration changes.
+type ScannerConfig struct {
+	// Paths is the allowlist of files to record, e.g. "etc/ssh/sshd_config".
+	// Paths are relative to the root of the layer; a leading "/" is ignored.
+	Paths []string `yaml:"paths" json:"paths"`
+	// Contents controls whether file contents are recorded in addition to
+	// the hash.
+	Contents bool `yaml:"contents" json:"contents"`
+	// MaxSize overrides DefaultMaxSize, if provided.
+	MaxSize int64 `yaml:"max_size" json:"max_size"`
+}
+
+// Scanner records the files named in its configuration as a
+// [claircore.File] of kind [claircore.FileKindConfig], with the SHA-256 digest
+// of the contents and, optionally, the contents themselves.
+//
+// The zero value records nothing.
+type Scanner struct {
+	paths    []string
+	contents bool
+	maxSize  int64
+}
+
+// Name implements indexer.VersionedScanner.
+func (*Scanner) Name() string { return scannerName }
+
+// Version implements indexer.VersionedScanner.
+func (*Scanner) Version() string { return scannerVersion }
+
+// Kind implements indexer.VersionedScanner.
+func (*Scanner) Kind() string { return scannerKind }
+
+// Configure implements indexer.ConfigurableScanner.
+func (s *Scanner) Configure(ctx context.Context, f indexer.ConfigDeserializer) error {
+	var cfg ScannerConfig
+	if err := f(&cfg); err != nil {
+		return err
+	}
+	s.paths = s.paths[:0]
+	for _, p := range cfg.Paths {
+		p = path.Clean(strings.TrimPrefix(p, "/"))
+		if p == "." || !fs.ValidPath(p) {
+			return fmt.Errorf("configfile: invalid path %q", p)
+		}
+		s.paths = append(s.paths, p)
+	}
+	s.contents = cfg.Contents
+	s.maxSize = cfg.MaxSize
+	if s.maxSize <= 0 {
+		s.maxSize = DefaultMaxSize
+	}
+	return nil
+}
+
+// Scan implements indexer.FileScanner.
+func (s *Scanner) Scan(ctx context.Context, l *claircore.Layer) ([]claircore.File, error) {
+	if len(s.paths) == 0 {
+		return nil, nil
+	}
+	ctx = zlog.ContextWithValues(ctx,
+		"component", "configfile/Scanner.Scan",
+		"version", s.Version(),
+		"layer", l.Hash.String())
+	zlog.Debug(ctx).Msg("start")
+	defer zlog.Debug(ctx).Msg("done")
+	sys, err := l.FS()
+	if err != nil {
+		return nil, fmt.Errorf("configfile: unable to create fs: %w", err)
+	}
+	defer sys.Close()
+
+	var out []claircore.File
+	for _, p := range s.paths {
+		f, err := s.record(sys, p)
+		switch {
+		case errors.Is(err, nil):
+		case errors.Is(err, fs.ErrNotExist):
+			continue
+		default:
+			return nil, fmt.Errorf("configfile: unable to record %q: %w", p, err)
+		}
+		if f == nil {
+			continue
+		}
+		zlog.Debug(ctx).
+			Str("path", p).
+			Stringer("hash", f.Hash).
+			Msg("recorded file")
+		out = append(out, *f)
+	}
+	return out, nil
+}
+
+// Record returns the File for the path "p", or nil if it's not a regular file.
+func (s *Scanner) record(sys fs.FS, p string) (*claircore.File, error) {
+	fi, err := fs.Stat(sys, p)
+	if err != nil {
+		return nil, err
+	}
+	if !fi.Mode().IsRegular() {
+		return nil, nil
+	}
+	f, err := sys.Open(p)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	var r io.Reader = f
+	var buf bytes.Buffer
+	keep := s.contents && fi.Size() <= s.maxSize
+	if keep {
+		r = io.TeeReader(f, &buf)
+	}
+	if _, err := io.Copy(h, r); err != nil {
+		return nil, err
+	}
+	d, err := claircore.NewDigest("sha256", h.Sum(nil))
+	if err != nil {
+		return nil, err
+	}
+	cf := claircore.File{
+		Path: p,
+		Kind: claircore.FileKindConfig,
+		Hash: d,
+	}
+	if keep {
+		cf.Contents = buf.Bytes()
+	}
+	return &cf, nil
+}
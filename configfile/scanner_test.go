@@ -0,0 +1,143 @@
+package configfile
+
+import (
+	"archive/tar"
+	"context"
+	"crypto/sha256"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/quay/zlog"
+
+	"github.com/quay/claircore"
+)
+
+func TestScanner(t *testing.T) {
+	ctx := zlog.Test(context.Background(), t)
+	const (
+		sshd   = "PermitRootLogin no\nPasswordAuthentication no\n"
+		passwd = "root:x:0:0:root:/root:/bin/bash\n"
+	)
+	tf, err := os.Create(filepath.Join(t.TempDir(), "layer.tar"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tf.Close()
+	tw := tar.NewWriter(tf)
+	for _, e := range []struct {
+		Name, Content string
+		Dir           bool
+	}{
+		{Name: "etc/ssh/", Dir: true},
+		{Name: "etc/ssh/sshd_config", Content: sshd},
+		{Name: "etc/passwd", Content: passwd},
+		{Name: "etc/shadow", Content: "root:*::0:::::\n"},
+	} {
+		h := &tar.Header{
+			Name:     e.Name,
+			Typeflag: tar.TypeReg,
+			Size:     int64(len(e.Content)),
+			Mode:     0o644,
+		}
+		if e.Dir {
+			h.Typeflag = tar.TypeDir
+			h.Mode = 0o755
+		}
+		if err := tw.WriteHeader(h); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(e.Content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	var l claircore.Layer
+	l.SetLocal(tf.Name())
+
+	digest := func(s string) claircore.Digest {
+		sum := sha256.Sum256([]byte(s))
+		d, err := claircore.NewDigest("sha256", sum[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		return d
+	}
+	opt := cmp.AllowUnexported(claircore.Digest{})
+	tt := []struct {
+		Name   string
+		Config ScannerConfig
+		Want   []claircore.File
+	}{
+		{
+			Name: "Unconfigured",
+		},
+		{
+			Name: "HashOnly",
+			Config: ScannerConfig{
+				Paths: []string{"/etc/ssh/sshd_config", "etc/passwd", "etc/os-release", "etc/ssh"},
+			},
+			Want: []claircore.File{
+				{Path: "etc/ssh/sshd_config", Kind: claircore.FileKindConfig, Hash: digest(sshd)},
+				{Path: "etc/passwd", Kind: claircore.FileKindConfig, Hash: digest(passwd)},
+			},
+		},
+		{
+			Name: "Contents",
+			Config: ScannerConfig{
+				Paths:    []string{"etc/ssh/sshd_config", "etc/passwd"},
+				Contents: true,
+				MaxSize:  int64(len(sshd)),
+			},
+			Want: []claircore.File{
+				{Path: "etc/ssh/sshd_config", Kind: claircore.FileKindConfig, Hash: digest(sshd), Contents: []byte(sshd)},
+				{Path: "etc/passwd", Kind: claircore.FileKindConfig, Hash: digest(passwd), Contents: []byte(passwd)},
+			},
+		},
+		{
+			Name: "TooLarge",
+			Config: ScannerConfig{
+				Paths:    []string{"etc/ssh/sshd_config"},
+				Contents: true,
+				MaxSize:  4,
+			},
+			Want: []claircore.File{
+				{Path: "etc/ssh/sshd_config", Kind: claircore.FileKindConfig, Hash: digest(sshd)},
+			},
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			ctx := zlog.Test(ctx, t)
+			var s Scanner
+			err := s.Configure(ctx, func(v interface{}) error {
+				*(v.(*ScannerConfig)) = tc.Config
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := s.Scan(ctx, &l)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !cmp.Equal(got, tc.Want, opt) {
+				t.Error(cmp.Diff(got, tc.Want, opt))
+			}
+		})
+	}
+
+	t.Run("BadPath", func(t *testing.T) {
+		var s Scanner
+		err := s.Configure(ctx, func(v interface{}) error {
+			v.(*ScannerConfig).Paths = []string{"../etc/passwd"}
+			return nil
+		})
+		if err == nil {
+			t.Error("expected error, got nil")
+		}
+	})
+}
@@ -22,6 +22,7 @@ import (
 var (
 	_ driver.Matcher       = (*matcher)(nil)
 	_ driver.RemoteMatcher = (*matcher)(nil)
+	_ driver.Scoped        = (*matcher)(nil)
 )
 
 const (
@@ -29,6 +30,9 @@ const (
 	//doc:url matcher
 	defaultURL    = "https://gw.api.openshift.io/api/v2/"
 	defaultSource = "clair-upstream"
+	// DefaultTimeout is used for requests if the provided client has no
+	// timeout set.
+	defaultTimeout = 5 * time.Second
 )
 
 var supportedEcosystems = []string{"pypi", "maven"}
@@ -102,10 +106,16 @@ func newMatcher(ecosystem string, key string, opt ...option) (*matcher, error) {
 	if m.source == "" {
 		m.source = defaultSource
 	}
-	// The CRDA remote matcher doesn't use the clair roundtripper
-	// client as the ratelimiting doesn't apply and a specific
-	// timeout is preferred.
-	m.client = &http.Client{Timeout: 5 * time.Second}
+	// The CRDA remote matcher prefers a specific timeout, so set one on a
+	// copy of the provided client if it doesn't have one.
+	switch {
+	case m.client == nil:
+		m.client = &http.Client{Timeout: defaultTimeout}
+	case m.client.Timeout == 0:
+		c := *m.client
+		c.Timeout = defaultTimeout
+		m.client = &c
+	}
 
 	return &m, nil
 }
@@ -149,6 +159,13 @@ func (m *matcher) Filter(record *claircore.IndexRecord) bool {
 	return record.Repository.Name == m.ecosystem
 }
 
+// Scope implements driver.Scoped.
+func (m *matcher) Scope() driver.Scope {
+	return driver.Scope{
+		Repositories: []string{m.ecosystem},
+	}
+}
+
 // Query implements driver.Matcher.
 func (*matcher) Query() []driver.MatchConstraint {
 	panic("unreachable")
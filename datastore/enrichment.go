@@ -15,6 +15,13 @@ type EnrichmentUpdater interface {
 	// EnrichmentRecord(s), and ensures enrichments from previous updates are not
 	// queries by clients.
 	UpdateEnrichments(ctx context.Context, kind string, fingerprint driver.Fingerprint, enrichments []driver.EnrichmentRecord) (uuid.UUID, error)
+	// DeltaUpdateEnrichments is like UpdateEnrichments, but also carries
+	// forward the enrichments from the updater's previous
+	// EnrichmentUpdateOperation, unless they share a tag with one of the
+	// provided EnrichmentRecords. Both happen in one update, so the new
+	// EnrichmentUpdateOperation is never seen holding only the changes. See
+	// driver.DeltaUpdater.
+	DeltaUpdateEnrichments(ctx context.Context, kind string, fingerprint driver.Fingerprint, enrichments []driver.EnrichmentRecord) (uuid.UUID, error)
 }
 
 // Enrichment is an interface for querying enrichments from the store.
@@ -0,0 +1,202 @@
+// Package memory implements the vulnerability store interface over
+// vulnerabilities held in memory.
+//
+// This is useful for matching without a database, such as when the
+// vulnerability data is loaded from a file or shipped alongside the
+// application.
+package memory
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/quay/claircore"
+	"github.com/quay/claircore/datastore"
+	"github.com/quay/claircore/libvuln/driver"
+)
+
+var _ datastore.Vulnerability = (*Store)(nil)
+
+// Store holds vulnerabilities in memory, indexed by package name.
+//
+// Store implements the same matching as the database-backed store, so the
+// results of a match don't depend on which is used.
+type Store struct {
+	mu     sync.RWMutex
+	byName map[string][]*claircore.Vulnerability
+	n      int
+}
+
+// New returns a Store containing the provided vulnerabilities.
+//
+// See Store.Add for how the vulnerabilities are handled.
+func New(vs ...*claircore.Vulnerability) *Store {
+	s := Store{
+		byName: make(map[string][]*claircore.Vulnerability),
+	}
+	s.Add(vs...)
+	return &s
+}
+
+// Add adds the provided vulnerabilities to the Store.
+//
+// The Store keeps a copy of every vulnerability. A vulnerability without an
+// ID is assigned one, because vulnerability reports are keyed by ID.
+// Vulnerabilities without a package name can never be matched, so they're
+// ignored.
+func (s *Store) Add(vs ...*claircore.Vulnerability) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, v := range vs {
+		if v == nil || v.Package == nil || v.Package.Name == "" {
+			continue
+		}
+		s.n++
+		c := *v
+		if c.ID == "" {
+			c.ID = strconv.Itoa(s.n)
+		}
+		s.byName[c.Package.Name] = append(s.byName[c.Package.Name], &c)
+	}
+}
+
+// Len reports the number of vulnerabilities in the Store.
+func (s *Store) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var n int
+	for _, vs := range s.byName {
+		n += len(vs)
+	}
+	return n
+}
+
+// Get implements datastore.Vulnerability.
+//
+// The returned vulnerabilities are shared between calls and must not be
+// modified. A Store doesn't keep update operations, so pinning any is an
+// error.
+func (s *Store) Get(ctx context.Context, records []*claircore.IndexRecord, opts datastore.GetOpts) (map[string][]*claircore.Vulnerability, error) {
+	if len(opts.UpdateOperations) != 0 {
+		return nil, fmt.Errorf("memory: can't pin update operations: %w", datastore.ErrUnknownUpdateOperation)
+	}
+	for _, m := range opts.Matchers {
+		if m <= 0 || m > driver.PackageCPE {
+			return nil, fmt.Errorf("was provided unknown matcher: %v", m)
+		}
+	}
+	provides := hasConstraint(opts.Matchers, driver.PackageProvides)
+	product := hasConstraint(opts.Matchers, driver.PackageCPE)
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make(map[string][]*claircore.Vulnerability)
+	for _, r := range records {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if r.Package == nil || r.Package.Name == "" {
+			continue
+		}
+		seen := make(map[*claircore.Vulnerability]struct{})
+		add := func(name, kind string) {
+			for _, v := range s.byName[name] {
+				if _, ok := seen[v]; ok {
+					continue
+				}
+				if v.Package.Kind != kind || !matches(r, v, opts) {
+					continue
+				}
+				seen[v] = struct{}{}
+				out[r.Package.ID] = append(out[r.Package.ID], v)
+			}
+		}
+		if product {
+			add(r.Package.CPE.ProductKey(), claircore.BINARY)
+			continue
+		}
+		add(r.Package.Name, r.Package.Kind)
+		if src := r.Package.Source; src != nil && src.Name != "" {
+			add(src.Name, src.Kind)
+		}
+		if provides {
+			for _, p := range r.Package.Provides {
+				n, _, _ := strings.Cut(p, " ")
+				add(n, claircore.BINARY)
+			}
+		}
+	}
+	return out, nil
+}
+
+// Matches reports whether the vulnerability "v" satisfies the constraints
+// and version filtering in "opts" for the record "r".
+//
+// Missing distributions and repositories compare as if all their fields were
+// empty, the same as in the database.
+func matches(r *claircore.IndexRecord, v *claircore.Vulnerability, opts datastore.GetOpts) bool {
+	rd, vd := r.Distribution, v.Dist
+	if rd == nil {
+		rd = &claircore.Distribution{}
+	}
+	if vd == nil {
+		vd = &claircore.Distribution{}
+	}
+	rr, vr := r.Repository, v.Repo
+	if rr == nil {
+		rr = &claircore.Repository{}
+	}
+	if vr == nil {
+		vr = &claircore.Repository{}
+	}
+	for _, m := range opts.Matchers {
+		var ok bool
+		switch m {
+		case driver.PackageSourceName, driver.PackageName, driver.PackageProvides, driver.PackageCPE:
+			// Handled by the name lookup.
+			ok = true
+		case driver.PackageModule:
+			ok = r.Package.Module == v.Package.Module
+		case driver.DistributionDID:
+			ok = rd.DID == vd.DID
+		case driver.DistributionName:
+			ok = rd.Name == vd.Name
+		case driver.DistributionVersion:
+			ok = rd.Version == vd.Version
+		case driver.DistributionVersionCodeName:
+			ok = rd.VersionCodeName == vd.VersionCodeName
+		case driver.DistributionVersionID:
+			ok = rd.VersionID == vd.VersionID
+		case driver.DistributionArch:
+			ok = rd.Arch == vd.Arch
+		case driver.DistributionCPE:
+			ok = rd.CPE.String() == vd.CPE.String()
+		case driver.DistributionPrettyName:
+			ok = rd.PrettyName == vd.PrettyName
+		case driver.RepositoryName:
+			ok = rr.Name == vr.Name
+		}
+		if !ok {
+			return false
+		}
+	}
+	if opts.VersionFiltering && !v.VersionAgnostic() {
+		nv := &r.Package.NormalizedVersion
+		rng := v.Range
+		if rng == nil || rng.Lower.Kind != nv.Kind || rng.Upper.Kind != nv.Kind || !rng.Contains(nv) {
+			return false
+		}
+	}
+	return true
+}
+
+func hasConstraint(ms []driver.MatchConstraint, want driver.MatchConstraint) bool {
+	for _, m := range ms {
+		if m == want {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,231 @@
+package memory_test
+
+import (
+	"context"
+	"sort"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/quay/zlog"
+
+	"github.com/quay/claircore"
+	"github.com/quay/claircore/datastore"
+	"github.com/quay/claircore/datastore/memory"
+	"github.com/quay/claircore/libvuln"
+	"github.com/quay/claircore/libvuln/driver"
+	"github.com/quay/claircore/pkg/cpe"
+	"github.com/quay/claircore/pkg/pep440"
+	"github.com/quay/claircore/python"
+)
+
+var (
+	debian11 = &claircore.Distribution{DID: "debian", VersionID: "11"}
+	debian12 = &claircore.Distribution{DID: "debian", VersionID: "12"}
+)
+
+func TestGet(t *testing.T) {
+	ctx := zlog.Test(context.Background(), t)
+	s := memory.New(
+		&claircore.Vulnerability{
+			Name:    "src-11",
+			Package: &claircore.Package{Name: "openssl", Kind: claircore.SOURCE},
+			Dist:    debian11,
+		},
+		&claircore.Vulnerability{
+			Name:    "src-12",
+			Package: &claircore.Package{Name: "openssl", Kind: claircore.SOURCE},
+			Dist:    debian12,
+		},
+		&claircore.Vulnerability{
+			Name:    "bin-12",
+			Package: &claircore.Package{Name: "libssl3", Kind: claircore.BINARY},
+			Dist:    debian12,
+		},
+		&claircore.Vulnerability{
+			Name:    "provided",
+			Package: &claircore.Package{Name: "libssl.so.3", Kind: claircore.BINARY},
+			Dist:    debian12,
+		},
+		&claircore.Vulnerability{
+			Name:    "in-range",
+			Package: &claircore.Package{Name: "libssl3", Kind: claircore.BINARY},
+			Dist:    debian12,
+			Range: &claircore.Range{
+				Lower: claircore.Version{Kind: "test", V: [10]int32{1}},
+				Upper: claircore.Version{Kind: "test", V: [10]int32{3}},
+			},
+		},
+		&claircore.Vulnerability{
+			Name:    "out-of-range",
+			Package: &claircore.Package{Name: "libssl3", Kind: claircore.BINARY},
+			Dist:    debian12,
+			Range: &claircore.Range{
+				Lower: claircore.Version{Kind: "test", V: [10]int32{3}},
+				Upper: claircore.Version{Kind: "test", V: [10]int32{4}},
+			},
+		},
+		&claircore.Vulnerability{
+			Name:           "agnostic",
+			Package:        &claircore.Package{Name: "libssl3", Kind: claircore.BINARY},
+			Dist:           debian12,
+			AlwaysAffected: true,
+		},
+		&claircore.Vulnerability{
+			Name:    "product",
+			Package: &claircore.Package{Name: "cpe:2.3:a:openssl:openssl", Kind: claircore.BINARY},
+		},
+		// Ignored: no package.
+		&claircore.Vulnerability{Name: "bogus"},
+	)
+	if got, want := s.Len(), 8; got != want {
+		t.Errorf("got: %d vulnerabilities, want: %d", got, want)
+	}
+	rec := &claircore.IndexRecord{
+		Package: &claircore.Package{
+			ID:                "1",
+			Name:              "libssl3",
+			Kind:              claircore.BINARY,
+			NormalizedVersion: claircore.Version{Kind: "test", V: [10]int32{2}},
+			Source:            &claircore.Package{Name: "openssl", Kind: claircore.SOURCE},
+			Provides:          []string{"libssl.so.3 = 3.0.11"},
+			CPE:               cpe.MustUnbind(`cpe:2.3:a:openssl:openssl:3.0.11:*:*:*:*:*:*:*`),
+		},
+		Distribution: debian12,
+	}
+
+	tt := []struct {
+		Name string
+		Opts datastore.GetOpts
+		Want []string
+	}{
+		{
+			Name: "Unconstrained",
+			Want: []string{"agnostic", "bin-12", "in-range", "out-of-range", "src-11", "src-12"},
+		},
+		{
+			Name: "Distribution",
+			Opts: datastore.GetOpts{
+				Matchers: []driver.MatchConstraint{driver.DistributionDID, driver.DistributionVersionID},
+			},
+			Want: []string{"agnostic", "bin-12", "in-range", "out-of-range", "src-12"},
+		},
+		{
+			Name: "Provides",
+			Opts: datastore.GetOpts{
+				Matchers: []driver.MatchConstraint{driver.DistributionVersionID, driver.PackageProvides},
+			},
+			Want: []string{"agnostic", "bin-12", "in-range", "out-of-range", "provided", "src-12"},
+		},
+		{
+			Name: "CPE",
+			Opts: datastore.GetOpts{
+				Matchers: []driver.MatchConstraint{driver.PackageCPE},
+			},
+			Want: []string{"product"},
+		},
+		{
+			Name: "VersionFiltering",
+			Opts: datastore.GetOpts{
+				Matchers:         []driver.MatchConstraint{driver.DistributionVersionID},
+				VersionFiltering: true,
+			},
+			Want: []string{"agnostic", "in-range"},
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			res, err := s.Get(ctx, []*claircore.IndexRecord{rec}, tc.Opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			ids := make(map[string]struct{})
+			for _, v := range res[rec.Package.ID] {
+				got = append(got, v.Name)
+				ids[v.ID] = struct{}{}
+			}
+			sort.Strings(got)
+			if !cmp.Equal(got, tc.Want) {
+				t.Error(cmp.Diff(got, tc.Want))
+			}
+			if len(ids) != len(got) {
+				t.Errorf("duplicate IDs: %v", ids)
+			}
+		})
+	}
+
+	t.Run("UnknownConstraint", func(t *testing.T) {
+		_, err := s.Get(ctx, []*claircore.IndexRecord{rec}, datastore.GetOpts{
+			Matchers: []driver.MatchConstraint{driver.MatchConstraint(1000)},
+		})
+		if err == nil {
+			t.Error("expected error")
+		}
+	})
+}
+
+func TestMatch(t *testing.T) {
+	ctx := zlog.Test(context.Background(), t)
+	mkPkg := func(id, name, version string) *claircore.Package {
+		v, err := pep440.Parse(version)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return &claircore.Package{
+			ID:                id,
+			Name:              name,
+			Version:           version,
+			Kind:              claircore.BINARY,
+			NormalizedVersion: v.Version(),
+		}
+	}
+	ir := &claircore.IndexReport{
+		Hash: claircore.MustParseDigest(`sha256:0000000000000000000000000000000000000000000000000000000000000000`),
+		Packages: map[string]*claircore.Package{
+			"1": mkPkg("1", "requests", "2.19.0"),
+			"2": mkPkg("2", "six", "1.16.0"),
+		},
+		Environments: map[string][]*claircore.Environment{
+			"1": {{PackageDB: "python:usr/lib/python3/site-packages", RepositoryIDs: []string{"pypi"}}},
+			"2": {{PackageDB: "python:usr/lib/python3/site-packages", RepositoryIDs: []string{"pypi"}}},
+		},
+		Repositories: map[string]*claircore.Repository{
+			"pypi": {ID: "pypi", Name: python.Repository.Name, URI: python.Repository.URI},
+		},
+	}
+	s := memory.New(
+		&claircore.Vulnerability{
+			Name:           "CVE-2018-18074",
+			Package:        &claircore.Package{Name: "requests", Kind: claircore.BINARY, Version: "<2.20.0"},
+			Repo:           &python.Repository,
+			FixedInVersion: "2.20.0",
+		},
+		&claircore.Vulnerability{
+			Name:           "CVE-2023-32681",
+			Package:        &claircore.Package{Name: "requests", Kind: claircore.BINARY, Version: ">=2.3.0,<2.31.0"},
+			Repo:           &python.Repository,
+			FixedInVersion: "2.31.0",
+		},
+		&claircore.Vulnerability{
+			Name:    "not-affected",
+			Package: &claircore.Package{Name: "six", Kind: claircore.BINARY, Version: "<1.0.0"},
+			Repo:    &python.Repository,
+		},
+	)
+
+	vr, err := libvuln.Match(ctx, ir, []driver.Matcher{&python.Matcher{}}, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, id := range vr.PackageVulnerabilities["1"] {
+		got = append(got, vr.Vulnerabilities[id].Name)
+	}
+	sort.Strings(got)
+	if want := []string{"CVE-2018-18074", "CVE-2023-32681"}; !cmp.Equal(got, want) {
+		t.Error(cmp.Diff(got, want))
+	}
+	if got := vr.PackageVulnerabilities["2"]; len(got) != 0 {
+		t.Errorf("unexpected vulnerabilities for six: %v", got)
+	}
+}
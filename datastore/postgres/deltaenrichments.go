@@ -0,0 +1,91 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/quay/zlog"
+
+	"github.com/quay/claircore/libvuln/driver"
+)
+
+var (
+	carryEnrichmentsCounter = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "claircore",
+			Subsystem: "vulnstore",
+			Name:      "carryenrichments_total",
+			Help:      "Total number of database queries issued carrying enrichments forward.",
+		},
+		[]string{"query"},
+	)
+	carryEnrichmentsDuration = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: "claircore",
+			Subsystem: "vulnstore",
+			Name:      "carryenrichments_duration_seconds",
+			Help:      "The duration of all queries issued carrying enrichments forward.",
+		},
+		[]string{"query"},
+	)
+)
+
+// DeltaUpdateEnrichments implements vulnstore.Updater.
+//
+// Enrichments are shared between UpdateOperations, so carrying them forward
+// only adds associations; nothing is copied.
+func (s *MatcherStore) DeltaUpdateEnrichments(ctx context.Context, name string, fp driver.Fingerprint, es []driver.EnrichmentRecord) (uuid.UUID, error) {
+	ctx = zlog.ContextWithValues(ctx, "component", "datastore/postgres/DeltaUpdateEnrichments")
+	return s.updateEnrichments(ctx, name, fp, es, true)
+}
+
+// CarryEnrichments associates the enrichments of the updater's update
+// operation preceding "id" with it, unless they share a tag with one already
+// associated.
+func carryEnrichments(ctx context.Context, tx pgx.Tx, name string, id uint64) error {
+	const query = `
+WITH
+	prev AS (
+		SELECT max(uo.id) AS id
+		FROM update_operation AS uo
+		WHERE uo.updater = $1
+		  AND uo.kind = 'enrichment'
+		  AND uo.id < $2
+	)
+INSERT
+INTO
+	uo_enrich (enrich, updater, uo, date)
+SELECT
+	old.enrich, $1, $2, transaction_timestamp()
+FROM
+	prev
+	JOIN uo_enrich AS old ON old.uo = prev.id
+	JOIN enrichment AS e ON e.id = old.enrich
+WHERE
+	NOT EXISTS(
+		SELECT 1
+		FROM uo_enrich AS n
+			JOIN enrichment AS ne ON ne.id = n.enrich
+		WHERE n.uo = $2
+		  AND ne.tags && e.tags)
+ON CONFLICT
+DO
+	NOTHING;`
+
+	start := time.Now()
+	tag, err := tx.Exec(ctx, query, name, id)
+	if err != nil {
+		return fmt.Errorf("failed to carry enrichments forward: %w", err)
+	}
+	carryEnrichmentsCounter.WithLabelValues("insert").Add(1)
+	carryEnrichmentsDuration.WithLabelValues("insert").Observe(time.Since(start).Seconds())
+	zlog.Debug(ctx).
+		Int64("count", tag.RowsAffected()).
+		Msg("carried enrichments forward")
+	return nil
+}
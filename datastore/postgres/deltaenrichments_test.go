@@ -0,0 +1,54 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/quay/zlog"
+
+	"github.com/quay/claircore/libvuln/driver"
+	"github.com/quay/claircore/test/integration"
+	pgtest "github.com/quay/claircore/test/postgres"
+)
+
+func TestDeltaUpdateEnrichments(t *testing.T) {
+	integration.NeedDB(t)
+	ctx := zlog.Test(context.Background(), t)
+	pool := pgtest.TestMatcherDB(ctx, t)
+	store := NewMatcherStore(pool)
+
+	const name = "carry-updater"
+	rec := func(tag, data string) driver.EnrichmentRecord {
+		return driver.EnrichmentRecord{
+			Tags:       []string{tag},
+			Enrichment: json.RawMessage(`"` + data + `"`),
+		}
+	}
+	if _, err := store.UpdateEnrichments(ctx, name, "1", []driver.EnrichmentRecord{
+		rec("a", "old"), rec("b", "old"),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.DeltaUpdateEnrichments(ctx, name, "2", []driver.EnrichmentRecord{
+		rec("b", "new"), rec("c", "new"),
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := store.GetEnrichment(ctx, name, []string{"a", "b", "c"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, r := range res {
+		got = append(got, r.Tags[0]+"="+string(r.Enrichment))
+	}
+	sort.Strings(got)
+	want := []string{`a="old"`, `b="new"`, `c="new"`}
+	if !cmp.Equal(got, want) {
+		t.Error(cmp.Diff(got, want))
+	}
+}
@@ -17,6 +17,7 @@ func TestDigestEncode(t *testing.T) {
 		claircore.MustParseDigest(`sha256:` + strings.Repeat(`d`, 64)),
 		claircore.MustParseDigest(`sha256:` + strings.Repeat(`e`, 64)),
 		claircore.MustParseDigest(`sha256:` + strings.Repeat(`f`, 64)),
+		claircore.MustParseDigest(`sha512:` + strings.Repeat(`0`, 128)),
 	}
 	want := `{"sha256:` + strings.Repeat(`a`, 64) +
 		`","sha256:` + strings.Repeat(`b`, 64) +
@@ -24,6 +25,7 @@ func TestDigestEncode(t *testing.T) {
 		`","sha256:` + strings.Repeat(`d`, 64) +
 		`","sha256:` + strings.Repeat(`e`, 64) +
 		`","sha256:` + strings.Repeat(`f`, 64) +
+		`","sha512:` + strings.Repeat(`0`, 128) +
 		`"}`
 	got, err := ds.EncodeText(nil, nil)
 	if err != nil {
@@ -64,6 +64,14 @@ var (
 // EnrichmentRecord(s), and ensures enrichments from previous updates are not
 // queried by clients.
 func (s *MatcherStore) UpdateEnrichments(ctx context.Context, name string, fp driver.Fingerprint, es []driver.EnrichmentRecord) (uuid.UUID, error) {
+	ctx = zlog.ContextWithValues(ctx, "component", "datastore/postgres/UpdateEnrichments")
+	return s.updateEnrichments(ctx, name, fp, es, false)
+}
+
+// UpdateEnrichments does the work of UpdateEnrichments and
+// DeltaUpdateEnrichments. If "carry" is set, the enrichments from the
+// previous update operation are carried forward in the same transaction.
+func (s *MatcherStore) updateEnrichments(ctx context.Context, name string, fp driver.Fingerprint, es []driver.EnrichmentRecord, carry bool) (uuid.UUID, error) {
 	const (
 		create = `
 INSERT
@@ -107,26 +115,28 @@ ON CONFLICT
 DO
 	NOTHING;`
 	)
-	ctx = zlog.ContextWithValues(ctx, "component", "datastore/postgres/UpdateEnrichments")
+	if err := s.writable(); err != nil {
+		return uuid.Nil, err
+	}
 
 	var id uint64
 	var ref uuid.UUID
 
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("unable to start transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
 	start := time.Now()
 
-	if err := s.pool.QueryRow(ctx, create, name, string(fp)).Scan(&id, &ref); err != nil {
+	if err := tx.QueryRow(ctx, create, name, string(fp)).Scan(&id, &ref); err != nil {
 		return uuid.Nil, fmt.Errorf("failed to create update_operation: %w", err)
 	}
 
 	updateEnrichmentsCounter.WithLabelValues("create").Add(1)
 	updateEnrichmentsDuration.WithLabelValues("create").Observe(time.Since(start).Seconds())
 
-	tx, err := s.pool.Begin(ctx)
-	if err != nil {
-		return uuid.Nil, fmt.Errorf("unable to start transaction: %w", err)
-	}
-	defer tx.Rollback(ctx)
-
 	zlog.Debug(ctx).
 		Str("ref", ref.String()).
 		Msg("update_operation created")
@@ -151,6 +161,12 @@ DO
 	updateEnrichmentsCounter.WithLabelValues("insert_batch").Add(1)
 	updateEnrichmentsDuration.WithLabelValues("insert_batch").Observe(time.Since(start).Seconds())
 
+	if carry {
+		if err := carryEnrichments(ctx, tx, name, id); err != nil {
+			return uuid.Nil, err
+		}
+	}
+
 	if err := tx.Commit(ctx); err != nil {
 		return uuid.Nil, fmt.Errorf("failed to commit transaction: %w", err)
 	}
@@ -207,7 +223,7 @@ WHERE
 		c    *pgxpool.Conn
 		rows pgx.Rows
 	)
-	c, err = s.pool.Acquire(ctx)
+	c, err = s.replica.Acquire(ctx)
 	if err != nil {
 		return nil, err
 	}
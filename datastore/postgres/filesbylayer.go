@@ -46,7 +46,7 @@ func (s *IndexerStore) FilesByLayer(ctx context.Context, hash claircore.Digest,
 		  AND kind = $3;
 		`
 		query = `
-		SELECT file.path, file.kind
+		SELECT file.path, file.kind, file.hash, file.contents
 		FROM file_scanartifact
 				 LEFT JOIN file ON file_scanartifact.file_id = file.id
 				 JOIN layer ON layer.hash = $1
@@ -93,6 +93,8 @@ func (s *IndexerStore) FilesByLayer(ctx context.Context, hash claircore.Digest,
 		err := rows.Scan(
 			&res[i].Path,
 			&res[i].Kind,
+			&res[i].Hash,
+			&res[i].Contents,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan file: %w", err)
@@ -0,0 +1,72 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/quay/zlog"
+
+	"github.com/quay/claircore"
+	"github.com/quay/claircore/indexer"
+	"github.com/quay/claircore/test"
+	"github.com/quay/claircore/test/integration"
+	pgtest "github.com/quay/claircore/test/postgres"
+)
+
+func TestFilesByLayer(t *testing.T) {
+	integration.NeedDB(t)
+	ctx := zlog.Test(context.Background(), t)
+	pool := pgtest.TestIndexerDB(ctx, t)
+	store := NewIndexerStore(pool)
+	defer store.Close(ctx)
+
+	scnr := indexer.NewPackageScannerMock("files-test", "1", "file")
+	if err := store.RegisterScanners(ctx, indexer.VersionedScanners{scnr}); err != nil {
+		t.Fatal(err)
+	}
+	l := &claircore.Layer{Hash: test.RandomSHA256Digest(t)}
+	m := claircore.Manifest{
+		Hash:   test.RandomSHA256Digest(t),
+		Layers: []*claircore.Layer{l},
+	}
+	if err := store.PersistManifest(ctx, m); err != nil {
+		t.Fatal(err)
+	}
+	// Files without recorded contents, like whiteouts, are stored without
+	// a hash.
+	want := []claircore.File{
+		{Path: "etc/.wh.motd", Kind: claircore.FileKindWhiteout},
+		{
+			Path:     "etc/ssh/sshd_config",
+			Kind:     claircore.FileKindConfig,
+			Hash:     test.RandomSHA256Digest(t),
+			Contents: []byte("PermitRootLogin no\n"),
+		},
+	}
+	if err := store.IndexFiles(ctx, want, l, scnr); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := store.FilesByLayer(ctx, l.Hash, indexer.VersionedScanners{scnr})
+	if err != nil {
+		t.Fatal(err)
+	}
+	byPath := make(map[string]claircore.File, len(got))
+	for _, f := range got {
+		byPath[f.Path] = f
+	}
+	for _, f := range want {
+		g, ok := byPath[f.Path]
+		if !ok {
+			t.Errorf("missing file %q", f.Path)
+			continue
+		}
+		if got, want := g.Hash.String(), f.Hash.String(); got != want {
+			t.Errorf("%s: hash: got: %q, want: %q", f.Path, got, want)
+		}
+		if !cmp.Equal(g.Contents, f.Contents) || g.Kind != f.Kind {
+			t.Error(cmp.Diff(g, f, cmp.AllowUnexported(claircore.Digest{})))
+		}
+	}
+}
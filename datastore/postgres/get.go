@@ -6,6 +6,7 @@ import (
 	"strconv"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/jackc/pgx/v4"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
@@ -37,27 +38,78 @@ var (
 )
 
 // Get implements vulnstore.Vulnerability.
+//
+// Unless database-side version filtering is requested, records that only
+// differ in their package names are batched into a single query fetching
+// the candidate vulnerabilities for all of them.
 func (s *MatcherStore) Get(ctx context.Context, records []*claircore.IndexRecord, opts datastore.GetOpts) (map[string][]*claircore.Vulnerability, error) {
 	ctx = zlog.ContextWithValues(ctx, "component", "internal/vulnstore/postgres/Get")
-	tx, err := s.pool.Begin(ctx)
+	tx, err := s.replica.BeginTx(ctx, pgx.TxOptions{AccessMode: pgx.ReadOnly})
 	if err != nil {
 		return nil, err
 	}
 	defer tx.Rollback(ctx)
+	if err := checkPinned(ctx, tx, opts.UpdateOperations); err != nil {
+		return nil, err
+	}
 	// start a batch
 	batch := &pgx.Batch{}
-	for _, record := range records {
-		query, err := buildGetQuery(record, &opts)
-		if err != nil {
-			// if we cannot build a query for an individual record continue to the next
-			zlog.Debug(ctx).
-				Err(err).
-				Str("record", fmt.Sprintf("%+v", record)).
-				Msg("could not build query for record")
-			continue
+	// queued holds the records each queued query is for. A nil index means
+	// all the returned rows are for the single record.
+	type queuedQuery struct {
+		records []*claircore.IndexRecord
+		index   map[nameKind][]*claircore.IndexRecord
+	}
+	var queued []queuedQuery
+	if opts.VersionFiltering {
+		for _, record := range records {
+			query, args, err := buildGetQuery(record, &opts)
+			if err != nil {
+				// if we cannot build a query for an individual record continue to the next
+				zlog.Debug(ctx).
+					Err(err).
+					Str("record", fmt.Sprintf("%+v", record)).
+					Msg("could not build query for record")
+				continue
+			}
+			// queue the select query
+			batch.Queue(query, args...)
+			queued = append(queued, queuedQuery{records: []*claircore.IndexRecord{record}})
+		}
+	} else {
+		var keys []string
+		groups := make(map[string][]*claircore.IndexRecord)
+		for _, record := range records {
+			key, err := batchKey(record, opts.Matchers)
+			if err != nil {
+				// if we cannot build a query for an individual record continue to the next
+				zlog.Debug(ctx).
+					Err(err).
+					Str("record", fmt.Sprintf("%+v", record)).
+					Msg("could not build query for record")
+				continue
+			}
+			if _, ok := groups[key]; !ok {
+				keys = append(keys, key)
+			}
+			groups[key] = append(groups[key], record)
+		}
+		for _, key := range keys {
+			group := groups[key]
+			query, args, err := buildBatchGetQuery(group, &opts)
+			if err != nil {
+				return nil, fmt.Errorf("failed to build batch query: %w", err)
+			}
+			batch.Queue(query, args...)
+			queued = append(queued, queuedQuery{
+				records: group,
+				index:   recordIndex(group, opts.Matchers),
+			})
 		}
-		// queue the select query
-		batch.Queue(query)
+		zlog.Debug(ctx).
+			Int("records", len(records)).
+			Int("queries", len(queued)).
+			Msg("batched records")
 	}
 	// send the batch
 	tctx, cancel := context.WithTimeout(ctx, 30*time.Second)
@@ -72,7 +124,7 @@ func (s *MatcherStore) Get(ctx context.Context, records []*claircore.IndexRecord
 	// gather all the returned vulns for each queued select statement
 	results := make(map[string][]*claircore.Vulnerability)
 	vulnSet := make(map[string]map[string]struct{})
-	for _, record := range records {
+	for _, q := range queued {
 		rows, err := res.Query()
 		if err != nil {
 			res.Close()
@@ -116,6 +168,9 @@ func (s *MatcherStore) Get(ctx context.Context, records []*claircore.IndexRecord
 				&v.Repo.URI,
 				&v.FixedInVersion,
 				&v.Updater,
+				&v.AlwaysAffected,
+				&v.FixState,
+				&v.Condition,
 			)
 			v.ID = strconv.FormatInt(id, 10)
 			if err != nil {
@@ -123,13 +178,19 @@ func (s *MatcherStore) Get(ctx context.Context, records []*claircore.IndexRecord
 				return nil, fmt.Errorf("failed to scan vulnerability: %v", err)
 			}
 
-			rid := record.Package.ID
-			if _, ok := vulnSet[rid]; !ok {
-				vulnSet[rid] = make(map[string]struct{})
+			rs := q.records
+			if q.index != nil {
+				rs = q.index[nameKind{v.Package.Name, v.Package.Kind}]
 			}
-			if _, ok := vulnSet[rid][v.ID]; !ok {
-				vulnSet[rid][v.ID] = struct{}{}
-				results[rid] = append(results[rid], v)
+			for _, record := range rs {
+				rid := record.Package.ID
+				if _, ok := vulnSet[rid]; !ok {
+					vulnSet[rid] = make(map[string]struct{})
+				}
+				if _, ok := vulnSet[rid][v.ID]; !ok {
+					vulnSet[rid][v.ID] = struct{}{}
+					results[rid] = append(results[rid], v)
+				}
 			}
 		}
 	}
@@ -145,3 +206,38 @@ func (s *MatcherStore) Get(ctx context.Context, records []*claircore.IndexRecord
 	}
 	return results, nil
 }
+
+// CheckPinned makes sure every pinned update operation exists and belongs to
+// the updater it's pinned for.
+func checkPinned(ctx context.Context, tx pgx.Tx, pins map[string]uuid.UUID) error {
+	const query = `SELECT ref::text, updater FROM update_operation WHERE ref = ANY($1::uuid[]);`
+	if len(pins) == 0 {
+		return nil
+	}
+	refs := make([]string, 0, len(pins))
+	for _, ref := range pins {
+		refs = append(refs, ref.String())
+	}
+	rows, err := tx.Query(ctx, query, refs)
+	if err != nil {
+		return fmt.Errorf("failed to look up update operations: %w", err)
+	}
+	defer rows.Close()
+	found := make(map[string]string, len(pins))
+	for rows.Next() {
+		var ref, updater string
+		if err := rows.Scan(&ref, &updater); err != nil {
+			return fmt.Errorf("failed to scan update operation: %w", err)
+		}
+		found[ref] = updater
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to look up update operations: %w", err)
+	}
+	for updater, ref := range pins {
+		if got, ok := found[ref.String()]; !ok || got != updater {
+			return fmt.Errorf("%w: %v (updater %q)", datastore.ErrUnknownUpdateOperation, ref, updater)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jackc/pgx/v4/pgxpool"
+
+	"github.com/quay/claircore"
+	"github.com/quay/claircore/datastore"
+	"github.com/quay/claircore/libvuln/driver"
+	"github.com/quay/claircore/test"
+	"github.com/quay/claircore/test/integration"
+	pgtest "github.com/quay/claircore/test/postgres"
+)
+
+// BenchmarkGet compares matching with and without pgx's statement cache. As
+// the queries Get issues only vary with the shape of a record, the cached
+// case should skip parsing and planning for all but the first record.
+func BenchmarkGet(b *testing.B) {
+	integration.NeedDB(b)
+	ctx, done := context.WithCancel(context.Background())
+	defer done()
+	pool := pgtest.TestMatcherDB(ctx, b)
+	store := NewMatcherStore(pool)
+
+	vulns := test.GenUniqueVulnerabilities(500, "benchmark")
+	if _, err := store.UpdateVulnerabilities(ctx, "benchmark", "", vulns); err != nil {
+		b.Fatal(err)
+	}
+	records := make([]*claircore.IndexRecord, len(vulns))
+	for i, v := range vulns {
+		records[i] = &claircore.IndexRecord{
+			Package:      v.Package,
+			Distribution: v.Dist,
+			Repository:   v.Repo,
+		}
+	}
+	opts := datastore.GetOpts{
+		Matchers: []driver.MatchConstraint{
+			driver.DistributionName,
+			driver.DistributionVersion,
+		},
+	}
+
+	cfg := pool.Config()
+	cfg.ConnConfig.BuildStatementCache = nil
+	uncached, err := pgxpool.ConnectConfig(ctx, cfg)
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer uncached.Close()
+
+	benchmarks := []struct {
+		name  string
+		store *MatcherStore
+	}{
+		{name: "Cached", store: store},
+		{name: "Uncached", store: NewMatcherStore(uncached)},
+	}
+	for _, bench := range benchmarks {
+		b.Run(bench.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				res, err := bench.store.Get(ctx, records, opts)
+				if err != nil {
+					b.Fatal(err)
+				}
+				if got, want := len(res), len(records); got != want {
+					b.Fatalf("got: %d results, want: %d", got, want)
+				}
+			}
+		})
+	}
+}
@@ -47,19 +47,19 @@ func (s *IndexerStore) IndexFiles(ctx context.Context, files []claircore.File, l
 
 		insert = `
 		INSERT INTO file
-			(path, kind)
+			(path, kind, hash, contents)
 		VALUES
-			($1, $2)
-		ON CONFLICT (path, kind) DO NOTHING;
+			($1, $2, $3, $4)
+		ON CONFLICT (path, kind, hash) DO NOTHING;
 		`
 
 		insertWith = `
 		INSERT
 		INTO file_scanartifact (file_id, layer_id, scanner_id)
 		VALUES (
-			(SELECT id FROM file WHERE file.path = $1 AND file.kind = $2),
-			$3,
-			$4
+			(SELECT id FROM file WHERE file.path = $1 AND file.kind = $2 AND file.hash = $3),
+			$4,
+			$5
 		)
 		ON CONFLICT DO NOTHING;
 		`
@@ -110,6 +110,8 @@ func (s *IndexerStore) IndexFiles(ctx context.Context, files []claircore.File, l
 			insertFileStmt.SQL,
 			f.Path,
 			f.Kind,
+			f.Hash,
+			f.Contents,
 		)
 		if err != nil {
 			return fmt.Errorf("batch insert failed for file %v: %w", f, err)
@@ -131,6 +133,7 @@ func (s *IndexerStore) IndexFiles(ctx context.Context, files []claircore.File, l
 			insertFileScanArtifactWithStmt.SQL,
 			f.Path,
 			f.Kind,
+			f.Hash,
 			layerID,
 			scannerID,
 		)
@@ -86,11 +86,14 @@ func (s *IndexerStore) IndexPackages(ctx context.Context, pkgs []*claircore.Pack
 				 WHERE layer.hash = $14
 			 )
 		INSERT
-		INTO package_scanartifact (layer_id, package_db, repository_hint, filepath, package_id, source_id, scanner_id)
+		INTO package_scanartifact (layer_id, package_db, repository_hint, filepath, provides, depends, confidence, package_id, source_id, scanner_id)
 		VALUES ((SELECT layer_id FROM layer),
 				$15,
 				$16,
 				$17,
+				$18,
+				$19,
+				$20,
 				(SELECT package_id FROM binary_package),
 				(SELECT source_id FROM source_package),
 				(SELECT scanner_id FROM scanner))
@@ -182,6 +185,9 @@ func (s *IndexerStore) IndexPackages(ctx context.Context, pkgs []*claircore.Pack
 			pkg.PackageDB,
 			pkg.RepositoryHint,
 			pkg.Filepath,
+			pkg.Provides,
+			pkg.Depends,
+			string(pkg.Confidence),
 		)
 		if err != nil {
 			return fmt.Errorf("batch insert failed for package_scanartifact %v: %w", pkg, err)
@@ -14,7 +14,7 @@ SELECT EXISTS(SELECT 1 FROM vuln LIMIT 1);
 		return true, nil
 	}
 
-	if err := s.pool.QueryRow(ctx, query).Scan(&ok); err != nil {
+	if err := s.replica.QueryRow(ctx, query).Scan(&ok); err != nil {
 		return false, err
 	}
 	// There were no rows when we looked, so report that. Don't update the bool,
@@ -0,0 +1,73 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/quay/claircore"
+	"github.com/quay/claircore/indexer"
+)
+
+var (
+	layerResultsCounter = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "claircore",
+			Subsystem: "indexer",
+			Name:      "layerresults_total",
+			Help:      "Total number of database queries issued in the LayerResults method.",
+		},
+		[]string{"query"},
+	)
+
+	layerResultsDuration = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: "claircore",
+			Subsystem: "indexer",
+			Name:      "layerresults_duration_seconds",
+			Help:      "The duration of all queries issued in the LayerResults method",
+		},
+		[]string{"query"},
+	)
+)
+
+func (s *IndexerStore) LayerResults(ctx context.Context, hash claircore.Digest, scnr indexer.VersionedScanner) (int, bool, error) {
+	const query = `
+SELECT
+	scanned_layer.result_count
+FROM
+	scanned_layer
+	JOIN layer ON layer.id = scanned_layer.layer_id
+	JOIN scanner ON scanner.id = scanned_layer.scanner_id
+WHERE
+	layer.hash = $1
+	AND scanner.name = $2
+	AND scanner.version = $3
+	AND scanner.kind = $4;
+`
+
+	ctx, done := context.WithTimeout(ctx, 10*time.Second)
+	defer done()
+	start := time.Now()
+	var n *int
+	err := s.pool.QueryRow(ctx, query, hash.String(), scnr.Name(), scnr.Version(), scnr.Kind()).
+		Scan(&n)
+	switch {
+	case errors.Is(err, nil):
+	case errors.Is(err, pgx.ErrNoRows):
+		return 0, false, nil
+	default:
+		return 0, false, err
+	}
+	layerResultsCounter.WithLabelValues("query").Add(1)
+	layerResultsDuration.WithLabelValues("query").Observe(time.Since(start).Seconds())
+
+	if n == nil {
+		return 0, false, nil
+	}
+	return *n, true, nil
+}
@@ -0,0 +1,81 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+
+	"github.com/quay/zlog"
+
+	"github.com/quay/claircore"
+	"github.com/quay/claircore/indexer"
+	"github.com/quay/claircore/test"
+	"github.com/quay/claircore/test/integration"
+	pgtest "github.com/quay/claircore/test/postgres"
+)
+
+func TestLayerResults(t *testing.T) {
+	integration.NeedDB(t)
+	ctx := zlog.Test(context.Background(), t)
+	pool := pgtest.TestIndexerDB(ctx, t)
+	store := NewIndexerStore(pool)
+	defer store.Close(ctx)
+
+	scnr := indexer.NewPackageScannerMock("results-test", "1", "package")
+	if err := store.RegisterScanners(ctx, indexer.VersionedScanners{scnr}); err != nil {
+		t.Fatal(err)
+	}
+	empty, found, old, unscanned := test.RandomSHA256Digest(t), test.RandomSHA256Digest(t), test.RandomSHA256Digest(t), test.RandomSHA256Digest(t)
+	m := claircore.Manifest{
+		Hash: test.RandomSHA256Digest(t),
+		Layers: []*claircore.Layer{
+			{Hash: empty}, {Hash: found}, {Hash: old}, {Hash: unscanned},
+		},
+	}
+	if err := store.PersistManifest(ctx, m); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.SetLayerResults(ctx, empty, scnr, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.SetLayerResults(ctx, found, scnr, 3); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.SetLayerScanned(ctx, old, scnr); err != nil {
+		t.Fatal(err)
+	}
+
+	tt := []struct {
+		Name  string
+		Layer claircore.Digest
+		N     int
+		Known bool
+	}{
+		{Name: "Empty", Layer: empty, N: 0, Known: true},
+		{Name: "Found", Layer: found, N: 3, Known: true},
+		{Name: "NoCount", Layer: old, Known: false},
+		{Name: "Unscanned", Layer: unscanned, Known: false},
+	}
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			n, ok, err := store.LayerResults(ctx, tc.Layer, scnr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, want := ok, tc.Known; got != want {
+				t.Errorf("known: got: %v, want: %v", got, want)
+			}
+			if got, want := n, tc.N; got != want {
+				t.Errorf("count: got: %d, want: %d", got, want)
+			}
+		})
+	}
+
+	// A recorded scan is also reported by LayerScanned.
+	ok, err := store.LayerScanned(ctx, empty, scnr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ok {
+		t.Error("expected layer to be reported as scanned")
+	}
+}
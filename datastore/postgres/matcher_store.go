@@ -2,6 +2,7 @@ package postgres
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
@@ -35,19 +36,62 @@ func InitPostgresMatcherStore(_ context.Context, pool *pgxpool.Pool, doMigration
 	return store, nil
 }
 
+// ErrReadOnly is returned by methods that would write through a MatcherStore
+// created by NewReadOnlyMatcherStore.
+var ErrReadOnly = errors.New("postgres: matcher store is read-only")
+
 // MatcherStore implements all interfaces in the vulnstore package
 type MatcherStore struct {
+	// Pool is used for updates and the queries supporting them.
 	pool *pgxpool.Pool
+	// Replica is used for the queries done while matching. It may be the same
+	// as "pool".
+	replica *pgxpool.Pool
 	// Initialized is used as an atomic bool for tracking initialization.
 	initialized uint32
+	readOnly    bool
 }
 
 func NewMatcherStore(pool *pgxpool.Pool) *MatcherStore {
 	return &MatcherStore{
-		pool: pool,
+		pool:    pool,
+		replica: pool,
+	}
+}
+
+// NewReplicaMatcherStore returns a MatcherStore that does the queries needed
+// for matching against "replica", which may be a read replica of "primary".
+// Updates, and the queries updaters depend on, use "primary".
+func NewReplicaMatcherStore(primary, replica *pgxpool.Pool) *MatcherStore {
+	return &MatcherStore{
+		pool:    primary,
+		replica: replica,
 	}
 }
 
+// NewReadOnlyMatcherStore returns a MatcherStore that only issues queries
+// against "pool". Methods that would write return ErrReadOnly.
+//
+// This is suitable for a process that only does matching, using a read
+// replica. Such a process should set libvuln's DisableBackgroundUpdates
+// option, as updates will fail.
+func NewReadOnlyMatcherStore(pool *pgxpool.Pool) *MatcherStore {
+	return &MatcherStore{
+		pool:     pool,
+		replica:  pool,
+		readOnly: true,
+	}
+}
+
+// Writable reports ErrReadOnly if the MatcherStore is read-only. Every method
+// that writes must check this before using the pool.
+func (s *MatcherStore) writable() error {
+	if s.readOnly {
+		return ErrReadOnly
+	}
+	return nil
+}
+
 var (
 	_ datastore.Updater       = (*MatcherStore)(nil)
 	_ datastore.Vulnerability = (*MatcherStore)(nil)
@@ -57,6 +101,9 @@ var (
 func (s *MatcherStore) DeleteUpdateOperations(ctx context.Context, id ...uuid.UUID) (int64, error) {
 	const query = `DELETE FROM update_operation WHERE ref = ANY($1::uuid[]);`
 	ctx = zlog.ContextWithValues(ctx, "component", "internal/vulnstore/postgres/deleteUpdateOperations")
+	if err := s.writable(); err != nil {
+		return 0, err
+	}
 	if len(id) == 0 {
 		return 0, nil
 	}
@@ -76,10 +123,16 @@ func (s *MatcherStore) DeleteUpdateOperations(ctx context.Context, id ...uuid.UU
 
 // RecordUpdaterStatus records that an updater is up to date with vulnerabilities at this time
 func (s *MatcherStore) RecordUpdaterStatus(ctx context.Context, updaterName string, updateTime time.Time, fingerprint driver.Fingerprint, updaterError error) error {
+	if err := s.writable(); err != nil {
+		return err
+	}
 	return recordUpdaterStatus(ctx, s.pool, updaterName, updateTime, fingerprint, updaterError)
 }
 
 // RecordUpdaterSetStatus records that all updaters from a updater set are up to date with vulnerabilities at this time
 func (s *MatcherStore) RecordUpdaterSetStatus(ctx context.Context, updaterSet string, updateTime time.Time) error {
+	if err := s.writable(); err != nil {
+		return err
+	}
 	return recordUpdaterSetStatus(ctx, s.pool, updaterSet, updateTime)
 }
@@ -0,0 +1,50 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/quay/zlog"
+)
+
+func TestReadOnlyMatcherStore(t *testing.T) {
+	ctx := zlog.Test(context.Background(), t)
+	// A nil pool means any write that gets past the check panics.
+	s := NewReadOnlyMatcherStore(nil)
+	writes := map[string]func() error{
+		"UpdateVulnerabilities": func() error {
+			_, err := s.UpdateVulnerabilities(ctx, "test", "", nil)
+			return err
+		},
+		"UpdateEnrichments": func() error {
+			_, err := s.UpdateEnrichments(ctx, "test", "", nil)
+			return err
+		},
+		"DeleteUpdateOperations": func() error {
+			_, err := s.DeleteUpdateOperations(ctx, uuid.New())
+			return err
+		},
+		"TombstoneVulnerabilities": func() error {
+			_, err := s.TombstoneVulnerabilities(ctx, "test", uuid.New())
+			return err
+		},
+		"GC": func() error {
+			_, err := s.GC(ctx, 1)
+			return err
+		},
+		"RecordUpdaterStatus": func() error {
+			return s.RecordUpdaterStatus(ctx, "test", time.Now(), "", nil)
+		},
+		"RecordUpdaterSetStatus": func() error {
+			return s.RecordUpdaterSetStatus(ctx, "test", time.Now())
+		},
+	}
+	for n, f := range writes {
+		if err := f(); !errors.Is(err, ErrReadOnly) {
+			t.Errorf("%s: got: %v, want: %v", n, err, ErrReadOnly)
+		}
+	}
+}
@@ -0,0 +1 @@
+ALTER TABLE package_scanartifact ADD COLUMN IF NOT EXISTS provides text[];
@@ -0,0 +1,7 @@
+-- Files may be recorded with a content hash and, optionally, their contents.
+-- The same path can then have different contents in different layers, so the
+-- hash becomes part of the file's identity.
+ALTER TABLE file ADD COLUMN IF NOT EXISTS hash text NOT NULL DEFAULT '';
+ALTER TABLE file ADD COLUMN IF NOT EXISTS contents bytea;
+DROP INDEX IF EXISTS file_unique_idx;
+CREATE UNIQUE INDEX IF NOT EXISTS file_unique_idx ON file (path, kind, hash);
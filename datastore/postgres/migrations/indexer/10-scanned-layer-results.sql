@@ -0,0 +1,4 @@
+-- The number of results a scanner produced for a layer. This is NULL for
+-- layers scanned before counts were recorded, so "found nothing" can be told
+-- apart from "unknown".
+ALTER TABLE scanned_layer ADD COLUMN IF NOT EXISTS result_count integer;
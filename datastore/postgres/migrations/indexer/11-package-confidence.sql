@@ -0,0 +1,3 @@
+-- How sure the scanner was of the package's identity. Empty is the default,
+-- high confidence.
+ALTER TABLE package_scanartifact ADD COLUMN IF NOT EXISTS confidence text NOT NULL DEFAULT '';
@@ -0,0 +1 @@
+ALTER TABLE vuln ADD COLUMN IF NOT EXISTS always_affected boolean NOT NULL DEFAULT false;
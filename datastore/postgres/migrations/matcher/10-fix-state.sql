@@ -0,0 +1 @@
+ALTER TABLE vuln ADD COLUMN IF NOT EXISTS fix_state text NOT NULL DEFAULT '';
@@ -0,0 +1 @@
+ALTER TABLE vuln ADD COLUMN IF NOT EXISTS condition text NOT NULL DEFAULT '';
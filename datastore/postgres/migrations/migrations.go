@@ -53,6 +53,26 @@ var IndexerMigrations = []migrate.Migration{
 		ID: 6,
 		Up: runFile("indexer/06-file-artifacts.sql"),
 	},
+	{
+		ID: 7,
+		Up: runFile("indexer/07-package-provides.sql"),
+	},
+	{
+		ID: 8,
+		Up: runFile("indexer/08-package-depends.sql"),
+	},
+	{
+		ID: 9,
+		Up: runFile("indexer/09-file-contents.sql"),
+	},
+	{
+		ID: 10,
+		Up: runFile("indexer/10-scanned-layer-results.sql"),
+	},
+	{
+		ID: 11,
+		Up: runFile("indexer/11-package-confidence.sql"),
+	},
 }
 
 var MatcherMigrations = []migrate.Migration{
@@ -88,4 +108,16 @@ var MatcherMigrations = []migrate.Migration{
 		ID: 8,
 		Up: runFile("matcher/08-updater-status.sql"),
 	},
+	{
+		ID: 9,
+		Up: runFile("matcher/09-always-affected.sql"),
+	},
+	{
+		ID: 10,
+		Up: runFile("matcher/10-fix-state.sql"),
+	},
+	{
+		ID: 11,
+		Up: runFile("matcher/11-condition.sql"),
+	},
 }
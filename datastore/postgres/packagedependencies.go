@@ -0,0 +1,147 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/quay/claircore"
+)
+
+var (
+	packageDependenciesCounter = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "claircore",
+			Subsystem: "indexer",
+			Name:      "packagedependencies_total",
+			Help:      "Total number of database queries issued in the PackageDependencies method.",
+		},
+		[]string{"query"},
+	)
+
+	packageDependenciesDuration = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: "claircore",
+			Subsystem: "indexer",
+			Name:      "packagedependencies_duration_seconds",
+			Help:      "The duration of all queries issued in the PackageDependencies method",
+		},
+		[]string{"query"},
+	)
+)
+
+// PackageDependencies returns the dependency edges for all the binary packages
+// found in the layers of the given manifest.
+//
+// Dependencies are resolved against the names and provides of the other
+// packages in the manifest. Edges are only present if the scanner that found a
+// package was configured to record dependencies.
+func (s *IndexerStore) PackageDependencies(ctx context.Context, manifest claircore.Digest) ([]claircore.Dependency, error) {
+	const query = `
+	SELECT package.id,
+		   package.name,
+		   package_scanartifact.provides,
+		   package_scanartifact.depends
+	FROM manifest
+			 JOIN manifest_layer ON manifest_layer.manifest_id = manifest.id
+			 JOIN package_scanartifact ON package_scanartifact.layer_id = manifest_layer.layer_id
+			 JOIN package ON package_scanartifact.package_id = package.id
+	WHERE manifest.hash = $1
+	  AND package.kind = 'binary';
+	`
+
+	ctx, done := context.WithTimeout(ctx, 15*time.Second)
+	defer done()
+	start := time.Now()
+	rows, err := s.pool.Query(ctx, query, manifest)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query dependencies for manifest %v: %w", manifest, err)
+	}
+	packageDependenciesCounter.WithLabelValues("query").Add(1)
+	packageDependenciesDuration.WithLabelValues("query").Observe(time.Since(start).Seconds())
+	defer rows.Close()
+
+	var pkgs []depPackage
+	for rows.Next() {
+		var p depPackage
+		var id int64
+		if err := rows.Scan(&id, &p.Name, &p.Provides, &p.Depends); err != nil {
+			return nil, fmt.Errorf("failed to scan dependencies: %w", err)
+		}
+		p.ID = strconv.FormatInt(id, 10)
+		pkgs = append(pkgs, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to scan dependencies: %w", err)
+	}
+	return resolveDependencies(pkgs), nil
+}
+
+// DepPackage is the subset of a package needed to construct dependency edges.
+type depPackage struct {
+	ID       string
+	Name     string
+	Provides []string
+	Depends  []string
+}
+
+// ResolveDependencies constructs the dependency edges for the provided
+// packages, resolving every dependency against the names and provides of the
+// packages. The returned slice is sorted by package ID, then requirement.
+//
+// A package may appear multiple times, if it was found in more than one layer.
+func resolveDependencies(pkgs []depPackage) []claircore.Dependency {
+	providers := make(map[string][]string)
+	add := func(name, id string) {
+		for _, p := range providers[name] {
+			if p == id {
+				return
+			}
+		}
+		providers[name] = append(providers[name], id)
+	}
+	for _, p := range pkgs {
+		add(p.Name, p.ID)
+		for _, pr := range p.Provides {
+			// Provides may be recorded as "name = version".
+			n, _, _ := strings.Cut(pr, " ")
+			add(n, p.ID)
+		}
+	}
+
+	seen := make(map[[2]string]struct{})
+	var out []claircore.Dependency
+	for _, p := range pkgs {
+		for _, d := range p.Depends {
+			k := [2]string{p.ID, d}
+			if _, ok := seen[k]; ok {
+				continue
+			}
+			seen[k] = struct{}{}
+			dep := claircore.Dependency{
+				Package:  p.ID,
+				Requires: d,
+			}
+			for _, id := range providers[d] {
+				if id != p.ID {
+					dep.Providers = append(dep.Providers, id)
+				}
+			}
+			sort.Strings(dep.Providers)
+			out = append(out, dep)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Package == out[j].Package {
+			return out[i].Requires < out[j].Requires
+		}
+		return out[i].Package < out[j].Package
+	})
+	return out
+}
@@ -0,0 +1,28 @@
+package postgres
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/quay/claircore"
+)
+
+func TestResolveDependencies(t *testing.T) {
+	pkgs := []depPackage{
+		{ID: "1", Name: "httpd", Depends: []string{"httpd-filesystem", "system-logos-httpd", "webclient"}},
+		{ID: "2", Name: "httpd-filesystem"},
+		{ID: "3", Name: "redhat-logos-httpd", Provides: []string{"system-logos-httpd = 90.4"}},
+		// Found again in a later layer.
+		{ID: "1", Name: "httpd", Depends: []string{"httpd-filesystem"}},
+	}
+	want := []claircore.Dependency{
+		{Package: "1", Requires: "httpd-filesystem", Providers: []string{"2"}},
+		{Package: "1", Requires: "system-logos-httpd", Providers: []string{"3"}},
+		{Package: "1", Requires: "webclient"},
+	}
+	got := resolveDependencies(pkgs)
+	if !cmp.Equal(got, want) {
+		t.Error(cmp.Diff(got, want))
+	}
+}
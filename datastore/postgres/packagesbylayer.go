@@ -66,7 +66,10 @@ SELECT
 	source_package.arch,
 	package_scanartifact.package_db,
 	package_scanartifact.repository_hint,
-	package_scanartifact.filepath
+	package_scanartifact.filepath,
+	package_scanartifact.provides,
+	package_scanartifact.depends,
+	package_scanartifact.confidence
 FROM
 	package_scanartifact
 	LEFT JOIN package ON
@@ -121,6 +124,7 @@ WHERE
 
 		var id, srcID int64
 		var nKind *string
+		var conf string
 		var nVer pgtype.Int4Array
 		err := rows.Scan(
 			&id,
@@ -142,9 +146,13 @@ WHERE
 			&pkg.PackageDB,
 			&pkg.RepositoryHint,
 			&pkg.Filepath,
+			&pkg.Provides,
+			&pkg.Depends,
+			&conf,
 		)
 		pkg.ID = strconv.FormatInt(id, 10)
 		spkg.ID = strconv.FormatInt(srcID, 10)
+		pkg.Confidence = claircore.Confidence(conf)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan packages: %w", err)
 		}
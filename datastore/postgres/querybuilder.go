@@ -2,32 +2,50 @@ package postgres
 
 import (
 	"fmt"
+	"sort"
 	"strconv"
 	"strings"
 
 	"github.com/doug-martin/goqu/v8"
 	_ "github.com/doug-martin/goqu/v8/dialect/postgres"
+	"github.com/google/uuid"
+	"github.com/jackc/pgtype"
 
 	"github.com/quay/claircore"
 	"github.com/quay/claircore/datastore"
 	"github.com/quay/claircore/libvuln/driver"
 )
 
-// getQueryBuilder validates a IndexRecord and creates a query string for vulnerability matching
-func buildGetQuery(record *claircore.IndexRecord, opts *datastore.GetOpts) (string, error) {
-	matchers := opts.Matchers
-	psql := goqu.Dialect("postgres")
-	exps := []goqu.Expression{}
+// ProvidedNames returns the capability names from the package's Provides,
+// stripping any version.
+func providedNames(p *claircore.Package) []string {
+	if len(p.Provides) == 0 {
+		return nil
+	}
+	out := make([]string, 0, len(p.Provides))
+	for _, pr := range p.Provides {
+		n, _, _ := strings.Cut(pr, " ")
+		out = append(out, n)
+	}
+	return out
+}
 
+// BuildGetQuery validates a IndexRecord and creates a query string and
+// arguments for vulnerability matching.
+//
+// Values are always passed as arguments, so the query string only depends on
+// the shape of the record and options. This lets pgx's per-connection
+// statement cache reuse the prepared statement across records.
+func buildGetQuery(record *claircore.IndexRecord, opts *datastore.GetOpts) (string, []interface{}, error) {
 	// Add package name as first condition in query.
 	if record.Package.Name == "" {
-		return "", fmt.Errorf("IndexRecord must provide a Package.Name")
+		return "", nil, fmt.Errorf("IndexRecord must provide a Package.Name")
 	}
 	packageQuery := goqu.And(
 		goqu.Ex{"package_name": record.Package.Name},
 		goqu.Ex{"package_kind": record.Package.Kind},
 	)
-	exps = append(exps, packageQuery)
+	exps := []goqu.Expression{packageQuery}
 
 	// If the package has a source, convert the first expression to an OR.
 	if record.Package.Source.Name != "" {
@@ -42,58 +60,270 @@ func buildGetQuery(record *claircore.IndexRecord, opts *datastore.GetOpts) (stri
 		exps[0] = or
 	}
 
-	// add matchers
+	// This constraint widens the package name condition, so it doesn't add an
+	// expression of its own.
+	if hasConstraint(opts.Matchers, driver.PackageProvides) {
+		if names := providedNames(record.Package); len(names) != 0 {
+			exps[0] = goqu.Or(
+				exps[0],
+				goqu.And(
+					goqu.Ex{"package_name": names},
+					goqu.Ex{"package_kind": claircore.BINARY},
+				),
+			)
+		}
+	}
+
+	// This constraint replaces the package name condition, because these
+	// vulnerabilities are recorded against a CPE product instead.
+	if hasConstraint(opts.Matchers, driver.PackageCPE) {
+		exps[0] = goqu.And(
+			goqu.Ex{"package_name": record.Package.CPE.ProductKey()},
+			goqu.Ex{"package_kind": claircore.BINARY},
+		)
+	}
+
+	cs, err := constraints(record, opts.Matchers)
+	if err != nil {
+		return "", nil, err
+	}
+	for _, c := range cs {
+		exps = append(exps, goqu.Ex{c.column: c.value})
+	}
+	if opts.VersionFiltering {
+		v := &record.Package.NormalizedVersion
+		var lit strings.Builder
+		b := make([]byte, 0, 16)
+		lit.WriteByte('{')
+		for i := 0; i < 10; i++ {
+			if i != 0 {
+				lit.WriteByte(',')
+			}
+			lit.Write(strconv.AppendInt(b, int64(v.V[i]), 10))
+		}
+		lit.WriteByte('}')
+		// Version-agnostic vulnerabilities don't have a meaningful range, so
+		// always return them.
+		exps = append(exps, goqu.Or(
+			goqu.And(
+				goqu.C("version_kind").Eq(v.Kind),
+				goqu.L("vulnerable_range @> ?::int[]", lit.String()),
+			),
+			goqu.Ex{"always_affected": true, "fixed_in_version": ""},
+		))
+	}
+	if e, err := pinnedExp(opts.UpdateOperations); err != nil {
+		return "", nil, err
+	} else if e != nil {
+		exps = append(exps, e)
+	}
+	return selectVulns(exps)
+}
+
+// BuildBatchGetQuery creates a query string and arguments fetching the
+// candidate vulnerabilities for all the provided records at once. The
+// records must have the same batchKey, and database-side version filtering
+// isn't supported.
+//
+// Candidates are selected by package name and kind, so results need to be
+// attributed to records using recordIndex.
+func buildBatchGetQuery(records []*claircore.IndexRecord, opts *datastore.GetOpts) (string, []interface{}, error) {
+	if len(records) == 0 {
+		return "", nil, fmt.Errorf("no IndexRecords provided")
+	}
+	if opts.VersionFiltering {
+		return "", nil, fmt.Errorf("version filtering can't be batched")
+	}
+	idx := recordIndex(records, opts.Matchers)
+	keys := make([]nameKind, 0, len(idx))
+	for k := range idx {
+		keys = append(keys, k)
+	}
+	// Sort the keys so the arguments are deterministic.
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].name != keys[j].name {
+			return keys[i].name < keys[j].name
+		}
+		return keys[i].kind < keys[j].kind
+	})
+	names := make([]string, len(keys))
+	kinds := make([]string, len(keys))
+	for i, k := range keys {
+		names[i], kinds[i] = k.name, k.kind
+	}
+	var nameArr, kindArr pgtype.TextArray
+	if err := nameArr.Set(names); err != nil {
+		return "", nil, err
+	}
+	if err := kindArr.Set(kinds); err != nil {
+		return "", nil, err
+	}
+	exps := []goqu.Expression{
+		goqu.L("(package_name, package_kind) IN (SELECT * FROM unnest(?::text[], ?::text[]))", nameArr, kindArr),
+	}
+	// All the records have the same constraints, so use the first.
+	cs, err := constraints(records[0], opts.Matchers)
+	if err != nil {
+		return "", nil, err
+	}
+	for _, c := range cs {
+		exps = append(exps, goqu.Ex{c.column: c.value})
+	}
+	if e, err := pinnedExp(opts.UpdateOperations); err != nil {
+		return "", nil, err
+	} else if e != nil {
+		exps = append(exps, e)
+	}
+	return selectVulns(exps)
+}
+
+// PinnedExp returns an expression limiting the vulnerabilities of the pinned
+// updaters to the ones recorded by their update operation, or nil if nothing
+// is pinned.
+func pinnedExp(pins map[string]uuid.UUID) (goqu.Expression, error) {
+	if len(pins) == 0 {
+		return nil, nil
+	}
+	// Sort the updaters so the arguments are deterministic.
+	updaters := make([]string, 0, len(pins))
+	for u := range pins {
+		updaters = append(updaters, u)
+	}
+	sort.Strings(updaters)
+	refs := make([]string, len(updaters))
+	for i, u := range updaters {
+		refs[i] = pins[u].String()
+	}
+	var updaterArr, refArr pgtype.TextArray
+	if err := updaterArr.Set(updaters); err != nil {
+		return nil, err
+	}
+	if err := refArr.Set(refs); err != nil {
+		return nil, err
+	}
+	return goqu.L(`(NOT updater = ANY(?::text[]) OR EXISTS (
+	SELECT 1 FROM uo_vuln JOIN update_operation uo ON (uo_vuln.uo = uo.id)
+	WHERE uo.ref = ANY(?::uuid[]) AND uo_vuln.vuln = vuln.id))`, updaterArr, refArr), nil
+}
+
+// NameKind is a package name and kind, as recorded in the vuln table.
+type nameKind struct {
+	name, kind string
+}
+
+// RecordIndex maps each package name and kind to the records whose
+// vulnerabilities may be recorded under it.
+func recordIndex(records []*claircore.IndexRecord, matchers []driver.MatchConstraint) map[nameKind][]*claircore.IndexRecord {
+	provides := hasConstraint(matchers, driver.PackageProvides)
+	product := hasConstraint(matchers, driver.PackageCPE)
+	idx := make(map[nameKind][]*claircore.IndexRecord)
+	add := func(k nameKind, r *claircore.IndexRecord) {
+		rs := idx[k]
+		if len(rs) != 0 && rs[len(rs)-1] == r {
+			return
+		}
+		idx[k] = append(rs, r)
+	}
+	for _, r := range records {
+		if product {
+			add(nameKind{r.Package.CPE.ProductKey(), claircore.BINARY}, r)
+			continue
+		}
+		add(nameKind{r.Package.Name, r.Package.Kind}, r)
+		if r.Package.Source != nil && r.Package.Source.Name != "" {
+			add(nameKind{r.Package.Source.Name, r.Package.Source.Kind}, r)
+		}
+		if provides {
+			for _, n := range providedNames(r.Package) {
+				add(nameKind{n, claircore.BINARY}, r)
+			}
+		}
+	}
+	return idx
+}
+
+// BatchKey returns a key that's the same for records with the same values
+// for every constraint other than the package name, meaning they can be
+// queried with buildBatchGetQuery.
+func batchKey(record *claircore.IndexRecord, matchers []driver.MatchConstraint) (string, error) {
+	if record.Package.Name == "" {
+		return "", fmt.Errorf("IndexRecord must provide a Package.Name")
+	}
+	cs, err := constraints(record, matchers)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	for _, c := range cs {
+		fmt.Fprintf(&b, "%s=%v\x00", c.column, c.value)
+	}
+	return b.String(), nil
+}
+
+// Constraint is a column and the value it must have.
+type constraint struct {
+	column string
+	value  interface{}
+}
+
+// Constraints returns the column constraints for the provided matchers, in
+// order and ignoring duplicates.
+//
+// PackageProvides and PackageCPE change the package name condition instead,
+// so they're not reported.
+func constraints(record *claircore.IndexRecord, matchers []driver.MatchConstraint) ([]constraint, error) {
+	var out []constraint
 	seen := make(map[driver.MatchConstraint]struct{})
 	for _, m := range matchers {
 		if _, ok := seen[m]; ok {
 			continue
 		}
-		var ex goqu.Ex
+		seen[m] = struct{}{}
+		var c constraint
 		switch m {
+		case driver.PackageProvides, driver.PackageCPE:
+			continue
 		case driver.PackageModule:
-			ex = goqu.Ex{"package_module": record.Package.Module}
+			c = constraint{"package_module", record.Package.Module}
 		case driver.DistributionDID:
-			ex = goqu.Ex{"dist_id": record.Distribution.DID}
+			c = constraint{"dist_id", record.Distribution.DID}
 		case driver.DistributionName:
-			ex = goqu.Ex{"dist_name": record.Distribution.Name}
+			c = constraint{"dist_name", record.Distribution.Name}
 		case driver.DistributionVersionID:
-			ex = goqu.Ex{"dist_version_id": record.Distribution.VersionID}
+			c = constraint{"dist_version_id", record.Distribution.VersionID}
 		case driver.DistributionVersion:
-			ex = goqu.Ex{"dist_version": record.Distribution.Version}
+			c = constraint{"dist_version", record.Distribution.Version}
 		case driver.DistributionVersionCodeName:
-			ex = goqu.Ex{"dist_version_code_name": record.Distribution.VersionCodeName}
+			c = constraint{"dist_version_code_name", record.Distribution.VersionCodeName}
 		case driver.DistributionPrettyName:
-			ex = goqu.Ex{"dist_pretty_name": record.Distribution.PrettyName}
+			c = constraint{"dist_pretty_name", record.Distribution.PrettyName}
 		case driver.DistributionCPE:
-			ex = goqu.Ex{"dist_cpe": record.Distribution.CPE}
+			c = constraint{"dist_cpe", record.Distribution.CPE}
 		case driver.DistributionArch:
-			ex = goqu.Ex{"dist_arch": record.Distribution.Arch}
+			c = constraint{"dist_arch", record.Distribution.Arch}
 		case driver.RepositoryName:
-			ex = goqu.Ex{"repo_name": record.Repository.Name}
+			c = constraint{"repo_name", record.Repository.Name}
 		default:
-			return "", fmt.Errorf("was provided unknown matcher: %v", m)
+			return nil, fmt.Errorf("was provided unknown matcher: %v", m)
 		}
-		exps = append(exps, ex)
-		seen[m] = struct{}{}
+		out = append(out, c)
 	}
-	if opts.VersionFiltering {
-		v := &record.Package.NormalizedVersion
-		var lit strings.Builder
-		b := make([]byte, 0, 16)
-		lit.WriteString("'{")
-		for i := 0; i < 10; i++ {
-			if i != 0 {
-				lit.WriteByte(',')
-			}
-			lit.Write(strconv.AppendInt(b, int64(v.V[i]), 10))
+	return out, nil
+}
+
+func hasConstraint(matchers []driver.MatchConstraint, want driver.MatchConstraint) bool {
+	for _, m := range matchers {
+		if m == want {
+			return true
 		}
-		lit.WriteString("}'::int[]")
-		exps = append(exps, goqu.And(
-			goqu.C("version_kind").Eq(v.Kind),
-			goqu.L("vulnerable_range @> "+lit.String()),
-		))
 	}
+	return false
+}
 
+// SelectVulns creates the query string and arguments selecting the columns
+// Get scans from the vuln table, filtered by "exps".
+func selectVulns(exps []goqu.Expression) (string, []interface{}, error) {
+	psql := goqu.Dialect("postgres")
 	query := psql.Select(
 		"id",
 		"name",
@@ -121,11 +351,14 @@ func buildGetQuery(record *claircore.IndexRecord, opts *datastore.GetOpts) (stri
 		"repo_uri",
 		"fixed_in_version",
 		"updater",
-	).From("vuln").Where(exps...)
+		"always_affected",
+		"fix_state",
+		"condition",
+	).From("vuln").Where(exps...).Prepared(true)
 
-	sql, _, err := query.ToSQL()
+	sql, args, err := query.ToSQL()
 	if err != nil {
-		return "", err
+		return "", nil, err
 	}
-	return sql, nil
+	return sql, args, nil
 }
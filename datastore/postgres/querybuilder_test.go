@@ -6,10 +6,12 @@ import (
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/google/uuid"
 
 	"github.com/quay/claircore"
 	"github.com/quay/claircore/datastore"
 	"github.com/quay/claircore/libvuln/driver"
+	"github.com/quay/claircore/pkg/cpe"
 	"github.com/quay/claircore/pkg/pep440"
 	"github.com/quay/claircore/test"
 )
@@ -20,17 +22,25 @@ func TestGetQueryBuilderDeterministicArgs(t *testing.T) {
 		"id", "name", "description", "issued", "links", "severity", "normalized_severity", "package_name", "package_version",
 		"package_module", "package_arch", "package_kind", "dist_id", "dist_name", "dist_version", "dist_version_code_name",
 		"dist_version_id", "dist_arch", "dist_cpe", "dist_pretty_name", "arch_operation", "repo_name", "repo_key",
-		"repo_uri", "fixed_in_version", "updater"
+		"repo_uri", "fixed_in_version", "updater", "always_affected", "fix_state",
+		"condition"
 		FROM "vuln"
 		WHERE `
-		both     = `(((("package_name" = 'package-0') AND ("package_kind" = 'binary')) OR (("package_name" = 'source-package-0') AND ("package_kind" = 'source'))) AND `
-		noSource = `((("package_name" = 'package-0') AND  ("package_kind" = 'binary')) AND `
+		both     = `(((("package_name" = $1) AND ("package_kind" = $2)) OR (("package_name" = $3) AND ("package_kind" = $4))) AND `
+		noSource = `((("package_name" = $1) AND  ("package_kind" = $2)) AND `
 	)
+	args := func(pre []interface{}, vs ...interface{}) []interface{} {
+		return append(append([]interface{}{}, pre...), vs...)
+	}
+	bothArgs := []interface{}{"package-0", "binary", "source-package-0", "source"}
+	noSourceArgs := []interface{}{"package-0", "binary"}
 	var table = []struct {
 		// name of test
 		name string
 		// the expected query string returned
 		expectedQuery string
+		// the expected query arguments returned
+		expectedArgs []interface{}
 		// the match expressions which contrain the query
 		matchExps []driver.MatchConstraint
 		dbFilter  bool
@@ -40,8 +50,9 @@ func TestGetQueryBuilderDeterministicArgs(t *testing.T) {
 		{
 			name: "NoSource,id",
 			expectedQuery: preamble + noSource +
-				`("dist_id" = 'did-0'))`,
-			matchExps: []driver.MatchConstraint{driver.DistributionDID},
+				`("dist_id" = $3))`,
+			expectedArgs: args(noSourceArgs, "did-0"),
+			matchExps:    []driver.MatchConstraint{driver.DistributionDID},
 			indexRecord: func() *claircore.IndexRecord {
 				pkgs := test.GenUniquePackages(1)
 				pkgs[0].Source = &claircore.Package{} // clear source field
@@ -55,8 +66,9 @@ func TestGetQueryBuilderDeterministicArgs(t *testing.T) {
 		{
 			name: "id",
 			expectedQuery: preamble + both +
-				`("dist_id" = 'did-0'))`,
-			matchExps: []driver.MatchConstraint{driver.DistributionDID},
+				`("dist_id" = $5))`,
+			expectedArgs: args(bothArgs, "did-0"),
+			matchExps:    []driver.MatchConstraint{driver.DistributionDID},
 			indexRecord: func() *claircore.IndexRecord {
 				pkgs := test.GenUniquePackages(1)
 				dists := test.GenUniqueDistributions(1)
@@ -69,8 +81,9 @@ func TestGetQueryBuilderDeterministicArgs(t *testing.T) {
 		{
 			name: "id,version",
 			expectedQuery: preamble + both +
-				`("dist_id" = 'did-0') AND
-				("dist_version" = 'version-0'))`,
+				`("dist_id" = $5) AND
+				("dist_version" = $6))`,
+			expectedArgs: args(bothArgs, "did-0", "version-0"),
 			matchExps: []driver.MatchConstraint{
 				driver.DistributionDID,
 				driver.DistributionVersion,
@@ -87,9 +100,10 @@ func TestGetQueryBuilderDeterministicArgs(t *testing.T) {
 		{
 			name: "id,version,version_id",
 			expectedQuery: preamble + both +
-				`("dist_id" = 'did-0') AND
-				("dist_version" = 'version-0') AND
-				("dist_version_id" = 'version-id-0'))`,
+				`("dist_id" = $5) AND
+				("dist_version" = $6) AND
+				("dist_version_id" = $7))`,
+			expectedArgs: args(bothArgs, "did-0", "version-0", "version-id-0"),
 			matchExps: []driver.MatchConstraint{
 				driver.DistributionDID,
 				driver.DistributionVersion,
@@ -107,10 +121,11 @@ func TestGetQueryBuilderDeterministicArgs(t *testing.T) {
 		{
 			name: "id,version,version_id,version_code_name",
 			expectedQuery: preamble + both +
-				`("dist_id" = 'did-0') AND
-				("dist_version" = 'version-0') AND
-				("dist_version_id" = 'version-id-0') AND
-				("dist_version_code_name" = 'version-code-name-0'))`,
+				`("dist_id" = $5) AND
+				("dist_version" = $6) AND
+				("dist_version_id" = $7) AND
+				("dist_version_code_name" = $8))`,
+			expectedArgs: args(bothArgs, "did-0", "version-0", "version-id-0", "version-code-name-0"),
 			matchExps: []driver.MatchConstraint{
 				driver.DistributionDID,
 				driver.DistributionVersion,
@@ -129,10 +144,12 @@ func TestGetQueryBuilderDeterministicArgs(t *testing.T) {
 		{
 			name: "DatabaseFilter",
 			expectedQuery: preamble + both +
-				`(("version_kind" = '') AND
-				vulnerable_range @> '{0,0,0,0,0,0,0,0,0,0}'::int[]))`,
-			matchExps: []driver.MatchConstraint{},
-			dbFilter:  true,
+				`((("version_kind" = $5) AND
+				vulnerable_range @> $6::int[]) OR
+				(("always_affected" IS TRUE) AND ("fixed_in_version" = $7))))`,
+			expectedArgs: args(bothArgs, "", "{0,0,0,0,0,0,0,0,0,0}", ""),
+			matchExps:    []driver.MatchConstraint{},
+			dbFilter:     true,
 			indexRecord: func() *claircore.IndexRecord {
 				pkgs := test.GenUniquePackages(1)
 				dists := test.GenUniqueDistributions(1)
@@ -145,10 +162,12 @@ func TestGetQueryBuilderDeterministicArgs(t *testing.T) {
 		{
 			name: "DatabaseFilterPython",
 			expectedQuery: preamble + both +
-				`(("version_kind" = 'pep440') AND
-				vulnerable_range @> '{0,1,20,3,0,0,0,0,0,0}'::int[]))`,
-			matchExps: []driver.MatchConstraint{},
-			dbFilter:  true,
+				`((("version_kind" = $5) AND
+				vulnerable_range @> $6::int[]) OR
+				(("always_affected" IS TRUE) AND ("fixed_in_version" = $7))))`,
+			expectedArgs: args(bothArgs, "pep440", "{0,1,20,3,0,0,0,0,0,0}", ""),
+			matchExps:    []driver.MatchConstraint{},
+			dbFilter:     true,
 			indexRecord: func() *claircore.IndexRecord {
 				v, err := pep440.Parse("1.20.3")
 				if err != nil {
@@ -166,8 +185,9 @@ func TestGetQueryBuilderDeterministicArgs(t *testing.T) {
 		{
 			name: "module-filter",
 			expectedQuery: preamble + noSource +
-				`("package_module" = 'module:0'))`,
-			matchExps: []driver.MatchConstraint{driver.PackageModule},
+				`("package_module" = $3))`,
+			expectedArgs: args(noSourceArgs, "module:0"),
+			matchExps:    []driver.MatchConstraint{driver.PackageModule},
 			indexRecord: func() *claircore.IndexRecord {
 				pkgs := test.GenUniquePackages(1)
 				pkgs[0].Source = &claircore.Package{} // clear source field
@@ -181,8 +201,62 @@ func TestGetQueryBuilderDeterministicArgs(t *testing.T) {
 		{
 			name: "repo_name",
 			expectedQuery: preamble + noSource +
-				`("repo_name" = 'repository-0'))`,
-			matchExps: []driver.MatchConstraint{driver.RepositoryName},
+				`("repo_name" = $3))`,
+			expectedArgs: args(noSourceArgs, "repository-0"),
+			matchExps:    []driver.MatchConstraint{driver.RepositoryName},
+			indexRecord: func() *claircore.IndexRecord {
+				pkgs := test.GenUniquePackages(1)
+				pkgs[0].Source = &claircore.Package{} // clear source field
+				dists := test.GenUniqueDistributions(1)
+				repos := test.GenUniqueRepositories(1)
+				return &claircore.IndexRecord{
+					Package:      pkgs[0],
+					Distribution: dists[0],
+					Repository:   repos[0],
+				}
+			},
+		},
+		{
+			name: "provides",
+			expectedQuery: preamble +
+				`(((("package_name" = $1) AND ("package_kind" = $2)) OR
+				(("package_name" IN ($3, $4)) AND ("package_kind" = $5))) AND
+				("repo_name" = $6))`,
+			expectedArgs: args(noSourceArgs, "webserver", "httpd-mmn", "binary", "repository-0"),
+			matchExps:    []driver.MatchConstraint{driver.RepositoryName, driver.PackageProvides},
+			indexRecord: func() *claircore.IndexRecord {
+				pkgs := test.GenUniquePackages(1)
+				pkgs[0].Source = &claircore.Package{} // clear source field
+				pkgs[0].Provides = []string{"webserver", "httpd-mmn = 20120211x8664"}
+				dists := test.GenUniqueDistributions(1)
+				repos := test.GenUniqueRepositories(1)
+				return &claircore.IndexRecord{
+					Package:      pkgs[0],
+					Distribution: dists[0],
+					Repository:   repos[0],
+				}
+			},
+		},
+		{
+			name: "cpe",
+			expectedQuery: preamble +
+				`(("package_name" = $1) AND ("package_kind" = $2))`,
+			expectedArgs: []interface{}{"cpe:2.3:a:apache:http_server", "binary"},
+			matchExps:    []driver.MatchConstraint{driver.PackageCPE},
+			indexRecord: func() *claircore.IndexRecord {
+				pkgs := test.GenUniquePackages(1)
+				pkgs[0].CPE = cpe.MustUnbind(`cpe:2.3:a:apache:http_server:2.4.49:*:*:*:*:*:*:*`)
+				return &claircore.IndexRecord{
+					Package: pkgs[0],
+				}
+			},
+		},
+		{
+			name: "provides,none",
+			expectedQuery: preamble + noSource +
+				`("repo_name" = $3))`,
+			expectedArgs: args(noSourceArgs, "repository-0"),
+			matchExps:    []driver.MatchConstraint{driver.RepositoryName, driver.PackageProvides},
 			indexRecord: func() *claircore.IndexRecord {
 				pkgs := test.GenUniquePackages(1)
 				pkgs[0].Source = &claircore.Package{} // clear source field
@@ -210,7 +284,7 @@ func TestGetQueryBuilderDeterministicArgs(t *testing.T) {
 				Matchers:         tt.matchExps,
 				VersionFiltering: tt.dbFilter,
 			}
-			query, err := buildGetQuery(ir, &opts)
+			query, args, err := buildGetQuery(ir, &opts)
 			if err != nil {
 				t.Fatalf("failed to create query: %v", err)
 			}
@@ -218,6 +292,101 @@ func TestGetQueryBuilderDeterministicArgs(t *testing.T) {
 			if !cmp.Equal(query, tt.expectedQuery, normalizeWhitespace) {
 				t.Fatalf("%v", cmp.Diff(tt.expectedQuery, query, normalizeWhitespace))
 			}
+			if !cmp.Equal(args, tt.expectedArgs) {
+				t.Fatalf("%v", cmp.Diff(tt.expectedArgs, args))
+			}
 		})
 	}
 }
+
+func TestBatchGetQuery(t *testing.T) {
+	pkgs := test.GenUniquePackages(2)
+	pkgs[1].Source = &claircore.Package{} // clear source field
+	pkgs[1].Provides = []string{"webserver"}
+	dists := test.GenUniqueDistributions(1)
+	records := []*claircore.IndexRecord{
+		{Package: pkgs[0], Distribution: dists[0]},
+		{Package: pkgs[1], Distribution: dists[0]},
+	}
+	opts := datastore.GetOpts{
+		Matchers: []driver.MatchConstraint{driver.DistributionDID, driver.PackageProvides},
+	}
+
+	k0, err := batchKey(records[0], opts.Matchers)
+	if err != nil {
+		t.Fatal(err)
+	}
+	k1, err := batchKey(records[1], opts.Matchers)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if k0 != k1 {
+		t.Errorf("expected equal batch keys: %q != %q", k0, k1)
+	}
+	other := &claircore.IndexRecord{Package: pkgs[0], Distribution: test.GenUniqueDistributions(2)[1]}
+	if k, err := batchKey(other, opts.Matchers); err != nil || k == k0 {
+		t.Errorf("expected differing batch keys: %q, %v", k, err)
+	}
+
+	query, args, err := buildBatchGetQuery(records, &opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("got:\n%s", query)
+	wantQuery := `SELECT
+		"id", "name", "description", "issued", "links", "severity", "normalized_severity", "package_name", "package_version",
+		"package_module", "package_arch", "package_kind", "dist_id", "dist_name", "dist_version", "dist_version_code_name",
+		"dist_version_id", "dist_arch", "dist_cpe", "dist_pretty_name", "arch_operation", "repo_name", "repo_key",
+		"repo_uri", "fixed_in_version", "updater", "always_affected", "fix_state",
+		"condition"
+		FROM "vuln"
+		WHERE ((package_name, package_kind) IN (SELECT * FROM unnest($1::text[], $2::text[])) AND ("dist_id" = $3))`
+	normalizeWhitespace := cmpopts.AcyclicTransformer("normalizeWhitespace", strings.Fields)
+	if !cmp.Equal(query, wantQuery, normalizeWhitespace) {
+		t.Error(cmp.Diff(wantQuery, query, normalizeWhitespace))
+	}
+	wantArgs := []interface{}{
+		`{package-0,package-1,source-package-0,webserver}`,
+		`{binary,binary,source,binary}`,
+		"did-0",
+	}
+	if !cmp.Equal(args, wantArgs) {
+		t.Error(cmp.Diff(wantArgs, args))
+	}
+
+	opts.UpdateOperations = map[string]uuid.UUID{
+		"updater-b": uuid.MustParse("00000000-0000-0000-0000-00000000000b"),
+		"updater-a": uuid.MustParse("00000000-0000-0000-0000-00000000000a"),
+	}
+	query, args, err = buildBatchGetQuery(records, &opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("got:\n%s", query)
+	wantQuery = wantQuery[:len(wantQuery)-1] + ` AND (NOT updater = ANY($4::text[]) OR EXISTS (
+		SELECT 1 FROM uo_vuln JOIN update_operation uo ON (uo_vuln.uo = uo.id)
+		WHERE uo.ref = ANY($5::uuid[]) AND uo_vuln.vuln = vuln.id)))`
+	if !cmp.Equal(query, wantQuery, normalizeWhitespace) {
+		t.Error(cmp.Diff(wantQuery, query, normalizeWhitespace))
+	}
+	wantArgs = append(wantArgs,
+		`{updater-a,updater-b}`,
+		`{00000000-0000-0000-0000-00000000000a,00000000-0000-0000-0000-00000000000b}`,
+	)
+	if !cmp.Equal(args, wantArgs) {
+		t.Error(cmp.Diff(wantArgs, args))
+	}
+	opts.UpdateOperations = nil
+
+	idx := recordIndex(records, opts.Matchers)
+	for k, want := range map[nameKind]*claircore.IndexRecord{
+		{"package-0", "binary"}:        records[0],
+		{"source-package-0", "source"}: records[0],
+		{"package-1", "binary"}:        records[1],
+		{"webserver", "binary"}:        records[1],
+	} {
+		if got := idx[k]; len(got) != 1 || got[0] != want {
+			t.Errorf("%v: got: %v, want: %v", k, got, want)
+		}
+	}
+}
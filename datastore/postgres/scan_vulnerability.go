@@ -39,6 +39,9 @@ func scanVulnerability(v *claircore.Vulnerability, row scanner) error {
 		&v.Repo.Key,
 		&v.Repo.URI,
 		&v.FixedInVersion,
+		&v.AlwaysAffected,
+		&v.FixState,
+		&v.Condition,
 	); err != nil {
 		return err
 	}
@@ -0,0 +1,93 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/quay/claircore"
+	"github.com/quay/claircore/indexer"
+)
+
+var (
+	staleManifestsCounter = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "claircore",
+			Subsystem: "indexer",
+			Name:      "stalemanifests_total",
+			Help:      "Total number of database queries issued in the StaleManifests method.",
+		},
+		[]string{"query"},
+	)
+
+	staleManifestsDuration = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: "claircore",
+			Subsystem: "indexer",
+			Name:      "stalemanifests_duration_seconds",
+			Help:      "The duration of all queries issued in the StaleManifests method",
+		},
+		[]string{"query"},
+	)
+)
+
+// StaleManifests returns the manifests that were indexed with a different
+// version of any of the provided scanners, and not since with the provided
+// version.
+func (s *IndexerStore) StaleManifests(ctx context.Context, scnrs []indexer.ScannerInfo) ([]claircore.Digest, error) {
+	const query = `
+	WITH current (name, kind, version) AS (
+		SELECT * FROM unnest($1::text[], $2::text[], $3::text[])
+	)
+	SELECT DISTINCT manifest.hash
+	FROM scanned_manifest
+			 JOIN manifest ON scanned_manifest.manifest_id = manifest.id
+			 JOIN scanner ON scanned_manifest.scanner_id = scanner.id
+			 JOIN current ON scanner.name = current.name AND scanner.kind = current.kind
+	WHERE scanner.version <> current.version
+	  AND NOT EXISTS(
+		SELECT 1
+		FROM scanned_manifest AS sm
+				 JOIN scanner AS s ON sm.scanner_id = s.id
+		WHERE sm.manifest_id = manifest.id
+		  AND s.name = current.name
+		  AND s.kind = current.kind
+		  AND s.version = current.version);
+	`
+	if len(scnrs) == 0 {
+		return nil, nil
+	}
+	names := make([]string, len(scnrs))
+	kinds := make([]string, len(scnrs))
+	versions := make([]string, len(scnrs))
+	for i, sc := range scnrs {
+		names[i], kinds[i], versions[i] = sc.Name, sc.Kind, sc.Version
+	}
+
+	ctx, done := context.WithTimeout(ctx, 30*time.Second)
+	defer done()
+	start := time.Now()
+	rows, err := s.pool.Query(ctx, query, names, kinds, versions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query stale manifests: %w", err)
+	}
+	staleManifestsCounter.WithLabelValues("query").Add(1)
+	staleManifestsDuration.WithLabelValues("query").Observe(time.Since(start).Seconds())
+	defer rows.Close()
+
+	var out []claircore.Digest
+	for rows.Next() {
+		var d claircore.Digest
+		if err := rows.Scan(&d); err != nil {
+			return nil, fmt.Errorf("failed to scan stale manifest: %w", err)
+		}
+		out = append(out, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to scan stale manifest: %w", err)
+	}
+	return out, nil
+}
@@ -0,0 +1,67 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+
+	"github.com/quay/zlog"
+
+	"github.com/quay/claircore"
+	"github.com/quay/claircore/indexer"
+	"github.com/quay/claircore/test"
+	"github.com/quay/claircore/test/integration"
+	pgtest "github.com/quay/claircore/test/postgres"
+)
+
+func TestStaleManifests(t *testing.T) {
+	integration.NeedDB(t)
+	ctx := zlog.Test(context.Background(), t)
+	pool := pgtest.TestIndexerDB(ctx, t)
+	store := NewIndexerStore(pool)
+	defer store.Close(ctx)
+
+	v1 := indexer.VersionedScanners{indexer.NewPackageScannerMock("stale-test", "1", "package")}
+	v2 := indexer.VersionedScanners{indexer.NewPackageScannerMock("stale-test", "2", "package")}
+	info := []indexer.ScannerInfo{{Name: "stale-test", Kind: "package", Version: "2"}}
+	for _, vs := range []indexer.VersionedScanners{v1, v2} {
+		if err := store.RegisterScanners(ctx, vs); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	index := func(t *testing.T, d claircore.Digest, vs indexer.VersionedScanners) {
+		t.Helper()
+		if err := store.PersistManifest(ctx, claircore.Manifest{Hash: d}); err != nil {
+			t.Fatal(err)
+		}
+		if err := store.SetIndexFinished(ctx, &claircore.IndexReport{Hash: d, State: "IndexFinished"}, vs); err != nil {
+			t.Fatal(err)
+		}
+	}
+	stale := func(t *testing.T) map[string]bool {
+		t.Helper()
+		ds, err := store.StaleManifests(ctx, info)
+		if err != nil {
+			t.Fatal(err)
+		}
+		out := make(map[string]bool)
+		for _, d := range ds {
+			out[d.String()] = true
+		}
+		return out
+	}
+
+	old, current := test.RandomSHA256Digest(t), test.RandomSHA256Digest(t)
+	index(t, old, v1)
+	index(t, current, v2)
+	got := stale(t)
+	if !got[old.String()] || got[current.String()] {
+		t.Errorf("unexpected stale manifests: %v", got)
+	}
+
+	// Re-indexing with the new version makes the manifest current.
+	index(t, old, v2)
+	if got := stale(t); got[old.String()] {
+		t.Errorf("unexpected stale manifests: %v", got)
+	}
+}
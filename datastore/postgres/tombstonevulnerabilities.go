@@ -0,0 +1,66 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/quay/zlog"
+)
+
+var (
+	tombstoneVulnerabilitiesCounter = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "claircore",
+			Subsystem: "vulnstore",
+			Name:      "tombstonevulnerabilities_total",
+			Help:      "Total number of database queries issued in the TombstoneVulnerabilities method.",
+		},
+		[]string{"query"},
+	)
+	tombstoneVulnerabilitiesDuration = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: "claircore",
+			Subsystem: "vulnstore",
+			Name:      "tombstonevulnerabilities_duration_seconds",
+			Help:      "The duration of all queries issued in the TombstoneVulnerabilities method",
+		},
+		[]string{"query"},
+	)
+)
+
+// TombstoneVulnerabilities implements vulnstore.Updater.
+//
+// The vulnerabilities are deleted outright, so they're also removed from any
+// previous UpdateOperations and won't show up when diffing against them.
+func (s *MatcherStore) TombstoneVulnerabilities(ctx context.Context, updater string, ref uuid.UUID) (int64, error) {
+	const query = `
+DELETE FROM vuln
+WHERE updater = $1
+  AND NOT EXISTS(
+	SELECT 1
+	FROM uo_vuln
+			 JOIN update_operation uo ON uo_vuln.uo = uo.id
+	WHERE uo.ref = $2
+	  AND uo_vuln.vuln = vuln.id);
+`
+	ctx = zlog.ContextWithValues(ctx, "component", "datastore/postgres/TombstoneVulnerabilities")
+	if err := s.writable(); err != nil {
+		return 0, err
+	}
+	if ref == uuid.Nil {
+		return 0, fmt.Errorf("nil uuid is invalid as an update operation")
+	}
+
+	start := time.Now()
+	tag, err := s.pool.Exec(ctx, query, updater, ref)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete withdrawn vulnerabilities: %w", err)
+	}
+	tombstoneVulnerabilitiesCounter.WithLabelValues("delete").Add(1)
+	tombstoneVulnerabilitiesDuration.WithLabelValues("delete").Observe(time.Since(start).Seconds())
+	return tag.RowsAffected(), nil
+}
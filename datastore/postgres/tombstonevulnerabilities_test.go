@@ -0,0 +1,66 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+
+	"github.com/quay/zlog"
+
+	"github.com/quay/claircore"
+	"github.com/quay/claircore/datastore"
+	"github.com/quay/claircore/test/integration"
+	pgtest "github.com/quay/claircore/test/postgres"
+)
+
+func TestTombstoneVulnerabilities(t *testing.T) {
+	integration.NeedDB(t)
+	ctx := zlog.Test(context.Background(), t)
+	pool := pgtest.TestMatcherDB(ctx, t)
+	store := NewMatcherStore(pool)
+
+	const name = "tombstone-updater"
+	mkVuln := func(n string) *claircore.Vulnerability {
+		return &claircore.Vulnerability{
+			Updater: name,
+			Name:    n,
+			Package: &claircore.Package{Name: "pkg"},
+		}
+	}
+	if _, err := store.UpdateVulnerabilities(ctx, name, "1", []*claircore.Vulnerability{
+		mkVuln("kept"), mkVuln("withdrawn"),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	// Another updater's vulnerabilities must be left alone.
+	other := mkVuln("other")
+	other.Updater = "other-updater"
+	if _, err := store.UpdateVulnerabilities(ctx, other.Updater, "1", []*claircore.Vulnerability{other}); err != nil {
+		t.Fatal(err)
+	}
+	ref, err := store.UpdateVulnerabilities(ctx, name, "2", []*claircore.Vulnerability{mkVuln("kept")})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ct, err := store.TombstoneVulnerabilities(ctx, name, ref)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := ct, int64(1); got != want {
+		t.Errorf("got: %d removed, want: %d", got, want)
+	}
+
+	res, err := store.Get(ctx, []*claircore.IndexRecord{
+		{Package: &claircore.Package{ID: "1", Name: "pkg", Source: &claircore.Package{}}},
+	}, datastore.GetOpts{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make(map[string]bool)
+	for _, v := range res["1"] {
+		got[v.Name] = true
+	}
+	if !got["kept"] || !got["other"] || got["withdrawn"] {
+		t.Errorf("unexpected vulnerabilities: %v", got)
+	}
+}
@@ -62,14 +62,16 @@ func (s *MatcherStore) UpdateVulnerabilities(ctx context.Context, updater string
 			package_name, package_version, package_module, package_arch, package_kind,
 			dist_id, dist_name, dist_version, dist_version_code_name, dist_version_id, dist_arch, dist_cpe, dist_pretty_name,
 			repo_name, repo_key, repo_uri,
-			fixed_in_version, arch_operation, version_kind, vulnerable_range
+			fixed_in_version, arch_operation, version_kind, vulnerable_range,
+			always_affected, fix_state, condition
 		) VALUES (
 		  $1, $2,
 		  $3, $4, $5, $6, $7, $8, $9,
 		  $10, $11, $12, $13, $14,
 		  $15, $16, $17, $18, $19, $20, $21, $22,
 		  $23, $24, $25,
-		  $26, $27, $28, VersionRange($29, $30)
+		  $26, $27, $28, VersionRange($29, $30),
+		  $31, $32, $33
 		)
 		ON CONFLICT (hash_kind, hash) DO NOTHING;`
 		// Assoc associates an update operation and a vulnerability. It fails
@@ -81,6 +83,9 @@ func (s *MatcherStore) UpdateVulnerabilities(ctx context.Context, updater string
 		ON CONFLICT DO NOTHING;`
 	)
 	ctx = zlog.ContextWithValues(ctx, "component", "internal/vulnstore/postgres/updateVulnerabilities")
+	if err := s.writable(); err != nil {
+		return uuid.Nil, err
+	}
 
 	var id uint64
 	var ref uuid.UUID
@@ -135,6 +140,7 @@ func (s *MatcherStore) UpdateVulnerabilities(ctx context.Context, updater string
 			dist.DID, dist.Name, dist.Version, dist.VersionCodeName, dist.VersionID, dist.Arch, dist.CPE, dist.PrettyName,
 			repo.Name, repo.Key, repo.URI,
 			vuln.FixedInVersion, vuln.ArchOperation, vKind, vrLower, vrUpper,
+			vuln.AlwaysAffected, vuln.FixState, vuln.Condition,
 		)
 		if err != nil {
 			return uuid.Nil, fmt.Errorf("failed to queue vulnerability: %w", err)
@@ -201,6 +207,17 @@ func md5Vuln(v *claircore.Vulnerability) (string, []byte) {
 		b.WriteString(l)
 		b.WriteString(u)
 	}
+	// Only hash these when set, so existing vulnerabilities keep their
+	// hashes.
+	if v.AlwaysAffected {
+		b.WriteString("always_affected")
+	}
+	if v.FixState != "" {
+		b.WriteString(v.FixState)
+	}
+	if v.Condition != "" {
+		b.WriteString(v.Condition)
+	}
 	s := md5.Sum(b.Bytes())
 	return "md5", s[:]
 }
@@ -1,6 +1,7 @@
 package postgres
 
 import (
+	"bytes"
 	"database/sql/driver"
 	"encoding/json"
 	"fmt"
@@ -26,5 +27,5 @@ func (sr *jsonbIndexReport) Scan(value interface{}) error {
 		return fmt.Errorf("failed to type assert IndexReport to []bytes")
 	}
 
-	return json.Unmarshal(b, &sr)
+	return claircore.DecodeReport(bytes.NewReader(b), (*claircore.IndexReport)(sr))
 }
@@ -19,6 +19,12 @@ type Updater interface {
 	// vulnerabilities, and ensures vulnerabilities from previous updates are
 	// not queried by clients.
 	UpdateVulnerabilities(ctx context.Context, updater string, fingerprint driver.Fingerprint, vulns []*claircore.Vulnerability) (uuid.UUID, error)
+	// TombstoneVulnerabilities removes the updater's vulnerabilities that
+	// aren't part of the referenced UpdateOperation, meaning they were
+	// withdrawn upstream. See driver.Tombstoner.
+	//
+	// The number of vulnerabilities removed is returned.
+	TombstoneVulnerabilities(ctx context.Context, updater string, ref uuid.UUID) (int64, error)
 	// GetUpdateOperations returns a list of UpdateOperations in date descending
 	// order for the given updaters.
 	//
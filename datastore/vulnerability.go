@@ -2,6 +2,9 @@ package datastore
 
 import (
 	"context"
+	"errors"
+
+	"github.com/google/uuid"
 
 	"github.com/quay/claircore"
 	"github.com/quay/claircore/libvuln/driver"
@@ -18,8 +21,25 @@ type GetOpts struct {
 	// VersionFiltering enables filtering based on the normalized versions in
 	// the database.
 	VersionFiltering bool
+	// UpdateOperations pins the vulnerabilities returned for an updater to
+	// those recorded by a specific update operation, keyed by updater name.
+	// Updaters not present are unaffected.
+	//
+	// The update operations must still exist; if one doesn't, Get reports
+	// ErrUnknownUpdateOperation.
+	UpdateOperations map[string]uuid.UUID
 }
 
+// ErrUnknownUpdateOperation is reported, via errors.Is, when a pinned update
+// operation doesn't exist, or doesn't belong to the updater it was provided
+// for. This is usually because it's been garbage collected.
+var ErrUnknownUpdateOperation = errors.New("datastore: unknown update operation")
+
+// Vulnerability is the interface for retrieving the vulnerabilities that may
+// affect a set of IndexRecords.
+//
+// The postgres package provides a database-backed implementation and the
+// memory package one over vulnerabilities already loaded by the caller.
 type Vulnerability interface {
 	// get finds the vulnerabilities which match each package provided in the packages array
 	// this maybe a one to many relationship. each package is assumed to have an ID.
@@ -3,16 +3,19 @@ package debian
 import (
 	"context"
 
-	version "github.com/knqyf263/go-deb-version"
-
 	"github.com/quay/claircore"
+	"github.com/quay/claircore/internal/vercache"
 	"github.com/quay/claircore/libvuln/driver"
 )
 
 // Matcher is a [driver.Matcher] for Debian distributions.
 type Matcher struct{}
 
-var _ driver.Matcher = (*Matcher)(nil)
+var (
+	_ driver.Matcher              = (*Matcher)(nil)
+	_ driver.FixedVersionComparer = (*Matcher)(nil)
+	_ driver.Scoped               = (*Matcher)(nil)
+)
 
 // Name implements [driver.Matcher].
 func (*Matcher) Name() string {
@@ -35,6 +38,14 @@ func (*Matcher) Filter(record *claircore.IndexRecord) bool {
 	}
 }
 
+// Scope implements [driver.Scoped].
+func (*Matcher) Scope() driver.Scope {
+	return driver.Scope{
+		Distributions:     []string{"debian"},
+		DistributionNames: []string{"Debian GNU/Linux"},
+	}
+}
+
 // Query implements [driver.Matcher].
 func (*Matcher) Query() []driver.MatchConstraint {
 	return []driver.MatchConstraint{
@@ -55,11 +66,11 @@ func (*Matcher) Vulnerable(ctx context.Context, record *claircore.IndexRecord, v
 		return false, nil
 	}
 
-	v1, err := version.NewVersion(record.Package.Version)
+	v1, err := vercache.Deb(ctx, record.Package.Version)
 	if err != nil {
 		return false, nil
 	}
-	v2, err := version.NewVersion(vuln.FixedInVersion)
+	v2, err := vercache.Deb(ctx, vuln.FixedInVersion)
 	if err != nil {
 		return false, err
 	}
@@ -70,3 +81,8 @@ func (*Matcher) Vulnerable(ctx context.Context, record *claircore.IndexRecord, v
 
 	return false, nil
 }
+
+// CompareFixedVersions implements [driver.FixedVersionComparer].
+func (*Matcher) CompareFixedVersions(ctx context.Context, a, b string) int {
+	return vercache.CompareDeb(ctx, a, b)
+}
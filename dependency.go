@@ -0,0 +1,13 @@
+package claircore
+
+// Dependency is an edge in the package dependency graph of a manifest.
+type Dependency struct {
+	// Package is the ID of the package declaring the dependency.
+	Package string `json:"package"`
+	// Requires is the dependency as recorded in the package database: the
+	// name of a package or capability.
+	Requires string `json:"requires"`
+	// Providers are the IDs of the packages in the manifest that satisfy
+	// Requires. This is empty if the dependency could not be resolved.
+	Providers []string `json:"providers,omitempty"`
+}
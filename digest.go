@@ -10,6 +10,7 @@ import (
 	"hash"
 )
 
+// Supported digest algorithms.
 const (
 	SHA256 = "sha256"
 	SHA512 = "sha512"
@@ -35,9 +36,9 @@ func (d Digest) Algorithm() string { return d.algo }
 // Hash returns an instance of the hashing algorithm used for this Digest.
 func (d Digest) Hash() hash.Hash {
 	switch d.algo {
-	case "sha256":
+	case SHA256:
 		return sha256.New()
-	case "sha512":
+	case SHA512:
 		return sha512.New()
 	default:
 		panic("Hash() called on an invalid Digest")
@@ -93,9 +94,9 @@ func (e *DigestError) Unwrap() error {
 func (d *Digest) setChecksum(b []byte) error {
 	var sz int
 	switch d.algo {
-	case "sha256":
+	case SHA256:
 		sz = sha256.Size
-	case "sha512":
+	case SHA512:
 		sz = sha512.Size
 	default:
 		return &DigestError{msg: fmt.Sprintf("unknown algorthm %q", d.algo)}
@@ -118,13 +119,25 @@ func (d *Digest) setChecksum(b []byte) error {
 }
 
 // Scan implements sql.Scanner.
+//
+// An empty value, as stored for things without a digest, scans as the zero
+// Digest.
 func (d *Digest) Scan(i interface{}) error {
 	switch v := i.(type) {
 	case nil:
 		return nil
 	case string:
-		d.UnmarshalText([]byte(v))
-		return nil
+		if v == "" {
+			*d = Digest{}
+			return nil
+		}
+		return d.UnmarshalText([]byte(v))
+	case []byte:
+		if len(v) == 0 {
+			*d = Digest{}
+			return nil
+		}
+		return d.UnmarshalText(v)
 	default:
 		return &DigestError{msg: fmt.Sprintf("invalid digest type: %T", v)}
 	}
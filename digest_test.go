@@ -0,0 +1,136 @@
+package claircore_test
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/quay/claircore"
+)
+
+func TestDigestRoundTrip(t *testing.T) {
+	data := []byte("claircore")
+	s256 := sha256.Sum256(data)
+	s512 := sha512.Sum512(data)
+	tt := []struct {
+		Algo string
+		Sum  []byte
+	}{
+		{Algo: claircore.SHA256, Sum: s256[:]},
+		{Algo: claircore.SHA512, Sum: s512[:]},
+	}
+	for _, tc := range tt {
+		t.Run(tc.Algo, func(t *testing.T) {
+			want := tc.Algo + ":" + hex.EncodeToString(tc.Sum)
+			d, err := claircore.NewDigest(tc.Algo, tc.Sum)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := d.String(); got != want {
+				t.Errorf("got: %q, want: %q", got, want)
+			}
+			if got := d.Algorithm(); got != tc.Algo {
+				t.Errorf("got: %q, want: %q", got, tc.Algo)
+			}
+
+			t.Run("Parse", func(t *testing.T) {
+				p, err := claircore.ParseDigest(want)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !bytes.Equal(p.Checksum(), tc.Sum) || p.String() != want {
+					t.Errorf("got: %v, want: %v", p, want)
+				}
+			})
+			t.Run("Hash", func(t *testing.T) {
+				h := d.Hash()
+				h.Write(data)
+				if got := h.Sum(nil); !bytes.Equal(got, tc.Sum) {
+					t.Errorf("got: %x, want: %x", got, tc.Sum)
+				}
+			})
+			t.Run("JSON", func(t *testing.T) {
+				b, err := json.Marshal(d)
+				if err != nil {
+					t.Fatal(err)
+				}
+				var got claircore.Digest
+				if err := json.Unmarshal(b, &got); err != nil {
+					t.Fatal(err)
+				}
+				if got.String() != want {
+					t.Errorf("got: %v, want: %v", got, want)
+				}
+			})
+			t.Run("SQL", func(t *testing.T) {
+				v, err := d.Value()
+				if err != nil {
+					t.Fatal(err)
+				}
+				for _, in := range []interface{}{v, []byte(v.(string))} {
+					var got claircore.Digest
+					if err := got.Scan(in); err != nil {
+						t.Fatal(err)
+					}
+					if got.String() != want {
+						t.Errorf("%T: got: %v, want: %v", in, got, want)
+					}
+				}
+			})
+			t.Run("Dedupe", func(t *testing.T) {
+				// Digests are deduplicated by their string form, so
+				// independently parsed copies must agree.
+				up := tc.Algo + ":" + strings.ToUpper(hex.EncodeToString(tc.Sum))
+				m := map[string]struct{}{
+					claircore.MustParseDigest(want).String(): {},
+					claircore.MustParseDigest(up).String():   {},
+					d.String():                               {},
+				}
+				if len(m) != 1 {
+					t.Errorf("got %d keys, want 1", len(m))
+				}
+			})
+		})
+	}
+}
+
+func TestDigestInvalid(t *testing.T) {
+	for _, in := range []string{
+		"",
+		"sha256",
+		"md5:d41d8cd98f00b204e9800998ecf8427e",
+		"sha256:" + strings.Repeat("a", 128),
+		"sha512:" + strings.Repeat("a", 64),
+		"sha512:" + strings.Repeat("z", 128),
+	} {
+		var d claircore.Digest
+		if err := d.UnmarshalText([]byte(in)); err == nil {
+			t.Errorf("%q: expected error", in)
+		}
+		if in == "" {
+			// Empty values scan as the zero Digest; see TestDigestScanEmpty.
+			continue
+		}
+		var de *claircore.DigestError
+		if err := d.Scan(in); !errors.As(err, &de) {
+			t.Errorf("%q: unexpected error from Scan: %v", in, err)
+		}
+	}
+}
+
+func TestDigestScanEmpty(t *testing.T) {
+	for _, in := range []interface{}{nil, "", []byte{}} {
+		var d claircore.Digest
+		if err := d.Scan(in); err != nil {
+			t.Errorf("%#v: unexpected error: %v", in, err)
+		}
+		if got := d.String(); got != "" {
+			t.Errorf("%#v: got: %q, want zero Digest", in, got)
+		}
+	}
+}
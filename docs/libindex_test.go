@@ -6,6 +6,7 @@ import (
 	"net/http"
 
 	"github.com/quay/claircore"
+	"github.com/quay/claircore/indexer"
 	"github.com/quay/claircore/libindex"
 )
 
@@ -22,6 +23,10 @@ func Example_libindex() {
 	defer lib.Close(ctx) // Remember to cleanup when done.
 	// ANCHOR_END: new
 
+	// ANCHOR: correlation
+	ctx = indexer.WithCorrelationID(ctx, "request-1234")
+	// ANCHOR_END: correlation
+
 	// ANCHOR: index
 	m := new(claircore.Manifest)
 	// Populate somehow ...
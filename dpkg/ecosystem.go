@@ -12,6 +12,7 @@ import (
 // NewEcosystem provides the set of scanners and coalescers for the dpkg ecosystem
 func NewEcosystem(ctx context.Context) *indexer.Ecosystem {
 	return &indexer.Ecosystem{
+		Name: "dpkg",
 		PackageScanners: func(ctx context.Context) ([]indexer.PackageScanner, error) {
 			return []indexer.PackageScanner{
 				&Scanner{},
@@ -0,0 +1,57 @@
+package dpkg
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+
+	"github.com/quay/zlog"
+)
+
+// OwnedFiles returns the files owned by the packages in the dpkg database in
+// "sys", mapped to the name of the owning package, according to the file
+// lists dpkg keeps for every installed package. Directories shared between
+// packages are attributed to one of them.
+//
+// The returned paths are relative to the root of "sys", like the paths used
+// with the io/fs package. A nil map is returned if there are no file lists.
+func OwnedFiles(ctx context.Context, sys fs.FS) (map[string]string, error) {
+	ctx = zlog.ContextWithValues(ctx, "component", "dpkg/OwnedFiles")
+	ms, err := fs.Glob(sys, "var/lib/dpkg/info/*.list")
+	if err != nil {
+		return nil, fmt.Errorf("dpkg: unable to find file lists: %w", err)
+	}
+	if len(ms) == 0 {
+		return nil, nil
+	}
+	out := make(map[string]string)
+	for _, m := range ms {
+		// The lists are named for the package, with an architecture
+		// qualifier for "Multi-Arch: same" packages.
+		name, _, _ := strings.Cut(strings.TrimSuffix(path.Base(m), ".list"), ":")
+		b, err := fs.ReadFile(sys, m)
+		if err != nil {
+			return nil, fmt.Errorf("dpkg: unable to read %q: %w", m, err)
+		}
+		s := bufio.NewScanner(bytes.NewReader(b))
+		for s.Scan() {
+			// Every package lists the root as "/.", which isn't interesting.
+			p := strings.TrimPrefix(s.Text(), "/")
+			if p == "" || p == "." {
+				continue
+			}
+			out[p] = name
+		}
+		if err := s.Err(); err != nil {
+			return nil, fmt.Errorf("dpkg: unable to read %q: %w", m, err)
+		}
+	}
+	zlog.Debug(ctx).
+		Int("count", len(out)).
+		Msg("found owned files")
+	return out, nil
+}
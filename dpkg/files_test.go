@@ -0,0 +1,36 @@
+package dpkg
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/quay/zlog"
+)
+
+func TestOwnedFiles(t *testing.T) {
+	ctx := zlog.Test(context.Background(), t)
+	sys := fstest.MapFS{
+		"var/lib/dpkg/status": &fstest.MapFile{},
+		"var/lib/dpkg/info/python3-requests.list": &fstest.MapFile{Data: []byte(
+			"/.\n/usr\n/usr/lib/python3/dist-packages/requests-2.28.1.egg-info\n" +
+				"/usr/lib/python3/dist-packages/requests-2.28.1.egg-info/PKG-INFO\n")},
+		"var/lib/dpkg/info/python3-requests.md5sums": &fstest.MapFile{Data: []byte("ignored\n")},
+		"var/lib/dpkg/info/libc6:amd64.list": &fstest.MapFile{Data: []byte(
+			"/.\n/usr/lib/x86_64-linux-gnu/libc.so.6\n")},
+	}
+	got, err := OwnedFiles(ctx, sys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"usr": "python3-requests",
+		"usr/lib/python3/dist-packages/requests-2.28.1.egg-info":          "python3-requests",
+		"usr/lib/python3/dist-packages/requests-2.28.1.egg-info/PKG-INFO": "python3-requests",
+		"usr/lib/x86_64-linux-gnu/libc.so.6":                              "libc6",
+	}
+	if !cmp.Equal(got, want) {
+		t.Error(cmp.Diff(got, want))
+	}
+}
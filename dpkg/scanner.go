@@ -19,27 +19,47 @@ import (
 
 	"github.com/quay/claircore"
 	"github.com/quay/claircore/indexer"
-	"github.com/quay/claircore/pkg/tarfs"
 )
 
 const (
 	name    = "dpkg"
 	kind    = "package"
-	version = "5"
+	version = "6"
 )
 
 var (
-	_ indexer.VersionedScanner = (*Scanner)(nil)
-	_ indexer.PackageScanner   = (*Scanner)(nil)
+	_ indexer.VersionedScanner    = (*Scanner)(nil)
+	_ indexer.PackageScanner      = (*Scanner)(nil)
+	_ indexer.ConfigurableScanner = (*Scanner)(nil)
 )
 
+// ScannerConfig is the struct used to configure a Scanner.
+type ScannerConfig struct {
+	// Dependencies controls whether the "Depends" and "Pre-Depends" fields
+	// are recorded for each package. This is off by default because of the
+	// additional storage required.
+	Dependencies bool `yaml:"dependencies" json:"dependencies"`
+}
+
 // Scanner implements the scanner.PackageScanner interface.
 //
 // This looks for directories that look like dpkg databases and examines the
 // "status" file it finds there.
 //
 // The zero value is ready to use.
-type Scanner struct{}
+type Scanner struct {
+	deps bool
+}
+
+// Configure implements indexer.ConfigurableScanner.
+func (ps *Scanner) Configure(ctx context.Context, f indexer.ConfigDeserializer) error {
+	var cfg ScannerConfig
+	if err := f(&cfg); err != nil {
+		return err
+	}
+	ps.deps = cfg.Dependencies
+	return nil
+}
 
 // Name implements scanner.VersionedScanner.
 func (ps *Scanner) Name() string { return name }
@@ -70,15 +90,11 @@ func (ps *Scanner) Scan(ctx context.Context, layer *claircore.Layer) ([]*clairco
 	// Grab a handle to the tarball, make sure we can seek.
 	// If we can't, we'd need another reader for every database found.
 	// It's cleaner to just demand that it's a seeker.
-	rd, err := layer.Reader()
-	if err != nil {
-		return nil, fmt.Errorf("opening layer failed: %w", err)
-	}
-	defer rd.Close()
-	sys, err := tarfs.New(rd)
+	sys, err := layer.FS()
 	if err != nil {
 		return nil, fmt.Errorf("opening layer failed: %w", err)
 	}
+	defer sys.Close()
 
 	// This is a map keyed by directory. A "score" of 2 means this is almost
 	// certainly a dpkg database.
@@ -87,6 +103,11 @@ func (ps *Scanner) Scan(ctx context.Context, layer *claircore.Layer) ([]*clairco
 		if err != nil {
 			return err
 		}
+		// Symlinks are skipped, so a database reachable through a symlinked
+		// directory or file is only examined at its real location.
+		if d.Type()&fs.ModeSymlink != 0 {
+			return nil
+		}
 		switch dir, f := filepath.Split(p); {
 		case f == "status" && !d.IsDir():
 			loc[dir]++
@@ -155,6 +176,9 @@ func (ps *Scanner) Scan(ctx context.Context, layer *claircore.Layer) ([]*clairco
 				Arch:      hdr.Get("Architecture"),
 				PackageDB: fn,
 			}
+			if ps.deps {
+				p.Depends = parseDepends(hdr.Get("Pre-Depends"), hdr.Get("Depends"))
+			}
 			if src := hdr.Get("Source"); src != "" {
 				p.Source = &claircore.Package{
 					Name: src,
@@ -219,3 +243,33 @@ func (ps *Scanner) Scan(ctx context.Context, layer *claircore.Layer) ([]*clairco
 
 	return pkgs, nil
 }
+
+// ParseDepends returns the package names mentioned in the provided dpkg
+// relationship fields, in order and without duplicates.
+//
+// Version constraints and architecture qualifiers are discarded, and every
+// alternative in a "|" group is returned.
+func parseDepends(fields ...string) []string {
+	var out []string
+	seen := make(map[string]struct{})
+	for _, f := range fields {
+		for _, rel := range strings.FieldsFunc(f, func(r rune) bool { return r == ',' || r == '|' }) {
+			n := strings.TrimSpace(rel)
+			if i := strings.IndexAny(n, " (["); i != -1 {
+				n = n[:i]
+			}
+			if i := strings.IndexByte(n, ':'); i != -1 {
+				n = n[:i]
+			}
+			if n == "" {
+				continue
+			}
+			if _, ok := seen[n]; ok {
+				continue
+			}
+			seen[n] = struct{}{}
+			out = append(out, n)
+		}
+	}
+	return out
+}
@@ -6,16 +6,19 @@ import (
 	"context"
 	"errors"
 	"io"
+	"io/fs"
 	"net/http"
 	"net/textproto"
 	"os"
 	"testing"
+	"testing/fstest"
 	"time"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/quay/zlog"
 
 	"github.com/quay/claircore"
+	"github.com/quay/claircore/test"
 	"github.com/quay/claircore/test/fetch"
 )
 
@@ -888,6 +891,36 @@ func TestExtraMetadata(t *testing.T) {
 	}
 }
 
+func TestSquashfs(t *testing.T) {
+	t.Parallel()
+	ctx := zlog.Test(context.Background(), t)
+	status, err := os.ReadFile(`testdata/texlive.status`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tl, sl := test.SquashfsLayers(t, fstest.MapFS{
+		"var/lib/dpkg/status": &fstest.MapFile{Data: status, Mode: 0o644},
+		"var/lib/dpkg/info":   &fstest.MapFile{Mode: fs.ModeDir | 0o755},
+	})
+
+	var s Scanner
+	want, err := s.Scan(ctx, tl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := s.Scan(ctx, sl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("found %d packages", len(got))
+	if len(got) == 0 {
+		t.Error("no packages found")
+	}
+	if !cmp.Equal(got, want) {
+		t.Error(cmp.Diff(got, want))
+	}
+}
+
 // ExtraMetadataSetup is a helper to craft a layer that trips PROJQUAY-1308.
 func extraMetadataSetup(t *testing.T, layer string) {
 	t.Helper()
@@ -1014,3 +1047,80 @@ func TestKeyringPackage(t *testing.T) {
 		t.Fail()
 	}
 }
+
+func TestParseDepends(t *testing.T) {
+	tt := []struct {
+		name   string
+		fields []string
+		want   []string
+	}{
+		{
+			name:   "Empty",
+			fields: []string{"", ""},
+		},
+		{
+			name:   "Versioned",
+			fields: []string{"libc6 (>= 2.34), libtinfo6 (>= 6)"},
+			want:   []string{"libc6", "libtinfo6"},
+		},
+		{
+			name:   "Alternatives",
+			fields: []string{"debconf (>= 0.5) | debconf-2.0, perl:any"},
+			want:   []string{"debconf", "debconf-2.0", "perl"},
+		},
+		{
+			name:   "PreDepends",
+			fields: []string{"libc6 (>= 2.34)", "libc6 (>= 2.17), zlib1g [amd64]"},
+			want:   []string{"libc6", "zlib1g"},
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			got := parseDepends(tc.fields...)
+			if !cmp.Equal(got, tc.want) {
+				t.Error(cmp.Diff(got, tc.want))
+			}
+		})
+	}
+}
+
+// TestSymlinkedDB checks that a database reachable through a symlink is only
+// reported once, at its real location.
+func TestSymlinkedDB(t *testing.T) {
+	t.Parallel()
+	ctx := zlog.Test(context.Background(), t)
+	status, err := os.ReadFile(`testdata/debian-only.status`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tl, sl := test.SquashfsLayers(t, fstest.MapFS{
+		"usr/lib/sysimage/dpkg/status": &fstest.MapFile{Data: status, Mode: 0o644},
+		"usr/lib/sysimage/dpkg/info":   &fstest.MapFile{Mode: fs.ModeDir | 0o755},
+		"var/lib/dpkg": &fstest.MapFile{
+			Data: []byte("../../usr/lib/sysimage/dpkg"),
+			Mode: fs.ModeSymlink | 0o777,
+		},
+		// A second database with only the status file linked.
+		"opt/dpkg/status": &fstest.MapFile{
+			Data: []byte("../../usr/lib/sysimage/dpkg/status"),
+			Mode: fs.ModeSymlink | 0o777,
+		},
+		"opt/dpkg/info": &fstest.MapFile{Mode: fs.ModeDir | 0o755},
+	})
+
+	var s Scanner
+	for n, l := range map[string]*claircore.Layer{"tar": tl, "squashfs": sl} {
+		got, err := s.Scan(ctx, l)
+		if err != nil {
+			t.Fatalf("%s: %v", n, err)
+		}
+		if len(got) == 0 {
+			t.Errorf("%s: no packages found", n)
+		}
+		for _, p := range got {
+			if got, want := p.PackageDB, "usr/lib/sysimage/dpkg/status"; got != want {
+				t.Errorf("%s: %s: got: %q, want: %q", n, p.Name, got, want)
+			}
+		}
+	}
+}
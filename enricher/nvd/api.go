@@ -0,0 +1,222 @@
+package nvd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/quay/zlog"
+)
+
+const (
+	// PageSize is the largest page the API allows.
+	pageSize = 2000
+	// MaxRange is the longest modification date range the API allows.
+	maxRange = 120 * 24 * time.Hour
+	// ApiTime is the layout for dates in API requests.
+	apiTime = `2006-01-02T15:04:05.000-07:00`
+	// MaxRetries is the number of times a rate limited or unavailable
+	// request is retried.
+	maxRetries = 3
+)
+
+// Record is the metadata stored for a CVE, and the type of the values in an
+// enrichment.
+//
+// The fields are copied from the "cve" object in the API response.
+type Record struct {
+	ID           string `json:"id"`
+	Published    string `json:"published,omitempty"`
+	LastModified string `json:"lastModified,omitempty"`
+	VulnStatus   string `json:"vulnStatus,omitempty"`
+	Description  string `json:"description,omitempty"`
+	// Metrics is the "metrics" object, which holds the CVSS data keyed by
+	// version.
+	Metrics json.RawMessage `json:"metrics,omitempty"`
+	// CVSS is a summary of the newest CVSS version in Metrics, if any.
+	CVSS *CVSS `json:"cvss,omitempty"`
+}
+
+// CVSS is the summary of a single CVSS assessment.
+//
+// The NVD's own ("Primary") assessment is preferred over ones from other
+// sources.
+type CVSS struct {
+	Version      string  `json:"version"`
+	Vector       string  `json:"vectorString"`
+	BaseScore    float64 `json:"baseScore"`
+	BaseSeverity string  `json:"baseSeverity,omitempty"`
+}
+
+// ApiResponse is the subset of an API response the Enricher uses.
+type apiResponse struct {
+	ResultsPerPage  int `json:"resultsPerPage"`
+	StartIndex      int `json:"startIndex"`
+	TotalResults    int `json:"totalResults"`
+	Vulnerabilities []struct {
+		CVE apiCVE `json:"cve"`
+	} `json:"vulnerabilities"`
+}
+
+type apiCVE struct {
+	ID           string `json:"id"`
+	Published    string `json:"published"`
+	LastModified string `json:"lastModified"`
+	VulnStatus   string `json:"vulnStatus"`
+	Descriptions []struct {
+		Lang  string `json:"lang"`
+		Value string `json:"value"`
+	} `json:"descriptions"`
+	Metrics json.RawMessage `json:"metrics"`
+}
+
+// Record returns the Record for the CVE.
+func (c *apiCVE) Record() Record {
+	r := Record{
+		ID:           c.ID,
+		Published:    c.Published,
+		LastModified: c.LastModified,
+		VulnStatus:   c.VulnStatus,
+		Metrics:      c.Metrics,
+	}
+	for _, d := range c.Descriptions {
+		if d.Lang == "en" {
+			r.Description = d.Value
+			break
+		}
+	}
+	r.CVSS = summarize(c.Metrics)
+	return r
+}
+
+// ApiMetric is a single entry in one of the arrays in the "metrics" object.
+//
+// Version 2 assessments report the severity outside of the "cvssData"
+// object.
+type apiMetric struct {
+	Type         string `json:"type"`
+	BaseSeverity string `json:"baseSeverity"`
+	Data         CVSS   `json:"cvssData"`
+}
+
+// Summarize returns the summary of the newest CVSS version in the "metrics"
+// object, or nil if there's nothing usable.
+func summarize(raw json.RawMessage) *CVSS {
+	if len(raw) == 0 {
+		return nil
+	}
+	var m map[string][]apiMetric
+	if err := json.Unmarshal(raw, &m); err != nil {
+		return nil
+	}
+	for _, k := range []string{"cvssMetricV31", "cvssMetricV30", "cvssMetricV2"} {
+		ms := m[k]
+		if len(ms) == 0 {
+			continue
+		}
+		pick := &ms[0]
+		for i := range ms {
+			if ms[i].Type == "Primary" {
+				pick = &ms[i]
+				break
+			}
+		}
+		out := pick.Data
+		if out.BaseSeverity == "" {
+			out.BaseSeverity = pick.BaseSeverity
+		}
+		return &out
+	}
+	return nil
+}
+
+// Page requests every page of results for the query, writing a Record for
+// each CVE to the encoder. It returns the number of CVEs written.
+func (e *Enricher) page(ctx context.Context, q url.Values, enc *json.Encoder) (int, error) {
+	var ct int
+	for idx := 0; ; {
+		q.Set("startIndex", strconv.Itoa(idx))
+		q.Set("resultsPerPage", strconv.Itoa(pageSize))
+		res, err := e.get(ctx, q)
+		if err != nil {
+			return ct, err
+		}
+		for i := range res.Vulnerabilities {
+			r := res.Vulnerabilities[i].CVE.Record()
+			if err := enc.Encode(&r); err != nil {
+				return ct, err
+			}
+		}
+		n := len(res.Vulnerabilities)
+		ct += n
+		idx += n
+		zlog.Debug(ctx).
+			Int("index", idx).
+			Int("total", res.TotalResults).
+			Msg("fetched page")
+		if n == 0 || idx >= res.TotalResults {
+			return ct, nil
+		}
+	}
+}
+
+// Get makes a single API request, respecting the rate limit.
+//
+// The NVD responds with a 403 or 503 when a client is making requests too
+// quickly or the service is overloaded, so these are retried after the rate
+// limit window passes.
+func (e *Enricher) get(ctx context.Context, q url.Values) (*apiResponse, error) {
+	u := *e.api
+	u.RawQuery = q.Encode()
+	for try := 0; ; try++ {
+		if err := e.lim.Wait(ctx); err != nil {
+			return nil, err
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+		if err != nil {
+			return nil, err
+		}
+		if e.key != "" {
+			req.Header.Set("apiKey", e.key)
+		}
+		res, err := e.c.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		switch res.StatusCode {
+		case http.StatusOK:
+		case http.StatusForbidden, http.StatusTooManyRequests, http.StatusServiceUnavailable:
+			res.Body.Close()
+			if try == maxRetries {
+				return nil, fmt.Errorf("nvd: unexpected response: %s", res.Status)
+			}
+			zlog.Info(ctx).
+				Str("status", res.Status).
+				Msg("rate limited, waiting")
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(retryWait):
+			}
+			continue
+		default:
+			res.Body.Close()
+			return nil, fmt.Errorf("nvd: unexpected response: %s", res.Status)
+		}
+		var r apiResponse
+		err = json.NewDecoder(res.Body).Decode(&r)
+		res.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("nvd: unable to decode response: %w", err)
+		}
+		return &r, nil
+	}
+}
+
+// RetryWait is how long to wait before retrying a rate limited request: the
+// length of the NVD's rate limit window.
+var retryWait = 30 * time.Second
@@ -0,0 +1,49 @@
+package nvd
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/quay/zlog"
+
+	"github.com/quay/claircore/libvuln/driver"
+)
+
+var (
+	_ driver.UpdaterSetFactory = (*Factory)(nil)
+	_ driver.Configurable      = (*Factory)(nil)
+)
+
+// Factory constructs the UpdaterSet containing the Enricher.
+//
+// The first update pages through every CVE the NVD has, which takes hours
+// at the public rate limit, so the Enricher is opt-in: the returned set is
+// empty unless the Factory is configured with "enable" set.
+type Factory struct {
+	enable bool
+}
+
+// Configure implements driver.Configurable.
+func (f *Factory) Configure(ctx context.Context, cf driver.ConfigUnmarshaler, _ *http.Client) error {
+	var cfg Config
+	if err := cf(&cfg); err != nil {
+		return err
+	}
+	f.enable = cfg.Enable
+	return nil
+}
+
+// UpdaterSet implements driver.UpdaterSetFactory.
+func (f *Factory) UpdaterSet(ctx context.Context) (driver.UpdaterSet, error) {
+	s := driver.NewUpdaterSet()
+	if !f.enable {
+		ctx = zlog.ContextWithValues(ctx, "component", "enricher/nvd/Factory.UpdaterSet")
+		zlog.Debug(ctx).
+			Msg("not enabled, skipping")
+		return s, nil
+	}
+	if err := s.Add(&Enricher{}); err != nil {
+		return s, err
+	}
+	return s, nil
+}
@@ -0,0 +1,39 @@
+package nvd
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/quay/zlog"
+)
+
+func TestFactoryOptIn(t *testing.T) {
+	ctx := zlog.Test(context.Background(), t)
+	for _, tc := range []struct {
+		Name   string
+		Config string
+		Want   int
+	}{
+		{Name: "Default", Config: `{}`, Want: 0},
+		{Name: "Enabled", Config: `{"enable":true}`, Want: 1},
+	} {
+		t.Run(tc.Name, func(t *testing.T) {
+			var f Factory
+			err := f.Configure(ctx, func(v interface{}) error {
+				return json.Unmarshal([]byte(tc.Config), v)
+			}, http.DefaultClient)
+			if err != nil {
+				t.Fatal(err)
+			}
+			us, err := f.UpdaterSet(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := len(us.Updaters()); got != tc.Want {
+				t.Errorf("got: %d updaters, want: %d", got, tc.Want)
+			}
+		})
+	}
+}
@@ -0,0 +1,329 @@
+// Package nvd provides an enricher for baseline CVE metadata from the
+// National Vulnerability Database.
+//
+// The EnrichmentUpdater pages through the NVD CVE API, version 2.0, and
+// stores the description, dates, and CVSS metrics of every CVE keyed by its
+// ID. The Enricher attaches the stored metadata for every CVE mentioned in a
+// report's vulnerabilities, so reports have authoritative CVSS data and
+// descriptions even when a distribution's advisory lacks them.
+package nvd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/quay/zlog"
+	"golang.org/x/time/rate"
+
+	"github.com/quay/claircore"
+	"github.com/quay/claircore/libvuln/driver"
+	"github.com/quay/claircore/pkg/tmp"
+)
+
+var (
+	_ driver.Enricher          = (*Enricher)(nil)
+	_ driver.EnrichmentUpdater = (*Enricher)(nil)
+	_ driver.DeltaUpdater      = (*Enricher)(nil)
+	_ driver.Configurable      = (*Enricher)(nil)
+)
+
+const (
+	// Type is the type of data returned from the Enricher's Enrich method.
+	//
+	// The data is a JSON object mapping vulnerability IDs to arrays of
+	// Records.
+	Type = `message/vnd.clair.map.vulnerability; enricher=clair.nvd schema=https://csrc.nist.gov/schema/nvd/api/2.0/cve_api_json_2.0.schema`
+
+	// DefaultURL is the NVD CVE API endpoint.
+	//
+	//doc:url updater
+	DefaultURL = `https://services.nvd.nist.gov/rest/json/cves/2.0`
+
+	// This appears above and must be the same.
+	name = `clair.nvd`
+)
+
+// Enricher provides CVE metadata from the NVD as enrichments to a
+// VulnerabilityReport.
+//
+// The first update fetches every CVE. Later updates only fetch CVEs modified
+// since the previous one, using the time of the previous update as the
+// Fingerprint, and the store carries forward the rest.
+//
+// Configure must be called before any other methods.
+type Enricher struct {
+	driver.NoopUpdater
+	c   *http.Client
+	api *url.URL
+	key string
+	lim *rate.Limiter
+}
+
+// Config is the configuration for Enricher.
+type Config struct {
+	// URL is the CVE API endpoint. If unset, DefaultURL is used.
+	URL string `json:"url" yaml:"url"`
+	// APIKey is an NVD API key. Requests are much more strictly rate limited
+	// without one.
+	APIKey string `json:"api_key" yaml:"api_key"`
+	// Enable turns on the Enricher when it's constructed by a Factory.
+	Enable bool `json:"enable" yaml:"enable"`
+}
+
+// These are the NVD's published rate limits: 5 requests in a rolling 30
+// second window without an API key, and 50 with one.
+var (
+	publicRate = rate.Every(30 * time.Second / 5)
+	keyedRate  = rate.Every(30 * time.Second / 50)
+)
+
+// Configure implements driver.Configurable.
+func (e *Enricher) Configure(ctx context.Context, f driver.ConfigUnmarshaler, c *http.Client) error {
+	ctx = zlog.ContextWithValues(ctx, "component", "enricher/nvd/Enricher/Configure")
+	var cfg Config
+	e.c = c
+	if err := f(&cfg); err != nil {
+		return err
+	}
+	u := DefaultURL
+	if cfg.URL != "" {
+		u = cfg.URL
+	}
+	var err error
+	e.api, err = url.Parse(u)
+	if err != nil {
+		return fmt.Errorf("nvd: bad API URL: %w", err)
+	}
+	e.key = cfg.APIKey
+	lim := publicRate
+	if e.key != "" {
+		lim = keyedRate
+	}
+	e.lim = rate.NewLimiter(lim, 1)
+	zlog.Debug(ctx).
+		Stringer("url", e.api).
+		Bool("api_key", e.key != "").
+		Msg("configured")
+	return nil
+}
+
+// Name implements driver.Enricher and driver.EnrichmentUpdater.
+func (*Enricher) Name() string { return name }
+
+// Delta implements driver.DeltaUpdater.
+func (*Enricher) Delta() bool { return true }
+
+// FetchEnrichment implements driver.EnrichmentUpdater.
+//
+// The Fingerprint is the time the previous fetch started. If it's present,
+// only CVEs modified since then are fetched.
+func (e *Enricher) FetchEnrichment(ctx context.Context, hint driver.Fingerprint) (io.ReadCloser, driver.Fingerprint, error) {
+	ctx = zlog.ContextWithValues(ctx, "component", "enricher/nvd/Enricher/FetchEnrichment")
+	if e.api == nil || e.c == nil {
+		return nil, hint, errors.New("nvd: Enricher not configured")
+	}
+	var since time.Time
+	if hint != "" {
+		var err error
+		since, err = time.Parse(time.RFC3339, string(hint))
+		if err != nil {
+			zlog.Info(ctx).
+				Err(err).
+				Msg("ignoring unparsable fingerprint")
+			since = time.Time{}
+		}
+	}
+	now := time.Now().UTC()
+
+	out, err := tmp.NewFile("", "nvd.")
+	if err != nil {
+		return nil, hint, err
+	}
+	var success bool
+	defer func() {
+		if !success {
+			if err := out.Close(); err != nil {
+				zlog.Warn(ctx).Err(err).Msg("unable to close spool")
+			}
+		}
+	}()
+	enc := json.NewEncoder(out)
+
+	var ct int
+	if since.IsZero() {
+		zlog.Info(ctx).Msg("fetching all CVEs")
+		ct, err = e.page(ctx, url.Values{}, enc)
+		if err != nil {
+			return nil, hint, err
+		}
+	} else {
+		zlog.Info(ctx).
+			Time("since", since).
+			Msg("fetching modified CVEs")
+		// The API only allows ranges of up to 120 days.
+		for start := since; start.Before(now); {
+			end := start.Add(maxRange)
+			if end.After(now) {
+				end = now
+			}
+			v := url.Values{}
+			v.Set("lastModStartDate", start.Format(apiTime))
+			v.Set("lastModEndDate", end.Format(apiTime))
+			n, err := e.page(ctx, v, enc)
+			if err != nil {
+				return nil, hint, err
+			}
+			ct += n
+			start = end
+		}
+		if ct == 0 {
+			return nil, hint, driver.Unchanged
+		}
+	}
+	if _, err := out.Seek(0, io.SeekStart); err != nil {
+		return nil, hint, fmt.Errorf("nvd: unable to reset spool: %w", err)
+	}
+	zlog.Debug(ctx).
+		Int("count", ct).
+		Msg("fetched CVEs")
+	success = true
+	return out, driver.Fingerprint(now.Format(time.RFC3339)), nil
+}
+
+// ParseEnrichment implements driver.EnrichmentUpdater.
+//
+// Each CVE becomes a record tagged with its ID.
+func (e *Enricher) ParseEnrichment(ctx context.Context, rc io.ReadCloser) ([]driver.EnrichmentRecord, error) {
+	ctx = zlog.ContextWithValues(ctx, "component", "enricher/nvd/Enricher/ParseEnrichment")
+	defer rc.Close()
+	dec := json.NewDecoder(rc)
+	var out []driver.EnrichmentRecord
+	for {
+		var raw json.RawMessage
+		err := dec.Decode(&raw)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("nvd: unable to decode record: %w", err)
+		}
+		var r Record
+		if err := json.Unmarshal(raw, &r); err != nil {
+			return nil, fmt.Errorf("nvd: unable to decode record: %w", err)
+		}
+		out = append(out, driver.EnrichmentRecord{
+			Tags:       []string{r.ID},
+			Enrichment: raw,
+		})
+	}
+	zlog.Debug(ctx).
+		Int("count", len(out)).
+		Msg("decoded enrichments")
+	return out, nil
+}
+
+// This is a slightly more relaxed version of the validation pattern in the NVD
+// JSON schema: https://csrc.nist.gov/schema/nvd/api/2.0/cve_api_json_2.0.schema
+//
+// It allows for "CVE" to be case insensitive and for dashes and underscores
+// between the different segments.
+var cveRegexp = regexp.MustCompile(`(?i:cve)[-_][0-9]{4}[-_][0-9]{4,}`)
+
+// Enrich implements driver.Enricher.
+//
+// Every CVE mentioned by a vulnerability in the report is looked up with a
+// single query, and each vulnerability is then given the Records for the CVEs
+// it mentions. The vulnerabilities themselves are untouched, so a
+// distribution's severity and the NVD's CVSS data are both available.
+func (e *Enricher) Enrich(ctx context.Context, g driver.EnrichmentGetter, r *claircore.VulnerabilityReport) (string, []json.RawMessage, error) {
+	ctx = zlog.ContextWithValues(ctx, "component", "enricher/nvd/Enricher/Enrich")
+
+	// vulnerability ID → CVE IDs
+	want := make(map[string][]string)
+	all := make(map[string]struct{})
+	for id, v := range r.Vulnerabilities {
+		ids := cveIDs(v)
+		if len(ids) == 0 {
+			continue
+		}
+		want[id] = ids
+		for _, c := range ids {
+			all[c] = struct{}{}
+		}
+	}
+	if len(all) == 0 {
+		return Type, nil, nil
+	}
+	tags := make([]string, 0, len(all))
+	for c := range all {
+		tags = append(tags, c)
+	}
+	sort.Strings(tags)
+	recs, err := g.GetEnrichment(ctx, tags)
+	if err != nil {
+		return "", nil, err
+	}
+	byCVE := make(map[string]json.RawMessage, len(recs))
+	for _, rec := range recs {
+		for _, t := range rec.Tags {
+			byCVE[t] = rec.Enrichment
+		}
+	}
+
+	m := make(map[string][]json.RawMessage)
+	for id, ids := range want {
+		for _, c := range ids {
+			if b, ok := byCVE[c]; ok {
+				m[id] = append(m[id], b)
+			}
+		}
+	}
+	zlog.Debug(ctx).
+		Int("cves", len(tags)).
+		Int("found", len(byCVE)).
+		Int("count", len(m)).
+		Msg("enriched vulnerabilities")
+	if len(m) == 0 {
+		return Type, nil, nil
+	}
+	b, err := json.Marshal(m)
+	if err != nil {
+		return Type, nil, err
+	}
+	return Type, []json.RawMessage{b}, nil
+}
+
+// CveIDs returns the sorted, normalized CVE IDs mentioned in the free-form
+// parts of the vulnerability.
+func cveIDs(v *claircore.Vulnerability) []string {
+	t := make(map[string]struct{})
+	for _, elem := range []string{
+		v.Description,
+		v.Name,
+		v.Links,
+	} {
+		for _, m := range cveRegexp.FindAllString(elem, -1) {
+			// Normalize to the form used in the tags.
+			m = strings.ToUpper(strings.ReplaceAll(m, "_", "-"))
+			t[m] = struct{}{}
+		}
+	}
+	if len(t) == 0 {
+		return nil
+	}
+	ids := make([]string, 0, len(t))
+	for m := range t {
+		ids = append(ids, m)
+	}
+	sort.Strings(ids)
+	return ids
+}
@@ -0,0 +1,350 @@
+package nvd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/quay/zlog"
+	"golang.org/x/time/rate"
+
+	"github.com/quay/claircore"
+	"github.com/quay/claircore/libvuln/driver"
+)
+
+// MockAPI serves the provided CVEs like the CVE API, using a page size of 2
+// to exercise paging.
+type mockAPI struct {
+	CVEs []apiCVE
+	// Fail is the number of requests to reject as rate limited.
+	Fail     int32
+	requests int32
+}
+
+func (m *mockAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if atomic.AddInt32(&m.requests, 1) <= m.Fail {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+	if got, want := r.Header.Get("apiKey"), "key"; got != want {
+		http.Error(w, "bad key", http.StatusNotFound)
+		return
+	}
+	q := r.URL.Query()
+	var start, end time.Time
+	if s, e := q.Get("lastModStartDate"), q.Get("lastModEndDate"); s != "" || e != "" {
+		var err error
+		if start, err = time.Parse(apiTime, s); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if end, err = time.Parse(apiTime, e); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if end.Sub(start) > maxRange {
+			http.Error(w, "range too long", http.StatusBadRequest)
+			return
+		}
+	}
+	var match []apiCVE
+	for _, c := range m.CVEs {
+		mod, _ := time.Parse("2006-01-02T15:04:05.000", c.LastModified)
+		if !start.IsZero() && (mod.Before(start) || mod.After(end)) {
+			continue
+		}
+		match = append(match, c)
+	}
+	idx, _ := strconv.Atoi(q.Get("startIndex"))
+	if idx > len(match) {
+		idx = len(match)
+	}
+	page := match[idx:]
+	if len(page) > 2 {
+		page = page[:2]
+	}
+	res := apiResponse{
+		ResultsPerPage: len(page),
+		StartIndex:     idx,
+		TotalResults:   len(match),
+	}
+	for _, c := range page {
+		res.Vulnerabilities = append(res.Vulnerabilities, struct {
+			CVE apiCVE `json:"cve"`
+		}{CVE: c})
+	}
+	json.NewEncoder(w).Encode(&res)
+}
+
+func mkCVE(id string, mod time.Time) apiCVE {
+	c := apiCVE{
+		ID:           id,
+		Published:    "2021-10-05T09:15:07.000",
+		LastModified: mod.UTC().Format("2006-01-02T15:04:05.000"),
+		VulnStatus:   "Analyzed",
+		Metrics:      json.RawMessage(`{"cvssMetricV31":[{"type":"Primary","cvssData":{"version":"3.1","vectorString":"CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:N/A:N","baseScore":7.5,"baseSeverity":"HIGH"}}]}`),
+	}
+	c.Descriptions = append(c.Descriptions, struct {
+		Lang  string `json:"lang"`
+		Value string `json:"value"`
+	}{Lang: "en", Value: "Description of " + id + "."})
+	return c
+}
+
+func newEnricher(t *testing.T, ctx context.Context, srv *httptest.Server) *Enricher {
+	t.Helper()
+	e := &Enricher{}
+	f := func(i interface{}) error {
+		cfg := i.(*Config)
+		cfg.URL = srv.URL + "/rest/json/cves/2.0"
+		cfg.APIKey = "key"
+		return nil
+	}
+	if err := e.Configure(ctx, f, srv.Client()); err != nil {
+		t.Fatal(err)
+	}
+	// Don't wait on the real rate limit.
+	e.lim = rate.NewLimiter(rate.Inf, 1)
+	return e
+}
+
+func TestFetch(t *testing.T) {
+	t.Parallel()
+	ctx := zlog.Test(context.Background(), t)
+	now := time.Now()
+	api := &mockAPI{CVEs: []apiCVE{
+		mkCVE("CVE-2021-0001", now.Add(-200*24*time.Hour)),
+		mkCVE("CVE-2021-0002", now.Add(-150*24*time.Hour)),
+		mkCVE("CVE-2021-0003", now.Add(-24*time.Hour)),
+		mkCVE("CVE-2021-0004", now.Add(-time.Hour)),
+		mkCVE("CVE-2021-0005", now.Add(-time.Minute)),
+	}}
+	srv := httptest.NewServer(api)
+	t.Cleanup(srv.Close)
+	e := newEnricher(t, ctx, srv)
+
+	fetch := func(t *testing.T, hint driver.Fingerprint) ([]string, driver.Fingerprint) {
+		t.Helper()
+		rc, fp, err := e.FetchEnrichment(ctx, hint)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := time.Parse(time.RFC3339, string(fp)); err != nil {
+			t.Errorf("bad fingerprint: %v", err)
+		}
+		rs, err := e.ParseEnrichment(ctx, rc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var ids []string
+		for _, r := range rs {
+			ids = append(ids, r.Tags...)
+		}
+		return ids, fp
+	}
+
+	t.Run("Full", func(t *testing.T) {
+		got, _ := fetch(t, "")
+		want := []string{"CVE-2021-0001", "CVE-2021-0002", "CVE-2021-0003", "CVE-2021-0004", "CVE-2021-0005"}
+		if !cmp.Equal(got, want) {
+			t.Error(cmp.Diff(got, want))
+		}
+	})
+	t.Run("Incremental", func(t *testing.T) {
+		hint := driver.Fingerprint(now.Add(-180 * 24 * time.Hour).UTC().Format(time.RFC3339))
+		got, _ := fetch(t, hint)
+		want := []string{"CVE-2021-0002", "CVE-2021-0003", "CVE-2021-0004", "CVE-2021-0005"}
+		if !cmp.Equal(got, want) {
+			t.Error(cmp.Diff(got, want))
+		}
+	})
+	t.Run("Unchanged", func(t *testing.T) {
+		hint := driver.Fingerprint(time.Now().UTC().Format(time.RFC3339))
+		_, _, err := e.FetchEnrichment(ctx, hint)
+		if !errors.Is(err, driver.Unchanged) {
+			t.Errorf("got: %v, want: %v", err, driver.Unchanged)
+		}
+	})
+}
+
+func TestRetry(t *testing.T) {
+	ctx := zlog.Test(context.Background(), t)
+	prev := retryWait
+	retryWait = 0
+	t.Cleanup(func() { retryWait = prev })
+
+	api := &mockAPI{
+		CVEs: []apiCVE{mkCVE("CVE-2021-0001", time.Now())},
+		Fail: maxRetries,
+	}
+	srv := httptest.NewServer(api)
+	t.Cleanup(srv.Close)
+	e := newEnricher(t, ctx, srv)
+	rc, _, err := e.FetchEnrichment(ctx, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rs, err := e.ParseEnrichment(ctx, rc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := len(rs), 1; got != want {
+		t.Errorf("got: %d records, want: %d", got, want)
+	}
+
+	api.Fail = atomic.LoadInt32(&api.requests) + maxRetries + 1
+	if _, _, err := e.FetchEnrichment(ctx, ""); err == nil {
+		t.Error("expected error after exhausting retries")
+	}
+}
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+	ctx := zlog.Test(context.Background(), t)
+	api := &mockAPI{CVEs: []apiCVE{mkCVE("CVE-2021-41773", time.Now())}}
+	srv := httptest.NewServer(api)
+	t.Cleanup(srv.Close)
+	e := newEnricher(t, ctx, srv)
+	rc, _, err := e.FetchEnrichment(ctx, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rs, err := e.ParseEnrichment(ctx, rc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rs) != 1 {
+		t.Fatalf("got: %d records, want: 1", len(rs))
+	}
+	var got Record
+	if err := json.Unmarshal(rs[0].Enrichment, &got); err != nil {
+		t.Fatal(err)
+	}
+	got.LastModified = ""
+	want := Record{
+		ID:          "CVE-2021-41773",
+		Published:   "2021-10-05T09:15:07.000",
+		VulnStatus:  "Analyzed",
+		Description: "Description of CVE-2021-41773.",
+		Metrics:     json.RawMessage(`{"cvssMetricV31":[{"type":"Primary","cvssData":{"version":"3.1","vectorString":"CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:N/A:N","baseScore":7.5,"baseSeverity":"HIGH"}}]}`),
+		CVSS: &CVSS{
+			Version:      "3.1",
+			Vector:       "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:N/A:N",
+			BaseScore:    7.5,
+			BaseSeverity: "HIGH",
+		},
+	}
+	if !cmp.Equal(got, want) {
+		t.Error(cmp.Diff(got, want))
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	t.Parallel()
+	tt := []struct {
+		Name    string
+		Metrics string
+		Want    *CVSS
+	}{
+		{Name: "Empty", Metrics: `{}`},
+		{
+			Name:    "V2",
+			Metrics: `{"cvssMetricV2":[{"type":"Primary","baseSeverity":"MEDIUM","cvssData":{"version":"2.0","vectorString":"AV:N/AC:L/Au:N/C:P/I:N/A:N","baseScore":5.0}}]}`,
+			Want:    &CVSS{Version: "2.0", Vector: "AV:N/AC:L/Au:N/C:P/I:N/A:N", BaseScore: 5.0, BaseSeverity: "MEDIUM"},
+		},
+		{
+			Name: "Newest",
+			Metrics: `{"cvssMetricV2":[{"type":"Primary","baseSeverity":"MEDIUM","cvssData":{"version":"2.0","baseScore":5.0}}],` +
+				`"cvssMetricV30":[{"type":"Primary","cvssData":{"version":"3.0","baseScore":7.5,"baseSeverity":"HIGH"}}]}`,
+			Want: &CVSS{Version: "3.0", BaseScore: 7.5, BaseSeverity: "HIGH"},
+		},
+		{
+			Name: "Primary",
+			Metrics: `{"cvssMetricV31":[{"type":"Secondary","cvssData":{"version":"3.1","baseScore":5.5,"baseSeverity":"MEDIUM"}},` +
+				`{"type":"Primary","cvssData":{"version":"3.1","baseScore":9.8,"baseSeverity":"CRITICAL"}}]}`,
+			Want: &CVSS{Version: "3.1", BaseScore: 9.8, BaseSeverity: "CRITICAL"},
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			got := summarize(json.RawMessage(tc.Metrics))
+			if !cmp.Equal(got, tc.Want) {
+				t.Error(cmp.Diff(got, tc.Want))
+			}
+		})
+	}
+}
+
+type fakeGetter map[string]json.RawMessage
+
+func (g fakeGetter) GetEnrichment(_ context.Context, tags []string) ([]driver.EnrichmentRecord, error) {
+	var out []driver.EnrichmentRecord
+	for _, t := range tags {
+		if e, ok := g[t]; ok {
+			out = append(out, driver.EnrichmentRecord{Tags: []string{t}, Enrichment: e})
+		}
+	}
+	return out, nil
+}
+
+func TestEnrich(t *testing.T) {
+	t.Parallel()
+	ctx := zlog.Test(context.Background(), t)
+	g := fakeGetter{
+		"CVE-2021-41773": json.RawMessage(`{"id":"CVE-2021-41773"}`),
+		"CVE-2021-3711":  json.RawMessage(`{"id":"CVE-2021-3711"}`),
+	}
+	r := &claircore.VulnerabilityReport{
+		Vulnerabilities: map[string]*claircore.Vulnerability{
+			"1": {Name: "CVE-2021-41773"},
+			"2": {Name: "RHSA-2021:3754", Description: "Fixes cve_2021_3711."},
+			"3": {Name: "GHSA-xxxx-xxxx-xxxx"},
+			"4": {Name: "RHSA-2021:3816", Links: "https://access.redhat.com/security/cve/CVE-2021-3711 https://access.redhat.com/security/cve/CVE-2021-41773"},
+		},
+	}
+	e := &Enricher{}
+	cg := &countGetter{EnrichmentGetter: g}
+	kind, es, err := e.Enrich(ctx, cg, r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := kind, Type; got != want {
+		t.Errorf("got: %q, want: %q", got, want)
+	}
+	if len(es) != 1 {
+		t.Fatalf("got: %d enrichments, want: 1", len(es))
+	}
+	var got map[string][]Record
+	if err := json.Unmarshal(es[0], &got); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string][]Record{
+		"1": {{ID: "CVE-2021-41773"}},
+		"2": {{ID: "CVE-2021-3711"}},
+		"4": {{ID: "CVE-2021-3711"}, {ID: "CVE-2021-41773"}},
+	}
+	if !cmp.Equal(got, want) {
+		t.Error(cmp.Diff(got, want))
+	}
+	if got, want := cg.calls, 1; got != want {
+		t.Errorf("got: %d GetEnrichment calls, want: %d", got, want)
+	}
+}
+
+// CountGetter counts calls to GetEnrichment.
+type countGetter struct {
+	driver.EnrichmentGetter
+	calls int
+}
+
+func (g *countGetter) GetEnrichment(ctx context.Context, tags []string) ([]driver.EnrichmentRecord, error) {
+	g.calls++
+	return g.EnrichmentGetter.GetEnrichment(ctx, tags)
+}
@@ -5,6 +5,8 @@ type FileKind string
 
 const (
 	FileKindWhiteout = FileKind("whiteout")
+	FileKindKernel   = FileKind("kernel")
+	FileKindConfig   = FileKind("config")
 )
 
 // File represents interesing files that are found in the layer.
@@ -13,4 +15,10 @@ type File struct {
 	Path string
 	// Kind is what kind of file was found.
 	Kind FileKind
+	// Hash is the digest of the file's contents. Only populated by scanners
+	// that record contents.
+	Hash Digest
+	// Contents are the file's contents, if the scanner was configured to
+	// record them.
+	Contents []byte
 }
@@ -10,10 +10,30 @@ import (
 type coalescer struct {
 }
 
+// Coalesce implements [indexer.Coalescer].
+//
+// Only the standard library packages are reported, so their vulnerabilities
+// can be matched; the executables' module dependencies are left out. Every
+// executable is its own package database, so these are reported as-is.
 func (c *coalescer) Coalesce(ctx context.Context, ls []*indexer.LayerArtifacts) (*claircore.IndexReport, error) {
-	return &claircore.IndexReport{
+	ir := &claircore.IndexReport{
 		Environments: map[string][]*claircore.Environment{},
 		Packages:     map[string]*claircore.Package{},
 		Repositories: map[string]*claircore.Repository{},
-	}, nil
+	}
+	for _, l := range ls {
+		for _, pkg := range l.Pkgs {
+			if pkg.Name != stdlibName {
+				continue
+			}
+			ir.Packages[pkg.ID] = pkg
+			ir.Environments[pkg.ID] = []*claircore.Environment{
+				{
+					PackageDB:    pkg.PackageDB,
+					IntroducedIn: l.Hash,
+				},
+			}
+		}
+	}
+	return ir, nil
 }
@@ -0,0 +1,37 @@
+package gobin
+
+import (
+	"context"
+	"testing"
+
+	"github.com/quay/zlog"
+
+	"github.com/quay/claircore"
+	"github.com/quay/claircore/indexer"
+	"github.com/quay/claircore/test"
+)
+
+func TestCoalescer(t *testing.T) {
+	ctx := zlog.Test(context.Background(), t)
+	ls := []*indexer.LayerArtifacts{{
+		Hash: test.RandomSHA256Digest(t),
+		Pkgs: []*claircore.Package{
+			{ID: "1", Name: stdlibName, Version: "go1.20.3", PackageDB: "go:bin/app"},
+			{ID: "2", Name: "github.com/quay/zlog", Version: "v1.1.5", PackageDB: "go:bin/app"},
+		},
+	}}
+	var c coalescer
+	ir, err := c.Coalesce(ctx, ls)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := len(ir.Packages), 1; got != want {
+		t.Fatalf("got: %d packages, want: %d", got, want)
+	}
+	if _, ok := ir.Packages["1"]; !ok {
+		t.Error("standard library package missing")
+	}
+	if got, want := len(ir.Environments["1"]), 1; got != want {
+		t.Errorf("got: %d environments, want: %d", got, want)
+	}
+}
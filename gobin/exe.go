@@ -5,6 +5,8 @@ import (
 	"debug/buildinfo"
 	"errors"
 	"io"
+	"strconv"
+	"strings"
 	_ "unsafe" // for error linkname tricks
 
 	"github.com/quay/zlog"
@@ -35,15 +37,10 @@ func toPackages(ctx context.Context, out *[]*claircore.Package, p string, r io.R
 	ctx = zlog.ContextWithValues(ctx, "exe", p)
 	pkgdb := "go:" + p
 
-	*out = append(*out, &claircore.Package{
-		Kind:      claircore.BINARY,
-		Name:      "runtime",
-		Version:   bi.GoVersion,
-		PackageDB: pkgdb,
-	})
+	*out = append(*out, stdlibPackage(pkgdb, bi.GoVersion))
 	ev := zlog.Debug(ctx)
 	vs := map[string]string{
-		"runtime": bi.GoVersion,
+		stdlibName: bi.GoVersion,
 	}
 	*out = append(*out, &claircore.Package{
 		Kind:      claircore.BINARY,
@@ -70,3 +67,70 @@ func toPackages(ctx context.Context, out *[]*claircore.Package, p string, r io.R
 		Msg("analyzed exe")
 	return nil
 }
+
+// StdlibName is the name used for the package representing the Go standard
+// library and runtime. This is the name used in the Go vulnerability database.
+const stdlibName = `stdlib`
+
+// StdlibPackage returns the package for the standard library an executable was
+// built with, as reported by the toolchain version "v".
+//
+// Development toolchains have no release version, so the returned package has
+// no NormalizedVersion.
+func stdlibPackage(pkgdb, v string) *claircore.Package {
+	p := &claircore.Package{
+		Kind:      claircore.BINARY,
+		Name:      stdlibName,
+		Version:   v,
+		PackageDB: pkgdb,
+	}
+	if nv, ok := parseGoVersion(v); ok {
+		p.NormalizedVersion = nv
+	}
+	return p
+}
+
+// ParseGoVersion parses a toolchain version string like "go1.20.3" into a
+// semver Version.
+//
+// Versions like "go1.21rc2" are normalized to the release they precede.
+// Anything after a space (like a GOEXPERIMENT annotation) is ignored.
+// Development versions ("devel ...") report false.
+func parseGoVersion(s string) (v claircore.Version, ok bool) {
+	if i := strings.IndexByte(s, ' '); i != -1 {
+		s = s[:i]
+	}
+	if !strings.HasPrefix(s, "go") {
+		return v, false
+	}
+	s = s[2:]
+	v.Kind = `semver`
+	// Leave a leading epoch, like the OSV updater does.
+	for i := 1; i < 4 && s != ""; i++ {
+		end := 0
+		for end < len(s) && s[end] >= '0' && s[end] <= '9' {
+			end++
+		}
+		if end == 0 {
+			return claircore.Version{}, false
+		}
+		n, err := strconv.ParseInt(s[:end], 10, 32)
+		if err != nil {
+			return claircore.Version{}, false
+		}
+		v.V[i] = int32(n)
+		s = s[end:]
+		if strings.HasPrefix(s, ".") {
+			s = s[1:]
+			continue
+		}
+		break
+	}
+	switch {
+	case s == "":
+	case strings.HasPrefix(s, "rc"), strings.HasPrefix(s, "beta"):
+	default:
+		return claircore.Version{}, false
+	}
+	return v, true
+}
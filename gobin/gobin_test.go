@@ -134,7 +134,7 @@ func TestScanner(t *testing.T) {
 	// would be annoying.
 	for _, v := range vs {
 		switch {
-		case v.Name == "runtime":
+		case v.Name == stdlibName:
 			continue
 		case v.Version == "(devel)":
 			continue
@@ -0,0 +1,64 @@
+package gobin
+
+import (
+	"context"
+	"strings"
+
+	"github.com/quay/zlog"
+
+	"github.com/quay/claircore"
+	"github.com/quay/claircore/libvuln/driver"
+)
+
+// Matcher matches the Go standard library embedded in executables against
+// advisories for it, like the ones in the Go vulnerability database.
+//
+// Executables built by development toolchains are never reported as
+// vulnerable, as there's no release to compare against.
+type Matcher struct{}
+
+var _ driver.Matcher = (*Matcher)(nil)
+
+// Name implements [driver.Matcher].
+func (*Matcher) Name() string { return "gobin-stdlib" }
+
+// Filter implements [driver.Matcher].
+func (*Matcher) Filter(r *claircore.IndexRecord) bool {
+	return r.Package != nil &&
+		r.Package.Name == stdlibName &&
+		strings.HasPrefix(r.Package.PackageDB, "go:")
+}
+
+// Query implements [driver.Matcher].
+func (*Matcher) Query() []driver.MatchConstraint { return nil }
+
+// Vulnerable implements [driver.Matcher].
+func (*Matcher) Vulnerable(ctx context.Context, r *claircore.IndexRecord, v *claircore.Vulnerability) (bool, error) {
+	if v.Repo == nil || v.Repo.Name != "go" {
+		return false, nil
+	}
+	rv, ok := parseGoVersion(r.Package.Version)
+	if !ok {
+		zlog.Debug(ctx).
+			Str("package", r.Package.PackageDB).
+			Str("version", r.Package.Version).
+			Msg("not a release toolchain, skipping")
+		return false, nil
+	}
+	switch {
+	case v.Range != nil:
+		return v.Range.Contains(&rv), nil
+	case v.FixedInVersion != "":
+		fv, ok := parseGoVersion("go" + strings.TrimPrefix(v.FixedInVersion, "v"))
+		if !ok {
+			zlog.Warn(ctx).
+				Str("advisory", v.Name).
+				Str("fixed", v.FixedInVersion).
+				Msg("unable to parse fixed version")
+			return false, nil
+		}
+		return rv.Compare(&fv) == -1, nil
+	}
+	// No fix information means every version is affected.
+	return true, nil
+}
@@ -0,0 +1,93 @@
+package gobin
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/quay/zlog"
+
+	"github.com/quay/claircore"
+)
+
+func semver(maj, min, patch int32) claircore.Version {
+	v := claircore.Version{Kind: `semver`}
+	v.V[1], v.V[2], v.V[3] = maj, min, patch
+	return v
+}
+
+func TestParseGoVersion(t *testing.T) {
+	tt := []struct {
+		In   string
+		Want claircore.Version
+		OK   bool
+	}{
+		{In: "go1.20.3", Want: semver(1, 20, 3), OK: true},
+		{In: "go1.20", Want: semver(1, 20, 0), OK: true},
+		{In: "go1.21rc2", Want: semver(1, 21, 0), OK: true},
+		{In: "go1.19.9 X:boringcrypto", Want: semver(1, 19, 9), OK: true},
+		{In: "devel go1.21-3f8b04b Tue May 2 12:11:40 2023 +0000", OK: false},
+		{In: "1.20.3", OK: false},
+		{In: "go1.20.x", OK: false},
+	}
+	for _, tc := range tt {
+		got, ok := parseGoVersion(tc.In)
+		if ok != tc.OK {
+			t.Errorf("%q: ok: got: %v, want: %v", tc.In, ok, tc.OK)
+		}
+		if !cmp.Equal(got, tc.Want) {
+			t.Errorf("%q: %s", tc.In, cmp.Diff(got, tc.Want))
+		}
+	}
+}
+
+func TestMatcher(t *testing.T) {
+	ctx := zlog.Test(context.Background(), t)
+	goRepo := &claircore.Repository{Name: "go", URI: "https://pkg.go.dev/"}
+	fixed := &claircore.Vulnerability{
+		Name: "GO-2023-1753",
+		Repo: goRepo,
+		Range: &claircore.Range{
+			Upper: semver(1, 19, 9),
+		},
+	}
+	fixedInVersion := &claircore.Vulnerability{
+		Name:           "GO-2023-1752",
+		Repo:           goRepo,
+		FixedInVersion: "1.20.4",
+	}
+	other := &claircore.Vulnerability{
+		Name:           "CVE-0000-0000",
+		Repo:           &claircore.Repository{Name: "pypi"},
+		FixedInVersion: "1.20.4",
+	}
+	tt := []struct {
+		Version string
+		Vuln    *claircore.Vulnerability
+		Want    bool
+	}{
+		{Version: "go1.19.8", Vuln: fixed, Want: true},
+		{Version: "go1.19.9", Vuln: fixed, Want: false},
+		{Version: "go1.20.3", Vuln: fixedInVersion, Want: true},
+		{Version: "go1.20.4", Vuln: fixedInVersion, Want: false},
+		{Version: "go1.19.8", Vuln: other, Want: false},
+		{Version: "devel go1.21-3f8b04b Tue May 2 12:11:40 2023 +0000", Vuln: fixed, Want: false},
+	}
+	var m Matcher
+	for _, tc := range tt {
+		r := &claircore.IndexRecord{
+			Package: stdlibPackage("go:bin/test", tc.Version),
+		}
+		if !m.Filter(r) {
+			t.Errorf("%s: unexpectedly filtered", tc.Version)
+			continue
+		}
+		got, err := m.Vulnerable(ctx, r, tc.Vuln)
+		if err != nil {
+			t.Error(err)
+		}
+		if got != tc.Want {
+			t.Errorf("%s/%s: got: %v, want: %v", tc.Version, tc.Vuln.Name, got, tc.Want)
+		}
+	}
+}
@@ -0,0 +1,319 @@
+package gobin
+
+import (
+	"context"
+	"debug/elf"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/quay/zlog"
+
+	"github.com/quay/claircore"
+)
+
+// SymbolSource reports the symbols affected by Go advisories.
+type SymbolSource interface {
+	// AffectedSymbols returns the affected symbols for the advisory "id",
+	// keyed by package import path. Symbols are named the way the Go
+	// vulnerability database names them, e.g. "Get" or "Server.Serve".
+	//
+	// A nil map means the source knows of no symbols for the advisory, in
+	// which case the whole package is considered affected.
+	AffectedSymbols(ctx context.Context, id string) (map[string][]string, error)
+}
+
+// DefaultVulnDB is the URL of the public Go vulnerability database.
+//
+//doc:url matcher
+const DefaultVulnDB = `https://vuln.go.dev/`
+
+// VulnDB is a SymbolSource backed by a Go vulnerability database serving OSV
+// entries at "ID/$id.json", like [DefaultVulnDB].
+//
+// Entries are cached for the life of the VulnDB.
+type VulnDB struct {
+	// Client is used for requests. If nil, http.DefaultClient is used.
+	Client *http.Client
+	// URL is the root of the database. If empty, DefaultVulnDB is used.
+	URL string
+
+	cache sync.Map // map[string]map[string][]string
+}
+
+var _ SymbolSource = (*VulnDB)(nil)
+
+// AffectedSymbols implements SymbolSource.
+//
+// Advisories not in the database report no symbols.
+func (db *VulnDB) AffectedSymbols(ctx context.Context, id string) (map[string][]string, error) {
+	if v, ok := db.cache.Load(id); ok {
+		return v.(map[string][]string), nil
+	}
+	root := db.URL
+	if root == "" {
+		root = DefaultVulnDB
+	}
+	u, err := url.Parse(root)
+	if err != nil {
+		return nil, fmt.Errorf("gobin: bad vulndb url: %w", err)
+	}
+	u = u.JoinPath("ID", id+".json")
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("gobin: unable to construct request: %w", err)
+	}
+	c := db.Client
+	if c == nil {
+		c = http.DefaultClient
+	}
+	res, err := c.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("gobin: error requesting %q: %w", u.String(), err)
+	}
+	defer res.Body.Close()
+	var syms map[string][]string
+	switch res.StatusCode {
+	case http.StatusOK:
+		var entry struct {
+			Affected []struct {
+				Ecosystem struct {
+					Imports []struct {
+						Path    string   `json:"path"`
+						Symbols []string `json:"symbols"`
+					} `json:"imports"`
+				} `json:"ecosystem_specific"`
+			} `json:"affected"`
+		}
+		if err := json.NewDecoder(res.Body).Decode(&entry); err != nil {
+			return nil, fmt.Errorf("gobin: unable to decode %q: %w", u.String(), err)
+		}
+		for _, a := range entry.Affected {
+			for _, imp := range a.Ecosystem.Imports {
+				if syms == nil {
+					syms = make(map[string][]string)
+				}
+				syms[imp.Path] = append(syms[imp.Path], imp.Symbols...)
+			}
+		}
+	case http.StatusNotFound:
+	default:
+		return nil, fmt.Errorf("gobin: unexpected status requesting %q: %s", u.String(), res.Status)
+	}
+	db.cache.Store(id, syms)
+	return syms, nil
+}
+
+// Reachability filters vulnerabilities matched against Go executables down to
+// the ones whose affected symbols are present in the executable, similar to
+// the binary mode of govulncheck.
+//
+// This needs the layers of the image, so it's used after matching rather than
+// as part of it.
+type Reachability struct {
+	Symbols SymbolSource
+}
+
+// Filter removes vulnerabilities reported for packages found in Go executables
+// in the layer when none of the affected symbols are present in the
+// executable's symbol table.
+//
+// Executables that have had their symbol table stripped keep the
+// version-only matches. Vulnerabilities are only removed from
+// PackageVulnerabilities, and from Vulnerabilities once nothing refers to
+// them.
+func (r *Reachability) Filter(ctx context.Context, l *claircore.Layer, vr *claircore.VulnerabilityReport) error {
+	ctx = zlog.ContextWithValues(ctx,
+		"component", "gobin/Reachability.Filter",
+		"layer", l.Hash.String())
+	// Collect the affected packages, by executable.
+	exes := make(map[string][]string)
+	for id, vs := range vr.PackageVulnerabilities {
+		p, ok := vr.Packages[id]
+		if !ok || len(vs) == 0 || !strings.HasPrefix(p.PackageDB, "go:") {
+			continue
+		}
+		for _, env := range vr.Environments[id] {
+			if env.PackageDB == p.PackageDB && env.IntroducedIn.String() == l.Hash.String() {
+				exes[p.PackageDB] = append(exes[p.PackageDB], id)
+				break
+			}
+		}
+	}
+	if len(exes) == 0 {
+		return nil
+	}
+
+	sys, err := l.FS()
+	if err != nil {
+		return err
+	}
+	defer sys.Close()
+	var spool spoolfile
+	for db, ids := range exes {
+		p := strings.TrimPrefix(db, "go:")
+		ctx := zlog.ContextWithValues(ctx, "exe", p)
+		have, err := exeSymbols(sys, p, &spool)
+		switch {
+		case errors.Is(err, nil):
+		case errors.Is(err, errNoSymbols):
+			zlog.Debug(ctx).Msg("no symbol table, using version-only matches")
+			continue
+		default:
+			return err
+		}
+		for _, id := range ids {
+			keep := vr.PackageVulnerabilities[id][:0]
+			for _, vid := range vr.PackageVulnerabilities[id] {
+				ok, err := r.reachable(ctx, have, vr.Vulnerabilities[vid])
+				if err != nil {
+					return err
+				}
+				if ok {
+					keep = append(keep, vid)
+					continue
+				}
+				zlog.Debug(ctx).
+					Str("package", vr.Packages[id].Name).
+					Str("vulnerability", vid).
+					Msg("no affected symbols reachable")
+			}
+			vr.PackageVulnerabilities[id] = keep
+		}
+	}
+
+	// Remove vulnerabilities no longer referred to.
+	used := make(map[string]struct{})
+	for _, vs := range vr.PackageVulnerabilities {
+		for _, v := range vs {
+			used[v] = struct{}{}
+		}
+	}
+	for id := range vr.Vulnerabilities {
+		if _, ok := used[id]; !ok {
+			delete(vr.Vulnerabilities, id)
+		}
+	}
+	return nil
+}
+
+// Reachable reports whether any symbol affected by the vulnerability is in the
+// set "have".
+func (r *Reachability) reachable(ctx context.Context, have map[string]struct{}, v *claircore.Vulnerability) (bool, error) {
+	if v == nil {
+		return true, nil
+	}
+	syms, err := r.Symbols.AffectedSymbols(ctx, v.Name)
+	if err != nil {
+		return false, err
+	}
+	if syms == nil {
+		return true, nil
+	}
+	for path, ss := range syms {
+		if len(ss) == 0 {
+			// The whole package is affected; see if it's linked in at all.
+			if _, ok := have[path]; ok {
+				return true, nil
+			}
+			continue
+		}
+		for _, s := range ss {
+			if _, ok := have[path+"."+s]; ok {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// ErrNoSymbols is returned by exeSymbols if the executable has no symbol table,
+// or can't otherwise be inspected.
+var errNoSymbols = errors.New("no symbol table")
+
+// ExeSymbols returns the set of Go symbols in the executable at "p", in the
+// "import/path.Type.Method" form, along with every import path that has at
+// least one symbol.
+func exeSymbols(sys fs.FS, p string, spool *spoolfile) (map[string]struct{}, error) {
+	f, err := sys.Open(p)
+	switch {
+	case errors.Is(err, nil):
+	case errors.Is(err, fs.ErrNotExist):
+		// Not in this layer, so there's nothing to inspect.
+		return nil, errNoSymbols
+	default:
+		return nil, fmt.Errorf("gobin: unable to open %q: %w", p, err)
+	}
+	defer f.Close()
+	rd, ok := f.(io.ReaderAt)
+	if !ok {
+		if err := spool.Setup(); err != nil {
+			return nil, fmt.Errorf("gobin: unable to setup spool: %w", err)
+		}
+		sz, err := io.Copy(spool.File, f)
+		if err != nil {
+			return nil, fmt.Errorf("gobin: unable to spool %q: %w", p, err)
+		}
+		rd = io.NewSectionReader(spool.File, 0, sz)
+	}
+	exe, err := elf.NewFile(rd)
+	if err != nil {
+		// Not an ELF file; nothing to inspect.
+		return nil, errNoSymbols
+	}
+	defer exe.Close()
+	syms, err := exe.Symbols()
+	switch {
+	case errors.Is(err, nil):
+	case errors.Is(err, elf.ErrNoSymbols):
+		return nil, errNoSymbols
+	default:
+		return nil, fmt.Errorf("gobin: unable to read symbols from %q: %w", p, err)
+	}
+	out := make(map[string]struct{}, len(syms))
+	for _, s := range syms {
+		if elf.ST_TYPE(s.Info) != elf.STT_FUNC {
+			continue
+		}
+		path, name, ok := splitSymbol(s.Name)
+		if !ok {
+			continue
+		}
+		out[path] = struct{}{}
+		out[path+"."+name] = struct{}{}
+	}
+	if len(out) == 0 {
+		return nil, errNoSymbols
+	}
+	return out, nil
+}
+
+// SplitSymbol splits a linker symbol like "net/http.(*Server).Serve" into the
+// import path and the symbol name as used in the Go vulnerability database
+// ("net/http" and "Server.Serve").
+func splitSymbol(s string) (path, name string, ok bool) {
+	// The package path ends at the first dot after the last slash.
+	start := strings.LastIndexByte(s, '/') + 1
+	i := strings.IndexByte(s[start:], '.')
+	if i == -1 {
+		return "", "", false
+	}
+	path, name = s[:start+i], s[start+i+1:]
+	// Drop any type parameters.
+	if j := strings.IndexByte(name, '['); j != -1 {
+		if k := strings.LastIndexByte(name, ']'); k > j {
+			name = name[:j] + name[k+1:]
+		}
+	}
+	name = strings.NewReplacer("(*", "", "(", "", ")", "").Replace(name)
+	if path == "" || name == "" {
+		return "", "", false
+	}
+	return path, name, true
+}
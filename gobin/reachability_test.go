@@ -0,0 +1,198 @@
+package gobin
+
+import (
+	"archive/tar"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/quay/zlog"
+
+	"github.com/quay/claircore"
+)
+
+func TestSplitSymbol(t *testing.T) {
+	tt := []struct {
+		In         string
+		Path, Name string
+		OK         bool
+	}{
+		{In: "net/http.Get", Path: "net/http", Name: "Get", OK: true},
+		{In: "net/http.(*Server).Serve", Path: "net/http", Name: "Server.Serve", OK: true},
+		{In: "main.main", Path: "main", Name: "main", OK: true},
+		{In: "golang.org/x/net/html.Parse", Path: "golang.org/x/net/html", Name: "Parse", OK: true},
+		{In: "slices.Sort[go.shape.int]", Path: "slices", Name: "Sort", OK: true},
+		{In: "_start", OK: false},
+	}
+	for _, tc := range tt {
+		path, name, ok := splitSymbol(tc.In)
+		if ok != tc.OK || path != tc.Path || name != tc.Name {
+			t.Errorf("%q: got: (%q, %q, %v), want: (%q, %q, %v)",
+				tc.In, path, name, ok, tc.Path, tc.Name, tc.OK)
+		}
+	}
+}
+
+type staticSymbols map[string]map[string][]string
+
+func (s staticSymbols) AffectedSymbols(_ context.Context, id string) (map[string][]string, error) {
+	return s[id], nil
+}
+
+func TestReachability(t *testing.T) {
+	ctx := zlog.Test(context.Background(), t)
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("no go toolchain")
+	}
+	tmp := t.TempDir()
+	const prog = `package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+func main() { fmt.Println(strings.ToUpper("hello")) }
+`
+	for n, c := range map[string]string{
+		"go.mod":  "module example.com/reach\n\ngo 1.20\n",
+		"main.go": prog,
+	} {
+		if err := os.WriteFile(filepath.Join(tmp, n), []byte(c), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	build := func(name string, args ...string) string {
+		out := filepath.Join(tmp, name)
+		cmd := exec.CommandContext(ctx, "go", append([]string{"build", "-o", out}, args...)...)
+		cmd.Dir = tmp
+		cmd.Env = append(os.Environ(), "GOFLAGS=-mod=mod", "GOPROXY=off", "CGO_ENABLED=0")
+		if b, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("%v: %s", err, b)
+		}
+		return out
+	}
+	exes := map[string]string{
+		"bin/full":     build("full"),
+		"bin/stripped": build("stripped", "-ldflags=-s -w"),
+	}
+
+	// Write a layer with both executables.
+	tarname := filepath.Join(tmp, "layer.tar")
+	tf, err := os.Create(tarname)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tf.Close()
+	tw := tar.NewWriter(tf)
+	for name, p := range exes {
+		f, err := os.Open(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fi, err := f.Stat()
+		if err != nil {
+			t.Fatal(err)
+		}
+		hdr, err := tar.FileInfoHeader(fi, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		hdr.Name = name
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := io.Copy(tw, f); err != nil {
+			t.Fatal(err)
+		}
+		f.Close()
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	l := claircore.Layer{
+		Hash: claircore.MustParseDigest(`sha256:e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855`),
+		URI:  `file:///dev/null`,
+	}
+	l.SetLocal(tarname)
+
+	syms := staticSymbols{
+		"GO-REACHABLE":   {"strings": {"ToUpper"}},
+		"GO-UNREACHABLE": {"net/http": {"Server.Serve"}},
+		"GO-UNKNOWN":     nil,
+	}
+	report := func() *claircore.VulnerabilityReport {
+		vr := &claircore.VulnerabilityReport{
+			Packages:               map[string]*claircore.Package{},
+			Environments:           map[string][]*claircore.Environment{},
+			Vulnerabilities:        map[string]*claircore.Vulnerability{},
+			PackageVulnerabilities: map[string][]string{},
+		}
+		for id := range syms {
+			vr.Vulnerabilities[id] = &claircore.Vulnerability{ID: id, Name: id}
+		}
+		for i, name := range []string{"bin/full", "bin/stripped"} {
+			id := string(rune('1' + i))
+			vr.Packages[id] = stdlibPackage("go:"+name, "go1.20.1")
+			vr.Environments[id] = []*claircore.Environment{
+				{PackageDB: "go:" + name, IntroducedIn: l.Hash},
+			}
+			vr.PackageVulnerabilities[id] = []string{"GO-REACHABLE", "GO-UNKNOWN", "GO-UNREACHABLE"}
+		}
+		return vr
+	}
+
+	vr := report()
+	r := Reachability{Symbols: syms}
+	if err := r.Filter(ctx, &l, vr); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string][]string{
+		"1": {"GO-REACHABLE", "GO-UNKNOWN"},
+		// Stripped, so falls back to version-only matches.
+		"2": {"GO-REACHABLE", "GO-UNKNOWN", "GO-UNREACHABLE"},
+	}
+	if got := vr.PackageVulnerabilities; !cmp.Equal(got, want) {
+		t.Error(cmp.Diff(got, want))
+	}
+	if _, ok := vr.Vulnerabilities["GO-UNREACHABLE"]; !ok {
+		t.Error("vulnerability still referenced by stripped executable was removed")
+	}
+}
+
+func TestVulnDB(t *testing.T) {
+	ctx := zlog.Test(context.Background(), t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/ID/GO-2023-1571.json":
+			io.WriteString(w, `{"id":"GO-2023-1571","affected":[{"package":{"name":"stdlib","ecosystem":"Go"},`+
+				`"ecosystem_specific":{"imports":[{"path":"net/http","symbols":["Server.Serve","http2Server.ServeConn"]}]}}]}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	db := VulnDB{Client: srv.Client(), URL: srv.URL}
+
+	got, err := db.AffectedSymbols(ctx, "GO-2023-1571")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string][]string{"net/http": {"Server.Serve", "http2Server.ServeConn"}}
+	if !cmp.Equal(got, want) {
+		t.Error(cmp.Diff(got, want))
+	}
+	got, err = db.AffectedSymbols(ctx, "CVE-2023-0000")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != nil {
+		t.Errorf("got: %v, want: nil", got)
+	}
+}
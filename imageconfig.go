@@ -0,0 +1,35 @@
+package claircore
+
+import "strings"
+
+// ImageConfig is the runtime configuration recorded in an image's config,
+// as structured facts policy engines can evaluate alongside the packages.
+type ImageConfig struct {
+	// the user the image's processes run as, in any of the forms accepted by
+	// the image spec: "user", "uid", "user:group", "uid:gid", and so on.
+	// empty means the runtime's default, usually root.
+	User string `json:"user,omitempty"`
+	// the entrypoint and default arguments of the image's process
+	Entrypoint []string `json:"entrypoint,omitempty"`
+	Cmd        []string `json:"cmd,omitempty"`
+	// the exposed ports, in "port/protocol" form, sorted
+	ExposedPorts []string `json:"exposed_ports,omitempty"`
+	// the environment, in "KEY=value" form, in the order in the config
+	Env []string `json:"env,omitempty"`
+	// the working directory of the image's process
+	WorkingDir string `json:"working_dir,omitempty"`
+}
+
+// RunsAsRoot reports whether the image's processes run as the root user,
+// either because no user is set or because it's set to "root" or UID 0.
+//
+// Users given by a name other than "root" are assumed not to be root, as the
+// image's passwd database isn't consulted.
+func (c *ImageConfig) RunsAsRoot() bool {
+	u, _, _ := strings.Cut(c.User, ":")
+	switch u {
+	case "", "root", "0":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,190 @@
+package claircore
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"sort"
+
+	"github.com/quay/claircore/pkg/cpe"
+)
+
+// IndexDigestVersion is mixed into every digest returned by
+// IndexReport.Digest. It's changed whenever the set of fields included in the
+// digest changes, so digests computed by different versions of this package
+// never compare equal by accident.
+const indexDigestVersion = "claircore index digest v3\n"
+
+// Digest returns a stable digest of the contents of the report that matching
+// depends on. Reports with the same digest produce the same matches, so a
+// caller can use it to skip matching a report it has already seen.
+//
+// The digest covers every IndexRecord the report produces, which means every
+// package with an environment, paired with the distribution and each
+// repository of that environment, plus ActiveKernel and Files, as conditions
+// on vulnerabilities are evaluated against the files in the image. For these,
+// the included fields are:
+//
+//   - Package: Name, Version, Kind, PackageDB, NormalizedVersion, Module,
+//     Arch, CPE, Provides (in sorted order), and Confidence
+//   - Package.Source: Name, Version, Kind, Module
+//   - Distribution: DID, Name, Version, VersionCodeName, VersionID, Arch, CPE,
+//     and PrettyName
+//   - Repository: Name, Key, URI, and CPE
+//   - File: Path and Kind, in sorted order
+//
+// Everything else is excluded, notably: the manifest hash, state, success,
+// and error; database IDs for packages, distributions, and repositories; layer
+// information (Environment.IntroducedIn, Package.IntroducedIn and PresentIn);
+// Environment.PackageDB, as matchers only see the Package's; Depends;
+// ImageConfig; EmbeddedManifests; CanonicalPackages; and Stats. The order of
+// map iteration and of records doesn't affect the digest, but a record
+// appearing twice does. Files aren't part of the JSON encoding, so a decoded
+// report has a different digest than the original if the original had any.
+func (report *IndexReport) Digest() Digest {
+	rs := report.IndexRecords()
+	lines := make([][]byte, 0, len(rs))
+	for _, r := range rs {
+		b, err := json.Marshal(newDigestRecord(r))
+		if err != nil {
+			// None of the types can fail to marshal.
+			panic(err)
+		}
+		lines = append(lines, b)
+	}
+	sort.Slice(lines, func(i, j int) bool { return string(lines[i]) < string(lines[j]) })
+	files := make([][]byte, 0, len(report.Files))
+	for _, f := range report.Files {
+		b, err := json.Marshal(digestFile{Path: f.Path, Kind: f.Kind})
+		if err != nil {
+			panic(err)
+		}
+		files = append(files, b)
+	}
+	sort.Slice(files, func(i, j int) bool { return string(files[i]) < string(files[j]) })
+
+	h := sha256.New()
+	h.Write([]byte(indexDigestVersion))
+	h.Write([]byte(report.ActiveKernel))
+	h.Write([]byte{'\n'})
+	for _, l := range lines {
+		h.Write(l)
+		h.Write([]byte{'\n'})
+	}
+	for _, l := range files {
+		h.Write(l)
+		h.Write([]byte{'\n'})
+	}
+	d, err := NewDigest(SHA256, h.Sum(nil))
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+// DigestRecord is the form of an IndexRecord hashed by IndexReport.Digest.
+type digestRecord struct {
+	Name              string      `json:"name"`
+	Version           string      `json:"version"`
+	Kind              string      `json:"kind"`
+	PackageDB         string      `json:"package_db"`
+	NormalizedVersion *Version    `json:"normalized_version"`
+	Module            string      `json:"module"`
+	Arch              string      `json:"arch"`
+	CPE               string      `json:"cpe"`
+	Provides          []string    `json:"provides"`
+	Confidence        Confidence  `json:"confidence,omitempty"`
+	Source            *digestSrc  `json:"source"`
+	Distribution      *digestDist `json:"distribution"`
+	Repository        *digestRepo `json:"repository"`
+}
+
+type digestSrc struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	Kind    string `json:"kind"`
+	Module  string `json:"module"`
+}
+
+type digestDist struct {
+	DID             string `json:"did"`
+	Name            string `json:"name"`
+	Version         string `json:"version"`
+	VersionCodeName string `json:"version_code_name"`
+	VersionID       string `json:"version_id"`
+	Arch            string `json:"arch"`
+	CPE             string `json:"cpe"`
+	PrettyName      string `json:"pretty_name"`
+}
+
+type digestRepo struct {
+	Name string `json:"name"`
+	Key  string `json:"key"`
+	URI  string `json:"uri"`
+	CPE  string `json:"cpe"`
+}
+
+// DigestFile is the form of a File hashed by IndexReport.Digest.
+type digestFile struct {
+	Path string   `json:"path"`
+	Kind FileKind `json:"kind"`
+}
+
+func newDigestRecord(r *IndexRecord) *digestRecord {
+	p := r.Package
+	out := digestRecord{
+		Name:       p.Name,
+		Version:    p.Version,
+		Kind:       p.Kind,
+		PackageDB:  p.PackageDB,
+		Module:     p.Module,
+		Arch:       p.Arch,
+		CPE:        cpeString(p.CPE),
+		Confidence: p.Confidence,
+	}
+	if p.NormalizedVersion.Kind != "" {
+		v := p.NormalizedVersion
+		out.NormalizedVersion = &v
+	}
+	if len(p.Provides) != 0 {
+		out.Provides = append([]string(nil), p.Provides...)
+		sort.Strings(out.Provides)
+	}
+	if s := p.Source; s != nil {
+		out.Source = &digestSrc{
+			Name:    s.Name,
+			Version: s.Version,
+			Kind:    s.Kind,
+			Module:  s.Module,
+		}
+	}
+	if d := r.Distribution; d != nil {
+		out.Distribution = &digestDist{
+			DID:             d.DID,
+			Name:            d.Name,
+			Version:         d.Version,
+			VersionCodeName: d.VersionCodeName,
+			VersionID:       d.VersionID,
+			Arch:            d.Arch,
+			CPE:             cpeString(d.CPE),
+			PrettyName:      d.PrettyName,
+		}
+	}
+	if repo := r.Repository; repo != nil {
+		out.Repository = &digestRepo{
+			Name: repo.Name,
+			Key:  repo.Key,
+			URI:  repo.URI,
+			CPE:  cpeString(repo.CPE),
+		}
+	}
+	return &out
+}
+
+// CpeString returns the formatted string binding of the WFN, or the empty
+// string for the zero value.
+func cpeString(w cpe.WFN) string {
+	if w.Valid() != nil {
+		return ""
+	}
+	return w.BindFS()
+}
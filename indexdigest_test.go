@@ -0,0 +1,143 @@
+package claircore_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/quay/claircore"
+	"github.com/quay/claircore/pkg/cpe"
+)
+
+func TestIndexReportDigest(t *testing.T) {
+	layer := claircore.MustParseDigest(`sha256:` + "1111111111111111111111111111111111111111111111111111111111111111")
+	other := claircore.MustParseDigest(`sha256:` + "2222222222222222222222222222222222222222222222222222222222222222")
+	mk := func() *claircore.IndexReport {
+		return &claircore.IndexReport{
+			Hash:  layer,
+			State: "IndexFinished",
+			Packages: map[string]*claircore.Package{
+				"1": {
+					ID: "1", Name: "openssl", Version: "1.1.1k-7.el8", Kind: claircore.BINARY, Arch: "x86_64",
+					Source:   &claircore.Package{ID: "3", Name: "openssl", Version: "1.1.1k-7.el8", Kind: claircore.SOURCE},
+					Provides: []string{"libssl.so.1.1", "openssl-libs"},
+				},
+				"2": {ID: "2", Name: "zlib", Version: "1.2.11-18.el8", Kind: claircore.BINARY, Arch: "x86_64"},
+			},
+			Distributions: map[string]*claircore.Distribution{
+				"1": {ID: "1", DID: "rhel", Name: "Red Hat Enterprise Linux", VersionID: "8", CPE: cpe.MustUnbind("cpe:/o:redhat:enterprise_linux:8")},
+			},
+			Repositories: map[string]*claircore.Repository{
+				"1": {ID: "1", Name: "cpe:/o:redhat:enterprise_linux:8::baseos", Key: "rhel-cpe-repository"},
+			},
+			Environments: map[string][]*claircore.Environment{
+				"1": {{PackageDB: "/var/lib/rpm", IntroducedIn: layer, DistributionID: "1", RepositoryIDs: []string{"1"}}},
+				"2": {{PackageDB: "/var/lib/rpm", IntroducedIn: layer, DistributionID: "1", RepositoryIDs: []string{"1"}}},
+			},
+			Files: map[string]claircore.File{
+				"etc/httpd/conf/httpd.conf": {Path: "etc/httpd/conf/httpd.conf", Kind: claircore.FileKindConfig},
+			},
+			Success: true,
+		}
+	}
+	// The digest of a given report must not change unless the digest format
+	// is deliberately changed.
+	const golden = `sha256:c5ad9e4f4c8e4d4312f68c28408c9e13c6f26595f04f1700a7e7db7a094d5080`
+	want := mk().Digest()
+	if got := want.String(); got != golden {
+		t.Errorf("got: %v, want: %v", got, golden)
+	}
+
+	same := []struct {
+		Name   string
+		Modify func(*claircore.IndexReport)
+	}{
+		{"Hash", func(r *claircore.IndexReport) { r.Hash = other }},
+		{"State", func(r *claircore.IndexReport) { r.State = "ScanLayers"; r.Success = false; r.Err = "oops" }},
+		{"Layer", func(r *claircore.IndexReport) {
+			r.Environments["1"][0].IntroducedIn = other
+			r.Packages["1"].IntroducedIn = &other
+		}},
+		{"IDs", func(r *claircore.IndexReport) {
+			p := r.Packages["2"]
+			delete(r.Packages, "2")
+			p.ID = "20"
+			r.Packages["20"] = p
+			r.Environments["20"] = r.Environments["2"]
+			delete(r.Environments, "2")
+		}},
+		{"ProvidesOrder", func(r *claircore.IndexReport) {
+			r.Packages["1"].Provides = []string{"openssl-libs", "libssl.so.1.1"}
+		}},
+		{"EnvironmentPackageDB", func(r *claircore.IndexReport) { r.Environments["2"][0].PackageDB = "/usr/lib/sysimage/rpm" }},
+		{"Stats", func(r *claircore.IndexReport) { r.Stats = &claircore.IndexStats{Layers: 3} }},
+		{"FileContents", func(r *claircore.IndexReport) {
+			f := r.Files["etc/httpd/conf/httpd.conf"]
+			f.Contents = []byte("Listen 80\n")
+			r.Files["etc/httpd/conf/httpd.conf"] = f
+		}},
+	}
+	for _, tc := range same {
+		t.Run("Same"+tc.Name, func(t *testing.T) {
+			r := mk()
+			tc.Modify(r)
+			if got := r.Digest(); got.String() != want.String() {
+				t.Errorf("got: %v, want: %v", got, want)
+			}
+		})
+	}
+
+	differ := []struct {
+		Name   string
+		Modify func(*claircore.IndexReport)
+	}{
+		{"Version", func(r *claircore.IndexReport) { r.Packages["2"].Version = "1.2.11-19.el8" }},
+		{"Source", func(r *claircore.IndexReport) { r.Packages["1"].Source.Version = "1.1.1k-8.el8" }},
+		{"Provides", func(r *claircore.IndexReport) { r.Packages["1"].Provides = r.Packages["1"].Provides[:1] }},
+		{"Distribution", func(r *claircore.IndexReport) { r.Distributions["1"].VersionID = "9" }},
+		{"Repository", func(r *claircore.IndexReport) {
+			r.Repositories["1"].Name = "cpe:/a:redhat:enterprise_linux:8::appstream"
+		}},
+		{"Removed", func(r *claircore.IndexReport) { delete(r.Environments, "2") }},
+		{"Duplicated", func(r *claircore.IndexReport) {
+			r.Environments["2"] = append(r.Environments["2"], &claircore.Environment{PackageDB: "/other", DistributionID: "1", RepositoryIDs: []string{"1"}})
+		}},
+		{"ActiveKernel", func(r *claircore.IndexReport) { r.ActiveKernel = "4.18.0-477.el8.x86_64" }},
+		{"Confidence", func(r *claircore.IndexReport) { r.Packages["2"].Confidence = claircore.ConfidenceLow }},
+		{"PackageDB", func(r *claircore.IndexReport) { r.Packages["2"].PackageDB = "/usr/lib/sysimage/rpm" }},
+		{"Files", func(r *claircore.IndexReport) {
+			r.Files["boot/vmlinuz"] = claircore.File{Path: "boot/vmlinuz", Kind: claircore.FileKindKernel}
+		}},
+		{"FileKind", func(r *claircore.IndexReport) {
+			f := r.Files["etc/httpd/conf/httpd.conf"]
+			f.Kind = claircore.FileKindWhiteout
+			r.Files["etc/httpd/conf/httpd.conf"] = f
+		}},
+	}
+	for _, tc := range differ {
+		t.Run("Differ"+tc.Name, func(t *testing.T) {
+			r := mk()
+			tc.Modify(r)
+			if got := r.Digest(); got.String() == want.String() {
+				t.Errorf("got: %v, want a different digest", got)
+			}
+		})
+	}
+
+	t.Run("Roundtrip", func(t *testing.T) {
+		// Files aren't serialized, so leave them out on both sides.
+		in := mk()
+		in.Files = nil
+		want := in.Digest()
+		b, err := json.Marshal(in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var r claircore.IndexReport
+		if err := json.Unmarshal(b, &r); err != nil {
+			t.Fatal(err)
+		}
+		if got := r.Digest(); got.String() != want.String() {
+			t.Errorf("got: %v, want: %v", got, want)
+		}
+	})
+}
@@ -17,11 +17,17 @@ func coalesce(ctx context.Context, s *Controller) (State, error) {
 	defer cancel()
 	mu := sync.Mutex{}
 	reports := []*claircore.IndexReport{}
+	intro := newIntroductions()
+	distIn := make(distLayers)
+	pkgNames := make(map[string]struct{})
 	g := errgroup.Group{}
 	// dispatch a coalescer go routine for each ecosystem
 	for _, ecosystem := range s.Ecosystems {
 		artifacts := []*indexer.LayerArtifacts{}
 		pkgScanners, _ := ecosystem.PackageScanners(cctx)
+		for _, ps := range pkgScanners {
+			pkgNames[ps.Name()] = struct{}{}
+		}
 		distScanners, _ := ecosystem.DistributionScanners(cctx)
 		repoScanners, _ := ecosystem.RepositoryScanners(cctx)
 		fileScanners := []indexer.FileScanner{}
@@ -29,7 +35,7 @@ func coalesce(ctx context.Context, s *Controller) (State, error) {
 			fileScanners, _ = ecosystem.FileScanners(cctx)
 		}
 		// pack artifacts var
-		for _, layer := range s.manifest.Layers {
+		for i, layer := range s.manifest.Layers {
 			la := &indexer.LayerArtifacts{
 				Hash: layer.Hash,
 			}
@@ -42,6 +48,7 @@ func coalesce(ctx context.Context, s *Controller) (State, error) {
 				return Terminal, fmt.Errorf("failed to retrieve packages for %v: %w", layer.Hash, err)
 			}
 			la.Pkgs = append(la.Pkgs, pkgs...)
+			intro.Add(i, pkgs)
 			// get distributions from layer
 			vscnrs.DStoVS(distScanners) // method allocates new vscnr underlying array, clearing old contents
 			dists, err := s.Store.DistributionsByLayer(cctx, layer.Hash, vscnrs)
@@ -49,6 +56,7 @@ func coalesce(ctx context.Context, s *Controller) (State, error) {
 				return Terminal, fmt.Errorf("failed to retrieve distributions for %v: %w", layer.Hash, err)
 			}
 			la.Dist = append(la.Dist, dists...)
+			distIn.Add(i, dists)
 			// get repositories from layer
 			vscnrs.RStoVS(repoScanners)
 			repos, err := s.Store.RepositoriesByLayer(cctx, layer.Hash, vscnrs)
@@ -87,12 +95,80 @@ func coalesce(ctx context.Context, s *Controller) (State, error) {
 		return Terminal, err
 	}
 	s.report = MergeSR(s.report, reports)
+	distIn.Resolve(ctx, s.report, s.DistributionPreference, s.MultiDistribution)
+	if err := checkCoverage(ctx, s.report, pkgNames, s.StrictScannerCoverage, s.MultiDistribution); err != nil {
+		return Terminal, err
+	}
+	if len(s.manifest.Config) != 0 {
+		// A bad config shouldn't fail the index, as everything else in the
+		// report is still correct.
+		cfg, err := indexer.ScanImageConfig(s.manifest.Config)
+		if err != nil {
+			s.report.Warnings = append(s.report.Warnings, err.Error())
+		}
+		s.report.ImageConfig = cfg
+	}
 	for _, r := range s.Resolvers {
 		s.report = r.Resolve(ctx, s.report, s.manifest.Layers)
 	}
+	intro.Annotate(s.report, s.manifest.Layers)
 	return IndexManifest, nil
 }
 
+// Introductions tracks the earliest layer packages were seen in, both by
+// exact package and by name within a package database.
+type introductions struct {
+	byID   map[string]int
+	byName map[string]int
+}
+
+func newIntroductions() *introductions {
+	return &introductions{
+		byID:   make(map[string]int),
+		byName: make(map[string]int),
+	}
+}
+
+func nameKey(p *claircore.Package) string {
+	return p.PackageDB + "\x00" + p.Name
+}
+
+// Add records the packages as being found in the layer at index "i".
+func (in *introductions) Add(i int, pkgs []*claircore.Package) {
+	for _, p := range pkgs {
+		if j, ok := in.byID[p.ID]; !ok || i < j {
+			in.byID[p.ID] = i
+		}
+		k := nameKey(p)
+		if j, ok := in.byName[k]; !ok || i < j {
+			in.byName[k] = i
+		}
+	}
+}
+
+// Annotate sets the IntroducedIn and PresentIn members of every package in
+// the report.
+//
+// IntroducedIn is the earliest layer a package of the same name was found in
+// the same package database, and PresentIn is the earliest layer the exact
+// package was found in. These differ when a later layer changed the
+// package's version.
+func (in *introductions) Annotate(ir *claircore.IndexReport, layers []*claircore.Layer) {
+	for id, p := range ir.Packages {
+		i, ok := in.byID[id]
+		if !ok {
+			continue
+		}
+		present := layers[i].Hash
+		p.PresentIn = &present
+		if j, ok := in.byName[nameKey(p)]; ok && j < i {
+			i = j
+		}
+		intro := layers[i].Hash
+		p.IntroducedIn = &intro
+	}
+}
+
 // MergeSR merges IndexReports.
 //
 // source is the IndexReport that the indexer is working on.
@@ -117,6 +193,10 @@ func MergeSR(source *claircore.IndexReport, merge []*claircore.IndexReport) *cla
 		for k, v := range ir.Files {
 			source.Files[k] = v
 		}
+
+		if ir.ActiveKernel != "" {
+			source.ActiveKernel = ir.ActiveKernel
+		}
 	}
 	return source
 }
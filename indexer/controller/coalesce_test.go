@@ -2,9 +2,16 @@ package controller
 
 import (
 	"context"
+	"errors"
 	"testing"
 
+	"github.com/golang/mock/gomock"
+	"github.com/google/go-cmp/cmp"
+	"github.com/quay/zlog"
+
+	"github.com/quay/claircore"
 	"github.com/quay/claircore/indexer"
+	mock_indexer "github.com/quay/claircore/test/mock/indexer"
 )
 
 // TestCoalesce confirms when no error is encountered
@@ -42,3 +49,387 @@ func TestCoalesce(t *testing.T) {
 		})
 	}
 }
+
+// TestCoalesceIntroducedIn confirms that packages are annotated with the
+// earliest layer they were found in, regardless of which layer the coalescer
+// reports them from, and the earliest layer their final version was found in.
+func TestCoalesceIntroducedIn(t *testing.T) {
+	ctx := zlog.Test(context.Background(), t)
+	ctrl := gomock.NewController(t)
+	layers := []*claircore.Layer{
+		{Hash: claircore.MustParseDigest(`sha256:` + "1111111111111111111111111111111111111111111111111111111111111111")},
+		{Hash: claircore.MustParseDigest(`sha256:` + "2222222222222222222222222222222222222222222222222222222222222222")},
+		{Hash: claircore.MustParseDigest(`sha256:` + "3333333333333333333333333333333333333333333333333333333333333333")},
+	}
+	byLayer := map[string][]*claircore.Package{
+		layers[0].Hash.String(): {{ID: "a1", Name: "a", Version: "1"}},
+		layers[1].Hash.String(): {{ID: "a2", Name: "a", Version: "2"}, {ID: "b", Name: "b"}},
+		layers[2].Hash.String(): {{ID: "a2", Name: "a", Version: "2"}, {ID: "b", Name: "b"}},
+	}
+
+	store := mock_indexer.NewMockStore(ctrl)
+	store.EXPECT().PackagesByLayer(gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, d claircore.Digest, _ indexer.VersionedScanners) ([]*claircore.Package, error) {
+			return byLayer[d.String()], nil
+		}).Times(len(layers))
+	store.EXPECT().DistributionsByLayer(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, nil).Times(len(layers))
+	store.EXPECT().RepositoriesByLayer(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, nil).Times(len(layers))
+	store.EXPECT().FilesByLayer(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, nil).Times(len(layers))
+
+	ps := mock_indexer.NewMockPackageScanner(ctrl)
+	ps.EXPECT().Name().Return("mock").AnyTimes()
+	ps.EXPECT().Version().Return("1").AnyTimes()
+	ps.EXPECT().Kind().Return("package").AnyTimes()
+	co := mock_indexer.NewMockCoalescer(ctrl)
+	co.EXPECT().Coalesce(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, ls []*indexer.LayerArtifacts) (*claircore.IndexReport, error) {
+			// Report everything as coming from the last layer.
+			ir := &claircore.IndexReport{Packages: map[string]*claircore.Package{}}
+			for _, p := range ls[len(ls)-1].Pkgs {
+				ir.Packages[p.ID] = p
+			}
+			return ir, nil
+		})
+
+	c := New(&indexer.Options{
+		Store: store,
+		Ecosystems: []*indexer.Ecosystem{{
+			Name: "mock",
+			PackageScanners: func(context.Context) ([]indexer.PackageScanner, error) {
+				return []indexer.PackageScanner{ps}, nil
+			},
+			DistributionScanners: func(context.Context) ([]indexer.DistributionScanner, error) { return nil, nil },
+			RepositoryScanners:   func(context.Context) ([]indexer.RepositoryScanner, error) { return nil, nil },
+			Coalescer:            func(context.Context) (indexer.Coalescer, error) { return co, nil },
+		}},
+	})
+	c.manifest = &claircore.Manifest{Layers: layers}
+
+	if _, err := coalesce(ctx, c); err != nil {
+		t.Fatal(err)
+	}
+	for id, want := range map[string]struct{ Introduced, Present claircore.Digest }{
+		"a2": {Introduced: layers[0].Hash, Present: layers[1].Hash},
+		"b":  {Introduced: layers[1].Hash, Present: layers[1].Hash},
+	} {
+		p, ok := c.report.Packages[id]
+		if !ok {
+			t.Errorf("missing package %q", id)
+			continue
+		}
+		if got := p.IntroducedIn; got == nil || got.String() != want.Introduced.String() {
+			t.Errorf("%s: introduced in: got: %v, want: %v", id, got, want.Introduced)
+		}
+		if got := p.PresentIn; got == nil || got.String() != want.Present.String() {
+			t.Errorf("%s: present in: got: %v, want: %v", id, got, want.Present)
+		}
+	}
+}
+
+// TestCoalesceDistribution confirms that when more than one distribution is
+// found, every environment is pointed at the one chosen for matching.
+func TestCoalesceDistribution(t *testing.T) {
+	ctx := zlog.Test(context.Background(), t)
+	layers := []*claircore.Layer{
+		{Hash: claircore.MustParseDigest(`sha256:` + "1111111111111111111111111111111111111111111111111111111111111111")},
+		{Hash: claircore.MustParseDigest(`sha256:` + "2222222222222222222222222222222222222222222222222222222222222222")},
+		{Hash: claircore.MustParseDigest(`sha256:` + "3333333333333333333333333333333333333333333333333333333333333333")},
+	}
+	debian := &claircore.Distribution{ID: "1", DID: "debian", PrettyName: "Debian GNU/Linux 12 (bookworm)"}
+	alpine := &claircore.Distribution{ID: "2", DID: "alpine", PrettyName: "Alpine Linux v3.18"}
+	// The base image is debian, and a later layer copies in an alpine
+	// os-release file.
+	byLayer := map[string][]*claircore.Distribution{
+		layers[0].Hash.String(): {debian},
+		layers[2].Hash.String(): {alpine},
+	}
+
+	tt := []struct {
+		Name  string
+		Prefs []string
+		Multi bool
+		Want  string
+		// Envs is the expected distribution of each package, if not all
+		// of them are expected to be pointed at Want.
+		Envs map[string]string
+	}{
+		{Name: "Topmost", Want: alpine.ID},
+		{Name: "Preference", Prefs: []string{"rhel", "Debian", "alpine"}, Want: debian.ID},
+		{Name: "Unmatched", Prefs: []string{"rhel"}, Want: alpine.ID},
+		{
+			Name:  "Multi",
+			Multi: true,
+			Want:  alpine.ID,
+			Envs:  map[string]string{"a": debian.ID, "b": alpine.ID, "c": ""},
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			store := mock_indexer.NewMockStore(ctrl)
+			store.EXPECT().PackagesByLayer(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, nil).Times(len(layers))
+			store.EXPECT().DistributionsByLayer(gomock.Any(), gomock.Any(), gomock.Any()).
+				DoAndReturn(func(_ context.Context, d claircore.Digest, _ indexer.VersionedScanners) ([]*claircore.Distribution, error) {
+					return byLayer[d.String()], nil
+				}).Times(len(layers))
+			store.EXPECT().RepositoriesByLayer(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, nil).Times(len(layers))
+			store.EXPECT().FilesByLayer(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, nil).Times(len(layers))
+			co := mock_indexer.NewMockCoalescer(ctrl)
+			co.EXPECT().Coalesce(gomock.Any(), gomock.Any()).
+				DoAndReturn(func(_ context.Context, ls []*indexer.LayerArtifacts) (*claircore.IndexReport, error) {
+					// Associate packages with the nearest distribution, like
+					// the linux coalescer.
+					return &claircore.IndexReport{
+						Packages: map[string]*claircore.Package{
+							"a": {ID: "a", Name: "a"},
+							"b": {ID: "b", Name: "b"},
+							"c": {ID: "c", Name: "c"},
+						},
+						Environments: map[string][]*claircore.Environment{
+							"a": {{DistributionID: debian.ID}},
+							"b": {{DistributionID: alpine.ID}},
+							"c": {{}},
+						},
+						Distributions: map[string]*claircore.Distribution{
+							debian.ID: debian,
+							alpine.ID: alpine,
+						},
+					}, nil
+				})
+
+			c := New(&indexer.Options{
+				Store: store,
+				Ecosystems: []*indexer.Ecosystem{{
+					Name:                 "mock",
+					PackageScanners:      func(context.Context) ([]indexer.PackageScanner, error) { return nil, nil },
+					DistributionScanners: func(context.Context) ([]indexer.DistributionScanner, error) { return nil, nil },
+					RepositoryScanners:   func(context.Context) ([]indexer.RepositoryScanner, error) { return nil, nil },
+					Coalescer:            func(context.Context) (indexer.Coalescer, error) { return co, nil },
+				}},
+				DistributionPreference: tc.Prefs,
+				MultiDistribution:      tc.Multi,
+			})
+			c.manifest = &claircore.Manifest{Layers: layers}
+
+			if _, err := coalesce(ctx, c); err != nil {
+				t.Fatal(err)
+			}
+			if got, want := c.report.PrimaryDistribution, tc.Want; got != want {
+				t.Errorf("primary distribution: got: %q, want: %q", got, want)
+			}
+			if got, want := len(c.report.Distributions), 2; got != want {
+				t.Errorf("distributions: got: %d, want: %d", got, want)
+			}
+			envs := tc.Envs
+			if envs == nil {
+				envs = map[string]string{"a": tc.Want, "b": tc.Want, "c": ""}
+			}
+			for id, want := range envs {
+				if got := c.report.Environments[id][0].DistributionID; got != want {
+					t.Errorf("%s: got: %q, want: %q", id, got, want)
+				}
+			}
+		})
+	}
+}
+
+// TestCoalesceCoverage confirms that a detected distribution without a
+// configured package scanner is reported.
+func TestCoalesceCoverage(t *testing.T) {
+	ctx := zlog.Test(context.Background(), t)
+	layers := []*claircore.Layer{
+		{Hash: claircore.MustParseDigest(`sha256:` + "1111111111111111111111111111111111111111111111111111111111111111")},
+	}
+	rhel := &claircore.Distribution{ID: "1", DID: "rhel", VersionID: "9"}
+
+	tt := []struct {
+		Name     string
+		Scanner  string
+		Strict   bool
+		Warnings int
+		Err      bool
+	}{
+		{Name: "Configured", Scanner: "rpm"},
+		{Name: "Missing", Scanner: "dpkg", Warnings: 1},
+		{Name: "Strict", Scanner: "dpkg", Strict: true, Err: true},
+	}
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			store := mock_indexer.NewMockStore(ctrl)
+			store.EXPECT().PackagesByLayer(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, nil).Times(len(layers))
+			store.EXPECT().DistributionsByLayer(gomock.Any(), gomock.Any(), gomock.Any()).
+				Return([]*claircore.Distribution{rhel}, nil).Times(len(layers))
+			store.EXPECT().RepositoriesByLayer(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, nil).Times(len(layers))
+			store.EXPECT().FilesByLayer(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, nil).Times(len(layers))
+			co := mock_indexer.NewMockCoalescer(ctrl)
+			co.EXPECT().Coalesce(gomock.Any(), gomock.Any()).Return(&claircore.IndexReport{
+				Distributions: map[string]*claircore.Distribution{rhel.ID: rhel},
+			}, nil)
+			ps := mock_indexer.NewMockPackageScanner(ctrl)
+			ps.EXPECT().Name().Return(tc.Scanner).AnyTimes()
+			ps.EXPECT().Version().Return("1").AnyTimes()
+			ps.EXPECT().Kind().Return("package").AnyTimes()
+
+			c := New(&indexer.Options{
+				Store: store,
+				Ecosystems: []*indexer.Ecosystem{{
+					Name: "mock",
+					PackageScanners: func(context.Context) ([]indexer.PackageScanner, error) {
+						return []indexer.PackageScanner{ps}, nil
+					},
+					DistributionScanners: func(context.Context) ([]indexer.DistributionScanner, error) { return nil, nil },
+					RepositoryScanners:   func(context.Context) ([]indexer.RepositoryScanner, error) { return nil, nil },
+					Coalescer:            func(context.Context) (indexer.Coalescer, error) { return co, nil },
+				}},
+				StrictScannerCoverage: tc.Strict,
+			})
+			c.manifest = &claircore.Manifest{Layers: layers}
+
+			_, err := coalesce(ctx, c)
+			t.Log(err)
+			switch {
+			case tc.Err && !errors.Is(err, indexer.ErrMissingScanner):
+				t.Errorf("got: %v, want: %v", err, indexer.ErrMissingScanner)
+			case !tc.Err && err != nil:
+				t.Fatal(err)
+			}
+			if got, want := len(c.report.Warnings), tc.Warnings; got != want {
+				t.Errorf("warnings: got: %q, want: %d", c.report.Warnings, want)
+			}
+		})
+	}
+}
+
+// TestCoverageOrder confirms that the Warnings for several distributions
+// don't depend on map iteration order.
+func TestCoverageOrder(t *testing.T) {
+	ctx := zlog.Test(context.Background(), t)
+	mk := func() *claircore.IndexReport {
+		return &claircore.IndexReport{
+			Distributions: map[string]*claircore.Distribution{
+				"1": {ID: "1", DID: "ubuntu"},
+				"2": {ID: "2", DID: "alpine"},
+				"3": {ID: "3", DID: "rhel"},
+				"4": {ID: "4", DID: "debian"},
+			},
+		}
+	}
+	ir := mk()
+	if err := checkCoverage(ctx, ir, nil, false, true); err != nil {
+		t.Fatal(err)
+	}
+	want := ir.Warnings
+	if len(want) != 4 {
+		t.Fatalf("got: %q, want 4 warnings", want)
+	}
+	for i := 0; i < 10; i++ {
+		ir := mk()
+		if err := checkCoverage(ctx, ir, nil, false, true); err != nil {
+			t.Fatal(err)
+		}
+		if !cmp.Equal(ir.Warnings, want) {
+			t.Error(cmp.Diff(want, ir.Warnings))
+		}
+	}
+}
+
+// TestCoalesceMinimal confirms that an image without a distribution, like a
+// busybox image, is tagged as minimal.
+func TestCoalesceMinimal(t *testing.T) {
+	ctx := zlog.Test(context.Background(), t)
+	layers := []*claircore.Layer{
+		{Hash: claircore.MustParseDigest(`sha256:` + "1111111111111111111111111111111111111111111111111111111111111111")},
+	}
+	ctrl := gomock.NewController(t)
+	store := mock_indexer.NewMockStore(ctrl)
+	store.EXPECT().PackagesByLayer(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, nil).Times(len(layers))
+	store.EXPECT().DistributionsByLayer(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, nil).Times(len(layers))
+	store.EXPECT().RepositoriesByLayer(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, nil).Times(len(layers))
+	store.EXPECT().FilesByLayer(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, nil).Times(len(layers))
+	co := mock_indexer.NewMockCoalescer(ctrl)
+	co.EXPECT().Coalesce(gomock.Any(), gomock.Any()).Return(&claircore.IndexReport{}, nil)
+
+	c := New(&indexer.Options{
+		Store: store,
+		Ecosystems: []*indexer.Ecosystem{{
+			Name:                 "mock",
+			PackageScanners:      func(context.Context) ([]indexer.PackageScanner, error) { return nil, nil },
+			DistributionScanners: func(context.Context) ([]indexer.DistributionScanner, error) { return nil, nil },
+			RepositoryScanners:   func(context.Context) ([]indexer.RepositoryScanner, error) { return nil, nil },
+			Coalescer:            func(context.Context) (indexer.Coalescer, error) { return co, nil },
+		}},
+	})
+	c.manifest = &claircore.Manifest{Layers: layers}
+
+	if _, err := coalesce(ctx, c); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := c.report.DistributionTag, claircore.DistributionMinimal; got != want {
+		t.Errorf("got: %q, want: %q", got, want)
+	}
+	if got := c.report.Warnings; len(got) != 0 {
+		t.Errorf("unexpected warnings: %q", got)
+	}
+}
+
+func TestCoalesceImageConfig(t *testing.T) {
+	ctx := zlog.Test(context.Background(), t)
+	layers := []*claircore.Layer{
+		{Hash: claircore.MustParseDigest(`sha256:` + "1111111111111111111111111111111111111111111111111111111111111111")},
+	}
+	tt := []struct {
+		Name     string
+		Config   string
+		Want     *claircore.ImageConfig
+		Warnings int
+	}{
+		{Name: "None"},
+		{
+			Name:   "Root",
+			Config: `{"config":{"Entrypoint":["/bin/sh","-c"],"ExposedPorts":{"22/tcp":{}}}}`,
+			Want: &claircore.ImageConfig{
+				Entrypoint:   []string{"/bin/sh", "-c"},
+				ExposedPorts: []string{"22/tcp"},
+			},
+		},
+		{Name: "Malformed", Config: `{"config":`, Warnings: 1},
+	}
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			store := mock_indexer.NewMockStore(ctrl)
+			store.EXPECT().PackagesByLayer(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, nil).Times(len(layers))
+			store.EXPECT().DistributionsByLayer(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, nil).Times(len(layers))
+			store.EXPECT().RepositoriesByLayer(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, nil).Times(len(layers))
+			store.EXPECT().FilesByLayer(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, nil).Times(len(layers))
+			co := mock_indexer.NewMockCoalescer(ctrl)
+			co.EXPECT().Coalesce(gomock.Any(), gomock.Any()).Return(&claircore.IndexReport{}, nil)
+
+			c := New(&indexer.Options{
+				Store: store,
+				Ecosystems: []*indexer.Ecosystem{{
+					Name:                 "mock",
+					PackageScanners:      func(context.Context) ([]indexer.PackageScanner, error) { return nil, nil },
+					DistributionScanners: func(context.Context) ([]indexer.DistributionScanner, error) { return nil, nil },
+					RepositoryScanners:   func(context.Context) ([]indexer.RepositoryScanner, error) { return nil, nil },
+					Coalescer:            func(context.Context) (indexer.Coalescer, error) { return co, nil },
+				}},
+			})
+			c.manifest = &claircore.Manifest{Layers: layers}
+			if tc.Config != "" {
+				c.manifest.Config = []byte(tc.Config)
+			}
+
+			if _, err := coalesce(ctx, c); err != nil {
+				t.Fatal(err)
+			}
+			if got := c.report.ImageConfig; !cmp.Equal(got, tc.Want) {
+				t.Error(cmp.Diff(tc.Want, got))
+			}
+			if got, want := len(c.report.Warnings), tc.Warnings; got != want {
+				t.Errorf("warnings: got: %q, want: %d", c.report.Warnings, want)
+			}
+		})
+	}
+}
@@ -34,6 +34,7 @@ type Controller struct {
 func New(options *indexer.Options) *Controller {
 	// fully init any maps and arrays
 	scanRes := &claircore.IndexReport{
+		SchemaVersion: claircore.ReportSchemaVersion,
 		Packages:      map[string]*claircore.Package{},
 		Environments:  map[string][]*claircore.Environment{},
 		Distributions: map[string]*claircore.Distribution{},
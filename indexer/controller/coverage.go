@@ -0,0 +1,85 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/quay/zlog"
+
+	"github.com/quay/claircore"
+	"github.com/quay/claircore/indexer"
+)
+
+// DistPackageScanners maps distribution IDs (the "ID" field of os-release) to
+// the names of the package scanners able to find that distribution's
+// packages. Distributions not listed here aren't checked.
+var distPackageScanners = map[string][]string{
+	"alpine":              {"apk"},
+	"almalinux":           {"rpm"},
+	"amzn":                {"rpm"},
+	"centos":              {"rpm"},
+	"debian":              {"dpkg", "dpkg-distroless"},
+	"fedora":              {"rpm"},
+	"ol":                  {"rpm"},
+	"opensuse":            {"rpm"},
+	"opensuse-leap":       {"rpm"},
+	"opensuse-tumbleweed": {"rpm"},
+	"photon":              {"rpm"},
+	"rhel":                {"rpm"},
+	"rocky":               {"rpm"},
+	"sles":                {"rpm"},
+	"ubuntu":              {"dpkg"},
+}
+
+// CheckCoverage makes sure a package scanner for the report's distribution
+// was configured, so a misconfigured indexer doesn't produce a report that
+// looks clean only because the distribution's packages were never looked
+// for.
+//
+// Only the PrimaryDistribution is checked if there is one, as other
+// distributions are usually leftovers from a builder stage, unless "multi" is
+// set, in which case every distribution is checked, in DID order. A missing
+// scanner is recorded in the report's Warnings, or reported as an error
+// wrapping indexer.ErrMissingScanner if "strict" is set.
+func checkCoverage(ctx context.Context, ir *claircore.IndexReport, pkgScanners map[string]struct{}, strict, multi bool) error {
+	ds := make([]*claircore.Distribution, 0, 1)
+	if d, ok := ir.Distributions[ir.PrimaryDistribution]; ok && !multi {
+		ds = append(ds, d)
+	} else {
+		for _, d := range ir.Distributions {
+			ds = append(ds, d)
+		}
+		// Keep the Warnings in the same order from run to run.
+		sort.Slice(ds, func(i, j int) bool {
+			if ds[i].DID != ds[j].DID {
+				return ds[i].DID < ds[j].DID
+			}
+			return ds[i].ID < ds[j].ID
+		})
+	}
+Dist:
+	for _, d := range ds {
+		want, ok := distPackageScanners[strings.ToLower(d.DID)]
+		if !ok {
+			continue
+		}
+		for _, n := range want {
+			if _, ok := pkgScanners[n]; ok {
+				continue Dist
+			}
+		}
+		msg := fmt.Sprintf("no package scanner configured for distribution %q (want one of: %s)",
+			d.DID, strings.Join(want, ", "))
+		if strict {
+			return fmt.Errorf("%w: %s", indexer.ErrMissingScanner, msg)
+		}
+		zlog.Warn(ctx).
+			Str("distribution", d.DID).
+			Strs("want", want).
+			Msg("no package scanner configured for distribution")
+		ir.Warnings = append(ir.Warnings, msg)
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package controller
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/quay/zlog"
+
+	"github.com/quay/claircore"
+)
+
+// DistLayers tracks the topmost layer each distribution was found in, by
+// distribution ID.
+type distLayers map[string]int
+
+// Add records the distributions as being found in the layer at index "i".
+func (dl distLayers) Add(i int, ds []*claircore.Distribution) {
+	for _, d := range ds {
+		if j, ok := dl[d.ID]; !ok || i > j {
+			dl[d.ID] = i
+		}
+	}
+}
+
+// ResolveDistribution picks the distribution packages in the report are
+// matched against and points every environment with a distribution at it.
+//
+// Images built in multiple stages can carry an os-release file from a
+// builder stage, making more than one distribution show up. The first
+// distribution whose DID is listed in "prefs" wins, with the order of "prefs"
+// deciding between them; otherwise, the one found in the topmost layer does.
+// All the distributions are kept in the report, and the chosen one is
+// recorded as the PrimaryDistribution.
+//
+// If "multi" is set, environments keep the distribution the coalescer
+// attributed them to, so an image legitimately containing packages from
+// more than one distribution has each package matched against its own. The
+// PrimaryDistribution is still recorded.
+//
+// If there's no distribution at all, as in a busybox or scratch image, the
+// report is tagged as claircore.DistributionMinimal instead.
+func (dl distLayers) Resolve(ctx context.Context, ir *claircore.IndexReport, prefs []string, multi bool) {
+	if len(ir.Distributions) == 0 {
+		ir.DistributionTag = claircore.DistributionMinimal
+		return
+	}
+	ds := make([]*claircore.Distribution, 0, len(ir.Distributions))
+	for _, d := range ir.Distributions {
+		ds = append(ds, d)
+	}
+	// Sort topmost first, using the ID to make ties deterministic.
+	sort.Slice(ds, func(i, j int) bool {
+		li, lj := dl.layer(ds[i].ID), dl.layer(ds[j].ID)
+		if li != lj {
+			return li > lj
+		}
+		return ds[i].ID < ds[j].ID
+	})
+	chosen := ds[0]
+pref:
+	for _, p := range prefs {
+		for _, d := range ds {
+			if strings.EqualFold(d.DID, p) {
+				chosen = d
+				break pref
+			}
+		}
+	}
+	ir.PrimaryDistribution = chosen.ID
+	if len(ds) == 1 {
+		return
+	}
+	zlog.Info(ctx).
+		Int("count", len(ds)).
+		Str("chosen", chosen.PrettyName).
+		Str("id", chosen.ID).
+		Bool("multi", multi).
+		Msg("multiple distributions found")
+	if multi {
+		return
+	}
+	for _, envs := range ir.Environments {
+		for _, env := range envs {
+			if env.DistributionID != "" {
+				env.DistributionID = chosen.ID
+			}
+		}
+	}
+}
+
+// Layer reports the topmost layer the distribution "id" was found in, or -1
+// if it wasn't seen.
+func (dl distLayers) layer(id string) int {
+	if i, ok := dl[id]; ok {
+		return i
+	}
+	return -1
+}
@@ -12,10 +12,20 @@ import (
 func scanLayers(ctx context.Context, c *Controller) (State, error) {
 	zlog.Info(ctx).Msg("layers scan start")
 	defer zlog.Info(ctx).Msg("layers scan done")
-	err := c.LayerScanner.Scan(ctx, c.manifest.Hash, c.manifest.Layers)
+	stats, err := c.LayerScanner.ScanStats(ctx, c.manifest.Hash, c.manifest.Layers)
 	if err != nil {
 		return Terminal, fmt.Errorf("failed to scan all layer contents: %w", err)
 	}
-	zlog.Debug(ctx).Msg("layers scan ok")
+	c.report.Stats = stats
+	// Truncated layers aren't marked as scanned, so every report using them
+	// carries the warning.
+	for _, d := range stats.LayersTruncated {
+		c.report.Warnings = append(c.report.Warnings,
+			fmt.Sprintf("layer %s has more results than allowed; some were not recorded", d))
+	}
+	zlog.Debug(ctx).
+		Int("layers_scanned", stats.LayersScanned).
+		Int64("bytes", stats.Bytes).
+		Msg("layers scan ok")
 	return Coalesce, nil
 }
@@ -1,12 +1,21 @@
 package controller
 
 import (
+	"archive/tar"
+	"bytes"
 	"context"
 	"crypto/sha256"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/golang/mock/gomock"
+	"github.com/google/go-cmp/cmp"
 	"github.com/quay/claircore"
 	"github.com/quay/zlog"
 
@@ -43,7 +52,8 @@ func TestScanLayers(t *testing.T) {
 			ctx = zlog.Test(ctx, t)
 			s := table.mock(t)
 			opts := &indexer.Options{
-				Store: s,
+				Store:           s,
+				AllowNoScanners: true,
 			}
 			scnr := New(opts)
 			var err error
@@ -83,8 +93,8 @@ func TestScanNoErrors(t *testing.T) {
 	mock_ps.EXPECT().Version().AnyTimes().Return("1")
 	mock_store.EXPECT().LayerScanned(gomock.Any(), layers[0].Hash, mock_ps).Return(false, nil)
 	mock_store.EXPECT().LayerScanned(gomock.Any(), layers[1].Hash, mock_ps).Return(false, nil)
-	mock_store.EXPECT().SetLayerScanned(gomock.Any(), layers[0].Hash, mock_ps).Return(nil)
-	mock_store.EXPECT().SetLayerScanned(gomock.Any(), layers[1].Hash, mock_ps).Return(nil)
+	mock_store.EXPECT().SetLayerResults(gomock.Any(), layers[0].Hash, mock_ps, gomock.Any()).Return(nil)
+	mock_store.EXPECT().SetLayerResults(gomock.Any(), layers[1].Hash, mock_ps, gomock.Any()).Return(nil)
 	mock_store.EXPECT().IndexPackages(gomock.Any(), gomock.Any(), layers[0], mock_ps).Return(nil)
 	mock_store.EXPECT().IndexPackages(gomock.Any(), gomock.Any(), layers[1], mock_ps).Return(nil)
 
@@ -95,8 +105,8 @@ func TestScanNoErrors(t *testing.T) {
 	mock_ds.EXPECT().Version().AnyTimes().Return("1")
 	mock_store.EXPECT().LayerScanned(gomock.Any(), layers[0].Hash, mock_ds).Return(false, nil)
 	mock_store.EXPECT().LayerScanned(gomock.Any(), layers[1].Hash, mock_ds).Return(false, nil)
-	mock_store.EXPECT().SetLayerScanned(gomock.Any(), layers[0].Hash, mock_ds).Return(nil)
-	mock_store.EXPECT().SetLayerScanned(gomock.Any(), layers[1].Hash, mock_ds).Return(nil)
+	mock_store.EXPECT().SetLayerResults(gomock.Any(), layers[0].Hash, mock_ds, gomock.Any()).Return(nil)
+	mock_store.EXPECT().SetLayerResults(gomock.Any(), layers[1].Hash, mock_ds, gomock.Any()).Return(nil)
 	mock_store.EXPECT().IndexDistributions(gomock.Any(), gomock.Any(), layers[0], mock_ds).Return(nil)
 	mock_store.EXPECT().IndexDistributions(gomock.Any(), gomock.Any(), layers[1], mock_ds).Return(nil)
 
@@ -107,8 +117,8 @@ func TestScanNoErrors(t *testing.T) {
 	mock_rs.EXPECT().Version().AnyTimes().Return("1")
 	mock_store.EXPECT().LayerScanned(gomock.Any(), layers[0].Hash, mock_rs).Return(false, nil)
 	mock_store.EXPECT().LayerScanned(gomock.Any(), layers[1].Hash, mock_rs).Return(false, nil)
-	mock_store.EXPECT().SetLayerScanned(gomock.Any(), layers[0].Hash, mock_rs).Return(nil)
-	mock_store.EXPECT().SetLayerScanned(gomock.Any(), layers[1].Hash, mock_rs).Return(nil)
+	mock_store.EXPECT().SetLayerResults(gomock.Any(), layers[0].Hash, mock_rs, gomock.Any()).Return(nil)
+	mock_store.EXPECT().SetLayerResults(gomock.Any(), layers[1].Hash, mock_rs, gomock.Any()).Return(nil)
 	mock_store.EXPECT().IndexRepositories(gomock.Any(), gomock.Any(), layers[0], mock_rs).Return(nil)
 	mock_store.EXPECT().IndexRepositories(gomock.Any(), gomock.Any(), layers[1], mock_rs).Return(nil)
 
@@ -157,3 +167,465 @@ func TestScanNoErrors(t *testing.T) {
 		t.Errorf("got: %v state, wanted: %v state", state, Coalesce)
 	}
 }
+
+func TestScanLayersOnly(t *testing.T) {
+	ctx, done := context.WithCancel(context.Background())
+	defer done()
+	ctx = zlog.Test(ctx, t)
+	ctrl := gomock.NewController(t)
+
+	mock_ps := indexer_mock.NewMockPackageScanner(ctrl)
+	mock_store := indexer_mock.NewMockStore(ctrl)
+
+	_, layers := test.ServeLayers(t, 2)
+
+	// Only the second layer should be examined.
+	mock_ps.EXPECT().Scan(gomock.Any(), layers[1]).Return([]*claircore.Package{}, nil)
+	mock_ps.EXPECT().Kind().MinTimes(1).Return("package")
+	mock_ps.EXPECT().Name().AnyTimes().Return("package")
+	mock_ps.EXPECT().Version().AnyTimes().Return("1")
+	mock_store.EXPECT().LayerScanned(gomock.Any(), layers[1].Hash, mock_ps).Return(false, nil)
+	mock_store.EXPECT().SetLayerResults(gomock.Any(), layers[1].Hash, mock_ps, gomock.Any()).Return(nil)
+	mock_store.EXPECT().IndexPackages(gomock.Any(), gomock.Any(), layers[1], mock_ps).Return(nil)
+
+	ecosystem := &indexer.Ecosystem{
+		Name: "test-ecosystem",
+		PackageScanners: func(ctx context.Context) ([]indexer.PackageScanner, error) {
+			return []indexer.PackageScanner{mock_ps}, nil
+		},
+		DistributionScanners: func(ctx context.Context) ([]indexer.DistributionScanner, error) {
+			return nil, nil
+		},
+		RepositoryScanners: func(ctx context.Context) ([]indexer.RepositoryScanner, error) {
+			return nil, nil
+		},
+	}
+	sOpts := &indexer.Options{
+		Store:      mock_store,
+		Ecosystems: []*indexer.Ecosystem{ecosystem},
+	}
+	d, err := claircore.NewDigest("sha256", make([]byte, sha256.Size))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ls, err := indexer.NewLayerScanner(ctx, 1, sOpts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, time.Minute)
+	defer cancel()
+	if err := ls.ScanLayers(ctx, d, layers, []claircore.Digest{layers[1].Hash}); err != nil {
+		t.Fatalf("failed to scan test layers: %v", err)
+	}
+}
+
+func TestScanPackageFilter(t *testing.T) {
+	ctx, done := context.WithCancel(context.Background())
+	defer done()
+	ctx = zlog.Test(ctx, t)
+	ctrl := gomock.NewController(t)
+
+	mock_ps := indexer_mock.NewMockPackageScanner(ctrl)
+	mock_store := indexer_mock.NewMockStore(ctrl)
+
+	_, layers := test.ServeLayers(t, 1)
+
+	mock_ps.EXPECT().Scan(gomock.Any(), layers[0]).Return([]*claircore.Package{
+		{Name: "kept", Filepath: "usr/lib/python3/site-packages/kept-1.0.dist-info/METADATA"},
+		{Name: "example", Filepath: "usr/share/doc/example/site-packages/example-1.0.dist-info/METADATA"},
+	}, nil)
+	mock_ps.EXPECT().Kind().MinTimes(1).Return("package")
+	mock_ps.EXPECT().Name().AnyTimes().Return("package")
+	mock_ps.EXPECT().Version().AnyTimes().Return("1")
+	mock_store.EXPECT().LayerScanned(gomock.Any(), layers[0].Hash, mock_ps).Return(false, nil)
+	mock_store.EXPECT().SetLayerResults(gomock.Any(), layers[0].Hash, mock_ps, gomock.Any()).Return(nil)
+	mock_store.EXPECT().IndexPackages(gomock.Any(), gomock.Any(), layers[0], mock_ps).
+		DoAndReturn(func(_ context.Context, pkgs []*claircore.Package, _ *claircore.Layer, _ indexer.VersionedScanner) error {
+			if len(pkgs) != 1 || pkgs[0].Name != "kept" {
+				t.Errorf("unexpected packages stored: %+v", pkgs)
+			}
+			return nil
+		})
+
+	ecosystem := &indexer.Ecosystem{
+		Name: "test-ecosystem",
+		PackageScanners: func(ctx context.Context) ([]indexer.PackageScanner, error) {
+			return []indexer.PackageScanner{mock_ps}, nil
+		},
+		DistributionScanners: func(ctx context.Context) ([]indexer.DistributionScanner, error) {
+			return nil, nil
+		},
+		RepositoryScanners: func(ctx context.Context) ([]indexer.RepositoryScanner, error) {
+			return nil, nil
+		},
+	}
+	sOpts := &indexer.Options{
+		Store:      mock_store,
+		Ecosystems: []*indexer.Ecosystem{ecosystem},
+		PackageFilter: func(p *claircore.Package) bool {
+			return !strings.HasPrefix(p.Filepath, "usr/share/doc/")
+		},
+	}
+	d, err := claircore.NewDigest("sha256", make([]byte, sha256.Size))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ls, err := indexer.NewLayerScanner(ctx, 1, sOpts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, time.Minute)
+	defer cancel()
+	if err := ls.Scan(ctx, d, layers); err != nil {
+		t.Fatalf("failed to scan test layers: %v", err)
+	}
+}
+
+func TestScanTransformers(t *testing.T) {
+	stripDebug := indexer.ResultTransformerFunc(func(_ context.Context, _ indexer.VersionedScanner, _ *claircore.Layer, r *indexer.ScanResult) error {
+		for _, p := range r.Packages {
+			p.Name = strings.TrimSuffix(p.Name, "-debuginfo")
+		}
+		return nil
+	})
+	dropExample := indexer.ResultTransformerFunc(func(_ context.Context, _ indexer.VersionedScanner, _ *claircore.Layer, r *indexer.ScanResult) error {
+		// Relies on running after stripDebug.
+		out := r.Packages[:0]
+		for _, p := range r.Packages {
+			if p.Name != "example" {
+				out = append(out, p)
+			}
+		}
+		r.Packages = out
+		return nil
+	})
+	errTransform := errors.New("transform failed")
+	fail := indexer.ResultTransformerFunc(func(context.Context, indexer.VersionedScanner, *claircore.Layer, *indexer.ScanResult) error {
+		return errTransform
+	})
+
+	tt := []struct {
+		name         string
+		transformers []indexer.ResultTransformer
+		want         []string
+		err          error
+	}{
+		{
+			name:         "Ordered",
+			transformers: []indexer.ResultTransformer{stripDebug, dropExample},
+			want:         []string{"kept"},
+		},
+		{
+			name:         "Error",
+			transformers: []indexer.ResultTransformer{stripDebug, fail},
+			err:          errTransform,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx, done := context.WithCancel(context.Background())
+			defer done()
+			ctx = zlog.Test(ctx, t)
+			ctrl := gomock.NewController(t)
+
+			mock_ps := indexer_mock.NewMockPackageScanner(ctrl)
+			mock_store := indexer_mock.NewMockStore(ctrl)
+
+			_, layers := test.ServeLayers(t, 1)
+
+			mock_ps.EXPECT().Scan(gomock.Any(), layers[0]).Return([]*claircore.Package{
+				{Name: "kept-debuginfo"},
+				{Name: "example-debuginfo"},
+			}, nil)
+			mock_ps.EXPECT().Kind().MinTimes(1).Return("package")
+			mock_ps.EXPECT().Name().AnyTimes().Return("package")
+			mock_ps.EXPECT().Version().AnyTimes().Return("1")
+			mock_store.EXPECT().LayerScanned(gomock.Any(), layers[0].Hash, mock_ps).Return(false, nil)
+			// A failed transform must not store anything.
+			if tc.err == nil {
+				mock_store.EXPECT().SetLayerResults(gomock.Any(), layers[0].Hash, mock_ps, gomock.Any()).Return(nil)
+				mock_store.EXPECT().IndexPackages(gomock.Any(), gomock.Any(), layers[0], mock_ps).
+					DoAndReturn(func(_ context.Context, pkgs []*claircore.Package, _ *claircore.Layer, _ indexer.VersionedScanner) error {
+						var got []string
+						for _, p := range pkgs {
+							got = append(got, p.Name)
+						}
+						if !cmp.Equal(got, tc.want) {
+							t.Error(cmp.Diff(got, tc.want))
+						}
+						return nil
+					})
+			}
+
+			ecosystem := &indexer.Ecosystem{
+				Name: "test-ecosystem",
+				PackageScanners: func(ctx context.Context) ([]indexer.PackageScanner, error) {
+					return []indexer.PackageScanner{mock_ps}, nil
+				},
+				DistributionScanners: func(ctx context.Context) ([]indexer.DistributionScanner, error) {
+					return nil, nil
+				},
+				RepositoryScanners: func(ctx context.Context) ([]indexer.RepositoryScanner, error) {
+					return nil, nil
+				},
+			}
+			sOpts := &indexer.Options{
+				Store:        mock_store,
+				Ecosystems:   []*indexer.Ecosystem{ecosystem},
+				Transformers: tc.transformers,
+			}
+			d, err := claircore.NewDigest("sha256", make([]byte, sha256.Size))
+			if err != nil {
+				t.Fatal(err)
+			}
+			ls, err := indexer.NewLayerScanner(ctx, 1, sOpts)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			ctx, cancel := context.WithTimeout(ctx, time.Minute)
+			defer cancel()
+			err = ls.Scan(ctx, d, layers)
+			if !errors.Is(err, tc.err) {
+				t.Fatalf("got error: %v, want: %v", err, tc.err)
+			}
+		})
+	}
+}
+
+func TestScanStats(t *testing.T) {
+	ctx, done := context.WithCancel(context.Background())
+	defer done()
+	ctx = zlog.Test(ctx, t)
+	ctrl := gomock.NewController(t)
+
+	mock_ps := indexer_mock.NewMockPackageScanner(ctrl)
+	mock_store := indexer_mock.NewMockStore(ctrl)
+
+	// Two distinct layers, one repeated, with on-disk contents of known size.
+	dir := t.TempDir()
+	var layers []*claircore.Layer
+	for i, sz := range []int{1024, 2048} {
+		f := filepath.Join(dir, strconv.Itoa(i))
+		if err := os.WriteFile(f, make([]byte, sz), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		sum := sha256.Sum256([]byte{byte(i)})
+		d, err := claircore.NewDigest("sha256", sum[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		l := &claircore.Layer{Hash: d}
+		l.SetLocal(f)
+		layers = append(layers, l)
+	}
+	layers = append(layers, layers[0])
+
+	// The second layer has results from a previous scan.
+	mock_ps.EXPECT().Scan(gomock.Any(), layers[0]).Return([]*claircore.Package{{Name: "a"}, {Name: "b"}}, nil)
+	mock_ps.EXPECT().Kind().MinTimes(1).Return("package")
+	mock_ps.EXPECT().Name().AnyTimes().Return("package")
+	mock_ps.EXPECT().Version().AnyTimes().Return("1")
+	mock_store.EXPECT().LayerScanned(gomock.Any(), layers[0].Hash, mock_ps).Return(false, nil)
+	mock_store.EXPECT().LayerScanned(gomock.Any(), layers[1].Hash, mock_ps).Return(true, nil)
+	mock_store.EXPECT().SetLayerResults(gomock.Any(), layers[0].Hash, mock_ps, gomock.Any()).Return(nil)
+	mock_store.EXPECT().IndexPackages(gomock.Any(), gomock.Any(), layers[0], mock_ps).Return(nil)
+
+	ecosystem := &indexer.Ecosystem{
+		Name: "test-ecosystem",
+		PackageScanners: func(ctx context.Context) ([]indexer.PackageScanner, error) {
+			return []indexer.PackageScanner{mock_ps}, nil
+		},
+		DistributionScanners: func(ctx context.Context) ([]indexer.DistributionScanner, error) {
+			return nil, nil
+		},
+		RepositoryScanners: func(ctx context.Context) ([]indexer.RepositoryScanner, error) {
+			return nil, nil
+		},
+	}
+	sOpts := &indexer.Options{
+		Store:      mock_store,
+		Ecosystems: []*indexer.Ecosystem{ecosystem},
+	}
+	d, err := claircore.NewDigest("sha256", make([]byte, sha256.Size))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ls, err := indexer.NewLayerScanner(ctx, 1, sOpts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, time.Minute)
+	defer cancel()
+	got, err := ls.ScanStats(ctx, d, layers)
+	if err != nil {
+		t.Fatalf("failed to scan test layers: %v", err)
+	}
+	want := &claircore.IndexStats{
+		Layers:        2,
+		LayersScanned: 1,
+		Bytes:         1024,
+		Packages:      map[string]int{"test-ecosystem": 2},
+	}
+	if !cmp.Equal(got, want) {
+		t.Error(cmp.Diff(got, want))
+	}
+}
+
+func TestScanCorruptLayer(t *testing.T) {
+	ctx, done := context.WithCancel(context.Background())
+	defer done()
+	ctx = zlog.Test(ctx, t)
+
+	// A layer truncated in the middle of a file's contents.
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	if err := tw.WriteHeader(&tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     "etc/os-release",
+		Size:     2048,
+		Mode:     0o644,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tw.Write(make([]byte, 2048)); err != nil {
+		t.Fatal(err)
+	}
+	f := filepath.Join(t.TempDir(), "layer")
+	if err := os.WriteFile(f, buf.Bytes()[:1024], 0o644); err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256(buf.Bytes())
+	ld, err := claircore.NewDigest("sha256", sum[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := &claircore.Layer{Hash: ld}
+	l.SetLocal(f)
+	d, err := claircore.NewDigest("sha256", make([]byte, sha256.Size))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, skip := range []bool{false, true} {
+		t.Run(fmt.Sprintf("Skip=%v", skip), func(t *testing.T) {
+			ctx := zlog.Test(ctx, t)
+			ctrl := gomock.NewController(t)
+			mock_ps := indexer_mock.NewMockPackageScanner(ctrl)
+			mock_store := indexer_mock.NewMockStore(ctrl)
+			// The scanner's never called, and the layer isn't marked as
+			// scanned.
+			mock_ps.EXPECT().Kind().MinTimes(1).Return("package")
+			mock_ps.EXPECT().Name().AnyTimes().Return("package")
+			mock_ps.EXPECT().Version().AnyTimes().Return("1")
+			mock_store.EXPECT().LayerScanned(gomock.Any(), ld, mock_ps).Return(false, nil)
+
+			sOpts := &indexer.Options{
+				Store: mock_store,
+				Ecosystems: []*indexer.Ecosystem{{
+					Name: "test-ecosystem",
+					PackageScanners: func(ctx context.Context) ([]indexer.PackageScanner, error) {
+						return []indexer.PackageScanner{mock_ps}, nil
+					},
+					DistributionScanners: func(ctx context.Context) ([]indexer.DistributionScanner, error) {
+						return nil, nil
+					},
+					RepositoryScanners: func(ctx context.Context) ([]indexer.RepositoryScanner, error) {
+						return nil, nil
+					},
+				}},
+				SkipCorruptLayers: skip,
+			}
+			ls, err := indexer.NewLayerScanner(ctx, 1, sOpts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			stats, err := ls.ScanStats(ctx, d, []*claircore.Layer{l})
+			t.Logf("error: %v", err)
+			if skip {
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got, want := len(stats.LayersSkipped), 1; got != want {
+					t.Fatalf("got: %d skipped layers, want: %d", got, want)
+				}
+				if got, want := stats.LayersSkipped[0].String(), ld.String(); got != want {
+					t.Errorf("got: %s, want: %s", got, want)
+				}
+				return
+			}
+			if !errors.Is(err, indexer.ErrCorruptLayer) {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestScanLimits(t *testing.T) {
+	ctx := zlog.Test(context.Background(), t)
+	ctrl := gomock.NewController(t)
+
+	mock_ps := indexer_mock.NewMockPackageScanner(ctrl)
+	mock_store := indexer_mock.NewMockStore(ctrl)
+
+	f := filepath.Join(t.TempDir(), "layer")
+	if err := os.WriteFile(f, make([]byte, 1024), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	l := &claircore.Layer{Hash: test.RandomSHA256Digest(t)}
+	l.SetLocal(f)
+
+	mock_ps.EXPECT().Scan(gomock.Any(), l).Return([]*claircore.Package{{Name: "a"}, {Name: "b"}, {Name: "c"}}, nil)
+	mock_ps.EXPECT().Kind().MinTimes(1).Return("package")
+	mock_ps.EXPECT().Name().AnyTimes().Return("package")
+	mock_ps.EXPECT().Version().AnyTimes().Return("1")
+	mock_store.EXPECT().LayerScanned(gomock.Any(), l.Hash, mock_ps).Return(false, nil)
+	mock_store.EXPECT().IndexPackages(gomock.Any(), gomock.Any(), l, mock_ps).
+		DoAndReturn(func(_ context.Context, pkgs []*claircore.Package, _ *claircore.Layer, _ indexer.VersionedScanner) error {
+			if got, want := len(pkgs), 2; got != want {
+				t.Errorf("stored packages: got: %d, want: %d", got, want)
+			}
+			return nil
+		})
+	// The truncated layer isn't marked as scanned, so no SetLayerResults.
+
+	ls, err := indexer.NewLayerScanner(ctx, 1, &indexer.Options{
+		Store: mock_store,
+		Ecosystems: []*indexer.Ecosystem{{
+			Name: "test-ecosystem",
+			PackageScanners: func(ctx context.Context) ([]indexer.PackageScanner, error) {
+				return []indexer.PackageScanner{mock_ps}, nil
+			},
+			DistributionScanners: func(ctx context.Context) ([]indexer.DistributionScanner, error) { return nil, nil },
+			RepositoryScanners:   func(ctx context.Context) ([]indexer.RepositoryScanner, error) { return nil, nil },
+		}},
+		MaxLayerPackages: 2,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := New(&indexer.Options{LayerScanner: ls})
+	c.manifest = &claircore.Manifest{
+		Hash:   test.RandomSHA256Digest(t),
+		Layers: []*claircore.Layer{l},
+	}
+	if _, err := scanLayers(ctx, c); err != nil {
+		t.Fatal(err)
+	}
+	stats := c.report.Stats
+	if got, want := len(stats.LayersTruncated), 1; got != want {
+		t.Fatalf("truncated layers: got: %d, want: %d", got, want)
+	}
+	if got, want := stats.LayersTruncated[0].String(), l.Hash.String(); got != want {
+		t.Errorf("truncated layer: got: %s, want: %s", got, want)
+	}
+	if got, want := stats.Packages["test-ecosystem"], 2; got != want {
+		t.Errorf("packages: got: %d, want: %d", got, want)
+	}
+	if got, want := len(c.report.Warnings), 1; got != want {
+		t.Errorf("warnings: got: %q, want: %d", c.report.Warnings, want)
+	}
+}
@@ -0,0 +1,23 @@
+package indexer
+
+import (
+	"context"
+
+	"github.com/quay/zlog"
+)
+
+// CorrelationIDKey is the log context key holding the correlation ID set by
+// WithCorrelationID.
+const CorrelationIDKey = "correlation_id"
+
+// WithCorrelationID returns a Context that causes every message logged with
+// it, or any Context derived from it, to include "id" as the value of
+// CorrelationIDKey.
+//
+// The log context is carried as OpenTelemetry baggage, so the ID follows the
+// Context into LayerScanner's per-scanner goroutines and into the scanners
+// themselves without any changes to them. Callers should set it on the
+// Context passed to (*libindex.Libindex).Index or (*LayerScanner).Scan.
+func WithCorrelationID(ctx context.Context, id string) context.Context {
+	return zlog.ContextWithValues(ctx, CorrelationIDKey, id)
+}
@@ -0,0 +1,78 @@
+package indexer_test
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/quay/zlog"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+
+	"github.com/quay/claircore"
+	"github.com/quay/claircore/indexer"
+	mock_indexer "github.com/quay/claircore/test/mock/indexer"
+)
+
+func TestCorrelationID(t *testing.T) {
+	var buf bytes.Buffer
+	l := zerolog.New(&buf).Level(zerolog.DebugLevel)
+	zlog.Set(&l)
+	t.Cleanup(func() { zlog.Set(&log.Logger) })
+
+	ctx := context.Background()
+	store := mock_indexer.NewMockStore(gomock.NewController(t))
+	store.EXPECT().LayerScanned(gomock.Any(), gomock.Any(), gomock.Any()).Return(true, nil)
+	ls := newMockLayerScanner(ctx, t, store)
+	buf.Reset()
+
+	ctx = indexer.WithCorrelationID(ctx, "req-1234")
+	l0 := claircore.MustParseDigest(`sha256:` + strings.Repeat("a", 64))
+	m := claircore.MustParseDigest(`sha256:` + strings.Repeat("b", 64))
+	if err := ls.Scan(ctx, m, []*claircore.Layer{{Hash: l0}}); err != nil {
+		t.Fatal(err)
+	}
+
+	var n int
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if !strings.Contains(line, `"scanner":"mock"`) {
+			continue
+		}
+		n++
+		if !strings.Contains(line, `"`+indexer.CorrelationIDKey+`":"req-1234"`) {
+			t.Errorf("missing correlation ID: %s", line)
+		}
+	}
+	if n == 0 {
+		t.Errorf("no scanner log lines: %s", buf.String())
+	}
+}
+
+// NewMockLayerScanner returns a LayerScanner running a single mock package
+// scanner named "mock" against the provided Store.
+func newMockLayerScanner(ctx context.Context, t *testing.T, store indexer.Store) *indexer.LayerScanner {
+	t.Helper()
+	ctrl := gomock.NewController(t)
+	s := mock_indexer.NewMockPackageScanner(ctrl)
+	s.EXPECT().Name().AnyTimes().Return("mock")
+	s.EXPECT().Version().AnyTimes().Return("1")
+	s.EXPECT().Kind().AnyTimes().Return("package")
+	ls, err := indexer.NewLayerScanner(ctx, 1, &indexer.Options{
+		Store: store,
+		Ecosystems: []*indexer.Ecosystem{{
+			Name: "mock",
+			PackageScanners: func(context.Context) ([]indexer.PackageScanner, error) {
+				return []indexer.PackageScanner{s}, nil
+			},
+			DistributionScanners: func(context.Context) ([]indexer.DistributionScanner, error) { return nil, nil },
+			RepositoryScanners:   func(context.Context) ([]indexer.RepositoryScanner, error) { return nil, nil },
+			FileScanners:         func(context.Context) ([]indexer.FileScanner, error) { return nil, nil },
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ls
+}
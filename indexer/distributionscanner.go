@@ -11,3 +11,31 @@ type DistributionScanner interface {
 	VersionedScanner
 	Scan(context.Context, *claircore.Layer) ([]*claircore.Distribution, error)
 }
+
+// DistributionScoped is an optional interface for package, repository, and
+// file scanners that only apply to some distributions.
+//
+// LayerScanner runs a DistributionScoped scanner once the distribution
+// scanners have finished with every layer of the manifest, and only if
+// AppliesTo reports true for at least one of the Distributions found. If no
+// Distribution was found, AppliesTo is called with nil.
+//
+// A skipped scanner doesn't record the layer as scanned, so the layer is
+// scanned again if it shows up in a manifest the scanner does apply to.
+type DistributionScoped interface {
+	AppliesTo(*claircore.Distribution) bool
+}
+
+type distributionsKey struct{}
+
+// DetectedDistributions returns the Distributions found in the manifest being
+// scanned, for scanners run after distribution detection: DistributionScoped
+// scanners, and package and file scanners when Options.TwoPhaseScan is set.
+//
+// The reported bool is false if the Context doesn't come from such a scan. The
+// returned slice may be empty if no Distribution was found, and must not be
+// modified.
+func DetectedDistributions(ctx context.Context) ([]*claircore.Distribution, bool) {
+	ds, ok := ctx.Value(distributionsKey{}).([]*claircore.Distribution)
+	return ds, ok
+}
@@ -2,6 +2,8 @@ package indexer
 
 import (
 	"context"
+	"fmt"
+	"strings"
 
 	"github.com/quay/zlog"
 )
@@ -95,3 +97,47 @@ func EcosystemsToScanners(ctx context.Context, ecosystems []*Ecosystem) ([]Packa
 	}
 	return ps, ds, rs, fis, nil
 }
+
+// FilterEcosystems returns the ecosystems selected by name, preserving their
+// order.
+//
+// If "enabled" is not empty, only the named ecosystems are selected. Any
+// ecosystem named in "disabled" is removed, even if it's also enabled. Naming
+// an ecosystem that's not present is reported as an error, to catch typos in
+// configuration.
+func FilterEcosystems(ecosystems []*Ecosystem, enabled, disabled []string) ([]*Ecosystem, error) {
+	if len(enabled) == 0 && len(disabled) == 0 {
+		return ecosystems, nil
+	}
+	present := make(map[string]struct{}, len(ecosystems))
+	for _, e := range ecosystems {
+		present[e.Name] = struct{}{}
+	}
+	var unknown []string
+	set := func(ns []string) map[string]struct{} {
+		m := make(map[string]struct{}, len(ns))
+		for _, n := range ns {
+			if _, ok := present[n]; !ok {
+				unknown = append(unknown, n)
+			}
+			m[n] = struct{}{}
+		}
+		return m
+	}
+	en, dis := set(enabled), set(disabled)
+	if len(unknown) != 0 {
+		return nil, fmt.Errorf("indexer: unknown ecosystems: %s", strings.Join(unknown, ", "))
+	}
+
+	out := make([]*Ecosystem, 0, len(ecosystems))
+	for _, e := range ecosystems {
+		if _, ok := en[e.Name]; len(en) != 0 && !ok {
+			continue
+		}
+		if _, ok := dis[e.Name]; ok {
+			continue
+		}
+		out = append(out, e)
+	}
+	return out, nil
+}
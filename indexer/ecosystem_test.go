@@ -0,0 +1,65 @@
+package indexer
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestFilterEcosystems(t *testing.T) {
+	es := []*Ecosystem{{Name: "dpkg"}, {Name: "rpm"}, {Name: "python"}, {Name: "java"}}
+	tt := []struct {
+		name              string
+		enabled, disabled []string
+		want              []string
+		err               bool
+	}{
+		{
+			name: "None",
+			want: []string{"dpkg", "rpm", "python", "java"},
+		},
+		{
+			name:    "Enabled",
+			enabled: []string{"java", "dpkg"},
+			want:    []string{"dpkg", "java"},
+		},
+		{
+			name:     "Disabled",
+			disabled: []string{"python"},
+			want:     []string{"dpkg", "rpm", "java"},
+		},
+		{
+			name:     "Both",
+			enabled:  []string{"dpkg", "python"},
+			disabled: []string{"python"},
+			want:     []string{"dpkg"},
+		},
+		{
+			name:     "Unknown",
+			disabled: []string{"pyhton"},
+			err:      true,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			out, err := FilterEcosystems(es, tc.enabled, tc.disabled)
+			if tc.err {
+				t.Log(err)
+				if err == nil {
+					t.Error("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := make([]string, len(out))
+			for i, e := range out {
+				got[i] = e.Name
+			}
+			if !cmp.Equal(got, tc.want) {
+				t.Error(cmp.Diff(got, tc.want))
+			}
+		})
+	}
+}
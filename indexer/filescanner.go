@@ -11,3 +11,15 @@ type FileScanner interface {
 	VersionedScanner
 	Scan(context.Context, *claircore.Layer) ([]claircore.File, error)
 }
+
+// PathScoped is an optional interface for scanners, usually file scanners,
+// that only look at a handful of paths.
+//
+// Paths returns patterns in the syntax of fs.Glob, matched against paths in
+// the layer's filesystem, which have no leading slash. LayerScanner only runs
+// a PathScoped scanner on layers with a file matching at least one of the
+// patterns. Other layers are recorded as scanned with no results, so a
+// scanner changing its patterns needs to change its Version as well.
+type PathScoped interface {
+	Paths() []string
+}
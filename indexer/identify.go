@@ -0,0 +1,101 @@
+package indexer
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/quay/zlog"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/quay/claircore"
+)
+
+// Identification is the result of LayerScanner.Identify.
+type Identification struct {
+	// Distributions found, in layer order.
+	Distributions []*claircore.Distribution
+	// Repositories found, in layer order.
+	Repositories []*claircore.Repository
+}
+
+// Identify runs only the distribution and repository scanners over the
+// layers, returning what they found. This is much cheaper than a Scan, and
+// is meant for classifying images before deciding to index them.
+//
+// Nothing is read from or written to the Store, so the layers are scanned
+// even if they were scanned before, and a later Scan doesn't benefit from
+// the work. Transformers are run on the results, but the package filter
+// isn't, as there are no packages. Corrupt layers are handled as in Scan.
+func (ls *LayerScanner) Identify(ctx context.Context, layers []*claircore.Layer) (*Identification, error) {
+	ctx = zlog.ContextWithValues(ctx, "component", "indexer/LayerScanner.Identify")
+	todo := dedupeLayers(layers)
+	vs := MergeVS(nil, ls.ds, ls.rs, nil)
+	// Results are collected by (layer, scanner) so they're reported in a
+	// stable order no matter when the scanners finish.
+	res := make([]result, len(todo)*len(vs))
+	stats := newScanStats()
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(int(ls.inflight))
+	for i, l := range todo {
+		if ls.bufSize != 0 {
+			l.SetBufferSize(ls.bufSize)
+		}
+		for j, s := range vs {
+			i, j, l, s := i, j, l, s
+			g.Go(func() error {
+				return ls.identifyLayer(gctx, l, s, stats, &res[i*len(vs)+j])
+			})
+		}
+	}
+	if err := g.Wait(); err != nil {
+		if cerr := ctx.Err(); cerr != nil {
+			err = cerr
+		}
+		return nil, err
+	}
+	var out Identification
+	for i := range res {
+		out.Distributions = append(out.Distributions, res[i].dists...)
+		out.Repositories = append(out.Repositories, res[i].repos...)
+	}
+	zlog.Debug(ctx).
+		Int("layers", len(todo)).
+		Int("distributions", len(out.Distributions)).
+		Int("repositories", len(out.Repositories)).
+		Msg("identified")
+	return &out, nil
+}
+
+// IdentifyLayer runs a single scanner over a layer for Identify, putting the
+// results in "r".
+func (ls *LayerScanner) identifyLayer(ctx context.Context, l *claircore.Layer, s VersionedScanner, stats *scanStats, r *result) error {
+	ctx = zlog.ContextWithValues(ctx,
+		"scanner", s.Name(),
+		"kind", s.Kind(),
+		"layer", l.Hash.String())
+	if lvl, ok := ls.logLevels[s.Name()]; ok {
+		ctx = zlog.ContextWithValues(ctx, logLevelKey, lvl.String())
+	}
+	if err := stats.Check(l); err != nil {
+		if !ls.skipCorrupt {
+			return err
+		}
+		if stats.Skip(l) {
+			zlog.Warn(ctx).Err(err).Msg("skipping corrupt layer")
+		}
+		return nil
+	}
+	if ps, ok := s.(PathScoped); ok {
+		ok, err := hasPaths(l, ps.Paths())
+		if err != nil {
+			return fmt.Errorf("unable to check paths for scanner %q: %w", s.Name(), err)
+		}
+		if !ok {
+			return nil
+		}
+	}
+	if err := r.Do(ctx, s, l); err != nil {
+		return err
+	}
+	return r.Transform(ctx, ls.transformers, s, l)
+}
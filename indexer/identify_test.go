@@ -0,0 +1,74 @@
+package indexer_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/quay/claircore"
+	"github.com/quay/claircore/indexer"
+	mock_indexer "github.com/quay/claircore/test/mock/indexer"
+)
+
+func TestIdentify(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	a := &claircore.Layer{Hash: claircore.MustParseDigest(`sha256:` + strings.Repeat("a", 64))}
+	b := &claircore.Layer{Hash: claircore.MustParseDigest(`sha256:` + strings.Repeat("b", 64))}
+	dist := &claircore.Distribution{DID: "debian", VersionID: "12"}
+	repo := &claircore.Repository{Name: "debian-bookworm"}
+
+	ds := mock_indexer.NewMockDistributionScanner(ctrl)
+	ds.EXPECT().Name().AnyTimes().Return("dist")
+	ds.EXPECT().Version().AnyTimes().Return("1")
+	ds.EXPECT().Kind().AnyTimes().Return("distribution")
+	ds.EXPECT().Scan(gomock.Any(), a).Return([]*claircore.Distribution{dist}, nil)
+	ds.EXPECT().Scan(gomock.Any(), b).Return(nil, nil)
+	rs := mock_indexer.NewMockRepositoryScanner(ctrl)
+	rs.EXPECT().Name().AnyTimes().Return("repo")
+	rs.EXPECT().Version().AnyTimes().Return("1")
+	rs.EXPECT().Kind().AnyTimes().Return("repository")
+	rs.EXPECT().Scan(gomock.Any(), a).Return(nil, nil)
+	rs.EXPECT().Scan(gomock.Any(), b).Return([]*claircore.Repository{repo}, nil)
+	// The package scanner and the Store must not be used.
+	ps := mock_indexer.NewMockPackageScanner(ctrl)
+	ps.EXPECT().Name().AnyTimes().Return("pkg")
+	ps.EXPECT().Version().AnyTimes().Return("1")
+	ps.EXPECT().Kind().AnyTimes().Return("package")
+	store := mock_indexer.NewMockStore(ctrl)
+
+	ls, err := indexer.NewLayerScanner(ctx, 2, &indexer.Options{
+		Store: store,
+		Ecosystems: []*indexer.Ecosystem{{
+			Name: "mock",
+			PackageScanners: func(context.Context) ([]indexer.PackageScanner, error) {
+				return []indexer.PackageScanner{ps}, nil
+			},
+			DistributionScanners: func(context.Context) ([]indexer.DistributionScanner, error) {
+				return []indexer.DistributionScanner{ds}, nil
+			},
+			RepositoryScanners: func(context.Context) ([]indexer.RepositoryScanner, error) {
+				return []indexer.RepositoryScanner{rs}, nil
+			},
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The duplicate layer is only scanned once.
+	got, err := ls.Identify(ctx, []*claircore.Layer{a, b, a})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &indexer.Identification{
+		Distributions: []*claircore.Distribution{dist},
+		Repositories:  []*claircore.Repository{repo},
+	}
+	if !cmp.Equal(got, want) {
+		t.Error(cmp.Diff(got, want))
+	}
+}
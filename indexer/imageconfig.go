@@ -0,0 +1,46 @@
+package indexer
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/quay/claircore"
+)
+
+// ScanImageConfig reads the runtime configuration out of an image's config
+// blob, in the format described by the OCI image spec. Docker's format is a
+// superset, so its configs work as well.
+//
+// A config without any runtime configuration returns a nil ImageConfig.
+func ScanImageConfig(b json.RawMessage) (*claircore.ImageConfig, error) {
+	var blob struct {
+		Config *struct {
+			User         string              `json:"User"`
+			ExposedPorts map[string]struct{} `json:"ExposedPorts"`
+			Env          []string            `json:"Env"`
+			Entrypoint   []string            `json:"Entrypoint"`
+			Cmd          []string            `json:"Cmd"`
+			WorkingDir   string              `json:"WorkingDir"`
+		} `json:"config"`
+	}
+	if err := json.Unmarshal(b, &blob); err != nil {
+		return nil, fmt.Errorf("indexer: unable to decode image config: %w", err)
+	}
+	c := blob.Config
+	if c == nil {
+		return nil, nil
+	}
+	out := claircore.ImageConfig{
+		User:       c.User,
+		Entrypoint: c.Entrypoint,
+		Cmd:        c.Cmd,
+		Env:        c.Env,
+		WorkingDir: c.WorkingDir,
+	}
+	for p := range c.ExposedPorts {
+		out.ExposedPorts = append(out.ExposedPorts, p)
+	}
+	sort.Strings(out.ExposedPorts)
+	return &out, nil
+}
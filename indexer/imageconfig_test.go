@@ -0,0 +1,81 @@
+package indexer
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/quay/claircore"
+)
+
+func TestScanImageConfig(t *testing.T) {
+	tt := []struct {
+		Name    string
+		In      string
+		Want    *claircore.ImageConfig
+		WantErr bool
+	}{
+		{
+			Name: "Full",
+			In: `{"architecture":"amd64","os":"linux","config":{` +
+				`"User":"1001:0",` +
+				`"ExposedPorts":{"8443/tcp":{},"53/udp":{},"8080/tcp":{}},` +
+				`"Env":["PATH=/usr/local/bin:/usr/bin","HOME=/app"],` +
+				`"Entrypoint":["/usr/bin/tini","--"],` +
+				`"Cmd":["/app/server"],` +
+				`"WorkingDir":"/app"` +
+				`},"rootfs":{"type":"layers","diff_ids":[]}}`,
+			Want: &claircore.ImageConfig{
+				User:         "1001:0",
+				ExposedPorts: []string{"53/udp", "8080/tcp", "8443/tcp"},
+				Env:          []string{"PATH=/usr/local/bin:/usr/bin", "HOME=/app"},
+				Entrypoint:   []string{"/usr/bin/tini", "--"},
+				Cmd:          []string{"/app/server"},
+				WorkingDir:   "/app",
+			},
+		},
+		{
+			Name: "Empty",
+			In:   `{"architecture":"amd64","config":{}}`,
+			Want: &claircore.ImageConfig{},
+		},
+		{
+			Name: "NoConfig",
+			In:   `{"architecture":"amd64"}`,
+		},
+		{
+			Name:    "Malformed",
+			In:      `{"config":[]}`,
+			WantErr: true,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			got, err := ScanImageConfig([]byte(tc.In))
+			if (err != nil) != tc.WantErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !cmp.Equal(got, tc.Want) {
+				t.Error(cmp.Diff(tc.Want, got))
+			}
+		})
+	}
+}
+
+func TestRunsAsRoot(t *testing.T) {
+	for user, want := range map[string]bool{
+		"":          true,
+		"root":      true,
+		"0":         true,
+		"0:0":       true,
+		"root:1000": true,
+		"1001":      false,
+		"1001:0":    false,
+		"nobody":    false,
+	} {
+		c := claircore.ImageConfig{User: user}
+		if got := c.RunsAsRoot(); got != want {
+			t.Errorf("%q: got: %v, want: %v", user, got, want)
+		}
+	}
+}
@@ -4,14 +4,23 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
+	"io/fs"
 	"net"
 	"runtime"
+	"sort"
+	"sync"
+	"time"
 
 	"github.com/quay/zlog"
+	"github.com/rs/zerolog"
 	"golang.org/x/sync/errgroup"
 	"golang.org/x/sync/semaphore"
 
 	"github.com/quay/claircore"
+	"github.com/quay/claircore/pkg/squashfs"
+	"github.com/quay/claircore/pkg/tarfs"
+	"github.com/quay/claircore/pkg/tracing"
 )
 
 type LayerScanner struct {
@@ -19,6 +28,27 @@ type LayerScanner struct {
 
 	// Maximum allowed in-flight scanners per Scan call
 	inflight int64
+	// Packages not passing the filter aren't stored.
+	filter func(*claircore.Package) bool
+	// Package scanner names to the name of their ecosystem, for stats.
+	ecosystem map[string]string
+	// Scanner names to the minimum level to log at.
+	logLevels map[string]zerolog.Level
+	// Buffer size set on layers before scanning, if not the default.
+	bufSize int
+	// Run package and file scanners after distribution detection.
+	twoPhase bool
+	// Skip corrupt layers instead of failing the scan.
+	skipCorrupt bool
+	// Run on every result before it's stored.
+	transformers []ResultTransformer
+	// Retries and initial backoff for failed Store writes.
+	retries int
+	backoff time.Duration
+	// Caps on the packages and files stored per (layer, scanner), or
+	// negative for none.
+	maxPkgs  int
+	maxFiles int
 
 	// Pre-constructed and configured scanners.
 	ps  []PackageScanner
@@ -47,17 +77,61 @@ func NewLayerScanner(ctx context.Context, concurrent int, opts *Options) (*Layer
 	if err != nil {
 		return nil, fmt.Errorf("failed to extract scanners from ecosystems: %v", err)
 	}
+	if len(ps)+len(ds)+len(rs)+len(fs) == 0 && !opts.AllowNoScanners {
+		return nil, fmt.Errorf("%w: %d ecosystems provide no scanners", ErrNoScanners, len(opts.Ecosystems))
+	}
+	eco, err := scannerEcosystems(ctx, opts.Ecosystems)
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract scanners from ecosystems: %v", err)
+	}
 
 	return &LayerScanner{
-		store:    opts.Store,
-		inflight: int64(concurrent),
-		ps:       configAndFilter(ctx, opts, ps),
-		ds:       configAndFilter(ctx, opts, ds),
-		rs:       configAndFilter(ctx, opts, rs),
-		fis:      configAndFilter(ctx, opts, fs),
+		store:        opts.Store,
+		inflight:     int64(concurrent),
+		filter:       opts.PackageFilter,
+		ecosystem:    eco,
+		logLevels:    opts.ScannerLogLevels,
+		skipCorrupt:  opts.SkipCorruptLayers,
+		bufSize:      opts.ReadBufferSize,
+		twoPhase:     opts.TwoPhaseScan,
+		transformers: opts.Transformers,
+		retries:      opts.StoreRetries,
+		backoff:      opts.StoreRetryBackoff,
+		maxPkgs:      limitOr(opts.MaxLayerPackages, DefaultMaxLayerPackages),
+		maxFiles:     limitOr(opts.MaxLayerFiles, DefaultMaxLayerFiles),
+		ps:           configAndFilter(ctx, opts, ps),
+		ds:           configAndFilter(ctx, opts, ds),
+		rs:           configAndFilter(ctx, opts, rs),
+		fis:          configAndFilter(ctx, opts, fs),
 	}, nil
 }
 
+// ScannerEcosystems maps the names of package scanners to the name of the
+// first ecosystem using them, mirroring how EcosystemsToScanners dedupes them.
+//
+// Ecosystems without a name are reported under the scanner's name, so their
+// packages aren't all counted together.
+func scannerEcosystems(ctx context.Context, ecosystems []*Ecosystem) (map[string]string, error) {
+	out := make(map[string]string)
+	for _, e := range ecosystems {
+		ps, err := e.PackageScanners(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, s := range ps {
+			n := s.Name()
+			if _, ok := out[n]; ok {
+				continue
+			}
+			out[n] = e.Name
+			if e.Name == "" {
+				out[n] = n
+			}
+		}
+	}
+	return out, nil
+}
+
 func configAndFilter[S VersionedScanner](ctx context.Context, opts *Options, ss []S) []S {
 	i := 0
 	for _, s := range ss {
@@ -125,56 +199,417 @@ func configAndFilter[S VersionedScanner](ctx context.Context, opts *Options, ss
 //
 // The provided Context controls cancellation for all scanners. The first error
 // reported halts all work and is returned from Scan.
+//
+// If the Context is canceled, Scan returns its error once the running
+// scanners have returned. No Store writes are started after cancellation,
+// and a layer is only marked as scanned after its results are written, so a
+// canceled scan never leaves a layer marked as scanned with partial results.
 func (ls *LayerScanner) Scan(ctx context.Context, manifest claircore.Digest, layers []*claircore.Layer) error {
-	ctx = zlog.ContextWithValues(ctx,
-		"component", "indexer/LayerScanner.Scan",
-		"manifest", manifest.String())
+	ctx = zlog.ContextWithValues(ctx, "component", "indexer/LayerScanner.Scan")
+	_, err := ls.scan(ctx, manifest, layers, nil)
+	return err
+}
+
+// ScanStats is like Scan, but also returns statistics about the work done.
+//
+// Layers with results from previous scans aren't scanned again, so only
+// layers scanned by this call contribute to the byte and package counts.
+func (ls *LayerScanner) ScanStats(ctx context.Context, manifest claircore.Digest, layers []*claircore.Layer) (*claircore.IndexStats, error) {
+	// This is how the indexer runs Scan, so log the same way.
+	ctx = zlog.ContextWithValues(ctx, "component", "indexer/LayerScanner.Scan")
+	return ls.scan(ctx, manifest, layers, nil)
+}
+
+// ScanLayers is like Scan, but only scans the layers whose digests are present
+// in "only". If "only" is empty, all layers are scanned.
+//
+// This is useful when re-indexing a manifest where only some layers are
+// expected to have changed, such as after a base image update. Layers that
+// have already been scanned are skipped as usual.
+func (ls *LayerScanner) ScanLayers(ctx context.Context, manifest claircore.Digest, layers []*claircore.Layer, only []claircore.Digest) error {
+	ctx = zlog.ContextWithValues(ctx, "component", "indexer/LayerScanner.ScanLayers")
+	_, err := ls.scan(ctx, manifest, layers, only)
+	return err
+}
 
+// Scan implements Scan, ScanStats, and ScanLayers.
+func (ls *LayerScanner) scan(ctx context.Context, manifest claircore.Digest, layers []*claircore.Layer, only []claircore.Digest) (*claircore.IndexStats, error) {
+	ctx = zlog.ContextWithValues(ctx, "manifest", manifest.String())
+	ctx, span := tracing.Start(ctx, "indexer.LayerScanner.Scan",
+		tracing.String("manifest", manifest.String()))
+	defer span.End()
+
+	stats := newScanStats()
 	sem := semaphore.NewWeighted(ls.inflight)
+	parent := ctx
 	g, ctx := errgroup.WithContext(ctx)
 	// Launch is a closure to capture the loop variables and then call the
 	// scanLayer method.
-	launch := func(l *claircore.Layer, s VersionedScanner) func() error {
+	launch := func(ctx context.Context, l *claircore.Layer, s VersionedScanner) func() error {
 		return func() error {
 			if err := sem.Acquire(ctx, 1); err != nil {
 				return err
 			}
 			defer sem.Release(1)
-			return ls.scanLayer(ctx, l, s)
+			return ls.scanLayer(ctx, l, s, stats)
+		}
+	}
+	var want map[string]struct{}
+	if len(only) != 0 {
+		want = make(map[string]struct{}, len(only))
+		for _, d := range only {
+			want[d.String()] = struct{}{}
+		}
+	}
+	// Scanners are run in up to two phases; see phaseOf.
+	var first sync.WaitGroup
+	var second []deferredScan
+	todo := dedupeLayers(layers)
+	scanners := ls.scanners()
+	for _, l := range todo {
+		if ls.bufSize != 0 {
+			l.SetBufferSize(ls.bufSize)
+		}
+		if want != nil {
+			if _, ok := want[l.Hash.String()]; !ok {
+				zlog.Debug(ctx).
+					Stringer("layer", l.Hash).
+					Msg("layer not requested, skipping")
+				continue
+			}
+		}
+		for _, s := range scanners {
+			switch ls.phaseOf(s) {
+			case phaseNone:
+				g.Go(launch(ctx, l, s))
+			case phaseFirst:
+				f := launch(ctx, l, s)
+				first.Add(1)
+				g.Go(func() error {
+					defer first.Done()
+					return f()
+				})
+			case phaseSecond:
+				ds, _ := s.(DistributionScoped)
+				second = append(second, deferredScan{l: l, s: s, ds: ds})
+			}
+		}
+	}
+	if len(second) != 0 {
+		g.Go(func() error {
+			first.Wait()
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			found, err := ls.distributions(ctx, todo)
+			if err != nil {
+				return err
+			}
+			zlog.Debug(ctx).
+				Int("count", len(found)).
+				Msg("distribution detection done")
+			ctx := context.WithValue(ctx, distributionsKey{}, found)
+			for _, d := range second {
+				if d.ds != nil && !appliesTo(d.ds, found) {
+					zlog.Debug(ctx).
+						Str("scanner", d.s.Name()).
+						Stringer("layer", d.l.Hash).
+						Msg("scanner does not apply to distribution, skipping")
+					continue
+				}
+				g.Go(launch(ctx, d.l, d.s))
+			}
+			return nil
+		})
+	}
+
+	if err := g.Wait(); err != nil {
+		// Report cancellation of the caller's Context as such, instead of
+		// whatever error a scanner or the Store returned because of it.
+		if cerr := parent.Err(); cerr != nil {
+			err = cerr
 		}
+		span.RecordError(err)
+		return nil, err
 	}
-	dedupe := make(map[string]struct{})
+	return stats.Report(ctx, len(todo)), nil
+}
+
+// DedupeLayers returns the layers with duplicate digests removed, keeping the
+// first occurrence.
+func dedupeLayers(layers []*claircore.Layer) []*claircore.Layer {
+	out := make([]*claircore.Layer, 0, len(layers))
+	seen := make(map[string]struct{}, len(layers))
 	for _, l := range layers {
-		if _, ok := dedupe[l.Hash.String()]; ok {
+		if _, ok := seen[l.Hash.String()]; ok {
 			continue
 		}
-		dedupe[l.Hash.String()] = struct{}{}
-		for _, s := range ls.ps {
-			g.Go(launch(l, s))
+		seen[l.Hash.String()] = struct{}{}
+		out = append(out, l)
+	}
+	return out
+}
+
+// Scanners returns all the configured scanners, in the order they're launched.
+func (ls *LayerScanner) scanners() VersionedScanners {
+	return MergeVS(ls.ps, ls.ds, ls.rs, ls.fis)
+}
+
+// ScanPhase is when a scanner runs during a scan.
+type scanPhase int
+
+const (
+	// PhaseNone scanners run right away.
+	phaseNone scanPhase = iota
+	// PhaseFirst scanners run right away, and the second phase waits for
+	// them to finish.
+	phaseFirst
+	// PhaseSecond scanners run once distribution detection is done.
+	phaseSecond
+)
+
+// PhaseOf reports when the scanner runs.
+//
+// The first phase is distribution scanners and, when scanning in two phases,
+// repository scanners. The second phase is the DistributionScoped scanners
+// and, when scanning in two phases, the package and file scanners. Everything
+// else runs right away.
+func (ls *LayerScanner) phaseOf(s VersionedScanner) scanPhase {
+	_, scoped := s.(DistributionScoped)
+	switch s.(type) {
+	case DistributionScanner:
+		return phaseFirst
+	case RepositoryScanner:
+		if ls.twoPhase && !scoped {
+			return phaseFirst
 		}
-		for _, s := range ls.ds {
-			g.Go(launch(l, s))
+	case PackageScanner, FileScanner:
+		if ls.twoPhase {
+			return phaseSecond
 		}
-		for _, s := range ls.rs {
-			g.Go(launch(l, s))
+	}
+	if scoped {
+		return phaseSecond
+	}
+	return phaseNone
+}
+
+// ScanStats holds the state shared by concurrent calls to scanLayer: the
+// accumulated statistics and the results of checking the layers.
+type scanStats struct {
+	mu      sync.Mutex
+	scanned map[string]*claircore.Layer
+	pkgs    map[string]int
+	checks  map[string]*layerCheck
+	skipped map[string]claircore.Digest
+	trunc   map[string]claircore.Digest
+}
+
+type layerCheck struct {
+	once sync.Once
+	err  error
+}
+
+func newScanStats() *scanStats {
+	return &scanStats{
+		scanned: make(map[string]*claircore.Layer),
+		pkgs:    make(map[string]int),
+		checks:  make(map[string]*layerCheck),
+		skipped: make(map[string]claircore.Digest),
+		trunc:   make(map[string]claircore.Digest),
+	}
+}
+
+// Check reports an error wrapping ErrCorruptLayer if the layer can't be read
+// as a tar archive. Every layer is only checked once.
+func (s *scanStats) Check(l *claircore.Layer) error {
+	s.mu.Lock()
+	c, ok := s.checks[l.Hash.String()]
+	if !ok {
+		c = new(layerCheck)
+		s.checks[l.Hash.String()] = c
+	}
+	s.mu.Unlock()
+	c.once.Do(func() { c.err = checkLayer(l) })
+	return c.err
+}
+
+// Skip records the layer as skipped, reporting whether it's the first time.
+func (s *scanStats) Skip(l *claircore.Layer) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.skipped[l.Hash.String()]; ok {
+		return false
+	}
+	s.skipped[l.Hash.String()] = l.Hash
+	return true
+}
+
+// Truncate records that results for the layer were dropped for being over
+// the limits.
+func (s *scanStats) Truncate(l *claircore.Layer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.trunc[l.Hash.String()] = l.Hash
+}
+
+// Add records that the layer was scanned, finding "n" packages in the
+// ecosystem "eco".
+func (s *scanStats) Add(l *claircore.Layer, eco string, n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.scanned[l.Hash.String()] = l
+	if n != 0 {
+		s.pkgs[eco] += n
+	}
+}
+
+// Report returns the accumulated stats for a manifest with "layers" distinct
+// layers.
+func (s *scanStats) Report(ctx context.Context, layers int) *claircore.IndexStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := claircore.IndexStats{
+		Layers:        layers,
+		LayersScanned: len(s.scanned),
+	}
+	for _, l := range s.scanned {
+		sz, err := layerSize(l)
+		if err != nil {
+			zlog.Warn(ctx).
+				Stringer("layer", l.Hash).
+				Err(err).
+				Msg("unable to determine layer size")
+			continue
 		}
-		for _, s := range ls.fis {
-			g.Go(launch(l, s))
+		out.Bytes += sz
+	}
+	if len(s.pkgs) != 0 {
+		out.Packages = make(map[string]int, len(s.pkgs))
+		for k, v := range s.pkgs {
+			out.Packages[k] = v
 		}
+	}
+	for _, d := range s.skipped {
+		out.LayersSkipped = append(out.LayersSkipped, d)
+	}
+	sort.Slice(out.LayersSkipped, func(i, j int) bool {
+		return out.LayersSkipped[i].String() < out.LayersSkipped[j].String()
+	})
+	for _, d := range s.trunc {
+		out.LayersTruncated = append(out.LayersTruncated, d)
+	}
+	sort.Slice(out.LayersTruncated, func(i, j int) bool {
+		return out.LayersTruncated[i].String() < out.LayersTruncated[j].String()
+	})
+	return &out
+}
+
+// ErrCorruptLayer is reported, via errors.Is, by errors from scanning a
+// layer that can't be read as a tar archive, such as a truncated download.
+// Fetching the layer again may fix it, unlike errors from the scanners
+// themselves.
+var ErrCorruptLayer = errors.New("indexer: corrupt layer")
+
+// CheckLayer makes sure the layer can be read as a tar archive or squashfs
+// image.
+//
+// Only problems with the archive itself are reported; a layer that can't be
+// opened at all is left for the scanners to report.
+func checkLayer(l *claircore.Layer) error {
+	if l.HasFS() {
+		return nil
+	}
+	rc, err := l.Reader()
+	if err != nil {
+		return nil
+	}
+	defer rc.Close()
+	_, err = claircore.LayerFS(rc)
+	if errors.Is(err, tarfs.ErrFormat) || errors.Is(err, squashfs.ErrFormat) {
+		return fmt.Errorf("%w %s: %w", ErrCorruptLayer, l.Hash, err)
+	}
+	return nil
+}
+
+// LayerSize reports the size of the layer's uncompressed tar.
+//
+// Layers backed by a filesystem set with SetFS report zero bytes.
+func layerSize(l *claircore.Layer) (int64, error) {
+	if l.HasFS() {
+		return 0, nil
+	}
+	rc, err := l.Reader()
+	if err != nil {
+		return 0, err
+	}
+	defer rc.Close()
+	sk, ok := rc.(io.Seeker)
+	if !ok {
+		return 0, fmt.Errorf("unable to seek layer")
+	}
+	return sk.Seek(0, io.SeekEnd)
+}
+
+// DeferredScan is a (layer, scanner) pair waiting on distribution detection.
+type deferredScan struct {
+	l  *claircore.Layer
+	s  VersionedScanner
+	ds DistributionScoped // Set if the scanner implements it.
+}
 
+// Distributions returns the Distributions the distribution scanners found in
+// the provided layers, including in earlier scans.
+func (ls *LayerScanner) distributions(ctx context.Context, layers []*claircore.Layer) ([]*claircore.Distribution, error) {
+	var vs VersionedScanners
+	vs.DStoVS(ls.ds)
+	var out []*claircore.Distribution
+	for _, l := range layers {
+		ds, err := ls.store.DistributionsByLayer(ctx, l.Hash, vs)
+		if err != nil {
+			return nil, fmt.Errorf("unable to look up distributions for layer %q: %w", l.Hash, err)
+		}
+		out = append(out, ds...)
 	}
+	return out, nil
+}
 
-	return g.Wait()
+// AppliesTo reports whether the scanner applies to any of the provided
+// Distributions, or to no Distribution if none were found.
+func appliesTo(s DistributionScoped, found []*claircore.Distribution) bool {
+	if len(found) == 0 {
+		return s.AppliesTo(nil)
+	}
+	for _, d := range found {
+		if s.AppliesTo(d) {
+			return true
+		}
+	}
+	return false
 }
 
 // ScanLayer (along with the result type) handles an individual (scanner, layer)
 // pair.
-func (ls *LayerScanner) scanLayer(ctx context.Context, l *claircore.Layer, s VersionedScanner) error {
+func (ls *LayerScanner) scanLayer(ctx context.Context, l *claircore.Layer, s VersionedScanner, stats *scanStats) (err error) {
 	ctx = zlog.ContextWithValues(ctx,
 		"component", "indexer/LayerScanner.scanLayer",
 		"scanner", s.Name(),
 		"kind", s.Kind(),
 		"layer", l.Hash.String())
+	ctx, span := tracing.Start(ctx, "indexer.LayerScanner.scanLayer",
+		tracing.String("scanner", s.Name()),
+		tracing.String("scanner.version", s.Version()),
+		tracing.String("scanner.kind", s.Kind()),
+		tracing.String("layer", l.Hash.String()))
+	defer func() {
+		if err != nil {
+			span.RecordError(err)
+		}
+		span.End()
+	}()
+	if lvl, ok := ls.logLevels[s.Name()]; ok {
+		ctx = zlog.ContextWithValues(ctx, logLevelKey, lvl.String())
+	}
 	zlog.Debug(ctx).Msg("scan start")
 	defer zlog.Debug(ctx).Msg("scan done")
 
@@ -186,17 +621,96 @@ func (ls *LayerScanner) scanLayer(ctx context.Context, l *claircore.Layer, s Ver
 		zlog.Debug(ctx).Msg("layer already scanned")
 		return nil
 	}
+	if err := stats.Check(l); err != nil {
+		if !ls.skipCorrupt {
+			return err
+		}
+		// The layer isn't marked as scanned, so it's scanned again when it
+		// next shows up.
+		if stats.Skip(l) {
+			zlog.Warn(ctx).Err(err).Msg("skipping corrupt layer")
+		}
+		return nil
+	}
+
+	if ps, ok := s.(PathScoped); ok {
+		ok, err := hasPaths(l, ps.Paths())
+		if err != nil {
+			return fmt.Errorf("unable to check paths for scanner %q: %w", s.Name(), err)
+		}
+		if !ok {
+			zlog.Debug(ctx).Msg("no paths of interest in layer, skipping")
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if err := ls.store.SetLayerResults(ctx, l.Hash, s, 0); err != nil {
+				return fmt.Errorf("could not set layer scanned: %w", err)
+			}
+			return nil
+		}
+	}
 
 	var result result
 	if err := result.Do(ctx, s, l); err != nil {
 		return err
 	}
 
-	if err = ls.store.SetLayerScanned(ctx, l.Hash, s); err != nil {
+	if err := result.Transform(ctx, ls.transformers, s, l); err != nil {
+		return err
+	}
+	if ls.filter != nil {
+		result.Filter(ctx, ls.filter)
+	}
+	trunc := result.Limit(ctx, ls.maxPkgs, ls.maxFiles)
+	if trunc {
+		stats.Truncate(l)
+	}
+	stats.Add(l, ls.ecosystem[s.Name()], len(result.pkgs))
+
+	// Store the results before marking the layer as scanned, so a failure
+	// storing them means the layer is scanned again instead of being
+	// reported as having no results. Transient failures are retried, so the
+	// scan work isn't thrown away.
+	err = retryStore(ctx, ls.retries, ls.backoff, func() error {
+		return result.Store(ctx, ls.store, s, l)
+	})
+	if err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if trunc {
+		// The layer isn't marked as scanned, so it's scanned again when it
+		// next shows up and every report using it notes the truncation.
+		return nil
+	}
+	err = retryStore(ctx, ls.retries, ls.backoff, func() error {
+		return ls.store.SetLayerResults(ctx, l.Hash, s, result.Len())
+	})
+	if err != nil {
 		return fmt.Errorf("could not set layer scanned: %w", err)
 	}
+	return nil
+}
 
-	return result.Store(ctx, ls.store, s, l)
+// HasPaths reports whether the layer has a file matching any of the patterns.
+func hasPaths(l *claircore.Layer, pats []string) (bool, error) {
+	sys, err := l.FS()
+	if err != nil {
+		return false, err
+	}
+	defer sys.Close()
+	for _, p := range pats {
+		ms, err := fs.Glob(sys, p)
+		if err != nil {
+			return false, err
+		}
+		if len(ms) != 0 {
+			return true, nil
+		}
+	}
+	return false, nil
 }
 
 // Result is a type that handles the kind-specific bits of the scan process.
@@ -207,6 +721,11 @@ type result struct {
 	files []claircore.File
 }
 
+// Len reports the number of results.
+func (r *result) Len() int {
+	return len(r.pkgs) + len(r.dists) + len(r.repos) + len(r.files)
+}
+
 // Do asserts the Scanner back to having a Scan method, and then calls it.
 //
 // The success value is captured and the error value is returned by Do.
@@ -237,9 +756,71 @@ func (r *result) Do(ctx context.Context, s VersionedScanner, l *claircore.Layer)
 	return err
 }
 
+// Transform runs the transformers over the result, in order.
+func (r *result) Transform(ctx context.Context, ts []ResultTransformer, s VersionedScanner, l *claircore.Layer) error {
+	if len(ts) == 0 {
+		return nil
+	}
+	sr := ScanResult{
+		Packages:      r.pkgs,
+		Distributions: r.dists,
+		Repositories:  r.repos,
+		Files:         r.files,
+	}
+	for _, t := range ts {
+		if err := t.Transform(ctx, s, l, &sr); err != nil {
+			return fmt.Errorf("result transformer failed: %w", err)
+		}
+	}
+	// Store uses which member is non-nil to pick the kind, and an empty
+	// result still needs to be recorded, so don't let a transformer nil out
+	// the member.
+	r.pkgs = keepNonNil(r.pkgs, sr.Packages)
+	r.dists = keepNonNil(r.dists, sr.Distributions)
+	r.repos = keepNonNil(r.repos, sr.Repositories)
+	r.files = keepNonNil(r.files, sr.Files)
+	return nil
+}
+
+// KeepNonNil returns "next", or an empty slice if "next" is nil but "prev"
+// wasn't.
+func keepNonNil[T any](prev, next []T) []T {
+	if next == nil && prev != nil {
+		return prev[:0]
+	}
+	return next
+}
+
+// Filter removes the packages "keep" returns false for.
+func (r *result) Filter(ctx context.Context, keep func(*claircore.Package) bool) {
+	if r.pkgs == nil {
+		return
+	}
+	// Filter in place, keeping the slice non-nil so an empty result is still
+	// stored.
+	out := r.pkgs[:0]
+	for _, p := range r.pkgs {
+		if keep(p) {
+			out = append(out, p)
+		}
+	}
+	if n := len(r.pkgs) - len(out); n != 0 {
+		zlog.Debug(ctx).Int("count", n).Msg("filtered packages")
+	}
+	for i := len(out); i < len(r.pkgs); i++ {
+		r.pkgs[i] = nil
+	}
+	r.pkgs = out
+}
+
 // Store calls the properly typed store method on whatever value was captured in
 // the result.
+//
+// Nothing is written if the Context is already done.
 func (r *result) Store(ctx context.Context, store Store, s VersionedScanner, l *claircore.Layer) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
 	switch {
 	case r.pkgs != nil:
 		zlog.Debug(ctx).Int("count", len(r.pkgs)).Msg("scan returned packages")
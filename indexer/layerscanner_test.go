@@ -0,0 +1,466 @@
+package indexer_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"testing/fstest"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/quay/claircore"
+	"github.com/quay/claircore/indexer"
+	mock_indexer "github.com/quay/claircore/test/mock/indexer"
+)
+
+// ScopedScanner is a package scanner only applying to the distribution with
+// the DID "did".
+type scopedScanner struct {
+	*mock_indexer.MockPackageScanner
+	did string
+}
+
+func (s *scopedScanner) AppliesTo(d *claircore.Distribution) bool {
+	return d != nil && d.DID == s.did
+}
+
+var _ indexer.DistributionScoped = (*scopedScanner)(nil)
+
+func TestDistributionScoped(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	found := []*claircore.Distribution{{DID: "debian", VersionID: "12"}}
+
+	ds := mock_indexer.NewMockDistributionScanner(ctrl)
+	ds.EXPECT().Name().AnyTimes().Return("dist")
+	ds.EXPECT().Version().AnyTimes().Return("1")
+	ds.EXPECT().Kind().AnyTimes().Return("distribution")
+	distScan := ds.EXPECT().Scan(gomock.Any(), gomock.Any()).Return(found, nil)
+
+	newScoped := func(name, did string) *scopedScanner {
+		s := mock_indexer.NewMockPackageScanner(ctrl)
+		s.EXPECT().Name().AnyTimes().Return(name)
+		s.EXPECT().Version().AnyTimes().Return("1")
+		s.EXPECT().Kind().AnyTimes().Return("package")
+		return &scopedScanner{MockPackageScanner: s, did: did}
+	}
+	deb := newScoped("deb", "debian")
+	rpm := newScoped("rpm", "rhel")
+	// Only the scanner for the detected distribution runs, and only after
+	// distribution detection.
+	deb.EXPECT().Scan(gomock.Any(), gomock.Any()).After(distScan).Return([]*claircore.Package{}, nil)
+	rpm.EXPECT().Scan(gomock.Any(), gomock.Any()).Times(0)
+
+	store := mock_indexer.NewMockStore(ctrl)
+	store.EXPECT().LayerScanned(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes().Return(false, nil)
+	store.EXPECT().SetLayerResults(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(2).Return(nil)
+	store.EXPECT().IndexDistributions(gomock.Any(), found, gomock.Any(), gomock.Any()).Return(nil)
+	store.EXPECT().IndexPackages(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+	store.EXPECT().DistributionsByLayer(gomock.Any(), gomock.Any(), gomock.Any()).After(distScan).Return(found, nil)
+
+	ls, err := indexer.NewLayerScanner(ctx, 2, &indexer.Options{
+		Store: store,
+		Ecosystems: []*indexer.Ecosystem{{
+			Name: "mock",
+			PackageScanners: func(context.Context) ([]indexer.PackageScanner, error) {
+				return []indexer.PackageScanner{deb, rpm}, nil
+			},
+			DistributionScanners: func(context.Context) ([]indexer.DistributionScanner, error) {
+				return []indexer.DistributionScanner{ds}, nil
+			},
+			RepositoryScanners: func(context.Context) ([]indexer.RepositoryScanner, error) { return nil, nil },
+			FileScanners:       func(context.Context) ([]indexer.FileScanner, error) { return nil, nil },
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	l := claircore.MustParseDigest(`sha256:` + strings.Repeat("a", 64))
+	m := claircore.MustParseDigest(`sha256:` + strings.Repeat("b", 64))
+	if err := ls.Scan(ctx, m, []*claircore.Layer{{Hash: l}}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTwoPhaseScan(t *testing.T) {
+	found := []*claircore.Distribution{{DID: "rhel", VersionID: "9"}}
+	for _, twoPhase := range []bool{false, true} {
+		t.Run(fmt.Sprintf("TwoPhase=%v", twoPhase), func(t *testing.T) {
+			ctx := context.Background()
+			ctrl := gomock.NewController(t)
+
+			ds := mock_indexer.NewMockDistributionScanner(ctrl)
+			ds.EXPECT().Name().AnyTimes().Return("dist")
+			ds.EXPECT().Version().AnyTimes().Return("1")
+			ds.EXPECT().Kind().AnyTimes().Return("distribution")
+			distScan := ds.EXPECT().Scan(gomock.Any(), gomock.Any()).Return(found, nil)
+			rs := mock_indexer.NewMockRepositoryScanner(ctrl)
+			rs.EXPECT().Name().AnyTimes().Return("repo")
+			rs.EXPECT().Version().AnyTimes().Return("1")
+			rs.EXPECT().Kind().AnyTimes().Return("repository")
+			repoScan := rs.EXPECT().Scan(gomock.Any(), gomock.Any()).Return([]*claircore.Repository{}, nil)
+			ps := mock_indexer.NewMockPackageScanner(ctrl)
+			ps.EXPECT().Name().AnyTimes().Return("pkg")
+			ps.EXPECT().Version().AnyTimes().Return("1")
+			ps.EXPECT().Kind().AnyTimes().Return("package")
+			pkgScan := ps.EXPECT().Scan(gomock.Any(), gomock.Any()).
+				DoAndReturn(func(ctx context.Context, _ *claircore.Layer) ([]*claircore.Package, error) {
+					got, ok := indexer.DetectedDistributions(ctx)
+					if ok != twoPhase {
+						t.Errorf("got distributions in context: %v, want: %v", ok, twoPhase)
+					}
+					if ok && !cmp.Equal(got, found) {
+						t.Error(cmp.Diff(got, found))
+					}
+					return []*claircore.Package{}, nil
+				})
+			if twoPhase {
+				pkgScan.After(distScan).After(repoScan)
+			}
+
+			store := mock_indexer.NewMockStore(ctrl)
+			store.EXPECT().LayerScanned(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes().Return(false, nil)
+			store.EXPECT().SetLayerResults(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(3).Return(nil)
+			store.EXPECT().IndexDistributions(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+			store.EXPECT().IndexRepositories(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+			store.EXPECT().IndexPackages(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+			if twoPhase {
+				store.EXPECT().DistributionsByLayer(gomock.Any(), gomock.Any(), gomock.Any()).Return(found, nil)
+			}
+
+			ls, err := indexer.NewLayerScanner(ctx, 3, &indexer.Options{
+				Store:        store,
+				TwoPhaseScan: twoPhase,
+				Ecosystems: []*indexer.Ecosystem{{
+					Name: "mock",
+					PackageScanners: func(context.Context) ([]indexer.PackageScanner, error) {
+						return []indexer.PackageScanner{ps}, nil
+					},
+					DistributionScanners: func(context.Context) ([]indexer.DistributionScanner, error) {
+						return []indexer.DistributionScanner{ds}, nil
+					},
+					RepositoryScanners: func(context.Context) ([]indexer.RepositoryScanner, error) {
+						return []indexer.RepositoryScanner{rs}, nil
+					},
+					FileScanners: func(context.Context) ([]indexer.FileScanner, error) { return nil, nil },
+				}},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			l := claircore.MustParseDigest(`sha256:` + strings.Repeat("a", 64))
+			m := claircore.MustParseDigest(`sha256:` + strings.Repeat("b", 64))
+			if err := ls.Scan(ctx, m, []*claircore.Layer{{Hash: l}}); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestIncrementalScan(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	layers := []*claircore.Layer{
+		{Hash: claircore.MustParseDigest(`sha256:` + strings.Repeat("a", 64))},
+		{Hash: claircore.MustParseDigest(`sha256:` + strings.Repeat("c", 64))},
+	}
+	m := claircore.MustParseDigest(`sha256:` + strings.Repeat("b", 64))
+
+	// The store remembers which scanners have scanned which layers.
+	var mu sync.Mutex
+	scanned := make(map[string]bool)
+	key := func(l claircore.Digest, s indexer.VersionedScanner) string {
+		return l.String() + "/" + s.Name()
+	}
+	store := mock_indexer.NewMockStore(ctrl)
+	store.EXPECT().LayerScanned(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes().
+		DoAndReturn(func(_ context.Context, l claircore.Digest, s indexer.VersionedScanner) (bool, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			return scanned[key(l, s)], nil
+		})
+	store.EXPECT().SetLayerResults(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes().
+		DoAndReturn(func(_ context.Context, l claircore.Digest, s indexer.VersionedScanner, _ int) error {
+			mu.Lock()
+			defer mu.Unlock()
+			scanned[key(l, s)] = true
+			return nil
+		})
+	store.EXPECT().IndexPackages(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes().Return(nil)
+
+	newScanner := func(name string) *mock_indexer.MockPackageScanner {
+		s := mock_indexer.NewMockPackageScanner(ctrl)
+		s.EXPECT().Name().AnyTimes().Return(name)
+		s.EXPECT().Version().AnyTimes().Return("1")
+		s.EXPECT().Kind().AnyTimes().Return("package")
+		return s
+	}
+	newLayerScanner := func(ps ...indexer.PackageScanner) *indexer.LayerScanner {
+		ls, err := indexer.NewLayerScanner(ctx, 2, &indexer.Options{
+			Store: store,
+			Ecosystems: []*indexer.Ecosystem{{
+				Name: "mock",
+				PackageScanners: func(context.Context) ([]indexer.PackageScanner, error) {
+					return ps, nil
+				},
+				DistributionScanners: func(context.Context) ([]indexer.DistributionScanner, error) { return nil, nil },
+				RepositoryScanners:   func(context.Context) ([]indexer.RepositoryScanner, error) { return nil, nil },
+				FileScanners:         func(context.Context) ([]indexer.FileScanner, error) { return nil, nil },
+			}},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ls
+	}
+
+	old := newScanner("old")
+	old.EXPECT().Scan(gomock.Any(), gomock.Any()).Times(len(layers)).Return([]*claircore.Package{}, nil)
+	if err := newLayerScanner(old).Scan(ctx, m, layers); err != nil {
+		t.Fatal(err)
+	}
+
+	// Adding a scanner only runs the new scanner; the old scanner's results
+	// are reused.
+	added := newScanner("added")
+	added.EXPECT().Scan(gomock.Any(), gomock.Any()).Times(len(layers)).Return([]*claircore.Package{}, nil)
+	ls := newLayerScanner(old, added)
+	if err := ls.Scan(ctx, m, layers); err != nil {
+		t.Fatal(err)
+	}
+	for _, l := range layers {
+		for _, s := range []indexer.VersionedScanner{old, added} {
+			if !scanned[key(l.Hash, s)] {
+				t.Errorf("layer %v not marked scanned by %q", l.Hash, s.Name())
+			}
+		}
+	}
+}
+
+func TestScanStoreFailure(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+
+	ps := mock_indexer.NewMockPackageScanner(ctrl)
+	ps.EXPECT().Name().AnyTimes().Return("pkg")
+	ps.EXPECT().Version().AnyTimes().Return("1")
+	ps.EXPECT().Kind().AnyTimes().Return("package")
+	ps.EXPECT().Scan(gomock.Any(), gomock.Any()).Return([]*claircore.Package{}, nil)
+
+	// A layer must not be marked as scanned if its results weren't stored,
+	// otherwise a later scan would skip it and report nothing.
+	store := mock_indexer.NewMockStore(ctrl)
+	store.EXPECT().LayerScanned(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes().Return(false, nil)
+	store.EXPECT().IndexPackages(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(errors.New("oops"))
+	store.EXPECT().SetLayerResults(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+	ls, err := indexer.NewLayerScanner(ctx, 1, &indexer.Options{
+		Store: store,
+		Ecosystems: []*indexer.Ecosystem{{
+			Name: "mock",
+			PackageScanners: func(context.Context) ([]indexer.PackageScanner, error) {
+				return []indexer.PackageScanner{ps}, nil
+			},
+			DistributionScanners: func(context.Context) ([]indexer.DistributionScanner, error) { return nil, nil },
+			RepositoryScanners:   func(context.Context) ([]indexer.RepositoryScanner, error) { return nil, nil },
+			FileScanners:         func(context.Context) ([]indexer.FileScanner, error) { return nil, nil },
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := claircore.MustParseDigest(`sha256:` + strings.Repeat("a", 64))
+	m := claircore.MustParseDigest(`sha256:` + strings.Repeat("b", 64))
+	if err := ls.Scan(ctx, m, []*claircore.Layer{{Hash: l}}); err == nil {
+		t.Error("expected error")
+	}
+}
+
+// PathScopedScanner is a file scanner only interested in some paths.
+type pathScopedScanner struct {
+	*mock_indexer.MockFileScanner
+	paths []string
+}
+
+func (s *pathScopedScanner) Paths() []string { return s.paths }
+
+var _ indexer.PathScoped = (*pathScopedScanner)(nil)
+
+func TestPathScoped(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	match := &claircore.Layer{Hash: claircore.MustParseDigest(`sha256:` + strings.Repeat("a", 64))}
+	match.SetFS(fstest.MapFS{
+		"etc/os-release":        {Data: []byte("ID=test\n")},
+		"opt/app/lib/thing.jar": {Data: []byte("jar")},
+	})
+	miss := &claircore.Layer{Hash: claircore.MustParseDigest(`sha256:` + strings.Repeat("c", 64))}
+	miss.SetFS(fstest.MapFS{
+		"etc/os-release": {Data: []byte("ID=test\n")},
+	})
+
+	fs := mock_indexer.NewMockFileScanner(ctrl)
+	fs.EXPECT().Name().AnyTimes().Return("jars")
+	fs.EXPECT().Version().AnyTimes().Return("1")
+	fs.EXPECT().Kind().AnyTimes().Return("file")
+	// The scanner is only run on the layer with a matching path.
+	fs.EXPECT().Scan(gomock.Any(), match).Return([]claircore.File{}, nil)
+	s := &pathScopedScanner{
+		MockFileScanner: fs,
+		paths:           []string{"usr/lib/*/*.jar", "opt/*/lib/*.jar"},
+	}
+
+	store := mock_indexer.NewMockStore(ctrl)
+	store.EXPECT().LayerScanned(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes().Return(false, nil)
+	// Both layers are recorded as scanned.
+	store.EXPECT().SetLayerResults(gomock.Any(), match.Hash, gomock.Any(), gomock.Any()).Return(nil)
+	store.EXPECT().SetLayerResults(gomock.Any(), miss.Hash, gomock.Any(), 0).Return(nil)
+	store.EXPECT().IndexFiles(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes().Return(nil)
+
+	ls, err := indexer.NewLayerScanner(ctx, 2, &indexer.Options{
+		Store: store,
+		Ecosystems: []*indexer.Ecosystem{{
+			Name:                 "mock",
+			PackageScanners:      func(context.Context) ([]indexer.PackageScanner, error) { return nil, nil },
+			DistributionScanners: func(context.Context) ([]indexer.DistributionScanner, error) { return nil, nil },
+			RepositoryScanners:   func(context.Context) ([]indexer.RepositoryScanner, error) { return nil, nil },
+			FileScanners: func(context.Context) ([]indexer.FileScanner, error) {
+				return []indexer.FileScanner{s}, nil
+			},
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := claircore.MustParseDigest(`sha256:` + strings.Repeat("b", 64))
+	if err := ls.Scan(ctx, m, []*claircore.Layer{match, miss}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestScanCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ctrl := gomock.NewController(t)
+	layers := []*claircore.Layer{
+		{Hash: claircore.MustParseDigest(`sha256:` + strings.Repeat("a", 64))},
+		{Hash: claircore.MustParseDigest(`sha256:` + strings.Repeat("c", 64))},
+	}
+	m := claircore.MustParseDigest(`sha256:` + strings.Repeat("b", 64))
+
+	// The scanner ignores cancellation, like a scanner in the middle of
+	// something that doesn't take a Context.
+	ps := mock_indexer.NewMockPackageScanner(ctrl)
+	ps.EXPECT().Name().AnyTimes().Return("pkg")
+	ps.EXPECT().Version().AnyTimes().Return("1")
+	ps.EXPECT().Kind().AnyTimes().Return("package")
+	ps.EXPECT().Scan(gomock.Any(), gomock.Any()).MinTimes(1).MaxTimes(len(layers)).
+		DoAndReturn(func(context.Context, *claircore.Layer) ([]*claircore.Package, error) {
+			cancel()
+			return []*claircore.Package{{Name: "a"}}, nil
+		})
+
+	// No writes may happen after cancellation.
+	store := mock_indexer.NewMockStore(ctrl)
+	store.EXPECT().LayerScanned(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes().Return(false, nil)
+	store.EXPECT().IndexPackages(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+	store.EXPECT().SetLayerResults(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+	ls, err := indexer.NewLayerScanner(ctx, 1, &indexer.Options{
+		Store: store,
+		Ecosystems: []*indexer.Ecosystem{{
+			Name: "mock",
+			PackageScanners: func(context.Context) ([]indexer.PackageScanner, error) {
+				return []indexer.PackageScanner{ps}, nil
+			},
+			DistributionScanners: func(context.Context) ([]indexer.DistributionScanner, error) { return nil, nil },
+			RepositoryScanners:   func(context.Context) ([]indexer.RepositoryScanner, error) { return nil, nil },
+			FileScanners:         func(context.Context) ([]indexer.FileScanner, error) { return nil, nil },
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ls.Scan(ctx, m, layers)
+	if got, want := err, context.Canceled; !errors.Is(got, want) {
+		t.Errorf("got: %v, want: %v", got, want)
+	}
+}
+
+func TestScanCancelStore(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ctrl := gomock.NewController(t)
+
+	ps := mock_indexer.NewMockPackageScanner(ctrl)
+	ps.EXPECT().Name().AnyTimes().Return("pkg")
+	ps.EXPECT().Version().AnyTimes().Return("1")
+	ps.EXPECT().Kind().AnyTimes().Return("package")
+	ps.EXPECT().Scan(gomock.Any(), gomock.Any()).Return([]*claircore.Package{{Name: "a"}}, nil)
+
+	// Cancellation during a write fails the write, and the layer must not be
+	// marked as scanned afterwards. The Store's error is reported as the
+	// cancellation it's caused by.
+	store := mock_indexer.NewMockStore(ctrl)
+	store.EXPECT().LayerScanned(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes().Return(false, nil)
+	store.EXPECT().IndexPackages(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(context.Context, []*claircore.Package, *claircore.Layer, indexer.VersionedScanner) error {
+			cancel()
+			return errors.New("connection closed")
+		})
+	store.EXPECT().SetLayerResults(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+	ls, err := indexer.NewLayerScanner(ctx, 1, &indexer.Options{
+		Store: store,
+		Ecosystems: []*indexer.Ecosystem{{
+			Name: "mock",
+			PackageScanners: func(context.Context) ([]indexer.PackageScanner, error) {
+				return []indexer.PackageScanner{ps}, nil
+			},
+			DistributionScanners: func(context.Context) ([]indexer.DistributionScanner, error) { return nil, nil },
+			RepositoryScanners:   func(context.Context) ([]indexer.RepositoryScanner, error) { return nil, nil },
+			FileScanners:         func(context.Context) ([]indexer.FileScanner, error) { return nil, nil },
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := claircore.MustParseDigest(`sha256:` + strings.Repeat("a", 64))
+	m := claircore.MustParseDigest(`sha256:` + strings.Repeat("b", 64))
+	err = ls.Scan(ctx, m, []*claircore.Layer{{Hash: l}})
+	if got, want := err, context.Canceled; !errors.Is(got, want) {
+		t.Errorf("got: %v, want: %v", got, want)
+	}
+}
+
+func TestNoScanners(t *testing.T) {
+	ctx := context.Background()
+	empty := []*indexer.Ecosystem{{
+		Name:                 "empty",
+		PackageScanners:      func(context.Context) ([]indexer.PackageScanner, error) { return nil, nil },
+		DistributionScanners: func(context.Context) ([]indexer.DistributionScanner, error) { return nil, nil },
+		RepositoryScanners:   func(context.Context) ([]indexer.RepositoryScanner, error) { return nil, nil },
+	}}
+	t.Run("Default", func(t *testing.T) {
+		_, err := indexer.NewLayerScanner(ctx, 1, &indexer.Options{Ecosystems: empty})
+		t.Log(err)
+		if got, want := err, indexer.ErrNoScanners; !errors.Is(got, want) {
+			t.Errorf("got: %v, want: %v", got, want)
+		}
+	})
+	t.Run("Allowed", func(t *testing.T) {
+		_, err := indexer.NewLayerScanner(ctx, 1, &indexer.Options{
+			Ecosystems:      empty,
+			AllowNoScanners: true,
+		})
+		if err != nil {
+			t.Error(err)
+		}
+	})
+}
@@ -0,0 +1,47 @@
+package indexer
+
+import (
+	"context"
+
+	"github.com/quay/zlog"
+)
+
+// These are the default caps on the results a single scanner can report for
+// a layer. They're far beyond what real images have, even ones vendoring
+// large language ecosystems, and only exist to keep pathological or
+// malicious images from filling the Store.
+const (
+	DefaultMaxLayerPackages = 250_000
+	DefaultMaxLayerFiles    = 1_000_000
+)
+
+// LimitOr returns "n", or "def" if "n" is zero.
+func limitOr(n, def int) int {
+	if n == 0 {
+		return def
+	}
+	return n
+}
+
+// Limit drops the packages and files past the first "pkgs" and "files",
+// reporting whether anything was dropped. A negative limit means no limit.
+func (r *result) Limit(ctx context.Context, pkgs, files int) bool {
+	trunc := false
+	if pkgs >= 0 && len(r.pkgs) > pkgs {
+		zlog.Warn(ctx).
+			Int("found", len(r.pkgs)).
+			Int("limit", pkgs).
+			Msg("too many packages in layer, truncating")
+		r.pkgs = r.pkgs[:pkgs]
+		trunc = true
+	}
+	if files >= 0 && len(r.files) > files {
+		zlog.Warn(ctx).
+			Int("found", len(r.files)).
+			Int("limit", files).
+			Msg("too many files in layer, truncating")
+		r.files = r.files[:files]
+		trunc = true
+	}
+	return trunc
+}
@@ -0,0 +1,81 @@
+package indexer
+
+import (
+	"bytes"
+	"io"
+
+	"github.com/rs/zerolog"
+)
+
+// LogLevelKey is the log context key holding the minimum level of messages
+// logged while a scanner runs.
+//
+// The zlog package always logs via a single logger and only hands it the
+// Context values as already-serialized fields, so neither a Context, a
+// sub-logger, nor a zerolog.Hook can change the level per scanner. Instead,
+// scanLayer records the level from Options.ScannerLogLevels with the rest of
+// the log context, and the writer returned by LogFilter acts on it.
+const logLevelKey = "log_level"
+
+// LevelField is the serialized prefix of the logLevelKey field.
+var levelField = []byte(`"` + logLevelKey + `":"`)
+
+// LogFilter returns a writer for a zerolog.Logger that writes to "w",
+// dropping messages below the level set for the scanner that logged them in
+// Options.ScannerLogLevels.
+//
+// The Logger must write JSON, so any other formatting, such as a
+// zerolog.ConsoleWriter, should be done by "w".
+//
+// Overrides can't raise a scanner's verbosity above the Logger's own level:
+// messages below it are never written, so they never reach the filter. To get
+// debug messages from one scanner only, set the Logger to debug and override
+// every other scanner to a higher level.
+func LogFilter(w io.Writer) zerolog.LevelWriter {
+	return &logFilter{w: w}
+}
+
+type logFilter struct {
+	w io.Writer
+}
+
+// Write implements io.Writer.
+func (f *logFilter) Write(p []byte) (int, error) {
+	return f.WriteLevel(zerolog.NoLevel, p)
+}
+
+// WriteLevel implements zerolog.LevelWriter.
+func (f *logFilter) WriteLevel(l zerolog.Level, p []byte) (int, error) {
+	if drop(l, p) {
+		return len(p), nil
+	}
+	if lw, ok := f.w.(zerolog.LevelWriter); ok {
+		return lw.WriteLevel(l, p)
+	}
+	return f.w.Write(p)
+}
+
+// Drop reports whether the message "p" at level "l" is below the minimum level
+// recorded in it.
+//
+// This is on the path of every log message, so the field is found by scanning
+// the bytes rather than decoding the message.
+func drop(l zerolog.Level, p []byte) bool {
+	if l == zerolog.NoLevel {
+		return false
+	}
+	i := bytes.Index(p, levelField)
+	if i == -1 {
+		return false
+	}
+	v := p[i+len(levelField):]
+	end := bytes.IndexByte(v, '"')
+	if end == -1 {
+		return false
+	}
+	min, err := zerolog.ParseLevel(string(v[:end]))
+	if err != nil {
+		return false
+	}
+	return l < min
+}
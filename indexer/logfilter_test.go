@@ -0,0 +1,38 @@
+package indexer
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/rs/zerolog"
+)
+
+func TestLogFilter(t *testing.T) {
+	var buf bytes.Buffer
+	log := zerolog.New(LogFilter(&buf))
+
+	log.Debug().Str("scanner", "java").Str(logLevelKey, "info").Msg("dropped")
+	log.Info().Str("scanner", "java").Str(logLevelKey, "info").Msg("java info")
+	log.Debug().Str("scanner", "python").Msg("python debug")
+	log.Debug().Str("scanner", "ruby").Str(logLevelKey, "bogus").Msg("bad level")
+	log.Log().Str(logLevelKey, "error").Msg("no level")
+	log.Debug().Str("scanner", "rpm").Msg(`"log_level":"error"`)
+
+	var got []string
+	dec := json.NewDecoder(&buf)
+	for dec.More() {
+		var v struct {
+			Message string `json:"message"`
+		}
+		if err := dec.Decode(&v); err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, v.Message)
+	}
+	want := []string{"java info", "python debug", "bad level", "no level", `"log_level":"error"`}
+	if !cmp.Equal(got, want) {
+		t.Error(cmp.Diff(got, want))
+	}
+}
@@ -1,7 +1,13 @@
 package indexer
 
 import (
+	"errors"
 	"net/http"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/quay/claircore"
 )
 
 // Options are options to instantiate a indexer
@@ -16,4 +22,73 @@ type Options struct {
 	Ecosystems   []*Ecosystem
 	Resolvers    []Resolver
 	Vscnrs       VersionedScanners
+	// PackageFilter, if set, is called on every package found by a package
+	// scanner before it's stored. Packages it returns false for are dropped.
+	PackageFilter func(*claircore.Package) bool
+	// ScannerLogLevels sets the minimum level of messages logged while the
+	// named scanners run. It's only enforced by loggers writing via
+	// LogFilter, and can't raise a scanner's verbosity above the logger's
+	// own level.
+	ScannerLogLevels map[string]zerolog.Level
+	// SkipCorruptLayers controls what happens when a layer can't be read as
+	// a tar archive. By default, the scan fails with an error wrapping
+	// ErrCorruptLayer. If set, the layer is skipped with a warning and listed
+	// in the IndexStats instead.
+	SkipCorruptLayers bool
+	// Transformers are run, in order, on the result of every scanner before
+	// it's stored. They run before PackageFilter.
+	Transformers []ResultTransformer
+	// DistributionPreference is an ordered list of distribution IDs (the
+	// "ID" field of os-release, like "rhel" or "debian") used to pick the
+	// distribution to match against when more than one is found. If none of
+	// the found distributions are listed, the one in the topmost layer is
+	// used.
+	DistributionPreference []string
+	// MultiDistribution keeps every package attributed to the distribution
+	// found alongside it, instead of pointing all of them at the one picked
+	// by DistributionPreference, for images that contain packages from
+	// more than one distribution.
+	MultiDistribution bool
+	// ReadBufferSize is the size of the buffer files in a layer are read
+	// through. If zero, tarfs.DefaultBufferSize is used. If negative, reads
+	// are unbuffered.
+	ReadBufferSize int
+	// TwoPhaseScan runs the distribution and repository scanners over every
+	// layer before any package or file scanner, which then have the
+	// detected distributions available via DetectedDistributions. This
+	// gives up some concurrency.
+	TwoPhaseScan bool
+	// StrictScannerCoverage fails the index with an error wrapping
+	// ErrMissingScanner when no package scanner for the detected distribution
+	// is configured. By default, a warning is added to the IndexReport.
+	StrictScannerCoverage bool
+	// AllowNoScanners lets NewLayerScanner succeed when the Ecosystems
+	// provide no scanners at all. By default, that's reported as an error
+	// wrapping ErrNoScanners, as every scan would find nothing.
+	AllowNoScanners bool
+	// StoreRetries is the number of times a failed write of a scanner's
+	// results to the Store is retried, if the error is Retryable, before
+	// the scan fails. Retries back off exponentially, starting at
+	// StoreRetryBackoff or DefaultStoreRetryBackoff. By default, writes
+	// aren't retried.
+	StoreRetries      int
+	StoreRetryBackoff time.Duration
+	// MaxLayerPackages and MaxLayerFiles cap the number of packages and
+	// files a single scanner can report for a layer, so a crafted image
+	// can't make the Store hold unbounded results. Results past the cap
+	// are dropped and the layer is listed in IndexStats.LayersTruncated. The
+	// layer isn't marked as scanned, so it's listed for every manifest using
+	// it. If zero, DefaultMaxLayerPackages and DefaultMaxLayerFiles are used.
+	// If negative, results aren't capped.
+	MaxLayerPackages int
+	MaxLayerFiles    int
 }
+
+// ErrMissingScanner is reported, via errors.Is, when indexing with
+// Options.StrictScannerCoverage finds a distribution none of the configured
+// package scanners handle.
+var ErrMissingScanner = errors.New("indexer: missing package scanner")
+
+// ErrNoScanners is reported, via errors.Is, when the configured ecosystems
+// provide no scanners of any kind, unless Options.AllowNoScanners is set.
+var ErrNoScanners = errors.New("indexer: no scanners configured")
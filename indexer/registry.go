@@ -0,0 +1,92 @@
+package indexer
+
+import (
+	"context"
+	"sync"
+)
+
+var registry = struct {
+	sync.Mutex
+	fs []func(context.Context) *Ecosystem
+}{}
+
+// RegisterEcosystem registers a constructor for an Ecosystem that's part of the
+// default configuration.
+//
+// Packages providing a default configuration, like libindex, register their
+// ecosystems when imported. Registration order is preserved, as it decides
+// which ecosystem a shared scanner is attributed to.
+func RegisterEcosystem(f func(context.Context) *Ecosystem) {
+	registry.Lock()
+	defer registry.Unlock()
+	registry.fs = append(registry.fs, f)
+}
+
+// ScannerInfo describes a scanner.
+type ScannerInfo struct {
+	Name    string `json:"name"`
+	Kind    string `json:"kind"`
+	Version string `json:"version"`
+}
+
+// EcosystemInfo describes an Ecosystem and its scanners.
+type EcosystemInfo struct {
+	Name     string        `json:"name"`
+	Scanners []ScannerInfo `json:"scanners"`
+}
+
+// RegisteredEcosystems describes the registered ecosystems, in registration
+// order.
+func RegisteredEcosystems(ctx context.Context) ([]EcosystemInfo, error) {
+	es := registered(ctx)
+	out := make([]EcosystemInfo, 0, len(es))
+	for _, e := range es {
+		// Describing one ecosystem at a time lists its scanners even if an
+		// earlier ecosystem shares them.
+		ss, err := ecosystemScanners(ctx, []*Ecosystem{e})
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, EcosystemInfo{
+			Name:     e.Name,
+			Scanners: ss,
+		})
+	}
+	return out, nil
+}
+
+// RegisteredScanners describes the scanners the registered ecosystems provide,
+// deduplicated as EcosystemsToScanners does.
+func RegisteredScanners(ctx context.Context) ([]ScannerInfo, error) {
+	return ecosystemScanners(ctx, registered(ctx))
+}
+
+// Registered constructs the registered ecosystems.
+func registered(ctx context.Context) []*Ecosystem {
+	registry.Lock()
+	fs := make([]func(context.Context) *Ecosystem, len(registry.fs))
+	copy(fs, registry.fs)
+	registry.Unlock()
+	out := make([]*Ecosystem, len(fs))
+	for i, f := range fs {
+		out[i] = f(ctx)
+	}
+	return out
+}
+
+func ecosystemScanners(ctx context.Context, es []*Ecosystem) ([]ScannerInfo, error) {
+	ps, ds, rs, fs, err := EcosystemsToScanners(ctx, es)
+	if err != nil {
+		return nil, err
+	}
+	vs := MergeVS(ps, ds, rs, fs)
+	out := make([]ScannerInfo, len(vs))
+	for i, s := range vs {
+		out[i] = ScannerInfo{
+			Name:    s.Name(),
+			Kind:    s.Kind(),
+			Version: s.Version(),
+		}
+	}
+	return out, nil
+}
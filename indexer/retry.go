@@ -0,0 +1,79 @@
+package indexer
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/quay/zlog"
+)
+
+// DefaultStoreRetryBackoff is the delay before the first retry of a failed
+// Store write, if Options.StoreRetryBackoff isn't set.
+const DefaultStoreRetryBackoff = 250 * time.Millisecond
+
+// Retryable reports whether "err", returned by a Store, looks transient, so
+// the same call may succeed if retried.
+//
+// Errors are considered transient if they:
+//
+//   - have a "Temporary() bool" or "SafeToRetry() bool" method returning true,
+//     like many network errors;
+//   - have a "SQLState() string" method reporting a serialization failure, a
+//     deadlock, or a connection exception, like PostgreSQL errors; or
+//   - are a connection reset or refused.
+//
+// Store implementations can make their own errors retryable by implementing
+// one of the above methods.
+func Retryable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var tmp interface{ Temporary() bool }
+	if errors.As(err, &tmp) && tmp.Temporary() {
+		return true
+	}
+	var safe interface{ SafeToRetry() bool }
+	if errors.As(err, &safe) && safe.SafeToRetry() {
+		return true
+	}
+	var sql interface{ SQLState() string }
+	if errors.As(err, &sql) {
+		// Serialization failure, deadlock, or any connection exception.
+		code := sql.SQLState()
+		if code == "40001" || code == "40P01" || strings.HasPrefix(code, "08") {
+			return true
+		}
+	}
+	return errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED)
+}
+
+// RetryStore calls "f" until it succeeds, reports a non-Retryable error, or
+// has been retried "retries" times. Each retry waits twice as long as the
+// previous one, starting at "backoff".
+func retryStore(ctx context.Context, retries int, backoff time.Duration, f func() error) error {
+	if backoff <= 0 {
+		backoff = DefaultStoreRetryBackoff
+	}
+	for i := 0; ; i++ {
+		err := f()
+		if err == nil || i >= retries || !Retryable(err) {
+			return err
+		}
+		zlog.Info(ctx).
+			Err(err).
+			Int("attempt", i+1).
+			Dur("wait", backoff).
+			Msg("retrying store write")
+		t := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return err
+		case <-t.C:
+		}
+		backoff *= 2
+	}
+}
@@ -0,0 +1,114 @@
+package indexer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/quay/zlog"
+)
+
+type sqlStateErr string
+
+func (e sqlStateErr) Error() string    { return "sql error " + string(e) }
+func (e sqlStateErr) SQLState() string { return string(e) }
+
+type temporaryErr bool
+
+func (e temporaryErr) Error() string   { return "temporary error" }
+func (e temporaryErr) Temporary() bool { return bool(e) }
+
+func TestRetryable(t *testing.T) {
+	tt := []struct {
+		Name string
+		Err  error
+		Want bool
+	}{
+		{"Nil", nil, false},
+		{"Plain", errors.New("nope"), false},
+		{"Canceled", fmt.Errorf("failed: %w", context.Canceled), false},
+		{"Serialization", fmt.Errorf("failed: %w", sqlStateErr("40001")), true},
+		{"Deadlock", sqlStateErr("40P01"), true},
+		{"ConnectionFailure", sqlStateErr("08006"), true},
+		{"UniqueViolation", sqlStateErr("23505"), false},
+		{"Temporary", fmt.Errorf("failed: %w", temporaryErr(true)), true},
+		{"NotTemporary", temporaryErr(false), false},
+		{"ConnReset", fmt.Errorf("write: %w", syscall.ECONNRESET), true},
+	}
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			if got := Retryable(tc.Err); got != tc.Want {
+				t.Errorf("got: %v, want: %v", got, tc.Want)
+			}
+		})
+	}
+}
+
+func TestRetryStore(t *testing.T) {
+	ctx := zlog.Test(context.Background(), t)
+	const backoff = time.Millisecond
+	transient := sqlStateErr("40001")
+	// Fail returns a func failing with "err" the first "n" calls, and counting
+	// calls in "ct".
+	fail := func(n int, err error, ct *int) func() error {
+		return func() error {
+			*ct++
+			if *ct <= n {
+				return err
+			}
+			return nil
+		}
+	}
+
+	t.Run("Recovers", func(t *testing.T) {
+		var ct int
+		if err := retryStore(ctx, 3, backoff, fail(2, transient, &ct)); err != nil {
+			t.Error(err)
+		}
+		if got, want := ct, 3; got != want {
+			t.Errorf("got: %d calls, want: %d", got, want)
+		}
+	})
+	t.Run("GivesUp", func(t *testing.T) {
+		var ct int
+		err := retryStore(ctx, 2, backoff, fail(5, transient, &ct))
+		if !errors.Is(err, transient) {
+			t.Errorf("got: %v, want: %v", err, transient)
+		}
+		if got, want := ct, 3; got != want {
+			t.Errorf("got: %d calls, want: %d", got, want)
+		}
+	})
+	t.Run("Disabled", func(t *testing.T) {
+		var ct int
+		if err := retryStore(ctx, 0, backoff, fail(1, transient, &ct)); err == nil {
+			t.Error("expected error")
+		}
+		if got, want := ct, 1; got != want {
+			t.Errorf("got: %d calls, want: %d", got, want)
+		}
+	})
+	t.Run("Permanent", func(t *testing.T) {
+		var ct int
+		if err := retryStore(ctx, 3, backoff, fail(1, errors.New("permanent"), &ct)); err == nil {
+			t.Error("expected error")
+		}
+		if got, want := ct, 1; got != want {
+			t.Errorf("got: %d calls, want: %d", got, want)
+		}
+	})
+	t.Run("Canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(ctx)
+		cancel()
+		var ct int
+		if err := retryStore(ctx, 3, time.Hour, fail(5, transient, &ct)); err == nil {
+			t.Error("expected error")
+		}
+		if got, want := ct, 1; got != want {
+			t.Errorf("got: %d calls, want: %d", got, want)
+		}
+	})
+}
@@ -0,0 +1,55 @@
+package indexer
+
+import (
+	"github.com/quay/claircore"
+)
+
+// ScanPlan describes the work a LayerScanner would do for a manifest.
+//
+// See LayerScanner.Plan.
+type ScanPlan struct {
+	Manifest claircore.Digest
+	// Layers is the layers that would be scanned, with duplicates removed.
+	Layers []*claircore.Layer
+	// Scanners is the scanners that would be run over every layer.
+	Scanners []PlannedScanner
+}
+
+// PlannedScanner is a scanner in a ScanPlan.
+type PlannedScanner struct {
+	VersionedScanner
+	// Deferred is set if the scanner waits for distribution detection
+	// before running.
+	Deferred bool
+	// Scoped is set if the scanner implements DistributionScoped, so whether
+	// it actually runs depends on the distributions detected.
+	Scoped bool
+}
+
+// Scans reports the maximum number of (layer, scanner) pairs the plan would
+// run. Layers with results from previous scans and scanners that don't apply
+// to the detected distributions make the actual number lower.
+func (p *ScanPlan) Scans() int {
+	return len(p.Layers) * len(p.Scanners)
+}
+
+// Plan returns the plan a call to Scan with the same arguments would follow,
+// without doing any work.
+//
+// This can be used to check the configuration or estimate the cost of a scan
+// before starting it.
+func (ls *LayerScanner) Plan(manifest claircore.Digest, layers []*claircore.Layer) ScanPlan {
+	p := ScanPlan{
+		Manifest: manifest,
+		Layers:   dedupeLayers(layers),
+	}
+	for _, s := range ls.scanners() {
+		_, scoped := s.(DistributionScoped)
+		p.Scanners = append(p.Scanners, PlannedScanner{
+			VersionedScanner: s,
+			Deferred:         ls.phaseOf(s) == phaseSecond,
+			Scoped:           scoped,
+		})
+	}
+	return p
+}
@@ -0,0 +1,107 @@
+package indexer_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/quay/claircore"
+	"github.com/quay/claircore/indexer"
+	mock_indexer "github.com/quay/claircore/test/mock/indexer"
+)
+
+func TestPlan(t *testing.T) {
+	ctx := context.Background()
+	type planned struct {
+		Name     string
+		Deferred bool
+		Scoped   bool
+	}
+	tt := []struct {
+		TwoPhase bool
+		Want     []planned
+	}{
+		{
+			TwoPhase: false,
+			Want: []planned{
+				{Name: "pkg"},
+				{Name: "scoped", Deferred: true, Scoped: true},
+				{Name: "dist"},
+				{Name: "repo"},
+			},
+		},
+		{
+			TwoPhase: true,
+			Want: []planned{
+				{Name: "pkg", Deferred: true},
+				{Name: "scoped", Deferred: true, Scoped: true},
+				{Name: "dist"},
+				{Name: "repo"},
+			},
+		},
+	}
+	for _, tc := range tt {
+		t.Run(fmt.Sprintf("TwoPhase=%v", tc.TwoPhase), func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			// The store and the scanners' Scan methods must not be called.
+			store := mock_indexer.NewMockStore(ctrl)
+			pkg := mock_indexer.NewMockPackageScanner(ctrl)
+			pkg.EXPECT().Name().AnyTimes().Return("pkg")
+			pkg.EXPECT().Kind().AnyTimes().Return("package")
+			sp := mock_indexer.NewMockPackageScanner(ctrl)
+			sp.EXPECT().Name().AnyTimes().Return("scoped")
+			sp.EXPECT().Kind().AnyTimes().Return("package")
+			scoped := &scopedScanner{MockPackageScanner: sp, did: "debian"}
+			ds := mock_indexer.NewMockDistributionScanner(ctrl)
+			ds.EXPECT().Name().AnyTimes().Return("dist")
+			ds.EXPECT().Kind().AnyTimes().Return("distribution")
+			rs := mock_indexer.NewMockRepositoryScanner(ctrl)
+			rs.EXPECT().Name().AnyTimes().Return("repo")
+			rs.EXPECT().Kind().AnyTimes().Return("repository")
+
+			ls, err := indexer.NewLayerScanner(ctx, 1, &indexer.Options{
+				Store:        store,
+				TwoPhaseScan: tc.TwoPhase,
+				Ecosystems: []*indexer.Ecosystem{{
+					Name: "mock",
+					PackageScanners: func(context.Context) ([]indexer.PackageScanner, error) {
+						return []indexer.PackageScanner{pkg, scoped}, nil
+					},
+					DistributionScanners: func(context.Context) ([]indexer.DistributionScanner, error) {
+						return []indexer.DistributionScanner{ds}, nil
+					},
+					RepositoryScanners: func(context.Context) ([]indexer.RepositoryScanner, error) {
+						return []indexer.RepositoryScanner{rs}, nil
+					},
+					FileScanners: func(context.Context) ([]indexer.FileScanner, error) { return nil, nil },
+				}},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			a := &claircore.Layer{Hash: claircore.MustParseDigest(`sha256:` + strings.Repeat("a", 64))}
+			b := &claircore.Layer{Hash: claircore.MustParseDigest(`sha256:` + strings.Repeat("b", 64))}
+			m := claircore.MustParseDigest(`sha256:` + strings.Repeat("c", 64))
+			p := ls.Plan(m, []*claircore.Layer{a, b, a})
+
+			if got, want := len(p.Layers), 2; got != want {
+				t.Errorf("got: %d layers, want: %d", got, want)
+			}
+			var got []planned
+			for _, s := range p.Scanners {
+				got = append(got, planned{Name: s.Name(), Deferred: s.Deferred, Scoped: s.Scoped})
+			}
+			if !cmp.Equal(got, tc.Want) {
+				t.Error(cmp.Diff(got, tc.Want))
+			}
+			if got, want := p.Scans(), 8; got != want {
+				t.Errorf("got: %d scans, want: %d", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,134 @@
+package indexer
+
+import (
+	"archive/tar"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"runtime/debug"
+
+	"github.com/quay/zlog"
+
+	"github.com/quay/claircore"
+)
+
+// SelfTest runs every configured scanner over a minimal synthetic layer, a
+// tar archive holding only empty directories, and reports the scanners that
+// fail.
+//
+// A scanner fails if it returns an error, panics, or reports results for the
+// empty layer. The returned error joins an error for every failing scanner.
+// Nothing is written to the Store.
+//
+// It's meant to be called before accepting work, so a misconfigured scanner
+// shows up as a startup failure instead of as empty reports.
+func (ls *LayerScanner) SelfTest(ctx context.Context) error {
+	ctx = zlog.ContextWithValues(ctx, "component", "indexer/LayerScanner.SelfTest")
+	l, cleanup, err := selfTestLayer()
+	if err != nil {
+		return fmt.Errorf("indexer: unable to create self-test layer: %w", err)
+	}
+	defer cleanup()
+
+	var errs []error
+	for _, s := range ls.scanners() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		ctx := zlog.ContextWithValues(ctx, "scanner", s.Name(), "kind", s.Kind())
+		if lvl, ok := ls.logLevels[s.Name()]; ok {
+			ctx = zlog.ContextWithValues(ctx, logLevelKey, lvl.String())
+		}
+		if err := selfTest(ctx, s, l); err != nil {
+			zlog.Warn(ctx).Err(err).Msg("self-test failed")
+			errs = append(errs, fmt.Errorf("indexer: scanner %q (%s): %w", s.Name(), s.Kind(), err))
+			continue
+		}
+		zlog.Debug(ctx).Msg("self-test ok")
+	}
+	return errors.Join(errs...)
+}
+
+// SelfTest runs the scanner over the layer.
+func selfTest(ctx context.Context, s VersionedScanner, l *claircore.Layer) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			zlog.Debug(ctx).Bytes("stack", debug.Stack()).Send()
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	var n int
+	switch s := s.(type) {
+	case PackageScanner:
+		var r []*claircore.Package
+		r, err = s.Scan(ctx, l)
+		n = len(r)
+	case DistributionScanner:
+		var r []*claircore.Distribution
+		r, err = s.Scan(ctx, l)
+		n = len(r)
+	case RepositoryScanner:
+		var r []*claircore.Repository
+		r, err = s.Scan(ctx, l)
+		n = len(r)
+	case FileScanner:
+		var r []claircore.File
+		r, err = s.Scan(ctx, l)
+		n = len(r)
+	default:
+		panic(fmt.Sprintf("programmer error: unknown type %T used as scanner", s))
+	}
+	switch {
+	case err != nil:
+		return err
+	case n != 0:
+		return fmt.Errorf("found %d results in an empty layer", n)
+	}
+	return nil
+}
+
+// SelfTestLayer writes the self-test layer to a temporary file, returning the
+// Layer and a function to remove the file.
+func selfTestLayer() (*claircore.Layer, func(), error) {
+	f, err := os.CreateTemp("", "selftest.*.tar")
+	if err != nil {
+		return nil, nil, err
+	}
+	cleanup := func() { os.Remove(f.Name()) }
+	h := sha256.New()
+	tw := tar.NewWriter(io.MultiWriter(f, h))
+	for _, d := range []string{"etc/", "usr/", "usr/lib/", "var/", "var/lib/"} {
+		if err := tw.WriteHeader(&tar.Header{
+			Typeflag: tar.TypeDir,
+			Name:     d,
+			Mode:     0o755,
+		}); err != nil {
+			f.Close()
+			cleanup()
+			return nil, nil, err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		f.Close()
+		cleanup()
+		return nil, nil, err
+	}
+	if err := f.Close(); err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+	d, err := claircore.NewDigest(claircore.SHA256, h.Sum(nil))
+	if err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+	l := &claircore.Layer{Hash: d}
+	if err := l.SetLocal(f.Name()); err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+	return l, cleanup, nil
+}
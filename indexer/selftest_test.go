@@ -0,0 +1,76 @@
+package indexer_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+
+	"github.com/quay/claircore"
+	"github.com/quay/claircore/indexer"
+	mock_indexer "github.com/quay/claircore/test/mock/indexer"
+)
+
+func TestSelfTest(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+
+	newScanner := func(name string) *mock_indexer.MockPackageScanner {
+		s := mock_indexer.NewMockPackageScanner(ctrl)
+		s.EXPECT().Name().AnyTimes().Return(name)
+		s.EXPECT().Version().AnyTimes().Return("1")
+		s.EXPECT().Kind().AnyTimes().Return("package")
+		return s
+	}
+	good := newScanner("good")
+	good.EXPECT().Scan(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, l *claircore.Layer) ([]*claircore.Package, error) {
+			// The layer must be usable like a fetched layer.
+			sys, err := l.FS()
+			if err != nil {
+				return nil, err
+			}
+			defer sys.Close()
+			return nil, nil
+		})
+	broken := newScanner("broken")
+	broken.EXPECT().Scan(gomock.Any(), gomock.Any()).Return(nil, errors.New("misconfigured"))
+	panicky := newScanner("panicky")
+	panicky.EXPECT().Scan(gomock.Any(), gomock.Any()).Do(func(context.Context, *claircore.Layer) { panic("oops") })
+	liar := newScanner("liar")
+	liar.EXPECT().Scan(gomock.Any(), gomock.Any()).Return([]*claircore.Package{{Name: "ghost"}}, nil)
+
+	// No expectations: the Store must not be used.
+	store := mock_indexer.NewMockStore(ctrl)
+	ls, err := indexer.NewLayerScanner(ctx, 1, &indexer.Options{
+		Store: store,
+		Ecosystems: []*indexer.Ecosystem{{
+			Name: "mock",
+			PackageScanners: func(context.Context) ([]indexer.PackageScanner, error) {
+				return []indexer.PackageScanner{good, broken, panicky, liar}, nil
+			},
+			DistributionScanners: func(context.Context) ([]indexer.DistributionScanner, error) { return nil, nil },
+			RepositoryScanners:   func(context.Context) ([]indexer.RepositoryScanner, error) { return nil, nil },
+			FileScanners:         func(context.Context) ([]indexer.FileScanner, error) { return nil, nil },
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ls.SelfTest(ctx)
+	t.Log(err)
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	msg := err.Error()
+	for _, n := range []string{"broken", "panicky", "liar"} {
+		if !strings.Contains(msg, `"`+n+`"`) {
+			t.Errorf("error doesn't mention %q", n)
+		}
+	}
+	if strings.Contains(msg, `"good"`) {
+		t.Error(`error mentions "good"`)
+	}
+}
@@ -32,6 +32,12 @@ type Setter interface {
 	//
 	// After this method is returned a call to Querier.LayerScanned with the same arguments must return true.
 	SetLayerScanned(ctx context.Context, hash claircore.Digest, scnr VersionedScanner) error
+	// SetLayerResults is like SetLayerScanned, but also records the number of
+	// results the scanner produced for the layer.
+	//
+	// After this method is returned a call to Querier.LayerResults with the same arguments must return
+	// the provided count.
+	SetLayerResults(ctx context.Context, hash claircore.Digest, scnr VersionedScanner, n int) error
 	// RegisterPackageScanners registers the provided scanners with the persistence layer.
 	RegisterScanners(ctx context.Context, scnrs VersionedScanners) error
 	// SetIndexReport persists the current state of the IndexReport.
@@ -57,8 +63,16 @@ type Querier interface {
 	ManifestScanned(ctx context.Context, hash claircore.Digest, scnrs VersionedScanners) (bool, error)
 	// LayerScanned returns whether the given layer was scanned by the provided scanner.
 	LayerScanned(ctx context.Context, hash claircore.Digest, scnr VersionedScanner) (bool, error)
+	// LayerResults returns the number of results the provided scanner produced for the given layer.
+	//
+	// The boolean reports whether the count is known. It's false if the layer wasn't scanned by the
+	// scanner, or if the scan was marked with SetLayerScanned and so has no count recorded.
+	LayerResults(ctx context.Context, hash claircore.Digest, scnr VersionedScanner) (int, bool, error)
 	// PackagesByLayer gets all the packages found in a layer limited by the provided scanners.
 	PackagesByLayer(ctx context.Context, hash claircore.Digest, scnrs VersionedScanners) ([]*claircore.Package, error)
+	// PackageDependencies returns the dependency edges recorded for the packages found in the
+	// given manifest.
+	PackageDependencies(ctx context.Context, manifest claircore.Digest) ([]claircore.Dependency, error)
 	// DistributionsByLayer gets all the distributions found in a layer limited by the provided scanners.
 	DistributionsByLayer(ctx context.Context, hash claircore.Digest, scnrs VersionedScanners) ([]*claircore.Distribution, error)
 	// RepositoriesByLayer gets all the repositories found in a layer limited by the provided scanners.
@@ -70,6 +84,10 @@ type Querier interface {
 	// AffectedManifests returns a list of manifest digests which the target vulnerability
 	// affects.
 	AffectedManifests(ctx context.Context, v claircore.Vulnerability, f claircore.CheckVulnernableFunc) ([]claircore.Digest, error)
+	// StaleManifests returns the manifests which were indexed with a version of any of the
+	// provided scanners other than the provided one, and haven't been indexed since with the
+	// provided version. These need to be indexed again to pick up changes to the scanners.
+	StaleManifests(ctx context.Context, scnrs []ScannerInfo) ([]claircore.Digest, error)
 }
 
 // Indexer interface provide the method set required for indexing layer and manifest contents into
@@ -0,0 +1,83 @@
+package indexer_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/quay/claircore"
+	"github.com/quay/claircore/pkg/tracing"
+	mock_indexer "github.com/quay/claircore/test/mock/indexer"
+)
+
+// RecordingTracer keeps every Span it starts.
+type recordingTracer struct {
+	mu    sync.Mutex
+	spans []*recordedSpan
+}
+
+type recordedSpan struct {
+	Name  string
+	Attrs map[string]string
+	Err   string
+	Ended bool
+}
+
+func (r *recordingTracer) Start(ctx context.Context, name string, attrs ...tracing.Attr) (context.Context, tracing.Span) {
+	s := &recordedSpan{Name: name, Attrs: make(map[string]string)}
+	for _, a := range attrs {
+		s.Attrs[a.Key] = a.Value
+	}
+	r.mu.Lock()
+	r.spans = append(r.spans, s)
+	r.mu.Unlock()
+	return ctx, s
+}
+
+func (s *recordedSpan) RecordError(err error) { s.Err = err.Error() }
+func (s *recordedSpan) End()                  { s.Ended = true }
+
+func TestTracing(t *testing.T) {
+	ctx := context.Background()
+	tr := &recordingTracer{}
+	tracing.Set(tr)
+	t.Cleanup(func() { tracing.Set(nil) })
+
+	store := mock_indexer.NewMockStore(gomock.NewController(t))
+	store.EXPECT().LayerScanned(gomock.Any(), gomock.Any(), gomock.Any()).Return(false, errors.New("store down"))
+	ls := newMockLayerScanner(ctx, t, store)
+
+	l0 := claircore.MustParseDigest(`sha256:` + strings.Repeat("a", 64))
+	m := claircore.MustParseDigest(`sha256:` + strings.Repeat("b", 64))
+	if err := ls.Scan(ctx, m, []*claircore.Layer{{Hash: l0}}); err == nil {
+		t.Fatal("expected error")
+	}
+
+	want := []*recordedSpan{
+		{
+			Name:  "indexer.LayerScanner.Scan",
+			Attrs: map[string]string{"manifest": m.String()},
+			Err:   "store down",
+			Ended: true,
+		},
+		{
+			Name: "indexer.LayerScanner.scanLayer",
+			Attrs: map[string]string{
+				"scanner":         "mock",
+				"scanner.version": "1",
+				"scanner.kind":    "package",
+				"layer":           l0.String(),
+			},
+			Err:   "store down",
+			Ended: true,
+		},
+	}
+	if got := tr.spans; !cmp.Equal(got, want) {
+		t.Error(cmp.Diff(got, want))
+	}
+}
@@ -0,0 +1,35 @@
+package indexer
+
+import (
+	"context"
+
+	"github.com/quay/claircore"
+)
+
+// ScanResult is the output of running a single scanner on a single layer.
+//
+// Only the member matching the scanner's kind is populated.
+type ScanResult struct {
+	Packages      []*claircore.Package
+	Distributions []*claircore.Distribution
+	Repositories  []*claircore.Repository
+	Files         []claircore.File
+}
+
+// ResultTransformer is an extension point for modifying scan results before
+// they're stored, such as normalizing package names.
+//
+// Transform may modify or replace the members of the ScanResult. Returning an
+// error prevents the result from being stored, and fails the scan.
+type ResultTransformer interface {
+	Transform(ctx context.Context, s VersionedScanner, l *claircore.Layer, r *ScanResult) error
+}
+
+// ResultTransformerFunc is an adapter to allow the use of an ordinary
+// function as a ResultTransformer.
+type ResultTransformerFunc func(ctx context.Context, s VersionedScanner, l *claircore.Layer, r *ScanResult) error
+
+// Transform implements ResultTransformer.
+func (f ResultTransformerFunc) Transform(ctx context.Context, s VersionedScanner, l *claircore.Layer, r *ScanResult) error {
+	return f(ctx, s, l, r)
+}
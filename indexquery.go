@@ -0,0 +1,94 @@
+package claircore
+
+import (
+	"path"
+	"sort"
+)
+
+// IndexQuery provides read-only queries over an IndexReport, as returned by
+// IndexReport.Query.
+//
+// Results are sorted, and share pointers with the report.
+type IndexQuery struct {
+	r *IndexReport
+}
+
+// Query returns an IndexQuery over the report's current contents.
+func (report *IndexReport) Query() *IndexQuery {
+	return &IndexQuery{r: report}
+}
+
+// PackagesInLayer returns the packages that were introduced in the layer
+// with the provided digest.
+func (q *IndexQuery) PackagesInLayer(d Digest) []*Package {
+	want := d.String()
+	return q.packages(func(p *Package) bool {
+		for _, env := range q.r.Environments[p.ID] {
+			if env != nil && env.IntroducedIn.String() == want {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// PackagesByName returns the packages with a name matching the provided
+// glob, using the syntax of path.Match.
+//
+// The only possible returned error is path.ErrBadPattern.
+func (q *IndexQuery) PackagesByName(glob string) ([]*Package, error) {
+	// Check the pattern up front, so an empty report still reports it.
+	if _, err := path.Match(glob, ""); err != nil {
+		return nil, err
+	}
+	return q.packages(func(p *Package) bool {
+		ok, _ := path.Match(glob, p.Name)
+		return ok
+	}), nil
+}
+
+// Distributions returns the distributions in the report.
+func (q *IndexQuery) Distributions() []*Distribution {
+	out := make([]*Distribution, 0, len(q.r.Distributions))
+	for _, d := range q.r.Distributions {
+		if d != nil {
+			out = append(out, d)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out
+}
+
+// Repositories returns the repositories in the report.
+func (q *IndexQuery) Repositories() []*Repository {
+	out := make([]*Repository, 0, len(q.r.Repositories))
+	for _, r := range q.r.Repositories {
+		if r != nil {
+			out = append(out, r)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out
+}
+
+// Packages returns the packages that "f" reports true for, sorted by name,
+// version, and then ID.
+func (q *IndexQuery) packages(f func(*Package) bool) []*Package {
+	out := []*Package{}
+	for _, p := range q.r.Packages {
+		if p != nil && f(p) {
+			out = append(out, p)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		a, b := out[i], out[j]
+		switch {
+		case a.Name != b.Name:
+			return a.Name < b.Name
+		case a.Version != b.Version:
+			return a.Version < b.Version
+		}
+		return a.ID < b.ID
+	})
+	return out
+}
@@ -0,0 +1,71 @@
+package claircore_test
+
+import (
+	"errors"
+	"path"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/quay/claircore"
+)
+
+func TestIndexQuery(t *testing.T) {
+	var (
+		base = claircore.MustParseDigest(`sha256:` + "1111111111111111111111111111111111111111111111111111111111111111")
+		app  = claircore.MustParseDigest(`sha256:` + "2222222222222222222222222222222222222222222222222222222222222222")
+	)
+	ir := &claircore.IndexReport{
+		Packages: map[string]*claircore.Package{
+			"1": {ID: "1", Name: "openssl-libs", Version: "1.1.1k"},
+			"2": {ID: "2", Name: "openssl", Version: "1.1.1k"},
+			"3": {ID: "3", Name: "curl", Version: "7.76.1"},
+			"4": {ID: "4", Name: "requests", Version: "2.31.0"},
+		},
+		Environments: map[string][]*claircore.Environment{
+			"1": {{IntroducedIn: base}},
+			"2": {{IntroducedIn: base}},
+			"3": {{IntroducedIn: base}, {IntroducedIn: app}},
+			"4": {{IntroducedIn: app}},
+		},
+		Distributions: map[string]*claircore.Distribution{
+			"2": {ID: "2", Name: "Red Hat Enterprise Linux Server"},
+			"1": {ID: "1", Name: "Debian GNU/Linux"},
+		},
+	}
+	q := ir.Query()
+	names := func(ps []*claircore.Package) []string {
+		out := []string{}
+		for _, p := range ps {
+			out = append(out, p.Name)
+		}
+		return out
+	}
+
+	if got, want := names(q.PackagesInLayer(app)), []string{"curl", "requests"}; !cmp.Equal(got, want) {
+		t.Error(cmp.Diff(got, want))
+	}
+	if got, want := names(q.PackagesInLayer(base)), []string{"curl", "openssl", "openssl-libs"}; !cmp.Equal(got, want) {
+		t.Error(cmp.Diff(got, want))
+	}
+	ps, err := q.PackagesByName("openssl*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := names(ps), []string{"openssl", "openssl-libs"}; !cmp.Equal(got, want) {
+		t.Error(cmp.Diff(got, want))
+	}
+	if _, err := q.PackagesByName("[openssl"); !errors.Is(err, path.ErrBadPattern) {
+		t.Errorf("got: %v, want: %v", err, path.ErrBadPattern)
+	}
+	var ds []string
+	for _, d := range q.Distributions() {
+		ds = append(ds, d.ID)
+	}
+	if got, want := ds, []string{"1", "2"}; !cmp.Equal(got, want) {
+		t.Error(cmp.Diff(got, want))
+	}
+	if got := q.Repositories(); len(got) != 0 {
+		t.Errorf("got: %v, want: empty", got)
+	}
+}
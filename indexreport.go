@@ -12,11 +12,20 @@ type IndexRecord struct {
 	Repository   *Repository
 }
 
+// DistributionMinimal is the IndexReport's DistributionTag for images where no
+// distribution was detected, like busybox images or scratch images holding
+// static binaries. Packages found in them can only be matched by their own
+// ecosystems, like Go modules or Python packages.
+const DistributionMinimal = "unknown/minimal"
+
 // IndexReport provides a database for discovered artifacts in an image.
 //
 // IndexReports make heavy usage of lookup maps to associate information
 // without repetition.
 type IndexReport struct {
+	// the version of the serialized form of the report; see
+	// ReportSchemaVersion and DecodeReport
+	SchemaVersion int `json:"schema_version"`
 	// the manifest hash this IndexReport is describing
 	Hash Digest `json:"manifest_hash"`
 	// the current state of the index operation
@@ -29,6 +38,28 @@ type IndexReport struct {
 	Repositories map[string]*Repository `json:"repository"`
 	// a list of environment details a package was discovered in key'd by package id
 	Environments map[string][]*Environment `json:"environments"`
+	// the id of the distribution packages are matched against; when more
+	// than one distribution was found, every environment refers to this one
+	PrimaryDistribution string `json:"primary_distribution,omitempty"`
+	// set to DistributionMinimal when no distribution was detected at all
+	DistributionTag string `json:"distribution_tag,omitempty"`
+	// the release of the kernel the image is configured to boot, if detected
+	ActiveKernel string `json:"active_kernel,omitempty"`
+	// the runtime configuration from the image's config, if one was provided
+	// with the Manifest
+	ImageConfig *ImageConfig `json:"image_config,omitempty"`
+	// images found as tarballs inside the manifest's layers, which are indexed
+	// as manifests of their own
+	EmbeddedManifests []EmbeddedManifest `json:"embedded_manifests,omitempty"`
+	// language packages whose files are owned by an OS package key'd by
+	// package id, to the id of the OS package, which is the canonical entry.
+	// only populated if ownership was reconciled during indexing
+	CanonicalPackages map[string]string `json:"canonical_packages,omitempty"`
+	// problems found while indexing that may make the report incomplete,
+	// like a detected distribution with no package scanner configured
+	Warnings []string `json:"warnings,omitempty"`
+	// statistics about the work done to produce this report
+	Stats *IndexStats `json:"stats,omitempty"`
 	// whether the index operation finished successfully
 	Success bool `json:"success"`
 	// an error string in the case the index did not succeed
@@ -37,6 +68,37 @@ type IndexReport struct {
 	Files map[string]File `json:"-"`
 }
 
+// IndexStats are statistics about the work done to index a manifest, useful
+// for tracking the cost of indexing.
+//
+// Layers with results from indexing a previous manifest aren't scanned again,
+// so only scanned layers contribute to the byte and package counts.
+type IndexStats struct {
+	// the number of distinct layers in the manifest
+	Layers int `json:"layers"`
+	// the number of layers that were scanned
+	LayersScanned int `json:"layers_scanned"`
+	// layers that were skipped because they couldn't be read
+	LayersSkipped []Digest `json:"layers_skipped,omitempty"`
+	// layers with more results than allowed, which were only partially
+	// stored
+	LayersTruncated []Digest `json:"layers_truncated,omitempty"`
+	// the total size of the scanned layers, uncompressed
+	Bytes int64 `json:"bytes"`
+	// the number of packages found in the scanned layers key'd by ecosystem
+	Packages map[string]int `json:"packages,omitempty"`
+}
+
+// CanonicalPackage returns the id of the authoritative package for the
+// package with the provided id: the OS package owning its files, if one was
+// recorded, or else the id itself.
+func (report *IndexReport) CanonicalPackage(id string) string {
+	if c, ok := report.CanonicalPackages[id]; ok {
+		return c
+	}
+	return id
+}
+
 // IndexRecords returns a list of IndexRecords derived from the IndexReport
 func (report *IndexReport) IndexRecords() []*IndexRecord {
 	out := []*IndexRecord{}
@@ -0,0 +1,34 @@
+package initramfs
+
+import (
+	"context"
+
+	"github.com/quay/claircore"
+	"github.com/quay/claircore/indexer"
+)
+
+type coalescer struct{}
+
+// Coalesce implements [indexer.Coalescer].
+//
+// The contents of an image are only ever changed by replacing the whole
+// image, so the packages found in each layer are reported as-is.
+func (c *coalescer) Coalesce(ctx context.Context, ls []*indexer.LayerArtifacts) (*claircore.IndexReport, error) {
+	ir := &claircore.IndexReport{
+		Environments: map[string][]*claircore.Environment{},
+		Packages:     map[string]*claircore.Package{},
+		Repositories: map[string]*claircore.Repository{},
+	}
+	for _, l := range ls {
+		for _, pkg := range l.Pkgs {
+			ir.Packages[pkg.ID] = pkg
+			ir.Environments[pkg.ID] = []*claircore.Environment{
+				{
+					PackageDB:    pkg.PackageDB,
+					IntroducedIn: l.Hash,
+				},
+			}
+		}
+	}
+	return ir, nil
+}
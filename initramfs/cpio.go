@@ -0,0 +1,255 @@
+package initramfs
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"time"
+
+	"github.com/ulikunitz/xz"
+
+	"github.com/quay/claircore/pkg/decompress"
+)
+
+// An initramfs image is one or more "newc" cpio archives, concatenated and
+// padded with NULs. The kernel also accepts compressed archives; the usual
+// layout is an uncompressed "early" archive holding CPU microcode followed by
+// a compressed archive holding everything else.
+
+const (
+	magicNewc  = "070701"
+	magicCRC   = "070702"
+	headerSz   = 110
+	trailer    = "TRAILER!!!"
+	maxNameSz  = 4096
+	maxLinkSz  = 4096
+	typeMask   = 0o170000
+	typeDir    = 0o040000
+	typeReg    = 0o100000
+	typeLink   = 0o120000
+	permMask   = 0o7777
+	fieldCount = 13
+)
+
+// MagicXz is checked for if no registered decompressor recognizes a stream,
+// as xz-compressed images are common but xz isn't registered by default.
+var magicXz = []byte{0xfd, '7', 'z', 'X', 'Z', 0x00}
+
+var (
+	// ErrTooLarge is returned when the contents of an image exceed the
+	// configured size limit.
+	errTooLarge = errors.New("initramfs: contents exceed size limit")
+	// ErrFormat is returned when an image isn't a cpio archive in a known
+	// format.
+	errFormat = errors.New("initramfs: unknown archive format")
+)
+
+// Extractor copies the contents of cpio archives into a tar stream.
+type extractor struct {
+	tw *tar.Writer
+	// Rem is the number of bytes of file contents left before the size limit
+	// is reached.
+	rem int64
+}
+
+// Archives reads all the (possibly compressed) cpio archives in "r".
+// Compressed archives are recognized by the formats registered with
+// decompress.RegisterDecompressor.
+//
+// Anything following a compressed archive is ignored.
+func (e *extractor) archives(r io.Reader) error {
+	br := bufio.NewReader(r)
+	for {
+		if err := skipPadding(br); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return fmt.Errorf("initramfs: unable to read image: %w", err)
+		}
+		n := decompress.MagicLen()
+		if n < len(magicXz) {
+			n = len(magicXz)
+		}
+		b, _ := br.Peek(n)
+		d := decompress.Detect(b)
+		switch {
+		case bytes.HasPrefix(b, []byte(magicNewc)), bytes.HasPrefix(b, []byte(magicCRC)):
+			if err := e.archive(br); err != nil {
+				return err
+			}
+			continue
+		case d != nil:
+			z, err := d.NewReader(br)
+			if err != nil {
+				return fmt.Errorf("initramfs: unable to open %s stream: %w", d.Name, err)
+			}
+			defer z.Close()
+			return e.archives(z)
+		case bytes.HasPrefix(b, magicXz):
+			z, err := xz.NewReader(br)
+			if err != nil {
+				return fmt.Errorf("initramfs: unable to open xz stream: %w", err)
+			}
+			return e.archives(z)
+		}
+		return errFormat
+	}
+}
+
+// Archive reads a single cpio archive, up to and including its trailer entry.
+func (e *extractor) archive(br *bufio.Reader) error {
+	var b [headerSz]byte
+	for {
+		if _, err := io.ReadFull(br, b[:]); err != nil {
+			return fmt.Errorf("initramfs: unable to read header: %w", err)
+		}
+		h, err := parseHeader(b[:])
+		if err != nil {
+			return err
+		}
+		name := make([]byte, h.NameSize)
+		if _, err := io.ReadFull(br, name); err != nil {
+			return fmt.Errorf("initramfs: unable to read name: %w", err)
+		}
+		if name[len(name)-1] != 0 {
+			return fmt.Errorf("initramfs: unterminated name %q", name)
+		}
+		if _, err := br.Discard(pad(headerSz + h.NameSize)); err != nil {
+			return fmt.Errorf("initramfs: unable to read name: %w", err)
+		}
+		n := string(name[:len(name)-1])
+		if n == trailer {
+			return nil
+		}
+		if err := e.entry(br, &h, n); err != nil {
+			return err
+		}
+		if _, err := br.Discard(pad(h.Size)); err != nil {
+			return fmt.Errorf("initramfs: unable to read %q: %w", n, err)
+		}
+	}
+}
+
+// Entry writes the entry "n" to the tar stream, consuming its contents.
+//
+// Only directories, regular files, and symlinks are copied. Hard links are
+// written as separate files; the cpio format only stores the contents with the
+// last link, so earlier links end up empty.
+func (e *extractor) entry(br *bufio.Reader, h *header, n string) error {
+	// Cpio names are usually relative, but there's nothing stopping them
+	// from being absolute or escaping the root.
+	n = path.Clean("/" + n)[1:]
+	hdr := tar.Header{
+		Name:    n,
+		Mode:    int64(h.Mode & permMask),
+		ModTime: time.Unix(h.MTime, 0),
+	}
+	t := h.Mode & typeMask
+	if n == "" {
+		// The root directory, which the tar stream has implicitly.
+		t = 0
+	}
+	switch t {
+	case typeDir:
+		hdr.Typeflag = tar.TypeDir
+		hdr.Name += "/"
+		if err := e.tw.WriteHeader(&hdr); err != nil {
+			return fmt.Errorf("initramfs: unable to write %q: %w", n, err)
+		}
+	case typeReg:
+		if h.Size > e.rem {
+			return errTooLarge
+		}
+		e.rem -= h.Size
+		hdr.Typeflag = tar.TypeReg
+		hdr.Size = h.Size
+		if err := e.tw.WriteHeader(&hdr); err != nil {
+			return fmt.Errorf("initramfs: unable to write %q: %w", n, err)
+		}
+		if _, err := io.CopyN(e.tw, br, h.Size); err != nil {
+			return fmt.Errorf("initramfs: unable to copy %q: %w", n, err)
+		}
+		return nil
+	case typeLink:
+		if h.Size > maxLinkSz {
+			return fmt.Errorf("initramfs: overlong link target for %q", n)
+		}
+		tgt := make([]byte, h.Size)
+		if _, err := io.ReadFull(br, tgt); err != nil {
+			return fmt.Errorf("initramfs: unable to read %q: %w", n, err)
+		}
+		hdr.Typeflag = tar.TypeSymlink
+		hdr.Linkname = string(tgt)
+		if err := e.tw.WriteHeader(&hdr); err != nil {
+			return fmt.Errorf("initramfs: unable to write %q: %w", n, err)
+		}
+		return nil
+	}
+	if _, err := br.Discard(int(h.Size)); err != nil {
+		return fmt.Errorf("initramfs: unable to read %q: %w", n, err)
+	}
+	return nil
+}
+
+// Header is the subset of a "newc" header that's used.
+type header struct {
+	Mode     uint32
+	MTime    int64
+	Size     int64
+	NameSize int
+}
+
+// ParseHeader parses a "newc" or "crc" header.
+//
+// The header is the magic followed by thirteen 8-digit hex fields: ino, mode,
+// uid, gid, nlink, mtime, filesize, devmajor, devminor, rdevmajor, rdevminor,
+// namesize, and check.
+func parseHeader(b []byte) (header, error) {
+	var h header
+	if m := string(b[:6]); m != magicNewc && m != magicCRC {
+		return h, errFormat
+	}
+	var fs [fieldCount]uint32
+	for i := range fs {
+		off := 6 + i*8
+		v, err := strconv.ParseUint(string(b[off:off+8]), 16, 32)
+		if err != nil {
+			return h, fmt.Errorf("initramfs: bad header field: %w", err)
+		}
+		fs[i] = uint32(v)
+	}
+	h.Mode = fs[1]
+	h.MTime = int64(fs[5])
+	h.Size = int64(fs[6])
+	h.NameSize = int(fs[11])
+	if h.NameSize < 1 || h.NameSize > maxNameSz {
+		return h, fmt.Errorf("initramfs: bad name size: %d", h.NameSize)
+	}
+	return h, nil
+}
+
+// Pad reports the number of bytes needed to pad "n" to a multiple of four.
+func pad[T int | int64](n T) int {
+	return int((4 - n%4) % 4)
+}
+
+// SkipPadding consumes NUL bytes, returning io.EOF if nothing follows them.
+func skipPadding(br *bufio.Reader) error {
+	for {
+		b, err := br.Peek(1)
+		if err != nil {
+			return err
+		}
+		if b[0] != 0 {
+			return nil
+		}
+		if _, err := br.Discard(1); err != nil {
+			return err
+		}
+	}
+}
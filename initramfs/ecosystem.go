@@ -0,0 +1,20 @@
+package initramfs
+
+import (
+	"context"
+
+	"github.com/quay/claircore/indexer"
+)
+
+// NewEcosystem provides the ecosystem for handling initramfs images.
+func NewEcosystem(ctx context.Context) *indexer.Ecosystem {
+	return &indexer.Ecosystem{
+		Name: "initramfs",
+		PackageScanners: func(context.Context) ([]indexer.PackageScanner, error) {
+			return []indexer.PackageScanner{&Scanner{}}, nil
+		},
+		DistributionScanners: func(context.Context) ([]indexer.DistributionScanner, error) { return nil, nil },
+		RepositoryScanners:   func(context.Context) ([]indexer.RepositoryScanner, error) { return nil, nil },
+		Coalescer:            func(context.Context) (indexer.Coalescer, error) { return &coalescer{}, nil },
+	}
+}
@@ -0,0 +1,296 @@
+// Package initramfs implements a package scanner that looks inside the
+// initramfs images installed in a layer.
+//
+// The other package scanners only see the files in a layer, so anything
+// packed into an initramfs is otherwise invisible.
+package initramfs
+
+import (
+	"archive/tar"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"runtime/trace"
+	"strings"
+
+	"github.com/quay/zlog"
+
+	"github.com/quay/claircore"
+	"github.com/quay/claircore/dpkg"
+	"github.com/quay/claircore/gobin"
+	"github.com/quay/claircore/indexer"
+	"github.com/quay/claircore/pkg/tarfs"
+	"github.com/quay/claircore/rpm"
+)
+
+const (
+	scannerName    = `initramfs`
+	scannerVersion = `2`
+	scannerKind    = `package`
+)
+
+const (
+	// DefaultMaxSize is the default limit on the size of an image, and on the
+	// total size of the files unpacked from it.
+	DefaultMaxSize = 256 * 1024 * 1024
+	// DefaultMaxDepth is the default limit on how deeply images inside of
+	// images are examined.
+	DefaultMaxDepth = 2
+)
+
+var (
+	_ indexer.VersionedScanner    = (*Scanner)(nil)
+	_ indexer.PackageScanner      = (*Scanner)(nil)
+	_ indexer.ConfigurableScanner = (*Scanner)(nil)
+)
+
+// ScannerConfig is the struct used to configure a Scanner.
+type ScannerConfig struct {
+	// MaxSize is the limit, in bytes, on the size of an image and on the
+	// total size of the files unpacked from it. Larger images are skipped.
+	// The default is DefaultMaxSize.
+	MaxSize int64 `yaml:"max_size" json:"max_size"`
+	// MaxDepth is the limit on how many images deep the Scanner will look.
+	// One means only images in the layer are examined. The default is
+	// DefaultMaxDepth.
+	MaxDepth int `yaml:"max_depth" json:"max_depth"`
+}
+
+// Scanner implements the indexer.PackageScanner interface.
+//
+// It looks for initramfs images in "/boot", unpacks them, and runs the gobin,
+// dpkg, and rpm package scanners over their contents. Packages found this way
+// have the path of the image prefixed to their Filepath, and inserted into
+// their PackageDB: a package from the rpm database "bdb:var/lib/rpm" in
+// "boot/initramfs.img" is reported with the PackageDB
+// "bdb:boot/initramfs.img:var/lib/rpm", and a Go binary at "usr/bin/tool"
+// with the Filepath "boot/initramfs.img:usr/bin/tool". Images nested in
+// images get one prefix for every level.
+//
+// The zero value is ready to use.
+type Scanner struct {
+	maxSize  int64
+	maxDepth int
+}
+
+// Name implements indexer.VersionedScanner.
+func (*Scanner) Name() string { return scannerName }
+
+// Version implements indexer.VersionedScanner.
+func (*Scanner) Version() string { return scannerVersion }
+
+// Kind implements indexer.VersionedScanner.
+func (*Scanner) Kind() string { return scannerKind }
+
+// Configure implements indexer.ConfigurableScanner.
+func (s *Scanner) Configure(ctx context.Context, f indexer.ConfigDeserializer) error {
+	var cfg ScannerConfig
+	if err := f(&cfg); err != nil {
+		return err
+	}
+	s.maxSize = cfg.MaxSize
+	s.maxDepth = cfg.MaxDepth
+	return nil
+}
+
+func (s *Scanner) limits() (size int64, depth int) {
+	size, depth = DefaultMaxSize, DefaultMaxDepth
+	if s.maxSize > 0 {
+		size = s.maxSize
+	}
+	if s.maxDepth > 0 {
+		depth = s.maxDepth
+	}
+	return size, depth
+}
+
+// Scanners returns the package scanners run over the contents of an image.
+//
+// The Scanner's version needs to change if this set does.
+func scanners() []indexer.PackageScanner {
+	return []indexer.PackageScanner{
+		gobin.Detector{},
+		&dpkg.Scanner{},
+		&rpm.Scanner{},
+	}
+}
+
+// Scan implements indexer.PackageScanner.
+//
+// A return of (nil, nil) is expected if there are no initramfs images.
+func (s *Scanner) Scan(ctx context.Context, layer *claircore.Layer) ([]*claircore.Package, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	defer trace.StartRegion(ctx, "Scanner.Scan").End()
+	trace.Log(ctx, "layer", layer.Hash.String())
+	ctx = zlog.ContextWithValues(ctx,
+		"component", "initramfs/Scanner.Scan",
+		"version", s.Version(),
+		"layer", layer.Hash.String())
+	zlog.Debug(ctx).Msg("start")
+	defer zlog.Debug(ctx).Msg("done")
+
+	sys, err := layer.FS()
+	if err != nil {
+		return nil, err
+	}
+	defer sys.Close()
+	return s.scanFS(ctx, layer, sys, 1)
+}
+
+// ScanFS examines the images in "sys", which is "depth" images deep.
+func (s *Scanner) scanFS(ctx context.Context, layer *claircore.Layer, sys fs.FS, depth int) ([]*claircore.Package, error) {
+	maxSize, _ := s.limits()
+	imgs, err := images(sys, maxSize)
+	if err != nil {
+		return nil, err
+	}
+	var out []*claircore.Package
+	for _, p := range imgs {
+		ctx := zlog.ContextWithValues(ctx, "image", p)
+		pkgs, err := s.scanImage(ctx, layer, sys, p, depth)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, pkgs...)
+	}
+	return out, nil
+}
+
+// Images returns the paths of the files in "sys" that look like initramfs
+// images, skipping any larger than "max".
+func images(sys fs.FS, max int64) ([]string, error) {
+	ents, err := fs.ReadDir(sys, "boot")
+	switch {
+	case errors.Is(err, nil):
+	case errors.Is(err, fs.ErrNotExist):
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("initramfs: unable to read %q: %w", "boot", err)
+	}
+	var out []string
+	for _, ent := range ents {
+		n := ent.Name()
+		if !ent.Type().IsRegular() ||
+			!(strings.HasPrefix(n, "initr") || strings.HasSuffix(n, ".img")) {
+			continue
+		}
+		fi, err := ent.Info()
+		if err != nil {
+			return nil, fmt.Errorf("initramfs: unable to stat %q: %w", n, err)
+		}
+		if fi.Size() > max {
+			continue
+		}
+		out = append(out, path.Join("boot", n))
+	}
+	return out, nil
+}
+
+// ScanImage unpacks the image at "p" into a temporary layer and runs the
+// package scanners over it.
+//
+// Images that can't be unpacked, or that unpack to more than the size limit,
+// are skipped.
+func (s *Scanner) scanImage(ctx context.Context, layer *claircore.Layer, sys fs.FS, p string, depth int) ([]*claircore.Package, error) {
+	maxSize, maxDepth := s.limits()
+	spool, err := os.CreateTemp("", "initramfs.*.tar")
+	if err != nil {
+		return nil, fmt.Errorf("initramfs: unable to create spool: %w", err)
+	}
+	defer func() {
+		spool.Close()
+		if err := os.Remove(spool.Name()); err != nil {
+			zlog.Warn(ctx).Err(err).Msg("unable to remove spool")
+		}
+	}()
+
+	f, err := sys.Open(p)
+	if err != nil {
+		return nil, fmt.Errorf("initramfs: unable to open %q: %w", p, err)
+	}
+	defer f.Close()
+	e := extractor{tw: tar.NewWriter(spool), rem: maxSize}
+	switch err := e.archives(f); {
+	case errors.Is(err, nil):
+	case errors.Is(err, errFormat):
+		zlog.Debug(ctx).Msg("not an initramfs image")
+		return nil, nil
+	case errors.Is(err, errTooLarge):
+		zlog.Info(ctx).
+			Int64("limit", maxSize).
+			Msg("image contents too large, skipping")
+		return nil, nil
+	default:
+		zlog.Info(ctx).
+			Err(err).
+			Msg("unable to unpack image, skipping")
+		return nil, nil
+	}
+	if err := e.tw.Close(); err != nil {
+		return nil, fmt.Errorf("initramfs: unable to write spool: %w", err)
+	}
+
+	// The layer hash is kept so that the scanners' logging makes sense.
+	inner := claircore.Layer{Hash: layer.Hash, URI: layer.URI}
+	inner.SetLocal(spool.Name())
+	var out []*claircore.Package
+	for _, sc := range scanners() {
+		pkgs, err := sc.Scan(ctx, &inner)
+		if err != nil {
+			return nil, fmt.Errorf("initramfs: %s scanner failed on %q: %w", sc.Name(), p, err)
+		}
+		out = append(out, pkgs...)
+	}
+	if depth < maxDepth {
+		isys, err := tarfs.New(spool)
+		if err != nil {
+			return nil, fmt.Errorf("initramfs: unable to read spool: %w", err)
+		}
+		pkgs, err := s.scanFS(ctx, layer, isys, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, pkgs...)
+	}
+	// Source packages may be shared, so make sure to only rewrite them once.
+	seen := make(map[*claircore.Package]struct{})
+	for _, pkg := range out {
+		for _, pkg := range []*claircore.Package{pkg, pkg.Source} {
+			if pkg == nil {
+				continue
+			}
+			if _, ok := seen[pkg]; ok {
+				continue
+			}
+			seen[pkg] = struct{}{}
+			if pkg.PackageDB != "" {
+				pkg.PackageDB = packageDB(p, pkg.PackageDB)
+			}
+			pkg.Filepath = nestedPath(p, pkg.Filepath)
+		}
+	}
+	return out, nil
+}
+
+// PackageDB inserts the image path "img" into the package database "db",
+// keeping any "kind:" prefix so that matchers keying off of it still work.
+func packageDB(img, db string) string {
+	if kind, rest, ok := strings.Cut(db, ":"); ok && !strings.Contains(kind, "/") {
+		return kind + ":" + img + ":" + rest
+	}
+	return img + ":" + db
+}
+
+// NestedPath prefixes the image path "img" to the path "p" of a file inside it.
+// If "p" is empty, the image itself is the best path there is.
+func nestedPath(img, p string) string {
+	if p == "" {
+		return img
+	}
+	return img + ":" + p
+}
@@ -0,0 +1,235 @@
+package initramfs
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/klauspost/compress/gzip"
+	"github.com/quay/zlog"
+
+	"github.com/quay/claircore"
+)
+
+// CpioFile is an entry to write with writeCpio.
+type cpioFile struct {
+	Name string
+	Mode uint32
+	Data string
+}
+
+// WriteCpio appends a "newc" archive of the files to "b".
+func writeCpio(b *bytes.Buffer, fs ...cpioFile) {
+	hdr := func(name string, mode uint32, sz int) {
+		fmt.Fprintf(b, "%s%08x%08x%08x%08x%08x%08x%08x%08x%08x%08x%08x%08x%08x",
+			magicNewc, 0, mode, 0, 0, 1, 0, sz, 0, 0, 0, 0, len(name)+1, 0)
+		b.WriteString(name)
+		b.WriteByte(0)
+		b.Write(make([]byte, pad(headerSz+len(name)+1)))
+	}
+	for _, f := range fs {
+		hdr(f.Name, f.Mode, len(f.Data))
+		b.WriteString(f.Data)
+		b.Write(make([]byte, pad(len(f.Data))))
+	}
+	hdr(trailer, 0, 0)
+	// Pad the archive out like the kernel's tools do.
+	b.Write(make([]byte, 512-b.Len()%512))
+}
+
+const dpkgStatus = `Package: busybox
+Status: install ok installed
+Architecture: amd64
+Version: 1:1.30.1-6+b3
+
+`
+
+// Image returns an initramfs image in the usual layout: an uncompressed
+// archive with microcode, and then a compressed archive with a dpkg database.
+// If "nested" is not nil, it's included as "boot/nested.img".
+func image(t testing.TB, nested []byte) []byte {
+	var b, inner bytes.Buffer
+	writeCpio(&b,
+		cpioFile{Name: "kernel", Mode: typeDir | 0o755},
+		cpioFile{Name: "kernel/x86/microcode/GenuineIntel.bin", Mode: typeReg | 0o644, Data: "not really microcode"},
+	)
+	fs := []cpioFile{
+		{Name: ".", Mode: typeDir | 0o755},
+		{Name: "var/lib/dpkg", Mode: typeDir | 0o755},
+		{Name: "var/lib/dpkg/info", Mode: typeDir | 0o755},
+		{Name: "var/lib/dpkg/status", Mode: typeReg | 0o644, Data: dpkgStatus},
+		{Name: "bin/sh", Mode: typeLink | 0o777, Data: "busybox"},
+		{Name: "../../escape", Mode: typeReg | 0o644, Data: "contained"},
+	}
+	if nested != nil {
+		fs = append(fs, cpioFile{Name: "boot/nested.img", Mode: typeReg | 0o644, Data: string(nested)})
+	}
+	writeCpio(&inner, fs...)
+	z := gzip.NewWriter(&b)
+	if _, err := z.Write(inner.Bytes()); err != nil {
+		t.Fatal(err)
+	}
+	if err := z.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return b.Bytes()
+}
+
+// Layer writes a layer containing the files and returns it.
+func layer(t testing.TB, files map[string][]byte) *claircore.Layer {
+	n := filepath.Join(t.TempDir(), "layer.tar")
+	f, err := os.Create(n)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	tw := tar.NewWriter(f)
+	for name, b := range files {
+		if err := tw.WriteHeader(&tar.Header{
+			Typeflag: tar.TypeReg,
+			Name:     name,
+			Mode:     0o644,
+			Size:     int64(len(b)),
+		}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write(b); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	l := claircore.Layer{
+		Hash: claircore.MustParseDigest(`sha256:e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855`),
+		URI:  `file:///dev/null`,
+	}
+	l.SetLocal(n)
+	return &l
+}
+
+func TestScanner(t *testing.T) {
+	ctx := zlog.Test(context.Background(), t)
+	busybox := func(img, db string) *claircore.Package {
+		return &claircore.Package{
+			Name:      "busybox",
+			Version:   "1:1.30.1-6+b3",
+			Kind:      claircore.BINARY,
+			Arch:      "amd64",
+			PackageDB: img + ":" + db,
+			Filepath:  img,
+		}
+	}
+
+	tt := []struct {
+		Name   string
+		Files  map[string][]byte
+		Config ScannerConfig
+		Want   []*claircore.Package
+	}{
+		{
+			Name: "None",
+			Files: map[string][]byte{
+				"etc/os-release": []byte("ID=test\n"),
+			},
+		},
+		{
+			Name: "NotAnImage",
+			Files: map[string][]byte{
+				"boot/grub.img": []byte("\xeb\x63\x90 not a cpio archive"),
+			},
+		},
+		{
+			Name: "Image",
+			Files: map[string][]byte{
+				"boot/initramfs.img": image(t, nil),
+			},
+			Want: []*claircore.Package{
+				busybox("boot/initramfs.img", "var/lib/dpkg/status"),
+			},
+		},
+		{
+			Name: "Nested",
+			Files: map[string][]byte{
+				"boot/initramfs.img": image(t, image(t, nil)),
+			},
+			Want: []*claircore.Package{
+				busybox("boot/initramfs.img", "var/lib/dpkg/status"),
+				busybox("boot/initramfs.img:boot/nested.img", "var/lib/dpkg/status"),
+			},
+		},
+		{
+			Name: "TooDeep",
+			Files: map[string][]byte{
+				"boot/initramfs.img": image(t, image(t, nil)),
+			},
+			Config: ScannerConfig{MaxDepth: 1},
+			Want: []*claircore.Package{
+				busybox("boot/initramfs.img", "var/lib/dpkg/status"),
+			},
+		},
+		{
+			Name: "TooLarge",
+			Files: map[string][]byte{
+				"boot/initramfs.img": image(t, nil),
+			},
+			Config: ScannerConfig{MaxSize: 64},
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			ctx := zlog.Test(ctx, t)
+			var s Scanner
+			if err := s.Configure(ctx, func(v interface{}) error {
+				*(v.(*ScannerConfig)) = tc.Config
+				return nil
+			}); err != nil {
+				t.Fatal(err)
+			}
+			got, err := s.Scan(ctx, layer(t, tc.Files))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !cmp.Equal(got, tc.Want) {
+				t.Error(cmp.Diff(got, tc.Want))
+			}
+		})
+	}
+}
+
+func TestExtract(t *testing.T) {
+	var out bytes.Buffer
+	e := extractor{tw: tar.NewWriter(&out), rem: DefaultMaxSize}
+	if err := e.archives(bytes.NewReader(image(t, nil))); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	tr := tar.NewReader(&out)
+	for {
+		h, err := tr.Next()
+		if err != nil {
+			break
+		}
+		got = append(got, h.Name)
+	}
+	want := []string{
+		"kernel/",
+		"kernel/x86/microcode/GenuineIntel.bin",
+		"var/lib/dpkg/",
+		"var/lib/dpkg/info/",
+		"var/lib/dpkg/status",
+		"bin/sh",
+		"escape",
+	}
+	if !cmp.Equal(got, want) {
+		t.Error(cmp.Diff(got, want))
+	}
+}
@@ -0,0 +1,91 @@
+package matcher
+
+import (
+	"context"
+	"sort"
+
+	"github.com/quay/zlog"
+
+	"github.com/quay/claircore"
+	"github.com/quay/claircore/pkg/condition"
+)
+
+type strictConditionsKey struct{}
+
+// WithStrictConditions returns a Context that makes Match and EnrichedMatch
+// leave out vulnerabilities whose Condition can't be confirmed, instead of
+// reporting them as conditionally affected.
+func WithStrictConditions(ctx context.Context) context.Context {
+	return context.WithValue(ctx, strictConditionsKey{}, true)
+}
+
+// StrictConditions reports whether WithStrictConditions was used on the
+// Context.
+func strictConditions(ctx context.Context) bool {
+	ok, _ := ctx.Value(strictConditionsKey{}).(bool)
+	return ok
+}
+
+// ApplyConditions evaluates the Condition of every vulnerability in the report
+// against the facts in the IndexReport.
+//
+// Vulnerabilities whose condition is known not to hold are removed from the
+// report. Ones whose condition can't be confirmed either way are listed in
+// ConditionallyAffected, or removed if "strict" is set. A condition that
+// doesn't parse is treated as unconfirmed.
+func applyConditions(ctx context.Context, ir *claircore.IndexReport, vr *claircore.VulnerabilityReport, strict bool) {
+	ctx = zlog.ContextWithValues(ctx, "component", "internal/matcher/applyConditions")
+	var facts *condition.Facts
+	drop := make(map[string]struct{})
+	for id, v := range vr.Vulnerabilities {
+		if v.Condition == "" {
+			continue
+		}
+		if facts == nil {
+			facts = condition.FromIndexReport(ir)
+		}
+		res := condition.Unknown
+		e, err := condition.Parse(v.Condition)
+		if err == nil {
+			res = e.Eval(facts)
+		} else {
+			zlog.Warn(ctx).
+				Str("vulnerability", v.Name).
+				Str("updater", v.Updater).
+				Err(err).
+				Msg("unable to parse condition")
+		}
+		zlog.Debug(ctx).
+			Str("vulnerability", v.Name).
+			Str("condition", v.Condition).
+			Stringer("result", res).
+			Msg("evaluated condition")
+		switch {
+		case res == condition.True:
+		case res == condition.Unknown && !strict:
+			vr.ConditionallyAffected = append(vr.ConditionallyAffected, id)
+		default:
+			drop[id] = struct{}{}
+		}
+	}
+	sort.Strings(vr.ConditionallyAffected)
+	if len(drop) == 0 {
+		return
+	}
+	for id := range drop {
+		delete(vr.Vulnerabilities, id)
+	}
+	for pkgID, ids := range vr.PackageVulnerabilities {
+		keep := ids[:0]
+		for _, id := range ids {
+			if _, ok := drop[id]; !ok {
+				keep = append(keep, id)
+			}
+		}
+		if len(keep) == 0 {
+			delete(vr.PackageVulnerabilities, pkgID)
+			continue
+		}
+		vr.PackageVulnerabilities[pkgID] = keep
+	}
+}
@@ -0,0 +1,109 @@
+package matcher
+
+import (
+	"context"
+	"sort"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/quay/zlog"
+
+	"github.com/quay/claircore"
+	"github.com/quay/claircore/datastore/memory"
+	"github.com/quay/claircore/libvuln/driver"
+)
+
+func TestMatchConditions(t *testing.T) {
+	ctx := zlog.Test(context.Background(), t)
+	ir, _ := fixture(t)
+	var p *claircore.Package
+	for _, cur := range ir.Packages {
+		if p == nil || cur.ID < p.ID {
+			p = cur
+		}
+	}
+	conds := map[string]string{
+		"holds":   "package:" + p.Name,
+		"fails":   "package:not-installed",
+		"unknown": "package:" + p.Name + " && file:etc/not-recorded.conf",
+		"invalid": "package:",
+		"none":    "",
+	}
+	var vs []*claircore.Vulnerability
+	for id, c := range conds {
+		vs = append(vs, &claircore.Vulnerability{
+			ID:             id,
+			Name:           "ADV-" + id,
+			Updater:        "fixture",
+			Package:        &claircore.Package{Name: p.Name, Kind: claircore.BINARY},
+			Dist:           ir.Distributions["11"],
+			FixedInVersion: p.Version + "+1",
+			Condition:      c,
+		})
+	}
+	store := memory.New(vs...)
+	ms := []driver.Matcher{&debianMatcher}
+
+	tt := []struct {
+		Name        string
+		Strict      bool
+		Want        []string
+		Conditional []string
+	}{
+		{
+			Name:        "Default",
+			Want:        []string{"holds", "invalid", "none", "unknown"},
+			Conditional: []string{"invalid", "unknown"},
+		},
+		{
+			Name:   "Strict",
+			Strict: true,
+			Want:   []string{"holds", "none"},
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			ctx := ctx
+			if tc.Strict {
+				ctx = WithStrictConditions(ctx)
+			}
+			vr, err := Match(ctx, ir, ms, store)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := append([]string(nil), vr.PackageVulnerabilities[p.ID]...)
+			sort.Strings(got)
+			if !cmp.Equal(got, tc.Want) {
+				t.Error(cmp.Diff(got, tc.Want))
+			}
+			if got, want := len(vr.Vulnerabilities), len(tc.Want); got != want {
+				t.Errorf("got: %d vulnerabilities, want: %d", got, want)
+			}
+			if !cmp.Equal(vr.ConditionallyAffected, tc.Conditional) {
+				t.Error(cmp.Diff(vr.ConditionallyAffected, tc.Conditional))
+			}
+		})
+	}
+	t.Run("LowConfidence", func(t *testing.T) {
+		// Vulnerabilities dropped by conditions shouldn't be listed as
+		// low-confidence, or kept as conditional when suppressed.
+		ir, _ := fixture(t)
+		ir.Packages[p.ID].Confidence = claircore.ConfidenceLow
+		vr, err := Match(WithStrictConditions(ctx), ir, ms, store)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := append([]string(nil), vr.LowConfidence...)
+		sort.Strings(got)
+		if want := []string{"holds", "none"}; !cmp.Equal(got, want) {
+			t.Error(cmp.Diff(got, want))
+		}
+		vr, err = Match(WithSuppressLowConfidence(ctx), ir, ms, store)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(vr.ConditionallyAffected) != 0 {
+			t.Errorf("unexpected conditional vulnerabilities: %v", vr.ConditionallyAffected)
+		}
+	})
+}
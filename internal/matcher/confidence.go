@@ -0,0 +1,90 @@
+package matcher
+
+import (
+	"context"
+	"sort"
+
+	"github.com/quay/zlog"
+
+	"github.com/quay/claircore"
+)
+
+type suppressLowConfidenceKey struct{}
+
+// WithSuppressLowConfidence returns a Context that makes Match and
+// EnrichedMatch leave out the vulnerabilities of packages identified with
+// claircore.ConfidenceLow, instead of listing them in LowConfidence.
+func WithSuppressLowConfidence(ctx context.Context) context.Context {
+	return context.WithValue(ctx, suppressLowConfidenceKey{}, true)
+}
+
+// SuppressLowConfidence reports whether WithSuppressLowConfidence was used on
+// the Context.
+func suppressLowConfidence(ctx context.Context) bool {
+	ok, _ := ctx.Value(suppressLowConfidenceKey{}).(bool)
+	return ok
+}
+
+// ApplyConfidence handles the vulnerabilities of low-confidence packages in
+// the report.
+//
+// If "suppress" is set, low-confidence packages are removed from
+// PackageVulnerabilities, along with any vulnerabilities no other package
+// has, which are also dropped from ConditionallyAffected. Otherwise, the
+// vulnerabilities only low-confidence packages have are listed in
+// LowConfidence, replacing its previous contents.
+func applyConfidence(ctx context.Context, vr *claircore.VulnerabilityReport, suppress bool) {
+	ctx = zlog.ContextWithValues(ctx, "component", "internal/matcher/applyConfidence")
+	vr.LowConfidence = nil
+	// Low holds the vulnerabilities of low-confidence packages, and high the
+	// vulnerabilities of everything else.
+	low := make(map[string]struct{})
+	high := make(map[string]struct{})
+	var pkgs []string
+	for pkgID, ids := range vr.PackageVulnerabilities {
+		seen := high
+		if p, ok := vr.Packages[pkgID]; ok && p.Confidence == claircore.ConfidenceLow {
+			seen = low
+			pkgs = append(pkgs, pkgID)
+		}
+		for _, id := range ids {
+			seen[id] = struct{}{}
+		}
+	}
+	if len(pkgs) == 0 {
+		return
+	}
+	var only []string
+	for id := range low {
+		if _, ok := high[id]; !ok {
+			only = append(only, id)
+		}
+	}
+	sort.Strings(only)
+	if !suppress {
+		vr.LowConfidence = only
+		return
+	}
+	for _, pkgID := range pkgs {
+		delete(vr.PackageVulnerabilities, pkgID)
+	}
+	for _, id := range only {
+		delete(vr.Vulnerabilities, id)
+	}
+	if len(vr.ConditionallyAffected) != 0 {
+		keep := vr.ConditionallyAffected[:0]
+		for _, id := range vr.ConditionallyAffected {
+			if _, ok := low[id]; ok {
+				if _, ok := high[id]; !ok {
+					continue
+				}
+			}
+			keep = append(keep, id)
+		}
+		vr.ConditionallyAffected = keep
+	}
+	zlog.Debug(ctx).
+		Int("packages", len(pkgs)).
+		Int("vulnerabilities", len(only)).
+		Msg("suppressed low-confidence findings")
+}
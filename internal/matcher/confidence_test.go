@@ -0,0 +1,103 @@
+package matcher
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/quay/zlog"
+
+	"github.com/quay/claircore"
+	"github.com/quay/claircore/datastore/memory"
+	"github.com/quay/claircore/libvuln/driver"
+)
+
+func TestApplyConfidence(t *testing.T) {
+	ctx := zlog.Test(context.Background(), t)
+	report := func() *claircore.VulnerabilityReport {
+		return &claircore.VulnerabilityReport{
+			Packages: map[string]*claircore.Package{
+				"1": {ID: "1", Name: "guessed", Confidence: claircore.ConfidenceLow},
+				"2": {ID: "2", Name: "installed"},
+			},
+			Vulnerabilities: map[string]*claircore.Vulnerability{
+				"a": {ID: "a"},
+				"b": {ID: "b"},
+				"c": {ID: "c"},
+			},
+			PackageVulnerabilities: map[string][]string{
+				"1": {"a", "b"},
+				"2": {"b", "c"},
+			},
+			LowConfidence: []string{"stale"},
+		}
+	}
+
+	t.Run("Tag", func(t *testing.T) {
+		vr := report()
+		applyConfidence(ctx, vr, false)
+		if want := []string{"a"}; !cmp.Equal(vr.LowConfidence, want) {
+			t.Error(cmp.Diff(want, vr.LowConfidence))
+		}
+		if got, want := len(vr.Vulnerabilities), 3; got != want {
+			t.Errorf("got: %d vulnerabilities, want: %d", got, want)
+		}
+	})
+	t.Run("Suppress", func(t *testing.T) {
+		vr := report()
+		applyConfidence(ctx, vr, true)
+		if vr.LowConfidence != nil {
+			t.Errorf("unexpected LowConfidence: %v", vr.LowConfidence)
+		}
+		if want := map[string][]string{"2": {"b", "c"}}; !cmp.Equal(vr.PackageVulnerabilities, want) {
+			t.Error(cmp.Diff(want, vr.PackageVulnerabilities))
+		}
+		if _, ok := vr.Vulnerabilities["a"]; ok {
+			t.Error("vulnerability only in a low-confidence package not removed")
+		}
+		if _, ok := vr.Vulnerabilities["b"]; !ok {
+			t.Error("vulnerability also in a high-confidence package removed")
+		}
+	})
+}
+
+func TestMatchConfidence(t *testing.T) {
+	ctx := zlog.Test(context.Background(), t)
+	ms := []driver.Matcher{&debianMatcher}
+
+	ir, vs := fixture(t)
+	var p *claircore.Package
+	for _, cur := range ir.Packages {
+		if p == nil || cur.ID < p.ID {
+			p = cur
+		}
+	}
+	p.Confidence = claircore.ConfidenceLow
+	store := memory.New(vs...)
+
+	vr, err := Match(ctx, ir, ms, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := len(vr.PackageVulnerabilities[p.ID])
+	if want == 0 {
+		t.Fatal("fixture package has no vulnerabilities")
+	}
+	if got := len(vr.LowConfidence); got != want {
+		t.Errorf("got: %d low-confidence vulnerabilities, want: %d", got, want)
+	}
+
+	vr, err = Match(WithSuppressLowConfidence(ctx), ir, ms, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids, ok := vr.PackageVulnerabilities[p.ID]; ok {
+		t.Errorf("low-confidence package reported: %v", ids)
+	}
+	if got := len(vr.LowConfidence); got != 0 {
+		t.Errorf("got: %d low-confidence vulnerabilities, want: 0", got)
+	}
+	if got, want := len(vr.Vulnerabilities), 2*(len(ir.Packages)-1); got != want {
+		t.Errorf("got: %d vulnerabilities, want: %d", got, want)
+	}
+}
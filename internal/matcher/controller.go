@@ -2,6 +2,7 @@ package matcher
 
 import (
 	"context"
+	"encoding/json"
 	"time"
 
 	"github.com/quay/zlog"
@@ -95,6 +96,19 @@ func (mc *Controller) queryRemoteMatcher(ctx context.Context, interested []*clai
 	return true, vulns, err
 }
 
+// Enrich asks the Matcher for enrichments describing the provided matches, if
+// it implements driver.MatchEnricher.
+//
+// A Matcher that doesn't implement the interface reports an empty kind and no
+// enrichments.
+func (mc *Controller) Enrich(ctx context.Context, vulns map[string][]*claircore.Vulnerability) (string, []json.RawMessage, error) {
+	f, ok := mc.m.(driver.MatchEnricher)
+	if !ok || len(vulns) == 0 {
+		return "", nil, nil
+	}
+	return f.EnrichMatches(ctx, vulns)
+}
+
 // DbFilter reports whether the db-side version filtering can be used, and
 // whether it's authoritative.
 func (mc *Controller) dbFilter() (bool, bool) {
@@ -124,6 +138,14 @@ func (mc *Controller) query(ctx context.Context, interested []*claircore.IndexRe
 		Matchers:         matchers,
 		Debug:            true,
 		VersionFiltering: dbSide,
+		UpdateOperations: updateOperations(ctx),
+	}
+	if f, ok := mc.m.(driver.QueryRecorder); ok {
+		rs := make([]*claircore.IndexRecord, len(interested))
+		for i, r := range interested {
+			rs[i] = f.QueryRecord(r)
+		}
+		interested = rs
 	}
 	matches, err := mc.store.Get(ctx, interested, getOpts)
 	if err != nil {
@@ -137,6 +159,11 @@ func (mc *Controller) query(ctx context.Context, interested []*claircore.IndexRe
 func (mc *Controller) filter(ctx context.Context, interested []*claircore.IndexRecord, vulns map[string][]*claircore.Vulnerability) (map[string][]*claircore.Vulnerability, error) {
 	filtered := map[string][]*claircore.Vulnerability{}
 	for _, record := range interested {
+		// Vulnerable may not check the Context itself, so make sure a
+		// cancelled request doesn't keep going through every record.
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
 		match, err := filterVulns(ctx, mc.m, record, vulns[record.Package.ID])
 		if err != nil {
 			return nil, err
@@ -147,10 +174,21 @@ func (mc *Controller) filter(ctx context.Context, interested []*claircore.IndexR
 }
 
 // filter returns only the vulnerabilities affected by the provided package.
+//
+// Version-agnostic vulnerabilities affect the package regardless of its
+// version, so they're checked with the Matcher's VulnerableAnyVersion if it
+// implements driver.AgnosticMatcher. Everything else is up to Vulnerable.
 func filterVulns(ctx context.Context, m driver.Matcher, record *claircore.IndexRecord, vulns []*claircore.Vulnerability) ([]*claircore.Vulnerability, error) {
 	filtered := []*claircore.Vulnerability{}
+	am, agnostic := m.(driver.AgnosticMatcher)
 	for _, vuln := range vulns {
-		match, err := m.Vulnerable(ctx, record, vuln)
+		var match bool
+		var err error
+		if agnostic && vuln.VersionAgnostic() {
+			match, err = am.VulnerableAnyVersion(ctx, record, vuln)
+		} else {
+			match, err = m.Vulnerable(ctx, record, vuln)
+		}
 		if err != nil {
 			return nil, err
 		}
@@ -0,0 +1,122 @@
+package matcher
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/quay/zlog"
+
+	"github.com/quay/claircore"
+	"github.com/quay/claircore/libvuln/driver"
+	mock_driver "github.com/quay/claircore/test/mock/driver"
+)
+
+// AgnosticMatcher adds a driver.AgnosticMatcher implementation to a Matcher.
+type agnosticMatcher struct {
+	driver.Matcher
+	anyVersion bool
+}
+
+func (m *agnosticMatcher) VulnerableAnyVersion(context.Context, *claircore.IndexRecord, *claircore.Vulnerability) (bool, error) {
+	return m.anyVersion, nil
+}
+
+func TestFilterVulns(t *testing.T) {
+	ctx := zlog.Test(context.Background(), t)
+	agnostic := &claircore.Vulnerability{ID: "agnostic", AlwaysAffected: true}
+	fixed := &claircore.Vulnerability{ID: "fixed", AlwaysAffected: true, FixedInVersion: "2"}
+	unfixed := &claircore.Vulnerability{ID: "unfixed"}
+	vulns := []*claircore.Vulnerability{agnostic, fixed, unfixed}
+
+	table := []struct {
+		name    string
+		version string
+		// Vulnerable is the Matcher's answer for every vulnerability it's
+		// asked about.
+		vulnerable bool
+		// Hook adds a VulnerableAnyVersion method answering anyVersion, which
+		// is then asked about the agnostic vulnerability instead.
+		hook       bool
+		anyVersion bool
+		want       []string
+	}{
+		{
+			name:       "Affected",
+			version:    "1",
+			vulnerable: true,
+			want:       []string{"agnostic", "fixed", "unfixed"},
+		},
+		{
+			// Without the hook, the Matcher's other checks, like the
+			// architecture, still apply to agnostic vulnerabilities.
+			name:       "Unaffected",
+			version:    "3",
+			vulnerable: false,
+			want:       []string{},
+		},
+		{
+			// The Matcher decides packages without a version, too.
+			name:       "EmptyVersion",
+			version:    "",
+			vulnerable: true,
+			want:       []string{"agnostic", "fixed", "unfixed"},
+		},
+		{
+			name:       "EmptyVersionUnaffected",
+			version:    "",
+			vulnerable: false,
+			want:       []string{},
+		},
+		{
+			name:       "HookAffected",
+			version:    "3",
+			vulnerable: false,
+			hook:       true,
+			anyVersion: true,
+			want:       []string{"agnostic"},
+		},
+		{
+			name:       "HookUnaffected",
+			version:    "1",
+			vulnerable: true,
+			hook:       true,
+			anyVersion: false,
+			want:       []string{"fixed", "unfixed"},
+		},
+	}
+	for _, tc := range table {
+		t.Run(tc.name, func(t *testing.T) {
+			ctl := gomock.NewController(t)
+			mock := mock_driver.NewMockMatcher(ctl)
+			var m driver.Matcher = mock
+			if tc.hook {
+				mock.EXPECT().
+					Vulnerable(gomock.Any(), gomock.Any(), gomock.Not(agnostic)).
+					Return(tc.vulnerable, nil).
+					Times(2)
+				m = &agnosticMatcher{Matcher: mock, anyVersion: tc.anyVersion}
+			} else {
+				mock.EXPECT().
+					Vulnerable(gomock.Any(), gomock.Any(), gomock.Any()).
+					Return(tc.vulnerable, nil).
+					Times(3)
+			}
+			record := &claircore.IndexRecord{
+				Package: &claircore.Package{Name: "pkg", Version: tc.version},
+			}
+			got, err := filterVulns(ctx, m, record, vulns)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != len(tc.want) {
+				t.Fatalf("got: %d vulnerabilities, want: %d", len(got), len(tc.want))
+			}
+			for i, v := range got {
+				if v.ID != tc.want[i] {
+					t.Errorf("got: %q, want: %q", v.ID, tc.want[i])
+				}
+			}
+		})
+	}
+}
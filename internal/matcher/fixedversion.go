@@ -0,0 +1,121 @@
+package matcher
+
+import (
+	"context"
+
+	"github.com/quay/claircore"
+	"github.com/quay/claircore/datastore/memory"
+	"github.com/quay/claircore/libvuln/driver"
+)
+
+// Reported holds the vulnerabilities each Matcher reported, keyed by Matcher
+// name and then package id.
+type reported map[string]map[string][]*claircore.Vulnerability
+
+// ApplyFixedVersions records the version each vulnerable package should be
+// upgraded to in the report's FixedVersions, replacing its previous contents.
+//
+// The recommended version is the highest FixedInVersion of the package's
+// vulnerabilities, as ordered by the first of the Matchers handling the
+// package that implements driver.FixedVersionComparer. Only the
+// vulnerabilities that Matcher reported, and which are still in the report,
+// are considered: the versions of vulnerabilities found by other Matchers,
+// like the ecosystem ones folded in by overrides, aren't comparable.
+// Packages no such Matcher handles, and packages with only unfixed
+// vulnerabilities, get no recommendation.
+func applyFixedVersions(ctx context.Context, rt *router, ms []driver.Matcher, got reported, vr *claircore.VulnerabilityReport) {
+	vr.FixedVersions = nil
+	if len(vr.PackageVulnerabilities) == 0 {
+		return
+	}
+	// Picked is the Matcher recommending a version for each package.
+	picked := make(map[string]driver.Matcher)
+	for _, m := range ms {
+		if _, ok := m.(driver.FixedVersionComparer); !ok {
+			continue
+		}
+		for _, r := range rt.Records(m) {
+			if _, ok := vr.PackageVulnerabilities[r.Package.ID]; !ok {
+				continue
+			}
+			if _, ok := picked[r.Package.ID]; ok || !m.Filter(r) {
+				continue
+			}
+			picked[r.Package.ID] = m
+		}
+	}
+	for pkgID, ids := range vr.PackageVulnerabilities {
+		m, ok := picked[pkgID]
+		if !ok {
+			continue
+		}
+		c := m.(driver.FixedVersionComparer)
+		have := make(map[string]struct{}, len(ids))
+		for _, id := range ids {
+			have[id] = struct{}{}
+		}
+		var best string
+		for _, v := range got[m.Name()][pkgID] {
+			if _, ok := have[v.ID]; !ok || v.FixedInVersion == "" {
+				continue
+			}
+			if best == "" || c.CompareFixedVersions(ctx, v.FixedInVersion, best) > 0 {
+				best = v.FixedInVersion
+			}
+		}
+		if best == "" {
+			continue
+		}
+		if vr.FixedVersions == nil {
+			vr.FixedVersions = make(map[string]string)
+		}
+		vr.FixedVersions[pkgID] = best
+	}
+}
+
+// ReportedBy works out which of the vulnerabilities in "vs" each Matcher
+// implementing driver.FixedVersionComparer reports, by matching the routed
+// records against just those vulnerabilities.
+//
+// This is for reports whose vulnerabilities weren't all matched in one go,
+// so the Matchers' results aren't at hand.
+func reportedBy(ctx context.Context, rt *router, ms []driver.Matcher, vs map[string]*claircore.Vulnerability) (reported, error) {
+	// The vulnerabilities are already known, so don't pin update operations
+	// the in-memory store can't honor.
+	ctx = WithUpdateOperations(ctx, nil)
+	all := make([]*claircore.Vulnerability, 0, len(vs))
+	for _, v := range vs {
+		all = append(all, v)
+	}
+	store := memory.New(all...)
+	got := make(reported)
+	for _, m := range ms {
+		if _, ok := m.(driver.FixedVersionComparer); !ok {
+			continue
+		}
+		res, err := NewController(m, store).Match(ctx, rt.Records(m))
+		if err != nil {
+			return nil, err
+		}
+		got[m.Name()] = res
+	}
+	return got, nil
+}
+
+// FixedVersions recomputes the FixedVersions of the VulnerabilityReport "vr",
+// which was created from the IndexReport "ir" by the Matchers "ms", from the
+// vulnerabilities it currently lists. The Context should carry the same
+// options as when the report was created.
+//
+// This is needed after vulnerabilities are left out of a report, as the
+// recommended version may have been for one of them.
+func FixedVersions(ctx context.Context, ir *claircore.IndexReport, vr *claircore.VulnerabilityReport, ms []driver.Matcher) error {
+	records, _ := overrides(ctx).Expand(ir.IndexRecords())
+	rt := newRouter(records, ir.CanonicalPackages)
+	got, err := reportedBy(ctx, rt, ms, vr.Vulnerabilities)
+	if err != nil {
+		return err
+	}
+	applyFixedVersions(ctx, rt, ms, got, vr)
+	return nil
+}
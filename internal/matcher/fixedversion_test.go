@@ -0,0 +1,130 @@
+package matcher
+
+import (
+	"context"
+	"testing"
+
+	"github.com/quay/zlog"
+
+	"github.com/quay/claircore"
+	"github.com/quay/claircore/datastore/memory"
+	"github.com/quay/claircore/libvuln/driver"
+	"github.com/quay/claircore/libvuln/override"
+	"github.com/quay/claircore/python"
+)
+
+func TestMatchFixedVersions(t *testing.T) {
+	ctx := zlog.Test(context.Background(), t)
+	ir, vs := fixture(t)
+	var p *claircore.Package
+	for _, cur := range ir.Packages {
+		if p == nil || cur.ID < p.ID {
+			p = cur
+		}
+	}
+	// A second advisory for the package with a later fix, which is the one
+	// to recommend.
+	higher := p.Version + "+2"
+	vs = append(vs, &claircore.Vulnerability{
+		ID:             "higher",
+		Name:           "ADV-higher",
+		Updater:        "fixture",
+		Package:        &claircore.Package{Name: p.Name, Kind: claircore.BINARY},
+		Dist:           ir.Distributions["11"],
+		FixedInVersion: higher,
+	})
+	store := memory.New(vs...)
+
+	t.Run("Comparer", func(t *testing.T) {
+		vr, err := Match(ctx, ir, []driver.Matcher{&debianMatcher}, store)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := len(vr.FixedVersions), len(vr.PackageVulnerabilities); got != want {
+			t.Errorf("got: %d fixed versions, want: %d", got, want)
+		}
+		for id, got := range vr.FixedVersions {
+			want := ir.Packages[id].Version + "+1"
+			if id == p.ID {
+				want = higher
+			}
+			if got != want {
+				t.Errorf("%s: got: %q, want: %q", ir.Packages[id].Name, got, want)
+			}
+		}
+	})
+	t.Run("Recompute", func(t *testing.T) {
+		vr, err := Match(ctx, ir, []driver.Matcher{&debianMatcher}, store)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Leave out the advisory the recommendation was for, as a
+		// ReportFilter would.
+		var keep []string
+		for _, id := range vr.PackageVulnerabilities[p.ID] {
+			if id != "higher" {
+				keep = append(keep, id)
+			}
+		}
+		vr.PackageVulnerabilities[p.ID] = keep
+		delete(vr.Vulnerabilities, "higher")
+		if err := FixedVersions(ctx, ir, vr, []driver.Matcher{&debianMatcher}); err != nil {
+			t.Fatal(err)
+		}
+		if got, want := vr.FixedVersions[p.ID], p.Version+"+1"; got != want {
+			t.Errorf("got: %q, want: %q", got, want)
+		}
+	})
+	t.Run("Overrides", func(t *testing.T) {
+		// An ecosystem advisory folded into the package has a version that
+		// sorts higher, but isn't comparable to the distribution's.
+		rs, err := override.New([]override.Rule{{
+			Name:      p.Name,
+			Ecosystem: "pypi",
+		}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		pm, err := python.NewMatcher(python.MatcherConfig{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		store := memory.New(append(vs[:len(vs):len(vs)], &claircore.Vulnerability{
+			ID:             "ecosystem",
+			Name:           "PYSEC-0000-1",
+			Updater:        "osv",
+			Package:        &claircore.Package{Name: p.Name, Version: "<999", Kind: claircore.BINARY},
+			Repo:           &python.Repository,
+			FixedInVersion: "999",
+		})...)
+		ms := []driver.Matcher{&debianMatcher, pm}
+		vr, err := Match(WithOverrides(ctx, rs), ir, ms, store)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var folded bool
+		for _, id := range vr.PackageVulnerabilities[p.ID] {
+			folded = folded || id == "ecosystem"
+		}
+		if !folded {
+			t.Fatal("ecosystem advisory not matched")
+		}
+		if got, want := vr.FixedVersions[p.ID], higher; got != want {
+			t.Errorf("got: %q, want: %q", got, want)
+		}
+	})
+	t.Run("NoComparer", func(t *testing.T) {
+		// Hide the matcher's CompareFixedVersions method.
+		m := struct{ driver.Matcher }{&debianMatcher}
+		vr, err := Match(ctx, ir, []driver.Matcher{m}, store)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(vr.PackageVulnerabilities) == 0 {
+			t.Fatal("no vulnerabilities matched")
+		}
+		if vr.FixedVersions != nil {
+			t.Errorf("unexpected fixed versions: %v", vr.FixedVersions)
+		}
+	})
+}
@@ -6,33 +6,41 @@ import (
 	"fmt"
 	"runtime"
 	"sync/atomic"
+	"time"
 
+	"github.com/google/uuid"
 	"github.com/quay/zlog"
 	"golang.org/x/sync/errgroup"
 
 	"github.com/quay/claircore"
 	"github.com/quay/claircore/datastore"
+	"github.com/quay/claircore/internal/vercache"
 	"github.com/quay/claircore/libvuln/driver"
+	"github.com/quay/claircore/libvuln/override"
+	"github.com/quay/claircore/pkg/tracing"
 )
 
+// VersionCacheSize is the size of the cache of parsed versions shared by the
+// matchers for one report. Tests set it to zero to disable the cache.
+var versionCacheSize = vercache.DefaultSize
+
 // Match receives an IndexReport and creates a VulnerabilityReport containing matched vulnerabilities
 func Match(ctx context.Context, ir *claircore.IndexReport, matchers []driver.Matcher, store datastore.Vulnerability) (*claircore.VulnerabilityReport, error) {
+	ctx, span := tracing.Start(ctx, "matcher.Match",
+		tracing.String("manifest", ir.Hash.String()))
+	defer span.End()
+	// share parsed versions between the matchers
+	ctx = vercache.WithCache(ctx, vercache.New(versionCacheSize))
 	// the vulnerability report we are creating
-	vr := &claircore.VulnerabilityReport{
-		Hash:                   ir.Hash,
-		Packages:               ir.Packages,
-		Environments:           ir.Environments,
-		Distributions:          ir.Distributions,
-		Repositories:           ir.Repositories,
-		Vulnerabilities:        map[string]*claircore.Vulnerability{},
-		PackageVulnerabilities: map[string][]string{},
-	}
+	vr := newReport(ctx, ir)
 
-	// extract IndexRecords from the IndexReport
-	records := ir.IndexRecords()
-	// a channel where concurrent controllers will deliver vulnerabilities affecting a package.
-	// maps a package id to a list of vulnerabilities.
-	ctrlC := make(chan map[string][]*claircore.Vulnerability, 1024)
+	// extract IndexRecords from the IndexReport, add any records for
+	// overridden packages, and index them for routing
+	records, ex := overrides(ctx).Expand(ir.IndexRecords())
+	rt := newRouter(records, ir.CanonicalPackages)
+	// a channel where concurrent controllers will deliver vulnerabilities affecting a package,
+	// along with any enrichments the matcher contributed.
+	ctrlC := make(chan *result, 1024)
 	// a channel where controller errors will be reported
 	errorC := make(chan error, 1024)
 	// fan out all controllers, write their output to ctrlC, close ctrlC once all writers finish
@@ -43,12 +51,12 @@ func Match(ctx context.Context, ir *claircore.IndexReport, matchers []driver.Mat
 			mm := m
 			g.Go(func() error {
 				mc := NewController(mm, store)
-				vulns, err := mc.Match(ctx, records)
+				res, err := mc.matchAndEnrich(ctx, rt.Records(mm))
 				if err != nil {
 					return err
 				}
 				// in event of slow reader go routines will block
-				ctrlC <- vulns
+				ctrlC <- res
 				return nil
 			})
 		}
@@ -57,22 +65,149 @@ func Match(ctx context.Context, ir *claircore.IndexReport, matchers []driver.Mat
 		}
 	}()
 	// loop ranges until ctrlC is closed and fully drained, ctrlC is guaranteed to close
-	for vulnsByPackage := range ctrlC {
-		for pkgID, vulns := range vulnsByPackage {
-			for _, vuln := range vulns {
-				vr.Vulnerabilities[vuln.ID] = vuln
-				vr.PackageVulnerabilities[pkgID] = append(vr.PackageVulnerabilities[pkgID], vuln.ID)
-			}
-		}
+	got := make(reported, len(matchers))
+	for res := range ctrlC {
+		res.addTo(vr)
+		got[res.name] = res.vulns
 	}
 	select {
 	case err := <-errorC:
+		span.RecordError(err)
 		return nil, err
 	default:
 	}
+	ex.Fold(vr)
+	applyConditions(ctx, ir, vr, strictConditions(ctx))
+	applyConfidence(ctx, vr, suppressLowConfidence(ctx))
+	applyFixedVersions(ctx, rt, matchers, got, vr)
 	return vr, nil
 }
 
+// NewReport returns an empty VulnerabilityReport for the IndexReport, with
+// Stats allocated if timing was requested with WithTiming.
+func newReport(ctx context.Context, ir *claircore.IndexReport) *claircore.VulnerabilityReport {
+	vr := &claircore.VulnerabilityReport{
+		SchemaVersion:          claircore.ReportSchemaVersion,
+		Hash:                   ir.Hash,
+		Packages:               ir.Packages,
+		Environments:           ir.Environments,
+		Distributions:          ir.Distributions,
+		Repositories:           ir.Repositories,
+		Vulnerabilities:        map[string]*claircore.Vulnerability{},
+		PackageVulnerabilities: map[string][]string{},
+		Enrichments:            map[string][]json.RawMessage{},
+	}
+	if timing(ctx) {
+		vr.Stats = &claircore.MatchStats{
+			Matchers: make(map[string]time.Duration),
+		}
+	}
+	return vr
+}
+
+type timingKey struct{}
+
+// WithTiming returns a Context that makes Match and EnrichedMatch record the
+// time spent in each matcher in the returned report's Stats.
+func WithTiming(ctx context.Context) context.Context {
+	return context.WithValue(ctx, timingKey{}, true)
+}
+
+// Timing reports whether WithTiming was used on the Context.
+func timing(ctx context.Context) bool {
+	ok, _ := ctx.Value(timingKey{}).(bool)
+	return ok
+}
+
+type overrideKey struct{}
+
+// WithOverrides returns a Context that makes Match and EnrichedMatch also
+// match the packages the Rules apply to against the Rules' ecosystems.
+func WithOverrides(ctx context.Context, rs *override.Rules) context.Context {
+	return context.WithValue(ctx, overrideKey{}, rs)
+}
+
+// Overrides returns the Rules provided with WithOverrides, or nil.
+func overrides(ctx context.Context) *override.Rules {
+	rs, _ := ctx.Value(overrideKey{}).(*override.Rules)
+	return rs
+}
+
+type updateOperationsKey struct{}
+
+// WithUpdateOperations returns a Context that makes Match and EnrichedMatch
+// only consider the vulnerabilities recorded by the provided update
+// operations, keyed by updater name. Updaters without an update operation
+// use their current vulnerabilities.
+//
+// This allows reproducing a match against the vulnerabilities known at an
+// earlier time, as long as the update operations haven't been garbage
+// collected. Vulnerabilities an updater has since stopped reporting are still
+// recorded by its earlier update operations and so are part of the result,
+// unless the updater is a driver.Tombstoner: those delete withdrawn
+// vulnerabilities outright.
+func WithUpdateOperations(ctx context.Context, ops map[string]uuid.UUID) context.Context {
+	return context.WithValue(ctx, updateOperationsKey{}, ops)
+}
+
+// UpdateOperations returns the update operations provided with
+// WithUpdateOperations, or nil.
+func updateOperations(ctx context.Context) map[string]uuid.UUID {
+	ops, _ := ctx.Value(updateOperationsKey{}).(map[string]uuid.UUID)
+	return ops
+}
+
+// Result is the output of a single Controller.
+type result struct {
+	// the name of the matcher and the time it took.
+	name string
+	took time.Duration
+	// maps a package id to a list of vulnerabilities.
+	vulns map[string][]*claircore.Vulnerability
+	// enrichments contributed by the matcher, if any.
+	kind string
+	msg  []json.RawMessage
+}
+
+// MatchAndEnrich runs the Controller's matcher and then asks for any
+// enrichments it wants to attach to the results.
+//
+// Enrichment errors are logged and otherwise ignored.
+func (mc *Controller) matchAndEnrich(ctx context.Context, records []*claircore.IndexRecord) (*result, error) {
+	start := time.Now()
+	vulns, err := mc.Match(ctx, records)
+	if err != nil {
+		return nil, err
+	}
+	res := result{name: mc.m.Name(), vulns: vulns}
+	res.kind, res.msg, err = mc.Enrich(ctx, vulns)
+	if err != nil {
+		zlog.Error(ctx).
+			Err(err).
+			Str("matcher", mc.m.Name()).
+			Msg("match enrichment error")
+		res.kind, res.msg = "", nil
+	}
+	res.took = time.Since(start)
+	return &res, nil
+}
+
+// AddTo attaches the result to the provided VulnerabilityReport.
+func (r *result) addTo(vr *claircore.VulnerabilityReport) {
+	for pkgID, vulns := range r.vulns {
+		for _, vuln := range vulns {
+			vr.Vulnerabilities[vuln.ID] = vuln
+			vr.PackageVulnerabilities[pkgID] = append(vr.PackageVulnerabilities[pkgID], vuln.ID)
+		}
+	}
+	if len(r.msg) != 0 {
+		vr.Enrichments[r.kind] = append(vr.Enrichments[r.kind], r.msg...)
+	}
+	if vr.Stats != nil {
+		vr.Stats.Matchers[r.name] += r.took
+	}
+}
+
 // Store is the interface that can retrieve Enrichments and Vulnerabilities.
 type Store interface {
 	datastore.Vulnerability
@@ -82,25 +217,19 @@ type Store interface {
 // EnrichedMatch receives an IndexReport and creates a VulnerabilityReport
 // containing matched vulnerabilities and any relevant enrichments.
 func EnrichedMatch(ctx context.Context, ir *claircore.IndexReport, ms []driver.Matcher, es []driver.Enricher, s Store) (*claircore.VulnerabilityReport, error) {
+	// share parsed versions between the matchers
+	ctx = vercache.WithCache(ctx, vercache.New(versionCacheSize))
 	// the vulnerability report we are creating
-	vr := &claircore.VulnerabilityReport{
-		Hash:                   ir.Hash,
-		Packages:               ir.Packages,
-		Environments:           ir.Environments,
-		Distributions:          ir.Distributions,
-		Repositories:           ir.Repositories,
-		Vulnerabilities:        map[string]*claircore.Vulnerability{},
-		PackageVulnerabilities: map[string][]string{},
-		// The Enrichments member isn't constructed here because it's
-		// constructed separately and then added.
-	}
-	// extract IndexRecords from the IndexReport
-	records := ir.IndexRecords()
+	vr := newReport(ctx, ir)
+	// extract IndexRecords from the IndexReport, add any records for
+	// overridden packages, and index them for routing
+	records, ex := overrides(ctx).Expand(ir.IndexRecords())
+	rt := newRouter(records, ir.CanonicalPackages)
 	lim := runtime.GOMAXPROCS(0)
 
 	// Set up a pool to run matchers
 	mCh := make(chan driver.Matcher)
-	vCh := make(chan map[string][]*claircore.Vulnerability, lim)
+	vCh := make(chan *result, lim)
 	mg, mctx := errgroup.WithContext(ctx) // match group, match context
 	for i := 0; i < lim; i++ {
 		mg.Go(func() error { // Worker
@@ -111,11 +240,11 @@ func EnrichedMatch(ctx context.Context, ir *claircore.IndexReport, ms []driver.M
 					return mctx.Err()
 				default:
 				}
-				vs, err := NewController(m, s).Match(mctx, records)
+				res, err := NewController(m, s).matchAndEnrich(mctx, rt.Records(m))
 				if err != nil {
 					return fmt.Errorf("matcher error: %w", err)
 				}
-				vCh <- vs
+				vCh <- res
 			}
 			return nil
 		})
@@ -138,20 +267,24 @@ func EnrichedMatch(ctx context.Context, ir *claircore.IndexReport, ms []driver.M
 		}
 		return nil
 	})
+	got := make(reported, len(ms))
 	vg.Go(func() error { // Collector
-		for pkgVuln := range vCh {
-			for pkg, vs := range pkgVuln {
-				for _, v := range vs {
-					vr.Vulnerabilities[v.ID] = v
-					vr.PackageVulnerabilities[pkg] = append(vr.PackageVulnerabilities[pkg], v.ID)
-				}
-			}
+		for res := range vCh {
+			res.addTo(vr)
+			got[res.name] = res.vulns
 		}
 		return nil
 	})
 	if err := vg.Wait(); err != nil {
 		return nil, err
 	}
+	ex.Fold(vr)
+	// Conditions and confidence are applied before enriching, so enrichers
+	// don't see vulnerabilities that are left out. Confidence goes last, so
+	// LowConfidence only lists vulnerabilities conditions kept.
+	applyConditions(ctx, ir, vr, strictConditions(ctx))
+	applyConfidence(ctx, vr, suppressLowConfidence(ctx))
+	applyFixedVersions(ctx, rt, ms, got, vr)
 
 	// Set up a pool to run the enrichers and attach results to the report.
 	eCh := make(chan driver.Enricher)
@@ -178,7 +311,11 @@ func EnrichedMatch(ctx context.Context, ir *claircore.IndexReport, ms []driver.M
 		for e := range rCh {
 			em[e.kind] = append(em[e.kind], e.msg...)
 		}
-		vr.Enrichments = em
+		// Enrichments contributed by matchers are already present in the
+		// report, so append to them.
+		for k, v := range em {
+			vr.Enrichments[k] = append(vr.Enrichments[k], v...)
+		}
 		return nil
 	})
 	// Use an atomic to track closing the results channel.
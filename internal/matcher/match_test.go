@@ -0,0 +1,260 @@
+package matcher
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/uuid"
+	"github.com/quay/zlog"
+
+	"github.com/quay/claircore"
+	"github.com/quay/claircore/datastore"
+	"github.com/quay/claircore/datastore/memory"
+	"github.com/quay/claircore/debian"
+	"github.com/quay/claircore/libvuln/driver"
+	"github.com/quay/claircore/libvuln/override"
+	"github.com/quay/claircore/python"
+)
+
+// LoadFixture loads the IndexReport in testdata and generates a set of
+// advisories for it.
+//
+// Every package gets advisories that are fixed in a later version, fixed in an
+// earlier version, marked unaffected, and unfixed, so half of them match. Each
+// package also gets the same advisories for an older release, which the
+// matcher has to filter out.
+func loadFixture(t testing.TB) (*claircore.IndexReport, *memory.Store) {
+	t.Helper()
+	ir, vs := fixture(t)
+	return ir, memory.New(vs...)
+}
+
+// Fixture returns the IndexReport and advisories used by loadFixture. Every
+// advisory has an ID.
+func fixture(t testing.TB) (*claircore.IndexReport, []*claircore.Vulnerability) {
+	t.Helper()
+	f, err := os.Open(filepath.Join("testdata", "debian-10.index.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var ir claircore.IndexReport
+	if err := json.NewDecoder(f).Decode(&ir); err != nil {
+		t.Fatal(err)
+	}
+
+	old := &claircore.Distribution{
+		DID:     "debian",
+		Name:    "Debian GNU/Linux",
+		Version: "9 (stretch)",
+	}
+	var vs []*claircore.Vulnerability
+	for _, d := range []*claircore.Distribution{ir.Distributions["11"], old} {
+		for _, p := range ir.Packages {
+			for _, fixed := range []string{p.Version + "+1", "0.0.1", "0", ""} {
+				vs = append(vs, &claircore.Vulnerability{
+					ID:             strconv.Itoa(len(vs)),
+					Name:           fmt.Sprintf("ADV-%s-%s-%s", d.Version, p.Name, fixed),
+					Updater:        "fixture",
+					Package:        &claircore.Package{Name: p.Name, Kind: claircore.BINARY},
+					Dist:           d,
+					FixedInVersion: fixed,
+				})
+			}
+		}
+	}
+	return &ir, vs
+}
+
+func TestMatchTiming(t *testing.T) {
+	ctx := zlog.Test(context.Background(), t)
+	ir, store := loadFixture(t)
+	ms := []driver.Matcher{&debian.Matcher{}}
+
+	vr, err := Match(ctx, ir, ms, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if vr.Stats != nil {
+		t.Errorf("unexpected stats: %+v", vr.Stats)
+	}
+	if got, want := len(vr.Vulnerabilities), 2*len(ir.Packages); got != want {
+		t.Errorf("got: %d vulnerabilities, want: %d", got, want)
+	}
+
+	vr, err = Match(WithTiming(ctx), ir, ms, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if vr.Stats == nil {
+		t.Fatal("missing stats")
+	}
+	if _, ok := vr.Stats.Matchers["debian-matcher"]; !ok {
+		t.Errorf("missing timing for matcher: %v", vr.Stats.Matchers)
+	}
+}
+
+// OptsRecorder is a datastore.Vulnerability recording the GetOpts it's
+// called with, and returning nothing.
+type optsRecorder struct {
+	seen chan datastore.GetOpts
+}
+
+func (r *optsRecorder) Get(_ context.Context, _ []*claircore.IndexRecord, opts datastore.GetOpts) (map[string][]*claircore.Vulnerability, error) {
+	r.seen <- opts
+	return nil, nil
+}
+
+func TestMatchUpdateOperations(t *testing.T) {
+	ctx := zlog.Test(context.Background(), t)
+	ir, store := loadFixture(t)
+	ms := []driver.Matcher{&debian.Matcher{}}
+	ops := map[string]uuid.UUID{"fixture": uuid.New()}
+
+	rec := optsRecorder{seen: make(chan datastore.GetOpts, 1)}
+	if _, err := Match(WithUpdateOperations(ctx, ops), ir, ms, &rec); err != nil {
+		t.Fatal(err)
+	}
+	if got := <-rec.seen; !cmp.Equal(got.UpdateOperations, ops) {
+		t.Error(cmp.Diff(ops, got.UpdateOperations))
+	}
+
+	// The memory store can't honor the update operations, so it must not
+	// silently return current vulnerabilities.
+	_, err := Match(WithUpdateOperations(ctx, ops), ir, ms, store)
+	t.Log(err)
+	if !errors.Is(err, datastore.ErrUnknownUpdateOperation) {
+		t.Errorf("got: %v, want: %v", err, datastore.ErrUnknownUpdateOperation)
+	}
+}
+
+func TestMatchOverrides(t *testing.T) {
+	ctx := zlog.Test(context.Background(), t)
+	ir := &claircore.IndexReport{
+		Packages: map[string]*claircore.Package{
+			"1": {ID: "1", Name: "python3-requests", Version: "2.25.1-8.el9", Kind: claircore.BINARY},
+		},
+		Distributions: map[string]*claircore.Distribution{
+			"1": {ID: "1", DID: "rhel", VersionID: "9"},
+		},
+		Environments: map[string][]*claircore.Environment{
+			"1": {{PackageDB: "sqlite:var/lib/rpm", DistributionID: "1"}},
+		},
+	}
+	store := memory.New(&claircore.Vulnerability{
+		ID:      "1",
+		Updater: "osv",
+		Name:    "PYSEC-2023-74",
+		Package: &claircore.Package{Name: "requests", Version: "<2.31.0", Kind: claircore.BINARY},
+		Repo:    &python.Repository,
+	})
+	pm, err := python.NewMatcher(python.MatcherConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ms := []driver.Matcher{pm}
+
+	vr, err := Match(ctx, ir, ms, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := len(vr.Vulnerabilities); got != 0 {
+		t.Errorf("got: %d vulnerabilities without overrides, want: 0", got)
+	}
+
+	rs, err := override.New([]override.Rule{{
+		Name:       "python3-*",
+		Ecosystem:  "pypi",
+		TrimPrefix: "python3-",
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	vr, err = Match(WithOverrides(ctx, rs), ir, ms, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := vr.PackageVulnerabilities, map[string][]string{"1": {"1"}}; !cmp.Equal(got, want) {
+		t.Error(cmp.Diff(got, want))
+	}
+}
+
+// BenchmarkMatch measures Match over a realistic IndexReport and advisory
+// set, using the in-memory store so the result doesn't depend on a database.
+func BenchmarkMatch(b *testing.B) {
+	ctx := zlog.Test(context.Background(), b)
+	ir, store := loadFixture(b)
+	ms := []driver.Matcher{&debian.Matcher{}}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		vr, err := Match(ctx, ir, ms, store)
+		if err != nil {
+			b.Fatal(err)
+		}
+		if len(vr.Vulnerabilities) == 0 {
+			b.Fatal("no vulnerabilities matched")
+		}
+	}
+	b.ReportMetric(float64(len(ir.Packages)*b.N)/b.Elapsed().Seconds(), "packages/s")
+}
+
+// BenchmarkMatchSameVersion measures Match over a report where every package
+// has the same version and every advisory the same fixed version, with and
+// without the cache of parsed versions.
+func BenchmarkMatchSameVersion(b *testing.B) {
+	ctx := zlog.Test(context.Background(), b)
+	const n = 2000
+	dist := &claircore.Distribution{ID: "1", DID: "debian", Name: "Debian GNU/Linux", VersionID: "11"}
+	ir := &claircore.IndexReport{
+		Packages:      make(map[string]*claircore.Package, n),
+		Distributions: map[string]*claircore.Distribution{"1": dist},
+		Environments:  make(map[string][]*claircore.Environment, n),
+	}
+	var vs []*claircore.Vulnerability
+	for i := 0; i < n; i++ {
+		id := strconv.Itoa(i)
+		name := "pkg" + id
+		ir.Packages[id] = &claircore.Package{ID: id, Name: name, Version: "1:2.36-9+deb11u4", Kind: claircore.BINARY}
+		ir.Environments[id] = []*claircore.Environment{{PackageDB: "var/lib/dpkg/status", DistributionID: "1"}}
+		for j := 0; j < 4; j++ {
+			vs = append(vs, &claircore.Vulnerability{
+				ID:             strconv.Itoa(len(vs)),
+				Name:           fmt.Sprintf("CVE-2023-%d", len(vs)),
+				Updater:        "fixture",
+				Package:        &claircore.Package{Name: name, Kind: claircore.BINARY},
+				Dist:           dist,
+				FixedInVersion: "1:2.36-9+deb11u5",
+			})
+		}
+	}
+	store := memory.New(vs...)
+	ms := []driver.Matcher{&debian.Matcher{}}
+
+	run := func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			vr, err := Match(ctx, ir, ms, store)
+			if err != nil {
+				b.Fatal(err)
+			}
+			if got, want := len(vr.Vulnerabilities), len(vs); got != want {
+				b.Fatalf("got: %d vulnerabilities, want: %d", got, want)
+			}
+		}
+	}
+	b.Run("Cached", run)
+	b.Run("Uncached", func(b *testing.B) {
+		defer func(n int) { versionCacheSize = n }(versionCacheSize)
+		versionCacheSize = 0
+		run(b)
+	})
+}
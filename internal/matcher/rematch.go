@@ -0,0 +1,122 @@
+package matcher
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+
+	"github.com/quay/zlog"
+
+	"github.com/quay/claircore"
+	"github.com/quay/claircore/datastore/memory"
+	"github.com/quay/claircore/libvuln/driver"
+	"github.com/quay/claircore/pkg/tracing"
+)
+
+// Rematch returns a copy of the VulnerabilityReport "vr", which was created
+// from the IndexReport "ir", updated for the changes described by the
+// provided UpdateDiffs.
+//
+// Vulnerabilities removed by an update are dropped from the report and only
+// the vulnerabilities added by an update are matched against the IndexReport,
+// so this is much cheaper than matching the whole IndexReport again.
+// Enrichments are carried over as-is.
+func Rematch(ctx context.Context, ir *claircore.IndexReport, vr *claircore.VulnerabilityReport, ms []driver.Matcher, diffs ...*driver.UpdateDiff) (*claircore.VulnerabilityReport, error) {
+	ctx = zlog.ContextWithValues(ctx, "component", "internal/matcher/Rematch")
+	ctx, span := tracing.Start(ctx, "matcher.Rematch",
+		tracing.String("manifest", ir.Hash.String()))
+	defer span.End()
+
+	removed := make(map[string]struct{})
+	var added []*claircore.Vulnerability
+	for _, d := range diffs {
+		for i := range d.Removed {
+			removed[d.Removed[i].ID] = struct{}{}
+		}
+		for i := range d.Added {
+			added = append(added, &d.Added[i])
+		}
+	}
+
+	out := *vr
+	out.Vulnerabilities = make(map[string]*claircore.Vulnerability, len(vr.Vulnerabilities))
+	out.PackageVulnerabilities = make(map[string][]string, len(vr.PackageVulnerabilities))
+	out.Enrichments = make(map[string][]json.RawMessage, len(vr.Enrichments))
+	for id, v := range vr.Vulnerabilities {
+		if _, ok := removed[id]; !ok {
+			out.Vulnerabilities[id] = v
+		}
+	}
+	for pkg, ids := range vr.PackageVulnerabilities {
+		keep := make([]string, 0, len(ids))
+		for _, id := range ids {
+			if _, ok := removed[id]; !ok {
+				keep = append(keep, id)
+			}
+		}
+		if len(keep) != 0 {
+			out.PackageVulnerabilities[pkg] = keep
+		}
+	}
+	for k, v := range vr.Enrichments {
+		out.Enrichments[k] = v
+	}
+	out.ConditionallyAffected = nil
+	for _, id := range vr.ConditionallyAffected {
+		if _, ok := removed[id]; !ok {
+			out.ConditionallyAffected = append(out.ConditionallyAffected, id)
+		}
+	}
+	rm := len(vr.Vulnerabilities) - len(out.Vulnerabilities)
+	var ct int
+	if len(added) != 0 {
+		nr, err := Match(ctx, ir, ms, memory.New(added...))
+		if err != nil {
+			span.RecordError(err)
+			return nil, err
+		}
+		for pkg, ids := range nr.PackageVulnerabilities {
+		Add:
+			for _, id := range ids {
+				for _, have := range out.PackageVulnerabilities[pkg] {
+					if have == id {
+						continue Add
+					}
+				}
+				out.PackageVulnerabilities[pkg] = append(out.PackageVulnerabilities[pkg], id)
+				out.Vulnerabilities[id] = nr.Vulnerabilities[id]
+				ct++
+			}
+		}
+		out.Stats = nr.Stats
+		if len(nr.ConditionallyAffected) != 0 {
+			out.ConditionallyAffected = append(out.ConditionallyAffected, nr.ConditionallyAffected...)
+			sort.Strings(out.ConditionallyAffected)
+			out.ConditionallyAffected = compactStrings(out.ConditionallyAffected)
+		}
+	}
+	// Recompute which vulnerabilities only low-confidence packages have, as
+	// both the removed and added vulnerabilities can change it.
+	applyConfidence(ctx, &out, suppressLowConfidence(ctx))
+	// Likewise for the recommended fixed versions.
+	if err := FixedVersions(ctx, ir, &out, ms); err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	zlog.Debug(ctx).
+		Int("removed", rm).
+		Int("added", ct).
+		Msg("rematched report")
+	return &out, nil
+}
+
+// CompactStrings removes consecutive duplicates from the sorted slice.
+func compactStrings(ss []string) []string {
+	out := ss[:0]
+	for _, s := range ss {
+		if len(out) == 0 || s != out[len(out)-1] {
+			out = append(out, s)
+		}
+	}
+	return out
+}
@@ -0,0 +1,86 @@
+package matcher
+
+import (
+	"context"
+	"sort"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/quay/zlog"
+
+	"github.com/quay/claircore"
+	"github.com/quay/claircore/datastore/memory"
+	"github.com/quay/claircore/debian"
+	"github.com/quay/claircore/libvuln/driver"
+)
+
+// TestRematch checks that updating a report with an UpdateDiff produces the
+// same report as matching against the updated vulnerabilities.
+func TestRematch(t *testing.T) {
+	t.Parallel()
+	ctx := zlog.Test(context.Background(), t)
+	ms := []driver.Matcher{&debian.Matcher{}}
+	ir, vs := fixture(t)
+	// Some advisories only apply under a condition that can't be confirmed.
+	for i, v := range vs {
+		if i%7 == 0 {
+			v.Condition = "file:etc/not-recorded.conf"
+		}
+	}
+
+	// Start with the first two thirds of the advisories, then remove the
+	// first third and add the last.
+	third := len(vs) / 3
+	before, err := Match(ctx, ir, ms, memory.New(vs[:2*third]...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	diff := &driver.UpdateDiff{}
+	for _, v := range vs[:third] {
+		diff.Removed = append(diff.Removed, *v)
+	}
+	for _, v := range vs[2*third:] {
+		diff.Added = append(diff.Added, *v)
+	}
+	n := len(before.Vulnerabilities)
+	got, err := Rematch(ctx, ir, before, ms, diff)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := Match(ctx, ir, ms, memory.New(vs[third:]...))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if cmp.Equal(before.PackageVulnerabilities, want.PackageVulnerabilities) {
+		t.Fatal("fixture doesn't change the report")
+	}
+	for _, r := range []*claircore.VulnerabilityReport{got, want} {
+		for _, ids := range r.PackageVulnerabilities {
+			sort.Strings(ids)
+		}
+	}
+	if !cmp.Equal(got.PackageVulnerabilities, want.PackageVulnerabilities) {
+		t.Error(cmp.Diff(got.PackageVulnerabilities, want.PackageVulnerabilities))
+	}
+	if got, want := len(got.Vulnerabilities), len(want.Vulnerabilities); got != want {
+		t.Errorf("got: %d vulnerabilities, want: %d", got, want)
+	}
+	for id := range want.Vulnerabilities {
+		if _, ok := got.Vulnerabilities[id]; !ok {
+			t.Errorf("missing vulnerability %q", id)
+		}
+	}
+	if len(want.ConditionallyAffected) == 0 {
+		t.Fatal("fixture has no conditional vulnerabilities")
+	}
+	if !cmp.Equal(got.ConditionallyAffected, want.ConditionallyAffected) {
+		t.Error(cmp.Diff(got.ConditionallyAffected, want.ConditionallyAffected))
+	}
+	if !cmp.Equal(got.FixedVersions, want.FixedVersions) {
+		t.Error(cmp.Diff(got.FixedVersions, want.FixedVersions))
+	}
+	if len(before.Vulnerabilities) != n {
+		t.Error("original report modified")
+	}
+}
@@ -0,0 +1,101 @@
+package matcher
+
+import (
+	"sort"
+
+	"github.com/quay/claircore"
+	"github.com/quay/claircore/libvuln/driver"
+)
+
+// Router partitions a set of IndexRecords so that each Matcher is only handed
+// the records in its driver.Scope.
+//
+// The records are indexed once, so routing them to a Scoped Matcher costs time
+// proportional to the number of records in its Scope rather than the total.
+//
+// Records for packages with a canonical package, which are language packages
+// owned by an OS package, aren't handed to Matchers implementing
+// driver.OSOwnedFilter.
+type router struct {
+	all       []*claircore.IndexRecord
+	canonical map[string]string
+	byDID     map[string][]int
+	byName    map[string][]int
+	byRepo    map[string][]int
+}
+
+// NewRouter indexes the provided records. The "canonical" map is the
+// IndexReport's CanonicalPackages.
+func newRouter(records []*claircore.IndexRecord, canonical map[string]string) *router {
+	r := router{
+		all:       records,
+		canonical: canonical,
+		byDID:     make(map[string][]int),
+		byName:    make(map[string][]int),
+		byRepo:    make(map[string][]int),
+	}
+	for i, rec := range records {
+		if d := rec.Distribution; d != nil {
+			if d.DID != "" {
+				r.byDID[d.DID] = append(r.byDID[d.DID], i)
+			}
+			if d.Name != "" {
+				r.byName[d.Name] = append(r.byName[d.Name], i)
+			}
+		}
+		if repo := rec.Repository; repo != nil && repo.Name != "" {
+			r.byRepo[repo.Name] = append(r.byRepo[repo.Name], i)
+		}
+	}
+	return &r
+}
+
+// Records returns the records the Matcher can possibly match, in their
+// original order. A Matcher that doesn't implement driver.Scoped gets every
+// record.
+func (r *router) Records(m driver.Matcher) []*claircore.IndexRecord {
+	rs := r.scoped(m)
+	if _, ok := m.(driver.OSOwnedFilter); !ok || len(r.canonical) == 0 {
+		return rs
+	}
+	out := make([]*claircore.IndexRecord, 0, len(rs))
+	for _, rec := range rs {
+		if _, ok := r.canonical[rec.Package.ID]; ok {
+			continue
+		}
+		out = append(out, rec)
+	}
+	return out
+}
+
+// Scoped returns the records in the Matcher's driver.Scope.
+func (r *router) scoped(m driver.Matcher) []*claircore.IndexRecord {
+	sm, ok := m.(driver.Scoped)
+	if !ok {
+		return r.all
+	}
+	s := sm.Scope()
+	if s.Empty() {
+		return r.all
+	}
+	var idx []int
+	for _, k := range s.Distributions {
+		idx = append(idx, r.byDID[k]...)
+	}
+	for _, k := range s.DistributionNames {
+		idx = append(idx, r.byName[k]...)
+	}
+	for _, k := range s.Repositories {
+		idx = append(idx, r.byRepo[k]...)
+	}
+	sort.Ints(idx)
+	out := make([]*claircore.IndexRecord, 0, len(idx))
+	for n, i := range idx {
+		// A record may be listed under more than one key.
+		if n != 0 && idx[n-1] == i {
+			continue
+		}
+		out = append(out, r.all[i])
+	}
+	return out
+}
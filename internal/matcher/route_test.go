@@ -0,0 +1,173 @@
+package matcher
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/quay/zlog"
+
+	"github.com/quay/claircore"
+	"github.com/quay/claircore/debian"
+	"github.com/quay/claircore/libvuln/driver"
+)
+
+// ScopedMatcher wraps a Matcher, adding a Scope and counting calls to Filter.
+type scopedMatcher struct {
+	driver.Matcher
+	scope   driver.Scope
+	filters atomic.Int64
+}
+
+func (m *scopedMatcher) Scope() driver.Scope { return m.scope }
+
+func (m *scopedMatcher) Filter(r *claircore.IndexRecord) bool {
+	m.filters.Add(1)
+	return m.Matcher.Filter(r)
+}
+
+func TestRouter(t *testing.T) {
+	debian := &claircore.Distribution{DID: "debian", Name: "Debian GNU/Linux"}
+	named := &claircore.Distribution{Name: "Debian GNU/Linux"}
+	alpine := &claircore.Distribution{DID: "alpine", Name: "Alpine Linux"}
+	maven := &claircore.Repository{Name: "maven"}
+	rs := []*claircore.IndexRecord{
+		{Package: &claircore.Package{ID: "0"}, Distribution: debian},
+		{Package: &claircore.Package{ID: "1"}, Distribution: alpine},
+		{Package: &claircore.Package{ID: "2"}, Distribution: named},
+		{Package: &claircore.Package{ID: "3"}, Repository: maven},
+		{Package: &claircore.Package{ID: "4"}, Distribution: debian, Repository: maven},
+		{Package: &claircore.Package{ID: "5"}},
+	}
+	rt := newRouter(rs, nil)
+
+	tt := []struct {
+		Name  string
+		Scope driver.Scope
+		Want  []string
+	}{
+		{Name: "Empty", Want: []string{"0", "1", "2", "3", "4", "5"}},
+		{
+			Name:  "Distribution",
+			Scope: driver.Scope{Distributions: []string{"debian"}},
+			Want:  []string{"0", "4"},
+		},
+		{
+			Name: "DistributionName",
+			Scope: driver.Scope{
+				Distributions:     []string{"debian"},
+				DistributionNames: []string{"Debian GNU/Linux"},
+			},
+			Want: []string{"0", "2", "4"},
+		},
+		{
+			Name:  "Repository",
+			Scope: driver.Scope{Repositories: []string{"maven"}},
+			Want:  []string{"3", "4"},
+		},
+		{
+			Name: "Union",
+			Scope: driver.Scope{
+				Distributions: []string{"alpine", "debian"},
+				Repositories:  []string{"maven"},
+			},
+			Want: []string{"0", "1", "3", "4"},
+		},
+		{
+			Name:  "None",
+			Scope: driver.Scope{Distributions: []string{"ubuntu"}},
+			Want:  []string{},
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			m := &scopedMatcher{Matcher: &debianMatcher, scope: tc.Scope}
+			got := []string{}
+			for _, r := range rt.Records(m) {
+				got = append(got, r.Package.ID)
+			}
+			if !cmp.Equal(got, tc.Want) {
+				t.Error(cmp.Diff(got, tc.Want))
+			}
+		})
+	}
+
+	t.Run("Unscoped", func(t *testing.T) {
+		// Embedding the interface hides the Matcher's Scope method.
+		m := struct{ driver.Matcher }{&debianMatcher}
+		if got, want := len(rt.Records(m)), len(rs); got != want {
+			t.Errorf("got: %d records, want: %d", got, want)
+		}
+	})
+}
+
+var debianMatcher debian.Matcher
+
+func TestMatchScoped(t *testing.T) {
+	ctx := zlog.Test(context.Background(), t)
+	ir, store := loadFixture(t)
+
+	in := &scopedMatcher{
+		Matcher: &debianMatcher,
+		scope:   (&debianMatcher).Scope(),
+	}
+	out := &scopedMatcher{
+		Matcher: &debianMatcher,
+		scope:   driver.Scope{Distributions: []string{"alpine"}},
+	}
+	vr, err := Match(ctx, ir, []driver.Matcher{in, out}, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := len(vr.Vulnerabilities), 2*len(ir.Packages); got != want {
+		t.Errorf("got: %d vulnerabilities, want: %d", got, want)
+	}
+	if got, want := in.filters.Load(), int64(len(ir.IndexRecords())); got != want {
+		t.Errorf("in scope: got: %d calls to Filter, want: %d", got, want)
+	}
+	if got := out.filters.Load(); got != 0 {
+		t.Errorf("out of scope: got: %d calls to Filter, want: 0", got)
+	}
+}
+
+// OwnedMatcher wraps a Matcher, opting in to skipping OS-owned packages.
+type ownedMatcher struct{ driver.Matcher }
+
+func (*ownedMatcher) SkipOSOwned() {}
+
+func TestMatchOSOwned(t *testing.T) {
+	ctx := zlog.Test(context.Background(), t)
+	ir, store := loadFixture(t)
+	var owned string
+	for id := range ir.Packages {
+		if owned == "" || id < owned {
+			owned = id
+		}
+	}
+	// Any other package will do as the owner.
+	ir.CanonicalPackages = map[string]string{owned: "owner"}
+
+	for _, tc := range []struct {
+		Name    string
+		Matcher driver.Matcher
+		Skipped bool
+	}{
+		{Name: "OptIn", Matcher: &ownedMatcher{&debianMatcher}, Skipped: true},
+		{Name: "Default", Matcher: &debianMatcher},
+	} {
+		t.Run(tc.Name, func(t *testing.T) {
+			vr, err := Match(ctx, ir, []driver.Matcher{tc.Matcher}, store)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, got := vr.PackageVulnerabilities[owned]
+			if want := !tc.Skipped; got != want {
+				t.Errorf("package %q matched: got: %v, want: %v", owned, got, want)
+			}
+			if got, want := len(vr.PackageVulnerabilities), len(ir.Packages)-1; got < want {
+				t.Errorf("got: %d packages matched, want: at least %d", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+// Package ospkg reports which files in a layer are owned by the operating
+// system's package manager.
+//
+// The Resolver uses this to link language packages to the OS packages
+// owning their files. Otherwise the same files are reported once as, for
+// example, an rpm package and again as a python package, and language
+// Matchers implementing driver.OSOwnedFilter can skip the linked packages.
+package ospkg
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+
+	"github.com/quay/claircore/alpine"
+	"github.com/quay/claircore/dpkg"
+	"github.com/quay/claircore/rpm"
+)
+
+// Files maps files owned by OS packages to the name of the owning package.
+//
+// The zero value owns nothing.
+type Files map[string]string
+
+// Owned loads the files owned by the rpm, dpkg, and apk packages installed
+// in "sys".
+//
+// Only the package databases in "sys" itself are consulted, so for a layer
+// this finds files installed by the OS package manager in that same layer.
+func Owned(ctx context.Context, sys fs.FS) (Files, error) {
+	var out Files
+	for _, f := range []func(context.Context, fs.FS) (map[string]string, error){
+		rpm.OwnedFiles,
+		dpkg.OwnedFiles,
+		alpine.OwnedFiles,
+	} {
+		m, err := f(ctx, sys)
+		if err != nil {
+			return nil, fmt.Errorf("ospkg: unable to load owned files: %w", err)
+		}
+		switch {
+		case len(m) == 0:
+		case out == nil:
+			out = m
+		default:
+			for p, n := range m {
+				out[p] = n
+			}
+		}
+	}
+	return out, nil
+}
+
+// Has reports whether the file at "p" is owned by an OS package.
+func (f Files) Has(p string) bool {
+	_, ok := f[p]
+	return ok
+}
+
+// Owner returns the name of the OS package owning the file at "p", if any.
+func (f Files) Owner(p string) (string, bool) {
+	n, ok := f[p]
+	return n, ok
+}
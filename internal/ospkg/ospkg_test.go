@@ -0,0 +1,40 @@
+package ospkg
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+
+	"github.com/quay/zlog"
+)
+
+func TestOwned(t *testing.T) {
+	ctx := zlog.Test(context.Background(), t)
+	sys := fstest.MapFS{
+		"var/lib/dpkg/info/python3-requests.list": &fstest.MapFile{Data: []byte(
+			"/.\n/usr/lib/python3/dist-packages/requests-2.28.1.egg-info/PKG-INFO\n")},
+		"lib/apk/db/installed": &fstest.MapFile{Data: []byte(
+			"P:py3-six\nF:usr/lib/python3.11/site-packages/six-1.16.0.dist-info\nR:METADATA\n")},
+	}
+	owned, err := Owned(ctx, sys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for p, want := range map[string]bool{
+		"usr/lib/python3/dist-packages/requests-2.28.1.egg-info/PKG-INFO":      true,
+		"usr/lib/python3.11/site-packages/six-1.16.0.dist-info/METADATA":       true,
+		"usr/local/lib/python3.11/site-packages/six-1.16.0.dist-info/METADATA": false,
+	} {
+		if got := owned.Has(p); got != want {
+			t.Errorf("%q: got: %v, want: %v", p, got, want)
+		}
+	}
+
+	owned, err = Owned(ctx, fstest.MapFS{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if owned.Has("usr/bin/python3") {
+		t.Error("empty layer owns files")
+	}
+}
@@ -0,0 +1,145 @@
+package ospkg
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/quay/zlog"
+
+	"github.com/quay/claircore"
+	"github.com/quay/claircore/indexer"
+)
+
+var _ indexer.Resolver = (*Resolver)(nil)
+
+// Resolver links language packages to the OS packages owning their files.
+//
+// The Resolver uses the package databases as they are in the final image, so
+// it finds owned files whether they're in the same layer as the database
+// claiming them or in an earlier one. The owning OS package is recorded as
+// the canonical entry in the IndexReport's CanonicalPackages; the language
+// package itself is kept.
+type Resolver struct{}
+
+// Resolve implements indexer.Resolver.
+func (r *Resolver) Resolve(ctx context.Context, ir *claircore.IndexReport, layers []*claircore.Layer) *claircore.IndexReport {
+	ctx = zlog.ContextWithValues(ctx, "component", "internal/ospkg/Resolver.Resolve")
+	owned, err := finalOwned(ctx, layers)
+	if err != nil {
+		// Reconciliation is best-effort; the report is still correct without
+		// it, just noisier.
+		zlog.Warn(ctx).Err(err).Msg("unable to load owned files")
+		return ir
+	}
+	if len(owned) == 0 {
+		return ir
+	}
+
+	// Index the OS packages by name. Some package managers allow multiple
+	// versions of a package to be installed, so pick one predictably.
+	byName := make(map[string]string)
+	ids := make([]string, 0, len(ir.Packages))
+	for id := range ir.Packages {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		p := ir.Packages[id]
+		if !isOSPackage(p) {
+			continue
+		}
+		if _, ok := byName[p.Name]; !ok {
+			byName[p.Name] = id
+		}
+	}
+
+	for _, id := range ids {
+		p := ir.Packages[id]
+		if p.Kind != claircore.BINARY || isOSPackage(p) {
+			continue
+		}
+		n, ok := owned.Owner(pkgPath(p))
+		if !ok {
+			continue
+		}
+		osID, ok := byName[n]
+		if !ok {
+			zlog.Debug(ctx).
+				Str("package", p.Name).
+				Str("owner", n).
+				Msg("owning package not in report")
+			continue
+		}
+		if ir.CanonicalPackages == nil {
+			ir.CanonicalPackages = make(map[string]string)
+		}
+		ir.CanonicalPackages[id] = osID
+		zlog.Debug(ctx).
+			Str("package", p.Name).
+			Str("path", pkgPath(p)).
+			Str("owner", n).
+			Msg("package owned by OS package")
+	}
+	return ir
+}
+
+// FinalOwned returns the owned files according to the package databases in
+// the topmost layer that has any.
+//
+// Package managers rewrite their whole database when anything changes, so
+// that layer has the image's final view of package ownership.
+func finalOwned(ctx context.Context, layers []*claircore.Layer) (Files, error) {
+	for i := len(layers) - 1; i >= 0; i-- {
+		owned, err := layerOwned(ctx, layers[i])
+		if err != nil {
+			return nil, err
+		}
+		if owned != nil {
+			return owned, nil
+		}
+	}
+	return nil, nil
+}
+
+func layerOwned(ctx context.Context, l *claircore.Layer) (Files, error) {
+	sys, err := l.FS()
+	if err != nil {
+		return nil, fmt.Errorf("ospkg: unable to open layer %s: %w", l.Hash, err)
+	}
+	defer sys.Close()
+	return Owned(ctx, sys)
+}
+
+// IsOSPackage reports whether "p" was found in one of the package databases
+// Owned reads, at the root of a layer.
+func isOSPackage(p *claircore.Package) bool {
+	db := p.PackageDB
+	if k, rest, ok := strings.Cut(db, ":"); ok {
+		switch k {
+		case "bdb", "sqlite", "ndb":
+			// Rpm databases are prefixed with their format. Any further
+			// prefix means the database was nested in some other file.
+			return !strings.Contains(rest, ":")
+		default:
+			return false
+		}
+	}
+	return strings.HasPrefix(db, "var/lib/dpkg/") ||
+		db == "lib/apk/db/installed"
+}
+
+// PkgPath returns the path of the file a language package was found in.
+func pkgPath(p *claircore.Package) string {
+	if p.Filepath != "" {
+		return p.Filepath
+	}
+	// Language package databases are usually prefixed with a kind, like
+	// "go:" or "jar:".
+	db := p.PackageDB
+	if _, rest, ok := strings.Cut(db, ":"); ok {
+		db = rest
+	}
+	return db
+}
@@ -0,0 +1,133 @@
+package ospkg
+
+import (
+	"archive/tar"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/quay/zlog"
+
+	"github.com/quay/claircore"
+)
+
+// Layer writes a layer containing the files and returns it.
+func layer(t testing.TB, hash string, files map[string]string) *claircore.Layer {
+	n := filepath.Join(t.TempDir(), "layer.tar")
+	f, err := os.Create(n)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	tw := tar.NewWriter(f)
+	for name, data := range files {
+		if err := tw.WriteHeader(&tar.Header{
+			Typeflag: tar.TypeReg,
+			Name:     name,
+			Mode:     0o644,
+			Size:     int64(len(data)),
+		}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(data)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	l := claircore.Layer{
+		Hash: claircore.MustParseDigest(hash),
+		URI:  `file:///dev/null`,
+	}
+	l.SetLocal(n)
+	return &l
+}
+
+func TestResolver(t *testing.T) {
+	ctx := zlog.Test(context.Background(), t)
+	const metadata = `usr/lib/python3.11/site-packages/six-1.16.0.dist-info/METADATA`
+	layers := []*claircore.Layer{
+		// The python package's files and the database owning them are in
+		// separate layers.
+		layer(t, `sha256:1111111111111111111111111111111111111111111111111111111111111111`, map[string]string{
+			metadata:         "Name: six\nVersion: 1.16.0\n",
+			"usr/bin/runner": "",
+		}),
+		layer(t, `sha256:2222222222222222222222222222222222222222222222222222222222222222`, map[string]string{
+			"lib/apk/db/installed": "P:py3-six\nV:1.16.0-r6\nF:usr/lib/python3.11/site-packages/six-1.16.0.dist-info\nR:METADATA\n\n" +
+				"P:runner\nV:1.0-r0\nF:usr/bin\nR:runner\n",
+		}),
+	}
+	ir := &claircore.IndexReport{
+		Packages: map[string]*claircore.Package{
+			"1": {ID: "1", Name: "py3-six", Kind: claircore.BINARY, PackageDB: "lib/apk/db/installed"},
+			"2": {ID: "2", Name: "six", Kind: claircore.BINARY, PackageDB: "python:usr/lib/python3.11/site-packages", Filepath: metadata},
+			"3": {ID: "3", Name: "requests", Kind: claircore.BINARY, PackageDB: "python:usr/local/lib/python3.11/site-packages", Filepath: "usr/local/lib/python3.11/site-packages/requests-2.28.1.dist-info/METADATA"},
+			// Owned, but the owner isn't in the report.
+			"4": {ID: "4", Name: "stdlib", Kind: claircore.BINARY, PackageDB: "go:usr/bin/runner"},
+		},
+	}
+
+	var r Resolver
+	got := r.Resolve(ctx, ir, layers).CanonicalPackages
+	want := map[string]string{"2": "1"}
+	if !cmp.Equal(got, want) {
+		t.Error(cmp.Diff(got, want))
+	}
+	for id, want := range map[string]string{"1": "1", "2": "1", "3": "3"} {
+		if got := ir.CanonicalPackage(id); got != want {
+			t.Errorf("%s: got: %q, want: %q", id, got, want)
+		}
+	}
+}
+
+func TestResolverSameLayer(t *testing.T) {
+	ctx := zlog.Test(context.Background(), t)
+	const metadata = `usr/lib/python3.11/site-packages/six-1.16.0.dist-info/METADATA`
+	layers := []*claircore.Layer{
+		layer(t, `sha256:1111111111111111111111111111111111111111111111111111111111111111`, map[string]string{
+			"etc/os-release": "ID=alpine\n",
+		}),
+		// The python package's files and the database owning them are in
+		// the same layer, as when the OS package is installed on its own.
+		layer(t, `sha256:2222222222222222222222222222222222222222222222222222222222222222`, map[string]string{
+			metadata:               "Name: six\nVersion: 1.16.0\n",
+			"lib/apk/db/installed": "P:py3-six\nV:1.16.0-r6\nF:usr/lib/python3.11/site-packages/six-1.16.0.dist-info\nR:METADATA\n",
+		}),
+	}
+	ir := &claircore.IndexReport{
+		Packages: map[string]*claircore.Package{
+			"1": {ID: "1", Name: "py3-six", Kind: claircore.BINARY, PackageDB: "lib/apk/db/installed"},
+			"2": {ID: "2", Name: "six", Kind: claircore.BINARY, PackageDB: "python:usr/lib/python3.11/site-packages", Filepath: metadata},
+		},
+	}
+
+	var r Resolver
+	got := r.Resolve(ctx, ir, layers).CanonicalPackages
+	want := map[string]string{"2": "1"}
+	if !cmp.Equal(got, want) {
+		t.Error(cmp.Diff(got, want))
+	}
+}
+
+func TestIsOSPackage(t *testing.T) {
+	for db, want := range map[string]bool{
+		"bdb:var/lib/rpm":                            true,
+		"sqlite:usr/lib/sysimage/rpm":                true,
+		"var/lib/dpkg/status":                        true,
+		"var/lib/dpkg/status.d/base":                 true,
+		"lib/apk/db/installed":                       true,
+		"bdb:boot/initramfs.img:var/lib/rpm":         false,
+		"boot/initramfs.img:var/lib/dpkg/status":     false,
+		"python:usr/lib/python3/site-packages":       false,
+		"go:usr/bin/runner":                          false,
+		"usr/share/gems/specifications/rake.gemspec": false,
+	} {
+		if got := isOSPackage(&claircore.Package{PackageDB: db}); got != want {
+			t.Errorf("%q: got: %v, want: %v", db, got, want)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package vercache
+
+import (
+	"context"
+	"strings"
+
+	debversion "github.com/knqyf263/go-deb-version"
+	rpmversion "github.com/knqyf263/go-rpm-version"
+)
+
+// Deb parses a Debian package version, using the Cache in the Context if
+// there is one.
+func Deb(ctx context.Context, raw string) (debversion.Version, error) {
+	return Parse(ctx, "deb", raw, debversion.NewVersion)
+}
+
+// RPM parses an RPM package version, using the Cache in the Context if there
+// is one.
+func RPM(ctx context.Context, raw string) rpmversion.Version {
+	v, _ := Parse(ctx, "rpm", raw, parseRPM)
+	return v
+}
+
+func parseRPM(raw string) (rpmversion.Version, error) {
+	return rpmversion.NewVersion(raw), nil
+}
+
+// CompareDeb compares the Debian package versions "a" and "b", returning a
+// negative number, zero, or a positive number as "a" is lower than, equal
+// to, or higher than "b". Versions that don't parse are compared as strings.
+func CompareDeb(ctx context.Context, a, b string) int {
+	va, errA := Deb(ctx, a)
+	vb, errB := Deb(ctx, b)
+	if errA != nil || errB != nil {
+		return strings.Compare(a, b)
+	}
+	return va.Compare(vb)
+}
+
+// CompareRPM compares the RPM package versions "a" and "b", returning a
+// negative number, zero, or a positive number as "a" is lower than, equal
+// to, or higher than "b".
+func CompareRPM(ctx context.Context, a, b string) int {
+	return RPM(ctx, a).Compare(RPM(ctx, b))
+}
@@ -0,0 +1,123 @@
+// Package vercache implements a small LRU cache of parsed versions, shared by
+// the matchers run for a single report.
+//
+// Big images have many packages at the same version, and advisories repeat
+// the same fixed versions, so most of the version parsing done while matching
+// is parsing the same strings again.
+package vercache
+
+import (
+	"container/list"
+	"context"
+	"sync"
+)
+
+// DefaultSize is the number of parsed versions a Cache created by the matcher
+// holds.
+const DefaultSize = 4096
+
+// Cache is a bounded, least-recently-used cache of parsed versions. It's safe
+// for concurrent use.
+//
+// A nil *Cache caches nothing.
+type Cache struct {
+	mu  sync.Mutex
+	max int
+	ll  list.List
+	m   map[key]*list.Element
+}
+
+// Key identifies an entry. The scheme keeps versions parsed by different
+// functions apart.
+type key struct {
+	scheme string
+	raw    string
+}
+
+// Entry is an element in the LRU list.
+type entry struct {
+	key key
+	v   any
+	err error
+}
+
+// New returns a Cache holding up to "size" entries. If "size" isn't positive,
+// nil is returned.
+func New(size int) *Cache {
+	if size <= 0 {
+		return nil
+	}
+	return &Cache{
+		max: size,
+		m:   make(map[key]*list.Element, size),
+	}
+}
+
+func (c *Cache) get(k key) (*entry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.m[k]
+	if !ok {
+		return nil, false
+	}
+	c.ll.MoveToFront(el)
+	return el.Value.(*entry), true
+}
+
+func (c *Cache) put(e *entry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.m[e.key]; ok {
+		// Another goroutine parsed the same version.
+		c.ll.MoveToFront(el)
+		return
+	}
+	c.m[e.key] = c.ll.PushFront(e)
+	if c.ll.Len() > c.max {
+		el := c.ll.Back()
+		c.ll.Remove(el)
+		delete(c.m, el.Value.(*entry).key)
+	}
+}
+
+// Len reports the number of entries in the Cache.
+func (c *Cache) Len() int {
+	if c == nil {
+		return 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.ll.Len()
+}
+
+type ctxKey struct{}
+
+// WithCache returns a Context carrying the Cache.
+func WithCache(ctx context.Context, c *Cache) context.Context {
+	return context.WithValue(ctx, ctxKey{}, c)
+}
+
+// FromContext returns the Cache carried by the Context, or nil.
+func FromContext(ctx context.Context) *Cache {
+	c, _ := ctx.Value(ctxKey{}).(*Cache)
+	return c
+}
+
+// Parse returns the result of calling "parse" on "raw", using the Cache in the
+// Context if there is one. Errors are cached along with successful results.
+//
+// The "scheme" names the versioning scheme "parse" implements. Every call
+// using the same scheme must use an equivalent parse function.
+func Parse[V any](ctx context.Context, scheme, raw string, parse func(string) (V, error)) (V, error) {
+	c := FromContext(ctx)
+	if c == nil {
+		return parse(raw)
+	}
+	k := key{scheme: scheme, raw: raw}
+	if e, ok := c.get(k); ok {
+		return e.v.(V), e.err
+	}
+	v, err := parse(raw)
+	c.put(&entry{key: k, v: v, err: err})
+	return v, err
+}
@@ -0,0 +1,108 @@
+package vercache
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+// Counter returns a parse function that counts its calls.
+func counter() (func(string) (int, error), *int) {
+	var n int
+	return func(s string) (int, error) {
+		n++
+		return strconv.Atoi(s)
+	}, &n
+}
+
+func TestParse(t *testing.T) {
+	ctx := WithCache(context.Background(), New(2))
+	parse, calls := counter()
+
+	for i := 0; i < 3; i++ {
+		v, err := Parse(ctx, "int", "1", parse)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if v != 1 {
+			t.Errorf("got: %d, want: 1", v)
+		}
+	}
+	if got, want := *calls, 1; got != want {
+		t.Errorf("got: %d calls, want: %d", got, want)
+	}
+
+	t.Run("Error", func(t *testing.T) {
+		*calls = 0
+		for i := 0; i < 2; i++ {
+			_, err := Parse(ctx, "int", "x", parse)
+			if !errors.Is(err, strconv.ErrSyntax) {
+				t.Errorf("got: %v, want: %v", err, strconv.ErrSyntax)
+			}
+		}
+		if got, want := *calls, 1; got != want {
+			t.Errorf("got: %d calls, want: %d", got, want)
+		}
+	})
+
+	t.Run("Evict", func(t *testing.T) {
+		*calls = 0
+		// "x" is most recent, so this evicts "1".
+		Parse(ctx, "int", "2", parse)
+		if got, want := FromContext(ctx).Len(), 2; got != want {
+			t.Errorf("got: %d entries, want: %d", got, want)
+		}
+		Parse(ctx, "int", "1", parse)
+		if got, want := *calls, 2; got != want {
+			t.Errorf("got: %d calls, want: %d", got, want)
+		}
+	})
+
+	t.Run("Scheme", func(t *testing.T) {
+		v, err := Parse(ctx, "string", "1", func(s string) (string, error) { return s + "!", nil })
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := v, "1!"; got != want {
+			t.Errorf("got: %q, want: %q", got, want)
+		}
+	})
+
+	t.Run("NoCache", func(t *testing.T) {
+		ctx := context.Background()
+		*calls = 0
+		Parse(ctx, "int", "1", parse)
+		Parse(ctx, "int", "1", parse)
+		if got, want := *calls, 2; got != want {
+			t.Errorf("got: %d calls, want: %d", got, want)
+		}
+		if New(0) != nil {
+			t.Error("expected nil Cache")
+		}
+	})
+}
+
+func TestConcurrent(t *testing.T) {
+	ctx := WithCache(context.Background(), New(8))
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				s := strconv.Itoa(j % 16)
+				v, err := Parse(ctx, "int", s, strconv.Atoi)
+				if err != nil || strconv.Itoa(v) != s {
+					t.Errorf("got: %d, %v; want: %s", v, err, s)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if got, want := FromContext(ctx).Len(), 8; got != want {
+		t.Errorf("got: %d entries, want: %d", got, want)
+	}
+}
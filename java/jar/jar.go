@@ -61,7 +61,9 @@ const MinSize = 22
 // the jar.
 //
 // POM properties are a preferred source of information, falling back to
-// examining the jar manifest and then looking at the name. Anything that looks
+// examining the jar manifest and then looking at the name. If the manifest
+// only has a presentation name, a name is synthesized from it as a last
+// resort, and the Info is marked Heuristic. Anything that looks
 // like a jar bundled into the archive is also examined.
 //
 // The provided name is expected to be the full path within the layer to the jar
@@ -96,6 +98,19 @@ func Parse(ctx context.Context, name string, z *zip.Reader) ([]Info, error) {
 	// Look at the jar manifest if that fails.
 	i, err = extractManifest(ctx, base, z)
 	switch {
+	case errors.Is(err, nil) && i.Heuristic:
+		// A name guessed from the manifest is worse than one from the
+		// jar's file name, so prefer that if there is one.
+		if n, err := checkName(ctx, name); err == nil {
+			zlog.Debug(ctx).
+				Msg("using name mangling over synthesized manifest name")
+			ret = append(ret, n)
+			goto Finish
+		}
+		zlog.Debug(ctx).
+			Msg("using discovered manifest, with synthesized name")
+		ret = append(ret, i)
+		goto Finish
 	case errors.Is(err, nil):
 		zlog.Debug(ctx).
 			Msg("using discovered manifest")
@@ -325,7 +340,7 @@ type Info struct {
 	// Name is the machine name found.
 	//
 	// Metadata that contains a "presentation" name isn't used to populate this
-	// field.
+	// field, unless there's nothing else and Heuristic is set.
 	Name string
 	// Version is the version.
 	Version string
@@ -335,6 +350,10 @@ type Info struct {
 	// SHA is populated with the SHA1 of the file if this entry was discovered
 	// inside another archive.
 	SHA []byte
+	// Heuristic is set if the Name was synthesized from presentation metadata
+	// instead of found as-is, meaning it's a best guess and less likely to
+	// match advisories.
+	Heuristic bool
 }
 
 func (i *Info) String() string {
@@ -451,17 +470,53 @@ func (i *Info) parseManifest(ctx context.Context, r io.Reader) error {
 		}
 	}
 
+	heuristic := false
+	if name == "" && version != "" {
+		// Nothing usable as a machine name, so fall back to making one out
+		// of the presentation name.
+		if t := slugify(hdr.Get("Implementation-Title")); t != "" {
+			name = t
+			heuristic = true
+		}
+	}
+
 	if name == "" || version == "" {
 		zlog.Debug(ctx).
 			Strs("attrs", []string{name, version}).
 			Msg("manifest not useful")
 		return errUnpopulated
 	}
+	if heuristic {
+		zlog.Debug(ctx).
+			Str("name", name).
+			Msg("name synthesized from Implementation-Title")
+	}
 	i.Name = name
 	i.Version = version
+	i.Heuristic = heuristic
 	return nil
 }
 
+// Slugify turns a presentation name like "Apache Commons Lang" into something
+// shaped like an artifact ID, like "apache-commons-lang".
+func slugify(s string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(s) {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '.', r == '_':
+			if dash && b.Len() != 0 {
+				b.WriteByte('-')
+			}
+			dash = false
+			b.WriteRune(r)
+		default:
+			dash = true
+		}
+	}
+	return b.String()
+}
+
 // NewMainSectionReader returns a reader wrapping "r" that reads until the main
 // section of the manifest ends, or EOF. It appends newlines as needed to make
 // the manifest parse like MIME headers.
@@ -305,3 +305,87 @@ func TestManifestSectionReader(t *testing.T) {
 		})
 	}
 }
+
+func TestParsePreference(t *testing.T) {
+	ctx := zlog.Test(context.Background(), t)
+	const (
+		manifest = "Manifest-Version: 1.0\r\n" +
+			"Implementation-Title: Apache Commons Lang\r\n" +
+			"Implementation-Version: 2.6\r\n\r\n"
+		properties = "groupId=commons-lang\nartifactId=commons-lang\nversion=2.6\n"
+	)
+	mkJar := func(t *testing.T, files map[string]string) *zip.Reader {
+		t.Helper()
+		var buf bytes.Buffer
+		w := zip.NewWriter(&buf)
+		for n, c := range files {
+			f, err := w.Create(n)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := io.WriteString(f, c); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+		z, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return z
+	}
+	tt := []struct {
+		Name  string
+		Jar   string
+		Files map[string]string
+		Want  []Info
+	}{
+		{
+			Name: "Properties",
+			Jar:  "lib/lang.jar",
+			Files: map[string]string{
+				"META-INF/MANIFEST.MF": manifest,
+				"META-INF/maven/commons-lang/commons-lang/pom.properties": properties,
+			},
+			Want: []Info{{
+				Name:    "commons-lang:commons-lang",
+				Version: "2.6",
+				Source:  "META-INF/maven/commons-lang/commons-lang/pom.properties",
+			}},
+		},
+		{
+			Name:  "FileName",
+			Jar:   "lib/commons-lang-2.6.jar",
+			Files: map[string]string{"META-INF/MANIFEST.MF": manifest},
+			Want: []Info{{
+				Name:    "commons-lang",
+				Version: "2.6",
+				Source:  ".",
+			}},
+		},
+		{
+			Name:  "Heuristic",
+			Jar:   "lib/lang.jar",
+			Files: map[string]string{"META-INF/MANIFEST.MF": manifest},
+			Want: []Info{{
+				Name:      "apache-commons-lang",
+				Version:   "2.6",
+				Source:    "META-INF/MANIFEST.MF",
+				Heuristic: true,
+			}},
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			got, err := Parse(ctx, tc.Jar, mkJar(t, tc.Files))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !cmp.Equal(got, tc.Want) {
+				t.Error(cmp.Diff(tc.Want, got))
+			}
+		})
+	}
+}
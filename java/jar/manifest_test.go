@@ -197,3 +197,55 @@ Plugin-ScmUrl: https://github.com/jenkinsci/ssh-credentials-plugin
 		ExpectedVersion: "305.v8f4381501156",
 	},
 }
+
+func TestParseManifestHeuristic(t *testing.T) {
+	ctx := zlog.Test(context.Background(), t)
+	tt := []struct {
+		Name      string
+		Contents  string
+		Want      Info
+		WantError error
+	}{
+		{
+			Name: "PresentationOnly",
+			Contents: "Manifest-Version: 1.0\r\n" +
+				"Implementation-Title: Apache Commons Lang\r\n" +
+				"Implementation-Version: 2.6\r\n" +
+				"Implementation-Vendor: The Apache Software Foundation\r\n\r\n",
+			Want: Info{Name: "apache-commons-lang", Version: "2.6", Heuristic: true},
+		},
+		{
+			Name: "Punctuation",
+			Contents: "Manifest-Version: 1.0\r\n" +
+				"Implementation-Title: Acme (tm) Widgets: Core\r\n" +
+				"Implementation-Version: 1.2.3\r\n\r\n",
+			Want: Info{Name: "acme-tm-widgets-core", Version: "1.2.3", Heuristic: true},
+		},
+		{
+			Name: "MachineName",
+			Contents: "Manifest-Version: 1.0\r\n" +
+				"Implementation-Title: commons-lang\r\n" +
+				"Implementation-Vendor-Id: commons-lang\r\n" +
+				"Implementation-Version: 2.6\r\n\r\n",
+			Want: Info{Name: "commons-lang", Version: "2.6"},
+		},
+		{
+			Name: "NoVersion",
+			Contents: "Manifest-Version: 1.0\r\n" +
+				"Implementation-Title: Apache Commons Lang\r\n\r\n",
+			WantError: errUnpopulated,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			var got Info
+			err := got.parseManifest(ctx, strings.NewReader(tc.Contents))
+			if !errors.Is(err, tc.WantError) {
+				t.Fatalf("got error: %v, want: %v", err, tc.WantError)
+			}
+			if !cmp.Equal(got, tc.Want) {
+				t.Error(cmp.Diff(tc.Want, got))
+			}
+		})
+	}
+}
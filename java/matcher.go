@@ -11,7 +11,9 @@ import (
 type matcher struct{}
 
 var (
-	_ driver.Matcher = (*matcher)(nil)
+	_ driver.Matcher       = (*matcher)(nil)
+	_ driver.Scoped        = (*matcher)(nil)
+	_ driver.OSOwnedFilter = (*matcher)(nil)
 )
 
 // Name implements driver.Matcher.
@@ -22,20 +24,42 @@ func (*matcher) Filter(r *claircore.IndexRecord) bool {
 		r.Repository.Name == Repository.Name
 }
 
+// Scope implements driver.Scoped.
+func (*matcher) Scope() driver.Scope {
+	return driver.Scope{
+		Repositories: []string{Repository.Name},
+	}
+}
+
+// SkipOSOwned implements driver.OSOwnedFilter.
+func (*matcher) SkipOSOwned() {}
+
 // Query implements driver.Matcher.
 func (*matcher) Query() []driver.MatchConstraint {
 	return []driver.MatchConstraint{driver.RepositoryName}
 }
 
+// Vulnerable implements driver.Matcher.
+//
+// The FixedInVersion of the vulnerability is either a Maven version range
+// specification, such as "[2.0,2.14.1)", or URL query parameters: a "range"
+// holding a version range specification, or "introduced" and one of "fixed"
+// or "lastAffected".
 func (*matcher) Vulnerable(ctx context.Context, record *claircore.IndexRecord, vuln *claircore.Vulnerability) (bool, error) {
 	if vuln.FixedInVersion == "" {
 		return true, nil
 	}
+	if isMavenRange(vuln.FixedInVersion) {
+		return inRange(record.Package.Version, vuln.FixedInVersion)
+	}
 
 	decodedVersions, err := url.ParseQuery(vuln.FixedInVersion)
 	if err != nil {
 		return false, err
 	}
+	if decodedVersions.Has("range") {
+		return inRange(record.Package.Version, decodedVersions.Get("range"))
+	}
 
 	// Check for missing upper version
 	if !decodedVersions.Has("fixed") && !decodedVersions.Has("lastAffected") {
@@ -75,3 +99,17 @@ func (*matcher) Vulnerable(ctx context.Context, record *claircore.IndexRecord, v
 
 	return true, nil
 }
+
+// InRange reports whether the version "v" is within the version range
+// specification "spec".
+func inRange(v, spec string) (bool, error) {
+	rs, err := parseMavenRange(spec)
+	if err != nil {
+		return false, err
+	}
+	rv, err := parseMavenVersion(v)
+	if err != nil {
+		return false, err
+	}
+	return rs.Contains(rv), nil
+}
@@ -2,6 +2,7 @@ package java
 
 import (
 	"context"
+	"net/url"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
@@ -164,6 +165,82 @@ func TestVulnerable(t *testing.T) {
 			},
 			want: false,
 		},
+		{
+			name: "range0",
+			record: &claircore.IndexRecord{
+				Package: &claircore.Package{
+					Name:    "org.apache.logging.log4j:log4j-core",
+					Version: "2.14.1",
+					Kind:    "binary",
+				},
+			},
+			vuln: &claircore.Vulnerability{
+				Updater: "oss-index",
+				Name:    "CVE-2021-44228",
+				Package: &claircore.Package{
+					Name: "org.apache.logging.log4j:log4j-core",
+				},
+				FixedInVersion: "[2.0-beta9,2.15.0)",
+			},
+			want: true,
+		},
+		{
+			name: "range1",
+			record: &claircore.IndexRecord{
+				Package: &claircore.Package{
+					Name:    "org.apache.logging.log4j:log4j-core",
+					Version: "2.15.0",
+					Kind:    "binary",
+				},
+			},
+			vuln: &claircore.Vulnerability{
+				Updater: "oss-index",
+				Name:    "CVE-2021-44228",
+				Package: &claircore.Package{
+					Name: "org.apache.logging.log4j:log4j-core",
+				},
+				FixedInVersion: "[2.0-beta9,2.15.0)",
+			},
+			want: false,
+		},
+		{
+			name: "range2",
+			record: &claircore.IndexRecord{
+				Package: &claircore.Package{
+					Name:    "org.apache.logging.log4j:log4j-core",
+					Version: "2.0-alpha1",
+					Kind:    "binary",
+				},
+			},
+			vuln: &claircore.Vulnerability{
+				Updater: "oss-index",
+				Name:    "CVE-2021-44228",
+				Package: &claircore.Package{
+					Name: "org.apache.logging.log4j:log4j-core",
+				},
+				FixedInVersion: "range=" + url.QueryEscape("[2.0-beta9,2.15.0)"),
+			},
+			want: false,
+		},
+		{
+			name: "range3",
+			record: &claircore.IndexRecord{
+				Package: &claircore.Package{
+					Name:    "org.apache.logging.log4j:log4j-core",
+					Version: "2.15.0-SNAPSHOT",
+					Kind:    "binary",
+				},
+			},
+			vuln: &claircore.Vulnerability{
+				Updater: "oss-index",
+				Name:    "CVE-2021-44228",
+				Package: &claircore.Package{
+					Name: "org.apache.logging.log4j:log4j-core",
+				},
+				FixedInVersion: "range=" + url.QueryEscape("[2.0-beta9,2.15.0)"),
+			},
+			want: true,
+		},
 	}
 
 	for _, testcase := range testcases {
@@ -0,0 +1,145 @@
+package java
+
+import (
+	"fmt"
+	"strings"
+)
+
+// See https://maven.apache.org/enforcer/enforcer-rules/versionRanges.html
+//
+// A version range specification is one or more comma-separated ranges, each
+// bracketed with "[" and "]" for inclusive bounds or "(" and ")" for exclusive
+// bounds. Either bound may be omitted to leave that side unbounded, and a
+// single inclusive version ("[1.0]") means exactly that version. For example:
+//
+//	[2.0,2.14.1)      2.0 <= x < 2.14.1
+//	(,1.0],[1.2,)     x <= 1.0 or x >= 1.2
+//	[1.5]             x == 1.5
+//
+// This is the form used for affected versions by the OSS Index and by Maven
+// itself, and what GHSA ranges can be expressed as.
+
+// MavenRange is a single range in a version range specification.
+//
+// A nil bound is unbounded.
+type mavenRange struct {
+	Lower, Upper         *mavenVersion
+	LowerIncl, UpperIncl bool
+}
+
+// Contains reports whether the version "v" is within the range.
+func (r *mavenRange) Contains(v *mavenVersion) bool {
+	if r.Lower != nil {
+		c := v.Compare(r.Lower)
+		if c < 0 || (c == 0 && !r.LowerIncl) {
+			return false
+		}
+	}
+	if r.Upper != nil {
+		c := v.Compare(r.Upper)
+		if c > 0 || (c == 0 && !r.UpperIncl) {
+			return false
+		}
+	}
+	return true
+}
+
+// MavenRangeSpec is a version range specification: the union of its ranges.
+type mavenRangeSpec []mavenRange
+
+// Contains reports whether the version "v" is within any of the ranges.
+func (s mavenRangeSpec) Contains(v *mavenVersion) bool {
+	for i := range s {
+		if s[i].Contains(v) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsMavenRange reports whether "s" looks like a version range specification,
+// as opposed to a bare version or some other encoding.
+func isMavenRange(s string) bool {
+	s = strings.TrimSpace(s)
+	return strings.HasPrefix(s, "[") || strings.HasPrefix(s, "(")
+}
+
+// ParseMavenRange parses a version range specification.
+//
+// Unlike Maven, a bare version (a "soft" requirement) is not accepted: every
+// range must be bracketed.
+func parseMavenRange(s string) (mavenRangeSpec, error) {
+	var out mavenRangeSpec
+	rest := strings.TrimSpace(s)
+	if rest == "" {
+		return nil, fmt.Errorf("maven: empty version range")
+	}
+	for rest != "" {
+		var r mavenRange
+		switch rest[0] {
+		case '[':
+			r.LowerIncl = true
+		case '(':
+		default:
+			return nil, fmt.Errorf("maven: bad version range %q: expected '[' or '('", s)
+		}
+		end := strings.IndexAny(rest, "])")
+		if end == -1 {
+			return nil, fmt.Errorf("maven: bad version range %q: unterminated range", s)
+		}
+		r.UpperIncl = rest[end] == ']'
+		inner := rest[1:end]
+		rest = strings.TrimSpace(rest[end+1:])
+		if strings.ContainsAny(inner, "[(") {
+			return nil, fmt.Errorf("maven: bad version range %q: nested range", s)
+		}
+
+		lo, hi, isRange := strings.Cut(inner, ",")
+		lo, hi = strings.TrimSpace(lo), strings.TrimSpace(hi)
+		switch {
+		case !isRange:
+			// Exact version.
+			if !r.LowerIncl || !r.UpperIncl || lo == "" {
+				return nil, fmt.Errorf("maven: bad version range %q: single version must be inclusive", s)
+			}
+			v, err := parseMavenVersion(lo)
+			if err != nil {
+				return nil, fmt.Errorf("maven: bad version range %q: %w", s, err)
+			}
+			r.Lower, r.Upper = v, v
+		case strings.Contains(hi, ","):
+			return nil, fmt.Errorf("maven: bad version range %q: too many bounds", s)
+		default:
+			if lo != "" {
+				v, err := parseMavenVersion(lo)
+				if err != nil {
+					return nil, fmt.Errorf("maven: bad version range %q: %w", s, err)
+				}
+				r.Lower = v
+			}
+			if hi != "" {
+				v, err := parseMavenVersion(hi)
+				if err != nil {
+					return nil, fmt.Errorf("maven: bad version range %q: %w", s, err)
+				}
+				r.Upper = v
+			}
+			if r.Lower != nil && r.Upper != nil && r.Lower.Compare(r.Upper) > 0 {
+				return nil, fmt.Errorf("maven: bad version range %q: lower bound greater than upper bound", s)
+			}
+		}
+		out = append(out, r)
+
+		if rest == "" {
+			break
+		}
+		if rest[0] != ',' {
+			return nil, fmt.Errorf("maven: bad version range %q: expected ','", s)
+		}
+		rest = strings.TrimSpace(rest[1:])
+		if rest == "" {
+			return nil, fmt.Errorf("maven: bad version range %q: trailing ','", s)
+		}
+	}
+	return out, nil
+}
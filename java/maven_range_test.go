@@ -0,0 +1,83 @@
+package java
+
+import "testing"
+
+func TestMavenRange(t *testing.T) {
+	t.Parallel()
+	tt := []struct {
+		Range string
+		In    []string
+		Out   []string
+	}{
+		{
+			Range: "[2.0,2.14.1)",
+			In:    []string{"2.0", "2.0.0", "2.0.RELEASE", "2.1", "2.14.0", "2.14.1-SNAPSHOT", "2.14.1-rc1", "2.14.1-beta-2"},
+			Out:   []string{"1.9", "2.0-alpha-1", "2.0-SNAPSHOT", "2.14.1", "2.14.1.RELEASE", "2.14.1-1", "2.15"},
+		},
+		{
+			Range: "(,1.0],[1.2,)",
+			In:    []string{"0.1", "1.0", "1.0-final", "1.2", "1.2-sp1", "3"},
+			Out:   []string{"1.0-1", "1.1", "1.2-alpha1", "1.2-SNAPSHOT"},
+		},
+		{
+			Range: "[1.5]",
+			In:    []string{"1.5", "1.5.0", "1.5-ga"},
+			Out:   []string{"1.5.1", "1.4", "1.5-SNAPSHOT"},
+		},
+		{
+			Range: "(1.0,2.0)",
+			In:    []string{"1.0-1", "1.0.1", "2.0-alpha-1", "2.0-M1"},
+			Out:   []string{"1.0", "2.0", "1.0-SNAPSHOT"},
+		},
+		{
+			Range: " [ 1.0 , 2.0 ] ",
+			In:    []string{"1.0", "2.0"},
+			Out:   []string{"2.0.1"},
+		},
+	}
+	for _, tc := range tt {
+		rs, err := parseMavenRange(tc.Range)
+		if err != nil {
+			t.Errorf("%q: %v", tc.Range, err)
+			continue
+		}
+		for _, vs := range tc.In {
+			v, err := parseMavenVersion(vs)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !rs.Contains(v) {
+				t.Errorf("%q: %q should be in range", tc.Range, vs)
+			}
+		}
+		for _, vs := range tc.Out {
+			v, err := parseMavenVersion(vs)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if rs.Contains(v) {
+				t.Errorf("%q: %q should not be in range", tc.Range, vs)
+			}
+		}
+	}
+}
+
+func TestMavenRangeError(t *testing.T) {
+	t.Parallel()
+	for _, r := range []string{
+		"",
+		"1.0",
+		"[1.0",
+		"(1.0)",
+		"[]",
+		"[1.0,2.0,3.0]",
+		"[2.0,1.0]",
+		"[1.0,2.0),",
+		"[1.0,2.0)[3.0,)",
+		"[[1.0,2.0)]",
+	} {
+		if _, err := parseMavenRange(r); err == nil {
+			t.Errorf("%q: expected error", r)
+		}
+	}
+}
@@ -153,3 +153,22 @@ func TestMavenVersions(t *testing.T) {
 		}
 	})
 }
+
+func FuzzMavenVersion(f *testing.F) {
+	for _, s := range []string{"1.0", "1.0.1", "1-SNAPSHOT", "1-alpha10-SNAPSHOT", "1.0.0.RELEASE", "2.0-rc-1"} {
+		f.Add(s, "1.0")
+	}
+	f.Fuzz(func(t *testing.T, a, b string) {
+		va, err := parseMavenVersion(a)
+		if err != nil {
+			return
+		}
+		vb, err := parseMavenVersion(b)
+		if err != nil {
+			return
+		}
+		if ab, ba := va.Compare(vb), vb.Compare(va); ab != -ba {
+			t.Errorf("asymmetric comparison: %q vs %q: %d, %d", a, b, ab, ba)
+		}
+	})
+}
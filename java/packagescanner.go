@@ -24,7 +24,6 @@ import (
 	"github.com/quay/claircore"
 	"github.com/quay/claircore/indexer"
 	"github.com/quay/claircore/java/jar"
-	"github.com/quay/claircore/pkg/tarfs"
 )
 
 var (
@@ -64,7 +63,7 @@ type Scanner struct {
 func (*Scanner) Name() string { return "java" }
 
 // Version implements scanner.VersionedScanner.
-func (*Scanner) Version() string { return "4" }
+func (*Scanner) Version() string { return "7" }
 
 // Kind implements scanner.VersionedScanner.
 func (*Scanner) Kind() string { return "package" }
@@ -117,15 +116,11 @@ func (s *Scanner) Scan(ctx context.Context, layer *claircore.Layer) ([]*claircor
 		return nil, err
 	}
 
-	r, err := layer.Reader()
-	if err != nil {
-		return nil, err
-	}
-	defer r.Close()
-	sys, err := tarfs.New(r)
+	sys, err := layer.FS()
 	if err != nil {
 		return nil, err
 	}
+	defer sys.Close()
 
 	ars, err := archives(ctx, sys)
 	if err != nil {
@@ -223,12 +218,18 @@ func (s *Scanner) Scan(ctx context.Context, layer *claircore.Layer) ([]*claircor
 			case s.root != nil && i.Source == s.root.String():
 				// Populate as a maven artifact.
 				pkg.PackageDB = `maven:` + n
+			case l == "META-INF/MANIFEST.MF" && i.Heuristic:
+				// name synthesized from a manifest file's presentation
+				// name, so mark it as a guess
+				pkg.PackageDB = `jar-heuristic:` + n
+				pkg.Confidence = claircore.ConfidenceLow
 			case l == "META-INF/MANIFEST.MF":
 				// information pulled from a manifest file
 				pkg.PackageDB = `jar:` + n
 			case l == ".":
 				// Name guess.
 				pkg.PackageDB = `file:` + n
+				pkg.Confidence = claircore.ConfidenceLow
 			default:
 				return nil, fmt.Errorf("java: martian Info: %+v", i)
 			}
@@ -304,6 +305,7 @@ func (s *Scanner) search(ctx context.Context, i *jar.Info, ck []byte) error {
 		return sr.Response.Doc[i].ID < sr.Response.Doc[j].ID
 	})
 	i.Source = s.root.String()
+	i.Heuristic = false
 	d := &sr.Response.Doc[0]
 	i.Version = d.Version
 	i.Name = d.Group + ":" + d.Artifact
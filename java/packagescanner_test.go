@@ -396,6 +396,7 @@ var jenkins = test.ScannerTestcase{
 			Kind:           "binary",
 			PackageDB:      "file:usr/share/jenkins/jenkins.war",
 			RepositoryHint: "sha1:b875cd48a0bc955ae9c5c477ad991e1f26fb24d2",
+			Confidence:     claircore.ConfidenceLow,
 		},
 		{
 			Name:           "ant-launcher",
@@ -403,6 +404,7 @@ var jenkins = test.ScannerTestcase{
 			Kind:           "binary",
 			PackageDB:      "file:usr/share/jenkins/jenkins.war",
 			RepositoryHint: "sha1:ea0a0475fb6dfcdcf48b30410fd9d4f5c80df07e",
+			Confidence:     claircore.ConfidenceLow,
 		},
 		{
 			Name:           "antlr",
@@ -410,6 +412,7 @@ var jenkins = test.ScannerTestcase{
 			Kind:           "binary",
 			PackageDB:      "file:usr/share/jenkins/jenkins.war",
 			RepositoryHint: "sha1:83cd2cd674a217ade95a4bb83a8a14f351f48bd0",
+			Confidence:     claircore.ConfidenceLow,
 		},
 		{
 			Name:           "args4j:args4j",
@@ -459,6 +462,7 @@ var jenkins = test.ScannerTestcase{
 			Kind:           "binary",
 			PackageDB:      "file:usr/share/jenkins/jenkins.war",
 			RepositoryHint: "sha1:71ab0620a41ed37f626b96d80c2a7c58165550df",
+			Confidence:     claircore.ConfidenceLow,
 		},
 		{
 			Name:           "com.infradna.tool:bridge-method-annotation",
@@ -718,6 +722,7 @@ var jenkins = test.ScannerTestcase{
 			Kind:           "binary",
 			PackageDB:      "file:usr/share/jenkins/jenkins.war",
 			RepositoryHint: "sha1:a75914155a9f5808963170ec20653668a2ffd2fd",
+			Confidence:     claircore.ConfidenceLow,
 		},
 		{
 			Name:           "com.sun.solaris:embedded_su4j",
@@ -830,6 +835,7 @@ var jenkins = test.ScannerTestcase{
 			Kind:           "binary",
 			PackageDB:      "file:usr/share/jenkins/jenkins.war",
 			RepositoryHint: "sha1:6975da39a7040257bd51d21a231b76c915872d38",
+			Confidence:     claircore.ConfidenceLow,
 		},
 		{
 			Name:           "jaxen:jaxen",
@@ -844,6 +850,7 @@ var jenkins = test.ScannerTestcase{
 			Kind:           "binary",
 			PackageDB:      "file:usr/share/jenkins/jenkins.war",
 			RepositoryHint: "sha1:f37bba2b8b78fcc8111bb932318b621dcc6c5194",
+			Confidence:     claircore.ConfidenceLow,
 		},
 		{
 			Name:           "org.samba.jcifs:jcifs",
@@ -970,6 +977,7 @@ var jenkins = test.ScannerTestcase{
 			Kind:           "binary",
 			PackageDB:      "file:usr/share/jenkins/jenkins.war",
 			RepositoryHint: "sha1:ccbc77a5fd907ef863c29f3596c6f54ffa4e9442",
+			Confidence:     claircore.ConfidenceLow,
 		},
 		{
 			Name:           "org.jenkins-ci.modules:launchd-slave-installer",
@@ -1019,6 +1027,7 @@ var jenkins = test.ScannerTestcase{
 			Kind:           "binary",
 			PackageDB:      "file:usr/share/jenkins/jenkins.war",
 			RepositoryHint: "sha1:de7952cecd05b65e0e4370cc93fc03035175eef5",
+			Confidence:     claircore.ConfidenceLow,
 		},
 		{
 			Name:           "args4j:args4j",
@@ -1313,6 +1322,7 @@ var jenkins = test.ScannerTestcase{
 			Kind:           "binary",
 			PackageDB:      "file:usr/share/jenkins/jenkins.war",
 			RepositoryHint: "sha1:9b988ea84b9e4e9f1874e390ce099b8ac12cfff5",
+			Confidence:     claircore.ConfidenceLow,
 		},
 		{
 			Name:           "com.thoughtworks.xstream:xstream",
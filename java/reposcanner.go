@@ -4,16 +4,13 @@ package java
 
 import (
 	"context"
-	"errors"
 	"fmt"
-	"io"
 	"runtime/trace"
 
 	"github.com/quay/zlog"
 
 	"github.com/quay/claircore"
 	"github.com/quay/claircore/indexer"
-	"github.com/quay/claircore/pkg/tarfs"
 )
 
 var (
@@ -54,20 +51,11 @@ func (rs *RepoScanner) Scan(ctx context.Context, layer *claircore.Layer) ([]*cla
 		return nil, err
 	}
 
-	r, err := layer.Reader()
+	sys, err := layer.FS()
 	if err != nil {
-		return nil, err
-	}
-	defer r.Close()
-	ra, ok := r.(io.ReaderAt)
-	if !ok {
-		err := errors.New("unable to coerce to io.ReaderAt")
 		return nil, fmt.Errorf("opening layer failed: %w", err)
 	}
-	sys, err := tarfs.New(ra)
-	if err != nil {
-		return nil, err
-	}
+	defer sys.Close()
 	ars, err := archives(ctx, sys)
 	if err != nil {
 		return nil, err
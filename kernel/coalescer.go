@@ -0,0 +1,24 @@
+package kernel
+
+import (
+	"context"
+
+	"github.com/quay/claircore"
+	"github.com/quay/claircore/indexer"
+)
+
+type coalescer struct{}
+
+// Coalesce records the kernel release found in the highest layer as the
+// image's active kernel.
+func (c *coalescer) Coalesce(ctx context.Context, layerArtifacts []*indexer.LayerArtifacts) (*claircore.IndexReport, error) {
+	ir := &claircore.IndexReport{}
+	for _, l := range layerArtifacts {
+		for _, f := range l.Files {
+			if r := FileRelease(f); r != "" {
+				ir.ActiveKernel = r
+			}
+		}
+	}
+	return ir, nil
+}
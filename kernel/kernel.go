@@ -0,0 +1,125 @@
+// Package kernel implements detection of the kernel an image is configured to
+// boot, and helpers for telling kernel packages apart from the rest of an
+// IndexReport.
+//
+// Images that carry kernels (bootable container images, ostree commits, disk
+// image root filesystems) commonly have more than one installed. Reporting
+// every one of them is the default, but a caller can use [ActiveOnly] to
+// restrict a report to the kernel that will actually run.
+package kernel
+
+import (
+	"strings"
+
+	"github.com/quay/claircore"
+)
+
+// Rpm kernel packages come in a handful of flavors, each split into a number
+// of subpackages that all share the same version-release.
+var (
+	rpmFlavors = []string{
+		"kernel",
+		"kernel-64k",
+		"kernel-debug",
+		"kernel-rt",
+		"kernel-rt-debug",
+		"kernel-uek",
+	}
+	rpmSubpackages = []string{
+		"",
+		"-core",
+		"-modules",
+		"-modules-core",
+		"-modules-extra",
+	}
+)
+
+// dpkgPrefix is the prefix used by Debian and Ubuntu kernel image packages.
+const dpkgPrefix = `linux-image-`
+
+// IsKernel reports whether the package is a kernel image package.
+func IsKernel(p *claircore.Package) bool {
+	return Release(p) != ""
+}
+
+// Release returns the kernel release (what "uname -r" would report) of the
+// provided package, or the empty string if the package is not a kernel image
+// package.
+func Release(p *claircore.Package) string {
+	if p == nil || (p.Kind != "" && p.Kind != claircore.BINARY) {
+		return ""
+	}
+	if strings.HasPrefix(p.Name, dpkgPrefix) {
+		// Debian-style packages encode the release in the name. Meta
+		// packages like "linux-image-amd64" don't start with a digit.
+		r := strings.TrimPrefix(p.Name, dpkgPrefix)
+		r = strings.TrimPrefix(r, "unsigned-")
+		if r == "" || r[0] < '0' || r[0] > '9' {
+			return ""
+		}
+		return r
+	}
+	if !isRPMKernel(p.Name) {
+		return ""
+	}
+	v := p.Version
+	if i := strings.IndexByte(v, ':'); i != -1 {
+		v = v[i+1:]
+	}
+	if v == "" {
+		return ""
+	}
+	if p.Arch == "" {
+		return v
+	}
+	return v + "." + p.Arch
+}
+
+func isRPMKernel(n string) bool {
+	for _, f := range rpmFlavors {
+		if !strings.HasPrefix(n, f) {
+			continue
+		}
+		for _, s := range rpmSubpackages {
+			if n == f+s {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ActiveOnly returns an IndexReport with every kernel package other than the
+// one named by the report's ActiveKernel removed.
+//
+// If no active kernel was detected, the report is returned unmodified. The
+// passed report is never modified; a shallow copy is returned if any packages
+// need to be removed.
+func ActiveOnly(ir *claircore.IndexReport) *claircore.IndexReport {
+	if ir == nil || ir.ActiveKernel == "" {
+		return ir
+	}
+	var drop []string
+	for id, p := range ir.Packages {
+		if r := Release(p); r != "" && r != ir.ActiveKernel {
+			drop = append(drop, id)
+		}
+	}
+	if len(drop) == 0 {
+		return ir
+	}
+	out := *ir
+	out.Packages = make(map[string]*claircore.Package, len(ir.Packages))
+	for id, p := range ir.Packages {
+		out.Packages[id] = p
+	}
+	out.Environments = make(map[string][]*claircore.Environment, len(ir.Environments))
+	for id, e := range ir.Environments {
+		out.Environments[id] = e
+	}
+	for _, id := range drop {
+		delete(out.Packages, id)
+		delete(out.Environments, id)
+	}
+	return &out
+}
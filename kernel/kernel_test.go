@@ -0,0 +1,85 @@
+package kernel
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+
+	"github.com/quay/claircore"
+)
+
+func TestRelease(t *testing.T) {
+	tt := []struct {
+		pkg  claircore.Package
+		want string
+	}{
+		{
+			pkg:  claircore.Package{Name: "kernel-core", Version: "5.14.0-284.11.1.el9_2", Arch: "x86_64", Kind: claircore.BINARY},
+			want: "5.14.0-284.11.1.el9_2.x86_64",
+		},
+		{
+			pkg:  claircore.Package{Name: "kernel-rt", Version: "1:4.18.0-477.10.1.rt7.274.el8_8", Arch: "x86_64", Kind: claircore.BINARY},
+			want: "4.18.0-477.10.1.rt7.274.el8_8.x86_64",
+		},
+		{
+			pkg:  claircore.Package{Name: "linux-image-5.10.0-21-amd64", Version: "5.10.162-1", Arch: "amd64", Kind: claircore.BINARY},
+			want: "5.10.0-21-amd64",
+		},
+		{
+			pkg: claircore.Package{Name: "linux-image-amd64", Version: "5.10.162-1", Arch: "amd64", Kind: claircore.BINARY},
+		},
+		{
+			pkg: claircore.Package{Name: "kernel-headers", Version: "5.14.0-284.11.1.el9_2", Arch: "x86_64", Kind: claircore.BINARY},
+		},
+		{
+			pkg: claircore.Package{Name: "kernel", Version: "5.14.0-284.11.1.el9_2", Kind: claircore.SOURCE},
+		},
+	}
+	for _, tc := range tt {
+		if got := Release(&tc.pkg); got != tc.want {
+			t.Errorf("%s: got: %q, want: %q", tc.pkg.Name, got, tc.want)
+		}
+	}
+}
+
+func TestActiveOnly(t *testing.T) {
+	env := []*claircore.Environment{{}}
+	ir := &claircore.IndexReport{
+		Packages: map[string]*claircore.Package{
+			"1": {ID: "1", Name: "kernel-core", Version: "5.14.0-70.13.1.el9_0", Arch: "x86_64"},
+			"2": {ID: "2", Name: "kernel-core", Version: "5.14.0-284.11.1.el9_2", Arch: "x86_64"},
+			"3": {ID: "3", Name: "bash", Version: "5.1.8-6.el9_1", Arch: "x86_64"},
+		},
+		Environments: map[string][]*claircore.Environment{
+			"1": env,
+			"2": env,
+			"3": env,
+		},
+	}
+
+	t.Run("Unset", func(t *testing.T) {
+		if got := ActiveOnly(ir); got != ir {
+			t.Error("expected report to be returned unmodified")
+		}
+	})
+	t.Run("Set", func(t *testing.T) {
+		in := *ir
+		in.ActiveKernel = "5.14.0-284.11.1.el9_2.x86_64"
+		got := ActiveOnly(&in)
+		want := []string{"2", "3"}
+		var ids []string
+		for id := range got.Packages {
+			ids = append(ids, id)
+		}
+		if !cmp.Equal(ids, want, cmpopts.SortSlices(func(a, b string) bool { return a < b })) {
+			t.Error(cmp.Diff(ids, want))
+		}
+		if _, ok := got.Environments["1"]; ok {
+			t.Error("expected environment to be removed")
+		}
+		if len(in.Packages) != 3 {
+			t.Error("input report modified")
+		}
+	})
+}
@@ -0,0 +1,249 @@
+package kernel
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+
+	"github.com/quay/zlog"
+
+	"github.com/quay/claircore"
+	"github.com/quay/claircore/indexer"
+)
+
+const (
+	scannerName    = "kernel"
+	scannerVersion = "2"
+	scannerKind    = "file"
+)
+
+var (
+	_ indexer.FileScanner         = (*Scanner)(nil)
+	_ indexer.VersionedScanner    = (*Scanner)(nil)
+	_ indexer.ConfigurableScanner = (*Scanner)(nil)
+)
+
+// DefaultSymlinks are the paths examined for a symlink pointing at the
+// default kernel image.
+var DefaultSymlinks = []string{
+	"vmlinuz",
+	"boot/vmlinuz",
+}
+
+// DefaultGrubenv are the paths examined for a grub environment block with a
+// "saved_entry" naming the default boot entry.
+var DefaultGrubenv = []string{
+	"boot/grub2/grubenv",
+	"boot/grub/grubenv",
+}
+
+// ScannerConfig is the struct used to configure a Scanner.
+type ScannerConfig struct {
+	// Symlinks overrides DefaultSymlinks, if provided.
+	Symlinks []string `yaml:"symlinks" json:"symlinks"`
+	// Grubenv overrides DefaultGrubenv, if provided.
+	Grubenv []string `yaml:"grubenv" json:"grubenv"`
+}
+
+// Scanner looks for indications of which kernel a layer is configured to
+// boot. Symlinks are consulted before the grub environment.
+//
+// Found kernels are reported as a [claircore.File] of kind
+// [claircore.FileKindKernel] with a Path of "boot/vmlinuz-<release>".
+//
+// The zero value is ready to use.
+type Scanner struct {
+	symlinks []string
+	grubenv  []string
+}
+
+// Name implements indexer.VersionedScanner.
+func (*Scanner) Name() string { return scannerName }
+
+// Version implements indexer.VersionedScanner.
+func (*Scanner) Version() string { return scannerVersion }
+
+// Kind implements indexer.VersionedScanner.
+func (*Scanner) Kind() string { return scannerKind }
+
+// Configure implements indexer.ConfigurableScanner.
+func (s *Scanner) Configure(ctx context.Context, f indexer.ConfigDeserializer) error {
+	var cfg ScannerConfig
+	if err := f(&cfg); err != nil {
+		return err
+	}
+	s.symlinks = cfg.Symlinks
+	s.grubenv = cfg.Grubenv
+	return nil
+}
+
+// Scan implements indexer.FileScanner.
+func (s *Scanner) Scan(ctx context.Context, l *claircore.Layer) ([]claircore.File, error) {
+	ctx = zlog.ContextWithValues(ctx,
+		"component", "kernel/Scanner.Scan",
+		"version", s.Version(),
+		"layer", l.Hash.String())
+	zlog.Debug(ctx).Msg("start")
+	defer zlog.Debug(ctx).Msg("done")
+	sys, err := l.FS()
+	if err != nil {
+		return nil, fmt.Errorf("kernel: unable to create fs: %w", err)
+	}
+	defer sys.Close()
+
+	symlinks := DefaultSymlinks
+	if len(s.symlinks) != 0 {
+		symlinks = s.symlinks
+	}
+	for _, p := range symlinks {
+		r, err := fromSymlink(sys, p)
+		switch {
+		case errors.Is(err, nil):
+		case errors.Is(err, fs.ErrNotExist):
+			continue
+		default:
+			return nil, fmt.Errorf("kernel: unable to examine %q: %w", p, err)
+		}
+		if r == "" {
+			continue
+		}
+		zlog.Debug(ctx).
+			Str("path", p).
+			Str("release", r).
+			Msg("found kernel symlink")
+		return []claircore.File{file(r)}, nil
+	}
+
+	grubenv := DefaultGrubenv
+	if len(s.grubenv) != 0 {
+		grubenv = s.grubenv
+	}
+	for _, p := range grubenv {
+		r, err := fromGrubenv(sys, p)
+		switch {
+		case errors.Is(err, nil):
+		case errors.Is(err, fs.ErrNotExist):
+			continue
+		default:
+			return nil, fmt.Errorf("kernel: unable to examine %q: %w", p, err)
+		}
+		if r == "" {
+			continue
+		}
+		zlog.Debug(ctx).
+			Str("path", p).
+			Str("release", r).
+			Msg("found grub saved entry")
+		return []claircore.File{file(r)}, nil
+	}
+	return nil, nil
+}
+
+const imagePrefix = `vmlinuz-`
+
+func file(release string) claircore.File {
+	return claircore.File{
+		Path: "boot/" + imagePrefix + release,
+		Kind: claircore.FileKindKernel,
+	}
+}
+
+// FileRelease returns the kernel release recorded in a File reported by the
+// Scanner.
+func FileRelease(f claircore.File) string {
+	if f.Kind != claircore.FileKindKernel {
+		return ""
+	}
+	b := path.Base(f.Path)
+	if !strings.HasPrefix(b, imagePrefix) {
+		return ""
+	}
+	return strings.TrimPrefix(b, imagePrefix)
+}
+
+// ReadLinkFS is implemented by filesystems that can report the target of a
+// symlink, like the ones returned by claircore.Layer.FS.
+type readLinkFS interface {
+	fs.FS
+	ReadLink(name string) (string, error)
+}
+
+// FromSymlink reports the kernel release named by the symlink at "p", if any.
+//
+// If "sys" can't read symlinks, nothing is found.
+func fromSymlink(sys fs.FS, p string) (string, error) {
+	rl, ok := sys.(readLinkFS)
+	if !ok {
+		return "", nil
+	}
+	tgt, err := rl.ReadLink(p)
+	switch {
+	case errors.Is(err, nil):
+	case errors.Is(err, fs.ErrInvalid):
+		// Not a symlink.
+		return "", nil
+	default:
+		return "", err
+	}
+	b := path.Base(tgt)
+	if !strings.HasPrefix(b, imagePrefix) {
+		return "", nil
+	}
+	return strings.TrimPrefix(b, imagePrefix), nil
+}
+
+// FromGrubenv reports the kernel release named by the "saved_entry" key in the
+// grub environment block at "p", if any.
+func fromGrubenv(sys fs.FS, p string) (string, error) {
+	f, err := sys.Open(p)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		k, v, ok := strings.Cut(s.Text(), "=")
+		if !ok || k != "saved_entry" {
+			continue
+		}
+		return savedEntry(v), nil
+	}
+	return "", s.Err()
+}
+
+// SavedEntry extracts a kernel release from a grub "saved_entry" value.
+//
+// Two forms are understood: BootLoaderSpec entry IDs, which are the machine
+// ID and the kernel release joined with a hyphen, and legacy menu titles, which
+// have the kernel release in parentheses.
+func savedEntry(v string) string {
+	v = strings.TrimSpace(v)
+	if i := strings.IndexByte(v, '('); i != -1 {
+		if j := strings.IndexByte(v[i:], ')'); j != -1 {
+			return strings.TrimSpace(v[i+1 : i+j])
+		}
+	}
+	if len(v) > 33 && v[32] == '-' && isHex(v[:32]) {
+		return v[33:]
+	}
+	if v != "" && v[0] >= '0' && v[0] <= '9' {
+		return v
+	}
+	return ""
+}
+
+func isHex(s string) bool {
+	for _, r := range s {
+		switch {
+		case '0' <= r && r <= '9':
+		case 'a' <= r && r <= 'f':
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,113 @@
+package kernel
+
+import (
+	"context"
+	"io/fs"
+	"testing"
+	"testing/fstest"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/quay/zlog"
+
+	"github.com/quay/claircore"
+	"github.com/quay/claircore/test"
+)
+
+func TestScanner(t *testing.T) {
+	ctx := zlog.Test(context.Background(), t)
+	type entry struct {
+		Name, Link, Content string
+	}
+	tt := []struct {
+		name    string
+		entries []entry
+		want    []claircore.File
+	}{
+		{
+			name: "Empty",
+		},
+		{
+			name: "Symlink",
+			entries: []entry{
+				{Name: "boot/vmlinuz-5.14.0-284.11.1.el9_2.x86_64", Content: "\x00"},
+				{Name: "vmlinuz", Link: "boot/vmlinuz-5.14.0-284.11.1.el9_2.x86_64"},
+			},
+			want: []claircore.File{
+				{Path: "boot/vmlinuz-5.14.0-284.11.1.el9_2.x86_64", Kind: claircore.FileKindKernel},
+			},
+		},
+		{
+			name: "SymlinkAbsolute",
+			entries: []entry{
+				{Name: "boot/vmlinuz-5.10.0-21-amd64", Content: "\x00"},
+				{Name: "boot/vmlinuz", Link: "/boot/vmlinuz-5.10.0-21-amd64"},
+			},
+			want: []claircore.File{
+				{Path: "boot/vmlinuz-5.10.0-21-amd64", Kind: claircore.FileKindKernel},
+			},
+		},
+		{
+			name: "Grubenv",
+			entries: []entry{
+				{
+					Name:    "boot/grub2/grubenv",
+					Content: "# GRUB Environment Block\nsaved_entry=0e9a0d4f5c3b4e0a8e1b2c3d4e5f6a7b-4.18.0-477.10.1.el8_8.x86_64\nkernelopts=root=/dev/vda1\n",
+				},
+			},
+			want: []claircore.File{
+				{Path: "boot/vmlinuz-4.18.0-477.10.1.el8_8.x86_64", Kind: claircore.FileKindKernel},
+			},
+		},
+		{
+			name: "GrubenvUnknown",
+			entries: []entry{
+				{Name: "boot/grub2/grubenv", Content: "saved_entry=Windows\n"},
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := zlog.Test(ctx, t)
+			sys := fstest.MapFS{}
+			for _, e := range tc.entries {
+				f := &fstest.MapFile{Data: []byte(e.Content), Mode: 0o644}
+				if e.Link != "" {
+					f.Data = []byte(e.Link)
+					f.Mode = fs.ModeSymlink | 0o777
+				}
+				sys[e.Name] = f
+			}
+			tl, sl := test.SquashfsLayers(t, sys)
+
+			for n, l := range map[string]*claircore.Layer{"tar": tl, "squashfs": sl} {
+				var s Scanner
+				got, err := s.Scan(ctx, l)
+				if err != nil {
+					t.Fatalf("%s: %v", n, err)
+				}
+				opt := cmp.AllowUnexported(claircore.Digest{})
+				if !cmp.Equal(got, tc.want, opt) {
+					t.Errorf("%s: %s", n, cmp.Diff(got, tc.want, opt))
+				}
+			}
+		})
+	}
+}
+
+func TestSavedEntry(t *testing.T) {
+	tt := []struct {
+		in, want string
+	}{
+		{"0e9a0d4f5c3b4e0a8e1b2c3d4e5f6a7b-5.14.0-284.11.1.el9_2.x86_64", "5.14.0-284.11.1.el9_2.x86_64"},
+		{"Red Hat Enterprise Linux Server (3.10.0-1160.el7.x86_64) 7.9 (Maipo)", "3.10.0-1160.el7.x86_64"},
+		{"4.18.0-477.10.1.el8_8.x86_64", "4.18.0-477.10.1.el8_8.x86_64"},
+		{"Windows", ""},
+		{"", ""},
+	}
+	for _, tc := range tt {
+		if got := savedEntry(tc.in); got != tc.want {
+			t.Errorf("%q: got: %q, want: %q", tc.in, got, tc.want)
+		}
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"os"
 	"path/filepath"
 
+	"github.com/quay/claircore/pkg/squashfs"
 	"github.com/quay/claircore/pkg/tarfs"
 )
 
@@ -23,6 +24,10 @@ type Layer struct {
 
 	// path to local file containing uncompressed tar archive of the layer's content
 	localPath string
+	// buffer size for files opened via LayerFS, if not the default
+	bufSize int
+	// filesystem holding the layer's content, used instead of localPath
+	sys fs.FS
 }
 
 func (l *Layer) SetLocal(f string) error {
@@ -30,14 +35,120 @@ func (l *Layer) SetLocal(f string) error {
 	return nil
 }
 
+// SetBufferSize sets the size of the buffer used for reading files from the
+// fs.FS returned by LayerFS for this Layer's Reader.
+//
+// Zero selects tarfs.DefaultBufferSize. A negative size disables buffering.
+func (l *Layer) SetBufferSize(n int) {
+	l.bufSize = n
+}
+
+// SetFS makes the layer's contents the provided filesystem, instead of a
+// fetched tar or squashfs image. This allows scanning contents that aren't
+// in an archive at all, like an unpacked directory (via os.DirFS) or a
+// network filesystem.
+//
+// A Layer with an FS set has no Reader, and is never fetched. Its Hash must
+// still uniquely identify the contents.
+func (l *Layer) SetFS(sys fs.FS) {
+	l.sys = sys
+}
+
+// HasFS reports whether the layer's contents were set with SetFS.
+func (l *Layer) HasFS() bool {
+	return l.sys != nil
+}
+
+// Fetched reports whether the layer's contents are available locally.
 func (l *Layer) Fetched() bool {
+	if l.sys != nil {
+		return true
+	}
 	_, err := os.Stat(l.localPath)
 	return err == nil
 }
 
+// FSCloser is an fs.FS that must be closed once it's no longer needed.
+type FSCloser interface {
+	fs.FS
+	io.Closer
+}
+
+// FS returns a filesystem over the layer's contents. This is the filesystem
+// set with SetFS, or the result of LayerFS over the layer's Reader.
+//
+// The caller must close the returned FSCloser.
+func (l *Layer) FS() (FSCloser, error) {
+	if l.sys != nil {
+		return &layerFS{FS: l.sys}, nil
+	}
+	r, err := l.Reader()
+	if err != nil {
+		return nil, err
+	}
+	sys, err := LayerFS(r)
+	if err != nil {
+		r.Close()
+		return nil, err
+	}
+	return &layerFS{FS: sys, c: r}, nil
+}
+
+// LayerFS adds a Close method to an fs.FS, while keeping the optional
+// interfaces the fs package knows about.
+type layerFS struct {
+	fs.FS
+	c io.Closer
+}
+
+var (
+	_ fs.ReadDirFS  = (*layerFS)(nil)
+	_ fs.ReadFileFS = (*layerFS)(nil)
+	_ fs.StatFS     = (*layerFS)(nil)
+	_ fs.GlobFS     = (*layerFS)(nil)
+	_ fs.SubFS      = (*layerFS)(nil)
+)
+
+// Close implements io.Closer.
+func (f *layerFS) Close() error {
+	if f.c == nil {
+		return nil
+	}
+	return f.c.Close()
+}
+
+// ReadDir implements fs.ReadDirFS.
+func (f *layerFS) ReadDir(name string) ([]fs.DirEntry, error) { return fs.ReadDir(f.FS, name) }
+
+// ReadFile implements fs.ReadFileFS.
+func (f *layerFS) ReadFile(name string) ([]byte, error) { return fs.ReadFile(f.FS, name) }
+
+// Stat implements fs.StatFS.
+func (f *layerFS) Stat(name string) (fs.FileInfo, error) { return fs.Stat(f.FS, name) }
+
+// Glob implements fs.GlobFS.
+func (f *layerFS) Glob(pattern string) ([]string, error) { return fs.Glob(f.FS, pattern) }
+
+// Sub implements fs.SubFS.
+func (f *layerFS) Sub(dir string) (fs.FS, error) { return fs.Sub(f.FS, dir) }
+
+// ReadLink returns the target of the symlink "name". The error wraps
+// fs.ErrInvalid if "name" isn't a symlink, or the underlying fs.FS has no
+// ReadLink method. Both built-in layer formats have one.
+func (f *layerFS) ReadLink(name string) (string, error) {
+	rl, ok := f.FS.(interface {
+		ReadLink(string) (string, error)
+	})
+	if !ok {
+		return "", &fs.PathError{Op: "readlink", Path: name, Err: fs.ErrInvalid}
+	}
+	return rl.ReadLink(name)
+}
+
 // Reader returns a ReadAtCloser of the layer.
 //
-// It should also implement io.Seeker, and should be a tar stream.
+// It should also implement io.Seeker, and should be a tar stream or a squashfs
+// image. See LayerFS.
 func (l *Layer) Reader() (ReadAtCloser, error) {
 	if l.localPath == "" {
 		return nil, fmt.Errorf("claircore: Layer not fetched")
@@ -46,9 +157,48 @@ func (l *Layer) Reader() (ReadAtCloser, error) {
 	if err != nil {
 		return nil, fmt.Errorf("claircore: unable to open tar: %w", err)
 	}
+	if l.bufSize != 0 {
+		return &layerFile{File: f, bufSize: l.bufSize}, nil
+	}
 	return f, nil
 }
 
+// LayerFile is the Reader for a Layer with a buffer size set.
+type layerFile struct {
+	*os.File
+	bufSize int
+}
+
+// BufferSize reports the buffer size LayerFS should use.
+func (f *layerFile) BufferSize() int { return f.bufSize }
+
+// LayerFS returns an fs.FS over the layer contents in "r", usually returned by
+// Layer.Reader. Layers may be tar archives or squashfs images; the format is
+// detected by magic number.
+//
+// The reader must remain valid for the entire life of the returned FS.
+//
+// Files in a tar are read through a buffer; see Layer.SetBufferSize.
+func LayerFS(r io.ReaderAt) (fs.FS, error) {
+	sz := tarfs.DefaultBufferSize
+	if b, ok := r.(interface{ BufferSize() int }); ok {
+		sz = b.BufferSize()
+	}
+	if !squashfs.Sniff(r) {
+		return tarfs.NewSize(r, sz)
+	}
+	sys, err := squashfs.New(r)
+	if err == nil {
+		return sys, nil
+	}
+	// A tar whose first member's name starts with the magic number is
+	// unlikely, but possible.
+	if tsys, terr := tarfs.NewSize(r, sz); terr == nil {
+		return tsys, nil
+	}
+	return nil, err
+}
+
 // ReadAtCloser is an io.ReadCloser and also an io.ReaderAt
 type ReadAtCloser interface {
 	io.ReadCloser
@@ -81,17 +231,13 @@ var ErrNotFound = errors.New("claircore: unable to find any requested files")
 // "etc/os-release" will all result in any found content being stored with the
 // key "etc/os-release".
 //
-// Deprecated: Callers should instead use `pkg/tarfs` and the `io/fs` package.
+// Deprecated: Callers should instead use FS and the `io/fs` package.
 func (l *Layer) Files(paths ...string) (map[string]*bytes.Buffer, error) {
-	r, err := l.Reader()
-	if err != nil {
-		return nil, err
-	}
-	defer r.Close()
-	sys, err := tarfs.New(r.(*os.File))
+	sys, err := l.FS()
 	if err != nil {
 		return nil, err
 	}
+	defer sys.Close()
 
 	// Clean the input paths.
 	want := make(map[string]struct{})
@@ -0,0 +1,48 @@
+package claircore
+
+import (
+	"errors"
+	"io/fs"
+	"testing"
+	"testing/fstest"
+)
+
+func TestLayerSetFS(t *testing.T) {
+	var l Layer
+	l.SetFS(fstest.MapFS{
+		"etc/os-release": &fstest.MapFile{Data: []byte("ID=test\n"), Mode: 0o644},
+	})
+	if !l.HasFS() {
+		t.Error("expected HasFS to report true")
+	}
+	if !l.Fetched() {
+		t.Error("expected Fetched to report true")
+	}
+	if _, err := l.Reader(); err == nil {
+		t.Error("expected Reader to fail")
+	}
+
+	sys, err := l.FS()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sys.Close()
+	b, err := fs.ReadFile(sys, "etc/os-release")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(b), "ID=test\n"; got != want {
+		t.Errorf("got: %q, want: %q", got, want)
+	}
+	if _, err := fs.Stat(sys, "etc/shadow"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	fm, err := l.Files("/etc/os-release")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := fm["etc/os-release"].String(), "ID=test\n"; got != want {
+		t.Errorf("got: %q, want: %q", got, want)
+	}
+}
@@ -0,0 +1,33 @@
+package libc
+
+import (
+	"context"
+
+	"github.com/quay/claircore"
+	"github.com/quay/claircore/indexer"
+)
+
+type coalescer struct{}
+
+// Coalesce implements [indexer.Coalescer].
+//
+// Every library is its own package database, so the packages found in each
+// layer are reported as-is.
+func (c *coalescer) Coalesce(ctx context.Context, ls []*indexer.LayerArtifacts) (*claircore.IndexReport, error) {
+	ir := &claircore.IndexReport{
+		Environments: map[string][]*claircore.Environment{},
+		Packages:     map[string]*claircore.Package{},
+	}
+	for _, l := range ls {
+		for _, pkg := range l.Pkgs {
+			ir.Packages[pkg.ID] = pkg
+			ir.Environments[pkg.ID] = []*claircore.Environment{
+				{
+					PackageDB:    pkg.PackageDB,
+					IntroducedIn: l.Hash,
+				},
+			}
+		}
+	}
+	return ir, nil
+}
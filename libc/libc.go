@@ -0,0 +1,95 @@
+// Package libc implements detection of the C library in images without a
+// package manager, like distroless or scratch images holding a copied-in
+// root filesystem.
+//
+// The C library is found by the version banner compiled into it, and is
+// reported as a package with a CPE, so it can be matched by CPE-based
+// matchers like the one in the nvd package.
+package libc
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+
+	"github.com/quay/claircore/pkg/cpe"
+)
+
+// Flavor describes how to recognize one C library.
+type flavor struct {
+	// Name is the reported package name.
+	Name string
+	// CPE is the CPE format string, with a verb for the version.
+	CPE string
+	// Paths are fs.Glob patterns for the library's files.
+	Paths []string
+	// Version returns the version found in the contents of a file, or the
+	// empty string if the file doesn't have a recognizable banner.
+	Version func([]byte) string
+}
+
+// Flavors are the C libraries the Scanner recognizes.
+var flavors = []flavor{
+	{
+		Name: "glibc",
+		CPE:  "cpe:2.3:a:gnu:glibc:%s:*:*:*:*:*:*:*",
+		Paths: []string{
+			"lib/libc.so.6",
+			"lib64/libc.so.6",
+			"lib/*/libc.so.6",
+			"usr/lib/libc.so.6",
+			"usr/lib64/libc.so.6",
+			"usr/lib/*/libc.so.6",
+		},
+		Version: glibcVersion,
+	},
+	{
+		Name: "musl",
+		CPE:  "cpe:2.3:a:musl-libc:musl:%s:*:*:*:*:*:*:*",
+		Paths: []string{
+			"lib/ld-musl-*.so.1",
+			"usr/lib/ld-musl-*.so.1",
+		},
+		Version: muslVersion,
+	},
+}
+
+// GlibcBanner matches the banner glibc prints when run as a program, like:
+//
+//	GNU C Library (Debian GLIBC 2.36-9+deb12u4) stable release version 2.36.
+var glibcBanner = regexp.MustCompile(`GNU C Library \([^)\x00\n]*\) [a-z ]*release version ([0-9]+\.[0-9]+(?:\.[0-9]+)?)`)
+
+// GlibcVersion returns the version from glibc's banner.
+func glibcVersion(b []byte) string {
+	m := glibcBanner.FindSubmatch(b)
+	if m == nil {
+		return ""
+	}
+	return string(m[1])
+}
+
+// MuslBanner is the start of the message musl's dynamic loader prints when
+// run as a program. The version is printed from a separate string.
+var muslBanner = []byte("musl libc (")
+
+// MuslVersionString matches a version as a C string on its own.
+var muslVersionString = regexp.MustCompile(`\x00(1\.[0-9]+\.[0-9]+)\x00`)
+
+// MuslVersion returns the version from musl's dynamic loader. The loader's
+// banner must be present, so other files that happen to contain a version-like
+// string aren't reported.
+func muslVersion(b []byte) string {
+	if !bytes.Contains(b, muslBanner) {
+		return ""
+	}
+	m := muslVersionString.FindSubmatch(b)
+	if m == nil {
+		return ""
+	}
+	return string(m[1])
+}
+
+// WFN returns the CPE for version "v" of the flavor.
+func (f *flavor) WFN(v string) (cpe.WFN, error) {
+	return cpe.Unbind(fmt.Sprintf(f.CPE, v))
+}
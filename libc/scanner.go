@@ -0,0 +1,179 @@
+package libc
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+
+	"github.com/quay/zlog"
+
+	"github.com/quay/claircore"
+	"github.com/quay/claircore/indexer"
+)
+
+const (
+	scannerName    = "libc"
+	scannerVersion = "1"
+	scannerKind    = "package"
+
+	// MaxSize is the largest file examined. C libraries are a few
+	// megabytes.
+	maxSize = 16 * 1024 * 1024
+)
+
+var (
+	_ indexer.PackageScanner     = (*Scanner)(nil)
+	_ indexer.VersionedScanner   = (*Scanner)(nil)
+	_ indexer.DistributionScoped = (*Scanner)(nil)
+	_ indexer.PathScoped         = (*Scanner)(nil)
+)
+
+// Scanner finds glibc and musl by the version banner compiled into them.
+//
+// To keep false positives low, only ELF files at the usual locations of the
+// libraries are examined, and a recognizable banner is required: for glibc,
+// the "GNU C Library ... release version" line, and for musl, the dynamic
+// loader's "musl libc" message. Statically linked programs don't carry either
+// banner, so only the libraries themselves are found.
+//
+// Found libraries are reported as packages with a CPE and a PackageDB of
+// "libc:" followed by the path of the library.
+//
+// The zero value is ready to use.
+type Scanner struct{}
+
+// Name implements indexer.VersionedScanner.
+func (*Scanner) Name() string { return scannerName }
+
+// Version implements indexer.VersionedScanner.
+func (*Scanner) Version() string { return scannerVersion }
+
+// Kind implements indexer.VersionedScanner.
+func (*Scanner) Kind() string { return scannerKind }
+
+// AppliesTo implements indexer.DistributionScoped.
+//
+// The Scanner only runs on images without a detected distribution. Otherwise,
+// the C library is reported by the distribution's package manager, with
+// versions that account for backported fixes.
+func (*Scanner) AppliesTo(d *claircore.Distribution) bool {
+	return d == nil
+}
+
+// Paths implements indexer.PathScoped.
+func (*Scanner) Paths() []string {
+	var ps []string
+	for _, f := range flavors {
+		ps = append(ps, f.Paths...)
+	}
+	return ps
+}
+
+// Scan implements indexer.PackageScanner.
+func (s *Scanner) Scan(ctx context.Context, l *claircore.Layer) ([]*claircore.Package, error) {
+	ctx = zlog.ContextWithValues(ctx,
+		"component", "libc/Scanner.Scan",
+		"version", s.Version(),
+		"layer", l.Hash.String())
+	zlog.Debug(ctx).Msg("start")
+	defer zlog.Debug(ctx).Msg("done")
+	sys, err := l.FS()
+	if err != nil {
+		return nil, fmt.Errorf("libc: unable to create fs: %w", err)
+	}
+	defer sys.Close()
+
+	var out []*claircore.Package
+	// Seen tracks found libraries by name and version, as the same file can
+	// be reachable through more than one path, like when "lib" is a symlink
+	// to "usr/lib".
+	seen := make(map[string]struct{})
+	for i := range flavors {
+		f := &flavors[i]
+		for _, pat := range f.Paths {
+			ms, err := fs.Glob(sys, pat)
+			if err != nil {
+				return nil, fmt.Errorf("libc: bad pattern %q: %w", pat, err)
+			}
+			for _, p := range ms {
+				if err := ctx.Err(); err != nil {
+					return nil, err
+				}
+				v, err := fileVersion(sys, p, f)
+				if err != nil {
+					return nil, fmt.Errorf("libc: unable to examine %q: %w", p, err)
+				}
+				if v == "" {
+					zlog.Debug(ctx).
+						Str("path", p).
+						Msg("no version banner")
+					continue
+				}
+				k := f.Name + "\x00" + v
+				if _, ok := seen[k]; ok {
+					continue
+				}
+				seen[k] = struct{}{}
+				wfn, err := f.WFN(v)
+				if err != nil {
+					zlog.Info(ctx).
+						Err(err).
+						Str("path", p).
+						Str("found", v).
+						Msg("unable to make CPE")
+					continue
+				}
+				zlog.Debug(ctx).
+					Str("path", p).
+					Str("name", f.Name).
+					Str("found", v).
+					Msg("found C library")
+				out = append(out, &claircore.Package{
+					Name:      f.Name,
+					Version:   v,
+					Kind:      claircore.BINARY,
+					PackageDB: "libc:" + p,
+					Filepath:  p,
+					CPE:       wfn,
+				})
+			}
+		}
+	}
+	return out, nil
+}
+
+// ElfMagic is the start of every ELF file.
+var elfMagic = []byte("\x7fELF")
+
+// FileVersion returns the version of the C library in the file at "p", or the
+// empty string if it's not an ELF file with a recognizable banner.
+func fileVersion(sys fs.FS, p string, fl *flavor) (string, error) {
+	f, err := sys.Open(p)
+	switch {
+	case errors.Is(err, nil):
+	case errors.Is(err, fs.ErrNotExist):
+		// Dangling symlink.
+		return "", nil
+	default:
+		return "", err
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return "", err
+	}
+	if !fi.Mode().IsRegular() || fi.Size() > maxSize {
+		return "", nil
+	}
+	b, err := io.ReadAll(f)
+	if err != nil {
+		return "", err
+	}
+	if !bytes.HasPrefix(b, elfMagic) {
+		return "", nil
+	}
+	return fl.Version(b), nil
+}
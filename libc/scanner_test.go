@@ -0,0 +1,114 @@
+package libc
+
+import (
+	"context"
+	"io/fs"
+	"testing"
+	"testing/fstest"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/quay/zlog"
+
+	"github.com/quay/claircore"
+	"github.com/quay/claircore/nvd"
+	"github.com/quay/claircore/pkg/cpe"
+	"github.com/quay/claircore/test"
+)
+
+// Elf returns the contents of a fake ELF file holding "s".
+func elf(s string) []byte {
+	return []byte("\x7fELF\x02\x01\x01\x00\x00\x00junk\x00" + s + "\x00more junk\x00")
+}
+
+func TestScan(t *testing.T) {
+	ctx := zlog.Test(context.Background(), t)
+	const (
+		glibc = "GNU C Library (Debian GLIBC 2.36-9+deb12u4) stable release version 2.36.\nCopyright (C) 2022 Free Software Foundation, Inc."
+		musl  = "musl libc (x86_64)\nVersion %s\nDynamic Program Loader\x00junk\x001.2.4"
+	)
+	tt := []struct {
+		Name string
+		FS   fstest.MapFS
+		Want []*claircore.Package
+	}{
+		{
+			Name: "Glibc",
+			FS: fstest.MapFS{
+				"usr/lib/x86_64-linux-gnu/libc.so.6": {Data: elf(glibc)},
+				// Merged usr: the library may be reachable through two paths,
+				// but must only be reported once.
+				"lib": {Data: []byte("usr/lib"), Mode: fs.ModeSymlink | 0o777},
+			},
+			Want: []*claircore.Package{{
+				Name:      "glibc",
+				Version:   "2.36",
+				Kind:      claircore.BINARY,
+				PackageDB: "libc:usr/lib/x86_64-linux-gnu/libc.so.6",
+				Filepath:  "usr/lib/x86_64-linux-gnu/libc.so.6",
+				CPE:       cpe.MustUnbind("cpe:2.3:a:gnu:glibc:2.36:*:*:*:*:*:*:*"),
+			}},
+		},
+		{
+			Name: "Musl",
+			FS: fstest.MapFS{
+				"lib/ld-musl-x86_64.so.1": {Data: elf(musl)},
+			},
+			Want: []*claircore.Package{{
+				Name:      "musl",
+				Version:   "1.2.4",
+				Kind:      claircore.BINARY,
+				PackageDB: "libc:lib/ld-musl-x86_64.so.1",
+				Filepath:  "lib/ld-musl-x86_64.so.1",
+				CPE:       cpe.MustUnbind("cpe:2.3:a:musl-libc:musl:1.2.4:*:*:*:*:*:*:*"),
+			}},
+		},
+		{
+			Name: "NoBanner",
+			FS: fstest.MapFS{
+				"lib/libc.so.6":           {Data: elf("GLIBC_2.34\x002.34")},
+				"lib/ld-musl-x86_64.so.1": {Data: elf("1.2.4")},
+			},
+		},
+		{
+			Name: "NotELF",
+			FS: fstest.MapFS{
+				"lib/libc.so.6": {Data: []byte(glibc)},
+			},
+		},
+		{
+			Name: "Elsewhere",
+			FS: fstest.MapFS{
+				"opt/app/libc.so.6": {Data: elf(glibc)},
+			},
+		},
+	}
+	var s Scanner
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			ctx := zlog.Test(ctx, t)
+			tl, _ := test.SquashfsLayers(t, tc.FS)
+			got, err := s.Scan(ctx, tl)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !cmp.Equal(got, tc.Want) {
+				t.Error(cmp.Diff(got, tc.Want))
+			}
+			for _, p := range got {
+				if !(&nvd.Matcher{}).Filter(&claircore.IndexRecord{Package: p}) {
+					t.Errorf("%s: not considered by the nvd matcher", p.Name)
+				}
+			}
+		})
+	}
+}
+
+func TestAppliesTo(t *testing.T) {
+	var s Scanner
+	if !s.AppliesTo(nil) {
+		t.Error("should apply to images without a distribution")
+	}
+	if s.AppliesTo(&claircore.Distribution{DID: "debian"}) {
+		t.Error("should not apply to images with a distribution")
+	}
+}
@@ -0,0 +1,89 @@
+package libindex
+
+import (
+	"archive/tar"
+	"bufio"
+	"errors"
+	"io"
+)
+
+// PipeBuffer is the size of the buffer between the goroutine decompressing a
+// layer and the tar reader. It bounds the memory each layer being realized
+// uses beyond what the decompressor needs.
+const pipeBuffer = 1 << 20 // 1 MiB
+
+// WriteLayer writes the decompressed layer read from "r" to "w", returning the
+// number of bytes written and whether the contents parsed as a tar.
+//
+// Decompression happens in its own goroutine, feeding a pipe with a bounded
+// buffer. The tar headers are parsed from the other end of the pipe as the
+// contents are written, so decompressing the layer, writing it, and checking
+// it overlap instead of taking turns on a single core.
+//
+// Contents that aren't a tar, like a squashfs image, are still written in
+// full; it's up to the caller to check them some other way.
+func writeLayer(w io.Writer, r io.Reader) (int64, bool, error) {
+	pr, pw := io.Pipe()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		bw := bufio.NewWriterSize(pw, pipeBuffer)
+		_, err := io.Copy(bw, r)
+		if err == nil {
+			err = bw.Flush()
+		}
+		// A nil error is reported as io.EOF to the reader.
+		pw.CloseWithError(err)
+	}()
+	defer func() {
+		// Unblock the decompressor if we're returning early.
+		pr.Close()
+		<-done
+	}()
+
+	cw := &errWriter{w: w}
+	// Reading in large chunks keeps the hand-offs across the pipe, which
+	// need the goroutines to meet, infrequent.
+	tee := io.TeeReader(bufio.NewReaderSize(pr, pipeBuffer), cw)
+	isTar := true
+	tr := tar.NewReader(tee)
+	for {
+		_, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			isTar = false
+			break
+		}
+	}
+	// Copy whatever the tar reader didn't consume: the padding after the end
+	// of the archive, or everything after the header that failed to parse.
+	_, err := io.Copy(io.Discard, tee)
+	switch {
+	case cw.err != nil:
+		return cw.n, false, cw.err
+	case err != nil:
+		return cw.n, false, err
+	}
+	return cw.n, isTar, nil
+}
+
+// ErrWriter counts the bytes written through it and remembers the first
+// error, so write failures can be told apart from read failures on the other
+// side of an io.TeeReader.
+type errWriter struct {
+	w   io.Writer
+	n   int64
+	err error
+}
+
+func (e *errWriter) Write(b []byte) (int, error) {
+	if e.err != nil {
+		return 0, e.err
+	}
+	n, err := e.w.Write(b)
+	e.n += int64(n)
+	e.err = err
+	return n, err
+}
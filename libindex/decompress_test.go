@@ -0,0 +1,170 @@
+package libindex
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/klauspost/compress/gzip"
+
+	"github.com/quay/claircore"
+)
+
+// MkTar returns a tar with "n" files of "sz" bytes each. The contents are
+// random, but drawn from a small alphabet so they compress reasonably.
+func mkTar(t testing.TB, n, sz int) []byte {
+	t.Helper()
+	rng := rand.New(rand.NewSource(0))
+	const alphabet = "abcdefghijklmnop\n"
+	data := make([]byte, sz)
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for i := 0; i < n; i++ {
+		for j := range data {
+			data[j] = alphabet[rng.Intn(len(alphabet))]
+		}
+		if err := tw.WriteHeader(&tar.Header{
+			Typeflag: tar.TypeReg,
+			Name:     "file" + strconv.Itoa(i),
+			Size:     int64(sz),
+			Mode:     0o644,
+		}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write(data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func mkGzip(t testing.TB, b []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(b); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestWriteLayer(t *testing.T) {
+	tb := mkTar(t, 4, 3*pipeBuffer/2)
+	t.Run("Tar", func(t *testing.T) {
+		zr, err := gzip.NewReader(bytes.NewReader(mkGzip(t, tb)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var out bytes.Buffer
+		n, isTar, err := writeLayer(&out, zr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !isTar {
+			t.Error("tar not recognized")
+		}
+		if got, want := n, int64(len(tb)); got != want {
+			t.Errorf("got: %d bytes, want: %d", got, want)
+		}
+		if !bytes.Equal(out.Bytes(), tb) {
+			t.Error("contents differ")
+		}
+	})
+	t.Run("NotTar", func(t *testing.T) {
+		in := bytes.Repeat([]byte("not a tar\n"), 1000)
+		var out bytes.Buffer
+		_, isTar, err := writeLayer(&out, bytes.NewReader(in))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if isTar {
+			t.Error("unexpected tar")
+		}
+		if !bytes.Equal(out.Bytes(), in) {
+			t.Error("contents differ")
+		}
+	})
+	t.Run("ReadError", func(t *testing.T) {
+		want := errors.New("read error")
+		r := io.MultiReader(bytes.NewReader(tb[:len(tb)/2]), &errReader{want})
+		_, _, err := writeLayer(io.Discard, r)
+		t.Log(err)
+		if !errors.Is(err, want) {
+			t.Errorf("got: %v, want: %v", err, want)
+		}
+	})
+	t.Run("WriteError", func(t *testing.T) {
+		want := errors.New("write error")
+		_, _, err := writeLayer(&errWriter{err: want}, bytes.NewReader(tb))
+		t.Log(err)
+		if !errors.Is(err, want) {
+			t.Errorf("got: %v, want: %v", err, want)
+		}
+	})
+}
+
+type errReader struct{ err error }
+
+func (e *errReader) Read([]byte) (int, error) { return 0, e.err }
+
+// BenchmarkRealize compares decompressing a large gzipped layer to disk and
+// then checking it, as was done before, with the pipelined writeLayer.
+func BenchmarkRealize(b *testing.B) {
+	tb := mkTar(b, 32, 4<<20)
+	gz := mkGzip(b, tb)
+	dir := b.TempDir()
+	run := func(b *testing.B, f func(*os.File, io.Reader) error) {
+		b.SetBytes(int64(len(tb)))
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			fd, err := os.Create(filepath.Join(dir, "layer"))
+			if err != nil {
+				b.Fatal(err)
+			}
+			zr, err := gzip.NewReader(bytes.NewReader(gz))
+			if err != nil {
+				b.Fatal(err)
+			}
+			if err := f(fd, zr); err != nil {
+				b.Fatal(err)
+			}
+			zr.Close()
+			fd.Close()
+		}
+	}
+	b.Run("Serial", func(b *testing.B) {
+		run(b, func(fd *os.File, r io.Reader) error {
+			buf := bufio.NewWriter(fd)
+			if _, err := io.Copy(buf, r); err != nil {
+				return err
+			}
+			if err := buf.Flush(); err != nil {
+				return err
+			}
+			_, err := claircore.LayerFS(fd)
+			return err
+		})
+	})
+	b.Run("Pipelined", func(b *testing.B) {
+		run(b, func(fd *os.File, r io.Reader) error {
+			buf := bufio.NewWriter(fd)
+			if _, _, err := writeLayer(buf, r); err != nil {
+				return err
+			}
+			return buf.Flush()
+		})
+	})
+}
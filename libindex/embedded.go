@@ -0,0 +1,223 @@
+package libindex
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/quay/zlog"
+
+	"github.com/quay/claircore"
+	"github.com/quay/claircore/indexer"
+	"github.com/quay/claircore/pkg/tarfs"
+)
+
+// IndexEmbedded looks for image tarballs in the layers of the manifest "m",
+// indexes them, and records them in "ir".
+//
+// The layers are realized again using the FetchArena "fa". The layers of the
+// embedded images are extracted to disk, and only kept around while the
+// images from a single layer are indexed.
+func (l *Libindex) indexEmbedded(ctx context.Context, fa indexer.FetchArena, m *claircore.Manifest, ir *claircore.IndexReport, depth int) error {
+	ctx = zlog.ContextWithValues(ctx,
+		"component", "libindex/Libindex.indexEmbedded",
+		"depth", strconv.Itoa(depth))
+	r := fa.Realizer(ctx)
+	defer r.Close()
+	if err := r.Realize(ctx, m.Layers); err != nil {
+		return fmt.Errorf("libindex: unable to realize layers: %w", err)
+	}
+	opts := *l.indexerOptions
+	opts.FetchArena = localArena{}
+	var found []claircore.EmbeddedManifest
+	for _, layer := range m.Layers {
+		ctx := zlog.ContextWithValues(ctx, "layer", layer.Hash.String())
+		ms, err := l.layerEmbedded(ctx, &opts, layer, depth)
+		if err != nil {
+			return err
+		}
+		found = append(found, ms...)
+	}
+	if len(found) == 0 {
+		return nil
+	}
+	ir.EmbeddedManifests = found
+	return l.store.SetIndexReport(ctx, ir)
+}
+
+// LayerEmbedded indexes the images found in "layer" using "opts".
+func (l *Libindex) layerEmbedded(ctx context.Context, opts *indexer.Options, layer *claircore.Layer, depth int) ([]claircore.EmbeddedManifest, error) {
+	dir, err := os.MkdirTemp("", "libindex.embedded.*")
+	if err != nil {
+		return nil, fmt.Errorf("libindex: unable to create directory: %w", err)
+	}
+	defer func() {
+		if err := os.RemoveAll(dir); err != nil {
+			zlog.Warn(ctx).Err(err).Msg("unable to remove extracted layers")
+		}
+	}()
+	imgs, err := findImages(ctx, layer, dir)
+	if err != nil {
+		return nil, err
+	}
+	var out []claircore.EmbeddedManifest
+	for _, img := range imgs {
+		for _, m := range img.Manifests {
+			ctx := zlog.ContextWithValues(ctx,
+				"path", img.Path,
+				"embedded_manifest", m.Hash.String())
+			ir, err := l.index(ctx, opts, m, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			if !ir.Success {
+				zlog.Warn(ctx).
+					Str("reason", ir.Err).
+					Msg("unable to index embedded image")
+				continue
+			}
+			zlog.Info(ctx).Msg("indexed embedded image")
+			out = append(out, claircore.EmbeddedManifest{
+				Manifest: m.Hash,
+				Layer:    layer.Hash,
+				Path:     img.Path,
+			})
+		}
+	}
+	return out, nil
+}
+
+// EmbeddedImage is an image tarball found in a layer.
+type embeddedImage struct {
+	Path      string
+	Manifests []*claircore.Manifest
+}
+
+// FindImages returns the images found as tarballs in "layer", in lexical order
+// of their paths.
+//
+// The tarballs and the images' layers are extracted into "dir".
+func findImages(ctx context.Context, layer *claircore.Layer, dir string) ([]embeddedImage, error) {
+	sys, err := layer.FS()
+	if err != nil {
+		return nil, err
+	}
+	defer sys.Close()
+	var out []embeddedImage
+	walk := func(p string, d fs.DirEntry, err error) error {
+		switch {
+		case err != nil:
+			return err
+		case ctx.Err() != nil:
+			return ctx.Err()
+		case !d.Type().IsRegular() || !strings.HasSuffix(p, ".tar"):
+			return nil
+		}
+		ctx := zlog.ContextWithValues(ctx, "path", p)
+		img, err := spoolTar(sys, p, dir)
+		switch {
+		case errors.Is(err, nil):
+		case errors.Is(err, errNotTar):
+			return nil
+		default:
+			return err
+		}
+		defer img.Close()
+		isys, err := tarfs.New(img)
+		if err != nil {
+			zlog.Debug(ctx).Err(err).Msg("unable to open tarball")
+			return nil
+		}
+		if !isImageTar(isys) {
+			return nil
+		}
+		ms, err := readImageTar(ctx, isys, dir)
+		if err != nil {
+			// Don't fail the whole layer on a malformed image.
+			zlog.Info(ctx).Err(err).Msg("unable to read embedded image")
+			return nil
+		}
+		out = append(out, embeddedImage{Path: p, Manifests: ms})
+		return nil
+	}
+	if err := fs.WalkDir(sys, ".", walk); err != nil {
+		return nil, fmt.Errorf("libindex: unable to search layer: %w", err)
+	}
+	return out, nil
+}
+
+// ErrNotTar is returned by spoolTar if the file isn't a tarball.
+var errNotTar = errors.New("not a tarball")
+
+// SpoolTar copies the tarball at "p" into a file in "dir", which is removed
+// on close.
+func spoolTar(sys fs.FS, p, dir string) (*os.File, error) {
+	const (
+		blockSz  = 512
+		magicOff = 257
+	)
+	f, err := sys.Open(p)
+	if err != nil {
+		return nil, fmt.Errorf("libindex: unable to open %q: %w", p, err)
+	}
+	defer f.Close()
+	hdr := make([]byte, blockSz)
+	if _, err := io.ReadFull(f, hdr); err != nil {
+		return nil, errNotTar
+	}
+	if !bytes.HasPrefix(hdr[magicOff:], []byte("ustar")) {
+		return nil, errNotTar
+	}
+	spool, err := os.CreateTemp(dir, "image.*.tar")
+	if err != nil {
+		return nil, fmt.Errorf("libindex: unable to create spool: %w", err)
+	}
+	if err := os.Remove(spool.Name()); err != nil {
+		spool.Close()
+		return nil, fmt.Errorf("libindex: unable to create spool: %w", err)
+	}
+	if _, err := spool.Write(hdr); err != nil {
+		spool.Close()
+		return nil, fmt.Errorf("libindex: unable to spool %q: %w", p, err)
+	}
+	if _, err := io.Copy(spool, f); err != nil {
+		spool.Close()
+		return nil, fmt.Errorf("libindex: unable to spool %q: %w", p, err)
+	}
+	return spool, nil
+}
+
+var (
+	_ indexer.FetchArena = localArena{}
+	_ indexer.Realizer   = localRealizer{}
+)
+
+// LocalArena is a FetchArena for layers that are already on disk, like the
+// layers of embedded images.
+type localArena struct{}
+
+func (localArena) Realizer(context.Context) indexer.Realizer { return localRealizer{} }
+func (localArena) Close(context.Context) error               { return nil }
+
+type localRealizer struct{}
+
+// Realize checks that the layers are already on disk.
+//
+// The layers aren't verified against their digests: the files on disk are
+// already decompressed, while the digests are of the blobs they came from.
+func (localRealizer) Realize(_ context.Context, ls []*claircore.Layer) error {
+	for _, l := range ls {
+		if !l.Fetched() {
+			return fmt.Errorf("libindex: layer %s not on disk", l.Hash)
+		}
+	}
+	return nil
+}
+
+func (localRealizer) Close() error { return nil }
@@ -0,0 +1,203 @@
+package libindex
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/klauspost/compress/gzip"
+	"github.com/quay/zlog"
+
+	"github.com/quay/claircore"
+	"github.com/quay/claircore/pkg/tarfs"
+)
+
+type tarEntry struct {
+	Name string
+	Data []byte
+}
+
+func writeTar(t testing.TB, es ...tarEntry) []byte {
+	var b bytes.Buffer
+	tw := tar.NewWriter(&b)
+	for _, e := range es {
+		if err := tw.WriteHeader(&tar.Header{
+			Typeflag: tar.TypeReg,
+			Name:     e.Name,
+			Mode:     0o644,
+			Size:     int64(len(e.Data)),
+		}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write(e.Data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return b.Bytes()
+}
+
+func sha256Hex(b []byte) string {
+	s := sha256.Sum256(b)
+	return hex.EncodeToString(s[:])
+}
+
+func sha512Hex(b []byte) string {
+	s := sha512.Sum512(b)
+	return hex.EncodeToString(s[:])
+}
+
+func TestFindImages(t *testing.T) {
+	ctx := zlog.Test(context.Background(), t)
+	innerLayer := writeTar(t, tarEntry{Name: "etc/os-release", Data: []byte("ID=embedded\n")})
+
+	// A "docker save" archive, with an uncompressed layer.
+	config := []byte(`{"architecture":"amd64"}`)
+	docker := writeTar(t,
+		tarEntry{Name: "manifest.json", Data: []byte(
+			`[{"Config":"config.json","RepoTags":["example:latest"],"Layers":["abc/layer.tar"]}]`)},
+		tarEntry{Name: "config.json", Data: config},
+		tarEntry{Name: "abc/layer.tar", Data: innerLayer},
+	)
+
+	// An OCI layout, with a compressed layer.
+	var gz bytes.Buffer
+	z := gzip.NewWriter(&gz)
+	z.Write(innerLayer)
+	z.Close()
+	layerSum := sha256Hex(gz.Bytes())
+	ociConfig := []byte(`{"architecture":"amd64","config":{"User":"nobody"}}`)
+	ociConfigSum := sha256Hex(ociConfig)
+	manifest := []byte(fmt.Sprintf(`{"schemaVersion":2,`+
+		`"config":{"mediaType":"application/vnd.oci.image.config.v1+json","digest":"sha256:%s"},"layers":[`+
+		`{"mediaType":"application/vnd.oci.image.layer.v1.tar+gzip","digest":"sha256:%s"}]}`, ociConfigSum, layerSum))
+	manifestSum := sha256Hex(manifest)
+	oci := writeTar(t,
+		tarEntry{Name: "oci-layout", Data: []byte(`{"imageLayoutVersion":"1.0.0"}`)},
+		tarEntry{Name: "index.json", Data: []byte(fmt.Sprintf(`{"schemaVersion":2,"manifests":[`+
+			`{"mediaType":"application/vnd.oci.image.manifest.v1+json","digest":"sha256:%s"},`+
+			`{"mediaType":"application/vnd.oci.image.index.v1+json","digest":"sha256:%s"}]}`, manifestSum, manifestSum))},
+		tarEntry{Name: "blobs/sha256/" + manifestSum, Data: manifest},
+		tarEntry{Name: "blobs/sha256/" + ociConfigSum, Data: ociConfig},
+		tarEntry{Name: "blobs/sha256/" + layerSum, Data: gz.Bytes()},
+	)
+
+	// The same OCI layout, but using sha512 digests.
+	layerSum512 := sha512Hex(gz.Bytes())
+	manifest512 := []byte(fmt.Sprintf(`{"schemaVersion":2,"layers":[`+
+		`{"mediaType":"application/vnd.oci.image.layer.v1.tar+gzip","digest":"sha512:%s"}]}`, layerSum512))
+	manifestSum512 := sha512Hex(manifest512)
+	oci512 := writeTar(t,
+		tarEntry{Name: "oci-layout", Data: []byte(`{"imageLayoutVersion":"1.0.0"}`)},
+		tarEntry{Name: "index.json", Data: []byte(fmt.Sprintf(`{"schemaVersion":2,"manifests":[`+
+			`{"mediaType":"application/vnd.oci.image.manifest.v1+json","digest":"sha512:%s"}]}`, manifestSum512))},
+		tarEntry{Name: "blobs/sha512/" + manifestSum512, Data: manifest512},
+		tarEntry{Name: "blobs/sha512/" + layerSum512, Data: gz.Bytes()},
+	)
+
+	tmp := t.TempDir()
+	host := filepath.Join(tmp, "host.tar")
+	if err := os.WriteFile(host, writeTar(t,
+		tarEntry{Name: "opt/images/docker.tar", Data: docker},
+		tarEntry{Name: "opt/images/oci.tar", Data: oci},
+		tarEntry{Name: "opt/images/oci512.tar", Data: oci512},
+		tarEntry{Name: "opt/images/plain.tar", Data: innerLayer},
+		tarEntry{Name: "opt/images/short.tar", Data: []byte("not a tarball")},
+	), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	var l claircore.Layer
+	l.SetLocal(host)
+
+	dir := filepath.Join(tmp, "extract")
+	if err := os.Mkdir(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	imgs, err := findImages(ctx, &l, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type result struct {
+		Path     string
+		Manifest string
+		Config   string
+		Layers   []string
+	}
+	var got []result
+	for _, img := range imgs {
+		for _, m := range img.Manifests {
+			r := result{Path: img.Path, Manifest: m.Hash.String(), Config: string(m.Config)}
+			for _, l := range m.Layers {
+				r.Layers = append(r.Layers, l.Hash.String())
+				// Make sure the layer was decompressed.
+				rc, err := l.Reader()
+				if err != nil {
+					t.Fatal(err)
+				}
+				sys, err := tarfs.New(rc)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if _, err := fs.Stat(sys, "etc/os-release"); err != nil {
+					t.Error(err)
+				}
+				rc.Close()
+			}
+			got = append(got, r)
+		}
+	}
+	want := []result{
+		{
+			Path:     "opt/images/docker.tar",
+			Manifest: "sha256:" + sha256Hex(config),
+			Config:   string(config),
+			Layers:   []string{"sha256:" + sha256Hex(innerLayer)},
+		},
+		{
+			Path:     "opt/images/oci.tar",
+			Manifest: "sha256:" + manifestSum,
+			Config:   string(ociConfig),
+			Layers:   []string{"sha256:" + layerSum},
+		},
+		{
+			Path:     "opt/images/oci512.tar",
+			Manifest: "sha512:" + manifestSum512,
+			Layers:   []string{"sha512:" + layerSum512},
+		},
+	}
+	if !cmp.Equal(got, want) {
+		t.Error(cmp.Diff(got, want))
+	}
+}
+
+func TestLocalRealizer(t *testing.T) {
+	ctx := zlog.Test(context.Background(), t)
+	f := filepath.Join(t.TempDir(), "layer.tar")
+	if err := os.WriteFile(f, writeTar(t), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	var ok, missing claircore.Layer
+	ok.SetLocal(f)
+	missing.SetLocal(filepath.Join(t.TempDir(), "missing.tar"))
+
+	r := localArena{}.Realizer(ctx)
+	defer r.Close()
+	if err := r.Realize(ctx, []*claircore.Layer{&ok}); err != nil {
+		t.Error(err)
+	}
+	if err := r.Realize(ctx, []*claircore.Layer{&ok, &missing}); err == nil {
+		t.Error("expected error for layer not on disk")
+	}
+}
@@ -4,9 +4,9 @@ import (
 	"bufio"
 	"bytes"
 	"context"
-	"encoding/hex"
 	"errors"
 	"fmt"
+	"hash"
 	"io"
 	"net/http"
 	"net/url"
@@ -15,14 +15,14 @@ import (
 	"strings"
 	"sync"
 
-	"github.com/klauspost/compress/gzip"
-	"github.com/klauspost/compress/zstd"
 	"github.com/quay/claircore/indexer"
 	"github.com/quay/zlog"
 	"golang.org/x/sync/errgroup"
 	"golang.org/x/sync/singleflight"
 
 	"github.com/quay/claircore"
+	"github.com/quay/claircore/pkg/decompress"
+	"github.com/quay/claircore/pkg/squashfs"
 	"github.com/quay/claircore/pkg/tarfs"
 )
 
@@ -37,14 +37,41 @@ var (
 // Exported for use in cctool. If cctool goes away, this can get unexported. It is
 // remote in the sense that it pulls layers from the internet.
 type RemoteFetchArena struct {
-	wc *http.Client
-	sf *singleflight.Group
+	wc  *http.Client
+	sf  *singleflight.Group
+	lim *fetchLimiter
+	kc  Keychain
 
 	mu sync.Mutex
 	// Rc is a map of digest to refcount.
 	rc map[string]int
 
 	root string
+	// Noverify disables checking layers against their digests.
+	noverify bool
+}
+
+// ErrDigestMismatch is reported (via errors.Is) when a fetched layer's
+// contents don't match the layer's declared digest.
+var ErrDigestMismatch = errors.New("libindex: layer digest mismatch")
+
+// DigestMismatchError is the error returned when a fetched layer's contents
+// don't match the layer's declared digest.
+type DigestMismatchError struct {
+	// Want is the layer's declared digest.
+	Want claircore.Digest
+	// Got is the digest of the fetched contents.
+	Got claircore.Digest
+}
+
+// Error implements error.
+func (e *DigestMismatchError) Error() string {
+	return fmt.Sprintf("libindex: layer digest mismatch: got %v, want %v", e.Got, e.Want)
+}
+
+// Is allows the error to be matched by ErrDigestMismatch.
+func (e *DigestMismatchError) Is(target error) bool {
+	return target == ErrDigestMismatch
 }
 
 // NewRemoteFetchArena initializes the RemoteFetchArena.
@@ -52,12 +79,24 @@ type RemoteFetchArena struct {
 // This method is provided instead of a constructor function to make embedding
 // easier.
 func NewRemoteFetchArena(wc *http.Client, root string) *RemoteFetchArena {
-	return &RemoteFetchArena{
+	return NewRemoteFetchArenaWithOptions(wc, root, nil)
+}
+
+// NewRemoteFetchArenaWithOptions is like NewRemoteFetchArena, but additionally
+// configures how requests are made. See FetchOptions.
+func NewRemoteFetchArenaWithOptions(wc *http.Client, root string, opts *FetchOptions) *RemoteFetchArena {
+	a := &RemoteFetchArena{
 		wc:   wc,
 		root: root,
 		sf:   &singleflight.Group{},
 		rc:   make(map[string]int),
+		lim:  newFetchLimiter(opts),
+	}
+	if opts != nil {
+		a.kc = opts.Keychain
+		a.noverify = opts.SkipVerification
 	}
+	return a
 }
 
 func (a *RemoteFetchArena) forget(digest string) error {
@@ -79,14 +118,14 @@ func (a *RemoteFetchArena) forget(digest string) error {
 
 // FetchOne does a deduplicated fetch, then increments the refcount and renames
 // the file to the permanent place if applicable.
-func (a *RemoteFetchArena) fetchOne(ctx context.Context, l *claircore.Layer) (do func() error) {
+func (a *RemoteFetchArena) fetchOne(ctx context.Context, l *claircore.Layer, s *authSession) (do func() error) {
 	do = func() error {
 		h := l.Hash.String()
 		tgt := filepath.Join(a.root, h)
 		var ff string
 		select {
 		case res := <-a.sf.DoChan(h, func() (interface{}, error) {
-			return a.realizeLayer(ctx, l)
+			return a.realizeLayer(ctx, l, s)
 		}):
 			if err := res.Err; err != nil {
 				return fmt.Errorf("error realizing layer %s: %w", h, err)
@@ -154,8 +193,10 @@ func (a *RemoteFetchArena) Close(ctx context.Context) error {
 
 // RealizeLayer is the inner function used inside the singleflight.
 //
-// The returned value is a temporary filename in the arena.
-func (a *RemoteFetchArena) realizeLayer(ctx context.Context, l *claircore.Layer) (string, error) {
+// The returned value is a temporary filename in the arena. If the registry
+// challenges the request and the layer doesn't carry its own "Authorization"
+// header, the session is used to authorize it.
+func (a *RemoteFetchArena) realizeLayer(ctx context.Context, l *claircore.Layer, s *authSession) (string, error) {
 	ctx = zlog.ContextWithValues(ctx,
 		"component", "libindex/fetchArena.realizeLayer",
 		"arena", a.root,
@@ -174,8 +215,6 @@ func (a *RemoteFetchArena) realizeLayer(ctx context.Context, l *claircore.Layer)
 	if l.Hash.Checksum() == nil {
 		return "", fmt.Errorf("digest is empty")
 	}
-	vh := l.Hash.Hash()
-	want := l.Hash.Checksum()
 
 	// Open our target file before hitting the network.
 	rm := true
@@ -205,10 +244,28 @@ func (a *RemoteFetchArena) realizeLayer(ctx context.Context, l *claircore.Layer)
 		Header:     l.Headers,
 	}
 	req = req.WithContext(ctx)
-	resp, err := a.wc.Do(req)
+	auth := s != nil && http.Header(l.Headers).Get("Authorization") == ""
+	if auth {
+		if v := s.Cached(url); v != "" {
+			req = withAuthorization(req, v)
+		}
+	}
+	resp, err := a.lim.Do(ctx, a.wc, req)
 	if err != nil {
 		return "", fmt.Errorf("fetcher: request failed: %w", err)
 	}
+	if auth && resp.StatusCode == http.StatusUnauthorized {
+		ch := resp.Header.Get("WWW-Authenticate")
+		resp.Body.Close()
+		v, err := s.Authorize(ctx, url, ch)
+		if err != nil {
+			return "", fmt.Errorf("fetcher: unable to authorize: %w", err)
+		}
+		resp, err = a.lim.Do(ctx, a.wc, withAuthorization(req, v))
+		if err != nil {
+			return "", fmt.Errorf("fetcher: request failed: %w", err)
+		}
+	}
 	defer resp.Body.Close()
 	switch resp.StatusCode {
 	case http.StatusOK:
@@ -223,69 +280,57 @@ func (a *RemoteFetchArena) realizeLayer(ctx context.Context, l *claircore.Layer)
 		}
 		return "", fmt.Errorf("fetcher: unexpected status code: %s", resp.Status)
 	}
-	tr := io.TeeReader(resp.Body, vh)
+	var vh hash.Hash
+	var body io.Reader = resp.Body
+	if !a.noverify {
+		vh = l.Hash.Hash()
+		body = io.TeeReader(resp.Body, vh)
+	}
 
-	br := bufio.NewReader(tr)
+	br := bufio.NewReader(body)
 	// Look at the content-type and optionally fix it up.
 	ct := resp.Header.Get("content-type")
 	zlog.Debug(ctx).
 		Str("content-type", ct).
 		Msg("reported content-type")
-	if ct == "" || ct == "text/plain" || ct == "binary/octet-stream" || ct == "application/octet-stream" {
+	var d *decompress.Decompressor
+	switch {
+	case ct == "" || ct == "text/plain" || ct == "binary/octet-stream" || ct == "application/octet-stream":
 		zlog.Debug(ctx).
 			Str("content-type", ct).
 			Msg("guessing compression")
-		b, err := br.Peek(4)
-		if err != nil {
+		b, err := br.Peek(decompress.MagicLen())
+		if err != nil && !errors.Is(err, io.EOF) {
 			return "", err
 		}
-		switch detectCompression(b) {
-		case cmpGzip:
-			ct = "application/gzip"
-		case cmpZstd:
-			ct = "application/zstd"
-		case cmpNone:
-			ct = "application/x-tar"
+		d = decompress.Detect(b)
+		format := "tar"
+		if d != nil {
+			format = d.Name
 		}
 		zlog.Debug(ctx).
-			Str("format", ct).
+			Str("format", format).
 			Msg("guessed compression")
-	}
-
-	var r io.Reader
-	switch {
-	case ct == "application/vnd.docker.image.rootfs.diff.tar.gzip":
-		// Catch the old docker media type.
-		fallthrough
-	case ct == "application/gzip" || ct == "application/x-gzip":
-		// GHCR reports gzipped layers as the latter.
-		fallthrough
-	case strings.HasSuffix(ct, ".tar+gzip"):
-		g, err := gzip.NewReader(br)
+	case ct == "application/x-tar" || strings.HasSuffix(ct, ".tar"):
+	default:
+		d, err = decompress.ForMediaType(ct)
 		if err != nil {
-			return "", err
+			return "", fmt.Errorf("fetcher: unknown content-type: %w", err)
 		}
-		defer g.Close()
-		r = g
-	case ct == "application/zstd":
-		fallthrough
-	case strings.HasSuffix(ct, ".tar+zstd"):
-		s, err := zstd.NewReader(br)
+	}
+
+	var r io.Reader = br
+	if d != nil {
+		dr, err := d.NewReader(br)
 		if err != nil {
 			return "", err
 		}
-		defer s.Close()
-		r = s
-	case ct == "application/x-tar":
-		fallthrough
-	case strings.HasSuffix(ct, ".tar"):
-		r = br
-	default:
-		return "", fmt.Errorf("fetcher: unknown content-type %q", ct)
+		defer dr.Close()
+		r = dr
 	}
 
 	buf := bufio.NewWriter(fd)
-	n, err := io.Copy(buf, r)
+	n, isTar, err := writeLayer(buf, r)
 	zlog.Debug(ctx).Int64("size", n).Msg("wrote file")
 	if err != nil {
 		return "", err
@@ -293,22 +338,31 @@ func (a *RemoteFetchArena) realizeLayer(ctx context.Context, l *claircore.Layer)
 	if err := buf.Flush(); err != nil {
 		return "", err
 	}
-	if got := vh.Sum(nil); !bytes.Equal(got, want) {
-		err := fmt.Errorf("fetcher: validation failed: got %q, expected %q",
-			hex.EncodeToString(got),
-			hex.EncodeToString(want))
-		return "", err
+	if vh != nil {
+		// Make sure the digest covers the whole blob, whatever the
+		// decompressor left unread.
+		if _, err := io.Copy(io.Discard, br); err != nil {
+			return "", err
+		}
+		if err := verifyDigest(l.Hash, vh.Sum(nil)); err != nil {
+			return "", err
+		}
+	} else {
+		zlog.Debug(ctx).Msg("skipping layer verification")
 	}
 
-	zlog.Debug(ctx).
-		Msg("checking if layer is a valid tar")
-	// TODO(hank) Need media types somewhere in here.
-	switch _, err := tarfs.New(fd); {
-	case errors.Is(err, nil):
-	case errors.Is(err, tarfs.ErrFormat):
-		fallthrough
-	default:
-		return "", err
+	// A tar was already checked as it was written.
+	if !isTar {
+		zlog.Debug(ctx).
+			Msg("checking if layer is a valid tar or squashfs image")
+		// TODO(hank) Need media types somewhere in here.
+		switch _, err := claircore.LayerFS(fd); {
+		case errors.Is(err, nil):
+		case errors.Is(err, tarfs.ErrFormat), errors.Is(err, squashfs.ErrFormat):
+			fallthrough
+		default:
+			return "", err
+		}
 	}
 
 	zlog.Debug(ctx).Msg("layer fetch ok")
@@ -316,9 +370,36 @@ func (a *RemoteFetchArena) realizeLayer(ctx context.Context, l *claircore.Layer)
 	return name, nil
 }
 
+// VerifyDigest returns a *DigestMismatchError if "sum" isn't the checksum of
+// "want".
+func verifyDigest(want claircore.Digest, sum []byte) error {
+	if bytes.Equal(sum, want.Checksum()) {
+		return nil
+	}
+	got, err := claircore.NewDigest(want.Algorithm(), sum)
+	if err != nil {
+		return err
+	}
+	return &DigestMismatchError{Want: want, Got: got}
+}
+
+// WithAuthorization returns a copy of the request with the "Authorization"
+// header set to "v".
+func withAuthorization(req *http.Request, v string) *http.Request {
+	r := req.Clone(req.Context())
+	if r.Header == nil {
+		r.Header = make(http.Header)
+	}
+	r.Header.Set("Authorization", v)
+	return r
+}
+
 // Fetcher returns an indexer.Fetcher.
+//
+// Registry credentials and tokens are cached for the lifetime of the returned
+// Realizer.
 func (a *RemoteFetchArena) Realizer(_ context.Context) indexer.Realizer {
-	return &FetchProxy{a: a}
+	return &FetchProxy{a: a, auth: newAuthSession(a.wc, a.kc)}
 }
 
 // FetchProxy tracks the files fetched for layers.
@@ -326,16 +407,21 @@ func (a *RemoteFetchArena) Realizer(_ context.Context) indexer.Realizer {
 // This can be unexported if FetchArena gets unexported.
 type FetchProxy struct {
 	a     *RemoteFetchArena
+	auth  *authSession
 	clean []string
 }
 
 // Realize populates all the layers locally.
 func (p *FetchProxy) Realize(ctx context.Context, ls []*claircore.Layer) error {
 	g, ctx := errgroup.WithContext(ctx)
-	p.clean = make([]string, len(ls))
-	for i, l := range ls {
-		p.clean[i] = l.Hash.String()
-		g.Go(p.a.fetchOne(ctx, l))
+	p.clean = make([]string, 0, len(ls))
+	for _, l := range ls {
+		// Layers with their contents provided directly have nothing to fetch.
+		if l.HasFS() {
+			continue
+		}
+		p.clean = append(p.clean, l.Hash.String())
+		g.Go(p.a.fetchOne(ctx, l, p.auth))
 	}
 	if err := g.Wait(); err != nil {
 		return fmt.Errorf("encountered error while fetching a layer: %w", err)
@@ -363,28 +449,3 @@ func (p *FetchProxy) Close() error {
 	}
 	return nil
 }
-
-type compression int
-
-const (
-	cmpGzip compression = iota
-	cmpZstd
-	cmpNone
-)
-
-var cmpHeaders = [...][]byte{
-	{0x1F, 0x8B, 0x08},       // cmpGzip
-	{0x28, 0xB5, 0x2F, 0xFD}, // cmpZstd
-}
-
-func detectCompression(b []byte) compression {
-	for c, h := range cmpHeaders {
-		if len(b) < len(h) {
-			continue
-		}
-		if bytes.Equal(h, b[:len(h)]) {
-			return compression(c)
-		}
-	}
-	return cmpNone
-}
@@ -4,6 +4,7 @@ import (
 	"archive/tar"
 	"context"
 	"crypto/sha256"
+	"errors"
 	"fmt"
 	"io"
 	"math/rand"
@@ -12,7 +13,9 @@ import (
 	"os"
 	"path/filepath"
 	"strconv"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/quay/zlog"
 
@@ -158,6 +161,138 @@ func TestFetchConcurrent(t *testing.T) {
 	}
 }
 
+func TestFetchThrottled(t *testing.T) {
+	ctx, done := context.WithCancel(context.Background())
+	defer done()
+	ctx = zlog.Test(ctx, t)
+	ls, h := commonLayerServer(t, 2)
+	var calls, throttled int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Throttle every other request.
+		if atomic.AddInt32(&calls, 1)%2 == 1 {
+			atomic.AddInt32(&throttled, 1)
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		h.ServeHTTP(w, r)
+	}))
+	defer srv.Close()
+	ps := make([]*claircore.Layer, len(ls))
+	for i := range ls {
+		ls[i].URI = srv.URL + ls[i].URI
+		ps[i] = &ls[i]
+	}
+
+	a := NewRemoteFetchArenaWithOptions(srv.Client(), t.TempDir(), &FetchOptions{
+		RateLimit: 100,
+		RateBurst: 1,
+	})
+	f := a.Realizer(ctx)
+	if err := f.Realize(ctx, ps); err != nil {
+		t.Error(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Error(err)
+	}
+	if err := a.Close(ctx); err != nil {
+		t.Error(err)
+	}
+	if got, want := atomic.LoadInt32(&throttled), int32(len(ls)); got != want {
+		t.Errorf("throttled responses: got: %d, want: %d", got, want)
+	}
+
+	t.Run("NoRetry", func(t *testing.T) {
+		ctx := zlog.Test(ctx, t)
+		atomic.StoreInt32(&calls, 0)
+		a := NewRemoteFetchArenaWithOptions(srv.Client(), t.TempDir(), &FetchOptions{
+			MaxRetries: -1,
+		})
+		f := a.Realizer(ctx)
+		defer f.Close()
+		if err := f.Realize(ctx, ps[:1]); err == nil {
+			t.Error("expected error, got nil")
+		}
+	})
+}
+
+func TestFetchVerify(t *testing.T) {
+	ctx, done := context.WithCancel(context.Background())
+	defer done()
+	ctx = zlog.Test(ctx, t)
+	ls, h := commonLayerServer(t, 2)
+	srv := httptest.NewServer(h)
+	defer srv.Close()
+	// Serve the second layer's contents under the first layer's digest.
+	bad := ls[0]
+	bad.URI = srv.URL + ls[1].URI
+	good := ls[1]
+	good.URI = srv.URL + ls[1].URI
+
+	t.Run("Mismatch", func(t *testing.T) {
+		ctx := zlog.Test(ctx, t)
+		l := bad
+		a := NewRemoteFetchArena(srv.Client(), t.TempDir())
+		f := a.Realizer(ctx)
+		defer f.Close()
+		err := f.Realize(ctx, []*claircore.Layer{&l})
+		t.Log(err)
+		if !errors.Is(err, ErrDigestMismatch) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		var de *DigestMismatchError
+		if !errors.As(err, &de) {
+			t.Fatalf("unexpected error type: %T", err)
+		}
+		if got, want := de.Want.String(), ls[0].Hash.String(); got != want {
+			t.Errorf("want: got %q, want %q", got, want)
+		}
+		if got, want := de.Got.String(), ls[1].Hash.String(); got != want {
+			t.Errorf("got: got %q, want %q", got, want)
+		}
+	})
+	t.Run("Match", func(t *testing.T) {
+		ctx := zlog.Test(ctx, t)
+		l := good
+		a := NewRemoteFetchArena(srv.Client(), t.TempDir())
+		f := a.Realizer(ctx)
+		defer f.Close()
+		if err := f.Realize(ctx, []*claircore.Layer{&l}); err != nil {
+			t.Error(err)
+		}
+	})
+	t.Run("Skip", func(t *testing.T) {
+		ctx := zlog.Test(ctx, t)
+		l := bad
+		a := NewRemoteFetchArenaWithOptions(srv.Client(), t.TempDir(), &FetchOptions{
+			SkipVerification: true,
+		})
+		f := a.Realizer(ctx)
+		defer f.Close()
+		if err := f.Realize(ctx, []*claircore.Layer{&l}); err != nil {
+			t.Error(err)
+		}
+	})
+}
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	tt := []struct {
+		In   string
+		Want time.Duration
+	}{
+		{In: "", Want: 0},
+		{In: "bogus", Want: 0},
+		{In: "5", Want: 5 * time.Second},
+		{In: now.Add(time.Minute).Format(http.TimeFormat), Want: time.Minute},
+	}
+	for _, tc := range tt {
+		if got := retryAfter(tc.In, now); got != tc.Want {
+			t.Errorf("%q: got: %v, want: %v", tc.In, got, tc.Want)
+		}
+	}
+}
+
 func commonLayerServer(t testing.TB, ct int) ([]claircore.Layer, http.Handler) {
 	t.Helper()
 	dir := t.TempDir()
@@ -0,0 +1,151 @@
+package libindex
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/quay/zlog"
+	"golang.org/x/time/rate"
+)
+
+// DefaultFetchRetries is the number of times a throttled layer request is
+// retried if FetchOptions.MaxRetries is unset.
+const DefaultFetchRetries = 3
+
+// FetchOptions configures how a RemoteFetchArena makes requests.
+//
+// The zero value places no limit on requests and uses DefaultFetchRetries.
+type FetchOptions struct {
+	// RateLimit is the sustained number of layer requests per second allowed,
+	// shared across all concurrent fetches. If zero, requests are not
+	// limited.
+	RateLimit float64 `json:"rate_limit" yaml:"rate_limit"`
+	// RateBurst is the number of requests allowed to be made at once before
+	// RateLimit applies. If zero, 1 is used.
+	RateBurst int `json:"rate_burst" yaml:"rate_burst"`
+	// MaxRetries is the number of times a request answered with "429 Too
+	// Many Requests" is retried. If zero, DefaultFetchRetries is used. If
+	// negative, throttled requests are not retried.
+	MaxRetries int `json:"max_retries" yaml:"max_retries"`
+	// Keychain resolves credentials for registries that challenge layer
+	// requests. If nil, only anonymous bearer tokens are requested. See
+	// LoadDockerConfig for a Keychain using the docker client configuration.
+	Keychain Keychain `json:"-" yaml:"-"`
+	// SkipVerification disables checking fetched layers against their
+	// digests. Layers failing the check are reported with a
+	// DigestMismatchError.
+	SkipVerification bool `json:"skip_verification" yaml:"skip_verification"`
+}
+
+// FetchLimiter enforces the request rate for an arena.
+//
+// Besides the token bucket, a server-requested delay (from a "Retry-After"
+// header) pauses all requests until it elapses.
+type fetchLimiter struct {
+	lim     *rate.Limiter
+	retries int
+
+	mu    sync.Mutex
+	until time.Time
+}
+
+func newFetchLimiter(o *FetchOptions) *fetchLimiter {
+	l := fetchLimiter{
+		retries: DefaultFetchRetries,
+	}
+	if o == nil {
+		return &l
+	}
+	switch {
+	case o.MaxRetries < 0:
+		l.retries = 0
+	case o.MaxRetries > 0:
+		l.retries = o.MaxRetries
+	}
+	if o.RateLimit > 0 {
+		b := o.RateBurst
+		if b < 1 {
+			b = 1
+		}
+		l.lim = rate.NewLimiter(rate.Limit(o.RateLimit), b)
+	}
+	return &l
+}
+
+// Wait blocks until a request is allowed to be made.
+func (l *fetchLimiter) Wait(ctx context.Context) error {
+	l.mu.Lock()
+	d := time.Until(l.until)
+	l.mu.Unlock()
+	if d > 0 {
+		t := time.NewTimer(d)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+	if l.lim == nil {
+		return nil
+	}
+	return l.lim.Wait(ctx)
+}
+
+// Pause prevents requests from being made until "t".
+func (l *fetchLimiter) Pause(t time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if t.After(l.until) {
+		l.until = t
+	}
+}
+
+// Do makes the request with the client, respecting the rate limit and retrying
+// requests that are throttled by the server.
+//
+// The request must not have a body.
+func (l *fetchLimiter) Do(ctx context.Context, c *http.Client, req *http.Request) (*http.Response, error) {
+	for i := 0; ; i++ {
+		if err := l.Wait(ctx); err != nil {
+			return nil, err
+		}
+		res, err := c.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		if res.StatusCode != http.StatusTooManyRequests || i >= l.retries {
+			return res, nil
+		}
+		res.Body.Close()
+		now := time.Now()
+		d := retryAfter(res.Header.Get("Retry-After"), now)
+		if d <= 0 {
+			d = time.Duration(i+1) * time.Second
+		}
+		zlog.Info(ctx).
+			Dur("delay", d).
+			Int("attempt", i+1).
+			Msg("request throttled, waiting to retry")
+		l.Pause(now.Add(d))
+	}
+}
+
+// RetryAfter parses the value of a "Retry-After" header, which may be either a
+// number of seconds or an HTTP date. A non-positive duration is returned if
+// the value is missing or invalid.
+func retryAfter(v string, now time.Time) time.Duration {
+	if v == "" {
+		return 0
+	}
+	if s, err := strconv.Atoi(v); err == nil {
+		return time.Duration(s) * time.Second
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return t.Sub(now)
+	}
+	return 0
+}
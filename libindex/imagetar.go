@@ -0,0 +1,225 @@
+package libindex
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+
+	"github.com/quay/zlog"
+
+	"github.com/quay/claircore"
+	"github.com/quay/claircore/pkg/decompress"
+)
+
+// Image tarballs come in two flavors: the format written by "docker save",
+// which has a "manifest.json" at the root, and OCI image layouts, which have
+// an "oci-layout" file and an "index.json" at the root. Newer versions of
+// docker write both, in which case the OCI layout is used.
+
+const (
+	mediaTypeOCIManifest    = `application/vnd.oci.image.manifest.v1+json`
+	mediaTypeDockerManifest = `application/vnd.docker.distribution.manifest.v2+json`
+)
+
+// IsImageTar reports whether the tarball "sys" looks like a container image.
+func isImageTar(sys fs.FS) bool {
+	for _, n := range []string{"oci-layout", "manifest.json"} {
+		if _, err := fs.Stat(sys, n); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// ReadImageTar returns Manifests for the images in the tarball "sys".
+//
+// Layers are decompressed into files in "dir", which the returned Layers refer
+// to.
+func readImageTar(ctx context.Context, sys fs.FS, dir string) ([]*claircore.Manifest, error) {
+	if _, err := fs.Stat(sys, "oci-layout"); err == nil {
+		return readOCILayout(ctx, sys, dir)
+	}
+	return readDockerArchive(ctx, sys, dir)
+}
+
+// ReadOCILayout reads the image manifests listed in an OCI layout's index.
+//
+// Nested indexes aren't followed.
+func readOCILayout(ctx context.Context, sys fs.FS, dir string) ([]*claircore.Manifest, error) {
+	type descriptor struct {
+		MediaType string           `json:"mediaType"`
+		Digest    claircore.Digest `json:"digest"`
+	}
+	var idx struct {
+		Manifests []descriptor `json:"manifests"`
+	}
+	if err := readJSON(sys, "index.json", &idx); err != nil {
+		return nil, err
+	}
+	var out []*claircore.Manifest
+	for _, d := range idx.Manifests {
+		switch d.MediaType {
+		case mediaTypeOCIManifest, mediaTypeDockerManifest:
+		default:
+			zlog.Debug(ctx).
+				Str("media_type", d.MediaType).
+				Stringer("digest", d.Digest).
+				Msg("skipping non-manifest")
+			continue
+		}
+		var m struct {
+			Config descriptor   `json:"config"`
+			Layers []descriptor `json:"layers"`
+		}
+		if err := readJSON(sys, blobPath(d.Digest), &m); err != nil {
+			return nil, err
+		}
+		cm := claircore.Manifest{Hash: d.Digest}
+		// The config is required by the spec, but isn't needed for indexing,
+		// so tolerate layouts without one.
+		if len(m.Config.Digest.Checksum()) != 0 {
+			b, err := fs.ReadFile(sys, blobPath(m.Config.Digest))
+			if err != nil {
+				return nil, fmt.Errorf("libindex: unable to read image config: %w", err)
+			}
+			cm.Config = b
+		}
+		for _, ld := range m.Layers {
+			l, err := extractLayer(sys, blobPath(ld.Digest), dir, ld.Digest.Algorithm())
+			if err != nil {
+				return nil, err
+			}
+			if l.Hash.String() != ld.Digest.String() {
+				return nil, &DigestMismatchError{Want: ld.Digest, Got: l.Hash}
+			}
+			cm.Layers = append(cm.Layers, l)
+		}
+		out = append(out, &cm)
+	}
+	return out, nil
+}
+
+// ReadDockerArchive reads the images listed in a "docker save" archive's
+// manifest.
+//
+// These archives don't have image manifests, so the digest of the image's
+// config (the image ID) is used as the Manifest hash.
+func readDockerArchive(ctx context.Context, sys fs.FS, dir string) ([]*claircore.Manifest, error) {
+	var ms []struct {
+		Config string   `json:"Config"`
+		Layers []string `json:"Layers"`
+	}
+	if err := readJSON(sys, "manifest.json", &ms); err != nil {
+		return nil, err
+	}
+	var out []*claircore.Manifest
+	for _, m := range ms {
+		b, err := fs.ReadFile(sys, m.Config)
+		if err != nil {
+			return nil, fmt.Errorf("libindex: unable to read image config: %w", err)
+		}
+		sum := sha256.Sum256(b)
+		h, err := claircore.NewDigest(claircore.SHA256, sum[:])
+		if err != nil {
+			return nil, err
+		}
+		cm := claircore.Manifest{Hash: h, Config: b}
+		for _, p := range m.Layers {
+			l, err := extractLayer(sys, p, dir, claircore.SHA256)
+			if err != nil {
+				return nil, err
+			}
+			cm.Layers = append(cm.Layers, l)
+		}
+		zlog.Debug(ctx).
+			Stringer("manifest", cm.Hash).
+			Int("layers", len(cm.Layers)).
+			Msg("found image")
+		out = append(out, &cm)
+	}
+	return out, nil
+}
+
+// ExtractLayer decompresses the layer at "p" into a file in "dir" and returns
+// a Layer for it. The Layer's hash is the digest of the blob as stored, using
+// the algorithm "algo".
+func extractLayer(sys fs.FS, p, dir, algo string) (*claircore.Layer, error) {
+	var h hash.Hash
+	switch algo {
+	case claircore.SHA256:
+		h = sha256.New()
+	case claircore.SHA512:
+		h = sha512.New()
+	default:
+		return nil, fmt.Errorf("libindex: unsupported digest algorithm for layer %q: %q", p, algo)
+	}
+	f, err := sys.Open(p)
+	if err != nil {
+		return nil, fmt.Errorf("libindex: unable to open layer: %w", err)
+	}
+	defer f.Close()
+	br := bufio.NewReader(io.TeeReader(f, h))
+	peek, err := br.Peek(decompress.MagicLen())
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("libindex: unable to read layer %q: %w", p, err)
+	}
+	var rd io.Reader = br
+	if d := decompress.Detect(peek); d != nil {
+		dr, err := d.NewReader(br)
+		if err != nil {
+			return nil, fmt.Errorf("libindex: unable to read layer %q: %w", p, err)
+		}
+		defer dr.Close()
+		rd = dr
+	}
+
+	out, err := os.CreateTemp(dir, "layer.*.tar")
+	if err != nil {
+		return nil, fmt.Errorf("libindex: unable to create layer file: %w", err)
+	}
+	defer out.Close()
+	if _, _, err := writeLayer(out, rd); err != nil {
+		return nil, fmt.Errorf("libindex: unable to extract layer %q: %w", p, err)
+	}
+	// Make sure the digest covers the whole blob, whatever the decompressor
+	// left unread.
+	if _, err := io.Copy(io.Discard, br); err != nil {
+		return nil, fmt.Errorf("libindex: unable to read layer %q: %w", p, err)
+	}
+	d, err := claircore.NewDigest(algo, h.Sum(nil))
+	if err != nil {
+		return nil, err
+	}
+	l := claircore.Layer{
+		Hash: d,
+		URI:  "file://" + out.Name(),
+	}
+	l.SetLocal(out.Name())
+	return &l, nil
+}
+
+// BlobPath returns the path of the blob "d" in an OCI layout.
+func blobPath(d claircore.Digest) string {
+	return path.Join("blobs", d.Algorithm(), hex.EncodeToString(d.Checksum()))
+}
+
+func readJSON(sys fs.FS, p string, v interface{}) error {
+	b, err := fs.ReadFile(sys, p)
+	if err != nil {
+		return fmt.Errorf("libindex: unable to read %q: %w", p, err)
+	}
+	if err := json.Unmarshal(b, v); err != nil {
+		return fmt.Errorf("libindex: unable to decode %q: %w", p, err)
+	}
+	return nil
+}
@@ -17,11 +17,16 @@ import (
 
 	"github.com/quay/claircore"
 	"github.com/quay/claircore/alpine"
+	"github.com/quay/claircore/configfile"
 	"github.com/quay/claircore/dpkg"
 	"github.com/quay/claircore/gobin"
 	"github.com/quay/claircore/indexer"
 	"github.com/quay/claircore/indexer/controller"
+	"github.com/quay/claircore/initramfs"
+	"github.com/quay/claircore/internal/ospkg"
 	"github.com/quay/claircore/java"
+	"github.com/quay/claircore/kernel"
+	"github.com/quay/claircore/libc"
 	"github.com/quay/claircore/pkg/omnimatcher"
 	"github.com/quay/claircore/python"
 	"github.com/quay/claircore/rhel"
@@ -33,6 +38,33 @@ import (
 
 const versionMagic = "libindex number: 2\n"
 
+// DefaultEcosystems are the constructors for the ecosystems used if
+// Options.Ecosystems isn't provided.
+var defaultEcosystems = []func(context.Context) *indexer.Ecosystem{
+	dpkg.NewEcosystem,
+	alpine.NewEcosystem,
+	rhel.NewEcosystem,
+	rpm.NewEcosystem,
+	python.NewEcosystem,
+	java.NewEcosystem,
+	rhcc.NewEcosystem,
+	gobin.NewEcosystem,
+	ruby.NewEcosystem,
+	kernel.NewEcosystem,
+	libc.NewEcosystem,
+	configfile.NewEcosystem,
+}
+
+// Register the default configuration, so it can be inspected with
+// indexer.RegisteredEcosystems and indexer.RegisteredScanners.
+func init() {
+	for _, f := range defaultEcosystems {
+		indexer.RegisterEcosystem(f)
+	}
+	// The whiteout ecosystem is always added.
+	indexer.RegisterEcosystem(whiteout.NewEcosystem)
+}
+
 // LockSource abstracts over how locks are implemented.
 //
 // An online system needs distributed locks, offline use cases can use
@@ -69,7 +101,8 @@ type Libindex struct {
 // New creates a new instance of libindex.
 //
 // The passed http.Client will be used for fetching layers and any HTTP requests
-// made by scanners.
+// made by scanners. See the httputil package for constructing a client that
+// uses an egress proxy or additional trusted certificates.
 func New(ctx context.Context, opts *Options, cl *http.Client) (*Libindex, error) {
 	ctx = zlog.ContextWithValues(ctx, "component", "libindex/New")
 	// required
@@ -94,16 +127,35 @@ func New(ctx context.Context, opts *Options, cl *http.Client) (*Libindex, error)
 		opts.ControllerFactory = controller.New
 	}
 	if opts.Ecosystems == nil {
-		opts.Ecosystems = []*indexer.Ecosystem{
-			dpkg.NewEcosystem(ctx),
-			alpine.NewEcosystem(ctx),
-			rhel.NewEcosystem(ctx),
-			rpm.NewEcosystem(ctx),
-			python.NewEcosystem(ctx),
-			java.NewEcosystem(ctx),
-			rhcc.NewEcosystem(ctx),
-			gobin.NewEcosystem(ctx),
-			ruby.NewEcosystem(ctx),
+		opts.Ecosystems = make([]*indexer.Ecosystem, len(defaultEcosystems))
+		for i, f := range defaultEcosystems {
+			opts.Ecosystems[i] = f(ctx)
+		}
+		if opts.ScanInitramfs {
+			opts.Ecosystems = append(opts.Ecosystems, initramfs.NewEcosystem(ctx))
+		}
+	}
+	es, err := indexer.FilterEcosystems(opts.Ecosystems, opts.EnabledEcosystems, opts.DisabledEcosystems)
+	if err != nil {
+		return nil, err
+	}
+	opts.Ecosystems = es
+	names := make([]string, len(opts.Ecosystems))
+	for i, e := range opts.Ecosystems {
+		names[i] = e.Name
+	}
+	zlog.Info(ctx).
+		Strs("ecosystems", names).
+		Msg("enabled ecosystems")
+	if !opts.AllowNoScanners {
+		// Checked before adding the whiteout ecosystem, as its scanner
+		// doesn't find any contents on its own.
+		ps, ds, rs, fs, err := indexer.EcosystemsToScanners(ctx, opts.Ecosystems)
+		if err != nil {
+			return nil, err
+		}
+		if len(ps)+len(ds)+len(rs)+len(fs) == 0 {
+			return nil, fmt.Errorf("libindex: %w: ecosystems %v provide no scanners", indexer.ErrNoScanners, names)
 		}
 	}
 	// Add whiteout objects
@@ -112,6 +164,13 @@ func New(ctx context.Context, opts *Options, cl *http.Client) (*Libindex, error)
 	opts.Resolvers = []indexer.Resolver{
 		&whiteout.Resolver{},
 	}
+	// Only the ownership resolver is handed to the indexer. The whiteout
+	// resolver above has never been run from here, and turning it on would
+	// change every report, so that's left for its own change.
+	var resolvers []indexer.Resolver
+	if opts.ReconcileOwnership {
+		resolvers = append(resolvers, &ospkg.Resolver{})
+	}
 
 	if cl == nil {
 		return nil, errors.New("invalid *http.Client")
@@ -148,17 +207,37 @@ func New(ctx context.Context, opts *Options, cl *http.Client) (*Libindex, error)
 
 	// create indexer.Options
 	l.indexerOptions = &indexer.Options{
-		Store:         l.store,
-		FetchArena:    l.fa,
-		Ecosystems:    opts.Ecosystems,
-		Vscnrs:        l.vscnrs,
-		Client:        l.client,
-		ScannerConfig: opts.ScannerConfig,
+		Store:                  l.store,
+		FetchArena:             l.fa,
+		Ecosystems:             opts.Ecosystems,
+		Vscnrs:                 l.vscnrs,
+		Client:                 l.client,
+		ScannerConfig:          opts.ScannerConfig,
+		Resolvers:              resolvers,
+		PackageFilter:          opts.PackageFilter,
+		ScannerLogLevels:       opts.ScannerLogLevels,
+		SkipCorruptLayers:      opts.SkipCorruptLayers,
+		Transformers:           opts.Transformers,
+		DistributionPreference: opts.DistributionPreference,
+		MultiDistribution:      opts.MultiDistribution,
+		ReadBufferSize:         opts.ReadBufferSize,
+		TwoPhaseScan:           opts.TwoPhaseScan,
+		StrictScannerCoverage:  opts.StrictScannerCoverage,
+		AllowNoScanners:        opts.AllowNoScanners,
+		StoreRetries:           opts.StoreRetries,
+		StoreRetryBackoff:      opts.StoreRetryBackoff,
+		MaxLayerPackages:       opts.MaxLayerPackages,
+		MaxLayerFiles:          opts.MaxLayerFiles,
 	}
 	l.indexerOptions.LayerScanner, err = indexer.NewLayerScanner(ctx, opts.LayerScanConcurrency, l.indexerOptions)
 	if err != nil {
 		return nil, err
 	}
+	if opts.SelfTest {
+		if err := l.indexerOptions.LayerScanner.SelfTest(ctx); err != nil {
+			return nil, fmt.Errorf("scanner self-test failed: %w", err)
+		}
+	}
 
 	return l, nil
 }
@@ -181,7 +260,30 @@ func (l *Libindex) Index(ctx context.Context, manifest *claircore.Manifest) (*cl
 		"manifest", manifest.Hash.String())
 	zlog.Info(ctx).Msg("index request start")
 	defer zlog.Info(ctx).Msg("index request done")
+	return l.index(ctx, l.indexerOptions, manifest, 0)
+}
+
+// Identify reports the distributions and repositories found in the layers of
+// the provided Manifest, without running any package or file scanners.
+//
+// This is a cheap way to classify an image before deciding whether to Index
+// it. The layers are fetched as for Index, but the results aren't stored and
+// no IndexReport is created.
+func (l *Libindex) Identify(ctx context.Context, manifest *claircore.Manifest) (*indexer.Identification, error) {
+	ctx = zlog.ContextWithValues(ctx,
+		"component", "libindex/Libindex.Identify",
+		"manifest", manifest.Hash.String())
+	r := l.fa.Realizer(ctx)
+	defer r.Close()
+	if err := r.Realize(ctx, manifest.Layers); err != nil {
+		return nil, fmt.Errorf("libindex: unable to realize layers: %w", err)
+	}
+	return l.indexerOptions.LayerScanner.Identify(ctx, manifest.Layers)
+}
 
+// Index indexes the manifest using "opts", then any images embedded in it if
+// "depth" is less than the configured EmbeddedImageDepth.
+func (l *Libindex) index(ctx context.Context, opts *indexer.Options, manifest *claircore.Manifest, depth int) (*claircore.IndexReport, error) {
 	zlog.Debug(ctx).Msg("locking attempt")
 	lc, done := l.locker.Lock(ctx, manifest.Hash.String())
 	defer done()
@@ -191,8 +293,28 @@ func (l *Libindex) Index(ctx context.Context, manifest *claircore.Manifest) (*cl
 		return nil, err
 	}
 	zlog.Debug(ctx).Msg("locking OK")
-	c := l.ControllerFactory(l.indexerOptions)
-	return c.Index(lc, manifest)
+	// Only look for embedded images when the manifest is actually indexed,
+	// rather than served from the store.
+	embed := depth < l.EmbeddedImageDepth
+	if embed {
+		ok, err := l.store.ManifestScanned(lc, manifest.Hash, l.vscnrs)
+		if err != nil {
+			return nil, err
+		}
+		embed = !ok
+	}
+	c := l.ControllerFactory(opts)
+	ir, err := c.Index(lc, manifest)
+	if err != nil || !embed || !ir.Success {
+		return ir, err
+	}
+	if err := l.indexEmbedded(lc, opts.FetchArena, manifest, ir, depth); err != nil {
+		// The manifest itself was indexed fine, so report that.
+		zlog.Warn(ctx).
+			Err(err).
+			Msg("unable to index embedded images")
+	}
+	return ir, nil
 }
 
 // State returns an opaque identifier identifying how the struct is currently
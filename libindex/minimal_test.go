@@ -0,0 +1,105 @@
+package libindex
+
+import (
+	"archive/tar"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/quay/zlog"
+
+	"github.com/quay/claircore"
+	"github.com/quay/claircore/indexer"
+)
+
+// BusyboxLayer writes a layer laid out like the busybox image: a single
+// static binary with applet symlinks, and no os-release file or package
+// database.
+func busyboxLayer(t *testing.T) *claircore.Layer {
+	t.Helper()
+	name := filepath.Join(t.TempDir(), "busybox.tar")
+	f, err := os.Create(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	tw := tar.NewWriter(f)
+	hdrs := []struct {
+		tar.Header
+		data string
+	}{
+		{Header: tar.Header{Typeflag: tar.TypeDir, Name: "bin/", Mode: 0o755}},
+		{Header: tar.Header{Typeflag: tar.TypeReg, Name: "bin/busybox", Mode: 0o755}, data: "\x7fELF\x02\x01\x01\x00"},
+		{Header: tar.Header{Typeflag: tar.TypeSymlink, Name: "bin/sh", Linkname: "busybox", Mode: 0o777}},
+		{Header: tar.Header{Typeflag: tar.TypeSymlink, Name: "bin/ls", Linkname: "busybox", Mode: 0o777}},
+		{Header: tar.Header{Typeflag: tar.TypeDir, Name: "etc/", Mode: 0o755}},
+		{Header: tar.Header{Typeflag: tar.TypeReg, Name: "etc/passwd", Mode: 0o644}, data: "root:x:0:0:root:/root:/bin/sh\n"},
+		{Header: tar.Header{Typeflag: tar.TypeReg, Name: "etc/group", Mode: 0o644}, data: "root:x:0:\n"},
+		{Header: tar.Header{Typeflag: tar.TypeDir, Name: "root/", Mode: 0o700}},
+		{Header: tar.Header{Typeflag: tar.TypeDir, Name: "tmp/", Mode: 0o1777}},
+		{Header: tar.Header{Typeflag: tar.TypeDir, Name: "usr/", Mode: 0o755}},
+		{Header: tar.Header{Typeflag: tar.TypeDir, Name: "usr/sbin/", Mode: 0o755}},
+		{Header: tar.Header{Typeflag: tar.TypeSymlink, Name: "usr/sbin/init", Linkname: "../../bin/busybox", Mode: 0o777}},
+	}
+	for _, h := range hdrs {
+		h.Size = int64(len(h.data))
+		if err := tw.WriteHeader(&h.Header); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(h.data)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	var l claircore.Layer
+	if err := l.SetLocal(name); err != nil {
+		t.Fatal(err)
+	}
+	return &l
+}
+
+// TestMinimalImage checks that every default scanner handles an image without
+// an os-release file or package database, finding nothing instead of failing.
+func TestMinimalImage(t *testing.T) {
+	ctx := zlog.Test(context.Background(), t)
+	l := busyboxLayer(t)
+	es := make([]*indexer.Ecosystem, len(defaultEcosystems))
+	for i, f := range defaultEcosystems {
+		es[i] = f(ctx)
+	}
+	ps, ds, rs, fs, err := indexer.EcosystemsToScanners(ctx, es)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range indexer.MergeVS(ps, ds, rs, fs) {
+		var n int
+		var err error
+		switch s := s.(type) {
+		case indexer.PackageScanner:
+			var r []*claircore.Package
+			r, err = s.Scan(ctx, l)
+			n = len(r)
+		case indexer.DistributionScanner:
+			var r []*claircore.Distribution
+			r, err = s.Scan(ctx, l)
+			n = len(r)
+		case indexer.RepositoryScanner:
+			var r []*claircore.Repository
+			r, err = s.Scan(ctx, l)
+			n = len(r)
+		case indexer.FileScanner:
+			var r []claircore.File
+			r, err = s.Scan(ctx, l)
+			n = len(r)
+		}
+		if err != nil {
+			t.Errorf("%s scanner %q: %v", s.Kind(), s.Name(), err)
+		}
+		if n != 0 {
+			t.Errorf("%s scanner %q: found %d results", s.Kind(), s.Name(), n)
+		}
+	}
+}
@@ -3,6 +3,9 @@ package libindex
 import (
 	"time"
 
+	"github.com/rs/zerolog"
+
+	"github.com/quay/claircore"
 	"github.com/quay/claircore/indexer"
 )
 
@@ -34,11 +37,147 @@ type Options struct {
 	// layers to be indexed repeatedly by changing the identifier in the
 	// manifest.
 	NoLayerValidation bool
+	// EmbeddedImageDepth controls indexing of container images found as
+	// tarballs inside of layers, in either the "docker save" or OCI layout
+	// formats. Embedded images are indexed as manifests of their own and
+	// listed in the IndexReport's EmbeddedManifests. This is how many levels
+	// of embedding to follow; zero, the default, disables it.
+	//
+	// Looking for embedded images requires fetching the layers of newly
+	// indexed manifests a second time.
+	EmbeddedImageDepth int
+	// ReconcileOwnership controls linking language packages to the OS
+	// packages owning their files, using the package databases in the final
+	// image. Linked packages are recorded in the IndexReport's
+	// CanonicalPackages, with the OS package as the canonical entry.
+	// Language Matchers that implement driver.OSOwnedFilter, like the python
+	// and java ones, then skip the linked packages, so their vulnerabilities
+	// aren't reported a second time.
+	//
+	// This requires reading the layers with package databases again after
+	// they're scanned.
+	ReconcileOwnership bool
+	// PackageFilter, if set, is called on every package a package scanner
+	// finds before it's persisted. Packages it returns false for are dropped,
+	// which can be used to ignore packages by path, name, or ecosystem, like
+	// examples under "/usr/share/doc" or test fixtures.
+	//
+	// Scan results are persisted per layer, so changing the filter doesn't
+	// affect layers that have already been scanned.
+	PackageFilter func(*claircore.Package) bool
+	// ScannerLogLevels sets the minimum level of messages logged by the named
+	// scanners, to quiet chatty scanners without lowering the verbosity of
+	// everything else.
+	//
+	// The levels are only enforced if the global logger used by the zlog
+	// package writes via indexer.LogFilter. Overrides can only make a scanner
+	// quieter: messages below the global logger's level are never written, so
+	// an override can't raise a scanner's verbosity above it.
+	ScannerLogLevels map[string]zerolog.Level
+	// SkipCorruptLayers controls what happens when a layer can't be read as a
+	// tar archive, such as when it was truncated while being fetched. By
+	// default, indexing fails with an error wrapping indexer.ErrCorruptLayer,
+	// which callers can use to decide to fetch the layer again. If set, the
+	// layer is skipped with a warning and listed in the IndexReport's Stats
+	// instead.
+	SkipCorruptLayers bool
+	// Transformers are run, in order, on the result of every scanner before
+	// it's persisted, so normalization like stripping debuginfo variants from
+	// package names can be done in one place instead of in every scanner. They
+	// run before PackageFilter. An error from a Transformer fails the index.
+	//
+	// Like PackageFilter, changing the Transformers doesn't affect layers
+	// that have already been scanned.
+	Transformers []indexer.ResultTransformer
+	// DistributionPreference controls which distribution packages are
+	// matched against when an image has more than one, like when a
+	// multi-stage build leaves behind a builder stage's os-release file. It's
+	// an ordered list of distribution IDs (the "ID" field of os-release, like
+	// "rhel" or "debian"), with earlier entries winning. If none of the found
+	// distributions are listed, the one found in the topmost layer is used.
+	//
+	// All the distributions are listed in the IndexReport, with the chosen
+	// one recorded as its PrimaryDistribution.
+	DistributionPreference []string
+	// MultiDistribution matches each package against the distribution it
+	// was found with, instead of the single distribution picked by
+	// DistributionPreference. This is for images that legitimately contain
+	// packages from more than one distribution, like a wolfi base with
+	// glibc copied in from debian: RHEL packages are then matched against
+	// RHEL advisories and debian packages against debian advisories in the
+	// same report. Packages are attributed by the ecosystem that found
+	// them, so a package database is only linked to a distribution that
+	// ecosystem knows about.
+	MultiDistribution bool
+	// ScanInitramfs adds the initramfs ecosystem to the default ecosystems.
+	// It unpacks the initramfs images in "/boot" of every layer and scans
+	// their contents, which is expensive and only useful for bootable
+	// images, so it's off by default. It has no effect if Ecosystems is
+	// provided; add initramfs.NewEcosystem to it instead.
+	ScanInitramfs bool
+	// ReadBufferSize is the size, in bytes, of the buffer used when reading
+	// files out of layers. Larger buffers trade memory for fewer reads of
+	// the layer file, which speeds up scanning large files on high-latency
+	// or high-bandwidth storage. Each open file gets at most this much,
+	// smaller files get a buffer of their own size.
+	//
+	// If zero, tarfs.DefaultBufferSize is used. If negative, reads are
+	// unbuffered.
+	ReadBufferSize int
+	// TwoPhaseScan runs distribution and repository scanners over every
+	// layer before starting the package and file scanners, so those can
+	// use the detected distributions (see indexer.DetectedDistributions).
+	// By default, all scanners run concurrently.
+	TwoPhaseScan bool
+	// StoreRetries is the number of times writing a scanner's results is
+	// retried if it fails with a transient error, like a serialization
+	// failure or a dropped database connection, so the scan work isn't
+	// thrown away. Retries back off exponentially, starting at
+	// StoreRetryBackoff, or indexer.DefaultStoreRetryBackoff if that's zero.
+	// See indexer.Retryable for which errors are retried. By default, writes
+	// aren't retried.
+	StoreRetries      int
+	StoreRetryBackoff time.Duration
+	// MaxLayerPackages and MaxLayerFiles cap the number of packages and
+	// files a single scanner can record for a layer, protecting the
+	// database from crafted images with millions of fake packages. Results
+	// past the cap are dropped, the layer is listed in the IndexReport's
+	// Stats, and a warning is added to the IndexReport. If zero,
+	// indexer.DefaultMaxLayerPackages and indexer.DefaultMaxLayerFiles are
+	// used, which are high enough not to affect real images. If negative,
+	// results aren't capped.
+	MaxLayerPackages int
+	MaxLayerFiles    int
+	// StrictScannerCoverage makes indexing fail with an error wrapping
+	// indexer.ErrMissingScanner when an image's distribution is detected but
+	// no package scanner for it is configured, like a RHEL image indexed
+	// without the rpm scanner. By default, the IndexReport is returned with
+	// an entry in its Warnings instead, as the report is likely missing
+	// packages.
+	StrictScannerCoverage bool
+	// SelfTest runs every configured scanner over a tiny synthetic layer
+	// when the Libindex is created, making New fail if any of them errors
+	// or misbehaves. See indexer.LayerScanner.SelfTest.
+	SelfTest bool
 	// ControllerFactory provides an alternative method for creating a scanner during libindex runtime
 	// if nil the default factory will be used. useful for testing purposes
 	ControllerFactory ControllerFactory
 	// Ecosystems a list of ecosystems to use which define which package databases and coalescing methods we use
 	Ecosystems []*indexer.Ecosystem
+	// EnabledEcosystems and DisabledEcosystems select ecosystems from
+	// Ecosystems (or the defaults) by name, like "dpkg" or "python", so
+	// whole ecosystems can be toggled from configuration. If
+	// EnabledEcosystems is not empty, only the named ecosystems are used.
+	// Ecosystems named in DisabledEcosystems are never used.
+	//
+	// Naming an ecosystem that isn't configured is an error. The "whiteout"
+	// ecosystem is always used.
+	EnabledEcosystems  []string
+	DisabledEcosystems []string
+	// AllowNoScanners lets New succeed when the selected ecosystems provide
+	// no scanners. By default, that's an error wrapping indexer.ErrNoScanners,
+	// as every IndexReport would be empty.
+	AllowNoScanners bool
 	// ScannerConfig holds functions that can be passed into configurable
 	// scanners. They're broken out by kind, and only used if a scanner
 	// implements the appropriate interface.
@@ -0,0 +1,109 @@
+package libindex
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/quay/claircore"
+	"github.com/quay/claircore/indexer"
+)
+
+var _ indexer.FetchArena = (*PrerealizedArena)(nil)
+
+// PrerealizedArena is a FetchArena serving layers from files already on disk,
+// keyed by the layer's digest. Layers it doesn't have a file for are realized
+// by another FetchArena, if one was provided.
+//
+// This is meant for tests: known-good fixture layers can be provided once and
+// used by every Index call, without fetching, decompressing, or verifying
+// them again. The files must be the uncompressed tar or squashfs contents of
+// the layers, and aren't checked against the digests.
+//
+// A PrerealizedArena is safe for concurrent use. It never removes the files
+// it was provided.
+type PrerealizedArena struct {
+	files map[string]string
+	next  indexer.FetchArena
+}
+
+// NewPrerealizedArena returns a PrerealizedArena serving the provided files,
+// keyed by the string form of the layer digest. The "next" FetchArena is used for any other layer; if it's
+// nil, realizing any other layer is an error.
+func NewPrerealizedArena(files map[string]string, next indexer.FetchArena) (*PrerealizedArena, error) {
+	a := PrerealizedArena{
+		files: make(map[string]string, len(files)),
+		next:  next,
+	}
+	for k, f := range files {
+		d, err := claircore.ParseDigest(k)
+		if err != nil {
+			return nil, fmt.Errorf("libindex: unable to use layer %q: %w", k, err)
+		}
+		fi, err := os.Stat(f)
+		if err != nil {
+			return nil, fmt.Errorf("libindex: unable to use layer %s: %w", d, err)
+		}
+		if !fi.Mode().IsRegular() {
+			return nil, fmt.Errorf("libindex: unable to use layer %s: %q is not a regular file", d, f)
+		}
+		a.files[d.String()] = f
+	}
+	return &a, nil
+}
+
+// Realizer implements indexer.FetchArena.
+func (a *PrerealizedArena) Realizer(ctx context.Context) indexer.Realizer {
+	r := prerealizer{a: a}
+	if a.next != nil {
+		r.next = a.next.Realizer(ctx)
+	}
+	return &r
+}
+
+// Close implements indexer.FetchArena.
+func (a *PrerealizedArena) Close(ctx context.Context) error {
+	if a.next == nil {
+		return nil
+	}
+	return a.next.Close(ctx)
+}
+
+// Prerealizer is the Realizer returned by PrerealizedArena.
+type prerealizer struct {
+	a    *PrerealizedArena
+	next indexer.Realizer
+}
+
+// Realize points layers with a known digest at their files, and hands the
+// rest to the next Realizer.
+func (r *prerealizer) Realize(ctx context.Context, ls []*claircore.Layer) error {
+	var rest []*claircore.Layer
+	for _, l := range ls {
+		if l.HasFS() {
+			continue
+		}
+		if f, ok := r.a.files[l.Hash.String()]; ok {
+			if err := l.SetLocal(f); err != nil {
+				return fmt.Errorf("libindex: unable to use layer %s: %w", l.Hash, err)
+			}
+			continue
+		}
+		rest = append(rest, l)
+	}
+	if len(rest) == 0 {
+		return nil
+	}
+	if r.next == nil {
+		return fmt.Errorf("libindex: layer %s not pre-realized", rest[0].Hash)
+	}
+	return r.next.Realize(ctx, rest)
+}
+
+// Close releases the layers realized by the next Realizer.
+func (r *prerealizer) Close() error {
+	if r.next == nil {
+		return nil
+	}
+	return r.next.Close()
+}
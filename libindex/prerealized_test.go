@@ -0,0 +1,153 @@
+package libindex
+
+import (
+	"archive/tar"
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/quay/claircore"
+	"github.com/quay/claircore/indexer"
+	"github.com/quay/claircore/test"
+)
+
+// CountingArena is a FetchArena recording the layers it's asked to realize,
+// and realizing none of them.
+type countingArena struct {
+	seen   []claircore.Digest
+	closed bool
+}
+
+func (a *countingArena) Realizer(context.Context) indexer.Realizer { return (*countingRealizer)(a) }
+func (a *countingArena) Close(context.Context) error               { return nil }
+
+type countingRealizer countingArena
+
+func (r *countingRealizer) Realize(_ context.Context, ls []*claircore.Layer) error {
+	for _, l := range ls {
+		r.seen = append(r.seen, l.Hash)
+	}
+	return nil
+}
+
+func (r *countingRealizer) Close() error {
+	r.closed = true
+	return nil
+}
+
+func TestPrerealizedArena(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	known := test.RandomSHA256Digest(t)
+	name := filepath.Join(dir, "layer.tar")
+	writeOSRelease(t, name)
+
+	t.Run("Known", func(t *testing.T) {
+		a, err := NewPrerealizedArena(map[string]string{known.String(): name}, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r := a.Realizer(ctx)
+		defer r.Close()
+		l := &claircore.Layer{Hash: known}
+		if err := r.Realize(ctx, []*claircore.Layer{l}); err != nil {
+			t.Fatal(err)
+		}
+		if !l.Fetched() {
+			t.Fatal("layer not realized")
+		}
+		sys, err := l.FS()
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer sys.Close()
+		if _, err := fs.Stat(sys, "etc/os-release"); err != nil {
+			t.Error(err)
+		}
+	})
+	t.Run("Unknown", func(t *testing.T) {
+		a, err := NewPrerealizedArena(map[string]string{known.String(): name}, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r := a.Realizer(ctx)
+		defer r.Close()
+		err = r.Realize(ctx, []*claircore.Layer{{Hash: test.RandomSHA256Digest(t)}})
+		t.Log(err)
+		if err == nil {
+			t.Error("expected error")
+		}
+	})
+	t.Run("Next", func(t *testing.T) {
+		var next countingArena
+		a, err := NewPrerealizedArena(map[string]string{known.String(): name}, &next)
+		if err != nil {
+			t.Fatal(err)
+		}
+		other := test.RandomSHA256Digest(t)
+		r := a.Realizer(ctx)
+		if err := r.Realize(ctx, []*claircore.Layer{{Hash: known}, {Hash: other}}); err != nil {
+			t.Fatal(err)
+		}
+		if err := r.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if len(next.seen) != 1 || next.seen[0].String() != other.String() {
+			t.Errorf("next arena realized: %v, want: [%v]", next.seen, other)
+		}
+		if !next.closed {
+			t.Error("next realizer not closed")
+		}
+	})
+	t.Run("Missing", func(t *testing.T) {
+		_, err := NewPrerealizedArena(map[string]string{known.String(): filepath.Join(dir, "nope")}, nil)
+		t.Log(err)
+		if err == nil {
+			t.Error("expected error")
+		}
+	})
+	t.Run("Untouched", func(t *testing.T) {
+		a, err := NewPrerealizedArena(map[string]string{known.String(): name}, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r := a.Realizer(ctx)
+		if err := r.Realize(ctx, []*claircore.Layer{{Hash: known}}); err != nil {
+			t.Fatal(err)
+		}
+		r.Close()
+		a.Close(ctx)
+		if _, err := os.Stat(name); err != nil {
+			t.Errorf("layer file removed: %v", err)
+		}
+	})
+}
+
+// WriteOSRelease writes a tar containing only an "etc/os-release" file to
+// "name".
+func writeOSRelease(t *testing.T, name string) {
+	t.Helper()
+	f, err := os.Create(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	const b = "ID=test\n"
+	tw := tar.NewWriter(f)
+	if err := tw.WriteHeader(&tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     "etc/os-release",
+		Size:     int64(len(b)),
+		Mode:     0o644,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tw.Write([]byte(b)); err != nil {
+		t.Fatal(err)
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,53 @@
+package libindex
+
+import (
+	"context"
+	"testing"
+
+	"github.com/quay/zlog"
+
+	"github.com/quay/claircore/indexer"
+)
+
+func TestRegistered(t *testing.T) {
+	ctx := zlog.Test(context.Background(), t)
+	es, err := indexer.RegisteredEcosystems(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := len(es), len(defaultEcosystems)+1; got != want {
+		t.Fatalf("got: %d ecosystems, want: %d", got, want)
+	}
+	if got, want := es[0].Name, "dpkg"; got != want {
+		t.Errorf("got: %q, want: %q", got, want)
+	}
+	if got, want := es[len(es)-1].Name, "whiteout"; got != want {
+		t.Errorf("got: %q, want: %q", got, want)
+	}
+	total := 0
+	for _, e := range es {
+		if len(e.Scanners) == 0 {
+			t.Errorf("%s: no scanners", e.Name)
+		}
+		total += len(e.Scanners)
+	}
+
+	ss, err := indexer.RegisteredScanners(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Scanners shared between ecosystems are only listed once.
+	if len(ss) == 0 || len(ss) >= total {
+		t.Errorf("got: %d scanners, from %d listed by ecosystem", len(ss), total)
+	}
+	seen := make(map[indexer.ScannerInfo]bool)
+	for _, s := range ss {
+		if s.Name == "" || s.Kind == "" || s.Version == "" {
+			t.Errorf("incomplete scanner info: %+v", s)
+		}
+		if seen[s] {
+			t.Errorf("duplicate scanner: %+v", s)
+		}
+		seen[s] = true
+	}
+}
@@ -0,0 +1,438 @@
+package libindex
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/quay/zlog"
+)
+
+// Credential is a credential for a container registry.
+type Credential struct {
+	Username string
+	Password string
+	// IdentityToken is an OAuth2 refresh token. If populated, it's used
+	// instead of Username and Password when requesting bearer tokens.
+	IdentityToken string
+}
+
+// Keychain resolves credentials for registries.
+type Keychain interface {
+	// Resolve reports the credential to use for the registry at "host", or
+	// false if there's no credential configured.
+	Resolve(ctx context.Context, host string) (Credential, bool, error)
+}
+
+// DockerConfig is the subset of a docker client configuration ("config.json")
+// needed to find registry credentials.
+//
+// DockerConfig implements Keychain.
+type DockerConfig struct {
+	Auths       map[string]DockerAuth `json:"auths"`
+	CredsStore  string                `json:"credsStore"`
+	CredHelpers map[string]string     `json:"credHelpers"`
+}
+
+var _ Keychain = (*DockerConfig)(nil)
+
+// DockerAuth is a credential stored inline in a DockerConfig.
+type DockerAuth struct {
+	// Auth is the base64 encoding of "username:password".
+	Auth          string `json:"auth"`
+	Username      string `json:"username"`
+	Password      string `json:"password"`
+	IdentityToken string `json:"identitytoken"`
+}
+
+// LoadDockerConfig reads the docker client configuration at "path".
+//
+// If "path" is empty, the file is located the same way the docker client does:
+// "config.json" inside the directory named by the DOCKER_CONFIG environment
+// variable, or inside "$HOME/.docker". A configuration file that doesn't exist
+// is not an error; it reports no credentials.
+func LoadDockerConfig(path string) (*DockerConfig, error) {
+	if path == "" {
+		dir := os.Getenv("DOCKER_CONFIG")
+		if dir == "" {
+			home, err := os.UserHomeDir()
+			if err != nil {
+				return &DockerConfig{}, nil
+			}
+			dir = filepath.Join(home, ".docker")
+		}
+		path = filepath.Join(dir, "config.json")
+	}
+	b, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, nil):
+	case errors.Is(err, os.ErrNotExist):
+		return &DockerConfig{}, nil
+	default:
+		return nil, fmt.Errorf("libindex: unable to read docker config: %w", err)
+	}
+	var cfg DockerConfig
+	if err := json.Unmarshal(b, &cfg); err != nil {
+		return nil, fmt.Errorf("libindex: unable to parse docker config %q: %w", path, err)
+	}
+	return &cfg, nil
+}
+
+// DockerHubServer is the key the docker client uses for Docker Hub credentials.
+const dockerHubServer = `https://index.docker.io/v1/`
+
+// ServerKeys returns the keys a docker client configuration may use for
+// "host", in order of preference.
+func serverKeys(host string) []string {
+	switch host {
+	case "docker.io", "index.docker.io", "registry-1.docker.io":
+		return []string{dockerHubServer, "index.docker.io", "docker.io", "registry-1.docker.io"}
+	}
+	return []string{host}
+}
+
+// NormalizeServer strips any scheme and path from a key in the "auths" map.
+func normalizeServer(s string) string {
+	if i := strings.Index(s, "://"); i != -1 {
+		s = s[i+3:]
+	}
+	if i := strings.IndexByte(s, '/'); i != -1 {
+		s = s[:i]
+	}
+	return s
+}
+
+// Resolve implements Keychain.
+//
+// A per-registry credential helper takes precedence over the default
+// credential store, which takes precedence over inline credentials.
+func (c *DockerConfig) Resolve(ctx context.Context, host string) (Credential, bool, error) {
+	keys := serverKeys(host)
+	helper := c.CredsStore
+	for _, k := range keys {
+		if h, ok := c.CredHelpers[k]; ok {
+			helper = h
+			break
+		}
+	}
+	if helper != "" {
+		cred, ok, err := credentialHelper(ctx, helper, keys[0])
+		if err != nil || ok {
+			return cred, ok, err
+		}
+	}
+	for _, k := range keys {
+		for s, a := range c.Auths {
+			if s != k && normalizeServer(s) != k {
+				continue
+			}
+			return a.credential()
+		}
+	}
+	return Credential{}, false, nil
+}
+
+func (a *DockerAuth) credential() (Credential, bool, error) {
+	cred := Credential{
+		Username:      a.Username,
+		Password:      a.Password,
+		IdentityToken: a.IdentityToken,
+	}
+	if a.Auth != "" {
+		b, err := base64.StdEncoding.DecodeString(a.Auth)
+		if err != nil {
+			return Credential{}, false, fmt.Errorf("libindex: invalid docker auth: %w", err)
+		}
+		u, p, ok := strings.Cut(string(b), ":")
+		if !ok {
+			return Credential{}, false, errors.New("libindex: invalid docker auth: missing separator")
+		}
+		cred.Username, cred.Password = u, p
+	}
+	if cred == (Credential{}) {
+		return cred, false, nil
+	}
+	return cred, true, nil
+}
+
+// CredentialHelper runs the docker credential helper "name" to look up the
+// credential for "server".
+//
+// This is a variable so that tests can substitute it.
+var credentialHelper = func(ctx context.Context, name, server string) (Credential, bool, error) {
+	cmd := exec.CommandContext(ctx, "docker-credential-"+name, "get")
+	cmd.Stdin = strings.NewReader(server)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if bytes.Contains(stdout.Bytes(), []byte("credentials not found")) {
+			return Credential{}, false, nil
+		}
+		return Credential{}, false, fmt.Errorf("libindex: credential helper %q failed: %w (stderr: %q)",
+			name, err, stderr.String())
+	}
+	return parseHelperOutput(stdout.Bytes())
+}
+
+// ParseHelperOutput decodes the response of a credential helper's "get"
+// command.
+func parseHelperOutput(b []byte) (Credential, bool, error) {
+	var res struct {
+		Username string `json:"Username"`
+		Secret   string `json:"Secret"`
+	}
+	if err := json.Unmarshal(b, &res); err != nil {
+		return Credential{}, false, fmt.Errorf("libindex: unable to parse credential helper output: %w", err)
+	}
+	if res.Secret == "" {
+		return Credential{}, false, nil
+	}
+	// Helpers use this sentinel username to signal an identity token.
+	if res.Username == "<token>" {
+		return Credential{IdentityToken: res.Secret}, true, nil
+	}
+	return Credential{Username: res.Username, Password: res.Secret}, true, nil
+}
+
+// Challenge is a parsed "WWW-Authenticate" header.
+type challenge struct {
+	Scheme string
+	Params map[string]string
+}
+
+// ParseChallenge parses a single challenge from a "WWW-Authenticate" header.
+//
+// Parameter values may be quoted, and quoted values may contain commas.
+func parseChallenge(h string) (challenge, error) {
+	h = strings.TrimSpace(h)
+	scheme, rest, _ := strings.Cut(h, " ")
+	if scheme == "" {
+		return challenge{}, errors.New("empty challenge")
+	}
+	c := challenge{
+		Scheme: strings.ToLower(scheme),
+		Params: make(map[string]string),
+	}
+	for rest = strings.TrimSpace(rest); rest != ""; rest = strings.TrimSpace(rest) {
+		k, v, ok := strings.Cut(rest, "=")
+		if !ok {
+			return challenge{}, fmt.Errorf("malformed challenge parameter: %q", rest)
+		}
+		k = strings.ToLower(strings.TrimSpace(k))
+		v = strings.TrimLeft(v, " ")
+		if strings.HasPrefix(v, `"`) {
+			var b strings.Builder
+			i := 1
+			for ; i < len(v) && v[i] != '"'; i++ {
+				if v[i] == '\\' && i+1 < len(v) {
+					i++
+				}
+				b.WriteByte(v[i])
+			}
+			if i == len(v) {
+				return challenge{}, fmt.Errorf("unterminated quoted value for %q", k)
+			}
+			c.Params[k] = b.String()
+			rest = v[i+1:]
+		} else {
+			end := strings.IndexByte(v, ',')
+			if end == -1 {
+				end = len(v)
+			}
+			c.Params[k] = strings.TrimSpace(v[:end])
+			rest = v[end:]
+		}
+		rest = strings.TrimPrefix(strings.TrimSpace(rest), ",")
+	}
+	return c, nil
+}
+
+// RepoPath extracts the repository from a registry API path.
+var repoPath = regexp.MustCompile(`^/v2/(.+)/blobs/[^/]+$`)
+
+// AuthSession resolves and caches registry authorizations for the duration of
+// a single scan.
+//
+// The zero value is not usable; use newAuthSession.
+type authSession struct {
+	kc Keychain
+	c  *http.Client
+
+	mu    sync.Mutex
+	creds map[string]*Credential // keyed by host; nil if none configured
+	authz map[string]string      // keyed by host and repository
+}
+
+func newAuthSession(c *http.Client, kc Keychain) *authSession {
+	return &authSession{
+		kc:    kc,
+		c:     c,
+		creds: make(map[string]*Credential),
+		authz: make(map[string]string),
+	}
+}
+
+// AuthzKey returns the cache key for the resource at "u".
+func authzKey(u *url.URL) string {
+	if m := repoPath.FindStringSubmatch(u.Path); m != nil {
+		return u.Host + "/" + m[1]
+	}
+	return u.Host
+}
+
+// Cached returns a previously computed "Authorization" header value for "u",
+// or the empty string.
+func (s *authSession) Cached(u *url.URL) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.authz[authzKey(u)]
+}
+
+// Credential returns the credential for "host", consulting the Keychain at most
+// once per host.
+func (s *authSession) credential(ctx context.Context, host string) (*Credential, error) {
+	s.mu.Lock()
+	cred, ok := s.creds[host]
+	s.mu.Unlock()
+	if ok {
+		return cred, nil
+	}
+	if s.kc != nil {
+		c, found, err := s.kc.Resolve(ctx, host)
+		if err != nil {
+			return nil, err
+		}
+		if found {
+			cred = &c
+		}
+	}
+	s.mu.Lock()
+	s.creds[host] = cred
+	s.mu.Unlock()
+	return cred, nil
+}
+
+// Authorize responds to the challenge "h" returned for a request to "u",
+// returning an "Authorization" header value to retry the request with.
+//
+// If no credential is configured for the registry, an anonymous bearer token
+// is requested.
+func (s *authSession) Authorize(ctx context.Context, u *url.URL, h string) (string, error) {
+	ctx = zlog.ContextWithValues(ctx,
+		"component", "libindex/authSession.Authorize",
+		"host", u.Host)
+	if h == "" {
+		return "", errors.New("libindex: unauthorized, but no challenge provided")
+	}
+	ch, err := parseChallenge(h)
+	if err != nil {
+		return "", fmt.Errorf("libindex: bad challenge: %w", err)
+	}
+	cred, err := s.credential(ctx, u.Host)
+	if err != nil {
+		return "", err
+	}
+	var v string
+	switch ch.Scheme {
+	case "basic":
+		if cred == nil || cred.Username == "" {
+			return "", fmt.Errorf("libindex: no credentials for %q", u.Host)
+		}
+		v = "Basic " + base64.StdEncoding.EncodeToString([]byte(cred.Username+":"+cred.Password))
+	case "bearer":
+		tok, err := s.token(ctx, ch.Params, cred)
+		if err != nil {
+			return "", err
+		}
+		v = "Bearer " + tok
+	default:
+		return "", fmt.Errorf("libindex: unsupported auth scheme %q", ch.Scheme)
+	}
+	zlog.Debug(ctx).
+		Str("scheme", ch.Scheme).
+		Bool("anonymous", cred == nil).
+		Msg("authorized")
+	s.mu.Lock()
+	s.authz[authzKey(u)] = v
+	s.mu.Unlock()
+	return v, nil
+}
+
+// Token requests a bearer token from the realm in the challenge parameters.
+func (s *authSession) token(ctx context.Context, params map[string]string, cred *Credential) (string, error) {
+	realm, err := url.Parse(params["realm"])
+	if err != nil || realm.Scheme == "" {
+		return "", fmt.Errorf("libindex: bad token realm %q", params["realm"])
+	}
+	var req *http.Request
+	if cred != nil && cred.IdentityToken != "" {
+		// OAuth2 refresh token flow.
+		form := url.Values{
+			"grant_type":    {"refresh_token"},
+			"refresh_token": {cred.IdentityToken},
+			"client_id":     {"claircore"},
+		}
+		if v := params["service"]; v != "" {
+			form.Set("service", v)
+		}
+		if v := params["scope"]; v != "" {
+			form.Set("scope", v)
+		}
+		req, err = http.NewRequestWithContext(ctx, http.MethodPost, realm.String(), strings.NewReader(form.Encode()))
+		if err != nil {
+			return "", err
+		}
+		req.Header.Set("content-type", "application/x-www-form-urlencoded")
+	} else {
+		q := realm.Query()
+		if v := params["service"]; v != "" {
+			q.Set("service", v)
+		}
+		if v := params["scope"]; v != "" {
+			q.Set("scope", v)
+		}
+		realm.RawQuery = q.Encode()
+		req, err = http.NewRequestWithContext(ctx, http.MethodGet, realm.String(), nil)
+		if err != nil {
+			return "", err
+		}
+		if cred != nil && cred.Username != "" {
+			req.SetBasicAuth(cred.Username, cred.Password)
+		}
+	}
+	res, err := s.c.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("libindex: token request failed: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("libindex: token request failed: unexpected status code: %s", res.Status)
+	}
+	var tr struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(io.LimitReader(res.Body, 1<<20)).Decode(&tr); err != nil {
+		return "", fmt.Errorf("libindex: unable to decode token response: %w", err)
+	}
+	switch {
+	case tr.Token != "":
+		return tr.Token, nil
+	case tr.AccessToken != "":
+		return tr.AccessToken, nil
+	}
+	return "", errors.New("libindex: token response contained no token")
+}
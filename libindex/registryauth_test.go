@@ -0,0 +1,188 @@
+package libindex
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/quay/zlog"
+
+	"github.com/quay/claircore"
+)
+
+func TestParseChallenge(t *testing.T) {
+	tt := []struct {
+		In   string
+		Want challenge
+	}{
+		{
+			In: `Bearer realm="https://auth.example.com/token",service="registry.example.com",scope="repository:a/b:pull,push"`,
+			Want: challenge{
+				Scheme: "bearer",
+				Params: map[string]string{
+					"realm":   "https://auth.example.com/token",
+					"service": "registry.example.com",
+					"scope":   "repository:a/b:pull,push",
+				},
+			},
+		},
+		{
+			In: `Basic realm=registry`,
+			Want: challenge{
+				Scheme: "basic",
+				Params: map[string]string{"realm": "registry"},
+			},
+		},
+	}
+	for _, tc := range tt {
+		got, err := parseChallenge(tc.In)
+		if err != nil {
+			t.Errorf("%q: %v", tc.In, err)
+			continue
+		}
+		if !cmp.Equal(got, tc.Want) {
+			t.Error(cmp.Diff(got, tc.Want))
+		}
+	}
+	if _, err := parseChallenge(`Bearer realm="unterminated`); err == nil {
+		t.Error("expected error, got nil")
+	}
+}
+
+func TestDockerConfig(t *testing.T) {
+	ctx := zlog.Test(context.Background(), t)
+	dir := t.TempDir()
+	t.Setenv("DOCKER_CONFIG", dir)
+	cfg := `{
+	"auths": {
+		"https://index.docker.io/v1/": {"auth": "` + base64.StdEncoding.EncodeToString([]byte("hub:secret")) + `"},
+		"registry.example.com": {"identitytoken": "refresh"}
+	},
+	"credHelpers": {"helped.example.com": "test"}
+}`
+	if err := os.WriteFile(filepath.Join(dir, "config.json"), []byte(cfg), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	helper := credentialHelper
+	t.Cleanup(func() { credentialHelper = helper })
+	credentialHelper = func(_ context.Context, name, server string) (Credential, bool, error) {
+		if name != "test" || server != "helped.example.com" {
+			t.Errorf("unexpected helper call: %q %q", name, server)
+		}
+		return parseHelperOutput([]byte(`{"ServerURL":"helped.example.com","Username":"helper","Secret":"pass"}`))
+	}
+
+	kc, err := LoadDockerConfig("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tt := []struct {
+		Host  string
+		Want  Credential
+		Found bool
+	}{
+		{Host: "registry-1.docker.io", Want: Credential{Username: "hub", Password: "secret"}, Found: true},
+		{Host: "registry.example.com", Want: Credential{IdentityToken: "refresh"}, Found: true},
+		{Host: "helped.example.com", Want: Credential{Username: "helper", Password: "pass"}, Found: true},
+		{Host: "quay.io", Found: false},
+	}
+	for _, tc := range tt {
+		got, ok, err := kc.Resolve(ctx, tc.Host)
+		if err != nil {
+			t.Errorf("%s: %v", tc.Host, err)
+			continue
+		}
+		if ok != tc.Found {
+			t.Errorf("%s: found: got: %v, want: %v", tc.Host, ok, tc.Found)
+		}
+		if !cmp.Equal(got, tc.Want) {
+			t.Errorf("%s: %s", tc.Host, cmp.Diff(got, tc.Want))
+		}
+	}
+
+	if _, err := LoadDockerConfig(filepath.Join(dir, "missing.json")); err != nil {
+		t.Errorf("missing config: %v", err)
+	}
+}
+
+type staticKeychain map[string]Credential
+
+func (k staticKeychain) Resolve(_ context.Context, host string) (Credential, bool, error) {
+	c, ok := k[host]
+	return c, ok, nil
+}
+
+func TestFetchAuthorized(t *testing.T) {
+	ctx, done := context.WithCancel(context.Background())
+	defer done()
+	ctx = zlog.Test(ctx, t)
+	ls, h := commonLayerServer(t, 2)
+	const token = "sekrit"
+	var tokenCalls int32
+	mux := http.NewServeMux()
+	var srv *httptest.Server
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&tokenCalls, 1)
+		if u, p, ok := r.BasicAuth(); !ok || u != "user" || p != "pass" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if got, want := r.URL.Query().Get("scope"), "repository:test/repo:pull"; got != want {
+			t.Errorf("scope: got: %q, want: %q", got, want)
+		}
+		json.NewEncoder(w).Encode(map[string]string{"token": token})
+	})
+	mux.HandleFunc("/v2/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer "+token {
+			w.Header().Set("WWW-Authenticate",
+				`Bearer realm="`+srv.URL+`/token",service="test",scope="repository:test/repo:pull"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		r.URL.Path = "/" + r.URL.Path[strings.LastIndexByte(r.URL.Path, '/')+1:]
+		h.ServeHTTP(w, r)
+	})
+	srv = httptest.NewServer(mux)
+	defer srv.Close()
+	ps := make([]*claircore.Layer, len(ls))
+	for i := range ls {
+		ls[i].URI = srv.URL + "/v2/test/repo/blobs" + ls[i].URI
+		ps[i] = &ls[i]
+	}
+
+	host := strings.TrimPrefix(srv.URL, "http://")
+	a := NewRemoteFetchArenaWithOptions(srv.Client(), t.TempDir(), &FetchOptions{
+		Keychain: staticKeychain{host: {Username: "user", Password: "pass"}},
+	})
+	defer a.Close(ctx)
+	// Fetch serially, so the second layer uses the cached token.
+	f := a.Realizer(ctx)
+	defer f.Close()
+	for _, p := range ps {
+		if err := f.Realize(ctx, []*claircore.Layer{p}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got, want := atomic.LoadInt32(&tokenCalls), int32(1); got != want {
+		t.Errorf("token requests: got: %d, want: %d", got, want)
+	}
+
+	t.Run("Anonymous", func(t *testing.T) {
+		ctx := zlog.Test(ctx, t)
+		a := NewRemoteFetchArena(srv.Client(), t.TempDir())
+		defer a.Close(ctx)
+		f := a.Realizer(ctx)
+		defer f.Close()
+		if err := f.Realize(ctx, ps[:1]); err == nil {
+			t.Error("expected error, got nil")
+		}
+	})
+}
@@ -0,0 +1,276 @@
+package libvuln
+
+import (
+	"archive/zip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/quay/zlog"
+
+	"github.com/quay/claircore/datastore"
+	"github.com/quay/claircore/libvuln/driver"
+	"github.com/quay/claircore/libvuln/jsonblob"
+	"github.com/quay/claircore/libvuln/updates"
+)
+
+// ArchiveVersion is the version of the offline archive format written by
+// ExportArchive.
+const ArchiveVersion = 1
+
+// Names of the files inside an offline archive.
+const (
+	archiveManifest = `manifest.json`
+	archiveData     = `updates.json`
+)
+
+// ArchiveManifest describes the contents of an offline archive.
+//
+// The manifest records a checksum of the update data, so an archive that's
+// been truncated or tampered with in transit is rejected by VerifyArchive and
+// ImportArchive. Operators wanting to verify the archive as a whole should
+// checksum the archive file itself.
+type ArchiveManifest struct {
+	// Version is the archive format version.
+	Version int `json:"version"`
+	// Created is when the archive was written.
+	Created time.Time `json:"created"`
+	// Updaters is the sorted list of updaters with data in the archive.
+	Updaters []string `json:"updaters"`
+	// Vulnerabilities is the number of vulnerabilities in the archive.
+	Vulnerabilities int `json:"vulnerabilities"`
+	// Enrichments is the number of enrichment records in the archive.
+	Enrichments int `json:"enrichments"`
+	// Size is the length of the update data, in bytes.
+	Size int64 `json:"size"`
+	// SHA256 is the hex-encoded SHA-256 digest of the update data.
+	SHA256 string `json:"sha256"`
+}
+
+// ExportArchive runs updaters and writes all of their data into a single
+// offline archive on "w", suitable for ImportArchive on a system without
+// network access.
+//
+// The "opts" are passed to updates.NewManager to select and configure the
+// updaters to run. If any updater fails, no archive is written, so an archive
+// always holds complete data for the updaters it lists.
+func ExportArchive(ctx context.Context, w io.Writer, c *http.Client, opts ...updates.ManagerOption) (*ArchiveManifest, error) {
+	ctx = zlog.ContextWithValues(ctx, "component", "libvuln/ExportArchive")
+
+	s, err := jsonblob.New()
+	if err != nil {
+		return nil, err
+	}
+	m, err := updates.NewManager(ctx, s, updates.NewLocalLockSource(), c, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.Run(ctx); err != nil {
+		return nil, err
+	}
+	return writeArchive(ctx, w, s)
+}
+
+// WriteArchive writes the contents of the Store as an offline archive.
+func writeArchive(ctx context.Context, w io.Writer, s *jsonblob.Store) (*ArchiveManifest, error) {
+	mf := ArchiveManifest{
+		Version: ArchiveVersion,
+		Created: time.Now().UTC(),
+	}
+	seen := make(map[string]struct{})
+	for _, e := range s.Entries() {
+		if _, ok := seen[e.Updater]; !ok {
+			seen[e.Updater] = struct{}{}
+			mf.Updaters = append(mf.Updaters, e.Updater)
+		}
+		mf.Vulnerabilities += len(e.Vuln)
+		mf.Enrichments += len(e.Enrichment)
+	}
+	sort.Strings(mf.Updaters)
+
+	z := zip.NewWriter(w)
+	f, err := z.Create(archiveData)
+	if err != nil {
+		return nil, fmt.Errorf("libvuln: unable to write archive: %w", err)
+	}
+	h := sha256.New()
+	cw := countWriter{w: io.MultiWriter(f, h)}
+	if err := s.Store(&cw); err != nil {
+		return nil, fmt.Errorf("libvuln: unable to write archive: %w", err)
+	}
+	mf.Size = cw.n
+	mf.SHA256 = hex.EncodeToString(h.Sum(nil))
+
+	f, err = z.Create(archiveManifest)
+	if err != nil {
+		return nil, fmt.Errorf("libvuln: unable to write archive: %w", err)
+	}
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(&mf); err != nil {
+		return nil, fmt.Errorf("libvuln: unable to write archive: %w", err)
+	}
+	if err := z.Close(); err != nil {
+		return nil, fmt.Errorf("libvuln: unable to write archive: %w", err)
+	}
+	zlog.Info(ctx).
+		Strs("updaters", mf.Updaters).
+		Int("vulnerabilities", mf.Vulnerabilities).
+		Int("enrichments", mf.Enrichments).
+		Str("sha256", mf.SHA256).
+		Msg("archive written")
+	return &mf, nil
+}
+
+// CountWriter counts the bytes written through it.
+type countWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countWriter) Write(b []byte) (int, error) {
+	n, err := c.w.Write(b)
+	c.n += int64(n)
+	return n, err
+}
+
+// VerifyArchive checks that the offline archive in "r", of length "size", is
+// a version this package understands and that its update data matches the
+// manifest.
+func VerifyArchive(r io.ReaderAt, size int64) (*ArchiveManifest, error) {
+	_, mf, err := openArchive(r, size)
+	return mf, err
+}
+
+// OpenArchive opens and verifies the archive, returning the zip reader and
+// the manifest.
+func openArchive(r io.ReaderAt, size int64) (*zip.Reader, *ArchiveManifest, error) {
+	z, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, nil, fmt.Errorf("libvuln: unable to open archive: %w", err)
+	}
+	f, err := z.Open(archiveManifest)
+	if err != nil {
+		return nil, nil, fmt.Errorf("libvuln: unable to open archive manifest: %w", err)
+	}
+	defer f.Close()
+	var mf ArchiveManifest
+	if err := json.NewDecoder(f).Decode(&mf); err != nil {
+		return nil, nil, fmt.Errorf("libvuln: unable to read archive manifest: %w", err)
+	}
+	if mf.Version != ArchiveVersion {
+		return nil, nil, fmt.Errorf("libvuln: unsupported archive version %d", mf.Version)
+	}
+
+	d, err := z.Open(archiveData)
+	if err != nil {
+		return nil, nil, fmt.Errorf("libvuln: unable to open archive data: %w", err)
+	}
+	defer d.Close()
+	h := sha256.New()
+	n, err := io.Copy(h, d)
+	if err != nil {
+		return nil, nil, fmt.Errorf("libvuln: unable to read archive data: %w", err)
+	}
+	if n != mf.Size {
+		return nil, nil, fmt.Errorf("libvuln: archive data size mismatch: got %d, want %d", n, mf.Size)
+	}
+	if got := hex.EncodeToString(h.Sum(nil)); got != mf.SHA256 {
+		return nil, nil, fmt.Errorf("libvuln: archive data checksum mismatch: got %s, want %s", got, mf.SHA256)
+	}
+	return z, &mf, nil
+}
+
+// ImportArchive verifies the offline archive in "r", of length "size", and
+// imports its contents into the provided store.
+//
+// Nothing is imported unless the whole archive verifies. Updates whose
+// fingerprint matches one already in the store are skipped, so importing the
+// same archive twice is harmless.
+func ImportArchive(ctx context.Context, s datastore.Updater, r io.ReaderAt, size int64) (*ArchiveManifest, error) {
+	ctx = zlog.ContextWithValues(ctx, "component", "libvuln/ImportArchive")
+
+	z, mf, err := openArchive(r, size)
+	if err != nil {
+		return nil, err
+	}
+	zlog.Info(ctx).
+		Time("created", mf.Created).
+		Strs("updaters", mf.Updaters).
+		Str("sha256", mf.SHA256).
+		Msg("archive verified")
+	d, err := z.Open(archiveData)
+	if err != nil {
+		return nil, fmt.Errorf("libvuln: unable to open archive data: %w", err)
+	}
+	defer d.Close()
+	if err := importUpdates(ctx, s, d); err != nil {
+		return nil, err
+	}
+	return mf, nil
+}
+
+// ImportUpdates imports the jsonblob-formatted updates in "in" into the
+// store, skipping updates already present.
+func importUpdates(ctx context.Context, s datastore.Updater, in io.Reader) error {
+	l, err := jsonblob.Load(ctx, in)
+	if err != nil {
+		return err
+	}
+
+	vOps, err := s.GetUpdateOperations(ctx, driver.VulnerabilityKind)
+	if err != nil {
+		return err
+	}
+	eOps, err := s.GetUpdateOperations(ctx, driver.EnrichmentKind)
+	if err != nil {
+		return err
+	}
+
+Update:
+	for l.Next() {
+		e := l.Entry()
+		ops := vOps
+		if len(e.Enrichment) != 0 {
+			ops = eOps
+		}
+		for _, op := range ops[e.Updater] {
+			// This only helps if updaters don't keep something that
+			// changes in the fingerprint.
+			if op.Fingerprint == e.Fingerprint {
+				zlog.Info(ctx).
+					Str("updater", e.Updater).
+					Msg("fingerprint match, skipping")
+				continue Update
+			}
+		}
+		if len(e.Enrichment) != 0 {
+			ref, err := s.UpdateEnrichments(ctx, e.Updater, e.Fingerprint, e.Enrichment)
+			if err != nil {
+				return err
+			}
+			zlog.Info(ctx).
+				Str("updater", e.Updater).
+				Str("ref", ref.String()).
+				Int("count", len(e.Enrichment)).
+				Msg("enrichment update imported")
+			continue
+		}
+		ref, err := s.UpdateVulnerabilities(ctx, e.Updater, e.Fingerprint, e.Vuln)
+		if err != nil {
+			return err
+		}
+		zlog.Info(ctx).
+			Str("updater", e.Updater).
+			Str("ref", ref.String()).
+			Int("count", len(e.Vuln)).
+			Msg("update imported")
+	}
+	return l.Err()
+}
@@ -0,0 +1,226 @@
+package libvuln
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/quay/zlog"
+
+	"github.com/quay/claircore"
+	"github.com/quay/claircore/libvuln/driver"
+	"github.com/quay/claircore/libvuln/jsonblob"
+	"github.com/quay/claircore/libvuln/updates"
+	"github.com/quay/claircore/test"
+)
+
+// ArchiveUpdater is an Updater returning a fixed set of vulnerabilities.
+type archiveUpdater struct {
+	vs []*claircore.Vulnerability
+}
+
+func (*archiveUpdater) Name() string { return "test" }
+
+func (*archiveUpdater) Fetch(context.Context, driver.Fingerprint) (io.ReadCloser, driver.Fingerprint, error) {
+	return io.NopCloser(strings.NewReader("")), "fp", nil
+}
+
+func (u *archiveUpdater) Parse(context.Context, io.ReadCloser) ([]*claircore.Vulnerability, error) {
+	return u.vs, nil
+}
+
+func TestArchive(t *testing.T) {
+	ctx := zlog.Test(context.Background(), t)
+	vs := test.GenUniqueVulnerabilities(10, "test")
+
+	var buf bytes.Buffer
+	mf, err := ExportArchive(ctx, &buf, &http.Client{},
+		updates.WithFactories(map[string]driver.UpdaterSetFactory{}),
+		updates.WithOutOfTree([]driver.Updater{&archiveUpdater{vs: vs}}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := mf.Updaters, []string{"test"}; !cmp.Equal(got, want) {
+		t.Error(cmp.Diff(got, want))
+	}
+	if got, want := mf.Vulnerabilities, len(vs); got != want {
+		t.Errorf("got: %d vulnerabilities, want: %d", got, want)
+	}
+
+	b := buf.Bytes()
+	s, err := jsonblob.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := ImportArchive(ctx, s, bytes.NewReader(b), int64(len(b)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cmp.Equal(got, mf) {
+		t.Error(cmp.Diff(got, mf))
+	}
+	var imported []*claircore.Vulnerability
+	for _, e := range s.Entries() {
+		if e.Fingerprint != "fp" {
+			t.Errorf("got fingerprint: %q", e.Fingerprint)
+		}
+		imported = append(imported, e.Vuln...)
+	}
+	if !cmp.Equal(imported, vs) {
+		t.Error(cmp.Diff(imported, vs))
+	}
+
+	// A second import should be skipped.
+	if _, err := ImportArchive(ctx, s, bytes.NewReader(b), int64(len(b))); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := len(s.Entries()), 1; got != want {
+		t.Errorf("got: %d updates, want: %d", got, want)
+	}
+}
+
+func TestArchiveEnrichments(t *testing.T) {
+	ctx := zlog.Test(context.Background(), t)
+	src, err := jsonblob.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	es := []driver.EnrichmentRecord{
+		{Tags: []string{"CVE-2023-0001"}, Enrichment: json.RawMessage(`{"score":1}`)},
+		{Tags: []string{"CVE-2023-0002"}, Enrichment: json.RawMessage(`{"score":2}`)},
+	}
+	if _, err := src.UpdateEnrichments(ctx, "test-enrichment", "fp", es); err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	mf, err := writeArchive(ctx, &buf, src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := mf.Enrichments, len(es); got != want {
+		t.Errorf("got: %d enrichments, want: %d", got, want)
+	}
+
+	b := buf.Bytes()
+	dst, err := jsonblob.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ImportArchive(ctx, dst, bytes.NewReader(b), int64(len(b))); err != nil {
+		t.Fatal(err)
+	}
+	ops, err := dst.GetUpdateOperations(ctx, driver.EnrichmentKind)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := len(ops["test-enrichment"]), 1; got != want {
+		t.Fatalf("got: %d enrichment updates, want: %d", got, want)
+	}
+	got := dst.Entries()[ops["test-enrichment"][0].Ref].Enrichment
+	if !cmp.Equal(got, es) {
+		t.Error(cmp.Diff(got, es))
+	}
+}
+
+func TestArchiveVerify(t *testing.T) {
+	ctx := zlog.Test(context.Background(), t)
+	src, err := jsonblob.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := src.UpdateVulnerabilities(ctx, "test", "fp", test.GenUniqueVulnerabilities(10, "test")); err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if _, err := writeArchive(ctx, &buf, src); err != nil {
+		t.Fatal(err)
+	}
+	b := buf.Bytes()
+	if _, err := VerifyArchive(bytes.NewReader(b), int64(len(b))); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("Truncated", func(t *testing.T) {
+		b := b[:len(b)/2]
+		if _, err := VerifyArchive(bytes.NewReader(b), int64(len(b))); err == nil {
+			t.Error("expected error")
+		}
+	})
+	t.Run("Checksum", func(t *testing.T) {
+		tb := rewriteArchive(t, b, func(name string, data []byte) []byte {
+			if name == archiveData {
+				return bytes.Replace(data, []byte("test"), []byte("tset"), 1)
+			}
+			return data
+		})
+		_, err := VerifyArchive(bytes.NewReader(tb), int64(len(tb)))
+		t.Log(err)
+		if err == nil {
+			t.Error("expected error")
+		}
+		dst, err := jsonblob.New()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := ImportArchive(ctx, dst, bytes.NewReader(tb), int64(len(tb))); err == nil {
+			t.Error("expected error")
+		}
+		if got := len(dst.Entries()); got != 0 {
+			t.Errorf("got: %d updates imported from a bad archive", got)
+		}
+	})
+	t.Run("Version", func(t *testing.T) {
+		tb := rewriteArchive(t, b, func(name string, data []byte) []byte {
+			if name == archiveManifest {
+				return bytes.Replace(data, []byte(`"version": 1`), []byte(`"version": 2`), 1)
+			}
+			return data
+		})
+		_, err := VerifyArchive(bytes.NewReader(tb), int64(len(tb)))
+		t.Log(err)
+		if err == nil {
+			t.Error("expected error")
+		}
+	})
+}
+
+// RewriteArchive returns a copy of the archive "b" with every file's contents
+// passed through "f".
+func rewriteArchive(t *testing.T, b []byte, f func(string, []byte) []byte) []byte {
+	t.Helper()
+	z, err := zip.NewReader(bytes.NewReader(b), int64(len(b)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for _, zf := range z.File {
+		rc, err := zf.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		out, err := w.Create(zf.Name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := out.Write(f(zf.Name, data)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
@@ -38,6 +38,20 @@ type EnrichmentUpdater interface {
 	ParseEnrichment(context.Context, io.ReadCloser) ([]EnrichmentRecord, error)
 }
 
+// DeltaUpdater is an optional interface for EnrichmentUpdaters whose
+// ParseEnrichment output only contains the records that changed since the
+// Fingerprint passed to FetchEnrichment, such as ones paging through an API by
+// modification date.
+//
+// The store carries forward the records from the previous update that don't
+// share a tag with any of the new records as part of the same update, so the
+// latest update is always the complete set.
+type DeltaUpdater interface {
+	// Delta reports whether ParseEnrichment output should be merged into the
+	// previous update instead of replacing it.
+	Delta() bool
+}
+
 // NoopUpdater is designed to be embedded into other Updater types so they can
 // be used in the original updater machinery.
 //
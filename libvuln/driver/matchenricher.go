@@ -0,0 +1,22 @@
+package driver
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/quay/claircore"
+)
+
+// MatchEnricher is an additional interface that a Matcher can implement to
+// contribute enrichments to the VulnerabilityReport.
+//
+// EnrichMatches is called with the vulnerabilities the Matcher reported,
+// keyed by package ID. The returned string is the type the enrichments are
+// keyed by in the VulnerabilityReport's Enrichments member, and should be
+// unique to the Matcher so that multiple Matchers and Enrichers can coexist
+// without clobbering each other.
+//
+// The same caveats as Enricher apply: the passed map may not be modified.
+type MatchEnricher interface {
+	EnrichMatches(ctx context.Context, vulns map[string][]*claircore.Vulnerability) (string, []json.RawMessage, error)
+}
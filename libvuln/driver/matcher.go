@@ -39,6 +39,17 @@ const (
 	DistributionPrettyName
 	// should match claircore.Package.Repository.Name => claircore.Vulnerability.Package.Repository.Name
 	RepositoryName
+	// should match any of claircore.Package.Provides => claircore.Vulnerability.Package.Name,
+	// in addition to the package and source package names.
+	//
+	// Unlike other constraints, this widens the match rather than narrowing it.
+	PackageProvides
+	// should match the part, vendor, and product of claircore.Package.CPE => claircore.Vulnerability.Package.Name,
+	// as reported by (cpe.WFN).ProductKey.
+	//
+	// This replaces the package name match, so only vulnerabilities recorded
+	// against a CPE product are returned.
+	PackageCPE
 )
 
 // Matcher is an interface which a Controller uses to query the vulnstore for vulnerabilities.
@@ -52,6 +63,10 @@ type Matcher interface {
 	Query() []MatchConstraint
 	// Vulnerable informs the Controller if the given package is affected by the given vulnerability.
 	// for example checking the "FixedInVersion" field.
+	//
+	// The Context is always non-nil and carries the request's cancellation
+	// and logging values. Implementations that do any expensive or blocking
+	// work should honor it and return its error when it's done.
 	Vulnerable(ctx context.Context, record *claircore.IndexRecord, vuln *claircore.Vulnerability) (bool, error)
 }
 
@@ -66,3 +81,82 @@ type VersionFilter interface {
 	// be completely normalized into a claircore.Version.
 	VersionAuthoritative() bool
 }
+
+// QueryRecorder is an additional interface that a Matcher can implement to
+// change the IndexRecords used to query the database.
+//
+// QueryRecord returns the record to query with in place of "r", which must not
+// be modified. The returned record's Package must have the same ID as r's,
+// because results are keyed by it. Vulnerable is still called with the
+// original record.
+type QueryRecorder interface {
+	QueryRecord(r *claircore.IndexRecord) *claircore.IndexRecord
+}
+
+// FixedVersionComparer is an additional interface that a Matcher can
+// implement to opt-in to recommending fixed versions in reports.
+//
+// It's only meaningful for Matchers whose vulnerabilities have a plain
+// version in their FixedInVersion, which the report then recommends
+// upgrading to. When more than one vulnerability applies to a package, the
+// highest of their FixedInVersion values is recommended.
+type FixedVersionComparer interface {
+	// CompareFixedVersions returns a negative number if the FixedInVersion
+	// "a" is a lower version than "b", a positive number if it's higher,
+	// and zero if they're the same version.
+	CompareFixedVersions(ctx context.Context, a, b string) int
+}
+
+// OSOwnedFilter is an additional interface that a Matcher for a language
+// ecosystem can implement to opt in to skipping packages whose files are
+// owned by an OS package, as recorded in the IndexReport's CanonicalPackages.
+// The OS package is matched instead, so the same files aren't reported twice.
+type OSOwnedFilter interface {
+	SkipOSOwned()
+}
+
+// AgnosticMatcher is an additional interface that a Matcher can implement to
+// check version-agnostic vulnerabilities, as reported by
+// (*claircore.Vulnerability).VersionAgnostic, without comparing versions.
+//
+// Matchers that don't implement it have Vulnerable called for those
+// vulnerabilities too, so Vulnerable must then treat an empty FixedInVersion
+// as affecting every version, including an empty installed one.
+type AgnosticMatcher interface {
+	// VulnerableAnyVersion reports whether the record is affected by the
+	// version-agnostic "vuln". It makes every check Vulnerable does, like
+	// the architecture, except for the version comparison.
+	VulnerableAnyVersion(ctx context.Context, record *claircore.IndexRecord, vuln *claircore.Vulnerability) (bool, error)
+}
+
+// Scoped is an additional interface that a Matcher can implement to declare
+// the IndexRecords it can possibly match.
+//
+// Records are routed to a Scoped Matcher only if they're in its Scope, so
+// Filter and Vulnerable are never called with records outside of it. Filter
+// is still called with the records in the Scope, so it only needs to be exact
+// where the Scope isn't.
+type Scoped interface {
+	Scope() Scope
+}
+
+// Scope describes the IndexRecords a Matcher can possibly match.
+//
+// A record is in the Scope if any of the fields list it. The zero Scope
+// contains every record.
+type Scope struct {
+	// Distributions is compared to a record's Distribution.DID.
+	Distributions []string
+	// DistributionNames is compared to a record's Distribution.Name.
+	DistributionNames []string
+	// Repositories is compared to a record's Repository.Name.
+	Repositories []string
+}
+
+// Empty reports whether the Scope lists nothing, and so contains every
+// record.
+func (s *Scope) Empty() bool {
+	return len(s.Distributions) == 0 &&
+		len(s.DistributionNames) == 0 &&
+		len(s.Repositories) == 0
+}
@@ -1,6 +1,7 @@
 package driver
 
 import (
+	"sort"
 	"time"
 
 	"github.com/google/uuid"
@@ -32,3 +33,26 @@ type UpdateDiff struct {
 	Added   []claircore.Vulnerability `json:"added"`
 	Removed []claircore.Vulnerability `json:"removed"`
 }
+
+// PackageNames returns the sorted names of the packages that vulnerabilities
+// were added or removed for.
+//
+// This can be used to find the IndexReports an update may affect. Matchers
+// may look up vulnerabilities by a package's source name instead of its own,
+// so both should be considered.
+func (d *UpdateDiff) PackageNames() []string {
+	seen := make(map[string]struct{})
+	for _, vs := range [][]claircore.Vulnerability{d.Added, d.Removed} {
+		for i := range vs {
+			if p := vs[i].Package; p != nil && p.Name != "" {
+				seen[p.Name] = struct{}{}
+			}
+		}
+	}
+	out := make([]string, 0, len(seen))
+	for n := range seen {
+		out = append(out, n)
+	}
+	sort.Strings(out)
+	return out
+}
@@ -41,6 +41,20 @@ type Fetcher interface {
 	Fetch(context.Context, Fingerprint) (io.ReadCloser, Fingerprint, error)
 }
 
+// Tombstoner is an optional interface for Updaters whose Parse output is
+// always the complete set of their vulnerabilities, such as ones parsing a
+// whole feed rather than a list of changes.
+//
+// A vulnerability such an Updater emitted previously but that's missing from
+// its latest output was withdrawn upstream. After a successful update, the
+// store removes these instead of reporting them until they're garbage
+// collected.
+type Tombstoner interface {
+	// Tombstone reports whether vulnerabilities missing from the latest
+	// Parse output should be removed.
+	Tombstone() bool
+}
+
 // Unchanged is returned by Fetchers when the database has not changed.
 var Unchanged = errors.New("database contents unchanged")
 
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"sort"
 	"sync"
@@ -73,8 +74,21 @@ func (l *Loader) Next() bool {
 			l.next.Fingerprint = l.de.Fingerprint
 			l.next.Date = l.de.Date
 		}
-		l.next.Vuln = append(l.next.Vuln, l.de.Vuln)
-		l.de.Vuln = nil // Needed to ensure the Decoder allocates new backing memory.
+		switch l.de.Kind {
+		case driver.EnrichmentKind:
+			if l.de.Enrichment == nil {
+				l.err = fmt.Errorf("jsonblob: missing enrichment record for update %v", id)
+				return false
+			}
+			l.next.Enrichment = append(l.next.Enrichment, *l.de.Enrichment)
+		default:
+			// Blobs written before enrichments were stored have no Kind.
+			l.next.Vuln = append(l.next.Vuln, l.de.Vuln)
+		}
+		// Needed to ensure the Decoder allocates new backing memory.
+		l.de.Vuln = nil
+		l.de.Enrichment = nil
+		l.de.Kind = ""
 
 		// If this was an initial diskEntry, promote the ref.
 		if id != l.cur {
@@ -86,7 +100,8 @@ func (l *Loader) Next() bool {
 		}
 	}
 	l.e = l.next
-	return true
+	l.next = nil
+	return l.e != nil
 }
 
 // Entry returns the latest loaded Entry.
@@ -115,6 +130,17 @@ func (s *Store) Store(w io.Writer) error {
 				CommonEntry: e.CommonEntry,
 				Ref:         id,
 				Vuln:        v,
+				Kind:        driver.VulnerabilityKind,
+			}); err != nil {
+				return err
+			}
+		}
+		for i := range e.Enrichment {
+			if err := enc.Encode(&diskEntry{
+				CommonEntry: e.CommonEntry,
+				Ref:         id,
+				Enrichment:  &e.Enrichment[i],
+				Kind:        driver.EnrichmentKind,
 			}); err != nil {
 				return err
 			}
@@ -139,7 +165,7 @@ type CommonEntry struct {
 	Date        time.Time
 }
 
-// DiskEntry is a single vulnerability. It's made from unpacking an Entry's
+// DiskEntry is a single vulnerability or enrichment record. It's made from unpacking an Entry's
 // slice and adding a uuid for grouping back into an Entry upon read.
 type diskEntry struct {
 	CommonEntry
@@ -183,6 +209,14 @@ func (s *Store) UpdateVulnerabilities(_ context.Context, updater string, fingerp
 	return ref, nil
 }
 
+// TombstoneVulnerabilities implements datastore.Updater.
+//
+// Every Entry is a complete record of an update, so there's nothing to
+// remove.
+func (s *Store) TombstoneVulnerabilities(_ context.Context, _ string, _ uuid.UUID) (int64, error) {
+	return 0, nil
+}
+
 // Copyops assumes all locks are taken care of.
 func (s *Store) copyops(ty driver.UpdateKind, us ...string) map[string][]driver.UpdateOperation {
 	ns := make(map[string]struct{})
@@ -267,6 +301,46 @@ func (s *Store) GC(_ context.Context, _ int) (int64, error) {
 // EnrichmentRecord(s), and ensures enrichments from previous updates are not
 // queries by clients.
 func (s *Store) UpdateEnrichments(ctx context.Context, kind string, fp driver.Fingerprint, es []driver.EnrichmentRecord) (uuid.UUID, error) {
+	s.Lock()
+	defer s.Unlock()
+	return s.updateEnrichments(kind, fp, es), nil
+}
+
+// DeltaUpdateEnrichments implements datastore.EnrichmentUpdater.
+//
+// Only the previous Entry held by this Store is consulted.
+func (s *Store) DeltaUpdateEnrichments(ctx context.Context, kind string, fp driver.Fingerprint, es []driver.EnrichmentRecord) (uuid.UUID, error) {
+	s.Lock()
+	defer s.Unlock()
+	var prev *Entry
+	if ops := s.ops[kind]; len(ops) != 0 {
+		prev = s.entry[ops[0].Ref]
+	}
+	// Copy the records, so carrying doesn't append to the caller's slice.
+	es = append(make([]driver.EnrichmentRecord, 0, len(es)), es...)
+	if prev != nil {
+		seen := make(map[string]struct{})
+		for _, r := range es {
+			for _, t := range r.Tags {
+				seen[t] = struct{}{}
+			}
+		}
+	Carry:
+		for _, r := range prev.Enrichment {
+			for _, t := range r.Tags {
+				if _, ok := seen[t]; ok {
+					continue Carry
+				}
+			}
+			es = append(es, r)
+		}
+	}
+	return s.updateEnrichments(kind, fp, es), nil
+}
+
+// UpdateEnrichments records a new Entry for the enrichments. The caller must
+// hold the lock.
+func (s *Store) updateEnrichments(kind string, fp driver.Fingerprint, es []driver.EnrichmentRecord) uuid.UUID {
 	now := time.Now()
 	e := Entry{
 		Enrichment: es,
@@ -275,8 +349,6 @@ func (s *Store) UpdateEnrichments(ctx context.Context, kind string, fp driver.Fi
 	e.Updater = kind
 	e.Fingerprint = fp
 	ref := uuid.New() // God help you if this wasn't unique.
-	s.Lock()
-	defer s.Unlock()
 	s.latest[driver.EnrichmentKind] = ref
 	s.entry[ref] = &e
 	s.ops[kind] = append([]driver.UpdateOperation{{
@@ -286,7 +358,7 @@ func (s *Store) UpdateEnrichments(ctx context.Context, kind string, fp driver.Fi
 		Updater:     kind,
 		Kind:        driver.EnrichmentKind,
 	}}, s.ops[kind]...)
-	return ref, nil
+	return ref
 }
 
 // RecordUpdaterStatus is unimplemented
@@ -1,14 +1,18 @@
 package jsonblob
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"io"
+	"strings"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
 	"golang.org/x/sync/errgroup"
 
 	"github.com/quay/claircore"
+	"github.com/quay/claircore/libvuln/driver"
 	"github.com/quay/claircore/test"
 )
 
@@ -70,3 +74,104 @@ func TestRoundtrip(t *testing.T) {
 		t.Error(cmp.Diff(got, vs))
 	}
 }
+
+func TestDeltaUpdateEnrichments(t *testing.T) {
+	ctx := context.Background()
+	s, err := New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec := func(tag, data string) driver.EnrichmentRecord {
+		return driver.EnrichmentRecord{
+			Tags:       []string{tag},
+			Enrichment: json.RawMessage(`"` + data + `"`),
+		}
+	}
+	if _, err := s.UpdateEnrichments(ctx, "test", "1", []driver.EnrichmentRecord{
+		rec("a", "old"), rec("b", "old"),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	ref, err := s.DeltaUpdateEnrichments(ctx, "test", "2", []driver.EnrichmentRecord{
+		rec("b", "new"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []driver.EnrichmentRecord{rec("b", "new"), rec("a", "old")}
+	if got := s.Entries()[ref].Enrichment; !cmp.Equal(got, want) {
+		t.Error(cmp.Diff(got, want))
+	}
+}
+
+func TestRoundtripEnrichments(t *testing.T) {
+	ctx := context.Background()
+	a, err := New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	vs := test.GenUniqueVulnerabilities(10, "test")
+	if _, err := a.UpdateVulnerabilities(ctx, "test", "", vs); err != nil {
+		t.Fatal(err)
+	}
+	es := []driver.EnrichmentRecord{
+		{Tags: []string{"a"}, Enrichment: json.RawMessage(`"a"`)},
+		{Tags: []string{"b", "c"}, Enrichment: json.RawMessage(`{"b":"c"}`)},
+	}
+	if _, err := a.UpdateEnrichments(ctx, "test-enrichment", "fp", es); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := a.Store(&buf); err != nil {
+		t.Fatal(err)
+	}
+	l, err := Load(ctx, &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var gotV []*claircore.Vulnerability
+	var gotE []driver.EnrichmentRecord
+	for l.Next() {
+		e := l.Entry()
+		switch e.Updater {
+		case "test":
+			gotV = append(gotV, e.Vuln...)
+			if len(e.Enrichment) != 0 {
+				t.Errorf("unexpected enrichments in vulnerability update: %v", e.Enrichment)
+			}
+		case "test-enrichment":
+			gotE = append(gotE, e.Enrichment...)
+			if len(e.Vuln) != 0 {
+				t.Errorf("unexpected vulnerabilities in enrichment update: %v", e.Vuln)
+			}
+			if got, want := e.Fingerprint, driver.Fingerprint("fp"); got != want {
+				t.Errorf("got: %q, want: %q", got, want)
+			}
+		default:
+			t.Errorf("unexpected updater: %q", e.Updater)
+		}
+	}
+	if err := l.Err(); err != nil {
+		t.Error(err)
+	}
+	if !cmp.Equal(gotV, vs) {
+		t.Error(cmp.Diff(gotV, vs))
+	}
+	if !cmp.Equal(gotE, es) {
+		t.Error(cmp.Diff(gotE, es))
+	}
+}
+
+func TestLoadEmpty(t *testing.T) {
+	l, err := Load(context.Background(), strings.NewReader(""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l.Next() {
+		t.Errorf("unexpected entry: %+v", l.Entry())
+	}
+	if err := l.Err(); err != nil {
+		t.Error(err)
+	}
+}
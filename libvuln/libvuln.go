@@ -15,9 +15,12 @@ import (
 	"github.com/quay/claircore"
 	"github.com/quay/claircore/datastore"
 	"github.com/quay/claircore/internal/matcher"
+	"github.com/quay/claircore/kernel"
 	"github.com/quay/claircore/libvuln/driver"
+	"github.com/quay/claircore/libvuln/override"
 	"github.com/quay/claircore/libvuln/updates"
 	"github.com/quay/claircore/matchers"
+	"github.com/quay/claircore/pkg/httputil"
 )
 
 // Libvuln exports methods for scanning an IndexReport and created
@@ -32,6 +35,11 @@ type Libvuln struct {
 	enrichers       []driver.Enricher
 	updateRetention int
 	updaters        *updates.Manager
+	activeKernel    bool
+	matchTiming     bool
+	overrides       *override.Rules
+	strictConds     bool
+	suppressLowConf bool
 }
 
 // TODO (crozzy): Find a home for this and stop redefining it.
@@ -72,7 +80,15 @@ func New(ctx context.Context, opts *Options) (*Libvuln, error) {
 		opts.UpdateWorkers = DefaultUpdateWorkers
 	}
 
-	if opts.Client == nil {
+	switch {
+	case opts.Client != nil:
+	case opts.Transport != nil:
+		c, err := httputil.NewClient(opts.Transport)
+		if err != nil {
+			return nil, fmt.Errorf("unable to construct HTTP client: %w", err)
+		}
+		opts.Client = c
+	default:
 		zlog.Warn(ctx).
 			Msg("using default HTTP client; this will become an error in the future")
 		opts.Client = http.DefaultClient // TODO(hank) Remove DefaultClient
@@ -86,6 +102,11 @@ func New(ctx context.Context, opts *Options) (*Libvuln, error) {
 		locker:          opts.Locker,
 		updateRetention: opts.UpdateRetention,
 		enrichers:       opts.Enrichers,
+		activeKernel:    opts.ActiveKernelOnly,
+		matchTiming:     opts.MatchTiming,
+		overrides:       opts.PackageOverrides,
+		strictConds:     opts.StrictConditions,
+		suppressLowConf: opts.SuppressLowConfidence,
 	}
 
 	// create matchers based on the provided config.
@@ -112,6 +133,7 @@ func New(ctx context.Context, opts *Options) (*Libvuln, error) {
 		updates.WithConfigs(opts.UpdaterConfigs),
 		updates.WithOutOfTree(opts.Updaters),
 		updates.WithGC(opts.UpdateRetention),
+		updates.WithVulnerabilityPolicy(opts.VulnerabilityPolicy),
 	)
 	if err != nil {
 		return nil, err
@@ -138,12 +160,103 @@ func (l *Libvuln) FetchUpdates(ctx context.Context) error {
 
 // Scan creates a VulnerabilityReport given a manifest's IndexReport.
 func (l *Libvuln) Scan(ctx context.Context, ir *claircore.IndexReport) (*claircore.VulnerabilityReport, error) {
+	if l.activeKernel {
+		ir = kernel.ActiveOnly(ir)
+	}
+	if l.matchTiming {
+		ctx = matcher.WithTiming(ctx)
+	}
+	if l.overrides != nil {
+		ctx = matcher.WithOverrides(ctx, l.overrides)
+	}
+	if l.strictConds {
+		ctx = matcher.WithStrictConditions(ctx)
+	}
+	if l.suppressLowConf {
+		ctx = matcher.WithSuppressLowConfidence(ctx)
+	}
 	if s, ok := l.store.(matcher.Store); ok {
 		return matcher.EnrichedMatch(ctx, ir, l.matchers, l.enrichers, s)
 	}
 	return matcher.Match(ctx, ir, l.matchers, l.store)
 }
 
+// ScanSnapshot is like Scan, but uses the vulnerabilities recorded by the
+// provided update operations, keyed by updater name, instead of the current
+// ones. Updaters without an update operation use their current
+// vulnerabilities.
+//
+// This reproduces a report against what was known at an earlier time. The
+// update operations must not have been deleted or garbage collected; see
+// UpdateOperations for finding them. If one is missing, the returned error
+// reports datastore.ErrUnknownUpdateOperation via errors.Is. Vulnerabilities
+// missing from a later update operation are still reported, unless their
+// updater is a driver.Tombstoner, which deletes them outright.
+func (l *Libvuln) ScanSnapshot(ctx context.Context, ir *claircore.IndexReport, ops map[string]uuid.UUID) (*claircore.VulnerabilityReport, error) {
+	return l.Scan(matcher.WithUpdateOperations(ctx, ops), ir)
+}
+
+// Match creates a VulnerabilityReport given a manifest's IndexReport, using
+// the provided Matchers and vulnerabilities.
+//
+// Unlike Scan, this doesn't need a Libvuln or a database: any
+// datastore.Vulnerability can be used, such as a memory.Store holding
+// vulnerabilities loaded by the caller. No enrichments are added.
+func Match(ctx context.Context, ir *claircore.IndexReport, ms []driver.Matcher, vs datastore.Vulnerability) (*claircore.VulnerabilityReport, error) {
+	return matcher.Match(ctx, ir, ms, vs)
+}
+
+// Rematch returns the VulnerabilityReport "vr", previously returned by Scan
+// for the IndexReport "ir", updated for the changes described by the
+// UpdateDiffs.
+//
+// Only the vulnerabilities added or removed by the updates are considered,
+// so this is much cheaper than calling Scan again. The UpdateDiffs should
+// cover every update since the report was created; see UpdateDiff and
+// driver.UpdateDiff.PackageNames for finding them and the reports they
+// affect. Enrichments are not updated.
+func (l *Libvuln) Rematch(ctx context.Context, ir *claircore.IndexReport, vr *claircore.VulnerabilityReport, diffs ...*driver.UpdateDiff) (*claircore.VulnerabilityReport, error) {
+	if l.activeKernel {
+		ir = kernel.ActiveOnly(ir)
+	}
+	if l.matchTiming {
+		ctx = matcher.WithTiming(ctx)
+	}
+	if l.overrides != nil {
+		ctx = matcher.WithOverrides(ctx, l.overrides)
+	}
+	if l.strictConds {
+		ctx = matcher.WithStrictConditions(ctx)
+	}
+	if l.suppressLowConf {
+		ctx = matcher.WithSuppressLowConfidence(ctx)
+	}
+	return matcher.Rematch(ctx, ir, vr, l.matchers, diffs...)
+}
+
+// Filter returns the VulnerabilityReport "vr", previously returned by Scan
+// for the IndexReport "ir", with the ReportFilter applied as of "now".
+//
+// Unlike calling the ReportFilter's Apply method directly, the recommended
+// fixed versions of packages are recomputed from the vulnerabilities left in
+// the report, instead of being dropped when they were for an ignored one.
+func (l *Libvuln) Filter(ctx context.Context, ir *claircore.IndexReport, vr *claircore.VulnerabilityReport, f *claircore.ReportFilter, now time.Time) (*claircore.VulnerabilityReport, error) {
+	out := f.Apply(vr, now)
+	if out.IgnoredVulnerabilities == nil || vr.FixedVersions == nil {
+		return out, nil
+	}
+	if l.activeKernel {
+		ir = kernel.ActiveOnly(ir)
+	}
+	if l.overrides != nil {
+		ctx = matcher.WithOverrides(ctx, l.overrides)
+	}
+	if err := matcher.FixedVersions(ctx, ir, out, l.matchers); err != nil {
+		return nil, fmt.Errorf("libvuln: unable to recompute fixed versions: %w", err)
+	}
+	return out, nil
+}
+
 // UpdateOperations returns UpdateOperations in date descending order keyed by the
 // Updater name
 func (l *Libvuln) UpdateOperations(ctx context.Context, kind driver.UpdateKind, updaters ...string) (map[string][]driver.UpdateOperation, error) {
@@ -6,6 +6,9 @@ import (
 
 	"github.com/quay/claircore/datastore"
 	"github.com/quay/claircore/libvuln/driver"
+	"github.com/quay/claircore/libvuln/override"
+	"github.com/quay/claircore/libvuln/updates"
+	"github.com/quay/claircore/pkg/httputil"
 )
 
 const (
@@ -36,10 +39,12 @@ type Options struct {
 	// "alpine"
 	// "aws"
 	// "debian"
+	// "nvd" - opt-in; only runs if configured with "enable" set.
 	// "oracle"
 	// "photon"
 	// "pyupio"
 	// "rhel"
+	// "rhel-repository-to-cpe"
 	// "suse"
 	// "ubuntu"
 	UpdaterSets []string
@@ -59,6 +64,7 @@ type Options struct {
 	// "alpine"
 	// "aws"
 	// "debian"
+	// "nvd-cpe" - opt-in; only used if configured with "enable" set.
 	// "oracle"
 	// "photon"
 	// "python"
@@ -66,6 +72,7 @@ type Options struct {
 	// "suse"
 	// "ubuntu"
 	// "crda" - remotematcher calls hosted api via RPC.
+	// "ossindex" - remotematcher calls the Sonatype OSS Index; needs credentials.
 	MatcherNames []string
 
 	// Config holds configuration blocks for MatcherFactories and Matchers,
@@ -82,6 +89,36 @@ type Options struct {
 	// requests.
 	Enrichers []driver.Enricher
 
+	// ActiveKernelOnly restricts reported kernel packages to the kernel an
+	// image is configured to boot, if one was detected during indexing.
+	//
+	// By default, every installed kernel is reported.
+	ActiveKernelOnly bool
+
+	// MatchTiming records the time spent in each matcher in the Stats of the
+	// VulnerabilityReports returned by Scan.
+	MatchTiming bool
+
+	// PackageOverrides tags OS packages that are really language packages
+	// with an additional ecosystem, so that ecosystem's matcher considers them
+	// too. Use override.LoadFile to read them from a rules file.
+	PackageOverrides *override.Rules
+
+	// StrictConditions leaves out vulnerabilities that only apply under a
+	// condition, like a module being enabled, that can't be confirmed from
+	// the IndexReport.
+	//
+	// By default, they're reported and listed in the VulnerabilityReport's
+	// ConditionallyAffected.
+	StrictConditions bool
+
+	// SuppressLowConfidence leaves out vulnerabilities of packages that were
+	// identified by best-effort heuristics, like guessing from file names.
+	//
+	// By default, they're reported, and vulnerabilities only found in such
+	// packages are listed in the VulnerabilityReport's LowConfidence.
+	SuppressLowConfidence bool
+
 	// UpdateWorkers controls the number of update workers running concurrently.
 	// If less than or equal to zero, a sensible default will be used.
 	UpdateWorkers int
@@ -100,7 +137,16 @@ type Options struct {
 	// UpdaterConfigs is a map of functions for configuration of Updaters.
 	UpdaterConfigs map[string]driver.ConfigUnmarshaler
 
-	// Client is an http.Client for use by all updaters. If unset,
-	// http.DefaultClient will be used.
+	// VulnerabilityPolicy, if set, is called on every vulnerability reported
+	// by an updater before it's stored, and may rewrite or drop it.
+	VulnerabilityPolicy updates.VulnerabilityPolicy
+
+	// Client is an http.Client for use by all updaters. If unset, a client
+	// constructed according to Transport will be used.
 	Client *http.Client
+
+	// Transport configures the egress proxy and additional trusted
+	// certificates used for the client constructed when Client is unset.
+	// If both are unset, http.DefaultClient will be used.
+	Transport *httputil.TransportConfig
 }
@@ -0,0 +1,326 @@
+// Package override implements user-supplied rules for matching OS packages
+// that are really language packages against that language's ecosystem as well.
+//
+// For example, an RPM named "python3-requests" is a packaged copy of the
+// "requests" distribution from PyPI. It's matched against the RHEL advisories
+// like every other RPM, but PyPI advisories for "requests" may describe
+// problems the distribution hasn't published an advisory for. A Rule tags such
+// packages with an additional ecosystem, so the ecosystem's matcher considers
+// them too.
+//
+// When both the distribution and the ecosystem report the same CVE for a
+// package, only one of them is kept, chosen by the Rule's Prefer field.
+package override
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/quay/claircore"
+	"github.com/quay/claircore/pkg/pep440"
+	"github.com/quay/claircore/pkg/pkgname"
+)
+
+// Values for Rule.Prefer.
+const (
+	// PreferDistribution keeps the distribution's vulnerability when both
+	// report the same CVE. This is the default.
+	PreferDistribution = "distribution"
+	// PreferEcosystem keeps the ecosystem's vulnerability when both report
+	// the same CVE.
+	PreferEcosystem = "ecosystem"
+)
+
+// Rule tags the OS packages it matches with an additional ecosystem.
+type Rule struct {
+	// Name is a path.Match pattern compared to the package name, such as
+	// "python3-*".
+	Name string `json:"name"`
+	// Distributions, if not empty, restricts the rule to packages from
+	// distributions with one of the listed IDs (the "ID" in os-release).
+	Distributions []string `json:"distributions,omitempty"`
+	// Ecosystem is the additional ecosystem. The only supported value is
+	// "pypi".
+	Ecosystem string `json:"ecosystem"`
+	// TrimPrefix is removed from the package name to find the name the
+	// package has in the ecosystem, so "python3-" turns "python3-requests"
+	// into "requests".
+	TrimPrefix string `json:"trim_prefix,omitempty"`
+	// Prefer is either PreferDistribution or PreferEcosystem. If empty,
+	// PreferDistribution is used.
+	Prefer string `json:"prefer,omitempty"`
+}
+
+// Ecosystem describes how to present an OS package to an ecosystem's matcher.
+type ecosystem struct {
+	repo *claircore.Repository
+	// Name normalizes the package name.
+	name func(string) string
+	// Version returns the normalized version for the upstream version, or
+	// false if it can't be parsed.
+	version func(string) (claircore.Version, bool)
+}
+
+// PypiRepository is the same as python.Repository. It's copied because the
+// matcher imports this package, and the python package imports the distribution
+// packages whose tests import the matcher.
+var pypiRepository = claircore.Repository{
+	Name: "pypi",
+	URI:  "https://pypi.org/simple",
+}
+
+// Ecosystems are the supported values for Rule.Ecosystem.
+var ecosystems = map[string]*ecosystem{
+	"pypi": {
+		repo: &pypiRepository,
+		name: pkgname.Python,
+		version: func(v string) (claircore.Version, bool) {
+			pv, err := pep440.Parse(v)
+			if err != nil {
+				return claircore.Version{}, false
+			}
+			return pv.Version(), true
+		},
+	},
+}
+
+// Rules is a validated set of Rules.
+//
+// The zero value and a nil *Rules have no rules.
+type Rules struct {
+	rules []Rule
+}
+
+// New returns Rules for the provided rules, checking that each one is
+// usable.
+func New(rules []Rule) (*Rules, error) {
+	rs := make([]Rule, len(rules))
+	copy(rs, rules)
+	var errs []error
+	for i := range rs {
+		r := &rs[i]
+		if _, err := path.Match(r.Name, ""); err != nil || r.Name == "" {
+			errs = append(errs, fmt.Errorf("rule %d: bad name pattern %q", i, r.Name))
+		}
+		if _, ok := ecosystems[r.Ecosystem]; !ok {
+			errs = append(errs, fmt.Errorf("rule %d: unsupported ecosystem %q", i, r.Ecosystem))
+		}
+		switch r.Prefer {
+		case "":
+			r.Prefer = PreferDistribution
+		case PreferDistribution, PreferEcosystem:
+		default:
+			errs = append(errs, fmt.Errorf("rule %d: unknown preference %q", i, r.Prefer))
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, fmt.Errorf("override: %w", err)
+	}
+	return &Rules{rules: rs}, nil
+}
+
+// Load reads a JSON array of Rules from "r".
+func Load(r io.Reader) (*Rules, error) {
+	var rs []Rule
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&rs); err != nil {
+		return nil, fmt.Errorf("override: unable to read rules: %w", err)
+	}
+	return New(rs)
+}
+
+// LoadFile reads a JSON array of Rules from the named file.
+func LoadFile(name string) (*Rules, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, fmt.Errorf("override: unable to open rules: %w", err)
+	}
+	defer f.Close()
+	return Load(f)
+}
+
+// Match returns the first rule applying to the record, or nil.
+func (rs *Rules) match(r *claircore.IndexRecord) *Rule {
+	if r.Package == nil || r.Distribution == nil {
+		return nil
+	}
+	for i := range rs.rules {
+		rule := &rs.rules[i]
+		if ok, _ := path.Match(rule.Name, r.Package.Name); !ok {
+			continue
+		}
+		if len(rule.Distributions) != 0 && !contains(rule.Distributions, r.Distribution.DID) {
+			continue
+		}
+		return rule
+	}
+	return nil
+}
+
+func contains(ss []string, s string) bool {
+	for _, x := range ss {
+		if x == s {
+			return true
+		}
+	}
+	return false
+}
+
+// Expand returns "records" with an additional record for every package a rule
+// applies to, presenting the package as a member of the rule's ecosystem.
+//
+// The added records have no Distribution, so distribution matchers ignore
+// them, and their Package has a different ID, so the vulnerabilities found
+// for them can be told apart. The returned Expansion folds them back into the
+// original package.
+//
+// Packages whose version can't be understood by the ecosystem are skipped.
+func (rs *Rules) Expand(records []*claircore.IndexRecord) ([]*claircore.IndexRecord, *Expansion) {
+	e := Expansion{added: make(map[string]tagged)}
+	if rs == nil || len(rs.rules) == 0 {
+		return records, &e
+	}
+	out := records
+	for _, r := range records {
+		rule := rs.match(r)
+		if rule == nil {
+			continue
+		}
+		id := r.Package.ID + "/" + rule.Ecosystem
+		if _, ok := e.added[id]; ok {
+			// Already added for another of the package's repositories.
+			continue
+		}
+		eco := ecosystems[rule.Ecosystem]
+		v := upstreamVersion(r.Package.Version)
+		nv, ok := eco.version(v)
+		if !ok {
+			continue
+		}
+		p := claircore.Package{
+			ID:                id,
+			Name:              eco.name(strings.TrimPrefix(r.Package.Name, rule.TrimPrefix)),
+			Version:           v,
+			Kind:              claircore.BINARY,
+			NormalizedVersion: nv,
+			PackageDB:         r.Package.PackageDB,
+			Filepath:          r.Package.Filepath,
+		}
+		if len(out) == len(records) {
+			// Don't modify the caller's slice.
+			out = append(make([]*claircore.IndexRecord, 0, len(records)+1), records...)
+		}
+		out = append(out, &claircore.IndexRecord{
+			Package:    &p,
+			Repository: eco.repo,
+		})
+		e.added[id] = tagged{id: r.Package.ID, prefer: rule.Prefer}
+	}
+	return out, &e
+}
+
+// UpstreamVersion strips the epoch and release from an RPM or dpkg version,
+// leaving the version the package was built from.
+func upstreamVersion(v string) string {
+	if i := strings.IndexByte(v, ':'); i != -1 {
+		v = v[i+1:]
+	}
+	if i := strings.LastIndexByte(v, '-'); i != -1 {
+		v = v[:i]
+	}
+	return v
+}
+
+// Expansion records the packages added by Expand.
+type Expansion struct {
+	// keyed by the added package's ID
+	added map[string]tagged
+}
+
+// Tagged is the package an added package stands in for, along with the
+// rule's preference.
+type tagged struct {
+	id     string
+	prefer string
+}
+
+// Fold moves the vulnerabilities found for added packages in "vr" to the
+// packages they stand in for.
+//
+// If the package already has a vulnerability for the same CVE, only the
+// preferred one is kept. Vulnerabilities are compared by the CVE IDs in their
+// names, or by their whole names if there are none.
+func (e *Expansion) Fold(vr *claircore.VulnerabilityReport) {
+	if len(e.added) == 0 {
+		return
+	}
+	dropped := make(map[string]struct{})
+	for added, t := range e.added {
+		eco, ok := vr.PackageVulnerabilities[added]
+		if !ok {
+			continue
+		}
+		delete(vr.PackageVulnerabilities, added)
+		dist := vr.PackageVulnerabilities[t.id]
+		keep, lose := dist, eco
+		if t.prefer == PreferEcosystem {
+			keep, lose = eco, dist
+		}
+		seen := make(map[string]struct{})
+		for _, id := range keep {
+			for _, k := range cveKeys(vr.Vulnerabilities[id]) {
+				seen[k] = struct{}{}
+			}
+		}
+		out := make([]string, 0, len(dist)+len(eco))
+		out = append(out, keep...)
+	Lose:
+		for _, id := range lose {
+			for _, k := range cveKeys(vr.Vulnerabilities[id]) {
+				if _, ok := seen[k]; ok {
+					dropped[id] = struct{}{}
+					continue Lose
+				}
+			}
+			out = append(out, id)
+		}
+		if len(out) != 0 {
+			vr.PackageVulnerabilities[t.id] = out
+		}
+	}
+	if len(dropped) == 0 {
+		return
+	}
+	// Only remove dropped vulnerabilities no other package still refers to.
+	for _, ids := range vr.PackageVulnerabilities {
+		for _, id := range ids {
+			delete(dropped, id)
+		}
+	}
+	for id := range dropped {
+		delete(vr.Vulnerabilities, id)
+	}
+}
+
+var cvePattern = regexp.MustCompile(`(?i)CVE-\d{4}-\d{4,}`)
+
+// CveKeys returns the keys a vulnerability is compared by.
+func cveKeys(v *claircore.Vulnerability) []string {
+	if v == nil {
+		return nil
+	}
+	if ks := cvePattern.FindAllString(v.Name, -1); len(ks) != 0 {
+		for i := range ks {
+			ks[i] = strings.ToUpper(ks[i])
+		}
+		return ks
+	}
+	return []string{v.Name}
+}
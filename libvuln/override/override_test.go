@@ -0,0 +1,177 @@
+package override
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/quay/claircore"
+	"github.com/quay/claircore/python"
+)
+
+func TestRepository(t *testing.T) {
+	if got, want := pypiRepository, python.Repository; !cmp.Equal(got, want) {
+		t.Error(cmp.Diff(got, want))
+	}
+}
+
+func TestLoad(t *testing.T) {
+	t.Run("Good", func(t *testing.T) {
+		const in = `[{"name":"python3-*","distributions":["rhel"],"ecosystem":"pypi","trim_prefix":"python3-"}]`
+		rs, err := Load(strings.NewReader(in))
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := []Rule{{
+			Name:          "python3-*",
+			Distributions: []string{"rhel"},
+			Ecosystem:     "pypi",
+			TrimPrefix:    "python3-",
+			Prefer:        PreferDistribution,
+		}}
+		if got := rs.rules; !cmp.Equal(got, want) {
+			t.Error(cmp.Diff(got, want))
+		}
+	})
+	bad := []struct {
+		Name string
+		In   string
+	}{
+		{Name: "Syntax", In: `[{"name":`},
+		{Name: "UnknownField", In: `[{"name":"a","ecosystem":"pypi","nope":true}]`},
+		{Name: "NoName", In: `[{"ecosystem":"pypi"}]`},
+		{Name: "BadPattern", In: `[{"name":"[","ecosystem":"pypi"}]`},
+		{Name: "Ecosystem", In: `[{"name":"a","ecosystem":"cran"}]`},
+		{Name: "Prefer", In: `[{"name":"a","ecosystem":"pypi","prefer":"both"}]`},
+	}
+	for _, tc := range bad {
+		t.Run(tc.Name, func(t *testing.T) {
+			_, err := Load(strings.NewReader(tc.In))
+			t.Log(err)
+			if err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
+
+func TestExpand(t *testing.T) {
+	rs, err := New([]Rule{{
+		Name:          "python3-*",
+		Distributions: []string{"rhel"},
+		Ecosystem:     "pypi",
+		TrimPrefix:    "python3-",
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rhel := &claircore.Distribution{DID: "rhel", VersionID: "9"}
+	fedora := &claircore.Distribution{DID: "fedora", VersionID: "39"}
+	requests := &claircore.Package{
+		ID:      "1",
+		Name:    "python3-requests",
+		Version: "2.25.1-8.el9",
+		Kind:    claircore.BINARY,
+	}
+	records := []*claircore.IndexRecord{
+		{Package: requests, Distribution: rhel, Repository: &claircore.Repository{Name: "baseos"}},
+		{Package: requests, Distribution: rhel, Repository: &claircore.Repository{Name: "appstream"}},
+		{Package: &claircore.Package{ID: "2", Name: "bash", Version: "5.1.8-6.el9"}, Distribution: rhel},
+		{Package: &claircore.Package{ID: "3", Name: "python3-idna", Version: "2.10-7.fc39"}, Distribution: fedora},
+		{Package: &claircore.Package{ID: "4", Name: "python3-odd", Version: "1:not.a.version-1"}, Distribution: rhel},
+	}
+
+	got, ex := rs.Expand(records)
+	if got, want := len(records), 5; got != want {
+		t.Fatalf("caller's slice modified: got: %d records, want: %d", got, want)
+	}
+	if got, want := len(got), len(records)+1; got != want {
+		t.Fatalf("got: %d records, want: %d", got, want)
+	}
+	r := got[len(got)-1]
+	if r.Distribution != nil {
+		t.Errorf("unexpected distribution: %+v", r.Distribution)
+	}
+	if got, want := r.Repository, &pypiRepository; got != want {
+		t.Errorf("got: %+v, want: %+v", got, want)
+	}
+	if got, want := r.Package.ID, "1/pypi"; got != want {
+		t.Errorf("got: %q, want: %q", got, want)
+	}
+	if got, want := r.Package.Name, "requests"; got != want {
+		t.Errorf("got: %q, want: %q", got, want)
+	}
+	if got, want := r.Package.Version, "2.25.1"; got != want {
+		t.Errorf("got: %q, want: %q", got, want)
+	}
+	if got, want := r.Package.NormalizedVersion.Kind, "pep440"; got != want {
+		t.Errorf("got: %q, want: %q", got, want)
+	}
+	if got, want := ex.added, map[string]tagged{"1/pypi": {id: "1", prefer: PreferDistribution}}; !cmp.Equal(got, want, cmp.AllowUnexported(tagged{})) {
+		t.Error(cmp.Diff(got, want, cmp.AllowUnexported(tagged{})))
+	}
+
+	t.Run("Nil", func(t *testing.T) {
+		var rs *Rules
+		got, ex := rs.Expand(records)
+		if len(got) != len(records) {
+			t.Errorf("got: %d records, want: %d", len(got), len(records))
+		}
+		ex.Fold(&claircore.VulnerabilityReport{})
+	})
+}
+
+func TestFold(t *testing.T) {
+	report := func() *claircore.VulnerabilityReport {
+		return &claircore.VulnerabilityReport{
+			Vulnerabilities: map[string]*claircore.Vulnerability{
+				"rhel-1": {ID: "rhel-1", Name: "CVE-2023-32681"},
+				"rhel-2": {ID: "rhel-2", Name: "CVE-2024-0001"},
+				"pypi-1": {ID: "pypi-1", Name: "PYSEC-2023-74 (cve-2023-32681)"},
+				"pypi-2": {ID: "pypi-2", Name: "GHSA-xxxx-yyyy-zzzz"},
+			},
+			PackageVulnerabilities: map[string][]string{
+				"1":      {"rhel-1", "rhel-2"},
+				"1/pypi": {"pypi-1", "pypi-2"},
+				"2":      {"pypi-2"},
+			},
+		}
+	}
+	tt := []struct {
+		Prefer  string
+		Want    []string
+		Dropped string
+	}{
+		{
+			Prefer:  PreferDistribution,
+			Want:    []string{"rhel-1", "rhel-2", "pypi-2"},
+			Dropped: "pypi-1",
+		},
+		{
+			Prefer:  PreferEcosystem,
+			Want:    []string{"pypi-1", "pypi-2", "rhel-2"},
+			Dropped: "rhel-1",
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.Prefer, func(t *testing.T) {
+			vr := report()
+			ex := Expansion{added: map[string]tagged{"1/pypi": {id: "1", prefer: tc.Prefer}}}
+			ex.Fold(vr)
+			if _, ok := vr.PackageVulnerabilities["1/pypi"]; ok {
+				t.Error("added package left in report")
+			}
+			if got := vr.PackageVulnerabilities["1"]; !cmp.Equal(got, tc.Want) {
+				t.Error(cmp.Diff(got, tc.Want))
+			}
+			if _, ok := vr.Vulnerabilities[tc.Dropped]; ok {
+				t.Errorf("dropped vulnerability %q left in report", tc.Dropped)
+			}
+			// Still referred to by another package.
+			if _, ok := vr.Vulnerabilities["pypi-2"]; !ok {
+				t.Error("shared vulnerability removed")
+			}
+		})
+	}
+}
@@ -54,6 +54,8 @@ type Manager struct {
 	// instructs manager to run gc and provides the number of
 	// update operations to keep.
 	updateRetention int
+	// rewrites vulnerabilities before they're stored.
+	policy VulnerabilityPolicy
 
 	locks  LockSource
 	client *http.Client
@@ -217,8 +219,7 @@ func (m *Manager) Run(ctx context.Context) error {
 				return
 			}
 
-			err = m.driveUpdater(ctx, u)
-			if err != nil {
+			if err := m.driveUpdater(ctx, u); err != nil {
 				errChan <- fmt.Errorf("%v: %w", u.Name(), err)
 			}
 		}(toRun[i])
@@ -352,6 +353,12 @@ func (m *Manager) driveUpdater(ctx context.Context, u driver.Updater) (err error
 			return
 		}
 
+		if d, ok := u.(driver.DeltaUpdater); ok && d.Delta() {
+			// The enrichments are only the changes, so carry forward the
+			// rest as part of the same update.
+			ref, err = m.store.DeltaUpdateEnrichments(ctx, name, newFP, ers)
+			break
+		}
 		ref, err = m.store.UpdateEnrichments(ctx, name, newFP, ers)
 	default:
 		var vulns []*claircore.Vulnerability
@@ -360,6 +367,12 @@ func (m *Manager) driveUpdater(ctx context.Context, u driver.Updater) (err error
 			err = fmt.Errorf("vulnerability database parse failed: %v", err)
 			return
 		}
+		if m.policy != nil {
+			vulns, err = applyPolicy(ctx, m.policy, name, vulns)
+			if err != nil {
+				return
+			}
+		}
 
 		ref, err = m.store.UpdateVulnerabilities(ctx, name, newFP, vulns)
 	}
@@ -367,6 +380,17 @@ func (m *Manager) driveUpdater(ctx context.Context, u driver.Updater) (err error
 		err = fmt.Errorf("failed to update: %v", err)
 		return
 	}
+	if t, ok := u.(driver.Tombstoner); ok && !euOK && t.Tombstone() {
+		var ct int64
+		ct, err = m.store.TombstoneVulnerabilities(ctx, name, ref)
+		if err != nil {
+			err = fmt.Errorf("failed to remove withdrawn vulnerabilities: %v", err)
+			return
+		}
+		zlog.Debug(ctx).
+			Int64("count", ct).
+			Msg("removed withdrawn vulnerabilities")
+	}
 	zlog.Info(ctx).
 		Str("ref", ref.String()).
 		Msg("successful update")
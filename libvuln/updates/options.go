@@ -99,3 +99,11 @@ func WithFactories(f map[string]driver.UpdaterSetFactory) ManagerOption {
 		m.factories = f
 	}
 }
+
+// WithVulnerabilityPolicy configures the Manager to pass every vulnerability
+// reported by an updater through the provided policy before storing it.
+func WithVulnerabilityPolicy(p VulnerabilityPolicy) ManagerOption {
+	return func(m *Manager) {
+		m.policy = p
+	}
+}
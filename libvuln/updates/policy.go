@@ -0,0 +1,46 @@
+package updates
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/quay/zlog"
+
+	"github.com/quay/claircore"
+)
+
+// VulnerabilityPolicy is a hook for rewriting vulnerabilities before they're
+// stored, for example to suppress or downgrade accepted risks in one place
+// instead of in every consumer.
+//
+// It's called with the name of the updater and each vulnerability the updater
+// reported. It returns the vulnerability to store, which may be "v" modified in
+// place, or nil to drop it. Returning an error fails the update, so nothing
+// from that run of the updater is stored.
+//
+// A policy is only applied when an updater has new data. Changes to a policy
+// take effect for an updater the next time its upstream data changes.
+type VulnerabilityPolicy func(ctx context.Context, updater string, v *claircore.Vulnerability) (*claircore.Vulnerability, error)
+
+// ApplyPolicy runs the policy "p" over "vs", returning the vulnerabilities
+// to store. The backing array of "vs" is reused.
+func applyPolicy(ctx context.Context, p VulnerabilityPolicy, updater string, vs []*claircore.Vulnerability) ([]*claircore.Vulnerability, error) {
+	out := vs[:0]
+	for _, v := range vs {
+		nv, err := p(ctx, updater, v)
+		if err != nil {
+			return nil, fmt.Errorf("policy error for vulnerability %q: %w", v.Name, err)
+		}
+		if nv != nil {
+			out = append(out, nv)
+		}
+	}
+	// Clear the tail, so dropped vulnerabilities can be collected.
+	for i := len(out); i < len(vs); i++ {
+		vs[i] = nil
+	}
+	zlog.Debug(ctx).
+		Int("dropped", len(vs)-len(out)).
+		Msg("applied vulnerability policy")
+	return out, nil
+}
@@ -0,0 +1,98 @@
+package updates
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/quay/zlog"
+
+	"github.com/quay/claircore"
+	"github.com/quay/claircore/libvuln/driver"
+	"github.com/quay/claircore/libvuln/jsonblob"
+)
+
+// PolicyUpdater is an Updater returning a fixed set of vulnerabilities.
+type policyUpdater struct{}
+
+func (policyUpdater) Name() string { return "policy" }
+
+func (policyUpdater) Fetch(context.Context, driver.Fingerprint) (io.ReadCloser, driver.Fingerprint, error) {
+	return io.NopCloser(strings.NewReader("")), "fp", nil
+}
+
+func (policyUpdater) Parse(context.Context, io.ReadCloser) ([]*claircore.Vulnerability, error) {
+	return []*claircore.Vulnerability{
+		{Name: "CVE-2023-0001", Severity: "Critical", NormalizedSeverity: claircore.Critical},
+		{Name: "CVE-2023-0002", Severity: "High", NormalizedSeverity: claircore.High},
+		{Name: "CVE-2023-0003", Severity: "Low", NormalizedSeverity: claircore.Low},
+	}, nil
+}
+
+func TestVulnerabilityPolicy(t *testing.T) {
+	ctx := zlog.Test(context.Background(), t)
+	run := func(t *testing.T, p VulnerabilityPolicy) (*jsonblob.Store, error) {
+		s, err := jsonblob.New()
+		if err != nil {
+			t.Fatal(err)
+		}
+		m, err := NewManager(ctx, s, NewLocalLockSource(), &http.Client{},
+			WithFactories(map[string]driver.UpdaterSetFactory{}),
+			WithOutOfTree([]driver.Updater{policyUpdater{}}),
+			WithVulnerabilityPolicy(p),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return s, m.Run(ctx)
+	}
+
+	t.Run("Rewrite", func(t *testing.T) {
+		// Drop an accepted risk and downgrade another.
+		p := func(_ context.Context, u string, v *claircore.Vulnerability) (*claircore.Vulnerability, error) {
+			if u != "policy" {
+				t.Errorf("unexpected updater: %q", u)
+			}
+			switch v.Name {
+			case "CVE-2023-0001":
+				return nil, nil
+			case "CVE-2023-0002":
+				v.NormalizedSeverity = claircore.Low
+			}
+			return v, nil
+		}
+		s, err := run(t, p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []*claircore.Vulnerability
+		for _, e := range s.Entries() {
+			got = append(got, e.Vuln...)
+		}
+		want := []*claircore.Vulnerability{
+			{Name: "CVE-2023-0002", Severity: "High", NormalizedSeverity: claircore.Low},
+			{Name: "CVE-2023-0003", Severity: "Low", NormalizedSeverity: claircore.Low},
+		}
+		if !cmp.Equal(got, want) {
+			t.Error(cmp.Diff(got, want))
+		}
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		p := func(_ context.Context, _ string, v *claircore.Vulnerability) (*claircore.Vulnerability, error) {
+			return nil, errors.New("policy unavailable")
+		}
+		s, err := run(t, p)
+		t.Log(err)
+		if err == nil {
+			t.Error("expected error")
+		}
+		if got := len(s.Entries()); got != 0 {
+			t.Errorf("got: %d updates stored, want: 0", got)
+		}
+	})
+}
@@ -1,5 +1,7 @@
 package claircore
 
+import "encoding/json"
+
 // Manifest represents a docker image. Layers array MUST be indexed
 // in the order that image layers are stacked.
 type Manifest struct {
@@ -8,4 +10,19 @@ type Manifest struct {
 	Hash Digest `json:"hash"`
 	// an array of filesystem layers indexed in the same order as the cooresponding image
 	Layers []*Layer `json:"layers"`
+	// the image's configuration blob, as described by the OCI image spec. it's
+	// optional; if provided, the runtime configuration it describes is
+	// recorded in the IndexReport's ImageConfig
+	Config json.RawMessage `json:"config,omitempty"`
+}
+
+// EmbeddedManifest links an image found as a tarball inside a layer to the
+// Manifest it was indexed as.
+type EmbeddedManifest struct {
+	// the hash of the embedded image's Manifest, which has its own IndexReport
+	Manifest Digest `json:"manifest_hash"`
+	// the layer the tarball was found in
+	Layer Digest `json:"layer"`
+	// the path of the tarball inside the layer
+	Path string `json:"path"`
 }
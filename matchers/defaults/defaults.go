@@ -10,9 +10,12 @@ import (
 	"github.com/quay/claircore/aws"
 	"github.com/quay/claircore/crda"
 	"github.com/quay/claircore/debian"
+	"github.com/quay/claircore/gobin"
 	"github.com/quay/claircore/libvuln/driver"
 	"github.com/quay/claircore/matchers/registry"
+	"github.com/quay/claircore/nvd"
 	"github.com/quay/claircore/oracle"
+	"github.com/quay/claircore/ossindex"
 	"github.com/quay/claircore/photon"
 	"github.com/quay/claircore/python"
 	"github.com/quay/claircore/rhel"
@@ -45,10 +48,9 @@ var defaultMatchers = []driver.Matcher{
 	&alpine.Matcher{},
 	&aws.Matcher{},
 	&debian.Matcher{},
+	&gobin.Matcher{},
 	&oracle.Matcher{},
 	&photon.Matcher{},
-	&python.Matcher{},
-	&rhel.Matcher{},
 	&suse.Matcher{},
 	&ubuntu.Matcher{},
 	rhcc.Matcher,
@@ -56,6 +58,10 @@ var defaultMatchers = []driver.Matcher{
 
 func inner(ctx context.Context) error {
 	registry.Register("crda", &crda.Factory{})
+	registry.Register("nvd-cpe", &nvd.MatcherFactory{})
+	registry.Register("ossindex", &ossindex.Factory{})
+	registry.Register("python", &python.MatcherFactory{})
+	registry.Register("rhel", &rhel.MatcherFactory{})
 
 	for _, m := range defaultMatchers {
 		mf := driver.MatcherStatic(m)
@@ -0,0 +1,84 @@
+package nvd
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/quay/zlog"
+
+	"github.com/quay/claircore/libvuln/driver"
+)
+
+var (
+	_ driver.UpdaterSetFactory   = (*Factory)(nil)
+	_ driver.Configurable        = (*Factory)(nil)
+	_ driver.MatcherFactory      = (*MatcherFactory)(nil)
+	_ driver.MatcherConfigurable = (*MatcherFactory)(nil)
+)
+
+// Factory constructs the UpdaterSet containing the Updater.
+//
+// The feeds are large and the match criteria are coarser than the data
+// distributions publish, so the Updater is opt-in: the returned set is empty
+// unless the Factory is configured with "enable" set.
+type Factory struct {
+	enable bool
+}
+
+// Configure implements driver.Configurable.
+func (f *Factory) Configure(ctx context.Context, cf driver.ConfigUnmarshaler, _ *http.Client) error {
+	var cfg Config
+	if err := cf(&cfg); err != nil {
+		return err
+	}
+	f.enable = cfg.Enable
+	return nil
+}
+
+// UpdaterSet implements driver.UpdaterSetFactory.
+func (f *Factory) UpdaterSet(ctx context.Context) (driver.UpdaterSet, error) {
+	if !f.enable {
+		ctx = zlog.ContextWithValues(ctx, "component", "nvd/Factory.UpdaterSet")
+		zlog.Debug(ctx).
+			Msg("not enabled, skipping")
+		return driver.NewUpdaterSet(), nil
+	}
+	return UpdaterSet(ctx)
+}
+
+// MatcherFactory constructs the Matcher.
+//
+// Like the Updater, the Matcher is opt-in: no Matcher is returned unless the
+// MatcherFactory is configured with "enable" set.
+type MatcherFactory struct {
+	enable bool
+}
+
+// MatcherConfig is the configuration accepted by the MatcherFactory.
+//
+// By convention, it's at a key called "nvd-cpe".
+type MatcherConfig struct {
+	// Enable turns on the Matcher.
+	Enable bool `json:"enable" yaml:"enable"`
+}
+
+// Configure implements driver.MatcherConfigurable.
+func (f *MatcherFactory) Configure(ctx context.Context, cf driver.MatcherConfigUnmarshaler, _ *http.Client) error {
+	var cfg MatcherConfig
+	if err := cf(&cfg); err != nil {
+		return err
+	}
+	f.enable = cfg.Enable
+	return nil
+}
+
+// Matcher implements driver.MatcherFactory.
+func (f *MatcherFactory) Matcher(ctx context.Context) ([]driver.Matcher, error) {
+	if !f.enable {
+		ctx = zlog.ContextWithValues(ctx, "component", "nvd/MatcherFactory.Matcher")
+		zlog.Debug(ctx).
+			Msg("not enabled, skipping")
+		return nil, nil
+	}
+	return []driver.Matcher{&Matcher{}}, nil
+}
@@ -0,0 +1,113 @@
+package nvd
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"github.com/quay/claircore"
+	"github.com/quay/claircore/libvuln/driver"
+	"github.com/quay/claircore/pkg/cpe"
+)
+
+// Matcher matches packages with a known CPE against the CPE match criteria
+// recorded by the Updater.
+//
+// Packages only have a CPE if the scanner that found them knows which product
+// they are, so most records are never considered.
+type Matcher struct{}
+
+var _ driver.Matcher = (*Matcher)(nil)
+
+// Name implements [driver.Matcher].
+func (*Matcher) Name() string { return "nvd-cpe" }
+
+// Filter implements [driver.Matcher].
+func (*Matcher) Filter(r *claircore.IndexRecord) bool {
+	return r.Package != nil &&
+		r.Package.CPE.ProductKey() != ""
+}
+
+// Query implements [driver.Matcher].
+func (*Matcher) Query() []driver.MatchConstraint {
+	return []driver.MatchConstraint{driver.PackageCPE}
+}
+
+// Vulnerable implements [driver.Matcher].
+//
+// The package's CPE must be described by the vulnerability's match criteria,
+// and its version must be inside any range the criteria has. If the CPE has
+// no version, the package's version is used in its place.
+func (*Matcher) Vulnerable(ctx context.Context, r *claircore.IndexRecord, v *claircore.Vulnerability) (bool, error) {
+	q, err := url.ParseQuery(v.FixedInVersion)
+	if err != nil {
+		return false, fmt.Errorf("nvd: malformed range %q: %w", v.FixedInVersion, err)
+	}
+	if !q.Has(paramCPE) {
+		return false, nil
+	}
+	crit, err := cpe.Unbind(q.Get(paramCPE))
+	if err != nil {
+		return false, fmt.Errorf("nvd: malformed criteria: %w", err)
+	}
+
+	tgt := r.Package.CPE
+	ver := &tgt.Attr[cpe.Version]
+	if ver.Kind != cpe.ValueSet && r.Package.Version != "" {
+		ver.Kind = cpe.ValueSet
+		ver.V = cpe.Quote(r.Package.Version)
+	}
+	if !cpe.Match(crit, tgt) {
+		return false, nil
+	}
+
+	rs := ranges(q)
+	if len(rs) == 0 {
+		return true, nil
+	}
+	if ver.Kind != cpe.ValueSet {
+		// Can't say anything about a range without a version.
+		return false, nil
+	}
+	pv := cpe.Unquote(ver.V)
+	for _, rg := range rs {
+		c := compareVersion(pv, rg.V)
+		var ok bool
+		switch rg.Param {
+		case paramStartIncluding:
+			ok = c >= 0
+		case paramStartExcluding:
+			ok = c > 0
+		case paramEndIncluding:
+			ok = c <= 0
+		case paramEndExcluding:
+			ok = c < 0
+		}
+		if !ok {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// Bound is a single bound of a version range.
+type bound struct {
+	Param string
+	V     string
+}
+
+// Ranges returns the version bounds present in the query.
+func ranges(q url.Values) []bound {
+	var out []bound
+	for _, p := range []string{
+		paramStartIncluding,
+		paramStartExcluding,
+		paramEndIncluding,
+		paramEndExcluding,
+	} {
+		if v := q.Get(p); v != "" {
+			out = append(out, bound{Param: p, V: v})
+		}
+	}
+	return out
+}
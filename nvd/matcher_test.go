@@ -0,0 +1,154 @@
+package nvd
+
+import (
+	"context"
+	"testing"
+
+	"github.com/quay/zlog"
+
+	"github.com/quay/claircore"
+	"github.com/quay/claircore/pkg/cpe"
+)
+
+func TestVulnerable(t *testing.T) {
+	ctx := zlog.Test(context.Background(), t)
+	criterion := func(c string, bounds ...string) *claircore.Vulnerability {
+		m := cpeMatch{CPE: c}
+		for i := 0; i+1 < len(bounds); i += 2 {
+			switch bounds[i] {
+			case paramStartIncluding:
+				m.StartIncluding = bounds[i+1]
+			case paramStartExcluding:
+				m.StartExcluding = bounds[i+1]
+			case paramEndIncluding:
+				m.EndIncluding = bounds[i+1]
+			case paramEndExcluding:
+				m.EndExcluding = bounds[i+1]
+			}
+		}
+		return &claircore.Vulnerability{
+			Updater:        updaterName,
+			FixedInVersion: m.Encode(),
+		}
+	}
+	record := func(c, v string) *claircore.IndexRecord {
+		return &claircore.IndexRecord{
+			Package: &claircore.Package{
+				Name:    "pkg",
+				Version: v,
+				CPE:     cpe.MustUnbind(c),
+			},
+		}
+	}
+	const (
+		openssl     = `cpe:2.3:a:openssl:openssl:*:*:*:*:*:*:*:*`
+		httpd       = `cpe:2.3:a:apache:http_server:2.4.49:*:*:*:*:*:*:*`
+		httpdAnyVer = `cpe:2.3:a:apache:http_server:*:*:*:*:*:*:*:*`
+	)
+	tt := []struct {
+		Name   string
+		Record *claircore.IndexRecord
+		Vuln   *claircore.Vulnerability
+		Want   bool
+	}{
+		{
+			Name:   "Exact",
+			Record: record(`cpe:2.3:a:apache:http_server:2.4.49:*:*:*:*:*:*:*`, ""),
+			Vuln:   criterion(httpd),
+			Want:   true,
+		},
+		{
+			Name:   "ExactMismatch",
+			Record: record(`cpe:2.3:a:apache:http_server:2.4.50:*:*:*:*:*:*:*`, ""),
+			Vuln:   criterion(httpd),
+			Want:   false,
+		},
+		{
+			Name:   "PackageVersion",
+			Record: record(httpdAnyVer, "2.4.49"),
+			Vuln:   criterion(httpd),
+			Want:   true,
+		},
+		{
+			Name:   "NoVersion",
+			Record: record(httpdAnyVer, ""),
+			Vuln:   criterion(httpd),
+			Want:   false,
+		},
+		{
+			Name:   "AllVersions",
+			Record: record(httpdAnyVer, ""),
+			Vuln:   criterion(httpdAnyVer),
+			Want:   true,
+		},
+		{
+			Name:   "InRange",
+			Record: record(`cpe:2.3:a:openssl:openssl:1.1.1k:*:*:*:*:*:*:*`, ""),
+			Vuln:   criterion(openssl, paramStartIncluding, "1.1.1", paramEndExcluding, "1.1.1l"),
+			Want:   true,
+		},
+		{
+			Name:   "LowerBound",
+			Record: record(`cpe:2.3:a:openssl:openssl:1.1.1:*:*:*:*:*:*:*`, ""),
+			Vuln:   criterion(openssl, paramStartIncluding, "1.1.1", paramEndExcluding, "1.1.1l"),
+			Want:   true,
+		},
+		{
+			Name:   "Fixed",
+			Record: record(`cpe:2.3:a:openssl:openssl:1.1.1l:*:*:*:*:*:*:*`, ""),
+			Vuln:   criterion(openssl, paramStartIncluding, "1.1.1", paramEndExcluding, "1.1.1l"),
+			Want:   false,
+		},
+		{
+			Name:   "BeforeRange",
+			Record: record(`cpe:2.3:a:openssl:openssl:1.0.2u:*:*:*:*:*:*:*`, ""),
+			Vuln:   criterion(openssl, paramStartIncluding, "1.1.1", paramEndExcluding, "1.1.1l"),
+			Want:   false,
+		},
+		{
+			Name:   "EndIncluding",
+			Record: record(`cpe:2.3:a:openssl:openssl:3.0.0:*:*:*:*:*:*:*`, ""),
+			Vuln:   criterion(openssl, paramStartExcluding, "1.1.1", paramEndIncluding, "3.0.0"),
+			Want:   true,
+		},
+		{
+			Name:   "StartExcluding",
+			Record: record(`cpe:2.3:a:openssl:openssl:1.1.1:*:*:*:*:*:*:*`, ""),
+			Vuln:   criterion(openssl, paramStartExcluding, "1.1.1", paramEndIncluding, "3.0.0"),
+			Want:   false,
+		},
+		{
+			Name:   "RangeWithoutVersion",
+			Record: record(`cpe:2.3:a:openssl:openssl:*:*:*:*:*:*:*:*`, ""),
+			Vuln:   criterion(openssl, paramEndExcluding, "1.1.1l"),
+			Want:   false,
+		},
+		{
+			Name:   "OtherProduct",
+			Record: record(`cpe:2.3:a:apache:tomcat:2.4.49:*:*:*:*:*:*:*`, ""),
+			Vuln:   criterion(httpd),
+			Want:   false,
+		},
+		{
+			Name:   "OtherUpdater",
+			Record: record(`cpe:2.3:a:apache:http_server:2.4.49:*:*:*:*:*:*:*`, ""),
+			Vuln:   &claircore.Vulnerability{FixedInVersion: "2.4.50"},
+			Want:   false,
+		},
+	}
+	m := &Matcher{}
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			if !m.Filter(tc.Record) {
+				t.Fatal("record filtered out")
+			}
+			got, err := m.Vulnerable(ctx, tc.Record, tc.Vuln)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := tc.Want; got != want {
+				t.Errorf("got: %v, want: %v", got, want)
+			}
+		})
+	}
+}
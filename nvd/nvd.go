@@ -0,0 +1,96 @@
+// Package nvd matches packages against the CPE match criteria published by
+// the National Vulnerability Database.
+//
+// The NVD describes the software a CVE affects as CPE match criteria: a CPE,
+// possibly containing wildcards, and an optional version range. The Updater
+// records every vulnerable criterion against the product it names, and the
+// Matcher compares the CPE of a detected product against the criteria using
+// the CPE name matching rules and a CPE-aware version comparison.
+package nvd
+
+// DefaultFeeds is the default place to look for CVE feeds.
+//
+// The Updater expects the structure to mirror that found here: files
+// organized by year, prefixed with `nvdcve-1.1-` and with `.meta` and
+// `.json.gz` extensions.
+//
+//doc:url updater
+const DefaultFeeds = `https://nvd.nist.gov/feeds/json/cve/1.1/`
+
+const (
+	updaterName = `nvd`
+
+	// First year for the yearly CVE feeds: https://nvd.nist.gov/vuln/data-feeds
+	firstYear = 2002
+
+	// TimeFormat is the layout of timestamps in the feeds, which lack
+	// seconds.
+	timeFormat = `2006-01-02T15:04Z07:00`
+)
+
+// These are the URL query parameters used to encode a match criterion in a
+// vulnerability's FixedInVersion field. The range parameters use the same
+// names as the feed.
+const (
+	paramCPE            = `cpe`
+	paramStartIncluding = `versionStartIncluding`
+	paramStartExcluding = `versionStartExcluding`
+	paramEndIncluding   = `versionEndIncluding`
+	paramEndExcluding   = `versionEndExcluding`
+)
+
+// CveFeed is the envelope of a yearly feed.
+type cveFeed struct {
+	Items []item `json:"CVE_Items"`
+}
+
+// Item is the subset of a CVE item the Updater uses.
+//
+// The field names and tags mirror the feed, so items can be round-tripped
+// through the spool file.
+type item struct {
+	CVE struct {
+		Meta struct {
+			ID string `json:"ID"`
+		} `json:"CVE_data_meta"`
+		References struct {
+			Data []struct {
+				URL string `json:"url"`
+			} `json:"reference_data"`
+		} `json:"references"`
+		Description struct {
+			Data []struct {
+				Lang  string `json:"lang"`
+				Value string `json:"value"`
+			} `json:"description_data"`
+		} `json:"description"`
+	} `json:"cve"`
+	Configurations struct {
+		Nodes []node `json:"nodes"`
+	} `json:"configurations"`
+	Impact struct {
+		V3 struct {
+			CVSS struct {
+				BaseSeverity string `json:"baseSeverity"`
+			} `json:"cvssV3"`
+		} `json:"baseMetricV3"`
+	} `json:"impact"`
+	Published string `json:"publishedDate"`
+}
+
+// Node is a node in a configuration tree.
+type node struct {
+	Operator string     `json:"operator"`
+	Children []node     `json:"children,omitempty"`
+	Match    []cpeMatch `json:"cpe_match,omitempty"`
+}
+
+// CpeMatch is a single match criterion.
+type cpeMatch struct {
+	Vulnerable     bool   `json:"vulnerable"`
+	CPE            string `json:"cpe23Uri"`
+	StartIncluding string `json:"versionStartIncluding,omitempty"`
+	StartExcluding string `json:"versionStartExcluding,omitempty"`
+	EndIncluding   string `json:"versionEndIncluding,omitempty"`
+	EndExcluding   string `json:"versionEndExcluding,omitempty"`
+}
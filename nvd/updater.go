@@ -0,0 +1,352 @@
+package nvd
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/quay/zlog"
+
+	"github.com/quay/claircore"
+	"github.com/quay/claircore/libvuln/driver"
+	"github.com/quay/claircore/pkg/cpe"
+	"github.com/quay/claircore/pkg/tmp"
+)
+
+var (
+	_ driver.Updater      = (*Updater)(nil)
+	_ driver.Configurable = (*Updater)(nil)
+)
+
+// Updater ingests the CPE match criteria from the NVD's yearly JSON feeds.
+//
+// Every vulnerable criterion becomes a vulnerability recorded against the
+// criterion's product, with the criterion and its version range encoded as
+// URL query parameters in the FixedInVersion field.
+//
+// Configure must be called before any other methods.
+type Updater struct {
+	c    *http.Client
+	feed *url.URL
+}
+
+// Config is the configuration for Updater.
+//
+// By convention, it's at a key called "nvd".
+type Config struct {
+	// FeedRoot is the URL of a directory laid out like DefaultFeeds.
+	FeedRoot *string `json:"feed_root" yaml:"feed_root"`
+	// Enable turns on the Updater when it's constructed by a Factory.
+	Enable bool `json:"enable" yaml:"enable"`
+}
+
+// UpdaterSet returns an UpdaterSet containing the Updater.
+func UpdaterSet(_ context.Context) (driver.UpdaterSet, error) {
+	us := driver.NewUpdaterSet()
+	if err := us.Add(&Updater{}); err != nil {
+		return us, err
+	}
+	return us, nil
+}
+
+// Name implements [driver.Updater].
+func (*Updater) Name() string { return updaterName }
+
+// Configure implements [driver.Configurable].
+func (u *Updater) Configure(ctx context.Context, f driver.ConfigUnmarshaler, c *http.Client) error {
+	var cfg Config
+	u.c = c
+	if err := f(&cfg); err != nil {
+		return err
+	}
+	root := DefaultFeeds
+	if cfg.FeedRoot != nil {
+		if !strings.HasSuffix(*cfg.FeedRoot, "/") {
+			return fmt.Errorf("URL missing trailing slash: %q", *cfg.FeedRoot)
+		}
+		root = *cfg.FeedRoot
+	}
+	var err error
+	u.feed, err = url.Parse(root)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// Fetch implements [driver.Updater].
+//
+// The fingerprint is the checksum of every yearly feed, and nothing is
+// fetched unless one of them has changed. The returned data is the relevant
+// parts of every CVE item, one JSON object per item.
+func (u *Updater) Fetch(ctx context.Context, hint driver.Fingerprint) (io.ReadCloser, driver.Fingerprint, error) {
+	ctx = zlog.ContextWithValues(ctx, "component", "nvd/Updater.Fetch")
+
+	// year → sha256
+	prev := make(map[int]string)
+	if err := json.Unmarshal([]byte(hint), &prev); err != nil && hint != "" {
+		return nil, driver.Fingerprint(""), err
+	}
+	cur := make(map[int]string, len(prev))
+	var yrs []int
+	changed := false
+	for y, lim := firstYear, time.Now().Year(); y <= lim; y++ {
+		yrs = append(yrs, y)
+		sum, err := u.checksum(ctx, y)
+		if err != nil {
+			return nil, hint, err
+		}
+		cur[y] = sum
+		if prev[y] != sum {
+			changed = true
+		}
+	}
+	if !changed {
+		return nil, hint, driver.Unchanged
+	}
+
+	out, err := tmp.NewFile("", "nvd.")
+	if err != nil {
+		return nil, hint, err
+	}
+	var success bool
+	defer func() {
+		if !success {
+			if err := out.Close(); err != nil {
+				zlog.Warn(ctx).Err(err).Msg("unable to close spool")
+			}
+		}
+	}()
+	enc := json.NewEncoder(out)
+	for _, y := range yrs {
+		if err := u.fetchYear(ctx, y, enc); err != nil {
+			return nil, hint, fmt.Errorf("nvd: unable to fetch feed for %d: %w", y, err)
+		}
+	}
+	if _, err := out.Seek(0, io.SeekStart); err != nil {
+		return nil, hint, fmt.Errorf("nvd: unable to reset spool: %w", err)
+	}
+	success = true
+
+	nh, err := json.Marshal(cur)
+	if err != nil {
+		panic(fmt.Errorf("unable to serialize new hint: %w", err))
+	}
+	return out, driver.Fingerprint(nh), nil
+}
+
+// Checksum returns the SHA256 reported in the meta file for the year's feed.
+func (u *Updater) checksum(ctx context.Context, y int) (string, error) {
+	m, err := u.feed.Parse(fmt.Sprintf("nvdcve-1.1-%d.meta", y))
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, m.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	res, err := u.c.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("nvd: unexpected response for %q: %s", m, res.Status)
+	}
+	s := bufio.NewScanner(res.Body)
+	for s.Scan() {
+		k, v, ok := strings.Cut(strings.TrimSpace(s.Text()), ":")
+		if ok && k == "sha256" {
+			return strings.ToUpper(v), nil
+		}
+	}
+	if err := s.Err(); err != nil {
+		return "", err
+	}
+	return "", fmt.Errorf("nvd: no checksum in %q", m)
+}
+
+// FetchYear fetches the year's feed and writes the items to the encoder.
+func (u *Updater) fetchYear(ctx context.Context, y int, enc *json.Encoder) error {
+	f, err := u.feed.Parse(fmt.Sprintf("nvdcve-1.1-%d.json.gz", y))
+	if err != nil {
+		return err
+	}
+	zlog.Debug(ctx).
+		Int("year", y).
+		Stringer("url", f).
+		Msg("requesting json")
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, f.String(), nil)
+	if err != nil {
+		return err
+	}
+	res, err := u.c.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected response: %s", res.Status)
+	}
+	gz, err := gzip.NewReader(res.Body)
+	if err != nil {
+		return err
+	}
+	defer gz.Close()
+	var feed cveFeed
+	if err := json.NewDecoder(gz).Decode(&feed); err != nil {
+		return err
+	}
+	for i := range feed.Items {
+		if err := enc.Encode(&feed.Items[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Parse implements [driver.Updater].
+func (u *Updater) Parse(ctx context.Context, rc io.ReadCloser) ([]*claircore.Vulnerability, error) {
+	ctx = zlog.ContextWithValues(ctx, "component", "nvd/Updater.Parse")
+	defer rc.Close()
+	var out []*claircore.Vulnerability
+	var skip int
+	dec := json.NewDecoder(rc)
+	for {
+		var it item
+		err := dec.Decode(&it)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("nvd: unable to decode item: %w", err)
+		}
+		vs, n := it.Vulnerabilities(ctx)
+		out = append(out, vs...)
+		skip += n
+	}
+	zlog.Debug(ctx).
+		Int("count", len(out)).
+		Int("skipped", skip).
+		Msg("parsed vulnerabilities")
+	return out, nil
+}
+
+// Vulnerabilities returns a vulnerability for every vulnerable match
+// criterion in the item, and the number of criteria that couldn't be used.
+//
+// Configurations that combine nodes with "AND" (for example, an application
+// only being vulnerable on a specific platform) are flattened, so the
+// vulnerable criteria are reported regardless of the other nodes.
+func (it *item) Vulnerabilities(ctx context.Context) ([]*claircore.Vulnerability, int) {
+	desc := ""
+	for _, d := range it.CVE.Description.Data {
+		if d.Lang == "en" {
+			desc = d.Value
+			break
+		}
+	}
+	links := make([]string, 0, len(it.CVE.References.Data))
+	for _, r := range it.CVE.References.Data {
+		links = append(links, r.URL)
+	}
+	sev := it.Impact.V3.CVSS.BaseSeverity
+	var issued time.Time
+	if t, err := time.Parse(timeFormat, it.Published); err == nil {
+		issued = t
+	}
+
+	var out []*claircore.Vulnerability
+	var skip int
+	seen := make(map[string]struct{})
+	var walk func([]node)
+	walk = func(ns []node) {
+		for i := range ns {
+			n := &ns[i]
+			walk(n.Children)
+			for _, m := range n.Match {
+				if !m.Vulnerable {
+					continue
+				}
+				wfn, err := cpe.Unbind(m.CPE)
+				if err != nil {
+					zlog.Debug(ctx).
+						Err(err).
+						Str("cve", it.CVE.Meta.ID).
+						Str("cpe", m.CPE).
+						Msg("skipping malformed criterion")
+					skip++
+					continue
+				}
+				key := wfn.ProductKey()
+				if key == "" {
+					skip++
+					continue
+				}
+				fv := m.Encode()
+				if _, ok := seen[fv]; ok {
+					continue
+				}
+				seen[fv] = struct{}{}
+				out = append(out, &claircore.Vulnerability{
+					Updater:            updaterName,
+					Name:               it.CVE.Meta.ID,
+					Description:        desc,
+					Issued:             issued,
+					Links:              strings.Join(links, " "),
+					Severity:           sev,
+					NormalizedSeverity: normalizeSeverity(sev),
+					Package: &claircore.Package{
+						Name: key,
+						Kind: claircore.BINARY,
+					},
+					FixedInVersion: fv,
+				})
+			}
+		}
+	}
+	walk(it.Configurations.Nodes)
+	return out, skip
+}
+
+// Encode returns the criterion and any version range as URL query
+// parameters.
+func (m *cpeMatch) Encode() string {
+	v := url.Values{}
+	v.Set(paramCPE, m.CPE)
+	for _, p := range []struct {
+		K, V string
+	}{
+		{paramStartIncluding, m.StartIncluding},
+		{paramStartExcluding, m.StartExcluding},
+		{paramEndIncluding, m.EndIncluding},
+		{paramEndExcluding, m.EndExcluding},
+	} {
+		if p.V != "" {
+			v.Set(p.K, p.V)
+		}
+	}
+	return v.Encode()
+}
+
+func normalizeSeverity(s string) claircore.Severity {
+	switch strings.ToUpper(s) {
+	case "LOW":
+		return claircore.Low
+	case "MEDIUM":
+		return claircore.Medium
+	case "HIGH":
+		return claircore.High
+	case "CRITICAL":
+		return claircore.Critical
+	}
+	return claircore.Unknown
+}
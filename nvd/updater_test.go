@@ -0,0 +1,118 @@
+package nvd
+
+import (
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/quay/zlog"
+
+	"github.com/quay/claircore"
+	"github.com/quay/claircore/libvuln/driver"
+)
+
+// MockServer serves the feed in testdata as the 2021 feed, and empty feeds
+// for every other year.
+func mockServer(t *testing.T) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := path.Base(r.URL.Path)
+		is2021 := strings.HasPrefix(name, "nvdcve-1.1-2021.")
+		switch path.Ext(name) {
+		case ".gz":
+			gz := gzip.NewWriter(w)
+			defer gz.Close()
+			if !is2021 {
+				io.WriteString(gz, `{"CVE_Items":[]}`)
+				return
+			}
+			f, err := os.Open("testdata/feed.json")
+			if err != nil {
+				t.Errorf("open failed: %v", err)
+				return
+			}
+			defer f.Close()
+			if _, err := io.Copy(gz, f); err != nil {
+				t.Errorf("write error: %v", err)
+			}
+		case ".meta":
+			fmt.Fprintf(w, "lastModifiedDate:2021-10-08T03:00:00-04:00\r\nsha256:%x\r\n", name)
+		default:
+			t.Errorf("unknown request path: %q", r.URL.Path)
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestUpdater(t *testing.T) {
+	ctx := zlog.Test(context.Background(), t)
+	srv := mockServer(t)
+	u := &Updater{}
+	f := func(i interface{}) error {
+		cfg := i.(*Config)
+		root := srv.URL + "/"
+		cfg.FeedRoot = &root
+		return nil
+	}
+	if err := u.Configure(ctx, f, srv.Client()); err != nil {
+		t.Fatal(err)
+	}
+
+	rc, fp, err := u.Fetch(ctx, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	vs, err := u.Parse(ctx, rc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []*claircore.Vulnerability{
+		{
+			Updater:            updaterName,
+			Name:               "CVE-2021-41773",
+			Description:        "A flaw was found in a change made to path normalization in Apache HTTP Server 2.4.49.",
+			Issued:             time.Date(2021, 10, 5, 9, 15, 0, 0, time.UTC),
+			Links:              "https://httpd.apache.org/security/vulnerabilities_24.html",
+			Severity:           "HIGH",
+			NormalizedSeverity: claircore.High,
+			Package: &claircore.Package{
+				Name: "cpe:2.3:a:apache:http_server",
+				Kind: claircore.BINARY,
+			},
+			FixedInVersion: "cpe=cpe%3A2.3%3Aa%3Aapache%3Ahttp_server%3A2.4.49%3A%2A%3A%2A%3A%2A%3A%2A%3A%2A%3A%2A%3A%2A",
+		},
+		{
+			Updater:            updaterName,
+			Name:               "CVE-2021-3711",
+			Description:        "A bug in the implementation of the SM2 decryption code means that the calculation of the buffer size may be up to 62 bytes too small.",
+			Issued:             time.Date(2021, 8, 24, 15, 15, 0, 0, time.UTC),
+			Links:              "https://www.openssl.org/news/secadv/20210824.txt",
+			Severity:           "CRITICAL",
+			NormalizedSeverity: claircore.Critical,
+			Package: &claircore.Package{
+				Name: "cpe:2.3:a:openssl:openssl",
+				Kind: claircore.BINARY,
+			},
+			FixedInVersion: "cpe=cpe%3A2.3%3Aa%3Aopenssl%3Aopenssl%3A%2A%3A%2A%3A%2A%3A%2A%3A%2A%3A%2A%3A%2A%3A%2A&versionEndExcluding=1.1.1l&versionStartIncluding=1.1.1",
+		},
+	}
+	if !cmp.Equal(vs, want) {
+		t.Error(cmp.Diff(vs, want))
+	}
+
+	_, _, err = u.Fetch(ctx, fp)
+	if !errors.Is(err, driver.Unchanged) {
+		t.Errorf("got: %v, want: %v", err, driver.Unchanged)
+	}
+}
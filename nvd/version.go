@@ -0,0 +1,86 @@
+package nvd
+
+import (
+	"strings"
+)
+
+// CompareVersion compares two version strings as they appear in CPEs and NVD
+// match ranges, returning -1, 0, or 1 like [strings.Compare].
+//
+// There's no versioning scheme mandated for CPEs, so this is a best-effort
+// comparison: versions are split into segments on any non-alphanumeric
+// character, and segments are split into runs of digits and letters. Digit
+// runs compare numerically, letter runs compare case-insensitively, and a
+// digit run sorts after a letter run. A version with additional runs sorts
+// after its prefix, so "1.0.2k" is after "1.0.2".
+func compareVersion(a, b string) int {
+	as, bs := versionRuns(a), versionRuns(b)
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		if c := compareRun(as[i], bs[i]); c != 0 {
+			return c
+		}
+	}
+	switch {
+	case len(as) < len(bs):
+		return -1
+	case len(as) > len(bs):
+		return 1
+	}
+	return 0
+}
+
+// VersionRuns splits a version string into digit and letter runs, discarding
+// all separators.
+func versionRuns(v string) []string {
+	var out []string
+	start, digit := -1, false
+	for i, r := range v {
+		isDigit := r >= '0' && r <= '9'
+		isAlpha := (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z')
+		switch {
+		case !isDigit && !isAlpha:
+			if start != -1 {
+				out = append(out, v[start:i])
+				start = -1
+			}
+		case start == -1:
+			start, digit = i, isDigit
+		case isDigit != digit:
+			out = append(out, v[start:i])
+			start, digit = i, isDigit
+		}
+	}
+	if start != -1 {
+		out = append(out, v[start:])
+	}
+	return out
+}
+
+// CompareRun compares a single digit or letter run.
+func compareRun(a, b string) int {
+	ad, bd := isNumeric(a), isNumeric(b)
+	switch {
+	case ad && bd:
+		a, b = strings.TrimLeft(a, "0"), strings.TrimLeft(b, "0")
+		// Comparing lengths first means there's no need to parse the
+		// numbers, which may overflow.
+		if len(a) != len(b) {
+			if len(a) < len(b) {
+				return -1
+			}
+			return 1
+		}
+		return strings.Compare(a, b)
+	case ad:
+		return 1
+	case bd:
+		return -1
+	}
+	return strings.Compare(strings.ToLower(a), strings.ToLower(b))
+}
+
+// IsNumeric reports whether the run is a digit run. Runs are never empty and
+// never mixed, so only the first byte needs to be checked.
+func isNumeric(s string) bool {
+	return s[0] >= '0' && s[0] <= '9'
+}
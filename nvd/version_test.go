@@ -0,0 +1,33 @@
+package nvd
+
+import "testing"
+
+func TestCompareVersion(t *testing.T) {
+	tt := []struct {
+		A, B string
+		Want int
+	}{
+		{"1.0", "1.0", 0},
+		{"1.0", "1.1", -1},
+		{"1.10", "1.9", 1},
+		{"2.4.49", "2.4.50", -1},
+		{"1.1.1k", "1.1.1l", -1},
+		{"1.1.1", "1.1.1a", -1},
+		{"1.0.2", "1.0.10", -1},
+		{"1.0.01", "1.0.1", 0},
+		{"9.0.0.M1", "9.0.0", 1},
+		{"1.0-rc1", "1.0.1", -1},
+		{"8.5.72", "10.0.0", -1},
+		{"1.2.3", "1.2.3_rc1", -1},
+		{"2023.10", "2023.9", 1},
+		{"1.0A", "1.0a", 0},
+	}
+	for _, tc := range tt {
+		if got, want := compareVersion(tc.A, tc.B), tc.Want; got != want {
+			t.Errorf("%q <=> %q: got: %d, want: %d", tc.A, tc.B, got, want)
+		}
+		if got, want := compareVersion(tc.B, tc.A), -tc.Want; got != want {
+			t.Errorf("%q <=> %q: got: %d, want: %d", tc.B, tc.A, got, want)
+		}
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"path/filepath"
 	"runtime/trace"
 	"testing"
+	"testing/fstest"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/quay/zlog"
@@ -187,3 +188,27 @@ func TestLayer(t *testing.T) {
 		t.Run(tc.Name, tc.Test)
 	}
 }
+
+func TestLayerFS(t *testing.T) {
+	t.Parallel()
+	ctx := zlog.Test(context.Background(), t)
+	b, err := os.ReadFile(filepath.Join("testdata", "alpine"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var l claircore.Layer
+	l.SetFS(fstest.MapFS{
+		"etc/os-release": &fstest.MapFile{Data: b, Mode: 0o644},
+	})
+
+	ds, err := (&Scanner{}).Scan(ctx, &l)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := len(ds), 1; got != want {
+		t.Fatalf("got: %d distributions, want: %d", got, want)
+	}
+	if got, want := ds[0].DID, "alpine"; got != want {
+		t.Errorf("got: %q, want: %q", got, want)
+	}
+}
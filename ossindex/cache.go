@@ -0,0 +1,65 @@
+package ossindex
+
+import (
+	"sync"
+	"time"
+)
+
+// Cache holds component report results, keyed by lowercased coordinate, for
+// a fixed time.
+type cache struct {
+	ttl time.Duration
+
+	mu    sync.Mutex
+	m     map[string]cacheEntry
+	sweep time.Time
+}
+
+type cacheEntry struct {
+	expires time.Time
+	vulns   []componentVuln
+}
+
+func newCache(ttl time.Duration) *cache {
+	return &cache{
+		ttl: ttl,
+		m:   make(map[string]cacheEntry),
+	}
+}
+
+// Get returns the cached vulnerabilities for the coordinate "k", reporting
+// whether there was an unexpired entry as of "now".
+func (c *cache) Get(k string, now time.Time) ([]componentVuln, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.m[k]
+	if !ok {
+		return nil, false
+	}
+	if !now.Before(e.expires) {
+		delete(c.m, k)
+		return nil, false
+	}
+	return e.vulns, true
+}
+
+// Put caches the vulnerabilities for the coordinate "k" as of "now".
+//
+// Expired entries are removed at most once per TTL, so entries for packages
+// that are never asked about again don't accumulate.
+func (c *cache) Put(k string, vs []componentVuln, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if now.After(c.sweep) {
+		for k, e := range c.m {
+			if !now.Before(e.expires) {
+				delete(c.m, k)
+			}
+		}
+		c.sweep = now.Add(c.ttl)
+	}
+	c.m[k] = cacheEntry{
+		expires: now.Add(c.ttl),
+		vulns:   vs,
+	}
+}
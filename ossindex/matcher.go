@@ -0,0 +1,297 @@
+package ossindex
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/quay/zlog"
+
+	"github.com/quay/claircore"
+	"github.com/quay/claircore/libvuln/driver"
+)
+
+var (
+	_ driver.Matcher       = (*Matcher)(nil)
+	_ driver.RemoteMatcher = (*Matcher)(nil)
+)
+
+// Matcher looks up language packages in the OSS Index.
+//
+// Results are cached per package URL, including packages with no known
+// vulnerabilities, so repeated matches of the same packages don't count
+// against the API's rate limit.
+type Matcher struct {
+	c     *http.Client
+	api   *url.URL
+	user  string
+	token string
+	cache *cache
+}
+
+func newMatcher(c *http.Client, cfg Config) (*Matcher, error) {
+	u := DefaultURL
+	if cfg.URL != "" {
+		u = cfg.URL
+	}
+	api, err := url.Parse(u)
+	if err != nil {
+		return nil, fmt.Errorf("ossindex: bad URL: %w", err)
+	}
+	ttl := DefaultTTL
+	if cfg.CacheTTL != 0 {
+		ttl = cfg.CacheTTL
+	}
+	if c == nil {
+		c = http.DefaultClient
+	}
+	return &Matcher{
+		c:     c,
+		api:   api,
+		user:  cfg.Username,
+		token: cfg.Token,
+		cache: newCache(ttl),
+	}, nil
+}
+
+// Name implements driver.Matcher.
+func (*Matcher) Name() string { return name }
+
+// Filter implements driver.Matcher.
+//
+// Only packages with a version and a package URL type the OSS Index knows
+// about are looked up.
+func (*Matcher) Filter(r *claircore.IndexRecord) bool {
+	return r.Package != nil && r.Package.Version != "" && coordinate(r) != ""
+}
+
+// Query implements driver.Matcher.
+func (*Matcher) Query() []driver.MatchConstraint {
+	panic("unreachable")
+}
+
+// Vulnerable implements driver.Matcher.
+func (*Matcher) Vulnerable(_ context.Context, _ *claircore.IndexRecord, _ *claircore.Vulnerability) (bool, error) {
+	panic("unreachable")
+}
+
+// Types are the package URL types looked up in the OSS Index.
+var types = map[string]struct{}{
+	"gem":    {},
+	"golang": {},
+	"maven":  {},
+	"npm":    {},
+	"pypi":   {},
+}
+
+// Coordinate returns the coordinate the OSS Index uses for the record's
+// package: its package URL without qualifiers. The empty string is returned
+// for packages that aren't looked up.
+func coordinate(r *claircore.IndexRecord) string {
+	p := r.PURL()
+	t, _, ok := strings.Cut(strings.TrimPrefix(p, "pkg:"), "/")
+	if !ok {
+		return ""
+	}
+	if _, ok := types[t]; !ok {
+		return ""
+	}
+	p, _, _ = strings.Cut(p, "?")
+	return p
+}
+
+// QueryRemoteMatcher implements driver.RemoteMatcher.
+//
+// Errors talking to the API are logged, and the affected packages are
+// reported as having no vulnerabilities.
+func (m *Matcher) QueryRemoteMatcher(ctx context.Context, records []*claircore.IndexRecord) (map[string][]*claircore.Vulnerability, error) {
+	ctx = zlog.ContextWithValues(ctx, "component", "ossindex/Matcher.QueryRemoteMatcher")
+
+	// Coordinates are compared case-insensitively, as the API may return a
+	// different spelling than was asked for.
+	byCoord := make(map[string][]*claircore.IndexRecord)
+	for _, r := range records {
+		c := coordinate(r)
+		if c == "" {
+			continue
+		}
+		k := strings.ToLower(c)
+		byCoord[k] = append(byCoord[k], r)
+	}
+
+	now := time.Now()
+	found := make(map[string][]componentVuln, len(byCoord))
+	var miss []string
+	for k := range byCoord {
+		if vs, ok := m.cache.Get(k, now); ok {
+			found[k] = vs
+			continue
+		}
+		miss = append(miss, k)
+	}
+	sort.Strings(miss)
+	zlog.Debug(ctx).
+		Int("packages", len(byCoord)).
+		Int("cached", len(found)).
+		Msg("looking up packages")
+
+	for start := 0; start < len(miss); start += batchSize {
+		end := start + batchSize
+		if end > len(miss) {
+			end = len(miss)
+		}
+		batch := miss[start:end]
+		reps, err := m.report(ctx, batch)
+		if err != nil {
+			zlog.Error(ctx).
+				Err(err).
+				Int("count", len(batch)).
+				Msg("remote api call failure")
+			continue
+		}
+		for _, k := range batch {
+			// Packages without a report entry have no known
+			// vulnerabilities.
+			vs := reps[k]
+			found[k] = vs
+			m.cache.Put(k, vs, now)
+		}
+	}
+
+	out := make(map[string][]*claircore.Vulnerability)
+	for k, vs := range found {
+		for _, r := range byCoord[k] {
+			for i := range vs {
+				out[r.Package.ID] = append(out[r.Package.ID], vs[i].Vulnerability(k, r))
+			}
+		}
+	}
+	zlog.Debug(ctx).
+		Int("packages", len(out)).
+		Msg("found vulnerable packages")
+	return out, nil
+}
+
+// Report asks the API for the component reports for the coordinates,
+// returning the vulnerabilities keyed by lowercased coordinate.
+func (m *Matcher) report(ctx context.Context, coords []string) (map[string][]componentVuln, error) {
+	b, err := json.Marshal(&reportRequest{Coordinates: coords})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, m.api.String(), bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(m.user, m.token)
+	res, err := m.c.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		var buf bytes.Buffer
+		buf.ReadFrom(&io.LimitedReader{R: res.Body, N: 256})
+		return nil, fmt.Errorf("ossindex: unexpected response: %q (body: %q)", res.Status, buf.String())
+	}
+	var reps []componentReport
+	if err := json.NewDecoder(res.Body).Decode(&reps); err != nil {
+		return nil, fmt.Errorf("ossindex: unable to decode response: %w", err)
+	}
+	out := make(map[string][]componentVuln, len(reps))
+	for _, r := range reps {
+		k := strings.ToLower(r.Coordinates)
+		out[k] = append(out[k], r.Vulnerabilities...)
+	}
+	return out, nil
+}
+
+// ReportRequest is the body of a component-report request.
+type reportRequest struct {
+	Coordinates []string `json:"coordinates"`
+}
+
+// ComponentReport is the subset of a component report the Matcher uses.
+type componentReport struct {
+	Coordinates     string          `json:"coordinates"`
+	Reference       string          `json:"reference"`
+	Vulnerabilities []componentVuln `json:"vulnerabilities"`
+}
+
+// ComponentVuln is a vulnerability in a component report.
+type componentVuln struct {
+	ID                 string   `json:"id"`
+	DisplayName        string   `json:"displayName"`
+	Title              string   `json:"title"`
+	Description        string   `json:"description"`
+	CVSSScore          float64  `json:"cvssScore"`
+	CVSSVector         string   `json:"cvssVector"`
+	CVE                string   `json:"cve"`
+	Reference          string   `json:"reference"`
+	ExternalReferences []string `json:"externalReferences"`
+}
+
+// Vulnerability returns the vulnerability for the record's package, whose
+// coordinate is "coord".
+//
+// Like vulnerabilities from the database, the returned vulnerability is
+// specific to a package, so its ID includes the coordinate.
+func (v *componentVuln) Vulnerability(coord string, r *claircore.IndexRecord) *claircore.Vulnerability {
+	n := v.DisplayName
+	if n == "" {
+		n = v.CVE
+	}
+	if n == "" {
+		n = v.ID
+	}
+	desc := v.Description
+	if desc == "" {
+		desc = v.Title
+	}
+	links := make([]string, 0, len(v.ExternalReferences)+1)
+	if v.Reference != "" {
+		links = append(links, v.Reference)
+	}
+	links = append(links, v.ExternalReferences...)
+	sev := v.CVSSVector
+	if sev == "" && v.CVSSScore != 0 {
+		sev = strconv.FormatFloat(v.CVSSScore, 'f', 1, 64)
+	}
+	return &claircore.Vulnerability{
+		ID:                 name + "-" + v.ID + "-" + coord,
+		Updater:            name,
+		Name:               n,
+		Description:        desc,
+		Links:              strings.Join(links, " "),
+		Severity:           sev,
+		NormalizedSeverity: normalizeSeverity(v.CVSSScore),
+		Package:            r.Package,
+		Repo:               r.Repository,
+	}
+}
+
+// NormalizeSeverity maps a CVSS score to a Severity, using the CVSS v3
+// qualitative rating scale.
+func normalizeSeverity(score float64) claircore.Severity {
+	switch {
+	case score >= 9.0:
+		return claircore.Critical
+	case score >= 7.0:
+		return claircore.High
+	case score >= 4.0:
+		return claircore.Medium
+	case score > 0:
+		return claircore.Low
+	}
+	return claircore.Unknown
+}
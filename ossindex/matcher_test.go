@@ -0,0 +1,277 @@
+package ossindex
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/quay/zlog"
+
+	"github.com/quay/claircore"
+)
+
+// MockAPI serves component reports for the coordinates in Vulns, checking
+// the credentials.
+type mockAPI struct {
+	t     *testing.T
+	Vulns map[string][]componentVuln
+	Fail  bool
+	// Asked holds every coordinate requested.
+	Asked    []string
+	requests int32
+}
+
+func (m *mockAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	atomic.AddInt32(&m.requests, 1)
+	if u, p, ok := r.BasicAuth(); !ok || u != "user@example.com" || p != "token" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	if m.Fail {
+		w.WriteHeader(http.StatusTooManyRequests)
+		return
+	}
+	var req reportRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		m.t.Error(err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	if len(req.Coordinates) > batchSize {
+		m.t.Errorf("too many coordinates: %d", len(req.Coordinates))
+	}
+	m.Asked = append(m.Asked, req.Coordinates...)
+	var out []componentReport
+	for _, c := range req.Coordinates {
+		out = append(out, componentReport{
+			Coordinates:     c,
+			Vulnerabilities: m.Vulns[c],
+		})
+	}
+	if err := json.NewEncoder(w).Encode(out); err != nil {
+		m.t.Error(err)
+	}
+}
+
+func newTestMatcher(t *testing.T, ctx context.Context, api *mockAPI) *Matcher {
+	t.Helper()
+	srv := httptest.NewServer(api)
+	t.Cleanup(srv.Close)
+	f := &Factory{}
+	err := f.Configure(ctx, func(v interface{}) error {
+		cfg := v.(*Config)
+		cfg.URL = srv.URL + "/api/v3/component-report"
+		cfg.Username = "user@example.com"
+		cfg.Token = "token"
+		return nil
+	}, srv.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ms, err := f.Matcher(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ms) != 1 {
+		t.Fatalf("got: %d matchers, want: 1", len(ms))
+	}
+	return ms[0].(*Matcher)
+}
+
+var (
+	maven = &claircore.Repository{Name: "maven", URI: "https://repo1.maven.apache.org/maven2"}
+	log4j = &claircore.IndexRecord{
+		Package: &claircore.Package{
+			ID:      "1",
+			Name:    "org.apache.logging.log4j:log4j-core",
+			Version: "2.14.1",
+			Kind:    claircore.BINARY,
+		},
+		Repository: maven,
+	}
+	log4jAgain = &claircore.IndexRecord{
+		Package: &claircore.Package{
+			ID:      "2",
+			Name:    "org.apache.logging.log4j:log4j-core",
+			Version: "2.14.1",
+			Kind:    claircore.BINARY,
+		},
+		Repository: maven,
+	}
+	guava = &claircore.IndexRecord{
+		Package: &claircore.Package{
+			ID:      "3",
+			Name:    "com.google.guava:guava",
+			Version: "32.0.0-jre",
+			Kind:    claircore.BINARY,
+		},
+		Repository: maven,
+	}
+	rpm = &claircore.IndexRecord{
+		Package: &claircore.Package{
+			ID:      "4",
+			Name:    "bash",
+			Version: "5.1.8-6.el9",
+			Kind:    claircore.BINARY,
+		},
+		Distribution: &claircore.Distribution{DID: "rhel", VersionID: "9"},
+	}
+)
+
+var log4shell = componentVuln{
+	ID:          "d887d1a6-4d47-4c9d-a4a4-1e0b2ea6b6b6",
+	DisplayName: "CVE-2021-44228",
+	Title:       "[CVE-2021-44228] Improper Input Validation",
+	Description: "Apache Log4j2 JNDI features do not protect against attacker controlled LDAP endpoints.",
+	CVSSScore:   10.0,
+	CVSSVector:  "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:C/C:H/I:H/A:H",
+	CVE:         "CVE-2021-44228",
+	Reference:   "https://ossindex.sonatype.org/vulnerability/CVE-2021-44228",
+	ExternalReferences: []string{
+		"https://nvd.nist.gov/vuln/detail/CVE-2021-44228",
+	},
+}
+
+func TestFilter(t *testing.T) {
+	t.Parallel()
+	m := &Matcher{}
+	for _, tc := range []struct {
+		R    *claircore.IndexRecord
+		Want bool
+	}{
+		{log4j, true},
+		{guava, true},
+		{rpm, false},
+		{&claircore.IndexRecord{Package: &claircore.Package{Name: "six", PackageDB: "python:usr/lib/python3/site-packages"}}, false},
+		{&claircore.IndexRecord{Package: &claircore.Package{Name: "six", Version: "1.16.0", PackageDB: "python:usr/lib/python3/site-packages"}}, true},
+	} {
+		if got := m.Filter(tc.R); got != tc.Want {
+			t.Errorf("%s: got: %v, want: %v", tc.R.Package.Name, got, tc.Want)
+		}
+	}
+}
+
+func TestQueryRemoteMatcher(t *testing.T) {
+	t.Parallel()
+	ctx := zlog.Test(context.Background(), t)
+	api := &mockAPI{
+		t: t,
+		Vulns: map[string][]componentVuln{
+			"pkg:maven/org.apache.logging.log4j/log4j-core@2.14.1": {log4shell},
+		},
+	}
+	m := newTestMatcher(t, ctx, api)
+
+	rs := []*claircore.IndexRecord{log4j, log4jAgain, guava}
+	got, err := m.QueryRemoteMatcher(ctx, rs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := "ossindex-" + log4shell.ID + "-pkg:maven/org.apache.logging.log4j/log4j-core@2.14.1"
+	mk := func(r *claircore.IndexRecord) *claircore.Vulnerability {
+		return &claircore.Vulnerability{
+			ID:                 id,
+			Updater:            "ossindex",
+			Name:               "CVE-2021-44228",
+			Description:        log4shell.Description,
+			Links:              "https://ossindex.sonatype.org/vulnerability/CVE-2021-44228 https://nvd.nist.gov/vuln/detail/CVE-2021-44228",
+			Severity:           log4shell.CVSSVector,
+			NormalizedSeverity: claircore.Critical,
+			Package:            r.Package,
+			Repo:               r.Repository,
+		}
+	}
+	want := map[string][]*claircore.Vulnerability{
+		"1": {mk(log4j)},
+		"2": {mk(log4jAgain)},
+	}
+	if !cmp.Equal(got, want) {
+		t.Error(cmp.Diff(got, want))
+	}
+	sort.Strings(api.Asked)
+	wantAsked := []string{
+		"pkg:maven/com.google.guava/guava@32.0.0-jre",
+		"pkg:maven/org.apache.logging.log4j/log4j-core@2.14.1",
+	}
+	if !cmp.Equal(api.Asked, wantAsked) {
+		t.Error(cmp.Diff(api.Asked, wantAsked))
+	}
+
+	// Everything, including the package without vulnerabilities, should be
+	// cached now.
+	again, err := m.QueryRemoteMatcher(ctx, rs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cmp.Equal(again, want) {
+		t.Error(cmp.Diff(again, want))
+	}
+	if got, want := atomic.LoadInt32(&api.requests), int32(1); got != want {
+		t.Errorf("got: %d requests, want: %d", got, want)
+	}
+}
+
+func TestQueryRemoteMatcherError(t *testing.T) {
+	t.Parallel()
+	ctx := zlog.Test(context.Background(), t)
+	api := &mockAPI{t: t, Fail: true}
+	m := newTestMatcher(t, ctx, api)
+	rs := []*claircore.IndexRecord{log4j, guava}
+	for i := 0; i < 2; i++ {
+		got, err := m.QueryRemoteMatcher(ctx, rs)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got) != 0 {
+			t.Errorf("unexpected results: %v", got)
+		}
+	}
+	// Failures aren't cached.
+	if got, want := atomic.LoadInt32(&api.requests), int32(2); got != want {
+		t.Errorf("got: %d requests, want: %d", got, want)
+	}
+}
+
+func TestFactoryOptIn(t *testing.T) {
+	t.Parallel()
+	ctx := zlog.Test(context.Background(), t)
+	f := &Factory{}
+	if err := f.Configure(ctx, func(interface{}) error { return nil }, http.DefaultClient); err != nil {
+		t.Fatal(err)
+	}
+	ms, err := f.Matcher(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ms) != 0 {
+		t.Errorf("got: %d matchers, want: 0", len(ms))
+	}
+}
+
+func TestCache(t *testing.T) {
+	t.Parallel()
+	c := newCache(time.Hour)
+	now := time.Now()
+	c.Put("a", []componentVuln{log4shell}, now)
+	c.Put("b", nil, now)
+	if vs, ok := c.Get("a", now.Add(time.Minute)); !ok || len(vs) != 1 {
+		t.Errorf("got: %v, %v; want cached entry", vs, ok)
+	}
+	if _, ok := c.Get("b", now.Add(time.Minute)); !ok {
+		t.Error("empty result not cached")
+	}
+	if _, ok := c.Get("a", now.Add(time.Hour)); ok {
+		t.Error("expired entry returned")
+	}
+	// Adding after the TTL sweeps out expired entries.
+	c.Put("c", nil, now.Add(2*time.Hour))
+	if got := len(c.m); got != 1 {
+		t.Errorf("got: %d entries, want: 1", got)
+	}
+}
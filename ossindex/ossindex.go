@@ -0,0 +1,101 @@
+// Package ossindex provides a remote matcher using the Sonatype OSS Index.
+//
+// The OSS Index has vulnerability data for language packages beyond what the
+// in-tree updaters provide. Packages are looked up by their package URL using
+// the component-report API, which requires an account to use at any volume,
+// so the matcher is only enabled when credentials are configured.
+package ossindex
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/quay/zlog"
+
+	"github.com/quay/claircore/libvuln/driver"
+)
+
+const (
+	// DefaultURL is the component-report API endpoint.
+	//
+	//doc:url matcher
+	DefaultURL = `https://ossindex.sonatype.org/api/v3/component-report`
+
+	// DefaultTTL is how long results are cached by default.
+	//
+	// The data for a given package version changes rarely, and the API is
+	// rate limited.
+	DefaultTTL = 12 * time.Hour
+
+	// BatchSize is the most coordinates the API accepts in one request.
+	batchSize = 128
+
+	name = `ossindex`
+)
+
+var (
+	_ driver.MatcherFactory      = (*Factory)(nil)
+	_ driver.MatcherConfigurable = (*Factory)(nil)
+)
+
+// Factory constructs the OSS Index Matcher.
+//
+// No Matcher is returned unless the Factory is configured with credentials.
+type Factory struct {
+	c   *http.Client
+	cfg Config
+}
+
+// Config is the configuration accepted by the Factory.
+type Config struct {
+	// URL is the component-report API endpoint. If unset, DefaultURL is
+	// used.
+	URL string `json:"url" yaml:"url"`
+	// Username is the OSS Index account name, usually an email address.
+	Username string `json:"username" yaml:"username"`
+	// Token is the API token for the account.
+	Token string `json:"token" yaml:"token"`
+	// CacheTTL is how long the results for a package are reused. If unset,
+	// DefaultTTL is used.
+	CacheTTL time.Duration `json:"cache_ttl" yaml:"cache_ttl"`
+}
+
+// Configure implements driver.MatcherConfigurable.
+func (f *Factory) Configure(ctx context.Context, cf driver.MatcherConfigUnmarshaler, c *http.Client) error {
+	ctx = zlog.ContextWithValues(ctx, "component", "ossindex/Factory.Configure")
+	f.c = c
+	if err := cf(&f.cfg); err != nil {
+		return err
+	}
+	if f.cfg.URL != "" {
+		if _, err := url.Parse(f.cfg.URL); err != nil {
+			return fmt.Errorf("ossindex: bad URL: %w", err)
+		}
+	}
+	if f.cfg.CacheTTL < 0 {
+		return fmt.Errorf("ossindex: bad cache TTL: %v", f.cfg.CacheTTL)
+	}
+	zlog.Debug(ctx).
+		Str("url", f.cfg.URL).
+		Bool("credentials", f.cfg.Username != "" && f.cfg.Token != "").
+		Msg("configured")
+	return nil
+}
+
+// Matcher implements driver.MatcherFactory.
+func (f *Factory) Matcher(ctx context.Context) ([]driver.Matcher, error) {
+	ctx = zlog.ContextWithValues(ctx, "component", "ossindex/Factory.Matcher")
+	if f.cfg.Username == "" || f.cfg.Token == "" {
+		zlog.Info(ctx).
+			Msg("no credentials configured, skipping")
+		return nil, nil
+	}
+	m, err := newMatcher(f.c, f.cfg)
+	if err != nil {
+		return nil, err
+	}
+	return []driver.Matcher{m}, nil
+}
@@ -31,8 +31,41 @@ type Package struct {
 	Arch string `json:"arch,omitempty"`
 	// CPE name for package
 	CPE cpe.WFN `json:"cpe,omitempty"`
+	// Provides is a list of capabilities (virtual packages) this package
+	// provides in addition to its own name, in the form "name" or
+	// "name = version".
+	Provides []string `json:"provides,omitempty"`
+	// Depends is a list of the names of packages or capabilities this package
+	// requires to be installed. It's only populated by scanners configured
+	// to record dependencies.
+	Depends []string `json:"depends,omitempty"`
+	// IntroducedIn is the earliest layer of the manifest a package of this
+	// name was found in. It's populated during indexing and is only
+	// meaningful in the context of a single manifest's report.
+	IntroducedIn *Digest `json:"introduced_in,omitempty"`
+	// PresentIn is the earliest layer of the manifest this exact version of
+	// the package was found in. If the package was upgraded or downgraded in
+	// a later layer, this differs from IntroducedIn.
+	PresentIn *Digest `json:"present_in,omitempty"`
+	// Confidence is how sure the scanner is of the package's identity.
+	// Scanners reading a package database or lockfile don't need to set it.
+	Confidence Confidence `json:"confidence,omitempty"`
 }
 
+// Confidence describes how a Package was identified.
+type Confidence string
+
+// These are the Confidence levels.
+const (
+	// ConfidenceHigh is for packages read from authoritative metadata, like
+	// a package database or lockfile. It's the zero value.
+	ConfidenceHigh Confidence = ""
+	// ConfidenceLow is for packages identified by best-effort heuristics,
+	// like guessing from file names. Their names and versions may not line up
+	// with advisories.
+	ConfidenceLow Confidence = "low"
+)
+
 const (
 	BINARY = "binary"
 	SOURCE = "source"
@@ -0,0 +1,300 @@
+// Package condition implements the expressions vulnerabilities use to describe
+// the configurations they're exploitable in, and evaluates them against what's
+// known about an image.
+//
+// An expression is made of facts joined with "&&" and "||", negated with "!",
+// and grouped with parentheses. A fact is a kind and an argument separated by
+// a colon:
+//
+//	file:<path>     a file exists at <path>
+//	package:<name>  a package named <name> is installed
+//
+// For example:
+//
+//	package:httpd && (file:etc/httpd/conf.d/dav.conf || !package:mod_ssl)
+//
+// Facts are only known as well as the indexer looked for them. Package
+// databases are read in full, so a package that wasn't found is known to be
+// absent. Files are only recorded if a scanner was looking for them, so a file
+// that wasn't found may still exist. Expressions are evaluated with
+// three-valued logic to carry that through to the result.
+package condition
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/quay/claircore"
+)
+
+// Result is the result of evaluating an expression.
+type Result int
+
+// These are the possible Results. The zero value is Unknown.
+const (
+	Unknown Result = iota
+	False
+	True
+)
+
+func (r Result) String() string {
+	switch r {
+	case False:
+		return "false"
+	case True:
+		return "true"
+	default:
+		return "unknown"
+	}
+}
+
+func (r Result) not() Result {
+	switch r {
+	case False:
+		return True
+	case True:
+		return False
+	default:
+		return Unknown
+	}
+}
+
+// Facts are what's known about an image.
+type Facts struct {
+	// Files are paths of files known to exist, without a leading slash.
+	Files map[string]struct{}
+	// Packages are the names of the installed packages.
+	Packages map[string]struct{}
+}
+
+// FromIndexReport returns the Facts recorded in the IndexReport: the names of
+// its packages and the paths of its files, other than whiteouts.
+func FromIndexReport(ir *claircore.IndexReport) *Facts {
+	f := Facts{
+		Files:    make(map[string]struct{}, len(ir.Files)),
+		Packages: make(map[string]struct{}, len(ir.Packages)),
+	}
+	for _, file := range ir.Files {
+		if file.Kind == claircore.FileKindWhiteout {
+			continue
+		}
+		f.Files[cleanPath(file.Path)] = struct{}{}
+	}
+	for _, p := range ir.Packages {
+		f.Packages[p.Name] = struct{}{}
+	}
+	return &f
+}
+
+func cleanPath(p string) string {
+	return strings.TrimLeft(p, "/")
+}
+
+// Expr is a parsed expression.
+type Expr struct {
+	root node
+	src  string
+}
+
+// String returns the expression as it was parsed.
+func (e *Expr) String() string {
+	return e.src
+}
+
+// Eval evaluates the expression against the Facts.
+func (e *Expr) Eval(f *Facts) Result {
+	return e.root.eval(f)
+}
+
+type node interface {
+	eval(*Facts) Result
+}
+
+type and struct{ l, r node }
+
+func (n and) eval(f *Facts) Result {
+	l, r := n.l.eval(f), n.r.eval(f)
+	switch {
+	case l == False || r == False:
+		return False
+	case l == True && r == True:
+		return True
+	}
+	return Unknown
+}
+
+type or struct{ l, r node }
+
+func (n or) eval(f *Facts) Result {
+	l, r := n.l.eval(f), n.r.eval(f)
+	switch {
+	case l == True || r == True:
+		return True
+	case l == False && r == False:
+		return False
+	}
+	return Unknown
+}
+
+type not struct{ x node }
+
+func (n not) eval(f *Facts) Result { return n.x.eval(f).not() }
+
+type fileFact string
+
+func (n fileFact) eval(f *Facts) Result {
+	if _, ok := f.Files[string(n)]; ok {
+		return True
+	}
+	// The file may exist without a scanner having recorded it.
+	return Unknown
+}
+
+type packageFact string
+
+func (n packageFact) eval(f *Facts) Result {
+	if _, ok := f.Packages[string(n)]; ok {
+		return True
+	}
+	return False
+}
+
+// ErrSyntax is reported, via errors.Is, for malformed expressions.
+var ErrSyntax = errors.New("condition: syntax error")
+
+// Parse parses the expression "s".
+func Parse(s string) (*Expr, error) {
+	p := parser{toks: tokenize(s)}
+	n, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t != "" {
+		return nil, fmt.Errorf("%w: unexpected %q in %q", ErrSyntax, t, s)
+	}
+	return &Expr{root: n, src: s}, nil
+}
+
+// Tokenize splits "s" into operators, parentheses, and facts.
+func tokenize(s string) []string {
+	var toks []string
+	for len(s) != 0 {
+		switch {
+		case s[0] == ' ' || s[0] == '\t' || s[0] == '\n':
+			s = s[1:]
+		case strings.HasPrefix(s, "&&"), strings.HasPrefix(s, "||"):
+			toks = append(toks, s[:2])
+			s = s[2:]
+		case s[0] == '!' || s[0] == '(' || s[0] == ')':
+			toks = append(toks, s[:1])
+			s = s[1:]
+		default:
+			i := strings.IndexAny(s, " \t\n()&|")
+			if i == -1 {
+				i = len(s)
+			}
+			if i == 0 {
+				// A lone "&" or "|".
+				i = 1
+			}
+			toks = append(toks, s[:i])
+			s = s[i:]
+		}
+	}
+	return toks
+}
+
+// Parser is a recursive descent parser over tokens:
+//
+//	or    = and { "||" and }
+//	and   = unary { "&&" unary }
+//	unary = "!" unary | "(" or ")" | fact
+type parser struct {
+	toks []string
+}
+
+func (p *parser) peek() string {
+	if len(p.toks) == 0 {
+		return ""
+	}
+	return p.toks[0]
+}
+
+func (p *parser) next() string {
+	t := p.peek()
+	if t != "" {
+		p.toks = p.toks[1:]
+	}
+	return t
+}
+
+func (p *parser) or() (node, error) {
+	l, err := p.and()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek() == "||" {
+		p.next()
+		r, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+		l = or{l, r}
+	}
+	return l, nil
+}
+
+func (p *parser) and() (node, error) {
+	l, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek() == "&&" {
+		p.next()
+		r, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		l = and{l, r}
+	}
+	return l, nil
+}
+
+func (p *parser) unary() (node, error) {
+	switch t := p.next(); t {
+	case "":
+		return nil, fmt.Errorf("%w: unexpected end of expression", ErrSyntax)
+	case "!":
+		x, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return not{x}, nil
+	case "(":
+		x, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if c := p.next(); c != ")" {
+			return nil, fmt.Errorf("%w: missing %q", ErrSyntax, ")")
+		}
+		return x, nil
+	default:
+		return fact(t)
+	}
+}
+
+func fact(t string) (node, error) {
+	kind, arg, ok := strings.Cut(t, ":")
+	if !ok || arg == "" {
+		return nil, fmt.Errorf("%w: malformed fact %q", ErrSyntax, t)
+	}
+	switch kind {
+	case "file":
+		return fileFact(cleanPath(arg)), nil
+	case "package":
+		return packageFact(arg), nil
+	}
+	return nil, fmt.Errorf("%w: unknown fact kind %q", ErrSyntax, kind)
+}
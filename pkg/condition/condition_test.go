@@ -0,0 +1,76 @@
+package condition
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/quay/claircore"
+)
+
+func TestEval(t *testing.T) {
+	t.Parallel()
+	facts := FromIndexReport(&claircore.IndexReport{
+		Packages: map[string]*claircore.Package{
+			"1": {ID: "1", Name: "httpd"},
+			"2": {ID: "2", Name: "openssl"},
+		},
+		Files: map[string]claircore.File{
+			"a": {Path: "/etc/httpd/conf.d/dav.conf", Kind: claircore.FileKindConfig},
+			"b": {Path: "etc/removed.conf", Kind: claircore.FileKindWhiteout},
+		},
+	})
+	tt := []struct {
+		In   string
+		Want Result
+	}{
+		{`package:httpd`, True},
+		{`package:nginx`, False},
+		{`file:etc/httpd/conf.d/dav.conf`, True},
+		{`file:/etc/httpd/conf.d/dav.conf`, True},
+		{`file:etc/httpd/conf.d/ssl.conf`, Unknown},
+		{`file:etc/removed.conf`, Unknown},
+		{`!package:nginx`, True},
+		{`!file:etc/httpd/conf.d/ssl.conf`, Unknown},
+		{`package:httpd && package:openssl`, True},
+		{`package:httpd && package:nginx`, False},
+		{`package:httpd && file:etc/httpd/conf.d/ssl.conf`, Unknown},
+		{`package:nginx && file:etc/httpd/conf.d/ssl.conf`, False},
+		{`package:nginx || file:etc/httpd/conf.d/ssl.conf`, Unknown},
+		{`package:httpd || file:etc/httpd/conf.d/ssl.conf`, True},
+		{`package:nginx || package:lighttpd`, False},
+		{`package:httpd&&(file:etc/httpd/conf.d/dav.conf||!package:openssl)`, True},
+		{`package:nginx || package:httpd && package:openssl`, True},
+		{`(package:nginx || package:httpd) && !package:openssl`, False},
+	}
+	for _, tc := range tt {
+		e, err := Parse(tc.In)
+		if err != nil {
+			t.Errorf("%q: %v", tc.In, err)
+			continue
+		}
+		if got, want := e.Eval(facts), tc.Want; got != want {
+			t.Errorf("%q: got: %v, want: %v", tc.In, got, want)
+		}
+	}
+}
+
+func TestParseError(t *testing.T) {
+	t.Parallel()
+	for _, in := range []string{
+		``,
+		`httpd`,
+		`package:`,
+		`module:dav`,
+		`package:httpd &&`,
+		`package:httpd & package:openssl`,
+		`(package:httpd`,
+		`package:httpd)`,
+		`package:httpd package:openssl`,
+	} {
+		_, err := Parse(in)
+		t.Logf("%q: %v", in, err)
+		if !errors.Is(err, ErrSyntax) {
+			t.Errorf("%q: got: %v, want: %v", in, err, ErrSyntax)
+		}
+	}
+}
@@ -0,0 +1,261 @@
+package cpe
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Relation is the set relation between two attribute values or two names, as
+// defined by the CPE name matching spec:
+// https://nvlpubs.nist.gov/nistpubs/Legacy/IR/nistir7696.pdf
+type Relation uint
+
+// These are the possible Relations.
+//
+// The Relation describes the source in terms of the target: if the source is
+// a Superset, every name described by the target is also described by the
+// source.
+const (
+	Undefined Relation = iota
+	Disjoint
+	Subset
+	Superset
+	Equal
+)
+
+func (r Relation) String() string {
+	switch r {
+	case Undefined:
+		return "undefined"
+	case Disjoint:
+		return "disjoint"
+	case Subset:
+		return "subset"
+	case Superset:
+		return "superset"
+	case Equal:
+		return "equal"
+	}
+	return "Relation(" + strconv.FormatUint(uint64(r), 10) + ")"
+}
+
+// Compare compares the attribute values of the source and target WFNs,
+// returning the relation for each attribute.
+//
+// Unset attributes are treated as ANY.
+func Compare(src, tgt WFN) (r [NumAttr]Relation) {
+	for i := 0; i < NumAttr; i++ {
+		r[i] = compareValue(&src.Attr[i], &tgt.Attr[i])
+	}
+	return r
+}
+
+// Match reports whether the source WFN describes the target WFN, meaning
+// every attribute of the source is a superset of or equal to the
+// corresponding attribute of the target.
+//
+// This is the "CPE_SUPERSET or CPE_EQUAL" test from the name matching spec.
+// The source is usually a pattern, like an NVD match criterion, and the
+// target a specific name, like one derived from a detected product.
+func Match(src, tgt WFN) bool {
+	for _, r := range Compare(src, tgt) {
+		if r != Superset && r != Equal {
+			return false
+		}
+	}
+	return true
+}
+
+// CompareName reports the relation between the source and target WFNs as a
+// whole.
+//
+// A name is Disjoint if any attribute is, Undefined if any attribute is, and
+// otherwise Equal, Subset, or Superset if all attributes are that or Equal.
+// Names that are a mix of Subset and Superset are Undefined.
+func CompareName(src, tgt WFN) Relation {
+	var sub, super bool
+	for _, r := range Compare(src, tgt) {
+		switch r {
+		case Disjoint:
+			return Disjoint
+		case Undefined:
+			return Undefined
+		case Subset:
+			sub = true
+		case Superset:
+			super = true
+		}
+	}
+	switch {
+	case sub && super:
+		return Undefined
+	case sub:
+		return Subset
+	case super:
+		return Superset
+	}
+	return Equal
+}
+
+// CompareValue implements the attribute comparison table from section 6.2 of
+// the name matching spec.
+func compareValue(src, tgt *Value) Relation {
+	sk, tk := src.Kind, tgt.Kind
+	if sk == ValueUnset {
+		sk = ValueAny
+	}
+	if tk == ValueUnset {
+		tk = ValueAny
+	}
+	// Wildcards in the target are never well-defined.
+	if tk == ValueSet && hasWildcard(tgt.V) {
+		return Undefined
+	}
+	switch sk {
+	case ValueAny:
+		if tk == ValueAny {
+			return Equal
+		}
+		return Superset
+	case ValueNA:
+		switch tk {
+		case ValueAny:
+			return Subset
+		case ValueNA:
+			return Equal
+		}
+		return Disjoint
+	}
+	// Source is a set value.
+	switch tk {
+	case ValueAny:
+		return Subset
+	case ValueNA:
+		return Disjoint
+	}
+	if hasWildcard(src.V) {
+		return compareWildcard(src.V, tgt.V)
+	}
+	if strings.EqualFold(src.V, tgt.V) {
+		return Equal
+	}
+	return Disjoint
+}
+
+// HasWildcard reports whether the quoted value contains an unquoted "*" or
+// "?" special character.
+func hasWildcard(s string) bool {
+	esc := false
+	for _, r := range s {
+		switch {
+		case esc:
+			esc = false
+		case r == '\\':
+			esc = true
+		case r == '*' || r == '?':
+			return true
+		}
+	}
+	return false
+}
+
+// CompareWildcard reports if the quoted pattern "src" matches the quoted
+// value "tgt".
+//
+// Special characters may only appear at the beginning and end of a value. A
+// "*" matches any number of characters and a run of "?" matches up to that
+// many characters.
+func compareWildcard(src, tgt string) Relation {
+	begins, ends := 0, 0 // -1 means "*".
+	switch {
+	case strings.HasPrefix(src, "*"):
+		src = src[1:]
+		begins = -1
+	default:
+		for strings.HasPrefix(src, "?") {
+			src = src[1:]
+			begins++
+		}
+	}
+	switch {
+	case strings.HasSuffix(src, "*") && !strings.HasSuffix(src, `\*`):
+		src = src[:len(src)-1]
+		ends = -1
+	default:
+		for strings.HasSuffix(src, "?") && !strings.HasSuffix(src, `\?`) {
+			src = src[:len(src)-1]
+			ends++
+		}
+	}
+	body := strings.ToLower(Unquote(src))
+	t := strings.ToLower(Unquote(tgt))
+	for off := 0; off <= len(t); off++ {
+		i := strings.Index(t[off:], body)
+		if i == -1 {
+			break
+		}
+		i += off
+		if begins != -1 && i > begins {
+			break
+		}
+		left := len(t) - i - len(body)
+		if ends == -1 || left <= ends {
+			return Superset
+		}
+		off = i
+	}
+	return Disjoint
+}
+
+// Unquote removes the quoting from a WFN value, returning the literal
+// string.
+func Unquote(s string) string {
+	if !strings.ContainsRune(s, '\\') {
+		return s
+	}
+	var b strings.Builder
+	b.Grow(len(s))
+	esc := false
+	for _, r := range s {
+		if r == '\\' && !esc {
+			esc = true
+			continue
+		}
+		esc = false
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// Quote quotes a literal string for use as a WFN value.
+//
+// The result is suitable to pass to NewValue.
+func Quote(s string) string {
+	var b strings.Builder
+	b.Grow(len(s))
+	for _, r := range s {
+		if reserved(r) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// ProductKey returns a string identifying the part, vendor, and product of
+// the WFN, or an empty string if any of them is not a set value.
+//
+// This is used to index vulnerabilities by the products they affect.
+func (w WFN) ProductKey() string {
+	var b strings.Builder
+	b.WriteString(`cpe:2.3`)
+	for _, a := range []Attribute{Part, Vendor, Product} {
+		v := w.Attr[int(a)]
+		if v.Kind != ValueSet || hasWildcard(v.V) {
+			return ""
+		}
+		b.WriteByte(':')
+		v.bind(&b)
+	}
+	return strings.ToLower(b.String())
+}
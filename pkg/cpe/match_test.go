@@ -0,0 +1,128 @@
+package cpe
+
+import (
+	"testing"
+)
+
+func TestCompareValue(t *testing.T) {
+	// Table 6-2 from https://nvlpubs.nist.gov/nistpubs/Legacy/IR/nistir7696.pdf
+	tt := []struct {
+		Src, Tgt Value
+		Want     Relation
+	}{
+		{Value{Kind: ValueAny}, Value{Kind: ValueAny}, Equal},
+		{Value{Kind: ValueAny}, Value{Kind: ValueNA}, Superset},
+		{Value{Kind: ValueAny}, Value{Kind: ValueSet, V: "i"}, Superset},
+		{Value{Kind: ValueAny}, Value{Kind: ValueSet, V: "k*"}, Undefined},
+		{Value{Kind: ValueNA}, Value{Kind: ValueAny}, Subset},
+		{Value{Kind: ValueNA}, Value{Kind: ValueNA}, Equal},
+		{Value{Kind: ValueNA}, Value{Kind: ValueSet, V: "i"}, Disjoint},
+		{Value{Kind: ValueSet, V: "i"}, Value{Kind: ValueSet, V: "i"}, Equal},
+		{Value{Kind: ValueSet, V: "i"}, Value{Kind: ValueSet, V: "k"}, Disjoint},
+		{Value{Kind: ValueSet, V: "I"}, Value{Kind: ValueSet, V: "i"}, Equal},
+		{Value{Kind: ValueSet, V: "i"}, Value{Kind: ValueAny}, Subset},
+		{Value{Kind: ValueSet, V: "i"}, Value{Kind: ValueNA}, Disjoint},
+		{Value{Kind: ValueSet, V: "i*"}, Value{Kind: ValueSet, V: "ijk"}, Superset},
+		{Value{Kind: ValueSet, V: "*k"}, Value{Kind: ValueSet, V: "ijk"}, Superset},
+		{Value{Kind: ValueSet, V: "?j?"}, Value{Kind: ValueSet, V: "ijk"}, Superset},
+		{Value{Kind: ValueSet, V: "?k"}, Value{Kind: ValueSet, V: "ijk"}, Disjoint},
+		{Value{Kind: ValueSet, V: "??k"}, Value{Kind: ValueSet, V: "ijk"}, Superset},
+		{Value{Kind: ValueSet, V: `8\.*`}, Value{Kind: ValueSet, V: `8\.0\.6001`}, Superset},
+		{Value{Kind: ValueSet, V: `8\.*`}, Value{Kind: ValueSet, V: `9\.0`}, Disjoint},
+		{Value{Kind: ValueSet, V: "i*"}, Value{Kind: ValueSet, V: "i*"}, Undefined},
+		{Value{Kind: ValueUnset}, Value{Kind: ValueSet, V: "i"}, Superset},
+	}
+	for _, tc := range tt {
+		if got, want := compareValue(&tc.Src, &tc.Tgt), tc.Want; got != want {
+			t.Errorf("%v ⊇ %v: got: %v, want: %v", tc.Src, tc.Tgt, got, want)
+		}
+	}
+}
+
+func TestMatch(t *testing.T) {
+	tt := []struct {
+		Src, Tgt string
+		Want     bool
+		Rel      Relation
+	}{
+		{
+			Src:  `cpe:2.3:a:apache:http_server:*:*:*:*:*:*:*:*`,
+			Tgt:  `cpe:2.3:a:apache:http_server:2.4.49:*:*:*:*:*:*:*`,
+			Want: true,
+			Rel:  Superset,
+		},
+		{
+			Src:  `cpe:2.3:a:apache:http_server:2.4.49:*:*:*:*:*:*:*`,
+			Tgt:  `cpe:2.3:a:apache:http_server:2.4.49:*:*:*:*:*:*:*`,
+			Want: true,
+			Rel:  Equal,
+		},
+		{
+			Src:  `cpe:2.3:a:apache:http_server:2.4.49:*:*:*:*:*:*:*`,
+			Tgt:  `cpe:2.3:a:apache:http_server:2.4.50:*:*:*:*:*:*:*`,
+			Want: false,
+			Rel:  Disjoint,
+		},
+		{
+			Src:  `cpe:2.3:a:apache:http_server:2.4.49:*:*:*:*:*:*:*`,
+			Tgt:  `cpe:2.3:a:apache:http_server:*:*:*:*:*:*:*:*`,
+			Want: false,
+			Rel:  Subset,
+		},
+		{
+			Src:  `cpe:2.3:a:apache:http_server:*:*:*:*:*:*:*:*`,
+			Tgt:  `cpe:2.3:a:apache:tomcat:9.0.1:*:*:*:*:*:*:*`,
+			Want: false,
+			Rel:  Disjoint,
+		},
+		{
+			Src:  `cpe:2.3:a:openssl:openssl:1.0.2?:*:*:*:*:*:*:*`,
+			Tgt:  `cpe:2.3:a:openssl:openssl:1.0.2k:*:*:*:*:*:*:*`,
+			Want: true,
+			Rel:  Superset,
+		},
+		{
+			Src:  `cpe:2.3:a:apache:http_server:2.4.49:*:*:*:*:*:*:*`,
+			Tgt:  `cpe:2.3:a:apache:http_server:*:*:*:*:*:*:*:-`,
+			Want: false,
+			Rel:  Undefined,
+		},
+	}
+	for _, tc := range tt {
+		src, tgt := MustUnbind(tc.Src), MustUnbind(tc.Tgt)
+		if got, want := Match(src, tgt), tc.Want; got != want {
+			t.Errorf("%s ⊇ %s: got: %v, want: %v", tc.Src, tc.Tgt, got, want)
+		}
+		if got, want := CompareName(src, tgt), tc.Rel; got != want {
+			t.Errorf("%s ⊇ %s: got: %v, want: %v", tc.Src, tc.Tgt, got, want)
+		}
+	}
+}
+
+func TestProductKey(t *testing.T) {
+	tt := []struct {
+		In, Want string
+	}{
+		{`cpe:2.3:a:Apache:HTTP_Server:2.4.49:*:*:*:*:*:*:*`, `cpe:2.3:a:apache:http_server`},
+		{`cpe:2.3:a:nodejs:node.js:*:*:*:*:*:*:*:*`, `cpe:2.3:a:nodejs:node.js`},
+		{`cpe:2.3:a:*:http_server:2.4.49:*:*:*:*:*:*:*`, ``},
+		{`cpe:2.3:a:apache:http_*:2.4.49:*:*:*:*:*:*:*`, ``},
+	}
+	for _, tc := range tt {
+		if got, want := MustUnbind(tc.In).ProductKey(), tc.Want; got != want {
+			t.Errorf("%s: got: %q, want: %q", tc.In, got, want)
+		}
+	}
+}
+
+func TestQuote(t *testing.T) {
+	for _, s := range []string{"1.0.2k", "2.4.49", "8.0_beta-1", "plain"} {
+		q := Quote(s)
+		if _, err := NewValue(q); err != nil {
+			t.Errorf("%q: %v", q, err)
+		}
+		if got := Unquote(q); got != s {
+			t.Errorf("got: %q, want: %q", got, s)
+		}
+	}
+}
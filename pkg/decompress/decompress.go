@@ -0,0 +1,173 @@
+// Package decompress is a registry of the compression formats layers and
+// compressed members of layers may be stored in.
+//
+// Gzip, zstd, and bzip2 are registered by default. Other formats, like xz or
+// lz4, can be added with RegisterDecompressor and are then used everywhere
+// claircore decompresses layers: fetching, extracting image archives, and
+// the scanners that read compressed files.
+package decompress
+
+import (
+	"bytes"
+	"compress/bzip2"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/zstd"
+)
+
+// Decompressor describes a compression format.
+type Decompressor struct {
+	// Name is the format's name, like "gzip". Structured media types with
+	// a "+" suffix of the name, like
+	// "application/vnd.oci.image.layer.v1.tar+gzip", are handled by the
+	// Decompressor.
+	Name string
+	// MediaTypes are additional media types handled by the Decompressor,
+	// like "application/gzip".
+	MediaTypes []string
+	// Magic is the prefix of every stream in the format. A Decompressor
+	// without one is only found by media type.
+	Magic []byte
+	// Check, if set, is called by Detect with the start of a stream Magic
+	// matched, and reports whether the stream really is in the format. It's
+	// handed at least CheckLen bytes, if the stream is that long.
+	Check    func(b []byte) bool
+	CheckLen int
+	// NewReader returns a reader of the decompressed contents of "r".
+	// Closing it must not close "r".
+	NewReader func(r io.Reader) (io.ReadCloser, error)
+}
+
+var registry = struct {
+	sync.RWMutex
+	ds []*Decompressor
+}{}
+
+func init() {
+	RegisterDecompressor(Decompressor{
+		Name: "gzip",
+		MediaTypes: []string{
+			"application/gzip",
+			// GHCR reports gzipped layers as this.
+			"application/x-gzip",
+			// The old docker layer media type.
+			"application/vnd.docker.image.rootfs.diff.tar.gzip",
+		},
+		Magic: []byte{0x1F, 0x8B, 0x08},
+		NewReader: func(r io.Reader) (io.ReadCloser, error) {
+			return gzip.NewReader(r)
+		},
+	})
+	RegisterDecompressor(Decompressor{
+		Name:       "zstd",
+		MediaTypes: []string{"application/zstd"},
+		Magic:      []byte{0x28, 0xB5, 0x2F, 0xFD},
+		NewReader: func(r io.Reader) (io.ReadCloser, error) {
+			z, err := zstd.NewReader(r)
+			if err != nil {
+				return nil, err
+			}
+			return z.IOReadCloser(), nil
+		},
+	})
+	RegisterDecompressor(Decompressor{
+		Name:       "bzip2",
+		MediaTypes: []string{"application/x-bzip2"},
+		Magic:      []byte{'B', 'Z', 'h'},
+		// The magic is followed by the block size, '1' through '9'. "BZh"
+		// alone is common enough in text to need the extra byte.
+		Check: func(b []byte) bool {
+			return len(b) > 3 && b[3] >= '1' && b[3] <= '9'
+		},
+		CheckLen: 4,
+		NewReader: func(r io.Reader) (io.ReadCloser, error) {
+			return io.NopCloser(bzip2.NewReader(r)), nil
+		},
+	})
+}
+
+// ErrUnknown is reported, via errors.Is, when no Decompressor is registered
+// for a media type.
+var ErrUnknown = errors.New("decompress: unknown format")
+
+// RegisterDecompressor registers a Decompressor, replacing any already
+// registered with the same Name. It's meant to be called from init
+// functions or before any layers are read.
+//
+// RegisterDecompressor panics if the Name is empty or NewReader is nil.
+func RegisterDecompressor(d Decompressor) {
+	if d.Name == "" || d.NewReader == nil {
+		panic(fmt.Sprintf("decompress: invalid decompressor %q", d.Name))
+	}
+	registry.Lock()
+	defer registry.Unlock()
+	for i, e := range registry.ds {
+		if e.Name == d.Name {
+			registry.ds[i] = &d
+			return
+		}
+	}
+	registry.ds = append(registry.ds, &d)
+}
+
+// ForMediaType returns the Decompressor for the media type "mt", or an error
+// wrapping ErrUnknown.
+func ForMediaType(mt string) (*Decompressor, error) {
+	registry.RLock()
+	defer registry.RUnlock()
+	for _, d := range registry.ds {
+		if strings.HasSuffix(mt, "+"+d.Name) {
+			return d, nil
+		}
+		for _, t := range d.MediaTypes {
+			if mt == t {
+				return d, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("%w: %q", ErrUnknown, mt)
+}
+
+// Detect returns the Decompressor whose Magic "b" starts with, or nil if
+// there's none. The longest matching Magic wins.
+//
+// "b" should be at least MagicLen bytes, if the stream is that long.
+func Detect(b []byte) *Decompressor {
+	registry.RLock()
+	defer registry.RUnlock()
+	var found *Decompressor
+	for _, d := range registry.ds {
+		if len(d.Magic) == 0 || !bytes.HasPrefix(b, d.Magic) {
+			continue
+		}
+		if d.Check != nil && !d.Check(b) {
+			continue
+		}
+		if found == nil || len(d.Magic) > len(found.Magic) {
+			found = d
+		}
+	}
+	return found
+}
+
+// MagicLen reports the length of the longest registered Magic or CheckLen,
+// which is how much of a stream Detect needs to see.
+func MagicLen() int {
+	registry.RLock()
+	defer registry.RUnlock()
+	n := 0
+	for _, d := range registry.ds {
+		if len(d.Magic) > n {
+			n = len(d.Magic)
+		}
+		if d.CheckLen > n {
+			n = d.CheckLen
+		}
+	}
+	return n
+}
@@ -0,0 +1,140 @@
+package decompress
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/zstd"
+)
+
+const want = "hello, world\n"
+
+func TestBuiltin(t *testing.T) {
+	var gz bytes.Buffer
+	gw := gzip.NewWriter(&gz)
+	gw.Write([]byte(want))
+	if err := gw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	zw, err := zstd.NewWriter(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zs := zw.EncodeAll([]byte(want), nil)
+	// Generated by python's bz2 module, as there's no bzip2 writer in the
+	// standard library.
+	bz, err := hex.DecodeString(`425a683931415926535954a49784000002d180001040040644908020003100302068620049d4b21f3f17724538509054a49784`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tt := []struct {
+		Name      string
+		MediaType string
+		In        []byte
+	}{
+		{Name: "gzip", MediaType: "application/vnd.oci.image.layer.v1.tar+gzip", In: gz.Bytes()},
+		{Name: "gzip", MediaType: "application/vnd.docker.image.rootfs.diff.tar.gzip", In: gz.Bytes()},
+		{Name: "zstd", MediaType: "application/zstd", In: zs},
+		{Name: "bzip2", MediaType: "application/x-bzip2", In: bz},
+	}
+	for _, tc := range tt {
+		t.Run(tc.MediaType, func(t *testing.T) {
+			d := Detect(tc.In[:MagicLen()])
+			if d == nil {
+				t.Fatal("not detected")
+			}
+			if got, want := d.Name, tc.Name; got != want {
+				t.Errorf("detected: got: %q, want: %q", got, want)
+			}
+			d, err := ForMediaType(tc.MediaType)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, want := d.Name, tc.Name; got != want {
+				t.Errorf("media type: got: %q, want: %q", got, want)
+			}
+			r, err := d.NewReader(bytes.NewReader(tc.In))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer r.Close()
+			b, err := io.ReadAll(r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := string(b); got != want {
+				t.Errorf("got: %q, want: %q", got, want)
+			}
+		})
+	}
+}
+
+func TestUnknown(t *testing.T) {
+	for _, in := range []string{"plain text", "BZh", "BZhello", "BZh0"} {
+		if d := Detect([]byte(in)); d != nil {
+			t.Errorf("%q: unexpected decompressor: %q", in, d.Name)
+		}
+	}
+	_, err := ForMediaType("application/x-tar")
+	t.Log(err)
+	if !errors.Is(err, ErrUnknown) {
+		t.Errorf("got: %v, want: %v", err, ErrUnknown)
+	}
+}
+
+func TestRegister(t *testing.T) {
+	// The "test" format is the contents prefixed with a magic, which is
+	// longer than the "zstd" magic it starts with.
+	magic := []byte{0x28, 0xB5, 0x2F, 0xFD, 'T', 'E', 'S', 'T'}
+	RegisterDecompressor(Decompressor{
+		Name:  "test",
+		Magic: magic,
+		NewReader: func(r io.Reader) (io.ReadCloser, error) {
+			if _, err := io.CopyN(io.Discard, r, int64(len(magic))); err != nil {
+				return nil, err
+			}
+			return io.NopCloser(r), nil
+		},
+	})
+	in := append(append([]byte{}, magic...), want...)
+	if got, want := MagicLen(), len(magic); got < want {
+		t.Errorf("magic length: got: %d, want: >=%d", got, want)
+	}
+	d := Detect(in)
+	if d == nil || d.Name != "test" {
+		t.Fatalf("got: %v, want: %q", d, "test")
+	}
+	if _, err := ForMediaType("application/vnd.example.layer+test"); err != nil {
+		t.Error(err)
+	}
+	r, err := d.NewReader(bytes.NewReader(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(b); got != want {
+		t.Errorf("got: %q, want: %q", got, want)
+	}
+
+	t.Run("Replace", func(t *testing.T) {
+		RegisterDecompressor(Decompressor{
+			Name:       "test",
+			MediaTypes: []string{"application/x-test"},
+			NewReader:  d.NewReader,
+		})
+		if d := Detect(in); d != nil && d.Name == "test" {
+			t.Error("replaced decompressor still detected")
+		}
+		if _, err := ForMediaType("application/x-test"); err != nil {
+			t.Error(err)
+		}
+	})
+}
@@ -0,0 +1,79 @@
+// Package httputil contains helpers for constructing HTTP clients that honor
+// an egress proxy and a private certificate authority.
+//
+// ClairCore makes its requests with caller-provided clients; this package is
+// for callers whose environment isn't described by the process environment
+// and system certificate pool.
+package httputil
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+)
+
+// TransportConfig describes the egress configuration for a Transport.
+//
+// The zero value results in a Transport equivalent to
+// [http.DefaultTransport].
+type TransportConfig struct {
+	// Proxy is the URL of a proxy to send all requests through. Credentials
+	// for an authenticating proxy may be provided in the URL's userinfo.
+	//
+	// If unset, the proxy is determined from the environment, as with
+	// [http.ProxyFromEnvironment].
+	Proxy string `json:"proxy" yaml:"proxy"`
+	// CABundle is the path to a file of PEM-encoded certificates that are
+	// trusted in addition to the system roots.
+	CABundle string `json:"ca_bundle" yaml:"ca_bundle"`
+}
+
+// NewTransport returns an http.Transport configured according to "cfg".
+func NewTransport(cfg *TransportConfig) (*http.Transport, error) {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	if cfg == nil {
+		return t, nil
+	}
+	if cfg.Proxy != "" {
+		u, err := url.Parse(cfg.Proxy)
+		if err != nil {
+			return nil, fmt.Errorf("httputil: bad proxy URL: %w", err)
+		}
+		if u.Scheme == "" || u.Host == "" {
+			return nil, fmt.Errorf("httputil: bad proxy URL: %q", cfg.Proxy)
+		}
+		t.Proxy = http.ProxyURL(u)
+	}
+	if cfg.CABundle != "" {
+		b, err := os.ReadFile(cfg.CABundle)
+		if err != nil {
+			return nil, fmt.Errorf("httputil: unable to read CA bundle: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(b) {
+			return nil, errors.New("httputil: no certificates found in CA bundle")
+		}
+		if t.TLSClientConfig == nil {
+			t.TLSClientConfig = &tls.Config{}
+		}
+		t.TLSClientConfig.RootCAs = pool
+	}
+	return t, nil
+}
+
+// NewClient returns an http.Client using a Transport configured according to
+// "cfg".
+func NewClient(cfg *TransportConfig) (*http.Client, error) {
+	t, err := NewTransport(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &http.Client{Transport: t}, nil
+}
@@ -0,0 +1,90 @@
+package httputil
+
+import (
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCABundle(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	bundle := filepath.Join(t.TempDir(), "ca.pem")
+	b := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+	if err := os.WriteFile(bundle, b, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("Untrusted", func(t *testing.T) {
+		c, err := NewClient(nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res, err := c.Get(srv.URL); err == nil {
+			res.Body.Close()
+			t.Error("expected certificate error")
+		}
+	})
+	t.Run("Trusted", func(t *testing.T) {
+		c, err := NewClient(&TransportConfig{CABundle: bundle})
+		if err != nil {
+			t.Fatal(err)
+		}
+		res, err := c.Get(srv.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+		if got, want := res.StatusCode, http.StatusNoContent; got != want {
+			t.Errorf("got: %d, want: %d", got, want)
+		}
+	})
+	t.Run("Empty", func(t *testing.T) {
+		empty := filepath.Join(t.TempDir(), "empty.pem")
+		if err := os.WriteFile(empty, nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := NewClient(&TransportConfig{CABundle: empty}); err == nil {
+			t.Error("expected error")
+		}
+	})
+}
+
+func TestProxy(t *testing.T) {
+	const target = `http://example.invalid/some/path`
+	var seen string
+	var auth string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = r.URL.String()
+		auth = r.Header.Get("Proxy-Authorization")
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer proxy.Close()
+
+	u := "http://user:pass@" + proxy.Listener.Addr().String()
+	c, err := NewClient(&TransportConfig{Proxy: u})
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := c.Get(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if got, want := seen, target; got != want {
+		t.Errorf("got: %q, want: %q", got, want)
+	}
+	if auth == "" {
+		t.Error("expected proxy credentials to be sent")
+	}
+
+	if _, err := NewClient(&TransportConfig{Proxy: "not a url"}); err == nil {
+		t.Error("expected error")
+	}
+}
@@ -1,6 +1,8 @@
 package ovalutil
 
 import (
+	"bufio"
+	"bytes"
 	"compress/bzip2"
 	"context"
 	"encoding/json"
@@ -10,6 +12,7 @@ import (
 	"net/http"
 	"net/url"
 	"path"
+	"strings"
 
 	"github.com/quay/zlog"
 
@@ -108,7 +111,9 @@ type FetcherConfig struct {
 // Fetcher.Compression, using the client provided as Fetcher.Client.
 //
 // Fetch makes GET requests, and will make conditional requests using the
-// passed-in hint.
+// passed-in hint. If Fetcher.Compression is CompressionAuto, the compression
+// is determined from the body itself, or failing that, the response's headers
+// and the URL.
 //
 // Tmp.File is used to return a ReadCloser that outlives the passed-in context.
 func (f *Fetcher) Fetch(ctx context.Context, hint driver.Fingerprint) (io.ReadCloser, driver.Fingerprint, error) {
@@ -153,72 +158,29 @@ func (f *Fetcher) Fetch(ctx context.Context, hint driver.Fingerprint) (io.ReadCl
 	zlog.Debug(ctx).Msg("request ok")
 
 	var r io.Reader
+	body := bufio.NewReader(res.Body)
 	cmp := f.Compression
 Compression:
 	switch cmp {
 	case CompressionAuto:
-		var kind string
-		for _, h := range []string{`content-type`, `content-disposition`} {
-			v := res.Header.Get(h)
-			if v == "" {
-				continue
-			}
-			switch h {
-			case `content-type`:
-				kind, _, err = mime.ParseMediaType(v)
-				if err == nil {
-					goto Found
-				}
-			case `content-disposition`:
-				var params map[string]string
-				_, params, err = mime.ParseMediaType(v)
-				if err != nil {
-					break
-				}
-				fn, ok := params["filename"]
-				if !ok {
-					break
-				}
-				kind = mime.TypeByExtension(path.Ext(fn))
-				if kind != "" {
-					goto Found
-				}
-			default:
-				panic("unreachable")
-			}
-			zlog.Debug(ctx).
-				Err(err).
-				Str("header", h).
-				Str("value", v).
-				Msg("failed to parse incoming HTTP header")
-		}
-		kind = mime.TypeByExtension(path.Ext(res.Request.URL.Path))
-	Found:
-		switch kind {
-		case `application/x-bzip2`:
-			cmp = CompressionBzip2
-		case `application/gzip`, `application/x-gzip`:
-			cmp = CompressionGzip
-		case `application/zstd`:
-			cmp = CompressionZstd
-		default:
-			// unknown type
-			cmp = CompressionNone
+		cmp, err = detectCompression(f.URL, res.Header, body)
+		if err != nil {
+			return nil, hint, err
 		}
 		goto Compression
 	case CompressionNone:
-		r = res.Body
+		r = body
 	case CompressionGzip:
-		gz, err := getGzip(res.Body)
+		gz, err := getGzip(body)
 		if err != nil {
 			return nil, hint, err
 		}
 		defer putGzip(gz)
 		r = gz
 	case CompressionBzip2:
-		r = bzip2.NewReader(res.Body)
+		r = bzip2.NewReader(body)
 	case CompressionZstd:
-		zz, err := getZstd(res.Body)
+		zz, err := getZstd(body)
 		if err != nil {
 			return nil, hint, err
 		}
@@ -262,6 +224,78 @@ Compression:
 	return tf, hint, nil
 }
 
+// Magic is the leading bytes of the supported compression formats.
+var magic = [...]struct {
+	c Compressor
+	b []byte
+}{
+	{CompressionGzip, []byte{0x1F, 0x8B}},
+	{CompressionBzip2, []byte("BZh")},
+	{CompressionZstd, []byte{0x28, 0xB5, 0x2F, 0xFD}},
+}
+
+// DetectCompression reports the compression of a response body.
+//
+// The leading bytes of the body are examined first, as mirrors don't always
+// label what they serve correctly: the magic of a supported format, or the
+// start of an XML document, is trusted. If they're not recognized, the
+// Content-Encoding and Content-Type headers are consulted, then the extension
+// of the URL. This means mirrors serving a database with a different
+// compression than upstream work without any configuration. A Content-Encoding
+// that isn't supported is reported as an error.
+func detectCompression(u *url.URL, h http.Header, body *bufio.Reader) (Compressor, error) {
+	// Errors here are reported when reading the body.
+	b, _ := body.Peek(4)
+	for _, m := range magic {
+		if !bytes.HasPrefix(b, m.b) {
+			continue
+		}
+		// The bzip2 magic is followed by the block size, '1' through '9'.
+		if m.c == CompressionBzip2 && (len(b) < 4 || b[3] < '1' || b[3] > '9') {
+			continue
+		}
+		return m.c, nil
+	}
+	var enc Compressor
+	switch ce := strings.ToLower(strings.TrimSpace(h.Get("content-encoding"))); ce {
+	case "gzip", "x-gzip":
+		enc = CompressionGzip
+	case "bzip2", "x-bzip2":
+		enc = CompressionBzip2
+	case "zstd":
+		enc = CompressionZstd
+	case "", "identity":
+	default:
+		return CompressionAuto, fmt.Errorf("ovalutil: unsupported content encoding %q", ce)
+	}
+	// An XML document isn't compressed, whatever the URL or headers say.
+	if bytes.HasPrefix(bytes.TrimLeft(b, " \t\r\n"), []byte("<")) {
+		return CompressionNone, nil
+	}
+	if enc != CompressionAuto {
+		return enc, nil
+	}
+	if t, _, err := mime.ParseMediaType(h.Get("content-type")); err == nil {
+		switch t {
+		case "application/gzip", "application/x-gzip":
+			return CompressionGzip, nil
+		case "application/x-bzip2":
+			return CompressionBzip2, nil
+		case "application/zstd":
+			return CompressionZstd, nil
+		}
+	}
+	switch path.Ext(u.Path) {
+	case ".gz":
+		return CompressionGzip, nil
+	case ".bz2":
+		return CompressionBzip2, nil
+	case ".zst":
+		return CompressionZstd, nil
+	}
+	return CompressionNone, nil
+}
+
 type fingerprint struct {
 	Etag string `json:",omitempty"`
 	Date string `json:",omitempty"`
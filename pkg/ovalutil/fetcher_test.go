@@ -0,0 +1,151 @@
+package ovalutil
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"testing"
+
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/zstd"
+	"github.com/quay/zlog"
+)
+
+func TestFetcherCompression(t *testing.T) {
+	ctx := zlog.Test(context.Background(), t)
+	want, err := os.ReadFile("testdata/oval.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	bz, err := os.ReadFile("testdata/oval.xml.bz2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var gz, zs bytes.Buffer
+	gw := gzip.NewWriter(&gz)
+	if _, err := gw.Write(want); err != nil {
+		t.Fatal(err)
+	}
+	if err := gw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	zw, err := zstd.NewWriter(&zs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := zw.Write(want); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	tt := []struct {
+		Name     string
+		Path     string
+		Encoding string
+		Body     []byte
+		Config   Compressor
+	}{
+		{Name: "None", Path: "/oval.xml", Body: want},
+		{Name: "GzipEncoding", Path: "/oval.xml", Encoding: "gzip", Body: gz.Bytes()},
+		{Name: "Bzip2Encoding", Path: "/oval.xml", Encoding: "bzip2", Body: bz},
+		{Name: "ZstdEncoding", Path: "/oval.xml", Encoding: "zstd", Body: zs.Bytes()},
+		{Name: "GzipMagic", Path: "/oval.xml", Body: gz.Bytes()},
+		{Name: "Bzip2Magic", Path: "/oval.xml.bz2", Body: bz},
+		// A mirror serving zstd where upstream uses gzip.
+		{Name: "ZstdMismatchedExtension", Path: "/oval.xml.gz", Body: zs.Bytes()},
+		{Name: "Configured", Path: "/oval", Body: zs.Bytes(), Config: CompressionZstd},
+	}
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			ctx := zlog.Test(ctx, t)
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != tc.Path {
+					http.NotFound(w, r)
+					return
+				}
+				if tc.Encoding != "" {
+					w.Header().Set("content-encoding", tc.Encoding)
+				}
+				w.Write(tc.Body)
+			}))
+			defer srv.Close()
+			u, err := url.Parse(srv.URL + tc.Path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			f := Fetcher{
+				URL:         u,
+				Client:      srv.Client(),
+				Compression: tc.Config,
+			}
+			rc, _, err := f.Fetch(ctx, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer rc.Close()
+			got, err := io.ReadAll(rc)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("got: %q, want: %q", got, want)
+			}
+		})
+	}
+
+	t.Run("UnknownEncoding", func(t *testing.T) {
+		ctx := zlog.Test(ctx, t)
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("content-encoding", "br")
+			w.Write(want)
+		}))
+		defer srv.Close()
+		u, _ := url.Parse(srv.URL)
+		f := Fetcher{URL: u, Client: srv.Client()}
+		if _, _, err := f.Fetch(ctx, ""); err == nil {
+			t.Error("expected error, got nil")
+		}
+	})
+}
+
+func TestDetectCompression(t *testing.T) {
+	// Bodies too short to recognize fall back to the headers and URL.
+	tt := []struct {
+		Name   string
+		Path   string
+		Header http.Header
+		Body   []byte
+		Want   Compressor
+	}{
+		{Name: "Magic", Path: "/oval.xml", Header: http.Header{"Content-Type": {"application/gzip"}}, Body: []byte{0x28, 0xB5, 0x2F, 0xFD}, Want: CompressionZstd},
+		{Name: "Bzip2BlockSize", Path: "/oval.xml", Body: []byte("BZh9"), Want: CompressionBzip2},
+		{Name: "NotBzip2", Path: "/oval.xml", Body: []byte("BZhx"), Want: CompressionNone},
+		{Name: "ContentType", Path: "/oval.xml", Header: http.Header{"Content-Type": {"application/zstd"}}, Body: []byte{0x28}, Want: CompressionZstd},
+		{Name: "Extension", Path: "/oval.xml.bz2", Body: []byte("B"), Want: CompressionBzip2},
+		{Name: "None", Path: "/oval.xml", Body: []byte("<?xml"), Want: CompressionNone},
+		{Name: "XMLMislabeled", Path: "/oval.xml.bz2", Header: http.Header{"Content-Type": {"application/x-bzip2"}}, Body: []byte("\n<?xml"), Want: CompressionNone},
+	}
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			u := &url.URL{Path: tc.Path}
+			h := tc.Header
+			if h == nil {
+				h = http.Header{}
+			}
+			got, err := detectCompression(u, h, bufio.NewReader(bytes.NewReader(tc.Body)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tc.Want {
+				t.Errorf("got: %v, want: %v", got, tc.Want)
+			}
+		})
+	}
+}
@@ -14,8 +14,8 @@ var (
 )
 
 func getGzip(r io.Reader) (*gzip.Reader, error) {
-	z := gzipPool.Get().(*gzip.Reader)
-	if z == nil {
+	z, ok := gzipPool.Get().(*gzip.Reader)
+	if !ok {
 		return gzip.NewReader(r)
 	}
 	if err := z.Reset(r); err != nil {
@@ -29,8 +29,8 @@ func putGzip(z *gzip.Reader) {
 }
 
 func getZstd(r io.Reader) (*zstd.Decoder, error) {
-	z := zstdPool.Get().(*zstd.Decoder)
-	if z == nil {
+	z, ok := zstdPool.Get().(*zstd.Decoder)
+	if !ok {
 		return zstd.NewReader(r)
 	}
 	if err := z.Reset(r); err != nil {
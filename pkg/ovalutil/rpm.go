@@ -44,6 +44,9 @@ func RPMDefsToVulns(ctx context.Context, root *oval.Root, protoVulns ProtoVulnsF
 	ctx = zlog.ContextWithValues(ctx, "component", "ovalutil/RPMDefsToVulns")
 	vulns := make([]*claircore.Vulnerability, 0, 10000)
 	cris := []*oval.Criterion{}
+	var stats struct {
+		Test, Obj, State int
+	}
 	for _, def := range root.Definitions.Definitions {
 		// create our prototype vulnerability
 		protoVulns, err := protoVulns(def)
@@ -77,6 +80,7 @@ func RPMDefsToVulns(ctx context.Context, root *oval.Root, protoVulns ProtoVulnsF
 			case errors.Is(err, errTestSkip):
 				continue
 			default:
+				stats.Test++
 				zlog.Debug(ctx).Str("test_ref", criterion.TestRef).Msg("test ref lookup failure. moving to next criterion")
 				continue
 			}
@@ -90,6 +94,13 @@ func RPMDefsToVulns(ctx context.Context, root *oval.Root, protoVulns ProtoVulnsF
 			//
 			// thus we *should* only need to care about a single rpminfo_object and optionally a state object providing the package's fixed-in version.
 
+			if len(objRefs) == 0 {
+				stats.Obj++
+				zlog.Debug(ctx).
+					Str("test_ref", criterion.TestRef).
+					Msg("test has no object reference. moving to next criterion")
+				continue
+			}
 			objRef := objRefs[0].ObjectRef
 			object, err := rpmObjectLookup(root, objRef)
 			switch {
@@ -98,6 +109,7 @@ func RPMDefsToVulns(ctx context.Context, root *oval.Root, protoVulns ProtoVulnsF
 				// We only handle rpminfo_objects.
 				continue
 			default:
+				stats.Obj++
 				zlog.Debug(ctx).
 					Err(err).
 					Str("object_ref", objRef).
@@ -113,6 +125,7 @@ func RPMDefsToVulns(ctx context.Context, root *oval.Root, protoVulns ProtoVulnsF
 				stateRef := stateRefs[0].StateRef
 				state, err = rpmStateLookup(root, stateRef)
 				if err != nil {
+					stats.State++
 					zlog.Debug(ctx).
 						Err(err).
 						Str("state_ref", stateRef).
@@ -146,6 +159,11 @@ func RPMDefsToVulns(ctx context.Context, root *oval.Root, protoVulns ProtoVulnsF
 			}
 		}
 	}
+	zlog.Debug(ctx).
+		Int("test", stats.Test).
+		Int("object", stats.Obj).
+		Int("state", stats.State).
+		Msg("ref lookup failures")
 
 	return vulns, nil
 }
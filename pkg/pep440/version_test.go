@@ -176,3 +176,23 @@ func TestOrdering(t *testing.T) {
 		t.Run(tc.Name, tc.Run)
 	}
 }
+
+func FuzzParse(f *testing.F) {
+	for _, s := range []string{"1.0.0", "1!2.3.4-a5-post_6.dev7.8", "2019.3", "1.0rc1", "0.9.post1.dev2"} {
+		f.Add(s)
+	}
+	f.Fuzz(func(t *testing.T, s string) {
+		v, err := Parse(s)
+		if err != nil {
+			return
+		}
+		// The canonical form should parse to an equal version.
+		c, err := Parse(v.String())
+		if err != nil {
+			t.Fatalf("%q: canonical form %q: %v", s, v.String(), err)
+		}
+		if got := v.Compare(&c); got != 0 {
+			t.Errorf("%q: canonical form %q compares %d", s, v.String(), got)
+		}
+	})
+}
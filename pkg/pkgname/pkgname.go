@@ -0,0 +1,64 @@
+// Package pkgname normalizes package names, so the names recorded by package
+// scanners and the names used as keys by advisory sources compare equal.
+//
+// Package indexes differ in which spellings of a name they consider the same
+// package. For example, the Python Package Index treats "Zope.Interface" and
+// "zope_interface" as the same package, but the former is what's found in the
+// installed metadata and the latter may be what an advisory uses.
+package pkgname
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// Normalize returns the normalized form of the package name "n" for the
+// ecosystem "eco".
+//
+// Ecosystems are the names used by OSV (see https://ossf.github.io/osv-schema/)
+// and are compared case-insensitively. Names for ecosystems without an entry in
+// the table are returned unchanged.
+func Normalize(eco, n string) string {
+	if f, ok := table[strings.ToLower(eco)]; ok {
+		return f(n)
+	}
+	return n
+}
+
+// Table maps lowercased ecosystem names to normalization functions.
+var table = map[string]func(string) string{
+	"pypi": Python,
+	"npm":  NPM,
+}
+
+// Python returns the [normalized form] of a Python package name, as specified
+// in PEP 503: lowercased, with runs of "-", "_", and "." replaced by a single
+// "-".
+//
+// [normalized form]: https://packaging.python.org/en/latest/specifications/name-normalization/
+func Python(n string) string {
+	return strings.ToLower(pySeparators.ReplaceAllLiteralString(strings.TrimSpace(n), "-"))
+}
+
+var pySeparators = regexp.MustCompile(`[-_.]+`)
+
+// NPM returns the normalized form of an npm package name.
+//
+// Scoped packages ("@scope/name") are lowercased, as the registry has only
+// allowed lowercase names since scopes were introduced, and a percent-encoded
+// "@" (as found in package URLs) is decoded. Unscoped names are returned as-is
+// apart from surrounding space, because some older packages have mixed-case
+// names that are distinct from their lowercase forms.
+func NPM(n string) string {
+	n = strings.TrimSpace(n)
+	if strings.HasPrefix(n, "%40") {
+		if u, err := url.PathUnescape(n); err == nil {
+			n = u
+		}
+	}
+	if strings.HasPrefix(n, "@") && strings.Contains(n, "/") {
+		return strings.ToLower(n)
+	}
+	return n
+}
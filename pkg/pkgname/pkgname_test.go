@@ -0,0 +1,39 @@
+package pkgname
+
+import "testing"
+
+func TestNormalize(t *testing.T) {
+	t.Parallel()
+	tt := []struct {
+		Eco, In, Want string
+	}{
+		// Python: https://packaging.python.org/en/latest/specifications/name-normalization/
+		{"PyPI", "friendly-bard", "friendly-bard"},
+		{"PyPI", "Friendly-Bard", "friendly-bard"},
+		{"PyPI", "FRIENDLY-BARD", "friendly-bard"},
+		{"PyPI", "friendly.bard", "friendly-bard"},
+		{"PyPI", "friendly_bard", "friendly-bard"},
+		{"PyPI", "friendly--bard", "friendly-bard"},
+		{"PyPI", "FrIeNdLy-._.-bArD", "friendly-bard"},
+		{"pypi", "Zope.Interface", "zope-interface"},
+		{"PyPI", " discord.py\n", "discord-py"},
+
+		// npm
+		{"npm", "@Babel/Core", "@babel/core"},
+		{"npm", "%40babel/core", "@babel/core"},
+		{"npm", "%40Types/Node", "@types/node"},
+		{"npm", "JSONStream", "JSONStream"},
+		{"npm", "left-pad", "left-pad"},
+		{"NPM", " lodash ", "lodash"},
+
+		// Other ecosystems are untouched.
+		{"Go", "github.com/BurntSushi/toml", "github.com/BurntSushi/toml"},
+		{"Maven", "org.apache.logging.log4j:log4j-core", "org.apache.logging.log4j:log4j-core"},
+		{"", "Some_Name", "Some_Name"},
+	}
+	for _, tc := range tt {
+		if got := Normalize(tc.Eco, tc.In); got != tc.Want {
+			t.Errorf("%s %q: got: %q, want: %q", tc.Eco, tc.In, got, tc.Want)
+		}
+	}
+}
@@ -172,3 +172,20 @@ func TestOrdering(t *testing.T) {
 		t.Run(tc.Name, tc.Run)
 	}
 }
+
+func FuzzParse(f *testing.F) {
+	for _, s := range []string{"v1.0.0", "v1.0.1", "v1.0.0-202201121008", "1.2", "v4.11.0-202304011200.p0.g3b2b5bb.assembly.stream"} {
+		f.Add(s)
+	}
+	f.Fuzz(func(t *testing.T, s string) {
+		v, err := Parse(s)
+		if err != nil {
+			return
+		}
+		if got := v.Compare(&v); got != 0 {
+			t.Errorf("%q: not equal to itself: %d", s, got)
+		}
+		v.Version(true)
+		v.Version(false)
+	})
+}
@@ -0,0 +1,209 @@
+package squashfs
+
+import (
+	"encoding/binary"
+	"io"
+	"sync"
+)
+
+// Block is a decompressed block and the position of the block following it on
+// disk.
+type block struct {
+	b    []byte
+	next int64
+}
+
+// Cache is a bounded cache of decompressed blocks, keyed by position on disk.
+//
+// When full, an arbitrary entry is evicted.
+type cache struct {
+	mu  sync.Mutex
+	max int
+	m   map[int64]block
+}
+
+func newCache(max int) *cache {
+	return &cache{
+		max: max,
+		m:   make(map[int64]block),
+	}
+}
+
+func (c *cache) get(pos int64) (block, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	b, ok := c.m[pos]
+	return b, ok
+}
+
+func (c *cache) put(pos int64, b block) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.m) >= c.max {
+		for k := range c.m {
+			delete(c.m, k)
+			break
+		}
+	}
+	c.m[pos] = b
+}
+
+// ReadRaw reads "sz" bytes at "pos", making sure the read stays within the
+// image.
+func (f *FS) readRaw(pos int64, sz int) ([]byte, error) {
+	if pos < 0 || pos+int64(sz) > int64(f.sb.BytesUsed) {
+		return nil, parseErr("read out of bounds: %d+%d", pos, sz)
+	}
+	b := make([]byte, sz)
+	if _, err := f.r.ReadAt(b, pos); err != nil {
+		return nil, parseErr("read at %d: %v", pos, err)
+	}
+	return b, nil
+}
+
+// MetadataBlock returns the metadata block at "pos".
+func (f *FS) metadataBlock(pos int64) (block, error) {
+	if b, ok := f.meta.get(pos); ok {
+		return b, nil
+	}
+	h, err := f.readRaw(pos, 2)
+	if err != nil {
+		return block{}, err
+	}
+	hdr := le.Uint16(h)
+	sz := int(hdr &^ metadataRaw)
+	if sz == 0 || sz > metadataSz {
+		return block{}, parseErr("bad metadata block size at %d: %d", pos, sz)
+	}
+	raw, err := f.readRaw(pos+2, sz)
+	if err != nil {
+		return block{}, err
+	}
+	b := block{b: raw, next: pos + 2 + int64(sz)}
+	if hdr&metadataRaw == 0 {
+		b.b, err = f.decompress(raw, metadataSz)
+		if err != nil {
+			return block{}, parseErr("metadata block at %d: %v", pos, err)
+		}
+	}
+	if len(b.b) == 0 {
+		return block{}, parseErr("empty metadata block at %d", pos)
+	}
+	f.meta.put(pos, b)
+	return b, nil
+}
+
+// DataBlock returns the data block at "pos", with the on-disk size "sz",
+// truncated to "want" bytes.
+func (f *FS) dataBlock(pos int64, sz uint32, want int) ([]byte, error) {
+	if sz&dataSizeMask == 0 {
+		// Sparse block.
+		return make([]byte, want), nil
+	}
+	var b []byte
+	if c, ok := f.data.get(pos); ok {
+		b = c.b
+	} else {
+		var err error
+		b, err = f.readRaw(pos, int(sz&dataSizeMask))
+		if err != nil {
+			return nil, err
+		}
+		if sz&dataRaw == 0 {
+			b, err = f.decompress(b, int(f.sb.BlockSize))
+			if err != nil {
+				return nil, parseErr("data block at %d: %v", pos, err)
+			}
+		}
+		f.data.put(pos, block{b: b})
+	}
+	if len(b) < want {
+		return nil, parseErr("short data block at %d: %d < %d", pos, len(b), want)
+	}
+	return b[:want], nil
+}
+
+// Fragment returns the fragment block "idx".
+func (f *FS) fragment(idx uint32) ([]byte, error) {
+	if idx >= f.sb.Fragments {
+		return nil, parseErr("fragment out of range: %d", idx)
+	}
+	// The fragment table is an array of pointers to metadata blocks holding
+	// the fragment entries.
+	p, err := f.readRaw(int64(f.sb.FragmentTable)+8*int64(idx/fragmentsBlock), 8)
+	if err != nil {
+		return nil, err
+	}
+	r, err := f.metadataReader(int64(le.Uint64(p)), (idx%fragmentsBlock)*fragmentSz)
+	if err != nil {
+		return nil, err
+	}
+	var ent struct {
+		Start  uint64
+		Size   uint32
+		Unused uint32
+	}
+	if err := binary.Read(r, le, &ent); err != nil {
+		return nil, parseErr("fragment entry %d: %v", idx, err)
+	}
+	if ent.Start > f.sb.BytesUsed {
+		return nil, parseErr("fragment %d out of bounds: %d", idx, ent.Start)
+	}
+	pos := int64(ent.Start)
+	if c, ok := f.data.get(pos); ok {
+		return c.b, nil
+	}
+	b, err := f.readRaw(pos, int(ent.Size&dataSizeMask))
+	if err != nil {
+		return nil, err
+	}
+	if ent.Size&dataRaw == 0 {
+		b, err = f.decompress(b, int(f.sb.BlockSize))
+		if err != nil {
+			return nil, parseErr("fragment block %d: %v", idx, err)
+		}
+	}
+	f.data.put(pos, block{b: b})
+	return b, nil
+}
+
+// MetadataReader is an io.Reader over consecutive metadata blocks.
+type metadataReader struct {
+	f    *FS
+	buf  []byte
+	next int64
+}
+
+// MetadataReader returns a reader starting "off" bytes into the decompressed
+// contents of the metadata block at "pos".
+func (f *FS) metadataReader(pos int64, off uint32) (*metadataReader, error) {
+	b, err := f.metadataBlock(pos)
+	if err != nil {
+		return nil, err
+	}
+	if int(off) > len(b.b) {
+		return nil, parseErr("metadata offset out of bounds: %d > %d", off, len(b.b))
+	}
+	return &metadataReader{
+		f:    f,
+		buf:  b.b[off:],
+		next: b.next,
+	}, nil
+}
+
+// Read implements io.Reader.
+func (r *metadataReader) Read(p []byte) (int, error) {
+	if len(r.buf) == 0 {
+		if r.next >= int64(r.f.sb.BytesUsed) {
+			return 0, io.EOF
+		}
+		b, err := r.f.metadataBlock(r.next)
+		if err != nil {
+			return 0, err
+		}
+		r.buf, r.next = b.b, b.next
+	}
+	n := copy(p, r.buf)
+	r.buf = r.buf[n:]
+	return n, nil
+}
@@ -0,0 +1,75 @@
+package squashfs
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/ulikunitz/xz"
+	"github.com/ulikunitz/xz/lzma"
+)
+
+// Decompressor returns the decompressed contents of "b", which must be no
+// larger than "max" bytes.
+type decompressor func(b []byte, max int) ([]byte, error)
+
+// ZstdDecoder is shared by all images, as a zstd.Decoder is safe for
+// concurrent use via DecodeAll.
+var zstdDecoder, _ = zstd.NewReader(nil, zstd.WithDecoderConcurrency(0))
+
+// DecompressorFor returns the decompressor for the compression ID "id".
+func decompressorFor(id uint16) (decompressor, error) {
+	switch id {
+	case compGzip:
+		// Despite the name, this is a zlib stream.
+		return streamDecompressor(func(r io.Reader) (io.Reader, error) {
+			return zlib.NewReader(r)
+		}), nil
+	case compLZMA:
+		return streamDecompressor(func(r io.Reader) (io.Reader, error) {
+			return lzma.NewReader(r)
+		}), nil
+	case compXZ:
+		return streamDecompressor(func(r io.Reader) (io.Reader, error) {
+			return xz.NewReader(r)
+		}), nil
+	case compZstd:
+		return func(b []byte, max int) ([]byte, error) {
+			out, err := zstdDecoder.DecodeAll(b, make([]byte, 0, max))
+			if err != nil {
+				return nil, err
+			}
+			if len(out) > max {
+				return nil, parseErr("decompressed block too large: %d > %d", len(out), max)
+			}
+			return out, nil
+		}, nil
+	case compLZO:
+		return nil, fmt.Errorf("squashfs: unsupported compression: lzo")
+	case compLZ4:
+		return nil, fmt.Errorf("squashfs: unsupported compression: lz4")
+	}
+	return nil, parseErr("unknown compression: %d", id)
+}
+
+// StreamDecompressor adapts a streaming decompression constructor into a
+// decompressor.
+func streamDecompressor(mk func(io.Reader) (io.Reader, error)) decompressor {
+	return func(b []byte, max int) ([]byte, error) {
+		r, err := mk(bytes.NewReader(b))
+		if err != nil {
+			return nil, err
+		}
+		out := bytes.NewBuffer(make([]byte, 0, max))
+		n, err := io.Copy(out, io.LimitReader(r, int64(max)+1))
+		if err != nil {
+			return nil, err
+		}
+		if n > int64(max) {
+			return nil, parseErr("decompressed block too large: > %d", max)
+		}
+		return out.Bytes(), nil
+	}
+}
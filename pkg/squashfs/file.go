@@ -0,0 +1,104 @@
+package squashfs
+
+import (
+	"io"
+	"io/fs"
+)
+
+var _ fs.File = (*file)(nil)
+
+// File implements fs.File.
+type file struct {
+	f  *FS
+	fi *fileInfo
+	// Disk is the position of the next data block, and blk is its index in
+	// the inode's block list.
+	disk int64
+	blk  int
+	// Off is the number of bytes of the file loaded into buf so far.
+	off int64
+	buf []byte
+}
+
+func (f *file) Close() error               { return nil }
+func (f *file) Stat() (fs.FileInfo, error) { return f.fi, nil }
+
+func (f *file) Read(b []byte) (int, error) {
+	if len(f.buf) == 0 {
+		if err := f.load(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(b, f.buf)
+	f.buf = f.buf[n:]
+	return n, nil
+}
+
+// Load reads the next block of the file into the buffer, returning io.EOF if
+// there's nothing left.
+func (f *file) load() error {
+	i := f.fi.i
+	if i.typ != typeFile || f.off >= int64(i.size) {
+		return io.EOF
+	}
+	want := int64(f.f.sb.BlockSize)
+	if rem := int64(i.size) - f.off; rem < want {
+		want = rem
+	}
+	var b []byte
+	var err error
+	if f.blk < len(i.blocks) {
+		sz := i.blocks[f.blk]
+		b, err = f.f.dataBlock(f.disk, sz, int(want))
+		f.disk += int64(sz & dataSizeMask)
+		f.blk++
+	} else {
+		// The rest of the file is the tail end, stored in a fragment.
+		b, err = f.f.fragment(i.frag)
+		if err == nil {
+			end := int64(i.fragOff) + want
+			if end > int64(len(b)) {
+				return parseErr("fragment %d too short: %d > %d", i.frag, end, len(b))
+			}
+			b = b[i.fragOff:end]
+		}
+	}
+	if err != nil {
+		return err
+	}
+	f.buf = b
+	f.off += want
+	return nil
+}
+
+var _ fs.ReadDirFile = (*dir)(nil)
+
+// Dir implements fs.ReadDirFile.
+type dir struct {
+	fi   *fileInfo
+	ents []dirent
+	pos  int
+}
+
+func (*dir) Close() error                 { return nil }
+func (*dir) Read(_ []byte) (int, error)   { return 0, io.EOF }
+func (d *dir) Stat() (fs.FileInfo, error) { return d.fi, nil }
+func (d *dir) ReadDir(n int) ([]fs.DirEntry, error) {
+	es := d.ents[d.pos:]
+	if len(es) == 0 {
+		if n <= 0 {
+			return nil, nil
+		}
+		return nil, io.EOF
+	}
+	end := len(es)
+	if n > 0 && n < end {
+		end = n
+	}
+	d.pos += end
+	ret := make([]fs.DirEntry, end)
+	for i := range ret {
+		ret[i] = &es[i]
+	}
+	return ret, nil
+}
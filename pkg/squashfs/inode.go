@@ -0,0 +1,240 @@
+package squashfs
+
+import (
+	"encoding/binary"
+	"io"
+	"io/fs"
+	"time"
+)
+
+// Inode types. Extended types are the basic type plus typeExtended; they carry
+// wider fields and extended attributes.
+const (
+	typeDir = 1 + iota
+	typeFile
+	typeSymlink
+	typeBlock
+	typeChar
+	typeFifo
+	typeSocket
+
+	typeExtended = 7
+)
+
+// Inode is the parsed form of an on-disk inode. Only the fields needed to
+// present the filesystem are kept.
+type inode struct {
+	typ   uint16 // Always a basic type.
+	perm  uint16
+	mtime uint32
+
+	// Directories
+	dirBlock uint32
+	dirOff   uint16
+	dirSize  uint32 // Size of the listing, in bytes.
+
+	// Regular files
+	start   uint64
+	size    uint64
+	frag    uint32
+	fragOff uint32
+	blocks  []uint32
+
+	// Symlinks
+	target string
+}
+
+// Inode reads the inode referenced by "ref".
+//
+// An inode reference is the offset of a metadata block from the start of the
+// inode table in the upper bits and the offset into the decompressed block in
+// the lower 16 bits.
+func (f *FS) inode(ref uint64) (*inode, error) {
+	r, err := f.metadataReader(int64(f.sb.InodeTable+ref>>16), uint32(ref&0xFFFF))
+	if err != nil {
+		return nil, err
+	}
+	var h struct {
+		Type   uint16
+		Perm   uint16
+		UID    uint16
+		GID    uint16
+		MTime  uint32
+		Number uint32
+	}
+	if err := binary.Read(r, le, &h); err != nil {
+		return nil, parseErr("inode %#x: %v", ref, err)
+	}
+	i := inode{
+		typ:   h.Type,
+		perm:  h.Perm,
+		mtime: h.MTime,
+	}
+	if i.typ > typeExtended {
+		i.typ -= typeExtended
+	}
+	switch h.Type {
+	case typeDir:
+		var d struct {
+			Start  uint32
+			Nlink  uint32
+			Size   uint16
+			Offset uint16
+			Parent uint32
+		}
+		err = binary.Read(r, le, &d)
+		i.dirBlock, i.dirOff, i.dirSize = d.Start, d.Offset, listingSize(uint32(d.Size))
+	case typeDir + typeExtended:
+		var d struct {
+			Nlink  uint32
+			Size   uint32
+			Start  uint32
+			Parent uint32
+			Count  uint16
+			Offset uint16
+			Xattr  uint32
+		}
+		err = binary.Read(r, le, &d)
+		i.dirBlock, i.dirOff, i.dirSize = d.Start, d.Offset, listingSize(d.Size)
+	case typeFile:
+		var d struct {
+			Start  uint32
+			Frag   uint32
+			Offset uint32
+			Size   uint32
+		}
+		err = binary.Read(r, le, &d)
+		i.start, i.frag, i.fragOff, i.size = uint64(d.Start), d.Frag, d.Offset, uint64(d.Size)
+	case typeFile + typeExtended:
+		var d struct {
+			Start  uint64
+			Size   uint64
+			Sparse uint64
+			Nlink  uint32
+			Frag   uint32
+			Offset uint32
+			Xattr  uint32
+		}
+		err = binary.Read(r, le, &d)
+		i.start, i.frag, i.fragOff, i.size = d.Start, d.Frag, d.Offset, d.Size
+	case typeSymlink, typeSymlink + typeExtended:
+		var d struct {
+			Nlink uint32
+			Size  uint32
+		}
+		if err = binary.Read(r, le, &d); err != nil {
+			break
+		}
+		if d.Size == 0 || d.Size > maxSymlink {
+			return nil, parseErr("inode %#x: bad symlink size: %d", ref, d.Size)
+		}
+		b := make([]byte, d.Size)
+		_, err = io.ReadFull(r, b)
+		i.target = string(b)
+	case typeBlock, typeChar, typeFifo, typeSocket,
+		typeBlock + typeExtended, typeChar + typeExtended,
+		typeFifo + typeExtended, typeSocket + typeExtended:
+		// Nothing else needed.
+	default:
+		return nil, parseErr("inode %#x: unknown type: %d", ref, h.Type)
+	}
+	if err != nil {
+		return nil, parseErr("inode %#x: %v", ref, err)
+	}
+
+	if i.typ == typeFile {
+		if i.start > f.sb.BytesUsed {
+			return nil, parseErr("inode %#x: data out of bounds: %d", ref, i.start)
+		}
+		// Blocks that don't fill a whole block are stored in a fragment, if
+		// there is one.
+		bs := uint64(f.sb.BlockSize)
+		n := i.size / bs
+		if i.frag == noFragment && i.size%bs != 0 {
+			n++
+		}
+		// Read the block list in chunks, so that a bogus size runs out of
+		// metadata instead of allocating a huge slice up front.
+		const chunk = 1024
+		buf := make([]uint32, chunk)
+		for n != 0 {
+			b := buf
+			if n < chunk {
+				b = buf[:n]
+			}
+			if err := binary.Read(r, le, b); err != nil {
+				return nil, parseErr("inode %#x: reading block list: %v", ref, err)
+			}
+			i.blocks = append(i.blocks, b...)
+			n -= uint64(len(b))
+		}
+	}
+	return &i, nil
+}
+
+// ListingSize converts the size recorded in a directory inode into the size of
+// the listing. The recorded size includes the "." and ".." entries, which
+// aren't stored.
+func listingSize(sz uint32) uint32 {
+	if sz < 3 {
+		return 0
+	}
+	return sz - 3
+}
+
+// Mode reports the fs.FileMode for the inode.
+func (i *inode) mode() fs.FileMode {
+	m := fs.FileMode(i.perm & 0o777)
+	if i.perm&0o4000 != 0 {
+		m |= fs.ModeSetuid
+	}
+	if i.perm&0o2000 != 0 {
+		m |= fs.ModeSetgid
+	}
+	if i.perm&0o1000 != 0 {
+		m |= fs.ModeSticky
+	}
+	return m | typeMode(i.typ)
+}
+
+// TypeMode reports the fs.FileMode type bits for the basic inode type "t".
+func typeMode(t uint16) fs.FileMode {
+	switch t {
+	case typeDir:
+		return fs.ModeDir
+	case typeSymlink:
+		return fs.ModeSymlink
+	case typeBlock:
+		return fs.ModeDevice
+	case typeChar:
+		return fs.ModeDevice | fs.ModeCharDevice
+	case typeFifo:
+		return fs.ModeNamedPipe
+	case typeSocket:
+		return fs.ModeSocket
+	}
+	return 0
+}
+
+// FileInfo implements fs.FileInfo.
+type fileInfo struct {
+	name string
+	i    *inode
+}
+
+var _ fs.FileInfo = (*fileInfo)(nil)
+
+func (fi *fileInfo) Name() string       { return fi.name }
+func (fi *fileInfo) Mode() fs.FileMode  { return fi.i.mode() }
+func (fi *fileInfo) ModTime() time.Time { return time.Unix(int64(fi.i.mtime), 0) }
+func (fi *fileInfo) IsDir() bool        { return fi.i.typ == typeDir }
+func (fi *fileInfo) Sys() interface{}   { return nil }
+func (fi *fileInfo) Size() int64 {
+	switch fi.i.typ {
+	case typeFile:
+		return int64(fi.i.size)
+	case typeSymlink:
+		return int64(len(fi.i.target))
+	}
+	return 0
+}
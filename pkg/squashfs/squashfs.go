@@ -0,0 +1,330 @@
+// Package squashfs implements the fs.FS interface over a squashfs image.
+//
+// Only version 4.0 images, as created by mksquashfs(1) and used by the Linux
+// kernel, are supported. Images compressed with gzip, lzma, xz, or zstd can
+// be read. Extended attributes, owners, and the export table are ignored.
+package squashfs
+
+import (
+	"encoding/binary"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// FS implements a filesystem abstraction over an io.ReaderAt containing a
+// squashfs image.
+type FS struct {
+	r          io.ReaderAt
+	sb         *superblock
+	decompress decompressor
+	root       *inode
+	meta       *cache
+	data       *cache
+
+	dirMu sync.Mutex
+	dirs  map[uint64][]dirent
+}
+
+// New creates an FS from the squashfs image contained in the ReaderAt.
+//
+// The ReaderAt must remain valid for the entire life of the returned FS.
+func New(r io.ReaderAt) (*FS, error) {
+	sb, err := readSuperblock(r)
+	if err != nil {
+		return nil, err
+	}
+	d, err := decompressorFor(sb.Compression)
+	if err != nil {
+		return nil, err
+	}
+	f := FS{
+		r:          r,
+		sb:         sb,
+		decompress: d,
+		meta:       newCache(512),
+		data:       newCache(32),
+		dirs:       make(map[uint64][]dirent),
+	}
+	f.root, err = f.inode(sb.RootInode)
+	if err != nil {
+		return nil, err
+	}
+	if f.root.typ != typeDir {
+		return nil, parseErr("root inode is not a directory")
+	}
+	return &f, nil
+}
+
+// Dirent is an entry in a directory listing.
+type dirent struct {
+	f    *FS
+	name string
+	typ  uint16
+	ref  uint64
+}
+
+var _ fs.DirEntry = (*dirent)(nil)
+
+func (d *dirent) Name() string      { return d.name }
+func (d *dirent) IsDir() bool       { return d.typ == typeDir }
+func (d *dirent) Type() fs.FileMode { return typeMode(d.typ) }
+func (d *dirent) Info() (fs.FileInfo, error) {
+	i, err := d.f.inode(d.ref)
+	if err != nil {
+		return nil, err
+	}
+	return &fileInfo{name: d.name, i: i}, nil
+}
+
+// ReadDirInode returns the sorted listing of the directory "i".
+func (f *FS) readDirInode(i *inode) ([]dirent, error) {
+	// Empty directories have no listing, so may share a position with the
+	// next directory's listing.
+	if i.dirSize == 0 {
+		return nil, nil
+	}
+	key := uint64(i.dirBlock)<<16 | uint64(i.dirOff)
+	f.dirMu.Lock()
+	ents, ok := f.dirs[key]
+	f.dirMu.Unlock()
+	if ok {
+		return ents, nil
+	}
+
+	r, err := f.metadataReader(int64(f.sb.DirTable)+int64(i.dirBlock), uint32(i.dirOff))
+	if err != nil {
+		return nil, err
+	}
+	// A listing is a series of runs of entries, each preceded by a header
+	// holding the values common to the run.
+	rem := int64(i.dirSize)
+	for rem > 0 {
+		var h struct {
+			Count  uint32 // Stored as one less than the number of entries.
+			Start  uint32
+			Number uint32
+		}
+		if err := binary.Read(r, le, &h); err != nil {
+			return nil, parseErr("directory header: %v", err)
+		}
+		rem -= 12
+		if h.Count >= 256 {
+			return nil, parseErr("bad directory header count: %d", h.Count+1)
+		}
+		for n := uint32(0); n <= h.Count && rem > 0; n++ {
+			var e struct {
+				Offset   uint16
+				InodeOff int16
+				Type     uint16
+				NameSize uint16 // Stored as one less than the length.
+			}
+			if err := binary.Read(r, le, &e); err != nil {
+				return nil, parseErr("directory entry: %v", err)
+			}
+			b := make([]byte, int(e.NameSize)+1)
+			if _, err := io.ReadFull(r, b); err != nil {
+				return nil, parseErr("directory entry: %v", err)
+			}
+			rem -= 8 + int64(len(b))
+			name := string(b)
+			if name == "." || name == ".." || strings.ContainsAny(name, "/\x00") {
+				return nil, parseErr("bad directory entry name: %q", name)
+			}
+			if e.Type < typeDir || e.Type > typeSocket {
+				return nil, parseErr("bad directory entry type: %d", e.Type)
+			}
+			ents = append(ents, dirent{
+				f:    f,
+				name: name,
+				typ:  e.Type,
+				ref:  uint64(h.Start)<<16 | uint64(e.Offset),
+			})
+		}
+	}
+	if rem != 0 {
+		return nil, parseErr("directory listing overran its size by %d bytes", -rem)
+	}
+	sort.Slice(ents, func(i, j int) bool { return ents[i].name < ents[j].name })
+
+	f.dirMu.Lock()
+	f.dirs[key] = ents
+	f.dirMu.Unlock()
+	return ents, nil
+}
+
+// GetInode returns the inode backing "name", resolving symlinks in every
+// element of the path except the final one, which is only resolved if
+// "follow" is set.
+//
+// The "op" parameter is used in error reporting.
+func (f *FS) getInode(op, name string, follow bool) (*inode, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{
+			Op:   op,
+			Path: name,
+			Err:  fs.ErrInvalid,
+		}
+	}
+	cur, dir := f.root, "."
+	elems := split(name)
+	links := 0
+	for len(elems) != 0 {
+		n := elems[0]
+		elems = elems[1:]
+		if cur.typ != typeDir {
+			return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+		}
+		ents, err := f.readDirInode(cur)
+		if err != nil {
+			return nil, &fs.PathError{Op: op, Path: name, Err: err}
+		}
+		idx := sort.Search(len(ents), func(i int) bool { return ents[i].name >= n })
+		if idx == len(ents) || ents[idx].name != n {
+			return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+		}
+		ent := &ents[idx]
+		i, err := f.inode(ent.ref)
+		if err != nil {
+			return nil, &fs.PathError{Op: op, Path: name, Err: err}
+		}
+		if i.typ == typeSymlink && (len(elems) != 0 || follow) {
+			links++
+			if links > maxLinks {
+				return nil, &fs.PathError{
+					Op:   op,
+					Path: name,
+					Err:  parseErr("too many levels of symbolic links"),
+				}
+			}
+			// Restart the walk from the root with the resolved path. Absolute
+			// targets are relative to the root of the image.
+			tgt := i.target
+			if !path.IsAbs(tgt) {
+				tgt = path.Join(dir, tgt)
+			}
+			elems = append(split(path.Join("/", tgt)[1:]), elems...)
+			cur, dir = f.root, "."
+			continue
+		}
+		cur, dir = i, path.Join(dir, n)
+	}
+	return cur, nil
+}
+
+// Split returns the elements of the slash-separated path "p".
+func split(p string) []string {
+	if p == "." || p == "" {
+		return nil
+	}
+	return strings.Split(p, "/")
+}
+
+// Open implements fs.FS.
+//
+// Symlinks are followed. Special files are presented as empty files.
+func (f *FS) Open(name string) (fs.File, error) {
+	const op = `open`
+	i, err := f.getInode(op, name, true)
+	if err != nil {
+		return nil, err
+	}
+	fi := &fileInfo{name: path.Base(name), i: i}
+	if i.typ == typeDir {
+		ents, err := f.readDirInode(i)
+		if err != nil {
+			return nil, &fs.PathError{Op: op, Path: name, Err: err}
+		}
+		return &dir{fi: fi, ents: ents}, nil
+	}
+	return &file{f: f, fi: fi, disk: int64(i.start)}, nil
+}
+
+// Stat implements fs.StatFS.
+func (f *FS) Stat(name string) (fs.FileInfo, error) {
+	const op = `stat`
+	i, err := f.getInode(op, name, true)
+	if err != nil {
+		return nil, err
+	}
+	return &fileInfo{name: path.Base(name), i: i}, nil
+}
+
+// Lstat is like Stat, but doesn't follow a symlink in the final element of
+// "name".
+func (f *FS) Lstat(name string) (fs.FileInfo, error) {
+	const op = `lstat`
+	i, err := f.getInode(op, name, false)
+	if err != nil {
+		return nil, err
+	}
+	return &fileInfo{name: path.Base(name), i: i}, nil
+}
+
+// ReadLink returns the target of the symlink "name".
+func (f *FS) ReadLink(name string) (string, error) {
+	const op = `readlink`
+	i, err := f.getInode(op, name, false)
+	if err != nil {
+		return "", err
+	}
+	if i.typ != typeSymlink {
+		return "", &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+	return i.target, nil
+}
+
+// ReadDir implements fs.ReadDirFS.
+func (f *FS) ReadDir(name string) ([]fs.DirEntry, error) {
+	const op = `readdir`
+	i, err := f.getInode(op, name, true)
+	if err != nil {
+		return nil, err
+	}
+	if i.typ != typeDir {
+		return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+	ents, err := f.readDirInode(i)
+	if err != nil {
+		return nil, &fs.PathError{Op: op, Path: name, Err: err}
+	}
+	ret := make([]fs.DirEntry, len(ents))
+	for n := range ents {
+		ret[n] = &ents[n]
+	}
+	return ret, nil
+}
+
+// ReadFile implements fs.ReadFileFS.
+func (f *FS) ReadFile(name string) ([]byte, error) {
+	// ReadFileFS is implemented because it can immediately allocate a byte
+	// slice of the correct size.
+	const op = `readfile`
+	i, err := f.getInode(op, name, true)
+	if err != nil {
+		return nil, err
+	}
+	if i.typ == typeDir {
+		return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+	r := file{f: f, fi: &fileInfo{name: path.Base(name), i: i}, disk: int64(i.start)}
+	ret := make([]byte, r.fi.Size())
+	if i.typ != typeFile {
+		ret = ret[:0]
+	}
+	if _, err := io.ReadFull(&r, ret); err != nil {
+		return nil, &fs.PathError{Op: op, Path: name, Err: err}
+	}
+	return ret, nil
+}
+
+// A bunch of static assertions for the fs interfaces.
+var (
+	_ fs.FS         = (*FS)(nil)
+	_ fs.ReadDirFS  = (*FS)(nil)
+	_ fs.ReadFileFS = (*FS)(nil)
+	_ fs.StatFS     = (*FS)(nil)
+)
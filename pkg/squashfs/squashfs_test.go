@@ -0,0 +1,298 @@
+package squashfs
+
+import (
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"testing/fstest"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/ulikunitz/xz"
+	"github.com/ulikunitz/xz/lzma"
+
+	"github.com/quay/claircore/test/squashfstest"
+)
+
+// Fixture is the contents of testdata/fixture.squashfs.
+//
+// If the fixture is missing, it's recreated from this.
+var fixture = fstest.MapFS{
+	"etc/os-release": &fstest.MapFile{
+		Data: []byte("ID=debian\nVERSION_ID=\"12\"\n"),
+		Mode: 0o644,
+	},
+	"etc/debian_version": &fstest.MapFile{
+		Data: []byte("12.0\n"),
+		Mode: 0o644,
+	},
+	"var/lib/dpkg/status": &fstest.MapFile{
+		Data: []byte("Package: bogus\nStatus: install ok installed\nVersion: 1\nArchitecture: all\n\n"),
+		Mode: 0o644,
+	},
+	"usr/bin/su": &fstest.MapFile{
+		Data: []byte("#!/bin/false\n"),
+		Mode: 0o755 | fs.ModeSetuid,
+	},
+	"usr/share/big": &fstest.MapFile{
+		// Spans a few blocks and ends in a fragment.
+		Data: bytes.Repeat([]byte("0123456789abcdef\n"), 1000),
+		Mode: 0o644,
+	},
+	"usr/share/empty": &fstest.MapFile{
+		Mode: 0o644,
+	},
+	"usr/lib/os-release": &fstest.MapFile{
+		Data: []byte("../../etc/os-release"),
+		Mode: fs.ModeSymlink | 0o777,
+	},
+	"bin": &fstest.MapFile{
+		Data: []byte("usr/bin"),
+		Mode: fs.ModeSymlink | 0o777,
+	},
+	"lib": &fstest.MapFile{
+		Data: []byte("/usr/lib"),
+		Mode: fs.ModeSymlink | 0o777,
+	},
+	"tmp": &fstest.MapFile{
+		Mode: fs.ModeDir | 0o777 | fs.ModeSticky,
+	},
+}
+
+func openFixture(t *testing.T) *FS {
+	t.Helper()
+	const name = `testdata/fixture.squashfs`
+	if _, err := os.Stat(name); errors.Is(err, os.ErrNotExist) {
+		t.Log("creating fixture")
+		var buf bytes.Buffer
+		if err := squashfstest.Write(&buf, fixture); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(name, buf.Bytes(), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	f, err := os.Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := f.Close(); err != nil {
+			t.Error(err)
+		}
+	})
+	if !Sniff(f) {
+		t.Fatal("fixture not detected as squashfs")
+	}
+	sys, err := New(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sys
+}
+
+func TestFixture(t *testing.T) {
+	sys := openFixture(t)
+
+	t.Run("FS", func(t *testing.T) {
+		if err := fstest.TestFS(sys,
+			"etc/os-release", "var/lib/dpkg/status", "usr/share/big", "usr/share/empty", "tmp",
+		); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("Contents", func(t *testing.T) {
+		for n, f := range fixture {
+			if !f.Mode.IsRegular() {
+				continue
+			}
+			got, err := fs.ReadFile(sys, n)
+			if err != nil {
+				t.Error(err)
+				continue
+			}
+			if !bytes.Equal(got, f.Data) {
+				t.Errorf("%s: contents differ", n)
+			}
+		}
+	})
+
+	t.Run("Modes", func(t *testing.T) {
+		for n, f := range fixture {
+			if f.Mode.Type() == fs.ModeSymlink {
+				continue
+			}
+			fi, err := sys.Stat(n)
+			if err != nil {
+				t.Error(err)
+				continue
+			}
+			if got, want := fi.Mode(), f.Mode; got != want {
+				t.Errorf("%s: got: %v, want: %v", n, got, want)
+			}
+		}
+	})
+
+	t.Run("Symlinks", func(t *testing.T) {
+		for n, want := range map[string]string{
+			"usr/lib/os-release": "etc/os-release",
+			"lib/os-release":     "etc/os-release",
+			"bin/su":             "usr/bin/su",
+		} {
+			got, err := fs.ReadFile(sys, n)
+			if err != nil {
+				t.Error(err)
+				continue
+			}
+			if !bytes.Equal(got, fixture[want].Data) {
+				t.Errorf("%s: contents differ from %s", n, want)
+			}
+		}
+		ents, err := fs.ReadDir(sys, ".")
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, e := range ents {
+			if e.Name() == "bin" && e.Type() != fs.ModeSymlink {
+				t.Errorf("bin: got type %v", e.Type())
+			}
+		}
+	})
+
+	t.Run("Concurrent", func(t *testing.T) {
+		const lim = 8
+		var wg sync.WaitGroup
+		wg.Add(lim)
+		for i := 0; i < lim; i++ {
+			go func() {
+				defer wg.Done()
+				if err := fstest.TestFS(sys, "usr/share/big"); err != nil {
+					t.Error(err)
+				}
+			}()
+		}
+		wg.Wait()
+	})
+}
+
+// TestFS runs some sanity checks on an image generated from this package's
+// directory.
+func TestFS(t *testing.T) {
+	var buf bytes.Buffer
+	if err := squashfstest.Write(&buf, os.DirFS(".")); err != nil {
+		t.Fatal(err)
+	}
+	sys, err := New(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := fstest.TestFS(sys, "squashfs.go", "squashfs_test.go", "testdata/fixture.squashfs"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCorrupt(t *testing.T) {
+	var buf bytes.Buffer
+	if err := squashfstest.Write(&buf, fixture); err != nil {
+		t.Fatal(err)
+	}
+	img := buf.Bytes()
+
+	t.Run("NotSquashfs", func(t *testing.T) {
+		r := strings.NewReader("not a squashfs image, but long enough to hold a superblock if it were one.......")
+		if Sniff(r) {
+			t.Error("unexpectedly detected as squashfs")
+		}
+		if _, err := New(r); !errors.Is(err, ErrFormat) {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("Truncated", func(t *testing.T) {
+		_, err := New(bytes.NewReader(img[:superblockSz]))
+		if !errors.Is(err, ErrFormat) {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("Garbage", func(t *testing.T) {
+		// Clobber everything after the superblock.
+		b := make([]byte, len(img))
+		copy(b, img[:superblockSz])
+		for i := superblockSz; i < len(b); i++ {
+			b[i] = 0xA5
+		}
+		sys, err := New(bytes.NewReader(b))
+		if err == nil {
+			_, err = fs.ReadFile(sys, "etc/os-release")
+		}
+		if !errors.Is(err, ErrFormat) {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+}
+
+func TestDecompress(t *testing.T) {
+	want := bytes.Repeat([]byte("squashfs "), 512)
+	tt := []struct {
+		name string
+		id   uint16
+		mk   func(io.Writer) (io.WriteCloser, error)
+	}{
+		{
+			name: "gzip",
+			id:   compGzip,
+			mk:   func(w io.Writer) (io.WriteCloser, error) { return zlib.NewWriter(w), nil },
+		},
+		{
+			name: "lzma",
+			id:   compLZMA,
+			mk:   func(w io.Writer) (io.WriteCloser, error) { return lzma.NewWriter(w) },
+		},
+		{
+			name: "xz",
+			id:   compXZ,
+			mk:   func(w io.Writer) (io.WriteCloser, error) { return xz.NewWriter(w) },
+		},
+		{
+			name: "zstd",
+			id:   compZstd,
+			mk:   func(w io.Writer) (io.WriteCloser, error) { return zstd.NewWriter(w) },
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			w, err := tc.mk(&buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := w.Write(want); err != nil {
+				t.Fatal(err)
+			}
+			if err := w.Close(); err != nil {
+				t.Fatal(err)
+			}
+			d, err := decompressorFor(tc.id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := d(buf.Bytes(), len(want))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Error("decompressed contents differ")
+			}
+			if _, err := d(buf.Bytes(), len(want)-1); !errors.Is(err, ErrFormat) {
+				t.Errorf("unexpected error for oversized block: %v", err)
+			}
+		})
+	}
+}
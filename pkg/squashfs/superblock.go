@@ -0,0 +1,118 @@
+package squashfs
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Constants from the on-disk format. Everything is little-endian.
+const (
+	magic          = 0x73717368 // "hsqs"
+	superblockSz   = 96
+	metadataSz     = 8192
+	metadataRaw    = 1 << 15 // Set in a metadata header if the block is stored uncompressed.
+	dataRaw        = 1 << 24 // Set in a data block size if the block is stored uncompressed.
+	dataSizeMask   = dataRaw - 1
+	noFragment     = 0xFFFFFFFF
+	fragmentSz     = 16
+	fragmentsBlock = metadataSz / fragmentSz
+	maxSymlink     = 4096
+	maxLinks       = 40
+)
+
+// Compression IDs.
+const (
+	compGzip = 1 + iota
+	compLZMA
+	compLZO
+	compXZ
+	compLZ4
+	compZstd
+)
+
+var le = binary.LittleEndian
+
+// ErrFormat can be compared via [errors.Is] against errors reported by [New]
+// and the returned FS to determine if the image is considered well-formed.
+var ErrFormat = errors.New("squashfs: format error reading image")
+
+// ParseErr returns an error that .Is reports true for ErrFormat.
+//
+// The `%w` verb does not work.
+func parseErr(f string, v ...interface{}) error {
+	return parseError(fmt.Sprintf(f, v...))
+}
+
+type parseError string
+
+func (e parseError) Is(tgt error) bool { return tgt == ErrFormat }
+func (e parseError) Error() string     { return "squashfs: " + string(e) }
+
+// Superblock is the header at the start of every image.
+type superblock struct {
+	Magic         uint32
+	Inodes        uint32
+	ModTime       uint32
+	BlockSize     uint32
+	Fragments     uint32
+	Compression   uint16
+	BlockLog      uint16
+	Flags         uint16
+	IDs           uint16
+	Major         uint16
+	Minor         uint16
+	RootInode     uint64
+	BytesUsed     uint64
+	IDTable       uint64
+	XattrTable    uint64
+	InodeTable    uint64
+	DirTable      uint64
+	FragmentTable uint64
+	ExportTable   uint64
+}
+
+// Sniff reports whether the contents of "r" start with the squashfs magic
+// number.
+func Sniff(r io.ReaderAt) bool {
+	b := make([]byte, 4)
+	if _, err := r.ReadAt(b, 0); err != nil {
+		return false
+	}
+	return le.Uint32(b) == magic
+}
+
+// ReadSuperblock reads and sanity checks the superblock in "r".
+func readSuperblock(r io.ReaderAt) (*superblock, error) {
+	var sb superblock
+	if err := binary.Read(io.NewSectionReader(r, 0, superblockSz), le, &sb); err != nil {
+		return nil, parseErr("unable to read superblock: %v", err)
+	}
+	switch {
+	case sb.Magic != magic:
+		return nil, parseErr("bad magic: %#08x", sb.Magic)
+	case sb.Major != 4 || sb.Minor != 0:
+		return nil, parseErr("unsupported version: %d.%d", sb.Major, sb.Minor)
+	case sb.BlockLog < 12 || sb.BlockLog > 20 || sb.BlockSize != 1<<sb.BlockLog:
+		return nil, parseErr("bad block size: %d (log %d)", sb.BlockSize, sb.BlockLog)
+	case sb.BytesUsed < superblockSz:
+		return nil, parseErr("bad image size: %d", sb.BytesUsed)
+	}
+	for _, t := range []struct {
+		name string
+		pos  uint64
+	}{
+		{"inode", sb.InodeTable},
+		{"directory", sb.DirTable},
+	} {
+		if t.pos < superblockSz || t.pos >= sb.BytesUsed {
+			return nil, parseErr("%s table out of bounds: %d", t.name, t.pos)
+		}
+	}
+	if sb.Fragments != 0 &&
+		(sb.FragmentTable < superblockSz || sb.FragmentTable >= sb.BytesUsed) {
+		return nil, parseErr("fragment table out of bounds: %d", sb.FragmentTable)
+	}
+	return &sb, nil
+}
@@ -13,7 +13,7 @@ var _ fs.File = (*file)(nil)
 // File implements fs.File.
 type file struct {
 	h *tar.Header
-	r *tar.Reader
+	r io.Reader
 }
 
 func (f *file) Close() error {
@@ -32,6 +32,18 @@ type parseError string
 func (e parseError) Is(tgt error) bool { return tgt == ErrFormat }
 func (e parseError) Error() string     { return string(e) }
 
+// TruncatedError is returned when the archive ends in the middle of a block or
+// a member's contents. Its Is method reports true for both ErrFormat and
+// io.ErrUnexpectedEOF.
+type truncatedError int64
+
+func (e truncatedError) Is(tgt error) bool {
+	return tgt == ErrFormat || tgt == io.ErrUnexpectedEOF
+}
+func (e truncatedError) Error() string {
+	return fmt.Sprintf("unexpected EOF at %d: archive truncated", int64(e))
+}
+
 // FindSegments looks at a tar blockwise to establish where individual files and
 // their headers are stored. Each returned segment describes a region that is
 // not a complete tar file, but can have exactly one file read from it.
@@ -74,7 +86,7 @@ Scan:
 			case n == blockSz:
 				// Make sure to process the read, even if EOF was returned.
 			default:
-				return nil, parseErr("unexpected EOF at %d: %v", off, err)
+				return nil, truncatedError(off + int64(n))
 			}
 		default:
 			return nil, err
@@ -123,6 +135,16 @@ Scan:
 		}
 		blk++       // Current header block
 		blk += nBlk // File contents
+		// Make sure the contents are actually present, otherwise a
+		// truncation on a block boundary looks like a missing trailer.
+		if nBlk != 0 {
+			if _, err := r.ReadAt(b[:1], blk*blockSz-1); err != nil {
+				if errors.Is(err, io.EOF) {
+					return nil, truncatedError(off + blockSz)
+				}
+				return nil, err
+			}
+		}
 		switch b[typeflag] {
 		case tar.TypeXHeader, tar.TypeGNULongLink, tar.TypeGNULongName, tar.TypeGNUSparse:
 			// All these are prepended to a "real" entry.
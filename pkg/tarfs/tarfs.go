@@ -3,6 +3,7 @@ package tarfs
 
 import (
 	"archive/tar"
+	"bufio"
 	"fmt"
 	"io"
 	"io/fs"
@@ -12,11 +13,16 @@ import (
 	"strings"
 )
 
+// DefaultBufferSize is the size of the buffer files returned by Open read
+// through, for an FS created by New.
+const DefaultBufferSize = 64 * 1024
+
 // FS implements a filesystem abstraction over an io.ReaderAt containing a tar.
 type FS struct {
 	r      io.ReaderAt
 	lookup map[string]int
 	inode  []inode
+	bufsz  int
 }
 
 // Inode is a fake inode(7)-like structure for keeping track of filesystem
@@ -50,10 +56,22 @@ func newDir(n string) inode {
 // The ReaderAt must remain valid for the entire life of the returned FS and any
 // FSes returned by Sub.
 func New(r io.ReaderAt) (*FS, error) {
+	return NewSize(r, DefaultBufferSize)
+}
+
+// NewSize is like New, but files returned by Open read through a buffer of at
+// most "size" bytes. Files smaller than that get a buffer of their own size.
+// A size of zero or less disables buffering, so every Read on a file is a
+// read of the underlying ReaderAt.
+//
+// Larger buffers mean fewer, bigger reads of the ReaderAt, which helps with
+// large files on storage with high per-request latency.
+func NewSize(r io.ReaderAt, size int) (*FS, error) {
 	var err error
 	s := FS{
 		r:      r,
 		lookup: make(map[string]int),
+		bufsz:  size,
 	}
 	hardlink := make(map[string][]string)
 	if err := s.add(".", newDir("."), hardlink); err != nil {
@@ -369,7 +387,9 @@ func (f *FS) Open(name string) (fs.File, error) {
 			Err:  fs.ErrExist,
 		}
 	}
-	if _, err := r.Next(); err != nil {
+	// Use the size from this header, as a hardlink's own header has none.
+	h, err := r.Next()
+	if err != nil {
 		return nil, &fs.PathError{
 			Op:   op,
 			Path: name,
@@ -378,10 +398,26 @@ func (f *FS) Open(name string) (fs.File, error) {
 	}
 	return &file{
 		h: i.h,
-		r: r,
+		r: f.buffered(r, h.Size),
 	}, nil
 }
 
+// Buffered wraps "r", a reader for a file of "sz" bytes, in a buffer if the FS
+// was configured with one.
+func (f *FS) buffered(r io.Reader, sz int64) io.Reader {
+	if f.bufsz <= 0 {
+		return r
+	}
+	n := f.bufsz
+	if sz < int64(n) {
+		n = int(sz)
+	}
+	if n == 0 {
+		return r
+	}
+	return bufio.NewReaderSize(r, n)
+}
+
 // Stat implements fs.StatFS.
 func (f *FS) Stat(name string) (fs.FileInfo, error) {
 	// StatFS is implemented because it can avoid allocating an intermediate
@@ -394,6 +430,24 @@ func (f *FS) Stat(name string) (fs.FileInfo, error) {
 	return i.h.FileInfo(), nil
 }
 
+// ReadLink returns the target of the symlink "name", as a path from the root
+// of the FS.
+func (f *FS) ReadLink(name string) (string, error) {
+	const op = `readlink`
+	i, err := f.getInode(op, name)
+	if err != nil {
+		return "", err
+	}
+	if i.h.Typeflag != tar.TypeSymlink {
+		return "", &fs.PathError{
+			Op:   op,
+			Path: name,
+			Err:  fs.ErrInvalid,
+		}
+	}
+	return i.h.Linkname, nil
+}
+
 // ReadDir implements fs.ReadDirFS.
 func (f *FS) ReadDir(name string) ([]fs.DirEntry, error) {
 	// ReadDirFS is implemented because it can avoid allocating an intermediate
@@ -480,6 +534,7 @@ func (f *FS) Sub(dir string) (fs.FS, error) {
 		r:      f.r,
 		inode:  f.inode,
 		lookup: make(map[string]int),
+		bufsz:  f.bufsz,
 	}
 	for n, i := range f.lookup {
 		rel, err := filepath.Rel(bp, n)
@@ -4,6 +4,8 @@ import (
 	"archive/tar"
 	"bytes"
 	"crypto/sha256"
+	"errors"
+	"fmt"
 	"io"
 	"io/fs"
 	"os"
@@ -360,6 +362,26 @@ func TestSymlinks(t *testing.T) {
 			t.Error("unexpected stat: ", fi.Name(), fi.IsDir())
 		}
 	}))
+	t.Run("ReadLink", run(false, []tar.Header{
+		{Name: `a/c`},
+		{
+			Typeflag: tar.TypeSymlink,
+			Name:     `a/b`,
+			Linkname: `c`,
+		},
+	}, func(t *testing.T, sys fs.FS) {
+		rl := sys.(*FS)
+		tgt, err := rl.ReadLink("a/b")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := tgt, "a/c"; got != want {
+			t.Errorf("got: %q, want: %q", got, want)
+		}
+		if _, err := rl.ReadLink("a/c"); !errors.Is(err, fs.ErrInvalid) {
+			t.Errorf("got: %v, want: %v", err, fs.ErrInvalid)
+		}
+	}))
 }
 
 func TestKnownLayers(t *testing.T) {
@@ -490,3 +512,206 @@ func TestTarConcatenate(t *testing.T) {
 		}
 	}
 }
+
+func TestTruncated(t *testing.T) {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	if err := tw.WriteHeader(&tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     "file",
+		Size:     2048,
+		Mode:     0o644,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tw.Write(make([]byte, 2048)); err != nil {
+		t.Fatal(err)
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	b := buf.Bytes()
+
+	// The complete archive, and one missing its trailer, are fine.
+	for _, n := range []int{len(b), 512 + 2048} {
+		if _, err := New(bytes.NewReader(b[:n])); err != nil {
+			t.Errorf("%d bytes: unexpected error: %v", n, err)
+		}
+	}
+	for _, n := range []int{
+		300,        // In the header.
+		512 + 100,  // In the contents.
+		512 + 1024, // In the contents, on a block boundary.
+	} {
+		_, err := New(bytes.NewReader(b[:n]))
+		t.Logf("%d bytes: %v", n, err)
+		if !errors.Is(err, ErrFormat) || !errors.Is(err, io.ErrUnexpectedEOF) {
+			t.Errorf("%d bytes: unexpected error: %v", n, err)
+		}
+	}
+}
+
+// CountingReaderAt counts calls to ReadAt.
+type countingReaderAt struct {
+	r io.ReaderAt
+	n int
+}
+
+func (c *countingReaderAt) ReadAt(b []byte, off int64) (int, error) {
+	c.n++
+	return c.r.ReadAt(b, off)
+}
+
+// Bigtar returns a tar with a file "big" of "sz" bytes, a small file, and a
+// hardlink to "big".
+func bigtar(t testing.TB, w io.Writer, sz int64) {
+	t.Helper()
+	tw := tar.NewWriter(w)
+	if err := tw.WriteHeader(&tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     "big",
+		Size:     sz,
+		Mode:     0o644,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	// Some non-repeating content, so misplaced reads show up.
+	h := sha256.New()
+	var sum []byte
+	for n := int64(0); n < sz; n += int64(len(sum)) {
+		h.Write(sum)
+		sum = h.Sum(sum[:0])
+		if rem := sz - n; rem < int64(len(sum)) {
+			sum = sum[:rem]
+		}
+		if _, err := tw.Write(sum); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.WriteHeader(&tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     "small",
+		Size:     5,
+		Mode:     0o644,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tw.Write([]byte("small")); err != nil {
+		t.Fatal(err)
+	}
+	if err := tw.WriteHeader(&tar.Header{
+		Typeflag: tar.TypeLink,
+		Name:     "link",
+		Linkname: "big",
+		Mode:     0o644,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBufferSize(t *testing.T) {
+	const sz = 1024 * 1024
+	var buf bytes.Buffer
+	bigtar(t, &buf, sz)
+	ref, err := NewSize(bytes.NewReader(buf.Bytes()), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := ref.ReadFile("big")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Read in small chunks, like a scanner using a default bufio.Reader.
+	read := func(t *testing.T, sys fs.FS, name string) []byte {
+		t.Helper()
+		f, err := sys.Open(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		var out bytes.Buffer
+		b := make([]byte, 512)
+		for {
+			n, err := f.Read(b)
+			out.Write(b[:n])
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		return out.Bytes()
+	}
+
+	reads := make(map[int]int)
+	for _, size := range []int{-1, 0, 16, 4096, DefaultBufferSize} {
+		cr := &countingReaderAt{r: bytes.NewReader(buf.Bytes())}
+		sys, err := NewSize(cr, size)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cr.n = 0
+		for _, name := range []string{"big", "link"} {
+			if got := read(t, sys, name); !bytes.Equal(got, want) {
+				t.Errorf("size %d: %s: contents differ", size, name)
+			}
+		}
+		if got := read(t, sys, "small"); string(got) != "small" {
+			t.Errorf("size %d: small: got %q", size, got)
+		}
+		reads[size] = cr.n
+		t.Logf("size %d: %d reads", size, cr.n)
+	}
+	if reads[DefaultBufferSize] >= reads[4096] || reads[4096] >= reads[0] {
+		t.Errorf("larger buffers should mean fewer reads: %v", reads)
+	}
+	if reads[-1] != reads[0] {
+		t.Errorf("negative and zero sizes should both be unbuffered: %v", reads)
+	}
+}
+
+// BenchmarkLargeFile reads a large file out of a tar on disk in small chunks,
+// with different buffer sizes.
+func BenchmarkLargeFile(b *testing.B) {
+	const sz = 64 * 1024 * 1024
+	f, err := os.Create(filepath.Join(b.TempDir(), "large.tar"))
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer f.Close()
+	bigtar(b, f, sz)
+
+	for _, size := range []int{0, 4096, DefaultBufferSize, 1024 * 1024} {
+		b.Run(fmt.Sprintf("Buffer%d", size), func(b *testing.B) {
+			sys, err := NewSize(f, size)
+			if err != nil {
+				b.Fatal(err)
+			}
+			buf := make([]byte, 4096)
+			b.SetBytes(sz)
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				f, err := sys.Open("big")
+				if err != nil {
+					b.Fatal(err)
+				}
+				for {
+					_, err := f.Read(buf)
+					if errors.Is(err, io.EOF) {
+						break
+					}
+					if err != nil {
+						b.Fatal(err)
+					}
+				}
+				f.Close()
+			}
+		})
+	}
+}
@@ -0,0 +1,101 @@
+// Package tracing is a small facade for distributed tracing.
+//
+// Claircore starts spans around layer scanning and vulnerability matching via
+// this package. By default the spans are discarded, so there's no dependency
+// on any tracing library. To export them, implement Tracer on top of the
+// tracing library of choice and install it with Set.
+//
+// For example, an adapter for OpenTelemetry looks like:
+//
+//	type otelTracer struct{ t trace.Tracer }
+//
+//	func (o otelTracer) Start(ctx context.Context, name string, attrs ...tracing.Attr) (context.Context, tracing.Span) {
+//		kvs := make([]attribute.KeyValue, len(attrs))
+//		for i, a := range attrs {
+//			kvs[i] = attribute.String(a.Key, a.Value)
+//		}
+//		ctx, s := o.t.Start(ctx, name, trace.WithAttributes(kvs...))
+//		return ctx, otelSpan{s}
+//	}
+//
+//	type otelSpan struct{ s trace.Span }
+//
+//	func (o otelSpan) RecordError(err error) {
+//		o.s.RecordError(err)
+//		o.s.SetStatus(codes.Error, err.Error())
+//	}
+//
+//	func (o otelSpan) End() { o.s.End() }
+//
+// Installed with:
+//
+//	tracing.Set(otelTracer{otel.Tracer("github.com/quay/claircore")})
+package tracing
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// Tracer starts Spans.
+type Tracer interface {
+	// Start begins a Span named "name" as a child of any Span in the passed
+	// Context, and returns a Context carrying the new Span.
+	Start(ctx context.Context, name string, attrs ...Attr) (context.Context, Span)
+}
+
+// Span is a single traced operation.
+type Span interface {
+	// RecordError marks the Span as failed with the provided error.
+	RecordError(error)
+	// End completes the Span. No methods should be called after End.
+	End()
+}
+
+// Attr is a key-value pair describing a Span.
+type Attr struct {
+	Key   string
+	Value string
+}
+
+// String returns an Attr with the provided key and value.
+func String(k, v string) Attr {
+	return Attr{Key: k, Value: v}
+}
+
+// Cur is the Tracer used by Start.
+var cur atomic.Value
+
+type holder struct{ Tracer }
+
+func init() {
+	cur.Store(holder{noop{}})
+}
+
+// Set configures the Tracer used by this package. Passing nil restores the
+// default, which discards all Spans.
+//
+// Spans already started are unaffected.
+func Set(t Tracer) {
+	if t == nil {
+		t = noop{}
+	}
+	cur.Store(holder{t})
+}
+
+// Start begins a Span using the Tracer configured with Set.
+//
+// The returned Span must be ended by the caller.
+func Start(ctx context.Context, name string, attrs ...Attr) (context.Context, Span) {
+	return cur.Load().(holder).Start(ctx, name, attrs...)
+}
+
+// Noop is the default Tracer.
+type noop struct{}
+
+func (noop) Start(ctx context.Context, _ string, _ ...Attr) (context.Context, Span) {
+	return ctx, noop{}
+}
+
+func (noop) RecordError(error) {}
+func (noop) End()              {}
@@ -0,0 +1,46 @@
+package tracing
+
+import (
+	"context"
+	"testing"
+)
+
+type ctxKey struct{}
+
+type testTracer struct{ started []string }
+
+func (t *testTracer) Start(ctx context.Context, name string, _ ...Attr) (context.Context, Span) {
+	t.started = append(t.started, name)
+	return context.WithValue(ctx, ctxKey{}, name), noop{}
+}
+
+func TestSet(t *testing.T) {
+	ctx := context.Background()
+	t.Cleanup(func() { Set(nil) })
+
+	got, s := Start(ctx, "default")
+	if got != ctx {
+		t.Error("default Tracer modified the Context")
+	}
+	s.RecordError(nil)
+	s.End()
+
+	tt := &testTracer{}
+	Set(tt)
+	got, s = Start(ctx, "set", String("k", "v"))
+	s.End()
+	if v, _ := got.Value(ctxKey{}).(string); v != "set" {
+		t.Errorf("got Context value %q, want %q", v, "set")
+	}
+	if len(tt.started) != 1 {
+		t.Errorf("got %d started spans, want 1", len(tt.started))
+	}
+
+	Set(nil)
+	if got, _ := Start(ctx, "reset"); got != ctx {
+		t.Error("Set(nil) didn't restore the default Tracer")
+	}
+	if len(tt.started) != 1 {
+		t.Errorf("got %d started spans, want 1", len(tt.started))
+	}
+}
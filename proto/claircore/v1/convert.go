@@ -0,0 +1,741 @@
+package claircorev1
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/quay/claircore"
+	"github.com/quay/claircore/pkg/cpe"
+)
+
+// IndexReportToProto returns the protobuf form of the provided IndexReport.
+func IndexReportToProto(r *claircore.IndexReport) *IndexReport {
+	if r == nil {
+		return nil
+	}
+	out := &IndexReport{
+		SchemaVersion:       int32(r.SchemaVersion),
+		ManifestHash:        r.Hash.String(),
+		State:               r.State,
+		Packages:            make(map[string]*Package, len(r.Packages)),
+		Distributions:       make(map[string]*Distribution, len(r.Distributions)),
+		Repositories:        make(map[string]*Repository, len(r.Repositories)),
+		Environments:        make(map[string]*Environments, len(r.Environments)),
+		PrimaryDistribution: r.PrimaryDistribution,
+		DistributionTag:     r.DistributionTag,
+		ActiveKernel:        r.ActiveKernel,
+		ImageConfig:         imageConfigToProto(r.ImageConfig),
+		CanonicalPackages:   r.CanonicalPackages,
+		Warnings:            r.Warnings,
+		Success:             r.Success,
+		Err:                 r.Err,
+	}
+	for k, v := range r.Packages {
+		out.Packages[k] = PackageToProto(v)
+	}
+	for k, v := range r.Distributions {
+		out.Distributions[k] = distToProto(v)
+	}
+	for k, v := range r.Repositories {
+		out.Repositories[k] = repoToProto(v)
+	}
+	for k, v := range r.Environments {
+		out.Environments[k] = envsToProto(v)
+	}
+	for _, m := range r.EmbeddedManifests {
+		out.EmbeddedManifests = append(out.EmbeddedManifests, &EmbeddedManifest{
+			ManifestHash: m.Manifest.String(),
+			Layer:        m.Layer.String(),
+			Path:         m.Path,
+		})
+	}
+	if s := r.Stats; s != nil {
+		out.Stats = &IndexStats{
+			Layers:        int64(s.Layers),
+			LayersScanned: int64(s.LayersScanned),
+			Bytes:         s.Bytes,
+		}
+		for _, d := range s.LayersSkipped {
+			out.Stats.LayersSkipped = append(out.Stats.LayersSkipped, d.String())
+		}
+		for _, d := range s.LayersTruncated {
+			out.Stats.LayersTruncated = append(out.Stats.LayersTruncated, d.String())
+		}
+		if s.Packages != nil {
+			out.Stats.Packages = make(map[string]int64, len(s.Packages))
+			for k, v := range s.Packages {
+				out.Stats.Packages[k] = int64(v)
+			}
+		}
+	}
+	return out
+}
+
+// IndexReportFromProto returns the IndexReport described by the provided
+// protobuf message.
+func IndexReportFromProto(r *IndexReport) (*claircore.IndexReport, error) {
+	if r == nil {
+		return nil, nil
+	}
+	var err error
+	out := &claircore.IndexReport{
+		SchemaVersion:       int(r.GetSchemaVersion()),
+		State:               r.GetState(),
+		Packages:            make(map[string]*claircore.Package, len(r.GetPackages())),
+		Distributions:       make(map[string]*claircore.Distribution, len(r.GetDistributions())),
+		Repositories:        make(map[string]*claircore.Repository, len(r.GetRepositories())),
+		Environments:        make(map[string][]*claircore.Environment, len(r.GetEnvironments())),
+		PrimaryDistribution: r.GetPrimaryDistribution(),
+		DistributionTag:     r.GetDistributionTag(),
+		ActiveKernel:        r.GetActiveKernel(),
+		ImageConfig:         imageConfigFromProto(r.GetImageConfig()),
+		CanonicalPackages:   r.GetCanonicalPackages(),
+		Warnings:            r.GetWarnings(),
+		Success:             r.GetSuccess(),
+		Err:                 r.GetErr(),
+	}
+	if out.Hash, err = parseDigest(r.GetManifestHash()); err != nil {
+		return nil, fmt.Errorf("manifest_hash: %w", err)
+	}
+	for k, v := range r.GetPackages() {
+		if out.Packages[k], err = PackageFromProto(v); err != nil {
+			return nil, fmt.Errorf("package %q: %w", k, err)
+		}
+	}
+	for k, v := range r.GetDistributions() {
+		if out.Distributions[k], err = distFromProto(v); err != nil {
+			return nil, fmt.Errorf("distribution %q: %w", k, err)
+		}
+	}
+	for k, v := range r.GetRepositories() {
+		if out.Repositories[k], err = repoFromProto(v); err != nil {
+			return nil, fmt.Errorf("repository %q: %w", k, err)
+		}
+	}
+	for k, v := range r.GetEnvironments() {
+		if out.Environments[k], err = envsFromProto(v); err != nil {
+			return nil, fmt.Errorf("environments %q: %w", k, err)
+		}
+	}
+	for _, m := range r.GetEmbeddedManifests() {
+		em := claircore.EmbeddedManifest{Path: m.GetPath()}
+		if em.Manifest, err = parseDigest(m.GetManifestHash()); err != nil {
+			return nil, fmt.Errorf("embedded manifest %q: %w", m.GetPath(), err)
+		}
+		if em.Layer, err = parseDigest(m.GetLayer()); err != nil {
+			return nil, fmt.Errorf("embedded manifest %q: %w", m.GetPath(), err)
+		}
+		out.EmbeddedManifests = append(out.EmbeddedManifests, em)
+	}
+	if s := r.GetStats(); s != nil {
+		out.Stats = &claircore.IndexStats{
+			Layers:        int(s.GetLayers()),
+			LayersScanned: int(s.GetLayersScanned()),
+			Bytes:         s.GetBytes(),
+		}
+		for _, l := range s.GetLayersSkipped() {
+			d, err := parseDigest(l)
+			if err != nil {
+				return nil, fmt.Errorf("stats: %w", err)
+			}
+			out.Stats.LayersSkipped = append(out.Stats.LayersSkipped, d)
+		}
+		for _, l := range s.GetLayersTruncated() {
+			d, err := parseDigest(l)
+			if err != nil {
+				return nil, fmt.Errorf("stats: %w", err)
+			}
+			out.Stats.LayersTruncated = append(out.Stats.LayersTruncated, d)
+		}
+		if ps := s.GetPackages(); ps != nil {
+			out.Stats.Packages = make(map[string]int, len(ps))
+			for k, v := range ps {
+				out.Stats.Packages[k] = int(v)
+			}
+		}
+	}
+	return out, nil
+}
+
+// VulnerabilityReportToProto returns the protobuf form of the provided
+// VulnerabilityReport.
+func VulnerabilityReportToProto(r *claircore.VulnerabilityReport) *VulnerabilityReport {
+	if r == nil {
+		return nil
+	}
+	out := &VulnerabilityReport{
+		SchemaVersion:            int32(r.SchemaVersion),
+		ManifestHash:             r.Hash.String(),
+		Packages:                 make(map[string]*Package, len(r.Packages)),
+		Distributions:            make(map[string]*Distribution, len(r.Distributions)),
+		Repositories:             make(map[string]*Repository, len(r.Repositories)),
+		Environments:             make(map[string]*Environments, len(r.Environments)),
+		Vulnerabilities:          make(map[string]*Vulnerability, len(r.Vulnerabilities)),
+		PackageVulnerabilities:   stringListsToProto(r.PackageVulnerabilities),
+		Enrichments:              make(map[string]*StringList, len(r.Enrichments)),
+		InheritedVulnerabilities: r.InheritedVulnerabilities,
+		ConditionallyAffected:    r.ConditionallyAffected,
+		LowConfidence:            r.LowConfidence,
+		FixedVersions:            r.FixedVersions,
+		VulnerabilityManifests:   stringListsToProto(r.VulnerabilityManifests),
+	}
+	for k, v := range r.Packages {
+		out.Packages[k] = PackageToProto(v)
+	}
+	for k, v := range r.Distributions {
+		out.Distributions[k] = distToProto(v)
+	}
+	for k, v := range r.Repositories {
+		out.Repositories[k] = repoToProto(v)
+	}
+	for k, v := range r.Environments {
+		out.Environments[k] = envsToProto(v)
+	}
+	for k, v := range r.Vulnerabilities {
+		out.Vulnerabilities[k] = VulnerabilityToProto(v)
+	}
+	for k, v := range r.Enrichments {
+		l := &StringList{Values: make([]string, len(v))}
+		for i, m := range v {
+			l.Values[i] = string(m)
+		}
+		out.Enrichments[k] = l
+	}
+	if r.IgnoredVulnerabilities != nil {
+		out.IgnoredVulnerabilities = make(map[string]*IgnoredVulnerabilities, len(r.IgnoredVulnerabilities))
+		for k, v := range r.IgnoredVulnerabilities {
+			l := &IgnoredVulnerabilities{Ignored: make([]*IgnoredVulnerability, len(v))}
+			for i, iv := range v {
+				l.Ignored[i] = &IgnoredVulnerability{
+					Id:     iv.ID,
+					Name:   iv.Name,
+					Reason: iv.Reason,
+				}
+				if iv.Expires != nil {
+					l.Ignored[i].Expires = timestamppb.New(*iv.Expires)
+				}
+			}
+			out.IgnoredVulnerabilities[k] = l
+		}
+	}
+	if s := r.Stats; s != nil {
+		out.Stats = &MatchStats{}
+		if s.Matchers != nil {
+			out.Stats.Matchers = make(map[string]*durationpb.Duration, len(s.Matchers))
+			for k, v := range s.Matchers {
+				out.Stats.Matchers[k] = durationpb.New(v)
+			}
+		}
+	}
+	return out
+}
+
+// VulnerabilityReportFromProto returns the VulnerabilityReport described by
+// the provided protobuf message.
+func VulnerabilityReportFromProto(r *VulnerabilityReport) (*claircore.VulnerabilityReport, error) {
+	if r == nil {
+		return nil, nil
+	}
+	var err error
+	out := &claircore.VulnerabilityReport{
+		SchemaVersion:            int(r.GetSchemaVersion()),
+		Packages:                 make(map[string]*claircore.Package, len(r.GetPackages())),
+		Distributions:            make(map[string]*claircore.Distribution, len(r.GetDistributions())),
+		Repositories:             make(map[string]*claircore.Repository, len(r.GetRepositories())),
+		Environments:             make(map[string][]*claircore.Environment, len(r.GetEnvironments())),
+		Vulnerabilities:          make(map[string]*claircore.Vulnerability, len(r.GetVulnerabilities())),
+		PackageVulnerabilities:   stringListsFromProto(r.GetPackageVulnerabilities()),
+		Enrichments:              make(map[string][]json.RawMessage, len(r.GetEnrichments())),
+		InheritedVulnerabilities: r.GetInheritedVulnerabilities(),
+		ConditionallyAffected:    r.GetConditionallyAffected(),
+		LowConfidence:            r.GetLowConfidence(),
+		FixedVersions:            r.GetFixedVersions(),
+		VulnerabilityManifests:   stringListsFromProto(r.GetVulnerabilityManifests()),
+	}
+	if out.Hash, err = parseDigest(r.GetManifestHash()); err != nil {
+		return nil, fmt.Errorf("manifest_hash: %w", err)
+	}
+	for k, v := range r.GetPackages() {
+		if out.Packages[k], err = PackageFromProto(v); err != nil {
+			return nil, fmt.Errorf("package %q: %w", k, err)
+		}
+	}
+	for k, v := range r.GetDistributions() {
+		if out.Distributions[k], err = distFromProto(v); err != nil {
+			return nil, fmt.Errorf("distribution %q: %w", k, err)
+		}
+	}
+	for k, v := range r.GetRepositories() {
+		if out.Repositories[k], err = repoFromProto(v); err != nil {
+			return nil, fmt.Errorf("repository %q: %w", k, err)
+		}
+	}
+	for k, v := range r.GetEnvironments() {
+		if out.Environments[k], err = envsFromProto(v); err != nil {
+			return nil, fmt.Errorf("environments %q: %w", k, err)
+		}
+	}
+	for k, v := range r.GetVulnerabilities() {
+		if out.Vulnerabilities[k], err = VulnerabilityFromProto(v); err != nil {
+			return nil, fmt.Errorf("vulnerability %q: %w", k, err)
+		}
+	}
+	for k, v := range r.GetEnrichments() {
+		ms := make([]json.RawMessage, len(v.GetValues()))
+		for i, s := range v.GetValues() {
+			if !json.Valid([]byte(s)) {
+				return nil, fmt.Errorf("enrichment %q: invalid JSON", k)
+			}
+			ms[i] = json.RawMessage(s)
+		}
+		out.Enrichments[k] = ms
+	}
+	if ivs := r.GetIgnoredVulnerabilities(); ivs != nil {
+		out.IgnoredVulnerabilities = make(map[string][]claircore.IgnoredVulnerability, len(ivs))
+		for k, v := range ivs {
+			l := make([]claircore.IgnoredVulnerability, len(v.GetIgnored()))
+			for i, iv := range v.GetIgnored() {
+				l[i] = claircore.IgnoredVulnerability{
+					ID:     iv.GetId(),
+					Name:   iv.GetName(),
+					Reason: iv.GetReason(),
+				}
+				if ts := iv.GetExpires(); ts != nil {
+					if err := ts.CheckValid(); err != nil {
+						return nil, fmt.Errorf("ignored vulnerability %q: expires: %w", iv.GetId(), err)
+					}
+					t := ts.AsTime()
+					l[i].Expires = &t
+				}
+			}
+			out.IgnoredVulnerabilities[k] = l
+		}
+	}
+	if s := r.GetStats(); s != nil {
+		out.Stats = &claircore.MatchStats{}
+		if ms := s.GetMatchers(); ms != nil {
+			out.Stats.Matchers = make(map[string]time.Duration, len(ms))
+			for k, v := range ms {
+				if err := v.CheckValid(); err != nil {
+					return nil, fmt.Errorf("stats: matcher %q: %w", k, err)
+				}
+				out.Stats.Matchers[k] = v.AsDuration()
+			}
+		}
+	}
+	return out, nil
+}
+
+// PackageToProto returns the protobuf form of the provided Package.
+//
+// Fields that are not part of the Package's JSON form are not carried over.
+func PackageToProto(p *claircore.Package) *Package {
+	if p == nil {
+		return nil
+	}
+	out := &Package{
+		Id:                p.ID,
+		Name:              p.Name,
+		Version:           p.Version,
+		Kind:              p.Kind,
+		Source:            PackageToProto(p.Source),
+		NormalizedVersion: versionToProto(p.NormalizedVersion),
+		Module:            p.Module,
+		Arch:              p.Arch,
+		Cpe:               cpeToProto(p.CPE),
+		Provides:          p.Provides,
+		Depends:           p.Depends,
+		Confidence:        string(p.Confidence),
+	}
+	if p.IntroducedIn != nil {
+		out.IntroducedIn = p.IntroducedIn.String()
+	}
+	if p.PresentIn != nil {
+		out.PresentIn = p.PresentIn.String()
+	}
+	return out
+}
+
+// PackageFromProto returns the Package described by the provided protobuf
+// message.
+func PackageFromProto(p *Package) (*claircore.Package, error) {
+	if p == nil {
+		return nil, nil
+	}
+	var err error
+	out := &claircore.Package{
+		ID:                p.GetId(),
+		Name:              p.GetName(),
+		Version:           p.GetVersion(),
+		Kind:              p.GetKind(),
+		NormalizedVersion: versionFromProto(p.GetNormalizedVersion()),
+		Module:            p.GetModule(),
+		Arch:              p.GetArch(),
+		Provides:          p.GetProvides(),
+		Depends:           p.GetDepends(),
+		Confidence:        claircore.Confidence(p.GetConfidence()),
+	}
+	if out.Source, err = PackageFromProto(p.GetSource()); err != nil {
+		return nil, fmt.Errorf("source: %w", err)
+	}
+	if out.CPE, err = cpeFromProto(p.GetCpe()); err != nil {
+		return nil, err
+	}
+	if out.IntroducedIn, err = parseDigestPtr(p.GetIntroducedIn()); err != nil {
+		return nil, fmt.Errorf("introduced_in: %w", err)
+	}
+	if out.PresentIn, err = parseDigestPtr(p.GetPresentIn()); err != nil {
+		return nil, fmt.Errorf("present_in: %w", err)
+	}
+	return out, nil
+}
+
+// VulnerabilityToProto returns the protobuf form of the provided
+// Vulnerability.
+func VulnerabilityToProto(v *claircore.Vulnerability) *Vulnerability {
+	if v == nil {
+		return nil
+	}
+	out := &Vulnerability{
+		Id:                 v.ID,
+		Updater:            v.Updater,
+		Name:               v.Name,
+		Description:        v.Description,
+		Links:              v.Links,
+		Severity:           v.Severity,
+		NormalizedSeverity: severityToProto(v.NormalizedSeverity),
+		Package:            PackageToProto(v.Package),
+		Distribution:       distToProto(v.Dist),
+		Repository:         repoToProto(v.Repo),
+		FixedInVersion:     v.FixedInVersion,
+		ArchOp:             archOpToProto(v.ArchOperation),
+		AlwaysAffected:     v.AlwaysAffected,
+		FixState:           v.FixState,
+		Condition:          v.Condition,
+	}
+	if !v.Issued.IsZero() {
+		out.Issued = timestamppb.New(v.Issued)
+	}
+	if v.Range != nil {
+		out.Range = &Range{
+			Lower: versionToProto(v.Range.Lower),
+			Upper: versionToProto(v.Range.Upper),
+		}
+	}
+	return out
+}
+
+// VulnerabilityFromProto returns the Vulnerability described by the provided
+// protobuf message.
+func VulnerabilityFromProto(v *Vulnerability) (*claircore.Vulnerability, error) {
+	if v == nil {
+		return nil, nil
+	}
+	var err error
+	out := &claircore.Vulnerability{
+		ID:             v.GetId(),
+		Updater:        v.GetUpdater(),
+		Name:           v.GetName(),
+		Description:    v.GetDescription(),
+		Links:          v.GetLinks(),
+		Severity:       v.GetSeverity(),
+		FixedInVersion: v.GetFixedInVersion(),
+		AlwaysAffected: v.GetAlwaysAffected(),
+		FixState:       v.GetFixState(),
+		Condition:      v.GetCondition(),
+	}
+	if out.NormalizedSeverity, err = severityFromProto(v.GetNormalizedSeverity()); err != nil {
+		return nil, err
+	}
+	if out.ArchOperation, err = archOpFromProto(v.GetArchOp()); err != nil {
+		return nil, err
+	}
+	if ts := v.GetIssued(); ts != nil {
+		if err := ts.CheckValid(); err != nil {
+			return nil, fmt.Errorf("issued: %w", err)
+		}
+		out.Issued = ts.AsTime()
+	}
+	if out.Package, err = PackageFromProto(v.GetPackage()); err != nil {
+		return nil, fmt.Errorf("package: %w", err)
+	}
+	if out.Dist, err = distFromProto(v.GetDistribution()); err != nil {
+		return nil, fmt.Errorf("distribution: %w", err)
+	}
+	if out.Repo, err = repoFromProto(v.GetRepository()); err != nil {
+		return nil, fmt.Errorf("repository: %w", err)
+	}
+	if r := v.GetRange(); r != nil {
+		out.Range = &claircore.Range{
+			Lower: versionFromProto(r.GetLower()),
+			Upper: versionFromProto(r.GetUpper()),
+		}
+	}
+	return out, nil
+}
+
+func distToProto(d *claircore.Distribution) *Distribution {
+	if d == nil {
+		return nil
+	}
+	return &Distribution{
+		Id:              d.ID,
+		Did:             d.DID,
+		Name:            d.Name,
+		Version:         d.Version,
+		VersionCodeName: d.VersionCodeName,
+		VersionId:       d.VersionID,
+		Arch:            d.Arch,
+		Cpe:             cpeToProto(d.CPE),
+		PrettyName:      d.PrettyName,
+	}
+}
+
+func distFromProto(d *Distribution) (*claircore.Distribution, error) {
+	if d == nil {
+		return nil, nil
+	}
+	c, err := cpeFromProto(d.GetCpe())
+	if err != nil {
+		return nil, err
+	}
+	return &claircore.Distribution{
+		ID:              d.GetId(),
+		DID:             d.GetDid(),
+		Name:            d.GetName(),
+		Version:         d.GetVersion(),
+		VersionCodeName: d.GetVersionCodeName(),
+		VersionID:       d.GetVersionId(),
+		Arch:            d.GetArch(),
+		CPE:             c,
+		PrettyName:      d.GetPrettyName(),
+	}, nil
+}
+
+func repoToProto(r *claircore.Repository) *Repository {
+	if r == nil {
+		return nil
+	}
+	return &Repository{
+		Id:   r.ID,
+		Name: r.Name,
+		Key:  r.Key,
+		Uri:  r.URI,
+		Cpe:  cpeToProto(r.CPE),
+	}
+}
+
+func repoFromProto(r *Repository) (*claircore.Repository, error) {
+	if r == nil {
+		return nil, nil
+	}
+	c, err := cpeFromProto(r.GetCpe())
+	if err != nil {
+		return nil, err
+	}
+	return &claircore.Repository{
+		ID:   r.GetId(),
+		Name: r.GetName(),
+		Key:  r.GetKey(),
+		URI:  r.GetUri(),
+		CPE:  c,
+	}, nil
+}
+
+func imageConfigToProto(c *claircore.ImageConfig) *ImageConfig {
+	if c == nil {
+		return nil
+	}
+	return &ImageConfig{
+		User:         c.User,
+		Entrypoint:   c.Entrypoint,
+		Cmd:          c.Cmd,
+		ExposedPorts: c.ExposedPorts,
+		Env:          c.Env,
+		WorkingDir:   c.WorkingDir,
+	}
+}
+
+func imageConfigFromProto(c *ImageConfig) *claircore.ImageConfig {
+	if c == nil {
+		return nil
+	}
+	return &claircore.ImageConfig{
+		User:         c.GetUser(),
+		Entrypoint:   c.GetEntrypoint(),
+		Cmd:          c.GetCmd(),
+		ExposedPorts: c.GetExposedPorts(),
+		Env:          c.GetEnv(),
+		WorkingDir:   c.GetWorkingDir(),
+	}
+}
+
+func envsToProto(es []*claircore.Environment) *Environments {
+	out := &Environments{Environments: make([]*Environment, len(es))}
+	for i, e := range es {
+		out.Environments[i] = &Environment{
+			PackageDb:      e.PackageDB,
+			IntroducedIn:   e.IntroducedIn.String(),
+			DistributionId: e.DistributionID,
+			RepositoryIds:  e.RepositoryIDs,
+		}
+	}
+	return out
+}
+
+func envsFromProto(es *Environments) ([]*claircore.Environment, error) {
+	out := make([]*claircore.Environment, len(es.GetEnvironments()))
+	for i, e := range es.GetEnvironments() {
+		d, err := parseDigest(e.GetIntroducedIn())
+		if err != nil {
+			return nil, fmt.Errorf("introduced_in: %w", err)
+		}
+		out[i] = &claircore.Environment{
+			PackageDB:      e.GetPackageDb(),
+			IntroducedIn:   d,
+			DistributionID: e.GetDistributionId(),
+			RepositoryIDs:  e.GetRepositoryIds(),
+		}
+	}
+	return out, nil
+}
+
+func stringListsToProto(m map[string][]string) map[string]*StringList {
+	if m == nil {
+		return nil
+	}
+	out := make(map[string]*StringList, len(m))
+	for k, v := range m {
+		out[k] = &StringList{Values: v}
+	}
+	return out
+}
+
+func stringListsFromProto(m map[string]*StringList) map[string][]string {
+	if m == nil {
+		return nil
+	}
+	out := make(map[string][]string, len(m))
+	for k, v := range m {
+		out[k] = v.GetValues()
+	}
+	return out
+}
+
+func versionToProto(v claircore.Version) *Version {
+	if v == (claircore.Version{}) {
+		return nil
+	}
+	return &Version{Kind: v.Kind, V: v.V[:]}
+}
+
+func versionFromProto(v *Version) (out claircore.Version) {
+	out.Kind = v.GetKind()
+	copy(out.V[:], v.GetV())
+	return out
+}
+
+// CpeToProto uses the formatted string binding, with the zero WFN mapping to
+// the empty string.
+func cpeToProto(w cpe.WFN) string {
+	if errors.Is(w.Valid(), cpe.ErrUnset) {
+		return ""
+	}
+	return w.BindFS()
+}
+
+func cpeFromProto(s string) (cpe.WFN, error) {
+	if s == "" {
+		return cpe.WFN{}, nil
+	}
+	w, err := cpe.Unbind(s)
+	if err != nil {
+		return cpe.WFN{}, fmt.Errorf("cpe: %w", err)
+	}
+	return w, nil
+}
+
+// ParseDigest maps the empty string to the zero Digest.
+func parseDigest(s string) (claircore.Digest, error) {
+	if s == "" {
+		return claircore.Digest{}, nil
+	}
+	return claircore.ParseDigest(s)
+}
+
+// ParseDigestPtr maps the empty string to nil.
+func parseDigestPtr(s string) (*claircore.Digest, error) {
+	if s == "" {
+		return nil, nil
+	}
+	d, err := claircore.ParseDigest(s)
+	if err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+func severityToProto(s claircore.Severity) Severity {
+	switch s {
+	case claircore.Negligible:
+		return Severity_SEVERITY_NEGLIGIBLE
+	case claircore.Low:
+		return Severity_SEVERITY_LOW
+	case claircore.Medium:
+		return Severity_SEVERITY_MEDIUM
+	case claircore.High:
+		return Severity_SEVERITY_HIGH
+	case claircore.Critical:
+		return Severity_SEVERITY_CRITICAL
+	}
+	return Severity_SEVERITY_UNKNOWN
+}
+
+func severityFromProto(s Severity) (claircore.Severity, error) {
+	switch s {
+	case Severity_SEVERITY_UNKNOWN:
+		return claircore.Unknown, nil
+	case Severity_SEVERITY_NEGLIGIBLE:
+		return claircore.Negligible, nil
+	case Severity_SEVERITY_LOW:
+		return claircore.Low, nil
+	case Severity_SEVERITY_MEDIUM:
+		return claircore.Medium, nil
+	case Severity_SEVERITY_HIGH:
+		return claircore.High, nil
+	case Severity_SEVERITY_CRITICAL:
+		return claircore.Critical, nil
+	}
+	return claircore.Unknown, fmt.Errorf("unknown severity %d", s)
+}
+
+func archOpToProto(o claircore.ArchOp) ArchOp {
+	switch o {
+	case claircore.OpEquals:
+		return ArchOp_ARCH_OP_EQUALS
+	case claircore.OpNotEquals:
+		return ArchOp_ARCH_OP_NOT_EQUALS
+	case claircore.OpPatternMatch:
+		return ArchOp_ARCH_OP_PATTERN_MATCH
+	}
+	return ArchOp_ARCH_OP_UNSPECIFIED
+}
+
+func archOpFromProto(o ArchOp) (claircore.ArchOp, error) {
+	switch o {
+	case ArchOp_ARCH_OP_UNSPECIFIED:
+		return claircore.ArchOp(0), nil
+	case ArchOp_ARCH_OP_EQUALS:
+		return claircore.OpEquals, nil
+	case ArchOp_ARCH_OP_NOT_EQUALS:
+		return claircore.OpNotEquals, nil
+	case ArchOp_ARCH_OP_PATTERN_MATCH:
+		return claircore.OpPatternMatch, nil
+	}
+	return claircore.ArchOp(0), fmt.Errorf("unknown arch op %d", o)
+}
@@ -0,0 +1,269 @@
+package claircorev1
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/quay/claircore"
+	"github.com/quay/claircore/pkg/cpe"
+)
+
+var (
+	testManifest = claircore.MustParseDigest(`sha256:` + "1111111111111111111111111111111111111111111111111111111111111111")
+	testLayer    = claircore.MustParseDigest(`sha256:` + "2222222222222222222222222222222222222222222222222222222222222222")
+	testPkg      = &claircore.Package{
+		ID:      "1",
+		Name:    "openssl",
+		Version: "1.1.1k-1",
+		Kind:    claircore.BINARY,
+		Source: &claircore.Package{
+			ID:      "2",
+			Name:    "openssl",
+			Version: "1.1.1k-1",
+			Kind:    claircore.SOURCE,
+		},
+		NormalizedVersion: claircore.Version{Kind: "semver", V: [10]int32{0, 1, 1, 1}},
+		Arch:              "x86_64",
+		CPE:               cpe.MustUnbind("cpe:2.3:a:openssl:openssl:1.1.1k:*:*:*:*:*:*:*"),
+		Module:            "openssl:1.1",
+		Provides:          []string{"libssl.so.1.1"},
+		Depends:           []string{"glibc"},
+		IntroducedIn:      &testLayer,
+		PresentIn:         &testLayer,
+		Confidence:        claircore.ConfidenceLow,
+	}
+	testDist = &claircore.Distribution{
+		ID:              "3",
+		DID:             "rhel",
+		Name:            "Red Hat Enterprise Linux",
+		Version:         "8",
+		VersionCodeName: "Ootpa",
+		VersionID:       "8",
+		Arch:            "x86_64",
+		CPE:             cpe.MustUnbind("cpe:2.3:o:redhat:enterprise_linux:8:*:*:*:*:*:*:*"),
+		PrettyName:      "Red Hat Enterprise Linux 8",
+	}
+	testRepo = &claircore.Repository{
+		ID:   "4",
+		Name: "rhel-8-for-x86_64-baseos-rpms",
+		Key:  "rhel-cpe-repository",
+		URI:  "https://cdn.redhat.com/content/dist/rhel8/8/x86_64/baseos/os",
+		CPE:  cpe.MustUnbind("cpe:2.3:o:redhat:rhel_baseos:8:*:*:*:*:*:*:*"),
+	}
+	testEnv = []*claircore.Environment{{
+		PackageDB:      "var/lib/rpm",
+		IntroducedIn:   testLayer,
+		DistributionID: "3",
+		RepositoryIDs:  []string{"4"},
+	}}
+	testVuln = &claircore.Vulnerability{
+		ID:                 "5",
+		Updater:            "rhel-vex",
+		Name:               "CVE-2021-3711",
+		Description:        "SM2 decryption buffer overflow",
+		Issued:             time.Date(2021, 8, 24, 0, 0, 0, 0, time.UTC),
+		Links:              "https://access.redhat.com/security/cve/CVE-2021-3711",
+		Severity:           "Important",
+		NormalizedSeverity: claircore.High,
+		Package:            &claircore.Package{Name: "openssl", Kind: claircore.BINARY},
+		Dist:               testDist,
+		Repo:               testRepo,
+		FixedInVersion:     "1:1.1.1k-5.el8_5",
+		Range: &claircore.Range{
+			Lower: claircore.Version{Kind: "semver", V: [10]int32{0, 1}},
+			Upper: claircore.Version{Kind: "semver", V: [10]int32{0, 1, 1, 1, 11}},
+		},
+		ArchOperation:  claircore.OpPatternMatch,
+		AlwaysAffected: true,
+		FixState:       "Affected",
+		Condition:      "module(openssl:1.1)",
+	}
+	testExpires = time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+)
+
+// Populated reports the fields of the claircore types in "v" that are the zero
+// value everywhere they appear, so fixtures can't miss fields added later.
+// Fields left out of the JSON form are skipped.
+func populated(t *testing.T, v any) {
+	t.Helper()
+	seen := make(map[reflect.Type]map[int]bool)
+	var walk func(reflect.Value)
+	walk = func(v reflect.Value) {
+		switch v.Kind() {
+		case reflect.Pointer, reflect.Interface:
+			if !v.IsNil() {
+				walk(v.Elem())
+			}
+		case reflect.Slice, reflect.Array:
+			for i := 0; i < v.Len(); i++ {
+				walk(v.Index(i))
+			}
+		case reflect.Map:
+			iter := v.MapRange()
+			for iter.Next() {
+				walk(iter.Value())
+			}
+		case reflect.Struct:
+			typ := v.Type()
+			if typ.PkgPath() != "github.com/quay/claircore" {
+				return
+			}
+			fs, ok := seen[typ]
+			if !ok {
+				fs = make(map[int]bool)
+				seen[typ] = fs
+			}
+			for i := 0; i < typ.NumField(); i++ {
+				f := typ.Field(i)
+				if !f.IsExported() || f.Tag.Get("json") == "-" {
+					continue
+				}
+				fs[i] = fs[i] || !v.Field(i).IsZero()
+				walk(v.Field(i))
+			}
+		}
+	}
+	walk(reflect.ValueOf(v))
+	for typ, fs := range seen {
+		for i, ok := range fs {
+			if !ok {
+				t.Errorf("%s.%s: not set in the fixture", typ.Name(), typ.Field(i).Name)
+			}
+		}
+	}
+}
+
+func TestIndexReport(t *testing.T) {
+	want := &claircore.IndexReport{
+		SchemaVersion:       claircore.ReportSchemaVersion,
+		Hash:                testManifest,
+		State:               "IndexFinished",
+		Packages:            map[string]*claircore.Package{"1": testPkg},
+		Distributions:       map[string]*claircore.Distribution{"3": testDist},
+		Repositories:        map[string]*claircore.Repository{"4": testRepo},
+		Environments:        map[string][]*claircore.Environment{"1": testEnv},
+		PrimaryDistribution: "3",
+		DistributionTag:     "rhel:8",
+		ActiveKernel:        "1",
+		ImageConfig: &claircore.ImageConfig{
+			User:         "1001",
+			Entrypoint:   []string{"/usr/bin/openssl"},
+			Cmd:          []string{"version"},
+			ExposedPorts: []string{"443/tcp"},
+			Env:          []string{"PATH=/usr/bin"},
+			WorkingDir:   "/",
+		},
+		EmbeddedManifests: []claircore.EmbeddedManifest{
+			{Manifest: testManifest, Layer: testLayer, Path: "image.tar"},
+		},
+		CanonicalPackages: map[string]string{"2": "1"},
+		Warnings:          []string{"layer truncated"},
+		Stats: &claircore.IndexStats{
+			Layers:          1,
+			LayersScanned:   1,
+			LayersSkipped:   []claircore.Digest{testLayer},
+			LayersTruncated: []claircore.Digest{testLayer},
+			Bytes:           1024,
+			Packages:        map[string]int{"rpm": 1},
+		},
+		Success: true,
+		Err:     "partial",
+	}
+	populated(t, want)
+	b, err := proto.Marshal(IndexReportToProto(want))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var m IndexReport
+	if err := proto.Unmarshal(b, &m); err != nil {
+		t.Fatal(err)
+	}
+	got, err := IndexReportFromProto(&m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	opt := cmp.AllowUnexported(claircore.Digest{})
+	if !cmp.Equal(got, want, opt) {
+		t.Error(cmp.Diff(got, want, opt))
+	}
+}
+
+func TestVulnerabilityReport(t *testing.T) {
+	want := &claircore.VulnerabilityReport{
+		SchemaVersion:          claircore.ReportSchemaVersion,
+		Hash:                   testManifest,
+		Packages:               map[string]*claircore.Package{"1": testPkg},
+		Distributions:          map[string]*claircore.Distribution{"3": testDist},
+		Repositories:           map[string]*claircore.Repository{"4": testRepo},
+		Environments:           map[string][]*claircore.Environment{"1": testEnv},
+		Vulnerabilities:        map[string]*claircore.Vulnerability{"5": testVuln},
+		PackageVulnerabilities: map[string][]string{"1": {"5"}},
+		Enrichments: map[string][]json.RawMessage{
+			"message/vnd.clair.map.vulnerability; enricher=clair.cvss schema=https://csrc.nist.gov/schema/nvd/feed/1.1/cvss-v3.x.json": {
+				json.RawMessage(`{"5":[{"baseScore":9.8}]}`),
+			},
+		},
+		InheritedVulnerabilities: []string{"5"},
+		ConditionallyAffected:    []string{"5"},
+		LowConfidence:            []string{"5"},
+		FixedVersions:            map[string]string{"1": "1:1.1.1k-5.el8_5"},
+		VulnerabilityManifests:   map[string][]string{"5": {testManifest.String()}},
+		IgnoredVulnerabilities: map[string][]claircore.IgnoredVulnerability{
+			"1": {{ID: "6", Name: "CVE-2021-3712", Reason: "not reachable", Expires: &testExpires}},
+		},
+		Stats: &claircore.MatchStats{
+			Matchers: map[string]time.Duration{"rhel": 150 * time.Millisecond},
+		},
+	}
+	populated(t, want)
+	b, err := proto.Marshal(VulnerabilityReportToProto(want))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var m VulnerabilityReport
+	if err := proto.Unmarshal(b, &m); err != nil {
+		t.Fatal(err)
+	}
+	got, err := VulnerabilityReportFromProto(&m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	opt := cmp.AllowUnexported(claircore.Digest{})
+	if !cmp.Equal(got, want, opt) {
+		t.Error(cmp.Diff(got, want, opt))
+	}
+}
+
+func TestFromProtoErrors(t *testing.T) {
+	t.Run("Digest", func(t *testing.T) {
+		_, err := PackageFromProto(&Package{Name: "bad", IntroducedIn: "sha256:nope"})
+		if err == nil {
+			t.Error("expected error")
+		}
+	})
+	t.Run("CPE", func(t *testing.T) {
+		_, err := PackageFromProto(&Package{Name: "bad", Cpe: "cpe:9"})
+		if err == nil {
+			t.Error("expected error")
+		}
+	})
+	t.Run("Severity", func(t *testing.T) {
+		_, err := VulnerabilityFromProto(&Vulnerability{NormalizedSeverity: Severity(99)})
+		if err == nil {
+			t.Error("expected error")
+		}
+	})
+	t.Run("Enrichment", func(t *testing.T) {
+		_, err := VulnerabilityReportFromProto(&VulnerabilityReport{
+			Enrichments: map[string]*StringList{"x": {Values: []string{"{"}}},
+		})
+		if err == nil {
+			t.Error("expected error")
+		}
+	})
+}
@@ -0,0 +1,8 @@
+// Package claircorev1 is the protobuf representation of the claircore report
+// types, for use in gRPC services and other non-JSON transports.
+//
+// The message types are generated from claircore.proto. Use the ToProto and
+// FromProto functions to convert between them and the claircore types.
+package claircorev1
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative claircore.proto
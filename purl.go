@@ -0,0 +1,213 @@
+package claircore
+
+import (
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// RepositoryResolver maps a package URL type and the repository a package was
+// found with (which may be nil) to the canonical URL of that repository. The
+// result is used as the "repository_url" qualifier of a package URL.
+//
+// The boolean reports whether the resolver handled the arguments; the next
+// resolver is consulted if it did not.
+type RepositoryResolver func(purlType string, repo *Repository) (string, bool)
+
+// Public registries used by DefaultRepositoryResolvers.
+const (
+	pypiURL     = `https://pypi.org`
+	mavenURL    = `https://repo1.maven.apache.org/maven2`
+	rubygemsURL = `https://rubygems.org`
+	goproxyURL  = `https://proxy.golang.org`
+)
+
+// DefaultRepositoryResolvers are the resolvers consulted by [Package.PURL]
+// after any resolvers passed to it. They map the repositories reported by
+// the in-tree scanners to the public registries.
+var DefaultRepositoryResolvers = []RepositoryResolver{
+	publicRegistry("pypi", pypiURL),
+	publicRegistry("maven", mavenURL),
+	publicRegistry("gem", rubygemsURL),
+	publicRegistry("golang", goproxyURL),
+}
+
+// PublicRegistry returns a RepositoryResolver that reports "u" for packages of
+// type "t" that either have no repository or have a repository pointing
+// somewhere within "u".
+func publicRegistry(t, u string) RepositoryResolver {
+	return func(pt string, repo *Repository) (string, bool) {
+		if pt != t {
+			return "", false
+		}
+		if repo == nil || repo.URI == "" || strings.HasPrefix(repo.URI, u) {
+			return u, true
+		}
+		return "", false
+	}
+}
+
+// MirrorResolver returns a RepositoryResolver that reports "u" for all
+// packages of type "t". This can be passed to [Package.PURL] to have packages
+// attributed to a private mirror.
+func MirrorResolver(t, u string) RepositoryResolver {
+	return func(pt string, _ *Repository) (string, bool) {
+		return u, pt == t
+	}
+}
+
+// PURL returns the package URL (see https://github.com/package-url/purl-spec)
+// for the package, as found on the distribution "dist" with the repository
+// "repo". Either may be nil.
+//
+// The "repository_url" qualifier is determined by consulting the passed
+// resolvers in order, then DefaultRepositoryResolvers. If no resolver
+// handles the package, the repository's URI is used, if any.
+//
+// The empty string is returned if the type of the package can't be
+// determined.
+func (p *Package) PURL(dist *Distribution, repo *Repository, rs ...RepositoryResolver) string {
+	t := purlType(p, dist, repo)
+	if t == "" {
+		return ""
+	}
+	var ns string
+	name, version := p.Name, p.Version
+	q := make(map[string]string)
+	switch t {
+	case "rpm", "deb", "apk":
+		if t == "rpm" {
+			// The rpm type puts the epoch in a qualifier.
+			var epoch string
+			epoch, version = rpmEpoch(version)
+			if epoch != "" {
+				q["epoch"] = epoch
+			}
+		}
+		if dist != nil {
+			ns = dist.DID
+			if dist.VersionID != "" {
+				q["distro"] = dist.DID + "-" + dist.VersionID
+			}
+		}
+		if p.Arch != "" {
+			q["arch"] = p.Arch
+		}
+	case "maven":
+		if i := strings.LastIndexByte(name, ':'); i != -1 {
+			ns, name = name[:i], name[i+1:]
+		}
+	case "golang":
+		if i := strings.LastIndexByte(name, '/'); i != -1 {
+			ns, name = name[:i], name[i+1:]
+		}
+	case "pypi":
+		name = strings.ToLower(strings.ReplaceAll(name, "_", "-"))
+	}
+	if u := resolveRepository(t, repo, rs); u != "" {
+		q["repository_url"] = u
+	}
+
+	var b strings.Builder
+	b.WriteString("pkg:")
+	b.WriteString(t)
+	b.WriteByte('/')
+	if ns != "" {
+		for _, s := range strings.Split(ns, "/") {
+			b.WriteString(url.PathEscape(s))
+			b.WriteByte('/')
+		}
+	}
+	b.WriteString(url.PathEscape(name))
+	if version != "" {
+		b.WriteByte('@')
+		b.WriteString(url.PathEscape(version))
+	}
+	if len(q) != 0 {
+		ks := make([]string, 0, len(q))
+		for k := range q {
+			ks = append(ks, k)
+		}
+		sort.Strings(ks)
+		for i, k := range ks {
+			if i == 0 {
+				b.WriteByte('?')
+			} else {
+				b.WriteByte('&')
+			}
+			b.WriteString(k)
+			b.WriteByte('=')
+			b.WriteString(url.QueryEscape(q[k]))
+		}
+	}
+	return b.String()
+}
+
+// RpmEpoch splits an rpm EVR into its epoch and the rest. An explicit zero
+// epoch is the same as none, and is reported as the empty string.
+func rpmEpoch(evr string) (string, string) {
+	e, vr, ok := strings.Cut(evr, ":")
+	if !ok || e == "" || strings.Trim(e, "0123456789") != "" {
+		return "", evr
+	}
+	if strings.Trim(e, "0") == "" {
+		return "", vr
+	}
+	return e, vr
+}
+
+// ResolveRepository consults the resolvers for the repository URL.
+func resolveRepository(t string, repo *Repository, rs []RepositoryResolver) string {
+	for _, r := range rs {
+		if u, ok := r(t, repo); ok {
+			return u
+		}
+	}
+	for _, r := range DefaultRepositoryResolvers {
+		if u, ok := r(t, repo); ok {
+			return u
+		}
+	}
+	if repo != nil && strings.Contains(repo.URI, "://") {
+		return repo.URI
+	}
+	return ""
+}
+
+// PURL returns the package URL for the record's package; see [Package.PURL].
+func (r *IndexRecord) PURL(rs ...RepositoryResolver) string {
+	return r.Package.PURL(r.Distribution, r.Repository, rs...)
+}
+
+// PurlType reports the package URL type for the package.
+func purlType(p *Package, dist *Distribution, repo *Repository) string {
+	if repo != nil {
+		switch repo.Name {
+		case "pypi":
+			return "pypi"
+		case "maven":
+			return "maven"
+		case "rubygems":
+			return "gem"
+		}
+	}
+	switch db := p.PackageDB; {
+	case strings.HasPrefix(db, "go:"):
+		return "golang"
+	case strings.HasPrefix(db, "python:"):
+		return "pypi"
+	case strings.HasPrefix(db, "maven:"), strings.HasPrefix(db, "jar:"):
+		return "maven"
+	}
+	if dist != nil {
+		switch dist.DID {
+		case "debian", "ubuntu":
+			return "deb"
+		case "alpine":
+			return "apk"
+		case "rhel", "centos", "fedora", "ol", "amzn", "photon", "sles", "opensuse", "opensuse-leap":
+			return "rpm"
+		}
+	}
+	return ""
+}
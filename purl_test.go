@@ -0,0 +1,64 @@
+package claircore
+
+import "testing"
+
+func TestPURL(t *testing.T) {
+	pypi := &Repository{Name: "pypi", URI: "https://pypi.org/simple"}
+	rhel := &Distribution{DID: "rhel", VersionID: "8"}
+	tt := []struct {
+		name string
+		pkg  Package
+		dist *Distribution
+		repo *Repository
+		rs   []RepositoryResolver
+		want string
+	}{
+		{
+			name: "Unknown",
+			pkg:  Package{Name: "mystery", Version: "1"},
+		},
+		{
+			name: "RPM",
+			pkg:  Package{Name: "openssl-libs", Version: "1:1.1.1k-7.el8_6", Arch: "x86_64"},
+			dist: rhel,
+			want: "pkg:rpm/rhel/openssl-libs@1.1.1k-7.el8_6?arch=x86_64&distro=rhel-8&epoch=1",
+		},
+		{
+			name: "RPMZeroEpoch",
+			pkg:  Package{Name: "bash", Version: "0:4.4.20-4.el8_6", Arch: "x86_64"},
+			dist: rhel,
+			want: "pkg:rpm/rhel/bash@4.4.20-4.el8_6?arch=x86_64&distro=rhel-8",
+		},
+		{
+			name: "Python",
+			pkg:  Package{Name: "Flask_Login", Version: "0.6.2", PackageDB: "python:usr/lib/python3.9/site-packages"},
+			repo: pypi,
+			want: "pkg:pypi/flask-login@0.6.2?repository_url=https%3A%2F%2Fpypi.org",
+		},
+		{
+			name: "PythonMirror",
+			pkg:  Package{Name: "requests", Version: "2.31.0"},
+			repo: pypi,
+			rs:   []RepositoryResolver{MirrorResolver("pypi", "https://pypi.example.com/simple")},
+			want: "pkg:pypi/requests@2.31.0?repository_url=https%3A%2F%2Fpypi.example.com%2Fsimple",
+		},
+		{
+			name: "Maven",
+			pkg:  Package{Name: "org.apache.logging.log4j:log4j-core", Version: "2.14.1", PackageDB: "maven:opt/app/log4j-core-2.14.1.jar"},
+			want: "pkg:maven/org.apache.logging.log4j/log4j-core@2.14.1?repository_url=https%3A%2F%2Frepo1.maven.apache.org%2Fmaven2",
+		},
+		{
+			name: "Go",
+			pkg:  Package{Name: "github.com/quay/claircore", Version: "v1.5.0", PackageDB: "go:bin/clair"},
+			want: "pkg:golang/github.com/quay/claircore@v1.5.0?repository_url=https%3A%2F%2Fproxy.golang.org",
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			got := tc.pkg.PURL(tc.dist, tc.repo, tc.rs...)
+			if got != tc.want {
+				t.Errorf("got: %q, want: %q", got, tc.want)
+			}
+		})
+	}
+}
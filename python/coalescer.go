@@ -28,17 +28,25 @@ func (c *coalescer) Coalesce(ctx context.Context, ls []*indexer.LayerArtifacts)
 			continue
 		}
 		rs := make([]string, len(l.Repos))
+		byURI := make(map[string][]string)
 		for i, r := range l.Repos {
 			rs[i] = r.ID
+			byURI[r.URI] = append(byURI[r.URI], r.ID)
 			ir.Repositories[r.ID] = r
 		}
 		for _, pkg := range l.Pkgs {
+			// Associate the package with the index it was installed from, if
+			// the repository scanner found it.
+			ids, ok := byURI[pkg.RepositoryHint]
+			if !ok {
+				ids = rs
+			}
 			ir.Packages[pkg.ID] = pkg
 			ir.Environments[pkg.ID] = []*claircore.Environment{
 				&claircore.Environment{
 					PackageDB:     pkg.PackageDB,
 					IntroducedIn:  l.Hash,
-					RepositoryIDs: rs,
+					RepositoryIDs: ids,
 				},
 			}
 		}
@@ -0,0 +1,58 @@
+package python
+
+import (
+	"encoding/json"
+	"io/fs"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// PublicHosts are the hosts serving the public Python Package Index. Artifacts
+// are downloaded from a different host than the index itself.
+var publicHosts = map[string]struct{}{
+	"pypi.org":               {},
+	"pypi.python.org":        {},
+	"files.pythonhosted.org": {},
+}
+
+// IndexHint reports where the package whose metadata is at "p" was installed
+// from, for use as a RepositoryHint.
+//
+// This is only discoverable for wheels: a direct_url.json ([PEP 610]) is
+// written for packages installed from a URL instead of an index, and some
+// installers write a provenance_url.json ([PEP 710]) recording where the
+// distribution was downloaded from. In either case, the origin of the URL is
+// returned, with the hosts for the public index reported as Repository.URI.
+// If neither is present, the package is assumed to come from the public
+// index.
+//
+// [PEP 610]: https://peps.python.org/pep-0610/
+// [PEP 710]: https://peps.python.org/pep-0710/
+func indexHint(sys fs.FS, p string) string {
+	if !strings.HasSuffix(p, `.dist-info/METADATA`) {
+		return Repository.URI
+	}
+	dir := path.Dir(p)
+	for _, n := range []string{`direct_url.json`, `provenance_url.json`} {
+		b, err := fs.ReadFile(sys, path.Join(dir, n))
+		if err != nil {
+			continue
+		}
+		var v struct {
+			URL string `json:"url"`
+		}
+		if err := json.Unmarshal(b, &v); err != nil || v.URL == "" {
+			continue
+		}
+		u, err := url.Parse(v.URL)
+		if err != nil || u.Scheme == "" {
+			continue
+		}
+		if _, ok := publicHosts[u.Hostname()]; ok {
+			return Repository.URI
+		}
+		return u.Scheme + "://" + u.Host
+	}
+	return Repository.URI
+}
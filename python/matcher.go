@@ -2,26 +2,117 @@ package python
 
 import (
 	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
 
 	"github.com/quay/zlog"
 
 	"github.com/quay/claircore"
 	"github.com/quay/claircore/libvuln/driver"
 	"github.com/quay/claircore/pkg/pep440"
+	"github.com/quay/claircore/pkg/pkgname"
 )
 
-var _ driver.Matcher = (*Matcher)(nil)
+var (
+	_ driver.Matcher             = (*Matcher)(nil)
+	_ driver.OSOwnedFilter       = (*Matcher)(nil)
+	_ driver.MatcherFactory      = (*MatcherFactory)(nil)
+	_ driver.MatcherConfigurable = (*MatcherFactory)(nil)
+)
 
 // Matcher attempts to correlate discovered python packages with reported
 // vulnerabilities.
-type Matcher struct{}
+//
+// Advisories are for packages on the public index, so packages known to come
+// from elsewhere are not matched: those installed from an index other than
+// the public one or one listed in MatcherConfig.PublicIndexes, and those
+// named in MatcherConfig.PrivatePackages.
+//
+// The zero value is ready to use.
+type Matcher struct {
+	private []string
+	public  map[string]struct{}
+}
+
+// MatcherConfig is the configuration accepted by the MatcherFactory.
+type MatcherConfig struct {
+	// PrivatePackages is a list of package names that are never matched
+	// against public advisories. An entry ending in "*" is a prefix. Names
+	// are compared in their normalized form, so "Acme_Tools" and "acme-tools"
+	// are the same.
+	PrivatePackages []string `json:"private_packages" yaml:"private_packages"`
+	// PublicIndexes is a list of index URLs, such as mirrors or proxies of
+	// the public index, whose packages should be matched as if they came
+	// from the public index. Only the scheme and host are considered.
+	PublicIndexes []string `json:"public_indexes" yaml:"public_indexes"`
+}
+
+// NewMatcher returns a Matcher using the provided configuration.
+func NewMatcher(cfg MatcherConfig) (*Matcher, error) {
+	m := Matcher{
+		public: map[string]struct{}{
+			Repository.URI: {},
+		},
+	}
+	for _, n := range cfg.PrivatePackages {
+		if n == "" || n == "*" {
+			return nil, fmt.Errorf("python: bad private package name: %q", n)
+		}
+		if p, ok := strings.CutSuffix(n, "*"); ok {
+			m.private = append(m.private, pkgname.Python(p)+"*")
+			continue
+		}
+		m.private = append(m.private, pkgname.Python(n))
+	}
+	for _, i := range cfg.PublicIndexes {
+		u, err := url.Parse(i)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return nil, fmt.Errorf("python: bad public index URL: %q", i)
+		}
+		m.public[u.Scheme+"://"+u.Host] = struct{}{}
+	}
+	return &m, nil
+}
 
 // Name implements driver.Matcher.
 func (*Matcher) Name() string { return "python" }
 
 // Filter implements driver.Matcher.
-func (*Matcher) Filter(record *claircore.IndexRecord) bool {
-	return record.Package.NormalizedVersion.Kind == "pep440"
+func (m *Matcher) Filter(record *claircore.IndexRecord) bool {
+	if record.Package.NormalizedVersion.Kind != "pep440" {
+		return false
+	}
+	if r := record.Repository; r != nil && r.URI != "" && r.URI != Repository.URI {
+		if _, ok := m.public[r.URI]; !ok {
+			return false
+		}
+	}
+	return !m.isPrivate(record.Package.Name)
+}
+
+// SkipOSOwned implements driver.OSOwnedFilter.
+func (*Matcher) SkipOSOwned() {}
+
+// IsPrivate reports whether the package "name" is configured as private.
+func (m *Matcher) isPrivate(name string) bool {
+	if len(m.private) == 0 {
+		return false
+	}
+	name = pkgname.Python(name)
+	for _, p := range m.private {
+		if pre, ok := strings.CutSuffix(p, "*"); ok {
+			if strings.HasPrefix(name, pre) {
+				return true
+			}
+			continue
+		}
+		if name == p {
+			return true
+		}
+	}
+	return false
 }
 
 // Query implements driver.Matcher.
@@ -56,3 +147,37 @@ func (*Matcher) Vulnerable(ctx context.Context, record *claircore.IndexRecord, v
 
 	return spec.Match(&v), nil
 }
+
+// MatcherFactory constructs a Matcher, which can be configured with a
+// MatcherConfig.
+type MatcherFactory struct {
+	cfg MatcherConfig
+}
+
+// Matcher implements driver.MatcherFactory.
+func (f *MatcherFactory) Matcher(_ context.Context) ([]driver.Matcher, error) {
+	m, err := NewMatcher(f.cfg)
+	if err != nil {
+		return nil, err
+	}
+	return []driver.Matcher{m}, nil
+}
+
+// Configure implements driver.MatcherConfigurable.
+func (f *MatcherFactory) Configure(ctx context.Context, cfg driver.MatcherConfigUnmarshaler, _ *http.Client) error {
+	ctx = zlog.ContextWithValues(ctx, "component", "python/MatcherFactory.Configure")
+	var fc MatcherConfig
+	if err := cfg(&fc); err != nil {
+		return err
+	}
+	// Check the configuration now, rather than when constructing matchers.
+	if _, err := NewMatcher(fc); err != nil {
+		return err
+	}
+	f.cfg = fc
+	zlog.Info(ctx).
+		Strs("private_packages", fc.PrivatePackages).
+		Strs("public_indexes", fc.PublicIndexes).
+		Msg("configured")
+	return nil
+}
@@ -90,3 +90,79 @@ func TestMatcher(t *testing.T) {
 		t.Run(tc.Name, tc.Run)
 	}
 }
+
+func TestFilter(t *testing.T) {
+	m, err := python.NewMatcher(python.MatcherConfig{
+		PrivatePackages: []string{"Acme_Tools", "internal-*"},
+		PublicIndexes:   []string{"https://mirror.example.com/pypi/simple"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pep440 := claircore.Version{Kind: "pep440"}
+	tt := []struct {
+		Name string
+		Repo string
+		Want bool
+	}{
+		{Name: "requests", Want: true},
+		{Name: "requests", Repo: python.Repository.URI, Want: true},
+		{Name: "requests", Repo: "https://mirror.example.com", Want: true},
+		{Name: "requests", Repo: "https://pkgs.example.com", Want: false},
+		{Name: "acme-tools", Want: false},
+		{Name: "ACME.tools", Want: false},
+		{Name: "acme-tools-extra", Want: true},
+		{Name: "internal-auth", Want: false},
+		{Name: "Internal_Auth", Want: false},
+		{Name: "internals", Want: true},
+	}
+	for _, tc := range tt {
+		r := claircore.IndexRecord{
+			Package: &claircore.Package{
+				Name:              tc.Name,
+				NormalizedVersion: pep440,
+			},
+		}
+		if tc.Repo != "" {
+			r.Repository = &claircore.Repository{URI: tc.Repo}
+		}
+		if got, want := m.Filter(&r), tc.Want; got != want {
+			t.Errorf("%s (%q): got: %v, want: %v", tc.Name, tc.Repo, got, want)
+		}
+	}
+
+	t.Run("Default", func(t *testing.T) {
+		var m python.Matcher
+		r := claircore.IndexRecord{
+			Package: &claircore.Package{
+				Name:              "acme-tools",
+				NormalizedVersion: pep440,
+			},
+			Repository: &python.Repository,
+		}
+		if !m.Filter(&r) {
+			t.Error("public package filtered out")
+		}
+		r.Repository = &claircore.Repository{URI: "https://pkgs.example.com"}
+		if m.Filter(&r) {
+			t.Error("private index package not filtered out")
+		}
+		r.Package.NormalizedVersion = claircore.Version{}
+		r.Repository = nil
+		if m.Filter(&r) {
+			t.Error("package without a pep440 version not filtered out")
+		}
+	})
+
+	t.Run("BadConfig", func(t *testing.T) {
+		for _, cfg := range []python.MatcherConfig{
+			{PrivatePackages: []string{"*"}},
+			{PrivatePackages: []string{""}},
+			{PublicIndexes: []string{"mirror.example.com"}},
+		} {
+			if _, err := python.NewMatcher(cfg); err == nil {
+				t.Errorf("%+v: expected error", cfg)
+			}
+		}
+	})
+}
@@ -19,7 +19,7 @@ import (
 	"github.com/quay/claircore"
 	"github.com/quay/claircore/indexer"
 	"github.com/quay/claircore/pkg/pep440"
-	"github.com/quay/claircore/pkg/tarfs"
+	"github.com/quay/claircore/pkg/pkgname"
 )
 
 var (
@@ -42,7 +42,7 @@ type Scanner struct{}
 func (*Scanner) Name() string { return "python" }
 
 // Version implements scanner.VersionedScanner.
-func (*Scanner) Version() string { return "3" }
+func (*Scanner) Version() string { return "7" }
 
 // Kind implements scanner.VersionedScanner.
 func (*Scanner) Kind() string { return "package" }
@@ -64,15 +64,11 @@ func (ps *Scanner) Scan(ctx context.Context, layer *claircore.Layer) ([]*clairco
 		return nil, err
 	}
 
-	r, err := layer.Reader()
-	if err != nil {
-		return nil, err
-	}
-	defer r.Close()
-	sys, err := tarfs.New(r)
+	sys, err := layer.FS()
 	if err != nil {
 		return nil, fmt.Errorf("python: unable to open tar: %w", err)
 	}
+	defer sys.Close()
 
 	ms, err := findDeliciousEgg(ctx, sys)
 	if err != nil {
@@ -111,15 +107,13 @@ func (ps *Scanner) Scan(ctx context.Context, layer *claircore.Layer) ([]*clairco
 			pkgDB = filepath.Join(n, "..")
 		}
 		ret = append(ret, &claircore.Package{
-			Name:              strings.ToLower(hdr.Get("Name")),
+			Name:              pkgname.Python(hdr.Get("Name")),
 			Version:           v.String(),
 			PackageDB:         "python:" + pkgDB,
 			Filepath:          n,
 			Kind:              claircore.BINARY,
 			NormalizedVersion: v.Version(),
-			// TODO Is there some way to pick up on where a wheel or egg was
-			// found?
-			RepositoryHint: "https://pypi.org/simple",
+			RepositoryHint:    indexHint(sys, n),
 		})
 	}
 	return ret, nil
@@ -144,6 +138,8 @@ func findDeliciousEgg(ctx context.Context, sys fs.FS) (out []string, err error)
 		"var/lib/rpm/Packages",
 		"var/lib/rpm/rpmdb.sqlite",
 		"var/lib/rpm/Packages.db",
+		"usr/lib/sysimage/rpm/rpmdb.sqlite",
+		"usr/lib/sysimage/rpm/Packages.db",
 	} {
 		if fi, err := fs.Stat(sys, p); err == nil && fi.Mode().IsRegular() {
 			rpm = true
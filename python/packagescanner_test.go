@@ -1,6 +1,7 @@
 package python_test
 
 import (
+	"archive/tar"
 	"context"
 	"encoding/gob"
 	"errors"
@@ -111,3 +112,147 @@ func TestScanLocal(t *testing.T) {
 		})
 	}
 }
+
+// WriteLayer returns a Layer containing the provided files.
+func writeLayer(t *testing.T, files map[string]string) *claircore.Layer {
+	t.Helper()
+	n := filepath.Join(t.TempDir(), "layer.tar")
+	f, err := os.Create(n)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tw := tar.NewWriter(f)
+	for name, c := range files {
+		if err := tw.WriteHeader(&tar.Header{
+			Typeflag: tar.TypeReg,
+			Name:     name,
+			Mode:     0o644,
+			Size:     int64(len(c)),
+		}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(c)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+	var l claircore.Layer
+	l.SetLocal(n)
+	return &l
+}
+
+// TestScanOwned checks that packages owned by an OS package are still
+// reported; they're only left out when matching.
+func TestScanOwned(t *testing.T) {
+	ctx := zlog.Test(context.Background(), t)
+	const site = `usr/lib/python3.11/site-packages/`
+	files := map[string]string{
+		// Installed by apk.
+		"lib/apk/db/installed": "P:py3-six\nV:1.16.0-r6\n" +
+			"F:" + site + "six-1.16.0.dist-info\nR:METADATA\n",
+		site + "six-1.16.0.dist-info/METADATA": "Name: six\nVersion: 1.16.0\n",
+		// Installed by pip.
+		site + "requests-2.31.0.dist-info/METADATA": "Name: requests\nVersion: 2.31.0\n",
+	}
+	l := writeLayer(t, files)
+
+	got, err := (&python.Scanner{}).Scan(ctx, l)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, p := range got {
+		names = append(names, p.Name)
+	}
+	sort.Strings(names)
+	if want := []string{"requests", "six"}; !cmp.Equal(names, want) {
+		t.Error(cmp.Diff(names, want))
+	}
+}
+
+func TestScanIndex(t *testing.T) {
+	ctx := zlog.Test(context.Background(), t)
+	const site = `usr/lib/python3.11/site-packages/`
+	files := map[string]string{
+		site + "requests-2.31.0.dist-info/METADATA":       "Name: requests\nVersion: 2.31.0\n",
+		site + "six-1.16.0.dist-info/METADATA":            "Name: six\nVersion: 1.16.0\n",
+		site + "six-1.16.0.dist-info/provenance_url.json": `{"url":"https://files.pythonhosted.org/packages/six-1.16.0-py2.py3-none-any.whl"}`,
+		site + "acme-1.0.0.dist-info/METADATA":            "Name: acme\nVersion: 1.0.0\n",
+		site + "acme-1.0.0.dist-info/provenance_url.json": `{"url":"https://pkgs.example.com/simple/acme/acme-1.0.0-py3-none-any.whl"}`,
+		site + "tool-2.0.0.dist-info/METADATA":            "Name: tool\nVersion: 2.0.0\n",
+		site + "tool-2.0.0.dist-info/direct_url.json":     `{"url":"https://git.example.org/tool.git","vcs_info":{"vcs":"git"}}`,
+	}
+	l := writeLayer(t, files)
+
+	pkgs, err := (&python.Scanner{}).Scan(ctx, l)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make(map[string]string)
+	for _, p := range pkgs {
+		got[p.Name] = p.RepositoryHint
+	}
+	want := map[string]string{
+		"requests": python.Repository.URI,
+		"six":      python.Repository.URI,
+		"acme":     "https://pkgs.example.com",
+		"tool":     "https://git.example.org",
+	}
+	if !cmp.Equal(got, want) {
+		t.Error(cmp.Diff(got, want))
+	}
+
+	repos, err := (&python.RepoScanner{}).Scan(ctx, l)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var uris []string
+	for _, r := range repos {
+		uris = append(uris, r.URI)
+	}
+	sort.Strings(uris)
+	wantURIs := []string{"https://git.example.org", "https://pkgs.example.com", python.Repository.URI}
+	if !cmp.Equal(uris, wantURIs) {
+		t.Error(cmp.Diff(uris, wantURIs))
+	}
+}
+
+// TestScanNames checks that package names are reported in their normalized
+// form, regardless of how the metadata spells them.
+func TestScanNames(t *testing.T) {
+	ctx := zlog.Test(context.Background(), t)
+	const site = `usr/lib/python3.11/site-packages/`
+	l := writeLayer(t, map[string]string{
+		site + "discord.py-2.3.2.dist-info/METADATA":        "Name: discord.py\nVersion: 2.3.2\n",
+		site + "Flask_Login-0.6.2.dist-info/METADATA":       "Name: Flask-Login\nVersion: 0.6.2\n",
+		site + "PyYAML-6.0.1.dist-info/METADATA":            "Name: PyYAML\nVersion: 6.0.1\n",
+		site + "typing_extensions-4.8.0.dist-info/METADATA": "Name: typing_extensions\nVersion: 4.8.0\n",
+		site + "zope.interface-6.0.dist-info/METADATA":      "Name: Zope.Interface\nVersion: 6.0\n",
+		site + "ruamel.yaml.clib-0.2.8.dist-info/METADATA":  "Name: ruamel.yaml.clib\nVersion: 0.2.8\n",
+	})
+	pkgs, err := (&python.Scanner{}).Scan(ctx, l)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, p := range pkgs {
+		got = append(got, p.Name)
+	}
+	sort.Strings(got)
+	want := []string{
+		"discord-py",
+		"flask-login",
+		"pyyaml",
+		"ruamel-yaml-clib",
+		"typing-extensions",
+		"zope-interface",
+	}
+	if !cmp.Equal(got, want) {
+		t.Error(cmp.Diff(got, want))
+	}
+}
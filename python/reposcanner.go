@@ -11,7 +11,6 @@ import (
 
 	"github.com/quay/claircore"
 	"github.com/quay/claircore/indexer"
-	"github.com/quay/claircore/pkg/tarfs"
 )
 
 var (
@@ -30,7 +29,7 @@ type RepoScanner struct{}
 func (*RepoScanner) Name() string { return "pip" }
 
 // Version implements scanner.VersionedScanner.
-func (*RepoScanner) Version() string { return "0.0.1" }
+func (*RepoScanner) Version() string { return "0.0.2" }
 
 // Kind implements scanner.VersionedScanner.
 func (*RepoScanner) Kind() string { return "repository" }
@@ -52,24 +51,35 @@ func (rs *RepoScanner) Scan(ctx context.Context, layer *claircore.Layer) ([]*cla
 		return nil, err
 	}
 
-	r, err := layer.Reader()
-	if err != nil {
-		return nil, err
-	}
-	defer r.Close()
-	sys, err := tarfs.New(r)
+	sys, err := layer.FS()
 	if err != nil {
 		return nil, fmt.Errorf("python: unable to open tar: %w", err)
 	}
+	defer sys.Close()
 
 	ms, err := findDeliciousEgg(ctx, sys)
 	if err != nil {
 		return nil, fmt.Errorf("python: failed to find delicious egg: %w", err)
 	}
-	if len(ms) != 0 {
-		// Just claim these came from pypi.
-		return []*claircore.Repository{&Repository}, nil
+	// Report a repository for every index packages were installed from.
+	// Packages without any record of where they came from are claimed to be
+	// from pypi.
+	var ret []*claircore.Repository
+	seen := make(map[string]struct{})
+	for _, n := range ms {
+		u := indexHint(sys, n)
+		if _, ok := seen[u]; ok {
+			continue
+		}
+		seen[u] = struct{}{}
+		if u == Repository.URI {
+			ret = append(ret, &Repository)
+			continue
+		}
+		ret = append(ret, &claircore.Repository{
+			Name: Repository.Name,
+			URI:  u,
+		})
 	}
-
-	return nil, nil
+	return ret, nil
 }
@@ -12,13 +12,13 @@ import (
 	"net/http"
 	"net/url"
 	"path/filepath"
-	"strings"
 
 	"github.com/quay/zlog"
 
 	"github.com/quay/claircore"
 	"github.com/quay/claircore/libvuln/driver"
 	"github.com/quay/claircore/pkg/pep440"
+	"github.com/quay/claircore/pkg/pkgname"
 	"github.com/quay/claircore/pkg/tmp"
 )
 
@@ -302,7 +302,7 @@ func (db db) Vulnerabilites(ctx context.Context, repo *claircore.Repository, upd
 				Updater:     updater,
 				Description: e.Advisory,
 				Package: &claircore.Package{
-					Name: strings.ToLower(k), // pip database lower cases all package names?
+					Name: pkgname.Python(k),
 					Kind: claircore.BINARY,
 					// pip provides a "specifier" to understand if a particular package
 					// version is affected by a vulnerability.
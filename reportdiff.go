@@ -0,0 +1,105 @@
+package claircore
+
+import "sort"
+
+// ReportDiff is the difference between two VulnerabilityReports, as
+// returned by DiffReports.
+//
+// Each list is sorted by package name, then vulnerability name.
+type ReportDiff struct {
+	// Added are the vulnerabilities only present in the current report.
+	Added []DiffEntry `json:"added"`
+	// Removed are the vulnerabilities only present in the old report.
+	Removed []DiffEntry `json:"removed"`
+	// Unchanged are the vulnerabilities present in both reports.
+	Unchanged []DiffEntry `json:"unchanged"`
+}
+
+// DiffEntry is a vulnerability affecting a package.
+type DiffEntry struct {
+	// Package is the affected package. For Removed entries this is from
+	// the old report, otherwise it's from the current report.
+	Package *Package `json:"package"`
+	// Vulnerability is the vulnerability affecting the package, from the
+	// same report as the Package.
+	Vulnerability *Vulnerability `json:"vulnerability"`
+}
+
+// DiffKey is the key DiffEntries are compared by.
+type diffKey struct {
+	pkg, vuln string
+}
+
+// DiffReports compares the vulnerabilities in two reports, e.g. the reports
+// for an image before ("old") and after ("cur") a change.
+//
+// Entries are keyed by package name and vulnerability name rather than by
+// ID, so a vulnerability is Unchanged if a package kept it across a version
+// change, and the comparison works for reports from different indexer or
+// matcher databases. The order of the maps and lists in the reports
+// doesn't matter. Either report may be nil.
+func DiffReports(old, cur *VulnerabilityReport) ReportDiff {
+	o, n := diffEntries(old), diffEntries(cur)
+	d := ReportDiff{
+		Added:     []DiffEntry{},
+		Removed:   []DiffEntry{},
+		Unchanged: []DiffEntry{},
+	}
+	for k, e := range n {
+		if _, ok := o[k]; ok {
+			d.Unchanged = append(d.Unchanged, e)
+			continue
+		}
+		d.Added = append(d.Added, e)
+	}
+	for k, e := range o {
+		if _, ok := n[k]; !ok {
+			d.Removed = append(d.Removed, e)
+		}
+	}
+	for _, es := range [][]DiffEntry{d.Added, d.Removed, d.Unchanged} {
+		sortEntries(es)
+	}
+	return d
+}
+
+// DiffEntries collects the entries in a report.
+//
+// If multiple package or vulnerability records share a key, the one with
+// the lowest ID is used so the result is stable.
+func diffEntries(r *VulnerabilityReport) map[diffKey]DiffEntry {
+	out := make(map[diffKey]DiffEntry)
+	if r == nil {
+		return out
+	}
+	for pkgID, vs := range r.PackageVulnerabilities {
+		p, ok := r.Packages[pkgID]
+		if !ok || p == nil {
+			continue
+		}
+		for _, vID := range vs {
+			v, ok := r.Vulnerabilities[vID]
+			if !ok || v == nil {
+				continue
+			}
+			k := diffKey{pkg: p.Name, vuln: v.Name}
+			if prev, ok := out[k]; ok {
+				if prev.Package.ID < p.ID || (prev.Package.ID == p.ID && prev.Vulnerability.ID <= v.ID) {
+					continue
+				}
+			}
+			out[k] = DiffEntry{Package: p, Vulnerability: v}
+		}
+	}
+	return out
+}
+
+func sortEntries(es []DiffEntry) {
+	sort.Slice(es, func(i, j int) bool {
+		a, b := es[i], es[j]
+		if a.Package.Name != b.Package.Name {
+			return a.Package.Name < b.Package.Name
+		}
+		return a.Vulnerability.Name < b.Vulnerability.Name
+	})
+}
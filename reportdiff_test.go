@@ -0,0 +1,73 @@
+package claircore_test
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/quay/claircore"
+)
+
+func TestDiffReports(t *testing.T) {
+	mk := func(pkgs map[string][2]string, vulns map[string]string, pv map[string][]string) *claircore.VulnerabilityReport {
+		r := &claircore.VulnerabilityReport{
+			Packages:               make(map[string]*claircore.Package),
+			Vulnerabilities:        make(map[string]*claircore.Vulnerability),
+			PackageVulnerabilities: pv,
+		}
+		for id, nv := range pkgs {
+			r.Packages[id] = &claircore.Package{ID: id, Name: nv[0], Version: nv[1]}
+		}
+		for id, name := range vulns {
+			r.Vulnerabilities[id] = &claircore.Vulnerability{ID: id, Name: name}
+		}
+		return r
+	}
+	old := mk(
+		map[string][2]string{"1": {"openssl", "1.1.1k"}, "2": {"zlib", "1.2.11"}},
+		map[string]string{"10": "CVE-2023-0001", "11": "CVE-2023-0002", "12": "CVE-2022-0001"},
+		map[string][]string{"1": {"11", "10"}, "2": {"12"}},
+	)
+	// The new image upgraded openssl, which fixed one vulnerability, and
+	// added curl. The IDs are different, as if from another database.
+	cur := mk(
+		map[string][2]string{"5": {"openssl", "1.1.1t"}, "6": {"zlib", "1.2.11"}, "7": {"curl", "7.76.1"}},
+		map[string]string{"20": "CVE-2023-0002", "21": "CVE-2022-0001", "22": "CVE-2023-0003"},
+		map[string][]string{"7": {"22"}, "6": {"21"}, "5": {"20"}},
+	)
+	type entry struct{ Pkg, Version, Vuln string }
+	flatten := func(es []claircore.DiffEntry) []entry {
+		out := []entry{}
+		for _, e := range es {
+			out = append(out, entry{e.Package.Name, e.Package.Version, e.Vulnerability.Name})
+		}
+		return out
+	}
+
+	d := claircore.DiffReports(old, cur)
+	if got, want := flatten(d.Added), []entry{{"curl", "7.76.1", "CVE-2023-0003"}}; !cmp.Equal(got, want) {
+		t.Errorf("added: %s", cmp.Diff(got, want))
+	}
+	if got, want := flatten(d.Removed), []entry{{"openssl", "1.1.1k", "CVE-2023-0001"}}; !cmp.Equal(got, want) {
+		t.Errorf("removed: %s", cmp.Diff(got, want))
+	}
+	want := []entry{
+		{"openssl", "1.1.1t", "CVE-2023-0002"},
+		{"zlib", "1.2.11", "CVE-2022-0001"},
+	}
+	if got := flatten(d.Unchanged); !cmp.Equal(got, want) {
+		t.Errorf("unchanged: %s", cmp.Diff(got, want))
+	}
+
+	// Reversing the arguments swaps Added and Removed.
+	r := claircore.DiffReports(cur, old)
+	if !cmp.Equal(flatten(r.Added), flatten(d.Removed)) || !cmp.Equal(flatten(r.Removed), flatten(d.Added)) {
+		t.Error("reversed diff is not symmetric")
+	}
+
+	// A nil report means everything was added.
+	n := claircore.DiffReports(nil, cur)
+	if got, want := len(n.Added), 3; got != want {
+		t.Errorf("got: %d added, want: %d", got, want)
+	}
+}
@@ -0,0 +1,210 @@
+package claircore
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"time"
+)
+
+// IgnoreRule describes a vulnerability to leave out of a VulnerabilityReport,
+// usually because the risk has been accepted.
+type IgnoreRule struct {
+	// Vulnerability is compared to a vulnerability's Name and ID. Names are
+	// compared case-insensitively, so "cve-2023-1234" matches
+	// "CVE-2023-1234". If empty, every vulnerability matches.
+	Vulnerability string `json:"vulnerability,omitempty"`
+	// Package is compared to the name of the affected package. If empty,
+	// every package matches.
+	Package string `json:"package,omitempty"`
+	// Expires is when the rule stops applying. If zero, the rule never
+	// expires.
+	Expires time.Time `json:"expires,omitempty"`
+	// Reason is recorded alongside the vulnerabilities the rule ignores.
+	Reason string `json:"reason,omitempty"`
+}
+
+// Matches reports whether the rule applies to the vulnerability "v" in the
+// package "p" as of "now".
+func (r *IgnoreRule) matches(p *Package, v *Vulnerability, now time.Time) bool {
+	if !r.Expires.IsZero() && !now.Before(r.Expires) {
+		return false
+	}
+	if r.Package != "" && (p == nil || p.Name != r.Package) {
+		return false
+	}
+	if r.Vulnerability != "" && r.Vulnerability != v.ID && !strings.EqualFold(r.Vulnerability, v.Name) {
+		return false
+	}
+	return true
+}
+
+// IgnoredVulnerability records a vulnerability a ReportFilter left out of a
+// report.
+type IgnoredVulnerability struct {
+	// the id of the vulnerability in the unfiltered report
+	ID string `json:"id"`
+	// the name of the vulnerability
+	Name string `json:"name"`
+	// the reason from the matching rule
+	Reason string `json:"reason,omitempty"`
+	// when the matching rule expires, if it does
+	Expires *time.Time `json:"expires,omitempty"`
+}
+
+// ReportFilter applies a set of IgnoreRules to VulnerabilityReports.
+//
+// A ReportFilter doesn't modify the reports it's applied to, so callers should
+// keep the unfiltered report and filter it whenever it's used. That way,
+// vulnerabilities ignored by a rule that has since expired are reported
+// again.
+type ReportFilter struct {
+	rules []IgnoreRule
+}
+
+// NewReportFilter returns a ReportFilter for the provided rules.
+//
+// A rule must name a vulnerability, a package, or both.
+func NewReportFilter(rules []IgnoreRule) (*ReportFilter, error) {
+	for i := range rules {
+		if rules[i].Vulnerability == "" && rules[i].Package == "" {
+			return nil, errors.New("ignore rule must name a vulnerability or a package")
+		}
+	}
+	rs := make([]IgnoreRule, len(rules))
+	copy(rs, rules)
+	return &ReportFilter{rules: rs}, nil
+}
+
+// Apply returns a copy of "vr" without the vulnerabilities matched by an
+// unexpired rule as of "now". The left out vulnerabilities are recorded in
+// the returned report's IgnoredVulnerabilities.
+//
+// The returned report shares pointers with "vr". Vulnerabilities that are
+// ignored for every package are also removed from Vulnerabilities,
+// InheritedVulnerabilities, ConditionallyAffected, LowConfidence, and
+// VulnerabilityManifests.
+//
+// A package's FixedVersions entry is kept only if one of its remaining
+// vulnerabilities is fixed in that version. Versions can't be ordered here,
+// so a recommendation that was only needed for ignored vulnerabilities is
+// dropped rather than lowered; see libvuln's Filter for recomputing it.
+func (f *ReportFilter) Apply(vr *VulnerabilityReport, now time.Time) *VulnerabilityReport {
+	out := *vr
+	out.PackageVulnerabilities = make(map[string][]string, len(vr.PackageVulnerabilities))
+	out.IgnoredVulnerabilities = nil
+	// Kept tracks the vulnerabilities still reported for some package.
+	kept := make(map[string]struct{})
+	for pkgID, ids := range vr.PackageVulnerabilities {
+		p := vr.Packages[pkgID]
+		var keep []string
+		for _, id := range ids {
+			v, ok := vr.Vulnerabilities[id]
+			if !ok {
+				keep = append(keep, id)
+				kept[id] = struct{}{}
+				continue
+			}
+			r := f.match(p, v, now)
+			if r == nil {
+				keep = append(keep, id)
+				kept[id] = struct{}{}
+				continue
+			}
+			if out.IgnoredVulnerabilities == nil {
+				out.IgnoredVulnerabilities = make(map[string][]IgnoredVulnerability)
+			}
+			iv := IgnoredVulnerability{
+				ID:     id,
+				Name:   v.Name,
+				Reason: r.Reason,
+			}
+			if !r.Expires.IsZero() {
+				t := r.Expires
+				iv.Expires = &t
+			}
+			out.IgnoredVulnerabilities[pkgID] = append(out.IgnoredVulnerabilities[pkgID], iv)
+		}
+		if len(keep) != 0 || len(ids) == 0 {
+			out.PackageVulnerabilities[pkgID] = keep
+		}
+	}
+	if out.IgnoredVulnerabilities == nil {
+		return &out
+	}
+	for _, ivs := range out.IgnoredVulnerabilities {
+		sort.Slice(ivs, func(i, j int) bool { return ivs[i].ID < ivs[j].ID })
+	}
+
+	// Gone are the vulnerabilities no longer reported for any package.
+	gone := make(map[string]struct{})
+	for _, ivs := range out.IgnoredVulnerabilities {
+		for _, iv := range ivs {
+			if _, ok := kept[iv.ID]; !ok {
+				gone[iv.ID] = struct{}{}
+			}
+		}
+	}
+	out.Vulnerabilities = make(map[string]*Vulnerability, len(vr.Vulnerabilities))
+	for id, v := range vr.Vulnerabilities {
+		if _, ok := gone[id]; !ok {
+			out.Vulnerabilities[id] = v
+		}
+	}
+	if vr.InheritedVulnerabilities != nil {
+		out.InheritedVulnerabilities = make([]string, 0, len(vr.InheritedVulnerabilities))
+		for _, id := range vr.InheritedVulnerabilities {
+			if _, ok := gone[id]; !ok {
+				out.InheritedVulnerabilities = append(out.InheritedVulnerabilities, id)
+			}
+		}
+	}
+	if vr.ConditionallyAffected != nil {
+		out.ConditionallyAffected = make([]string, 0, len(vr.ConditionallyAffected))
+		for _, id := range vr.ConditionallyAffected {
+			if _, ok := gone[id]; !ok {
+				out.ConditionallyAffected = append(out.ConditionallyAffected, id)
+			}
+		}
+	}
+	if vr.LowConfidence != nil {
+		out.LowConfidence = make([]string, 0, len(vr.LowConfidence))
+		for _, id := range vr.LowConfidence {
+			if _, ok := gone[id]; !ok {
+				out.LowConfidence = append(out.LowConfidence, id)
+			}
+		}
+	}
+	if vr.FixedVersions != nil {
+		out.FixedVersions = make(map[string]string, len(vr.FixedVersions))
+	Fixed:
+		for pkgID, fixed := range vr.FixedVersions {
+			for _, id := range out.PackageVulnerabilities[pkgID] {
+				if v, ok := vr.Vulnerabilities[id]; ok && v.FixedInVersion == fixed {
+					out.FixedVersions[pkgID] = fixed
+					continue Fixed
+				}
+			}
+		}
+	}
+	if vr.VulnerabilityManifests != nil {
+		out.VulnerabilityManifests = make(map[string][]string, len(vr.VulnerabilityManifests))
+		for id, ms := range vr.VulnerabilityManifests {
+			if _, ok := gone[id]; !ok {
+				out.VulnerabilityManifests[id] = ms
+			}
+		}
+	}
+	return &out
+}
+
+// Match returns the first rule matching the vulnerability "v" in the package
+// "p" as of "now", or nil.
+func (f *ReportFilter) match(p *Package, v *Vulnerability, now time.Time) *IgnoreRule {
+	for i := range f.rules {
+		if f.rules[i].matches(p, v, now) {
+			return &f.rules[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package claircore_test
+
+import (
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/quay/claircore"
+)
+
+func TestReportFilter(t *testing.T) {
+	now := time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC)
+	later := now.Add(30 * 24 * time.Hour)
+	vr := &claircore.VulnerabilityReport{
+		Packages: map[string]*claircore.Package{
+			"1": {ID: "1", Name: "openssl"},
+			"2": {ID: "2", Name: "curl"},
+			"3": {ID: "3", Name: "zlib"},
+		},
+		Vulnerabilities: map[string]*claircore.Vulnerability{
+			"a": {ID: "a", Name: "CVE-2023-0001", FixedInVersion: "3.0.9"},
+			"b": {ID: "b", Name: "CVE-2023-0002", FixedInVersion: "3.0.8"},
+			"c": {ID: "c", Name: "CVE-2023-0003", FixedInVersion: "8.1.1"},
+			"d": {ID: "d", Name: "CVE-2023-0004", FixedInVersion: "1.2.13"},
+		},
+		PackageVulnerabilities: map[string][]string{
+			"1": {"a", "b"},
+			"2": {"b", "c"},
+			"3": {"d"},
+		},
+		InheritedVulnerabilities: []string{"a", "d"},
+		ConditionallyAffected:    []string{"c", "d"},
+		LowConfidence:            []string{"b", "d"},
+		FixedVersions:            map[string]string{"1": "3.0.9", "2": "8.1.1", "3": "1.2.13"},
+	}
+	f, err := claircore.NewReportFilter([]claircore.IgnoreRule{
+		// Ignored everywhere, until later.
+		{Vulnerability: "cve-2023-0001", Expires: later, Reason: "accepted"},
+		// Ignored only in curl.
+		{Vulnerability: "CVE-2023-0002", Package: "curl"},
+		// Everything in zlib, by ID.
+		{Vulnerability: "d", Package: "zlib", Reason: "not reachable"},
+		// Already expired.
+		{Vulnerability: "CVE-2023-0003", Expires: now},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := f.Apply(vr, now)
+	if want := map[string][]string{
+		"1": {"b"},
+		"2": {"c"},
+	}; !cmp.Equal(got.PackageVulnerabilities, want) {
+		t.Error(cmp.Diff(got.PackageVulnerabilities, want))
+	}
+	if want := map[string][]claircore.IgnoredVulnerability{
+		"1": {{ID: "a", Name: "CVE-2023-0001", Reason: "accepted", Expires: &later}},
+		"2": {{ID: "b", Name: "CVE-2023-0002"}},
+		"3": {{ID: "d", Name: "CVE-2023-0004", Reason: "not reachable"}},
+	}; !cmp.Equal(got.IgnoredVulnerabilities, want) {
+		t.Error(cmp.Diff(got.IgnoredVulnerabilities, want))
+	}
+	var ids []string
+	for id := range got.Vulnerabilities {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	if want := []string{"b", "c"}; !cmp.Equal(ids, want) {
+		t.Error(cmp.Diff(ids, want))
+	}
+	if got := got.InheritedVulnerabilities; len(got) != 0 {
+		t.Errorf("got: %v, want: none", got)
+	}
+	if want := []string{"c"}; !cmp.Equal(got.ConditionallyAffected, want) {
+		t.Error(cmp.Diff(got.ConditionallyAffected, want))
+	}
+	if want := []string{"b"}; !cmp.Equal(got.LowConfidence, want) {
+		t.Error(cmp.Diff(got.LowConfidence, want))
+	}
+	// The openssl recommendation was for the ignored vulnerability.
+	if want := map[string]string{"2": "8.1.1"}; !cmp.Equal(got.FixedVersions, want) {
+		t.Error(cmp.Diff(got.FixedVersions, want))
+	}
+
+	// The input report is untouched.
+	if got, want := len(vr.PackageVulnerabilities["1"]), 2; got != want {
+		t.Errorf("input modified: got: %d, want: %d", got, want)
+	}
+	if vr.IgnoredVulnerabilities != nil {
+		t.Error("input modified: IgnoredVulnerabilities populated")
+	}
+
+	// Once the first rule expires, its vulnerability is reported again.
+	got = f.Apply(vr, later)
+	if want := []string{"a", "b"}; !cmp.Equal(got.PackageVulnerabilities["1"], want) {
+		t.Error(cmp.Diff(got.PackageVulnerabilities["1"], want))
+	}
+	if _, ok := got.IgnoredVulnerabilities["1"]; ok {
+		t.Errorf("expired rule applied: %v", got.IgnoredVulnerabilities["1"])
+	}
+	if want := []string{"a"}; !cmp.Equal(got.InheritedVulnerabilities, want) {
+		t.Error(cmp.Diff(got.InheritedVulnerabilities, want))
+	}
+	if got, want := got.FixedVersions["1"], "3.0.9"; got != want {
+		t.Errorf("got: %q, want: %q", got, want)
+	}
+}
+
+func TestReportFilterBadRule(t *testing.T) {
+	_, err := claircore.NewReportFilter([]claircore.IgnoreRule{{Reason: "everything"}})
+	t.Log(err)
+	if err == nil {
+		t.Error("expected error")
+	}
+}
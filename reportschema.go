@@ -0,0 +1,132 @@
+package claircore
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ReportSchemaVersion is the version of the serialized form of IndexReports
+// and VulnerabilityReports produced by this version of claircore.
+//
+// It must be incremented whenever a change to either report, or to a type
+// they contain, would make older serialized reports decode incorrectly. A
+// migration from the previous version must be added to indexMigrations and
+// vulnMigrations at the same time.
+//
+// Reports written before the version was recorded are version 0.
+const ReportSchemaVersion = 1
+
+// ErrUnknownSchemaVersion is returned by DecodeReport for reports written by a
+// newer version of claircore.
+var ErrUnknownSchemaVersion = errors.New("unknown report schema version")
+
+// RawReport is a report's JSON object, as migrations see it.
+type rawReport map[string]json.RawMessage
+
+// Migration updates a report from one schema version to the next.
+type migration func(rawReport) error
+
+// IndexMigrations and vulnMigrations hold the migrations for each report, where
+// the migration at index "n" updates a report from version "n" to "n+1".
+var (
+	indexMigrations = [ReportSchemaVersion]migration{
+		// 0 → 1: Reports could be written with null maps, and didn't
+		// record the distribution used for matching.
+		func(r rawReport) error {
+			nullToEmpty(r, "packages", "distributions", "repository", "environments")
+			var ds map[string]json.RawMessage
+			if err := json.Unmarshal(r["distributions"], &ds); err != nil {
+				return err
+			}
+			if _, ok := r["primary_distribution"]; !ok && len(ds) == 1 {
+				for id := range ds {
+					b, err := json.Marshal(id)
+					if err != nil {
+						return err
+					}
+					r["primary_distribution"] = b
+				}
+			}
+			return nil
+		},
+	}
+	vulnMigrations = [ReportSchemaVersion]migration{
+		// 0 → 1: Reports could be written with null maps.
+		func(r rawReport) error {
+			nullToEmpty(r,
+				"packages", "distributions", "repository", "environments",
+				"vulnerabilities", "package_vulnerabilities", "enrichments")
+			return nil
+		},
+	}
+)
+
+// NullToEmpty replaces the named members that are missing or null with empty
+// objects.
+func nullToEmpty(r rawReport, keys ...string) {
+	for _, k := range keys {
+		if v, ok := r[k]; !ok || bytes.Equal(bytes.TrimSpace(v), []byte("null")) {
+			r[k] = json.RawMessage("{}")
+		}
+	}
+}
+
+// DecodeReport reads a JSON-encoded report from "r" into "v", which must be
+// an *IndexReport or a *VulnerabilityReport.
+//
+// Reports written by older versions of claircore are migrated to the current
+// ReportSchemaVersion, so this should be used instead of decoding stored
+// reports directly. The decoded report's SchemaVersion is always the current
+// version. Reports with a newer version than this version of claircore knows
+// about are rejected with an error wrapping ErrUnknownSchemaVersion, and a
+// JSON null is rejected with an error.
+func DecodeReport(r io.Reader, v interface{}) error {
+	var ms []migration
+	switch v.(type) {
+	case *IndexReport:
+		ms = indexMigrations[:]
+	case *VulnerabilityReport:
+		ms = vulnMigrations[:]
+	default:
+		return fmt.Errorf("claircore: unable to decode report into %T", v)
+	}
+	var raw rawReport
+	if err := json.NewDecoder(r).Decode(&raw); err != nil {
+		return fmt.Errorf("claircore: unable to decode report: %w", err)
+	}
+	if raw == nil {
+		return errors.New("claircore: unable to decode report: report is null")
+	}
+	var ver int
+	if b, ok := raw["schema_version"]; ok {
+		if err := json.Unmarshal(b, &ver); err != nil {
+			return fmt.Errorf("claircore: unable to decode report schema version: %w", err)
+		}
+	}
+	switch {
+	case ver < 0, ver > ReportSchemaVersion:
+		return fmt.Errorf("claircore: report schema version %d: %w", ver, ErrUnknownSchemaVersion)
+	case ver < ReportSchemaVersion:
+		for i := ver; i < ReportSchemaVersion; i++ {
+			if err := ms[i](raw); err != nil {
+				return fmt.Errorf("claircore: unable to migrate report from schema version %d: %w", i, err)
+			}
+		}
+		b, err := json.Marshal(ReportSchemaVersion)
+		if err != nil {
+			return err
+		}
+		raw["schema_version"] = b
+	}
+	b, err := json.Marshal(raw)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(b, v); err != nil {
+		return fmt.Errorf("claircore: unable to decode report: %w", err)
+	}
+	return nil
+}
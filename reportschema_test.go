@@ -0,0 +1,123 @@
+package claircore_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/quay/claircore"
+)
+
+func TestDecodeReport(t *testing.T) {
+	t.Run("IndexV0", func(t *testing.T) {
+		// An index report as written before schema versions were recorded.
+		const in = `{
+			"manifest_hash": "sha256:5f70bf18a086007016e948b04aed3b82103a36bea41755b6cddfaf10ace3c6ef",
+			"state": "IndexFinished",
+			"packages": {"1": {"id": "1", "name": "openssl", "version": "3.0.11-1"}},
+			"distributions": {"2": {"id": "2", "did": "debian", "version_id": "12"}},
+			"repository": null,
+			"environments": {"1": [{"package_db": "var/lib/dpkg/status", "distribution_id": "2"}]},
+			"success": true,
+			"err": ""
+		}`
+		var got claircore.IndexReport
+		if err := claircore.DecodeReport(strings.NewReader(in), &got); err != nil {
+			t.Fatal(err)
+		}
+		if got, want := got.SchemaVersion, claircore.ReportSchemaVersion; got != want {
+			t.Errorf("schema version: got: %d, want: %d", got, want)
+		}
+		if got.Repositories == nil {
+			t.Error("null repositories not migrated")
+		}
+		if got, want := got.PrimaryDistribution, "2"; got != want {
+			t.Errorf("primary distribution: got: %q, want: %q", got, want)
+		}
+		if got, want := got.Packages["1"].Name, "openssl"; got != want {
+			t.Errorf("package: got: %q, want: %q", got, want)
+		}
+		if got, want := got.Hash.String(), "sha256:5f70bf18a086007016e948b04aed3b82103a36bea41755b6cddfaf10ace3c6ef"; got != want {
+			t.Errorf("hash: got: %q, want: %q", got, want)
+		}
+	})
+
+	t.Run("VulnerabilityV0", func(t *testing.T) {
+		const in = `{
+			"manifest_hash": "sha256:5f70bf18a086007016e948b04aed3b82103a36bea41755b6cddfaf10ace3c6ef",
+			"packages": {"1": {"id": "1", "name": "openssl"}},
+			"vulnerabilities": {"3": {"id": "3", "name": "CVE-2023-5678"}},
+			"package_vulnerabilities": {"1": ["3"]}
+		}`
+		var got claircore.VulnerabilityReport
+		if err := claircore.DecodeReport(strings.NewReader(in), &got); err != nil {
+			t.Fatal(err)
+		}
+		if got, want := got.SchemaVersion, claircore.ReportSchemaVersion; got != want {
+			t.Errorf("schema version: got: %d, want: %d", got, want)
+		}
+		if got.Enrichments == nil || got.Distributions == nil || got.Environments == nil {
+			t.Error("missing maps not migrated")
+		}
+		if got, want := got.PackageVulnerabilities["1"], []string{"3"}; !cmp.Equal(got, want) {
+			t.Error(cmp.Diff(got, want))
+		}
+	})
+
+	t.Run("RoundTrip", func(t *testing.T) {
+		want := &claircore.VulnerabilityReport{
+			SchemaVersion: claircore.ReportSchemaVersion,
+			Hash:          claircore.MustParseDigest(`sha256:5f70bf18a086007016e948b04aed3b82103a36bea41755b6cddfaf10ace3c6ef`),
+			Packages: map[string]*claircore.Package{
+				"1": {ID: "1", Name: "openssl"},
+			},
+			Distributions:          map[string]*claircore.Distribution{},
+			Repositories:           map[string]*claircore.Repository{},
+			Environments:           map[string][]*claircore.Environment{},
+			Vulnerabilities:        map[string]*claircore.Vulnerability{},
+			PackageVulnerabilities: map[string][]string{},
+			Enrichments:            map[string][]json.RawMessage{},
+		}
+		var buf bytes.Buffer
+		if err := json.NewEncoder(&buf).Encode(want); err != nil {
+			t.Fatal(err)
+		}
+		got := &claircore.VulnerabilityReport{}
+		if err := claircore.DecodeReport(&buf, got); err != nil {
+			t.Fatal(err)
+		}
+		if !cmp.Equal(got, want, cmp.AllowUnexported(claircore.Digest{})) {
+			t.Error(cmp.Diff(got, want, cmp.AllowUnexported(claircore.Digest{})))
+		}
+	})
+
+	t.Run("Future", func(t *testing.T) {
+		in := `{"schema_version": 1000, "packages": {}}`
+		var got claircore.IndexReport
+		err := claircore.DecodeReport(strings.NewReader(in), &got)
+		if !errors.Is(err, claircore.ErrUnknownSchemaVersion) {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("Null", func(t *testing.T) {
+		for _, v := range []interface{}{&claircore.IndexReport{}, &claircore.VulnerabilityReport{}} {
+			err := claircore.DecodeReport(strings.NewReader(`null`), v)
+			t.Log(err)
+			if err == nil {
+				t.Errorf("%T: expected error", v)
+			}
+		}
+	})
+
+	t.Run("BadType", func(t *testing.T) {
+		var got claircore.Package
+		if err := claircore.DecodeReport(strings.NewReader(`{}`), &got); err == nil {
+			t.Error("expected error")
+		}
+	})
+}
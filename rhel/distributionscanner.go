@@ -13,7 +13,6 @@ import (
 
 	"github.com/quay/claircore"
 	"github.com/quay/claircore/indexer"
-	"github.com/quay/claircore/pkg/tarfs"
 )
 
 var (
@@ -47,15 +46,11 @@ func (ds *DistributionScanner) Scan(ctx context.Context, l *claircore.Layer) ([]
 		"layer", l.Hash.String())
 	zlog.Debug(ctx).Msg("start")
 	defer zlog.Debug(ctx).Msg("done")
-	rd, err := l.Reader()
-	if err != nil {
-		return nil, fmt.Errorf("rhel: unable to create layer reader: %w", err)
-	}
-	defer rd.Close()
-	sys, err := tarfs.New(rd)
+	sys, err := l.FS()
 	if err != nil {
 		return nil, fmt.Errorf("rhel: unable to open tarfs: %w", err)
 	}
+	defer sys.Close()
 	d, err := findDistribution(sys)
 	if err != nil {
 		return nil, fmt.Errorf("rhel: unexpected error reading files: %w", err)
@@ -10,6 +10,7 @@ import (
 // NewEcosystem provides the set of scanners and coalescer for the rhel ecosystem.
 func NewEcosystem(ctx context.Context) *indexer.Ecosystem {
 	return &indexer.Ecosystem{
+		Name: "rhel",
 		PackageScanners: func(ctx context.Context) ([]indexer.PackageScanner, error) {
 			return []indexer.PackageScanner{new(rpm.Scanner)}, nil
 		},
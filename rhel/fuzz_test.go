@@ -0,0 +1,48 @@
+package rhel
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/quay/zlog"
+
+	"github.com/quay/claircore"
+)
+
+// FuzzParse checks that arbitrary input to the OVAL parser returns an error
+// instead of panicking.
+func FuzzParse(f *testing.F) {
+	b, err := os.ReadFile("testdata/com.redhat.rhsa-20201980.xml")
+	if err != nil {
+		f.Fatal(err)
+	}
+	f.Add(b)
+	f.Add([]byte(`<oval_definitions><definitions><definition id="oval:com.redhat.rhsa:def:1">` +
+		`<metadata><advisory><affected_cpe_list><cpe>cpe:/o:redhat:enterprise_linux:8</cpe></affected_cpe_list></advisory></metadata>` +
+		`<criteria><criterion test_ref="oval:com.redhat.rhsa:tst:1"/></criteria></definition></definitions>` +
+		`<tests><rpminfo_test id="oval:com.redhat.rhsa:tst:1"></rpminfo_test></tests></oval_definitions>`))
+	f.Fuzz(func(t *testing.T, b []byte) {
+		ctx := zlog.Test(context.Background(), t)
+		u, err := NewUpdater("fuzz", 8, "file:///dev/null")
+		if err != nil {
+			t.Fatal(err)
+		}
+		vs, err := u.Parse(ctx, io.NopCloser(bytes.NewReader(b)))
+		if err != nil {
+			return
+		}
+		// Make sure the results are usable by the matcher.
+		m := &Matcher{}
+		r := &claircore.IndexRecord{
+			Package: &claircore.Package{Name: "pkg", Version: "1.0-1.el8", Arch: "x86_64"},
+		}
+		for _, v := range vs {
+			if _, err := m.Vulnerable(ctx, r, v); err != nil {
+				t.Error(err)
+			}
+		}
+	})
+}
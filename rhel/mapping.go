@@ -0,0 +1,229 @@
+package rhel
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+
+	"github.com/quay/zlog"
+
+	"github.com/quay/claircore/datastore"
+	"github.com/quay/claircore/libvuln/driver"
+	"github.com/quay/claircore/pkg/tmp"
+)
+
+// MappingSource maps Red Hat content sets to the CPEs of the products they
+// belong to.
+type MappingSource interface {
+	// Get reports the CPEs, bound into strings, for the provided content
+	// sets. Unknown content sets are ignored.
+	Get(ctx context.Context, contentSets []string) ([]string, error)
+}
+
+var (
+	_ MappingSource = (*mappingFile)(nil)
+	_ MappingSource = (*StoredMapping)(nil)
+)
+
+// MappingUpdaterName is the name of the [MappingUpdater], and the name its
+// records are stored under.
+const MappingUpdaterName = `rhel-repository-to-cpe`
+
+var (
+	_ driver.EnrichmentUpdater = (*MappingUpdater)(nil)
+	_ driver.Configurable      = (*MappingUpdater)(nil)
+)
+
+// MappingUpdater fetches the repository-to-CPE mapping file and stores it as
+// enrichment records, one per content set. This means the mapping is
+// versioned and garbage collected like any other update operation, and can
+// be consulted via a [StoredMapping].
+type MappingUpdater struct {
+	driver.NoopUpdater
+	url    *url.URL
+	client *http.Client
+}
+
+// MappingUpdaterConfig is the configuration for the MappingUpdater.
+type MappingUpdaterConfig struct {
+	// URL is the location of the mapping file. If empty,
+	// [DefaultRepo2CPEMappingURL] is used.
+	URL string `json:"url" yaml:"url"`
+}
+
+// MappingUpdaterSet returns an UpdaterSet containing a MappingUpdater.
+func MappingUpdaterSet(_ context.Context) (driver.UpdaterSet, error) {
+	s := driver.NewUpdaterSet()
+	if err := s.Add(new(MappingUpdater)); err != nil {
+		return s, err
+	}
+	return s, nil
+}
+
+// Name implements [driver.EnrichmentUpdater].
+func (*MappingUpdater) Name() string { return MappingUpdaterName }
+
+// Configure implements [driver.Configurable].
+func (u *MappingUpdater) Configure(ctx context.Context, f driver.ConfigUnmarshaler, c *http.Client) error {
+	ctx = zlog.ContextWithValues(ctx, "component", "rhel/MappingUpdater.Configure")
+	var cfg MappingUpdaterConfig
+	if err := f(&cfg); err != nil {
+		return err
+	}
+	if cfg.URL == "" {
+		cfg.URL = DefaultRepo2CPEMappingURL
+	}
+	var err error
+	u.url, err = url.Parse(cfg.URL)
+	if err != nil {
+		return fmt.Errorf("rhel: bad mapping url: %w", err)
+	}
+	zlog.Debug(ctx).
+		Stringer("url", u.url).
+		Msg("configured mapping URL")
+	u.client = c
+	return nil
+}
+
+// MappingFingerprint is the fingerprint used by the MappingUpdater.
+type mappingFingerprint struct {
+	Etag         string `json:"etag,omitempty"`
+	LastModified string `json:"last_modified,omitempty"`
+}
+
+// FetchEnrichment implements [driver.EnrichmentUpdater].
+func (u *MappingUpdater) FetchEnrichment(ctx context.Context, hint driver.Fingerprint) (io.ReadCloser, driver.Fingerprint, error) {
+	ctx = zlog.ContextWithValues(ctx, "component", "rhel/MappingUpdater.FetchEnrichment")
+	if u.url == nil || u.client == nil {
+		return nil, hint, fmt.Errorf("rhel: MappingUpdater not configured")
+	}
+	var fp mappingFingerprint
+	if hint != "" {
+		if err := json.Unmarshal([]byte(hint), &fp); err != nil {
+			zlog.Info(ctx).
+				Err(err).
+				Msg("ignoring unparsable fingerprint")
+			fp = mappingFingerprint{}
+		}
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.url.String(), nil)
+	if err != nil {
+		return nil, hint, err
+	}
+	if fp.Etag != "" {
+		req.Header.Set("if-none-match", fp.Etag)
+	}
+	if fp.LastModified != "" {
+		req.Header.Set("if-modified-since", fp.LastModified)
+	}
+	res, err := u.client.Do(req)
+	if err != nil {
+		return nil, hint, err
+	}
+	defer res.Body.Close()
+	switch res.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotModified:
+		return nil, hint, driver.Unchanged
+	default:
+		return nil, hint, fmt.Errorf("rhel: unexpected response fetching mapping file: %v", res.Status)
+	}
+
+	f, err := tmp.NewFile("", "rhel-mapping.")
+	if err != nil {
+		return nil, hint, err
+	}
+	if _, err := io.Copy(f, res.Body); err != nil {
+		f.Close()
+		return nil, hint, err
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		f.Close()
+		return nil, hint, err
+	}
+	fp = mappingFingerprint{
+		Etag:         res.Header.Get("etag"),
+		LastModified: res.Header.Get("last-modified"),
+	}
+	b, err := json.Marshal(fp)
+	if err != nil {
+		f.Close()
+		return nil, hint, err
+	}
+	zlog.Debug(ctx).Msg("fetched mapping file")
+	return f, driver.Fingerprint(b), nil
+}
+
+// ParseEnrichment implements [driver.EnrichmentUpdater].
+//
+// Each content set in the mapping file becomes a record tagged with the
+// content set's name.
+func (u *MappingUpdater) ParseEnrichment(ctx context.Context, rc io.ReadCloser) ([]driver.EnrichmentRecord, error) {
+	ctx = zlog.ContextWithValues(ctx, "component", "rhel/MappingUpdater.ParseEnrichment")
+	defer rc.Close()
+	var mf mappingFile
+	if err := json.NewDecoder(rc).Decode(&mf); err != nil {
+		return nil, fmt.Errorf("rhel: unable to decode mapping file: %w", err)
+	}
+	names := make([]string, 0, len(mf.Data))
+	for n := range mf.Data {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	out := make([]driver.EnrichmentRecord, 0, len(names))
+	for _, n := range names {
+		b, err := json.Marshal(mf.Data[n])
+		if err != nil {
+			return nil, fmt.Errorf("rhel: unable to encode mapping for %q: %w", n, err)
+		}
+		out = append(out, driver.EnrichmentRecord{
+			Tags:       []string{n},
+			Enrichment: b,
+		})
+	}
+	zlog.Debug(ctx).
+		Int("count", len(out)).
+		Msg("parsed mapping file")
+	return out, nil
+}
+
+// StoredMapping is a MappingSource that consults the latest mapping stored
+// by the [MappingUpdater].
+//
+// This is useful when the indexer can reach the matcher's database, and
+// keeps every indexer using the same copy of the mapping. Set it as the
+// Mapping of a [RepositoryScannerConfig] to use it.
+type StoredMapping struct {
+	Store datastore.Enrichment
+}
+
+// Get implements MappingSource.
+func (m *StoredMapping) Get(ctx context.Context, rs []string) ([]string, error) {
+	if len(rs) == 0 {
+		return []string{}, nil
+	}
+	recs, err := m.Store.GetEnrichment(ctx, MappingUpdaterName, rs)
+	if err != nil {
+		return nil, fmt.Errorf("rhel: unable to query stored mapping: %w", err)
+	}
+	s := make(map[string]struct{})
+	for _, rec := range recs {
+		var v repo
+		if err := json.Unmarshal(rec.Enrichment, &v); err != nil {
+			return nil, fmt.Errorf("rhel: unable to decode stored mapping: %w", err)
+		}
+		for _, cpe := range v.CPEs {
+			s[cpe] = struct{}{}
+		}
+	}
+	out := make([]string, 0, len(s))
+	for k := range s {
+		out = append(out, k)
+	}
+	sort.Strings(out)
+	return out, nil
+}
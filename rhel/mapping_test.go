@@ -0,0 +1,149 @@
+package rhel
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/quay/zlog"
+
+	"github.com/quay/claircore"
+	"github.com/quay/claircore/libvuln/driver"
+)
+
+// EnrichmentStore is an in-memory stand-in for the latest update operation
+// of the enrichment store.
+type enrichmentStore struct {
+	mu     sync.Mutex
+	latest []driver.EnrichmentRecord
+}
+
+func (s *enrichmentStore) UpdateEnrichments(recs []driver.EnrichmentRecord) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.latest = recs
+}
+
+func (s *enrichmentStore) GetEnrichment(_ context.Context, name string, tags []string) ([]driver.EnrichmentRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if name != MappingUpdaterName {
+		return nil, nil
+	}
+	var out []driver.EnrichmentRecord
+Records:
+	for _, r := range s.latest {
+		for _, a := range r.Tags {
+			for _, b := range tags {
+				if a == b {
+					out = append(out, r)
+					continue Records
+				}
+			}
+		}
+	}
+	return out, nil
+}
+
+func TestMappingUpdater(t *testing.T) {
+	ctx := zlog.Test(context.Background(), t)
+	var mu sync.Mutex
+	mapping, etag := `{"data":{"content-set-1":{"cpes":["cpe:/o:redhat:enterprise_linux:6::server","cpe:/o:redhat:enterprise_linux:7::server"]},"content-set-2":{"cpes":["cpe:/o:redhat:enterprise_linux:7::server","cpe:/o:redhat:enterprise_linux:8::server"]}}}`, `"1"`
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if r.Header.Get("if-none-match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("etag", etag)
+		w.Write([]byte(mapping))
+	}))
+	defer srv.Close()
+
+	var u MappingUpdater
+	err := u.Configure(ctx, func(v interface{}) error {
+		v.(*MappingUpdaterConfig).URL = srv.URL
+		return nil
+	}, srv.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := &enrichmentStore{}
+	// Update mimics what the update Manager does with an EnrichmentUpdater.
+	update := func(hint driver.Fingerprint) driver.Fingerprint {
+		t.Helper()
+		rc, fp, err := u.FetchEnrichment(ctx, hint)
+		switch {
+		case errors.Is(err, nil):
+		case errors.Is(err, driver.Unchanged):
+			return hint
+		default:
+			t.Fatal(err)
+		}
+		recs, err := u.ParseEnrichment(ctx, rc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		store.UpdateEnrichments(recs)
+		return fp
+	}
+	var scanner RepositoryScanner
+	cfg := func(v interface{}) error {
+		v.(*RepositoryScannerConfig).Mapping = &StoredMapping{Store: store}
+		return nil
+	}
+	if err := scanner.Configure(ctx, cfg, srv.Client()); err != nil {
+		t.Fatal(err)
+	}
+	scan := func() []string {
+		t.Helper()
+		l := &claircore.Layer{}
+		l.SetLocal("testdata/layer-with-embedded-cs.tar")
+		rs, err := scanner.Scan(ctx, l)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, r := range rs {
+			got = append(got, r.Name)
+		}
+		sort.Strings(got)
+		return got
+	}
+
+	fp := update("")
+	want := []string{
+		"cpe:/o:redhat:enterprise_linux:6::server",
+		"cpe:/o:redhat:enterprise_linux:7::server",
+		"cpe:/o:redhat:enterprise_linux:8::server",
+	}
+	if got := scan(); !cmp.Equal(got, want) {
+		t.Error(cmp.Diff(got, want))
+	}
+
+	// Unchanged upstream keeps the same fingerprint.
+	if got := update(fp); got != fp {
+		t.Errorf("fingerprint changed: %q → %q", fp, got)
+	}
+
+	// Change the mapping: content-set-1 moves to RHEL 9.
+	mu.Lock()
+	mapping, etag = `{"data":{"content-set-1":{"cpes":["cpe:/o:redhat:enterprise_linux:9::baseos"]},"content-set-2":{"cpes":["cpe:/o:redhat:enterprise_linux:8::server"]}}}`, `"2"`
+	mu.Unlock()
+	if got := update(fp); got == fp {
+		t.Error("fingerprint unchanged after mapping change")
+	}
+	want = []string{
+		"cpe:/o:redhat:enterprise_linux:8::server",
+		"cpe:/o:redhat:enterprise_linux:9::baseos",
+	}
+	if got := scan(); !cmp.Equal(got, want) {
+		t.Error(cmp.Diff(got, want))
+	}
+}
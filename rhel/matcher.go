@@ -2,17 +2,104 @@ package rhel
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
 
 	version "github.com/knqyf263/go-rpm-version"
+	"github.com/quay/zlog"
 
 	"github.com/quay/claircore"
+	"github.com/quay/claircore/internal/vercache"
 	"github.com/quay/claircore/libvuln/driver"
 )
 
 // Matcher implements driver.Matcher.
-type Matcher struct{}
+//
+// A Matcher isn't modified after it's constructed, so a single Matcher is safe
+// for concurrent use by multiple goroutines. Vulnerable only reads its
+// arguments.
+//
+// The zero value is ready to use and strips no release suffixes.
+type Matcher struct {
+	suffixes []*regexp.Regexp
+}
+
+// MatcherConfig is the configuration accepted by the MatcherFactory.
+type MatcherConfig struct {
+	// ReleaseSuffixes is a list of regular expressions matching suffixes
+	// that vendors append to the release of packages they rebuild, such as
+	// `\.myco\d+`. A matching suffix is removed from the end of an installed
+	// package's release before it's compared to an advisory, so a rebuild of
+	// a vulnerable version is still reported as vulnerable.
+	ReleaseSuffixes []string `json:"release_suffixes" yaml:"release_suffixes"`
+}
+
+// NewMatcher returns a Matcher using the provided configuration.
+func NewMatcher(cfg MatcherConfig) (*Matcher, error) {
+	var m Matcher
+	for _, s := range cfg.ReleaseSuffixes {
+		if s == "" {
+			return nil, errors.New("rhel: empty release suffix")
+		}
+		re, err := regexp.Compile(`(?:` + s + `)$`)
+		if err != nil {
+			return nil, fmt.Errorf("rhel: bad release suffix %q: %w", s, err)
+		}
+		m.suffixes = append(m.suffixes, re)
+	}
+	return &m, nil
+}
+
+// MatcherFactory constructs a Matcher, which can be configured with a
+// MatcherConfig.
+type MatcherFactory struct {
+	cfg MatcherConfig
+}
+
+// Matcher implements driver.MatcherFactory.
+func (f *MatcherFactory) Matcher(_ context.Context) ([]driver.Matcher, error) {
+	m, err := NewMatcher(f.cfg)
+	if err != nil {
+		return nil, err
+	}
+	return []driver.Matcher{m}, nil
+}
+
+// Configure implements driver.MatcherConfigurable.
+func (f *MatcherFactory) Configure(ctx context.Context, cfg driver.MatcherConfigUnmarshaler, _ *http.Client) error {
+	ctx = zlog.ContextWithValues(ctx, "component", "rhel/MatcherFactory.Configure")
+	var fc MatcherConfig
+	if err := cfg(&fc); err != nil {
+		return err
+	}
+	// Check the configuration now, rather than when constructing matchers.
+	if _, err := NewMatcher(fc); err != nil {
+		return err
+	}
+	f.cfg = fc
+	zlog.Info(ctx).
+		Strs("release_suffixes", fc.ReleaseSuffixes).
+		Msg("configured")
+	return nil
+}
 
-var _ driver.Matcher = (*Matcher)(nil)
+var (
+	_ driver.Matcher              = (*Matcher)(nil)
+	_ driver.FixedVersionComparer = (*Matcher)(nil)
+	_ driver.MatchEnricher        = (*Matcher)(nil)
+	_ driver.QueryRecorder        = (*Matcher)(nil)
+	_ driver.AgnosticMatcher      = (*Matcher)(nil)
+
+	_ driver.MatcherFactory      = (*MatcherFactory)(nil)
+	_ driver.MatcherConfigurable = (*MatcherFactory)(nil)
+)
 
 // Name implements driver.Matcher.
 func (*Matcher) Name() string {
@@ -29,12 +116,57 @@ func (*Matcher) Query() []driver.MatchConstraint {
 	return []driver.MatchConstraint{
 		driver.PackageModule,
 		driver.RepositoryName,
+		driver.PackageProvides,
 	}
 }
 
+// QueryRecord implements driver.QueryRecorder.
+//
+// Debuginfo and debugsource packages aren't named in advisories, but are
+// built from the same sources as, and share the EVR of, the package they hold
+// debugging information for. Records for them are also queried as that base
+// package.
+func (*Matcher) QueryRecord(r *claircore.IndexRecord) *claircore.IndexRecord {
+	base, ok := debugBase(r.Package.Name)
+	if !ok {
+		return r
+	}
+	p := *r.Package
+	p.Provides = append(make([]string, 0, len(p.Provides)+1), p.Provides...)
+	p.Provides = append(p.Provides, base)
+	return &claircore.IndexRecord{
+		Package:      &p,
+		Distribution: r.Distribution,
+		Repository:   r.Repository,
+	}
+}
+
+// DebugSuffixes are the name suffixes of packages holding debugging
+// information for another package.
+var debugSuffixes = []string{"-debuginfo", "-debugsource"}
+
+// DebugBase returns the name of the package that the package named "name"
+// holds debugging information for, and reports whether it's such a package.
+//
+// A "foo-debuginfo" package is for the "foo" binary package and a
+// "foo-debugsource" package is for the "foo" source package, whose main
+// binary package shares its name.
+func debugBase(name string) (string, bool) {
+	for _, s := range debugSuffixes {
+		if b := strings.TrimSuffix(name, s); b != name && b != "" {
+			return b, true
+		}
+	}
+	return "", false
+}
+
 // Vulnerable implements driver.Matcher.
+//
+// Unfixed vulnerabilities are vulnerable whatever their FixState; the state
+// is carried on the vulnerability itself, so reports can tell deferred fixes
+// apart from ones that won't happen.
 func (m *Matcher) Vulnerable(ctx context.Context, record *claircore.IndexRecord, vuln *claircore.Vulnerability) (bool, error) {
-	pkgVer := version.NewVersion(record.Package.Version)
+	pkgVer := vercache.RPM(ctx, m.stripSuffix(providedVersion(record.Package, vuln.Package.Name)))
 	var vulnVer version.Version
 	// Assume the vulnerability record we have is for the last known vulnerable
 	// version, so greater versions aren't vulnerable.
@@ -42,7 +174,7 @@ func (m *Matcher) Vulnerable(ctx context.Context, record *claircore.IndexRecord,
 	// But if it's explicitly marked as a fixed-in version, it's only vulnerable
 	// if less than that version.
 	if vuln.FixedInVersion != "" {
-		vulnVer = version.NewVersion(vuln.FixedInVersion)
+		vulnVer = vercache.RPM(ctx, vuln.FixedInVersion)
 		cmp = func(i int) bool { return i == version.LESS }
 	} else {
 		// If a vulnerability doesn't have FixedInVersion, assume it is unfixed.
@@ -51,3 +183,131 @@ func (m *Matcher) Vulnerable(ctx context.Context, record *claircore.IndexRecord,
 	// compare version and architecture
 	return cmp(pkgVer.Compare(vulnVer)) && vuln.ArchOperation.Cmp(record.Package.Arch, vuln.Package.Arch), nil
 }
+
+// VulnerableAnyVersion implements driver.AgnosticMatcher.
+func (*Matcher) VulnerableAnyVersion(_ context.Context, record *claircore.IndexRecord, vuln *claircore.Vulnerability) (bool, error) {
+	return vuln.ArchOperation.Cmp(record.Package.Arch, vuln.Package.Arch), nil
+}
+
+// CompareFixedVersions implements driver.FixedVersionComparer.
+func (*Matcher) CompareFixedVersions(ctx context.Context, a, b string) int {
+	return vercache.CompareRPM(ctx, a, b)
+}
+
+// StripSuffix removes the first configured release suffix found at the end of
+// the release of the EVR "v". The release is left alone if removing the suffix
+// would leave it empty.
+func (m *Matcher) stripSuffix(v string) string {
+	i := strings.LastIndexByte(v, '-')
+	if len(m.suffixes) == 0 || i == -1 {
+		return v
+	}
+	rel := v[i+1:]
+	for _, re := range m.suffixes {
+		loc := re.FindStringIndex(rel)
+		if loc == nil || loc[0] == 0 {
+			continue
+		}
+		return v[:i+1] + rel[:loc[0]]
+	}
+	return v
+}
+
+// ProvidedVersion returns the version to compare against a vulnerability
+// recorded for the package named "name".
+//
+// If "name" is a capability the package provides with an explicit version,
+// that version is used. Otherwise, the package's version is used, including
+// for debugging packages matched against their base package.
+func providedVersion(p *claircore.Package, name string) string {
+	if name == p.Name || (p.Source != nil && name == p.Source.Name) {
+		return p.Version
+	}
+	if b, ok := debugBase(p.Name); ok && b == name {
+		return p.Version
+	}
+	for _, pr := range p.Provides {
+		n, v, ok := strings.Cut(pr, " = ")
+		if ok && n == name {
+			return v
+		}
+	}
+	return p.Version
+}
+
+// EnrichmentType is the type of the enrichments reported by
+// [Matcher.EnrichMatches].
+const EnrichmentType = `message/vnd.clair.map.vulnerability; enricher=rhel schema=https://pkg.go.dev/github.com/quay/claircore/rhel#Advisory`
+
+// Advisory is the enrichment reported for every vulnerability the Matcher
+// matches, when any of the information is available.
+type Advisory struct {
+	// Advisories are the Red Hat advisories (RHSA, RHBA, RHEA) associated
+	// with the vulnerability, by ID.
+	Advisories []string `json:"advisories,omitempty"`
+	// Links are the errata links for the Advisories.
+	Links []string `json:"links,omitempty"`
+	// Bugzilla is the list of Red Hat Bugzilla IDs.
+	Bugzilla []string `json:"bugzilla,omitempty"`
+	// CWE is the list of CWE IDs, in "CWE-NNN" form.
+	CWE []string `json:"cwe,omitempty"`
+}
+
+// EnrichMatches implements [driver.MatchEnricher].
+//
+// The information is recovered from the vulnerability's links, so Bugzilla
+// and CWE IDs are only reported if the links include them.
+func (*Matcher) EnrichMatches(ctx context.Context, vulns map[string][]*claircore.Vulnerability) (string, []json.RawMessage, error) {
+	m := make(map[string]*Advisory)
+	for _, vs := range vulns {
+		for _, v := range vs {
+			if _, ok := m[v.ID]; ok {
+				continue
+			}
+			if a := parseAdvisory(v.Links); a != nil {
+				m[v.ID] = a
+			}
+		}
+	}
+	if len(m) == 0 {
+		return EnrichmentType, nil, nil
+	}
+	b, err := json.Marshal(m)
+	if err != nil {
+		return EnrichmentType, nil, fmt.Errorf("rhel: unable to marshal enrichment: %w", err)
+	}
+	return EnrichmentType, []json.RawMessage{b}, nil
+}
+
+// ParseAdvisory pulls advisory, Bugzilla, and CWE information out of the
+// space-separated links string. A nil pointer is returned if nothing is
+// found.
+func parseAdvisory(links string) *Advisory {
+	var a Advisory
+	for _, l := range strings.Fields(links) {
+		u, err := url.Parse(l)
+		if err != nil {
+			continue
+		}
+		switch {
+		case u.Host == "access.redhat.com" && strings.HasPrefix(u.Path, "/errata/"):
+			a.Advisories = append(a.Advisories, path.Base(u.Path))
+			a.Links = append(a.Links, l)
+		case u.Host == "bugzilla.redhat.com":
+			id := u.Query().Get("id")
+			if id == "" {
+				id = path.Base(u.Path)
+			}
+			a.Bugzilla = append(a.Bugzilla, id)
+		case u.Host == "cwe.mitre.org" && strings.HasPrefix(u.Path, "/data/definitions/"):
+			a.CWE = append(a.CWE, "CWE-"+strings.TrimSuffix(path.Base(u.Path), ".html"))
+		}
+	}
+	if len(a.Advisories) == 0 && len(a.Bugzilla) == 0 && len(a.CWE) == 0 {
+		return nil
+	}
+	for _, s := range [][]string{a.Advisories, a.Links, a.Bugzilla, a.CWE} {
+		sort.Strings(s)
+	}
+	return &a
+}
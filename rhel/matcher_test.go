@@ -9,16 +9,21 @@ import (
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"sort"
+	"sync"
 	"testing"
 
+	"github.com/google/go-cmp/cmp"
 	"github.com/quay/zlog"
 
 	"github.com/quay/claircore"
+	"github.com/quay/claircore/datastore/memory"
 	"github.com/quay/claircore/datastore/postgres"
 	"github.com/quay/claircore/internal/matcher"
 	"github.com/quay/claircore/libvuln/driver"
 	"github.com/quay/claircore/libvuln/updates"
 	"github.com/quay/claircore/pkg/ctxlock"
+	"github.com/quay/claircore/pkg/ovalutil"
 	"github.com/quay/claircore/test/integration"
 	pgtest "github.com/quay/claircore/test/postgres"
 )
@@ -112,6 +117,7 @@ type vulnerableTestCase struct {
 }
 
 func TestVulnerable(t *testing.T) {
+	ctx := zlog.Test(context.Background(), t)
 	record := &claircore.IndexRecord{
 		Package: &claircore.Package{
 			Version: "0.33.0-6.el8",
@@ -141,18 +147,34 @@ func TestVulnerable(t *testing.T) {
 		},
 		FixedInVersion: "",
 	}
+	deferredVuln := &claircore.Vulnerability{
+		Package: &claircore.Package{
+			Version: "",
+		},
+		FixedInVersion: "",
+		FixState:       FixStateFixDeferred,
+	}
+	wontFixVuln := &claircore.Vulnerability{
+		Package: &claircore.Package{
+			Version: "",
+		},
+		FixedInVersion: "",
+		FixState:       FixStateWillNotFix,
+	}
 
 	testCases := []vulnerableTestCase{
 		{ir: record, v: fixedVulnPast, want: false, name: "vuln fixed in past version"},
 		{ir: record, v: fixedVulnCurrent, want: false, name: "vuln fixed in current version"},
 		{ir: record, v: fixedVulnFuture, want: true, name: "outdated package"},
 		{ir: record, v: unfixedVuln, want: true, name: "unfixed vuln"},
+		{ir: record, v: deferredVuln, want: true, name: "fix deferred vuln"},
+		{ir: record, v: wontFixVuln, want: true, name: "will not fix vuln"},
 	}
 
 	m := &Matcher{}
 
 	for _, tc := range testCases {
-		got, err := m.Vulnerable(nil, tc.ir, tc.v)
+		got, err := m.Vulnerable(ctx, tc.ir, tc.v)
 		if err != nil {
 			t.Error(err)
 		}
@@ -161,3 +183,386 @@ func TestVulnerable(t *testing.T) {
 		}
 	}
 }
+
+func TestVulnerableAnyVersion(t *testing.T) {
+	ctx := zlog.Test(context.Background(), t)
+	v := &claircore.Vulnerability{
+		Package:        &claircore.Package{Name: "telnet-server", Arch: "x86_64"},
+		ArchOperation:  claircore.OpEquals,
+		AlwaysAffected: true,
+	}
+	var m Matcher
+	for arch, want := range map[string]bool{"x86_64": true, "aarch64": false} {
+		record := &claircore.IndexRecord{
+			Package: &claircore.Package{Name: "telnet-server", Arch: arch},
+		}
+		got, err := m.VulnerableAnyVersion(ctx, record, v)
+		if err != nil {
+			t.Error(err)
+		}
+		if got != want {
+			t.Errorf("%s: got: %v, want: %v", arch, got, want)
+		}
+	}
+}
+
+func TestEnrichMatches(t *testing.T) {
+	t.Parallel()
+	ctx := zlog.Test(context.Background(), t)
+	m := &Matcher{}
+	vs := map[string][]*claircore.Vulnerability{
+		"1": {
+			{ID: "a", Links: ovalutil.Links(ovalDef)},
+			{ID: "b", Links: "https://access.redhat.com/security/cve/CVE-2007-5935"},
+			{ID: "c", Links: "https://bugzilla.redhat.com/show_bug.cgi?id=368591 https://cwe.mitre.org/data/definitions/190.html"},
+		},
+		"2": {
+			{ID: "a", Links: ovalutil.Links(ovalDef)},
+		},
+	}
+	kind, msgs, err := m.EnrichMatches(ctx, vs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := kind, EnrichmentType; got != want {
+		t.Errorf("got: %q, want: %q", got, want)
+	}
+	if got, want := len(msgs), 1; got != want {
+		t.Fatalf("got: %d messages, want: %d", got, want)
+	}
+	var got map[string]*Advisory
+	if err := json.Unmarshal(msgs[0], &got); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]*Advisory{
+		"a": {
+			Advisories: []string{"RHSA-2010:0401"},
+			Links:      []string{"https://access.redhat.com/errata/RHSA-2010:0401"},
+		},
+		"c": {
+			Bugzilla: []string{"368591"},
+			CWE:      []string{"CWE-190"},
+		},
+	}
+	if !cmp.Equal(got, want) {
+		t.Error(cmp.Diff(got, want))
+	}
+}
+
+func TestVulnerableProvides(t *testing.T) {
+	t.Parallel()
+	ctx := zlog.Test(context.Background(), t)
+	m := &Matcher{}
+	// The advisory targets the "webserver" capability, which is satisfied by
+	// a differently-named package.
+	mkVuln := func(name, fixed string) *claircore.Vulnerability {
+		return &claircore.Vulnerability{
+			Package: &claircore.Package{
+				Name: name,
+				Kind: claircore.BINARY,
+			},
+			FixedInVersion: fixed,
+		}
+	}
+	record := &claircore.IndexRecord{
+		Package: &claircore.Package{
+			Name:     "httpd",
+			Version:  "2.4.37-21.el8",
+			Kind:     claircore.BINARY,
+			Arch:     "x86_64",
+			Provides: []string{"webserver", "httpd-mmn = 20120211x8664"},
+		},
+	}
+	tt := []struct {
+		Name string
+		Vuln *claircore.Vulnerability
+		Want bool
+	}{
+		{Name: "Unversioned", Vuln: mkVuln("webserver", "2.4.37-30.el8"), Want: true},
+		{Name: "UnversionedFixed", Vuln: mkVuln("webserver", "2.4.37-10.el8"), Want: false},
+		{Name: "Versioned", Vuln: mkVuln("httpd-mmn", "20120211x8665"), Want: true},
+		{Name: "VersionedFixed", Vuln: mkVuln("httpd-mmn", "20120211x8664"), Want: false},
+	}
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			got, err := m.Vulnerable(ctx, record, tc.Vuln)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tc.Want {
+				t.Errorf("got: %v, want: %v", got, tc.Want)
+			}
+		})
+	}
+}
+
+// TestMatcherConcurrent shares one Matcher across many goroutines. It's only
+// interesting when run with the race detector.
+func TestMatcherConcurrent(t *testing.T) {
+	t.Parallel()
+	ctx := zlog.Test(context.Background(), t)
+	m := &Matcher{}
+	records := []*claircore.IndexRecord{
+		{Package: &claircore.Package{Name: "openssl", Version: "1.1.1k-4.el8", Arch: "x86_64"}},
+		{Package: &claircore.Package{Name: "openssl", Version: "1.1.1k-7.el8", Arch: "x86_64"}},
+		{Package: &claircore.Package{Name: "httpd", Version: "2.4.37-21.el8", Arch: "aarch64", Provides: []string{"webserver"}}},
+	}
+	vulns := []*claircore.Vulnerability{
+		{ID: "1", Package: &claircore.Package{Name: "openssl"}, FixedInVersion: "1:1.1.1k-5.el8", Links: ovalutil.Links(ovalDef)},
+		{ID: "2", Package: &claircore.Package{Name: "webserver", Arch: "x86_64|aarch64"}, ArchOperation: claircore.OpPatternMatch},
+		{ID: "3", Package: &claircore.Package{Name: "openssl", Arch: "ppc64le"}, ArchOperation: claircore.OpEquals},
+	}
+	// Compute the expected results serially.
+	want := make([][]bool, len(records))
+	for i, r := range records {
+		want[i] = make([]bool, len(vulns))
+		for j, v := range vulns {
+			ok, err := m.Vulnerable(ctx, r, v)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want[i][j] = ok
+		}
+	}
+
+	const workers = 64
+	var wg sync.WaitGroup
+	errCh := make(chan error, workers)
+	for n := 0; n < workers; n++ {
+		wg.Add(1)
+		go func(n int) {
+			defer wg.Done()
+			for k := 0; k < 100; k++ {
+				i, j := (n+k)%len(records), k%len(vulns)
+				got, err := m.Vulnerable(ctx, records[i], vulns[j])
+				if err != nil {
+					errCh <- err
+					return
+				}
+				if got != want[i][j] {
+					errCh <- fmt.Errorf("record %d, vuln %d: got: %v, want: %v", i, j, got, want[i][j])
+					return
+				}
+				if m.Filter(records[i]) {
+					errCh <- fmt.Errorf("record %d: unexpectedly passed filter", i)
+					return
+				}
+			}
+			vs := map[string][]*claircore.Vulnerability{"1": vulns}
+			if _, _, err := m.EnrichMatches(ctx, vs); err != nil {
+				errCh <- err
+			}
+		}(n)
+	}
+	wg.Wait()
+	close(errCh)
+	for err := range errCh {
+		t.Error(err)
+	}
+}
+
+func TestMatchDebugPackages(t *testing.T) {
+	t.Parallel()
+	ctx := zlog.Test(context.Background(), t)
+	f, err := os.Open(filepath.Join("testdata", "debuginfo-report.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var ir claircore.IndexReport
+	if err := json.NewDecoder(f).Decode(&ir); err != nil {
+		t.Fatalf("failed to decode IndexReport: %v", err)
+	}
+	repo := &claircore.Repository{Name: "cpe:/o:redhat:enterprise_linux:8::baseos"}
+	vulns := []*claircore.Vulnerability{
+		{
+			ID:             "openssl-libs",
+			Package:        &claircore.Package{Name: "openssl-libs", Kind: claircore.BINARY},
+			Repo:           repo,
+			FixedInVersion: "1:1.1.1k-5.el8",
+		},
+		{
+			ID:             "openssl",
+			Package:        &claircore.Package{Name: "openssl", Kind: claircore.BINARY},
+			Repo:           repo,
+			FixedInVersion: "1:1.1.1k-5.el8",
+		},
+		{
+			ID:             "zlib",
+			Package:        &claircore.Package{Name: "zlib", Kind: claircore.BINARY},
+			Repo:           repo,
+			FixedInVersion: "1.2.11-17.el8",
+		},
+	}
+
+	vr, err := matcher.Match(ctx, &ir, []driver.Matcher{&Matcher{}}, memory.New(vulns...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make(map[string][]string)
+	for pkg, ids := range vr.PackageVulnerabilities {
+		for _, id := range ids {
+			got[pkg] = append(got[pkg], vr.Vulnerabilities[id].ID)
+		}
+		sort.Strings(got[pkg])
+	}
+	want := map[string][]string{
+		// The binary package and its debuginfo package are matched against
+		// the binary package's advisory.
+		"1": {"openssl-libs"},
+		"2": {"openssl-libs"},
+		// The debugsource package is matched against the advisory for the
+		// package named for the sources.
+		"3": {"openssl"},
+		// The zlib-debuginfo package and the newer openssl-libs-debuginfo
+		// package are fixed.
+	}
+	if !cmp.Equal(got, want) {
+		t.Error(cmp.Diff(got, want))
+	}
+}
+
+func TestDebugBase(t *testing.T) {
+	t.Parallel()
+	tt := []struct {
+		In   string
+		Want string
+		OK   bool
+	}{
+		{"openssl-libs-debuginfo", "openssl-libs", true},
+		{"openssl-debugsource", "openssl", true},
+		{"openssl-libs", "", false},
+		{"debuginfo-install", "", false},
+		{"-debuginfo", "", false},
+	}
+	for _, tc := range tt {
+		got, ok := debugBase(tc.In)
+		if got != tc.Want || ok != tc.OK {
+			t.Errorf("%q: got: (%q, %v), want: (%q, %v)", tc.In, got, ok, tc.Want, tc.OK)
+		}
+	}
+}
+
+func TestVulnerableReleaseSuffix(t *testing.T) {
+	t.Parallel()
+	ctx := zlog.Test(context.Background(), t)
+	m, err := NewMatcher(MatcherConfig{
+		ReleaseSuffixes: []string{`\.\d+\.myco`, `\.myco\d+`},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fixed := func(v string) *claircore.Vulnerability {
+		return &claircore.Vulnerability{
+			Package:        &claircore.Package{Name: "openssl-libs"},
+			FixedInVersion: v,
+		}
+	}
+	pkg := func(v string) *claircore.IndexRecord {
+		return &claircore.IndexRecord{
+			Package: &claircore.Package{Name: "openssl-libs", Version: v},
+		}
+	}
+	tt := []struct {
+		name    string
+		pkg     string
+		fixed   string
+		want    bool
+		wantRaw bool // without the suffixes configured
+	}{
+		{
+			// The rebuild's release has more segments than the fix's, so it
+			// compares as newer, hiding the vulnerability unless the suffix
+			// is stripped.
+			name:    "RebuildOfVulnerable",
+			pkg:     "1:1.1.1k-5.el8.1.myco",
+			fixed:   "1:1.1.1k-5.el8_1",
+			want:    true,
+			wantRaw: false,
+		},
+		{
+			name:    "RebuildOfFixed",
+			pkg:     "1:1.1.1k-5.el8_1.1.myco",
+			fixed:   "1:1.1.1k-5.el8_1",
+			want:    false,
+			wantRaw: false,
+		},
+		{
+			name:    "OtherPattern",
+			pkg:     "1:1.1.1k-4.el8.myco1",
+			fixed:   "1:1.1.1k-5.el8",
+			want:    true,
+			wantRaw: true,
+		},
+		{
+			name:    "NotAtEnd",
+			pkg:     "2.5-3.el8.1.myco.x",
+			fixed:   "2.5-3.el8_1",
+			want:    false,
+			wantRaw: false,
+		},
+		{
+			// A release that's only a suffix is left alone.
+			name:    "WholeRelease",
+			pkg:     "2.5-.1.myco",
+			fixed:   "2.5-1",
+			want:    false,
+			wantRaw: false,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := m.Vulnerable(ctx, pkg(tc.pkg), fixed(tc.fixed))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tc.want {
+				t.Errorf("got: %v, want: %v", got, tc.want)
+			}
+			got, err = (&Matcher{}).Vulnerable(ctx, pkg(tc.pkg), fixed(tc.fixed))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tc.wantRaw {
+				t.Errorf("without suffixes: got: %v, want: %v", got, tc.wantRaw)
+			}
+		})
+	}
+}
+
+func TestMatcherFactory(t *testing.T) {
+	t.Parallel()
+	ctx := zlog.Test(context.Background(), t)
+	tt := []struct {
+		name string
+		cfg  string
+		ok   bool
+	}{
+		{name: "Empty", cfg: `{}`, ok: true},
+		{name: "Good", cfg: `{"release_suffixes":["\\.myco\\d+"]}`, ok: true},
+		{name: "EmptySuffix", cfg: `{"release_suffixes":[""]}`},
+		{name: "BadRegexp", cfg: `{"release_suffixes":["("]}`},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			var f MatcherFactory
+			err := f.Configure(ctx, func(v interface{}) error {
+				return json.Unmarshal([]byte(tc.cfg), v)
+			}, nil)
+			t.Log(err)
+			if (err == nil) != tc.ok {
+				t.Fatalf("got: %v, want ok: %v", err, tc.ok)
+			}
+			if !tc.ok {
+				return
+			}
+			ms, err := f.Matcher(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, want := len(ms), 1; got != want {
+				t.Errorf("got: %d matchers, want: %d", got, want)
+			}
+		})
+	}
+}
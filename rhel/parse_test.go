@@ -3,12 +3,17 @@ package rhel
 import (
 	"context"
 	"encoding/xml"
+	"io"
 	"os"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/google/go-cmp/cmp"
 	"github.com/quay/goval-parser/oval"
 	"github.com/quay/zlog"
+
+	"github.com/quay/claircore"
 )
 
 func TestParse(t *testing.T) {
@@ -47,6 +52,122 @@ func TestParse(t *testing.T) {
 	}
 }
 
+func TestParseFixState(t *testing.T) {
+	t.Parallel()
+	ctx := zlog.Test(context.Background(), t)
+
+	u, err := NewUpdater(`rhel-8-including-unpatched`, 8, "file:///dev/null")
+	if err != nil {
+		t.Fatal(err)
+	}
+	u.unpatched = true
+	vs, err := u.Parse(ctx, io.NopCloser(strings.NewReader(unpatchedDoc)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make(map[string]string)
+	for _, v := range vs {
+		if v.FixedInVersion != "" {
+			t.Errorf("unexpected fixed-in version for %q: %q", v.Package.Name, v.FixedInVersion)
+		}
+		got[v.Package.Name] = v.FixState
+	}
+	want := map[string]string{
+		"deferred": FixStateFixDeferred,
+		"wontfix":  FixStateWillNotFix,
+	}
+	if !cmp.Equal(got, want) {
+		t.Error(cmp.Diff(got, want))
+	}
+}
+
+// TestParseUnpatchedDefault checks that unpatched CVE definitions aren't
+// stored unless configured, so they don't change what's vulnerable.
+func TestParseUnpatchedDefault(t *testing.T) {
+	t.Parallel()
+	ctx := zlog.Test(context.Background(), t)
+
+	u, err := NewUpdater(`rhel-8-including-unpatched`, 8, "file:///dev/null")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := u.Configure(ctx, func(v interface{}) error { return nil }, nil); err != nil {
+		t.Fatal(err)
+	}
+	vs, err := u.Parse(ctx, io.NopCloser(strings.NewReader(unpatchedDoc)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := &Matcher{}
+	for _, name := range []string{"deferred", "wontfix"} {
+		r := &claircore.IndexRecord{
+			Package: &claircore.Package{Name: name, Version: "1.0-1.el8", Arch: "x86_64"},
+		}
+		for _, v := range vs {
+			ok, err := m.Vulnerable(ctx, r, v)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ok {
+				t.Errorf("%s: vulnerable to %q", name, v.Name)
+			}
+		}
+	}
+	if len(vs) != 0 {
+		t.Errorf("got: %d vulnerabilities, want: 0", len(vs))
+	}
+}
+
+// UnpatchedDoc is a trimmed-down OVAL document with an unpatched CVE
+// definition, in the form used in the "including-unpatched" streams.
+const unpatchedDoc = `<?xml version="1.0" encoding="UTF-8"?>
+<oval_definitions xmlns="http://oval.mitre.org/XMLSchema/oval-definitions-5" xmlns:oval="http://oval.mitre.org/XMLSchema/oval-common-5">
+  <definitions>
+    <definition id="oval:com.redhat.cve:def:20231234" version="1" class="vulnerability">
+      <metadata>
+        <title>CVE-2023-1234 example: flaw (moderate)</title>
+        <description>An example flaw.</description>
+        <advisory from="secalert@redhat.com">
+          <severity>Moderate</severity>
+          <affected>
+            <resolution state="Fix deferred">
+              <component>deferred</component>
+            </resolution>
+            <resolution state="Will not fix">
+              <component>wontfix</component>
+            </resolution>
+          </affected>
+          <affected_cpe_list>
+            <cpe>cpe:/o:redhat:enterprise_linux:8</cpe>
+          </affected_cpe_list>
+        </advisory>
+      </metadata>
+      <criteria operator="OR">
+        <criterion test_ref="oval:com.redhat.cve:tst:20231234001" comment="deferred is installed"/>
+        <criterion test_ref="oval:com.redhat.cve:tst:20231234002" comment="wontfix is installed"/>
+      </criteria>
+    </definition>
+  </definitions>
+  <tests>
+    <rpminfo_test id="oval:com.redhat.cve:tst:20231234001" version="1" comment="deferred is installed" check="at least one" xmlns="http://oval.mitre.org/XMLSchema/oval-definitions-5#linux">
+      <object object_ref="oval:com.redhat.cve:obj:20231234001"/>
+    </rpminfo_test>
+    <rpminfo_test id="oval:com.redhat.cve:tst:20231234002" version="1" comment="wontfix is installed" check="at least one" xmlns="http://oval.mitre.org/XMLSchema/oval-definitions-5#linux">
+      <object object_ref="oval:com.redhat.cve:obj:20231234002"/>
+    </rpminfo_test>
+  </tests>
+  <objects>
+    <rpminfo_object id="oval:com.redhat.cve:obj:20231234001" version="1" xmlns="http://oval.mitre.org/XMLSchema/oval-definitions-5#linux">
+      <name>deferred</name>
+    </rpminfo_object>
+    <rpminfo_object id="oval:com.redhat.cve:obj:20231234002" version="1" xmlns="http://oval.mitre.org/XMLSchema/oval-definitions-5#linux">
+      <name>wontfix</name>
+    </rpminfo_object>
+  </objects>
+  <states/>
+</oval_definitions>
+`
+
 // Here's a giant restructured struct for reference and tests.
 var ovalDef = oval.Definition{
 	XMLName: xml.Name{Space: "http://oval.mitre.org/XMLSchema/oval-definitions-5", Local: "definition"},
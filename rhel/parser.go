@@ -43,7 +43,7 @@ func (u *Updater) Parse(ctx context.Context, r io.ReadCloser) ([]*claircore.Vuln
 		// Red Hat OVAL data include information about vulnerabilities,
 		// that actually don't affect the package in any way. Storing them
 		// would increase number of records in DB without adding any value.
-		if isSkippableDefinitionType(defType) {
+		if isSkippableDefinitionType(defType, u.unpatched) {
 			return vs, nil
 		}
 
@@ -82,13 +82,77 @@ func (u *Updater) Parse(ctx context.Context, r io.ReadCloser) ([]*claircore.Vuln
 	if err != nil {
 		return nil, err
 	}
+	setFixStates(&root, vulns)
 	return vulns, nil
 }
 
-func isSkippableDefinitionType(defType ovalutil.DefinitionType) bool {
-	// TODO: Delete CVEDefinition of the condition when all work related
-	// to new OVAL data is done.
+// IsSkippableDefinitionType reports whether definitions of the type aren't
+// stored. CVE definitions are only stored if "unpatched" is set, as they
+// make every version of their packages vulnerable.
+func isSkippableDefinitionType(defType ovalutil.DefinitionType, unpatched bool) bool {
 	return defType == ovalutil.UnaffectedDefinition ||
 		defType == ovalutil.NoneDefinition ||
-		defType == ovalutil.CVEDefinition
+		(defType == ovalutil.CVEDefinition && !unpatched)
+}
+
+// Fix states Red Hat reports for vulnerabilities without a fix, recorded in
+// [claircore.Vulnerability.FixState].
+const (
+	// FixStateAffected is for vulnerabilities that will be fixed, but
+	// haven't been yet.
+	FixStateAffected = "Affected"
+	// FixStateFixDeferred is for vulnerabilities that may be fixed, but
+	// aren't scheduled to be.
+	FixStateFixDeferred = "Fix deferred"
+	// FixStateWillNotFix is for vulnerabilities that won't be fixed.
+	FixStateWillNotFix = "Will not fix"
+	// FixStateOutOfSupportScope is for vulnerabilities in packages outside
+	// the product's current support phase.
+	FixStateOutOfSupportScope = "Out of support scope"
+	// FixStateUnderInvestigation is for vulnerabilities Red Hat is still
+	// assessing.
+	FixStateUnderInvestigation = "Under investigation"
+)
+
+// SetFixStates fills in the FixState of the unfixed vulnerabilities from the
+// resolutions in their definitions' advisories.
+//
+// Vulnerabilities are tied back to their definitions by name, which is the
+// definition's title. A resolution without components applies to every
+// package in the definition.
+func setFixStates(root *oval.Root, vulns []*claircore.Vulnerability) {
+	states := make(map[string]map[string]string)
+	for _, def := range root.Definitions.Definitions {
+		rs := def.Advisory.Affected.Resolutions
+		if len(rs) == 0 {
+			continue
+		}
+		m := make(map[string]string)
+		for _, r := range rs {
+			if len(r.Components) == 0 {
+				m[""] = r.State
+			}
+			for _, c := range r.Components {
+				m[c] = r.State
+			}
+		}
+		states[def.Title] = m
+	}
+	if len(states) == 0 {
+		return
+	}
+	for _, v := range vulns {
+		if v.FixedInVersion != "" {
+			continue
+		}
+		m, ok := states[v.Name]
+		if !ok {
+			continue
+		}
+		if st, ok := m[v.Package.Name]; ok {
+			v.FixState = st
+		} else {
+			v.FixState = m[""]
+		}
+	}
 }
@@ -20,7 +20,6 @@ import (
 	"github.com/quay/claircore"
 	"github.com/quay/claircore/indexer"
 	"github.com/quay/claircore/pkg/cpe"
-	"github.com/quay/claircore/pkg/tarfs"
 	"github.com/quay/claircore/rhel/dockerfile"
 	"github.com/quay/claircore/rhel/internal/common"
 	"github.com/quay/claircore/rhel/internal/containerapi"
@@ -89,6 +88,10 @@ type RepositoryScannerConfig struct {
 	//
 	// The default is 10 seconds.
 	Timeout time.Duration `json:"timeout" yaml:"timeout"`
+	// Mapping, if set, is consulted instead of the mapping file, and the URL
+	// and File are ignored. It can only be set by a ConfigDeserializer that
+	// fills in the configuration directly. See [StoredMapping].
+	Mapping MappingSource `json:"-" yaml:"-"`
 }
 
 const (
@@ -132,6 +135,8 @@ func (r *RepositoryScanner) Configure(ctx context.Context, f indexer.ConfigDeser
 
 	var mf *mappingFile
 	switch {
+	case r.cfg.Mapping != nil:
+		// provided
 	case r.cfg.Repo2CPEMappingURL == "" && r.cfg.Repo2CPEMappingFile == "":
 		// defaults
 		r.cfg.Repo2CPEMappingURL = DefaultRepo2CPEMappingURL
@@ -149,10 +154,12 @@ func (r *RepositoryScanner) Configure(ctx context.Context, f indexer.ConfigDeser
 			return err
 		}
 	}
-	r.upd = common.NewUpdater(r.cfg.Repo2CPEMappingURL, mf)
-	tctx, done := context.WithTimeout(ctx, r.cfg.Timeout)
-	defer done()
-	r.upd.Get(tctx, c)
+	if r.cfg.Mapping == nil {
+		r.upd = common.NewUpdater(r.cfg.Repo2CPEMappingURL, mf)
+		tctx, done := context.WithTimeout(ctx, r.cfg.Timeout)
+		defer done()
+		r.upd.Get(tctx, c)
+	}
 
 	// Additional setup
 	root, err := url.Parse(r.cfg.API)
@@ -178,27 +185,13 @@ func (r *RepositoryScanner) Scan(ctx context.Context, l *claircore.Layer) (repos
 	zlog.Debug(ctx).Msg("start")
 	defer zlog.Debug(ctx).Msg("done")
 
-	rd, err := l.Reader()
-	if err != nil {
-		return nil, fmt.Errorf("rhel: unable to open layer: %w", err)
-	}
-	defer rd.Close()
-	sys, err := tarfs.New(rd)
+	sys, err := l.FS()
 	if err != nil {
 		return nil, fmt.Errorf("rhel: unable to open layer: %w", err)
 	}
+	defer sys.Close()
 
-	tctx, done := context.WithTimeout(ctx, r.cfg.Timeout)
-	defer done()
-	cmi, err := r.upd.Get(tctx, r.client)
-	if err != nil && cmi == nil {
-		return []*claircore.Repository{}, err
-	}
-	cm, ok := cmi.(*mappingFile)
-	if !ok || cm == nil {
-		return []*claircore.Repository{}, fmt.Errorf("rhel: unable to create a mappingFile object")
-	}
-	CPEs, err := mapContentSets(ctx, sys, cm)
+	CPEs, err := mapContentSets(ctx, sys, r.mapping)
 	if err != nil {
 		return []*claircore.Repository{}, err
 	}
@@ -234,9 +227,31 @@ func (r *RepositoryScanner) Scan(ctx context.Context, l *claircore.Layer) (repos
 	return repositories, nil
 }
 
+// Mapping returns the MappingSource to use, updating the mapping file if
+// needed.
+func (r *RepositoryScanner) mapping(ctx context.Context) (MappingSource, error) {
+	if r.cfg.Mapping != nil {
+		return r.cfg.Mapping, nil
+	}
+	if r.upd == nil {
+		return nil, fmt.Errorf("rhel: unable to create a mappingFile object")
+	}
+	tctx, done := context.WithTimeout(ctx, r.cfg.Timeout)
+	defer done()
+	cmi, err := r.upd.Get(tctx, r.client)
+	if err != nil && cmi == nil {
+		return nil, err
+	}
+	cm, ok := cmi.(*mappingFile)
+	if !ok || cm == nil {
+		return nil, fmt.Errorf("rhel: unable to create a mappingFile object")
+	}
+	return cm, nil
+}
+
 // MapContentSets returns a slice of CPEs bound into strings, as discovered by
 // examining information contained within the container.
-func mapContentSets(ctx context.Context, sys fs.FS, cm *mappingFile) ([]string, error) {
+func mapContentSets(ctx context.Context, sys fs.FS, mapping func(context.Context) (MappingSource, error)) ([]string, error) {
 	// Get CPEs using embedded content-set files.
 	// The files is be stored in /root/buildinfo/content_manifests/ and will need to
 	// be translated using mapping file provided by Red Hat's PST team.
@@ -264,6 +279,12 @@ func mapContentSets(ctx context.Context, sys fs.FS, cm *mappingFile) ([]string,
 	if len(m.ContentSets) == 0 {
 		return nil, nil
 	}
+	// Only load the mapping once it's needed, so layers without a content
+	// manifest, like minimal or non-Red Hat images, don't depend on it.
+	cm, err := mapping(ctx)
+	if err != nil {
+		return nil, err
+	}
 	return cm.Get(ctx, m.ContentSets)
 }
 
@@ -18,7 +18,10 @@ var Matcher driver.Matcher = &matcher{}
 
 type matcher struct{}
 
-var _ driver.Matcher = (*matcher)(nil)
+var (
+	_ driver.Matcher = (*matcher)(nil)
+	_ driver.Scoped  = (*matcher)(nil)
+)
 
 // Name implements [driver.Matcher].
 func (*matcher) Name() string { return "rhel-container-matcher" }
@@ -29,6 +32,13 @@ func (*matcher) Filter(r *claircore.IndexRecord) bool {
 		r.Repository.Name == goldRepo.Name
 }
 
+// Scope implements [driver.Scoped].
+func (*matcher) Scope() driver.Scope {
+	return driver.Scope{
+		Repositories: []string{goldRepo.Name},
+	}
+}
+
 // Query implements [driver.Matcher].
 func (*matcher) Query() []driver.MatchConstraint {
 	return []driver.MatchConstraint{driver.RepositoryName}
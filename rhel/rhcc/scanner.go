@@ -16,7 +16,6 @@ import (
 	"github.com/quay/claircore"
 	"github.com/quay/claircore/indexer"
 	"github.com/quay/claircore/pkg/rhctag"
-	"github.com/quay/claircore/pkg/tarfs"
 	"github.com/quay/claircore/rhel/dockerfile"
 	"github.com/quay/claircore/rhel/internal/common"
 )
@@ -211,15 +210,11 @@ type mappingFile struct {
 }
 
 func findLabels(ctx context.Context, layer *claircore.Layer) (map[string]string, string, error) {
-	r, err := layer.Reader()
-	if err != nil {
-		return nil, "", err
-	}
-	defer r.Close()
-	sys, err := tarfs.New(r)
+	sys, err := layer.FS()
 	if err != nil {
 		return nil, "", err
 	}
+	defer sys.Close()
 	ms, err := fs.Glob(sys, "root/buildinfo/Dockerfile-*")
 	if err != nil { // Can only return ErrBadPattern.
 		panic("progammer error: " + err.Error())
@@ -286,15 +281,11 @@ func (s *reposcanner) Kind() string { return "repository" }
 // Scan implements [indexer.RepositoryScanner].
 func (s *reposcanner) Scan(ctx context.Context, l *claircore.Layer) ([]*claircore.Repository, error) {
 	ctx = zlog.ContextWithValues(ctx, "component", "rhel/rhcc/reposcanner.Scan")
-	r, err := l.Reader()
-	if err != nil {
-		return nil, err
-	}
-	defer r.Close()
-	sys, err := tarfs.New(r)
+	sys, err := l.FS()
 	if err != nil {
 		return nil, err
 	}
+	defer sys.Close()
 	ms, err := fs.Glob(sys, "root/buildinfo/Dockerfile-*")
 	if err != nil { // Can only return ErrBadPattern.
 		panic("progammer error")
@@ -22,6 +22,7 @@ import (
 var (
 	_ driver.Updater      = (*Updater)(nil)
 	_ driver.Configurable = (*Updater)(nil)
+	_ driver.Tombstoner   = (*Updater)(nil)
 )
 
 // Updater fetches and parses RHEL-flavored OVAL databases.
@@ -29,6 +30,8 @@ type Updater struct {
 	ovalutil.Fetcher // fetch method promoted via embed
 	dist             *claircore.Distribution
 	name             string
+	// Store the unpatched CVE definitions, see UpdaterConfig.
+	unpatched bool
 }
 
 // UpdaterConfig is the configuration expected for any given updater.
@@ -37,6 +40,11 @@ type Updater struct {
 type UpdaterConfig struct {
 	ovalutil.FetcherConfig
 	Release int64 `json:"release" yaml:"release"`
+	// IncludeUnpatched stores the CVE definitions of the
+	// "including-unpatched" streams, with their FixState recorded. These
+	// have no fixed version, so every installed version of their packages
+	// is reported as vulnerable. By default, they're skipped.
+	IncludeUnpatched bool `json:"include_unpatched" yaml:"include_unpatched"`
 }
 
 // NewUpdater returns an Updater.
@@ -63,8 +71,15 @@ func (u *Updater) Configure(ctx context.Context, cf driver.ConfigUnmarshaler, c
 	if cfg.Release != 0 {
 		u.dist = mkRelease(cfg.Release)
 	}
+	u.unpatched = cfg.IncludeUnpatched
 	return u.Fetcher.Configure(ctx, cf, c)
 }
 
 // Name implements [driver.Updater].
 func (u *Updater) Name() string { return u.name }
+
+// Tombstone implements [driver.Tombstoner].
+//
+// Each OVAL database is a complete feed, so a definition that disappears from
+// it was withdrawn.
+func (u *Updater) Tombstone() bool { return true }
@@ -38,6 +38,7 @@ type Factory struct {
 	url          *url.URL
 	client       *http.Client
 	manifestEtag string
+	releases     map[int]struct{}
 }
 
 // FactoryConfig is the configuration accepted by the rhel updaters.
@@ -45,6 +46,9 @@ type Factory struct {
 // By convention, this should be in a map called "rhel".
 type FactoryConfig struct {
 	URL string `json:"url" yaml:"url"`
+	// Releases is a list of major RHEL releases (e.g. "8") to fetch OVAL
+	// files for. If unset, every release in the manifest is used.
+	Releases []string `json:"releases" yaml:"releases"`
 }
 
 var _ driver.Configurable = (*Factory)(nil)
@@ -70,6 +74,24 @@ func (f *Factory) Configure(ctx context.Context, cfg driver.ConfigUnmarshaler, c
 		f.url = u
 	}
 
+	f.releases = nil
+	if len(fc.Releases) != 0 {
+		f.releases = make(map[int]struct{}, len(fc.Releases))
+		for _, r := range fc.Releases {
+			n, err := strconv.Atoi(strings.TrimSpace(r))
+			if err != nil {
+				return fmt.Errorf("rhel: bad release %q: %w", r, err)
+			}
+			f.releases[n] = struct{}{}
+		}
+		zlog.Info(ctx).
+			Strs("releases", fc.Releases).
+			Msg("configured releases")
+	}
+	// Drop the cached manifest ETag, so a changed release list takes effect
+	// even if the manifest hasn't changed.
+	f.manifestEtag = ""
+
 	if c != nil {
 		zlog.Info(ctx).
 			Msg("configured HTTP client")
@@ -81,8 +103,11 @@ func (f *Factory) Configure(ctx context.Context, cfg driver.ConfigUnmarshaler, c
 
 // UpdaterSet implements [driver.UpdaterSetFactory].
 //
-// The returned Updaters determine the [claircore.Distribution] it's associated
-// with based on the path in the Pulp manifest.
+// The returned set has one Updater per OVAL file in the Pulp manifest, so
+// they can be run concurrently. The returned Updaters determine the
+// [claircore.Distribution] it's associated with based on the path in the
+// Pulp manifest. If the Factory was configured with a list of releases, OVAL
+// files for other releases are skipped.
 func (f *Factory) UpdaterSet(ctx context.Context) (driver.UpdaterSet, error) {
 	s := driver.NewUpdaterSet()
 
@@ -148,10 +173,17 @@ func (f *Factory) UpdaterSet(ctx context.Context) (driver.UpdaterSet, error) {
 				Msg("unable to parse pattern into int")
 			continue
 		}
+		if _, ok := f.releases[r]; f.releases != nil && !ok {
+			continue
+		}
 		up, err := NewUpdater(name, r, uri.String())
 		if err != nil {
 			return s, err
 		}
+		// Share the client, so the per-file updaters share connections
+		// when they're run concurrently. They keep their own
+		// fingerprints.
+		up.Client = f.client
 		_ = s.Add(up)
 	}
 	f.manifestEtag = res.Header.Get("etag")
@@ -0,0 +1,196 @@
+package rhel
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/quay/zlog"
+
+	"github.com/quay/claircore/libvuln/driver"
+	"github.com/quay/claircore/libvuln/jsonblob"
+	"github.com/quay/claircore/libvuln/updates"
+)
+
+func TestFactoryConcurrent(t *testing.T) {
+	t.Parallel()
+	ctx := zlog.Test(context.Background(), t)
+	body, err := os.ReadFile("testdata/com.redhat.rhsa-20201980.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := []string{
+		"RHEL7/rhel-7.oval.xml.bz2",
+		"RHEL8/rhel-8.oval.xml.bz2",
+		"RHEL8/ansible-2.oval.xml.bz2",
+		"RHEL9/rhel-9.oval.xml.bz2",
+	}
+	// Every file request blocks until all of them are in flight, so this
+	// deadlocks (and times out) if the updaters can't be run concurrently.
+	var arrived sync.WaitGroup
+	arrived.Add(len(files))
+	var mu sync.Mutex
+	seen := make(map[string]int)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p := strings.TrimPrefix(r.URL.Path, "/")
+		if p == "PULP_MANIFEST" {
+			for _, f := range files {
+				fmt.Fprintf(w, "%s,%x,%d\n", f, []byte(f), len(body))
+			}
+			return
+		}
+		mu.Lock()
+		seen[p]++
+		mu.Unlock()
+		arrived.Done()
+		arrived.Wait()
+		w.Header().Set("etag", `"`+p+`"`)
+		w.Write(body)
+	}))
+	defer srv.Close()
+
+	f, err := NewFactory(ctx, srv.URL+"/PULP_MANIFEST")
+	if err != nil {
+		t.Fatal(err)
+	}
+	store, err := jsonblob.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	mgr, err := updates.NewManager(ctx, store, updates.NewLocalLockSource(), srv.Client(),
+		updates.WithFactories(map[string]driver.UpdaterSetFactory{"rhel": f}),
+		updates.WithBatchSize(len(files)),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := mgr.Run(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// The Manager configured the factory with its client.
+	set, err := f.UpdaterSet(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, u := range set.Updaters() {
+		if u.(*Updater).Client != srv.Client() {
+			t.Errorf("%s: not using the shared client", u.Name())
+		}
+	}
+	for _, f := range files {
+		if seen[f] != 1 {
+			t.Errorf("%s: fetched %d times", f, seen[f])
+		}
+	}
+	fps := make(map[string]driver.Fingerprint)
+	for _, e := range store.Entries() {
+		fps[e.Updater] = e.Fingerprint
+	}
+	if got, want := len(fps), len(files); got != want {
+		t.Fatalf("got: %d updaters stored, want: %d", got, want)
+	}
+	uniq := make(map[driver.Fingerprint]struct{})
+	for _, fp := range fps {
+		uniq[fp] = struct{}{}
+	}
+	if got, want := len(uniq), len(files); got != want {
+		t.Errorf("got: %d distinct fingerprints, want: %d", got, want)
+	}
+}
+
+func TestFactoryReleases(t *testing.T) {
+	t.Parallel()
+	ctx := zlog.Test(context.Background(), t)
+	files := []string{
+		"RHEL6/rhel-6.oval.xml.bz2",
+		"RHEL7/rhel-7.oval.xml.bz2",
+		"RHEL8/rhel-8.oval.xml.bz2",
+		"RHEL8/ansible-2.oval.xml.bz2",
+		"RHEL9/rhel-9.oval.xml.bz2",
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/PULP_MANIFEST" {
+			t.Errorf("unexpected request: %s", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		for _, f := range files {
+			fmt.Fprintf(w, "%s,%x,%d\n", f, []byte(f), 0)
+		}
+	}))
+	defer srv.Close()
+
+	tt := []struct {
+		Name     string
+		Releases []string
+		Want     []string
+	}{
+		{
+			Name: "Unset",
+			Want: []string{"RHEL6-rhel-6", "RHEL7-rhel-7", "RHEL8-ansible-2", "RHEL8-rhel-8", "RHEL9-rhel-9"},
+		},
+		{
+			Name:     "Allowlist",
+			Releases: []string{"8", "9"},
+			Want:     []string{"RHEL8-ansible-2", "RHEL8-rhel-8", "RHEL9-rhel-9"},
+		},
+		{
+			Name:     "Unknown",
+			Releases: []string{"10"},
+			Want:     []string{},
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			ctx := zlog.Test(ctx, t)
+			f, err := NewFactory(ctx, srv.URL+"/PULP_MANIFEST")
+			if err != nil {
+				t.Fatal(err)
+			}
+			cfg := func(v interface{}) error {
+				v.(*FactoryConfig).Releases = tc.Releases
+				return nil
+			}
+			if err := f.Configure(ctx, cfg, srv.Client()); err != nil {
+				t.Fatal(err)
+			}
+			set, err := f.UpdaterSet(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := []string{}
+			for _, u := range set.Updaters() {
+				got = append(got, u.Name())
+			}
+			sort.Strings(got)
+			if !cmp.Equal(got, tc.Want) {
+				t.Error(cmp.Diff(got, tc.Want))
+			}
+		})
+	}
+
+	t.Run("Bad", func(t *testing.T) {
+		ctx := zlog.Test(ctx, t)
+		f, err := NewFactory(ctx, srv.URL+"/PULP_MANIFEST")
+		if err != nil {
+			t.Fatal(err)
+		}
+		cfg := func(v interface{}) error {
+			v.(*FactoryConfig).Releases = []string{"rhel9"}
+			return nil
+		}
+		err = f.Configure(ctx, cfg, srv.Client())
+		t.Log(err)
+		if err == nil {
+			t.Error("expected error")
+		}
+	})
+}
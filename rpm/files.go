@@ -0,0 +1,100 @@
+package rpm
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"runtime/trace"
+	"strings"
+
+	"github.com/quay/zlog"
+
+	"github.com/quay/claircore/rpm/internal/rpm"
+)
+
+// OwnedFiles returns the files owned by the packages in the rpm databases
+// found in "sys", mapped to the name of the owning package. Directories
+// shared between packages are attributed to one of them.
+//
+// The returned paths are relative to the root of "sys", like the paths used
+// with the io/fs package. A nil map is returned if there are no databases.
+func OwnedFiles(ctx context.Context, sys fs.FS) (map[string]string, error) {
+	defer trace.StartRegion(ctx, "OwnedFiles").End()
+	ctx = zlog.ContextWithValues(ctx, "component", "rpm/OwnedFiles")
+	var found []foundDB
+	if err := fs.WalkDir(sys, ".", findDBs(ctx, &found, sys)); err != nil {
+		return nil, fmt.Errorf("rpm: error walking fs: %w", err)
+	}
+	if len(found) == 0 {
+		return nil, nil
+	}
+	out := make(map[string]string)
+	for _, db := range found {
+		ctx := zlog.ContextWithValues(ctx, "db", db.String())
+		err := withDB(ctx, sys, db, func(nat nativeDB) error {
+			return filesFromDB(ctx, nat, out)
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	zlog.Debug(ctx).
+		Int("count", len(out)).
+		Msg("found owned files")
+	return out, nil
+}
+
+// FilesFromDB adds the files listed in every header in the database to "out".
+func filesFromDB(ctx context.Context, db nativeDB, out map[string]string) error {
+	rds, err := db.AllHeaders(ctx)
+	if err != nil {
+		return fmt.Errorf("rpm: error reading headers: %w", err)
+	}
+	for _, rd := range rds {
+		var h rpm.Header
+		if err := h.Parse(ctx, rd); err != nil {
+			return err
+		}
+		var (
+			name             string
+			dirs, bases, old []string
+			idx              []int32
+		)
+		for i := range h.Infos {
+			e := &h.Infos[i]
+			switch e.Tag {
+			case rpm.TagName, rpm.TagDirnames, rpm.TagBasenames, rpm.TagDirindexes, rpm.TagOldFilenames:
+			default:
+				continue
+			}
+			v, err := h.ReadData(ctx, e)
+			if err != nil {
+				return err
+			}
+			switch e.Tag {
+			case rpm.TagName:
+				name = v.(string)
+			case rpm.TagDirnames:
+				dirs = v.([]string)
+			case rpm.TagBasenames:
+				bases = v.([]string)
+			case rpm.TagDirindexes:
+				idx = v.([]int32)
+			case rpm.TagOldFilenames:
+				old = v.([]string)
+			}
+		}
+		// Files are normally stored as an index into the directory names
+		// and a base name, but very old packages list the full paths.
+		for i, b := range bases {
+			if i >= len(idx) || int(idx[i]) >= len(dirs) || idx[i] < 0 {
+				return fmt.Errorf("rpm: malformed file list")
+			}
+			out[strings.TrimPrefix(dirs[idx[i]]+b, "/")] = name
+		}
+		for _, p := range old {
+			out[strings.TrimPrefix(p, "/")] = name
+		}
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package rpm
+
+import (
+	"context"
+	"os"
+	"testing"
+	"testing/fstest"
+
+	"github.com/quay/zlog"
+)
+
+func TestOwnedFiles(t *testing.T) {
+	ctx := zlog.Test(context.Background(), t)
+	b, err := os.ReadFile("bdb/testdata/ubi8.Packages")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sys := fstest.MapFS{
+		"var/lib/rpm/Packages": &fstest.MapFile{Data: b, Mode: 0o644},
+	}
+	got, err := OwnedFiles(ctx, sys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("found %d files", len(got))
+	for p, want := range map[string]string{
+		"usr/bin/bash":       "bash",
+		"usr/lib/os-release": "redhat-release",
+	} {
+		if got, ok := got[p]; !ok || got != want {
+			t.Errorf("%q: got: %q, want: %q", p, got, want)
+		}
+	}
+	if _, ok := got["usr/local/bin/not-a-package"]; ok {
+		t.Error("unexpected file")
+	}
+
+	got, err = OwnedFiles(ctx, fstest.MapFS{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != nil {
+		t.Errorf("got %d files with no database", len(got))
+	}
+}
@@ -22,7 +22,9 @@ type nativeDB interface {
 
 // PackagesFromDB extracts the packages from the RPM headers provided by
 // the database.
-func packagesFromDB(ctx context.Context, pkgdb string, db nativeDB) ([]*claircore.Package, error) {
+//
+// If "deps" is set, the packages' requirements are recorded as well.
+func packagesFromDB(ctx context.Context, pkgdb string, db nativeDB, deps bool) ([]*claircore.Package, error) {
 	defer trace.StartRegion(ctx, "packagesFromDB").End()
 	rds, err := db.AllHeaders(ctx)
 	if err != nil {
@@ -64,6 +66,10 @@ func packagesFromDB(ctx context.Context, pkgdb string, db nativeDB) ([]*claircor
 		}
 		p.Version = constructEVR(&b, &info)
 		p.RepositoryHint = constructHint(&b, &info)
+		p.Provides = info.Provides
+		if deps {
+			p.Depends = info.Requires
+		}
 
 		if s, ok := src[info.SourceNEVR]; ok {
 			p.Source = s
@@ -112,6 +118,16 @@ type Info struct {
 	Signature  []byte // This is a PGP signature packet.
 	DigestAlgo int
 	Epoch      int
+	// Provides is the list of virtual provides; see virtualProvides.
+	Provides []string
+	// Requires is the list of required packages or capabilities; see
+	// requirements.
+	Requires []string
+
+	requireName    []string
+	provideName    []string
+	provideFlags   []int32
+	provideVersion []string
 }
 
 func (i *Info) Load(ctx context.Context, h *rpm.Header) error {
@@ -145,11 +161,68 @@ func (i *Info) Load(ctx context.Context, h *rpm.Header) error {
 			i.Digest = v.([]string)[0]
 		case rpm.TagSigPGP:
 			i.Signature = v.([]byte)
+		case rpm.TagProvideName:
+			i.provideName = v.([]string)
+		case rpm.TagProvideFlags:
+			i.provideFlags = v.([]int32)
+		case rpm.TagProvideVersion:
+			i.provideVersion = v.([]string)
+		case rpm.TagRequireName:
+			i.requireName = v.([]string)
 		}
 	}
+	i.Provides = virtualProvides(i.Name, i.provideName, i.provideFlags, i.provideVersion)
+	i.Requires = requirements(i.Name, i.requireName)
 	return nil
 }
 
+// SenseEqual is the RPMSENSE_EQUAL bit of a dependency's flags.
+const senseEqual = 1 << 3
+
+// VirtualProvides returns the "virtual package" capabilities from the
+// provided Provides tag data, formatted as "name" or "name = version".
+//
+// Every package provides its own name and a number of automatically generated
+// capabilities (e.g. "name(x86-64)", "config(name)", sonames, and paths), so
+// anything that's the package name, contains parentheses, or is a path is
+// omitted. A nil slice is returned if nothing remains.
+func virtualProvides(pkg string, names []string, flags []int32, versions []string) []string {
+	var out []string
+	for i, n := range names {
+		if n == pkg || n == "" || strings.ContainsAny(n, "()") || strings.HasPrefix(n, "/") {
+			continue
+		}
+		if i < len(flags) && i < len(versions) &&
+			flags[i]&senseEqual != 0 && versions[i] != "" {
+			n = n + " = " + versions[i]
+		}
+		out = append(out, n)
+	}
+	return out
+}
+
+// Requirements returns the package and virtual package names from the
+// provided Requires tag data, without duplicates.
+//
+// Like virtualProvides, automatically generated requirements (e.g.
+// "rpmlib(...)", sonames, and paths) are omitted, as is the package's own
+// name. A nil slice is returned if nothing remains.
+func requirements(pkg string, names []string) []string {
+	var out []string
+	seen := make(map[string]struct{})
+	for _, n := range names {
+		if n == pkg || n == "" || strings.ContainsAny(n, "()") || strings.HasPrefix(n, "/") {
+			continue
+		}
+		if _, ok := seen[n]; ok {
+			continue
+		}
+		seen[n] = struct{}{}
+		out = append(out, n)
+	}
+	return out
+}
+
 var wantTags = map[rpm.Tag]struct{}{
 	rpm.TagArch:              {},
 	rpm.TagEpoch:             {},
@@ -157,7 +230,11 @@ var wantTags = map[rpm.Tag]struct{}{
 	rpm.TagName:              {},
 	rpm.TagPayloadDigest:     {},
 	rpm.TagPayloadDigestAlgo: {},
+	rpm.TagProvideFlags:      {},
+	rpm.TagProvideName:       {},
+	rpm.TagProvideVersion:    {},
 	rpm.TagRelease:           {},
+	rpm.TagRequireName:       {},
 	rpm.TagSigPGP:            {},
 	rpm.TagSourceRPM:         {},
 	rpm.TagVersion:           {},
@@ -0,0 +1,68 @@
+package rpm
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestVirtualProvides(t *testing.T) {
+	t.Parallel()
+	tt := []struct {
+		Name     string
+		Pkg      string
+		Names    []string
+		Flags    []int32
+		Versions []string
+		Want     []string
+	}{
+		{
+			Name:     "Automatic",
+			Pkg:      "bash",
+			Names:    []string{"/bin/sh", "bash", "bash(x86-64)", "config(bash)"},
+			Flags:    []int32{0, 8, 8, 8},
+			Versions: []string{"", "4.4.19-10.el8", "4.4.19-10.el8", "4.4.19-10.el8"},
+			Want:     nil,
+		},
+		{
+			Name:     "Virtual",
+			Pkg:      "httpd",
+			Names:    []string{"httpd", "webserver", "httpd-mmn"},
+			Flags:    []int32{8, 0, 8},
+			Versions: []string{"2.4.37-21.el8", "", "20120211x8664"},
+			Want:     []string{"webserver", "httpd-mmn = 20120211x8664"},
+		},
+		{
+			Name:  "Short",
+			Pkg:   "dnf-data",
+			Names: []string{"dnf-conf"},
+			Want:  []string{"dnf-conf"},
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			got := virtualProvides(tc.Pkg, tc.Names, tc.Flags, tc.Versions)
+			if !cmp.Equal(got, tc.Want) {
+				t.Error(cmp.Diff(got, tc.Want))
+			}
+		})
+	}
+}
+
+func TestRequirements(t *testing.T) {
+	t.Parallel()
+	got := requirements("httpd", []string{
+		"/bin/sh",
+		"httpd-filesystem",
+		"httpd-tools",
+		"libc.so.6()(64bit)",
+		"rpmlib(CompressedFileNames)",
+		"httpd",
+		"system-logos-httpd",
+		"httpd-filesystem",
+	})
+	want := []string{"httpd-filesystem", "httpd-tools", "system-logos-httpd"}
+	if !cmp.Equal(got, want) {
+		t.Error(cmp.Diff(got, want))
+	}
+}
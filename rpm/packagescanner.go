@@ -13,7 +13,6 @@ import (
 
 	"github.com/quay/claircore"
 	"github.com/quay/claircore/indexer"
-	"github.com/quay/claircore/pkg/tarfs"
 	"github.com/quay/claircore/rpm/bdb"
 	"github.com/quay/claircore/rpm/ndb"
 	"github.com/quay/claircore/rpm/sqlite"
@@ -22,21 +21,32 @@ import (
 const (
 	pkgName    = "rpm"
 	pkgKind    = "package"
-	pkgVersion = "9"
+	pkgVersion = "10"
 )
 
 var (
-	_ indexer.VersionedScanner = (*Scanner)(nil)
-	_ indexer.PackageScanner   = (*Scanner)(nil)
+	_ indexer.VersionedScanner    = (*Scanner)(nil)
+	_ indexer.PackageScanner      = (*Scanner)(nil)
+	_ indexer.ConfigurableScanner = (*Scanner)(nil)
 )
 
+// ScannerConfig is the struct used to configure a Scanner.
+type ScannerConfig struct {
+	// Dependencies controls whether the "Requires" of each package are
+	// recorded. This is off by default because of the additional storage
+	// required.
+	Dependencies bool `yaml:"dependencies" json:"dependencies"`
+}
+
 // Scanner implements the scanner.PackageScanner interface.
 //
 // This looks for directories that look like rpm databases and examines the
 // files it finds there.
 //
 // The zero value is ready to use.
-type Scanner struct{}
+type Scanner struct {
+	deps bool
+}
 
 // Name implements scanner.VersionedScanner.
 func (*Scanner) Name() string { return pkgName }
@@ -47,6 +57,16 @@ func (*Scanner) Version() string { return pkgVersion }
 // Kind implements scanner.VersionedScanner.
 func (*Scanner) Kind() string { return pkgKind }
 
+// Configure implements indexer.ConfigurableScanner.
+func (ps *Scanner) Configure(ctx context.Context, f indexer.ConfigDeserializer) error {
+	var cfg ScannerConfig
+	if err := f(&cfg); err != nil {
+		return err
+	}
+	ps.deps = cfg.Dependencies
+	return nil
+}
+
 // Scan attempts to find rpm databases within the layer and enumerate the
 // packages there.
 //
@@ -64,17 +84,13 @@ func (ps *Scanner) Scan(ctx context.Context, layer *claircore.Layer) ([]*clairco
 	zlog.Debug(ctx).Msg("start")
 	defer zlog.Debug(ctx).Msg("done")
 
-	r, err := layer.Reader()
+	sys, err := layer.FS()
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("rpm: unable to create tarfs: %w", err)
 	}
-	defer r.Close()
+	defer sys.Close()
 
 	found := make([]foundDB, 0)
-	sys, err := tarfs.New(r)
-	if err != nil {
-		return nil, fmt.Errorf("rpm: unable to create tarfs: %w", err)
-	}
 	if err := fs.WalkDir(sys, ".", findDBs(ctx, &found, sys)); err != nil {
 		return nil, fmt.Errorf("rpm: error walking fs: %w", err)
 	}
@@ -95,94 +111,113 @@ func (ps *Scanner) Scan(ctx context.Context, layer *claircore.Layer) ([]*clairco
 		}
 		done[db.Path] = struct{}{}
 
-		var nat nativeDB // see native_db.go:/nativeDB
-		switch db.Kind {
-		case kindSQLite:
-			r, err := sys.Open(path.Join(db.Path, `rpmdb.sqlite`))
-			if err != nil {
-				return nil, fmt.Errorf("rpm: error reading sqlite db: %w", err)
-			}
-			defer func() {
-				if err := r.Close(); err != nil {
-					zlog.Warn(ctx).Err(err).Msg("unable to close tarfs sqlite db")
-				}
-			}()
-			f, err := os.CreateTemp(os.TempDir(), `rpmdb.sqlite.*`)
+		err := withDB(ctx, sys, db, func(nat nativeDB) error {
+			ps, err := packagesFromDB(ctx, db.String(), nat, ps.deps)
 			if err != nil {
-				return nil, fmt.Errorf("rpm: error reading sqlite db: %w", err)
+				return fmt.Errorf("rpm: error reading native db: %w", err)
 			}
-			defer func() {
-				if err := os.Remove(f.Name()); err != nil {
-					zlog.Error(ctx).Err(err).Msg("unable to unlink sqlite db")
-				}
-				if err := f.Close(); err != nil {
-					zlog.Warn(ctx).Err(err).Msg("unable to close sqlite db")
-				}
-			}()
-			zlog.Debug(ctx).Str("file", f.Name()).Msg("copying sqlite db out of tar")
-			if _, err := io.Copy(f, r); err != nil {
-				return nil, fmt.Errorf("rpm: error reading sqlite db: %w", err)
-			}
-			if err := f.Sync(); err != nil {
-				return nil, fmt.Errorf("rpm: error reading sqlite db: %w", err)
-			}
-			sdb, err := sqlite.Open(f.Name())
-			if err != nil {
-				return nil, fmt.Errorf("rpm: error reading sqlite db: %w", err)
-			}
-			defer sdb.Close()
-			nat = sdb
-		case kindBDB:
-			f, err := sys.Open(path.Join(db.Path, `Packages`))
-			if err != nil {
-				return nil, fmt.Errorf("rpm: error reading bdb db: %w", err)
-			}
-			defer f.Close()
-			r, done, err := mkAt(ctx, db.Kind, f)
-			if err != nil {
-				return nil, fmt.Errorf("rpm: error reading bdb db: %w", err)
-			}
-			defer done()
-			var bpdb bdb.PackageDB
-			if err := bpdb.Parse(r); err != nil {
-				return nil, fmt.Errorf("rpm: error parsing bdb db: %w", err)
-			}
-			nat = &bpdb
-		case kindNDB:
-			f, err := sys.Open(path.Join(db.Path, `Packages.db`))
-			if err != nil {
-				return nil, fmt.Errorf("rpm: error reading ndb db: %w", err)
+			pkgs = append(pkgs, ps...)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return pkgs, nil
+}
+
+// WithDB opens the database "db" in "sys" and calls "fn" with it. The database
+// is only valid for the duration of the call.
+func withDB(ctx context.Context, sys fs.FS, db foundDB, fn func(nativeDB) error) error {
+	switch db.Kind {
+	case kindSQLite:
+		r, err := sys.Open(path.Join(db.Path, `rpmdb.sqlite`))
+		if err != nil {
+			return fmt.Errorf("rpm: error reading sqlite db: %w", err)
+		}
+		defer func() {
+			if err := r.Close(); err != nil {
+				zlog.Warn(ctx).Err(err).Msg("unable to close tarfs sqlite db")
 			}
-			defer f.Close()
-			r, done, err := mkAt(ctx, db.Kind, f)
-			if err != nil {
-				return nil, fmt.Errorf("rpm: error reading ndb db: %w", err)
+		}()
+		f, err := os.CreateTemp(os.TempDir(), `rpmdb.sqlite.*`)
+		if err != nil {
+			return fmt.Errorf("rpm: error reading sqlite db: %w", err)
+		}
+		defer func() {
+			if err := os.Remove(f.Name()); err != nil {
+				zlog.Error(ctx).Err(err).Msg("unable to unlink sqlite db")
 			}
-			defer done()
-			var npdb ndb.PackageDB
-			if err := npdb.Parse(r); err != nil {
-				return nil, fmt.Errorf("rpm: error parsing ndb db: %w", err)
+			if err := f.Close(); err != nil {
+				zlog.Warn(ctx).Err(err).Msg("unable to close sqlite db")
 			}
-			nat = &npdb
-		default:
-			panic("programmer error: bad kind: " + db.Kind.String())
+		}()
+		zlog.Debug(ctx).Str("file", f.Name()).Msg("copying sqlite db out of tar")
+		if _, err := io.Copy(f, r); err != nil {
+			return fmt.Errorf("rpm: error reading sqlite db: %w", err)
+		}
+		if err := f.Sync(); err != nil {
+			return fmt.Errorf("rpm: error reading sqlite db: %w", err)
 		}
-		ps, err := packagesFromDB(ctx, db.String(), nat)
+		sdb, err := sqlite.Open(f.Name())
 		if err != nil {
-			return nil, fmt.Errorf("rpm: error reading native db: %w", err)
+			return fmt.Errorf("rpm: error reading sqlite db: %w", err)
 		}
-		pkgs = append(pkgs, ps...)
+		defer sdb.Close()
+		return fn(sdb)
+	case kindBDB:
+		f, err := sys.Open(path.Join(db.Path, `Packages`))
+		if err != nil {
+			return fmt.Errorf("rpm: error reading bdb db: %w", err)
+		}
+		defer f.Close()
+		r, done, err := mkAt(ctx, db.Kind, f)
+		if err != nil {
+			return fmt.Errorf("rpm: error reading bdb db: %w", err)
+		}
+		defer done()
+		var bpdb bdb.PackageDB
+		if err := bpdb.Parse(r); err != nil {
+			return fmt.Errorf("rpm: error parsing bdb db: %w", err)
+		}
+		return fn(&bpdb)
+	case kindNDB:
+		f, err := sys.Open(path.Join(db.Path, `Packages.db`))
+		if err != nil {
+			return fmt.Errorf("rpm: error reading ndb db: %w", err)
+		}
+		defer f.Close()
+		r, done, err := mkAt(ctx, db.Kind, f)
+		if err != nil {
+			return fmt.Errorf("rpm: error reading ndb db: %w", err)
+		}
+		defer done()
+		var npdb ndb.PackageDB
+		if err := npdb.Parse(r); err != nil {
+			return fmt.Errorf("rpm: error parsing ndb db: %w", err)
+		}
+		return fn(&npdb)
+	default:
+		panic("programmer error: bad kind: " + db.Kind.String())
 	}
-
-	return pkgs, nil
 }
 
+// FindDBs returns a WalkDirFunc adding every rpm database found to "out".
+//
+// Databases are found wherever they are in the layer, including both
+// "var/lib/rpm" and "usr/lib/sysimage/rpm", the location used by newer
+// distributions like Fedora. Symlinks are skipped: on those distributions,
+// "var/lib/rpm" is a symlink to "usr/lib/sysimage/rpm", and following it
+// would report the same database twice under different paths. The database
+// is found at the symlink's target instead, which is in the same layer or in
+// one that's scanned separately.
 func findDBs(ctx context.Context, out *[]foundDB, sys fs.FS) fs.WalkDirFunc {
 	return func(p string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return err
 		}
-		if d.IsDir() {
+		if d.IsDir() || d.Type()&fs.ModeSymlink != 0 {
 			return nil
 		}
 
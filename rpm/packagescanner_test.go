@@ -2,17 +2,20 @@ package rpm
 
 import (
 	"context"
+	"io/fs"
 	"net/http"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"testing"
+	"testing/fstest"
 	"time"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/quay/zlog"
 
 	"github.com/quay/claircore"
+	"github.com/quay/claircore/test"
 	"github.com/quay/claircore/test/fetch"
 	"github.com/quay/claircore/test/rpmtest"
 )
@@ -1626,8 +1629,10 @@ func TestScan(t *testing.T) {
 			t.Fatal(err)
 		}
 		t.Logf("found %d packages", len(got))
-		if !cmp.Equal(got, want) {
-			t.Fatal(cmp.Diff(got, want))
+		// The expected packages are transcribed from the RPM manifest, which
+		// doesn't have provides information.
+		if !cmp.Equal(got, want, rpmtest.IgnoreProvides) {
+			t.Fatal(cmp.Diff(got, want, rpmtest.IgnoreProvides))
 		}
 
 		ms, err := filepath.Glob(pat)
@@ -1695,3 +1700,95 @@ func TestLayer(t *testing.T) {
 		})
 	}
 }
+
+func TestSquashfs(t *testing.T) {
+	ctx := zlog.Test(context.Background(), t)
+	db, err := os.ReadFile(`ndb/testdata/Packages.db`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tl, sl := test.SquashfsLayers(t, fstest.MapFS{
+		"var/lib/rpm/Packages.db": &fstest.MapFile{Data: db, Mode: 0o644},
+	})
+
+	var s Scanner
+	want, err := s.Scan(ctx, tl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := s.Scan(ctx, sl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("found %d packages", len(got))
+	if len(got) == 0 {
+		t.Error("no packages found")
+	}
+	if !cmp.Equal(got, want) {
+		t.Error(cmp.Diff(got, want))
+	}
+}
+
+// TestSymlinkedDB checks the layout used by newer distributions, where
+// "var/lib/rpm" is a symlink to "usr/lib/sysimage/rpm", and a layout with the
+// database file itself symlinked. The database should be found once, at its
+// real location.
+func TestSymlinkedDB(t *testing.T) {
+	ctx := zlog.Test(context.Background(), t)
+	db, err := os.ReadFile(`ndb/testdata/Packages.db`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tl, _ := test.SquashfsLayers(t, fstest.MapFS{
+		"usr/lib/sysimage/rpm/Packages.db": &fstest.MapFile{Data: db, Mode: 0o644},
+	})
+	var s Scanner
+	want, err := s.Scan(ctx, tl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(want) == 0 {
+		t.Fatal("no packages found")
+	}
+
+	tt := []struct {
+		Name string
+		FS   fstest.MapFS
+	}{
+		{
+			Name: "Directory",
+			FS: fstest.MapFS{
+				"usr/lib/sysimage/rpm/Packages.db": &fstest.MapFile{Data: db, Mode: 0o644},
+				"var/lib/rpm": &fstest.MapFile{
+					Data: []byte("../../usr/lib/sysimage/rpm"),
+					Mode: fs.ModeSymlink | 0o777,
+				},
+			},
+		},
+		{
+			Name: "File",
+			FS: fstest.MapFS{
+				"usr/lib/sysimage/rpm/Packages.db": &fstest.MapFile{Data: db, Mode: 0o644},
+				"var/lib/rpm/Packages.db": &fstest.MapFile{
+					Data: []byte("../../../usr/lib/sysimage/rpm/Packages.db"),
+					Mode: fs.ModeSymlink | 0o777,
+				},
+			},
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			ctx := zlog.Test(ctx, t)
+			tl, sl := test.SquashfsLayers(t, tc.FS)
+			for n, l := range map[string]*claircore.Layer{"tar": tl, "squashfs": sl} {
+				got, err := s.Scan(ctx, l)
+				if err != nil {
+					t.Fatalf("%s: %v", n, err)
+				}
+				if !cmp.Equal(got, want) {
+					t.Errorf("%s: %s", n, cmp.Diff(got, want))
+				}
+			}
+		})
+	}
+}
@@ -16,7 +16,6 @@ import (
 
 	"github.com/quay/claircore"
 	"github.com/quay/claircore/indexer"
-	"github.com/quay/claircore/pkg/tarfs"
 )
 
 var (
@@ -63,7 +62,7 @@ type Scanner struct{}
 func (*Scanner) Name() string { return "ruby" }
 
 // Version implements scanner.VersionedScanner.
-func (*Scanner) Version() string { return "1" }
+func (*Scanner) Version() string { return "3" }
 
 // Kind implements scanner.VersionedScanner.
 func (*Scanner) Kind() string { return "package" }
@@ -84,15 +83,11 @@ func (ps *Scanner) Scan(ctx context.Context, layer *claircore.Layer) ([]*clairco
 		return nil, err
 	}
 
-	r, err := layer.Reader()
-	if err != nil {
-		return nil, err
-	}
-	defer r.Close()
-	sys, err := tarfs.New(r)
+	sys, err := layer.FS()
 	if err != nil {
 		return nil, fmt.Errorf("ruby: unable to open tar: %w", err)
 	}
+	defer sys.Close()
 
 	gs, err := gems(ctx, sys)
 	if err != nil {
@@ -6,6 +6,7 @@ import (
 	version "github.com/knqyf263/go-rpm-version"
 
 	"github.com/quay/claircore"
+	"github.com/quay/claircore/internal/vercache"
 	"github.com/quay/claircore/libvuln/driver"
 )
 
@@ -17,7 +18,11 @@ var (
 // Matcher implements driver.Matcher
 type Matcher struct{}
 
-var _ driver.Matcher = (*Matcher)(nil)
+var (
+	_ driver.Matcher              = (*Matcher)(nil)
+	_ driver.FixedVersionComparer = (*Matcher)(nil)
+	_ driver.Scoped               = (*Matcher)(nil)
+)
 
 // Name implements driver.Matcher
 func (*Matcher) Name() string {
@@ -40,6 +45,14 @@ func (*Matcher) Filter(record *claircore.IndexRecord) bool {
 	}
 }
 
+// Scope implements driver.Scoped.
+func (*Matcher) Scope() driver.Scope {
+	return driver.Scope{
+		Distributions:     OSReleaseIDs,
+		DistributionNames: OSReleaseNames,
+	}
+}
+
 // Query implements driver.Matcher
 func (*Matcher) Query() []driver.MatchConstraint {
 	return []driver.MatchConstraint{
@@ -51,19 +64,24 @@ func (*Matcher) Query() []driver.MatchConstraint {
 
 // Vulnerable implements driver.Matcher
 func (*Matcher) Vulnerable(ctx context.Context, record *claircore.IndexRecord, vuln *claircore.Vulnerability) (bool, error) {
-	pkgVer, vulnVer := version.NewVersion(record.Package.Version), version.NewVersion(vuln.Package.Version)
+	pkgVer, vulnVer := vercache.RPM(ctx, record.Package.Version), vercache.RPM(ctx, vuln.Package.Version)
 	// Assume the vulnerability record we have is for the last known vulnerable
 	// version, so greater versions aren't vulnerable.
 	cmp := func(i int) bool { return i != version.GREATER }
 	// But if it's explicitly marked as a fixed-in version, it't only vulnerable
 	// if less than that version.
 	if vuln.FixedInVersion != "" {
-		vulnVer = version.NewVersion(vuln.FixedInVersion)
+		vulnVer = vercache.RPM(ctx, vuln.FixedInVersion)
 		cmp = func(i int) bool { return i == version.LESS }
 	}
 	return cmp(pkgVer.Compare(vulnVer)) && vuln.ArchOperation.Cmp(record.Package.Arch, vuln.Package.Arch), nil
 }
 
+// CompareFixedVersions implements driver.FixedVersionComparer
+func (*Matcher) CompareFixedVersions(ctx context.Context, a, b string) int {
+	return vercache.CompareRPM(ctx, a, b)
+}
+
 // contains is a helper function to see if a slice of strings contains a specific string
 func contains(opts []string, s string) bool {
 
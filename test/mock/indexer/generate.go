@@ -1,4 +1,4 @@
 package mock_indexer
 
 //go:generate -command mockgen go run github.com/golang/mock/mockgen -destination=./mocks.go github.com/quay/claircore/indexer
-//go:generate mockgen Store,PackageScanner,VersionedScanner,DistributionScanner,RepositoryScanner,Coalescer,Realizer,FetchArena
+//go:generate mockgen Store,PackageScanner,VersionedScanner,DistributionScanner,RepositoryScanner,FileScanner,Coalescer,Realizer,FetchArena
@@ -1,5 +1,5 @@
 // Code generated by MockGen. DO NOT EDIT.
-// Source: github.com/quay/claircore/indexer (interfaces: Store,PackageScanner,VersionedScanner,DistributionScanner,RepositoryScanner,Coalescer,Realizer,FetchArena)
+// Source: github.com/quay/claircore/indexer (interfaces: Store,PackageScanner,VersionedScanner,DistributionScanner,RepositoryScanner,FileScanner,Coalescer,Realizer,FetchArena)
 
 // Package mock_indexer is a generated GoMock package.
 package mock_indexer
@@ -201,6 +201,22 @@ func (mr *MockStoreMockRecorder) IndexRepositories(arg0, arg1, arg2, arg3 interf
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IndexRepositories", reflect.TypeOf((*MockStore)(nil).IndexRepositories), arg0, arg1, arg2, arg3)
 }
 
+// LayerResults mocks base method.
+func (m *MockStore) LayerResults(arg0 context.Context, arg1 claircore.Digest, arg2 indexer.VersionedScanner) (int, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LayerResults", arg0, arg1, arg2)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// LayerResults indicates an expected call of LayerResults.
+func (mr *MockStoreMockRecorder) LayerResults(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LayerResults", reflect.TypeOf((*MockStore)(nil).LayerResults), arg0, arg1, arg2)
+}
+
 // LayerScanned mocks base method.
 func (m *MockStore) LayerScanned(arg0 context.Context, arg1 claircore.Digest, arg2 indexer.VersionedScanner) (bool, error) {
 	m.ctrl.T.Helper()
@@ -231,6 +247,21 @@ func (mr *MockStoreMockRecorder) ManifestScanned(arg0, arg1, arg2 interface{}) *
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ManifestScanned", reflect.TypeOf((*MockStore)(nil).ManifestScanned), arg0, arg1, arg2)
 }
 
+// PackageDependencies mocks base method.
+func (m *MockStore) PackageDependencies(arg0 context.Context, arg1 claircore.Digest) ([]claircore.Dependency, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PackageDependencies", arg0, arg1)
+	ret0, _ := ret[0].([]claircore.Dependency)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PackageDependencies indicates an expected call of PackageDependencies.
+func (mr *MockStoreMockRecorder) PackageDependencies(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PackageDependencies", reflect.TypeOf((*MockStore)(nil).PackageDependencies), arg0, arg1)
+}
+
 // PackagesByLayer mocks base method.
 func (m *MockStore) PackagesByLayer(arg0 context.Context, arg1 claircore.Digest, arg2 indexer.VersionedScanners) ([]*claircore.Package, error) {
 	m.ctrl.T.Helper()
@@ -317,6 +348,20 @@ func (mr *MockStoreMockRecorder) SetIndexReport(arg0, arg1 interface{}) *gomock.
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetIndexReport", reflect.TypeOf((*MockStore)(nil).SetIndexReport), arg0, arg1)
 }
 
+// SetLayerResults mocks base method.
+func (m *MockStore) SetLayerResults(arg0 context.Context, arg1 claircore.Digest, arg2 indexer.VersionedScanner, arg3 int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetLayerResults", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetLayerResults indicates an expected call of SetLayerResults.
+func (mr *MockStoreMockRecorder) SetLayerResults(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetLayerResults", reflect.TypeOf((*MockStore)(nil).SetLayerResults), arg0, arg1, arg2, arg3)
+}
+
 // SetLayerScanned mocks base method.
 func (m *MockStore) SetLayerScanned(arg0 context.Context, arg1 claircore.Digest, arg2 indexer.VersionedScanner) error {
 	m.ctrl.T.Helper()
@@ -331,6 +376,21 @@ func (mr *MockStoreMockRecorder) SetLayerScanned(arg0, arg1, arg2 interface{}) *
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetLayerScanned", reflect.TypeOf((*MockStore)(nil).SetLayerScanned), arg0, arg1, arg2)
 }
 
+// StaleManifests mocks base method.
+func (m *MockStore) StaleManifests(arg0 context.Context, arg1 []indexer.ScannerInfo) ([]claircore.Digest, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StaleManifests", arg0, arg1)
+	ret0, _ := ret[0].([]claircore.Digest)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StaleManifests indicates an expected call of StaleManifests.
+func (mr *MockStoreMockRecorder) StaleManifests(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StaleManifests", reflect.TypeOf((*MockStore)(nil).StaleManifests), arg0, arg1)
+}
+
 // MockPackageScanner is a mock of PackageScanner interface.
 type MockPackageScanner struct {
 	ctrl     *gomock.Controller
@@ -636,6 +696,86 @@ func (mr *MockRepositoryScannerMockRecorder) Version() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Version", reflect.TypeOf((*MockRepositoryScanner)(nil).Version))
 }
 
+// MockFileScanner is a mock of FileScanner interface.
+type MockFileScanner struct {
+	ctrl     *gomock.Controller
+	recorder *MockFileScannerMockRecorder
+}
+
+// MockFileScannerMockRecorder is the mock recorder for MockFileScanner.
+type MockFileScannerMockRecorder struct {
+	mock *MockFileScanner
+}
+
+// NewMockFileScanner creates a new mock instance.
+func NewMockFileScanner(ctrl *gomock.Controller) *MockFileScanner {
+	mock := &MockFileScanner{ctrl: ctrl}
+	mock.recorder = &MockFileScannerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockFileScanner) EXPECT() *MockFileScannerMockRecorder {
+	return m.recorder
+}
+
+// Kind mocks base method.
+func (m *MockFileScanner) Kind() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Kind")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// Kind indicates an expected call of Kind.
+func (mr *MockFileScannerMockRecorder) Kind() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Kind", reflect.TypeOf((*MockFileScanner)(nil).Kind))
+}
+
+// Name mocks base method.
+func (m *MockFileScanner) Name() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Name")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// Name indicates an expected call of Name.
+func (mr *MockFileScannerMockRecorder) Name() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Name", reflect.TypeOf((*MockFileScanner)(nil).Name))
+}
+
+// Scan mocks base method.
+func (m *MockFileScanner) Scan(arg0 context.Context, arg1 *claircore.Layer) ([]claircore.File, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Scan", arg0, arg1)
+	ret0, _ := ret[0].([]claircore.File)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Scan indicates an expected call of Scan.
+func (mr *MockFileScannerMockRecorder) Scan(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Scan", reflect.TypeOf((*MockFileScanner)(nil).Scan), arg0, arg1)
+}
+
+// Version mocks base method.
+func (m *MockFileScanner) Version() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Version")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// Version indicates an expected call of Version.
+func (mr *MockFileScannerMockRecorder) Version() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Version", reflect.TypeOf((*MockFileScanner)(nil).Version))
+}
+
 // MockCoalescer is a mock of Coalescer interface.
 type MockCoalescer struct {
 	ctrl     *gomock.Controller
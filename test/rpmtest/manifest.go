@@ -70,6 +70,7 @@ var Options = cmp.Options{
 	HintCompare,
 	EpochCompare,
 	IgnorePackageDB,
+	IgnoreProvides,
 	SortPackages,
 }
 
@@ -125,4 +126,6 @@ var (
 		return a.Name < b.Name
 	})
 	IgnorePackageDB = cmpopts.IgnoreFields(claircore.Package{}, ".PackageDB")
+	// RPM Manifest doesn't have provides information.
+	IgnoreProvides = cmpopts.IgnoreFields(claircore.Package{}, ".Provides")
 )
@@ -0,0 +1,95 @@
+package test
+
+import (
+	"archive/tar"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/quay/claircore"
+	"github.com/quay/claircore/test/squashfstest"
+)
+
+// SquashfsLayers writes the contents of "sys" as both a tar archive and a
+// squashfs image, returning a Layer for each. Scanning both should give the
+// same results.
+//
+// The files are removed when the test finishes.
+func SquashfsLayers(t testing.TB, sys fs.FS) (tarLayer, squashLayer *claircore.Layer) {
+	t.Helper()
+	dir := t.TempDir()
+
+	tf, err := os.Create(filepath.Join(dir, "layer.tar"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tf.Close()
+	if err := writeTar(tf, sys); err != nil {
+		t.Fatal(err)
+	}
+	sf, err := os.Create(filepath.Join(dir, "layer.squashfs"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sf.Close()
+	if err := squashfstest.Write(sf, sys); err != nil {
+		t.Fatal(err)
+	}
+
+	tarLayer = &claircore.Layer{Hash: RandomSHA256Digest(t)}
+	tarLayer.SetLocal(tf.Name())
+	squashLayer = &claircore.Layer{Hash: RandomSHA256Digest(t)}
+	squashLayer.SetLocal(sf.Name())
+	return tarLayer, squashLayer
+}
+
+// WriteTar writes the contents of "sys" as a tar archive.
+func writeTar(f *os.File, sys fs.FS) error {
+	tw := tar.NewWriter(f)
+	err := fs.WalkDir(sys, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil || p == "." {
+			return err
+		}
+		fi, err := d.Info()
+		if err != nil {
+			return err
+		}
+		h := tar.Header{
+			Name: p,
+			Mode: int64(fi.Mode().Perm()),
+		}
+		var b []byte
+		switch typ := fi.Mode().Type(); {
+		case typ == fs.ModeDir:
+			h.Typeflag = tar.TypeDir
+			h.Name += "/"
+		case typ == fs.ModeSymlink:
+			h.Typeflag = tar.TypeSymlink
+			if rl, ok := sys.(interface {
+				ReadLink(string) (string, error)
+			}); ok {
+				h.Linkname, err = rl.ReadLink(p)
+			} else {
+				b, err = fs.ReadFile(sys, p)
+				h.Linkname, b = string(b), nil
+			}
+		default:
+			h.Typeflag = tar.TypeReg
+			b, err = fs.ReadFile(sys, p)
+			h.Size = int64(len(b))
+		}
+		if err != nil {
+			return err
+		}
+		if err := tw.WriteHeader(&h); err != nil {
+			return err
+		}
+		_, err = tw.Write(b)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return tw.Close()
+}
@@ -0,0 +1,477 @@
+// Package squashfstest writes squashfs images for use as test fixtures.
+package squashfstest
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/fs"
+	"math"
+	"path"
+)
+
+// Constants from the on-disk format. Everything is little-endian.
+const (
+	magic       = 0x73717368
+	blockSize   = 4096
+	blockLog    = 12
+	metadataSz  = 8192
+	metadataRaw = 1 << 15
+	dataRaw     = 1 << 24
+	noFragment  = 0xFFFFFFFF
+	noTable     = math.MaxUint64
+	flagNoXattr = 0x0200
+	compGzip    = 1
+	superSz     = 96
+
+	typeDir     = 1
+	typeFile    = 2
+	typeSymlink = 3
+)
+
+var le = binary.LittleEndian
+
+// Write writes a gzip-compressed squashfs image with the contents of "sys" to
+// "w".
+//
+// Directories, regular files, and symlinks are supported. Symlink targets are
+// read with a ReadLink method if "sys" has one, and as the contents of the
+// link otherwise, which is how fstest.MapFS represents them. A small block
+// size is used so that modest files span multiple blocks and exercise
+// fragments. Ownership is not recorded.
+func Write(w io.Writer, sys fs.FS) error {
+	root, err := walk(sys)
+	if err != nil {
+		return err
+	}
+	var b builder
+	b.data.Write(make([]byte, superSz))
+
+	// Data blocks and fragments come first.
+	var order []*node
+	var visit func(*node)
+	visit = func(n *node) {
+		for _, c := range n.children {
+			visit(c)
+		}
+		order = append(order, n)
+	}
+	visit(root)
+	for _, n := range order {
+		if n.mode.IsRegular() {
+			b.writeData(n)
+		}
+	}
+	b.flushFragment()
+
+	// Children are written before their parents, so every inode reference a
+	// directory listing needs is known when it's written.
+	for i, n := range order {
+		n.num = uint32(i + 1)
+	}
+	for _, n := range order {
+		if err := b.writeInode(n, root, uint32(len(order))); err != nil {
+			return err
+		}
+	}
+	b.inodes.finish()
+	b.dirs.finish()
+
+	sb := superblock{
+		Magic:       magic,
+		Inodes:      uint32(len(order)),
+		BlockSize:   blockSize,
+		Fragments:   uint32(len(b.frags)),
+		Compression: compGzip,
+		BlockLog:    blockLog,
+		Flags:       flagNoXattr,
+		IDs:         1,
+		Major:       4,
+		RootInode:   root.ref,
+		XattrTable:  noTable,
+		ExportTable: noTable,
+	}
+	out := &b.data
+	sb.InodeTable = uint64(out.Len())
+	out.Write(b.inodes.out.Bytes())
+	sb.DirTable = uint64(out.Len())
+	out.Write(b.dirs.out.Bytes())
+
+	// The fragment and ID tables are metadata blocks of entries, followed by
+	// an index of the metadata blocks.
+	var ft metadataWriter
+	for _, f := range b.frags {
+		binary.Write(&ft, le, f)
+	}
+	sb.FragmentTable = writeTable(out, &ft)
+	var it metadataWriter
+	binary.Write(&it, le, uint32(0))
+	sb.IDTable = writeTable(out, &it)
+
+	sb.BytesUsed = uint64(out.Len())
+	if pad := out.Len() % 4096; pad != 0 {
+		out.Write(make([]byte, 4096-pad))
+	}
+	img := out.Bytes()
+	var hdr bytes.Buffer
+	binary.Write(&hdr, le, &sb)
+	copy(img, hdr.Bytes())
+	_, err = w.Write(img)
+	return err
+}
+
+// Node is a member of the filesystem being written.
+type node struct {
+	name     string
+	mode     fs.FileMode
+	mtime    uint32
+	contents []byte
+	children []*node
+
+	// Filled in while writing.
+	num     uint32
+	ref     uint64
+	start   uint64
+	blocks  []uint32
+	frag    uint32
+	fragOff uint32
+}
+
+// ReadLinker is implemented by filesystems that can report symlink targets.
+type readLinker interface {
+	ReadLink(name string) (string, error)
+}
+
+// Walk reads "sys" into a tree of nodes.
+func walk(sys fs.FS) (*node, error) {
+	nodes := make(map[string]*node)
+	err := fs.WalkDir(sys, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		fi, err := d.Info()
+		if err != nil {
+			return err
+		}
+		n := &node{
+			name: d.Name(),
+			mode: fi.Mode(),
+		}
+		if t := fi.ModTime().Unix(); t > 0 && t <= math.MaxUint32 {
+			n.mtime = uint32(t)
+		}
+		switch typ := fi.Mode().Type(); {
+		case typ == fs.ModeDir:
+		case typ == fs.ModeSymlink:
+			if rl, ok := sys.(readLinker); ok {
+				var tgt string
+				tgt, err = rl.ReadLink(p)
+				n.contents = []byte(tgt)
+			} else {
+				n.contents, err = fs.ReadFile(sys, p)
+			}
+			if err != nil {
+				return err
+			}
+		case typ.IsRegular():
+			n.contents, err = fs.ReadFile(sys, p)
+			if err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("squashfstest: unsupported file type for %q: %v", p, typ)
+		}
+		nodes[p] = n
+		if p != "." {
+			parent := nodes[path.Dir(p)]
+			parent.children = append(parent.children, n)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return nodes["."], nil
+}
+
+// Superblock is the header at the start of every image.
+type superblock struct {
+	Magic         uint32
+	Inodes        uint32
+	ModTime       uint32
+	BlockSize     uint32
+	Fragments     uint32
+	Compression   uint16
+	BlockLog      uint16
+	Flags         uint16
+	IDs           uint16
+	Major         uint16
+	Minor         uint16
+	RootInode     uint64
+	BytesUsed     uint64
+	IDTable       uint64
+	XattrTable    uint64
+	InodeTable    uint64
+	DirTable      uint64
+	FragmentTable uint64
+	ExportTable   uint64
+}
+
+type fragment struct {
+	Start  uint64
+	Size   uint32
+	Unused uint32
+}
+
+type builder struct {
+	data   bytes.Buffer
+	frag   []byte
+	frags  []fragment
+	inodes metadataWriter
+	dirs   metadataWriter
+}
+
+// WriteData writes the contents of a regular file as full blocks, with the
+// remainder added to the current fragment.
+func (b *builder) writeData(n *node) {
+	n.start = uint64(b.data.Len())
+	n.frag = noFragment
+	c := n.contents
+	for len(c) >= blockSize {
+		blk, raw := compress(c[:blockSize])
+		sz := uint32(len(blk))
+		if raw {
+			sz |= dataRaw
+		}
+		b.data.Write(blk)
+		n.blocks = append(n.blocks, sz)
+		c = c[blockSize:]
+	}
+	if len(c) == 0 {
+		return
+	}
+	if len(b.frag)+len(c) > blockSize {
+		b.flushFragment()
+	}
+	n.frag = uint32(len(b.frags))
+	n.fragOff = uint32(len(b.frag))
+	b.frag = append(b.frag, c...)
+}
+
+func (b *builder) flushFragment() {
+	if len(b.frag) == 0 {
+		return
+	}
+	blk, raw := compress(b.frag)
+	f := fragment{
+		Start: uint64(b.data.Len()),
+		Size:  uint32(len(blk)),
+	}
+	if raw {
+		f.Size |= dataRaw
+	}
+	b.data.Write(blk)
+	b.frags = append(b.frags, f)
+	b.frag = b.frag[:0]
+}
+
+// WriteInode writes the inode for "n" and, for directories, its listing.
+func (b *builder) writeInode(n, root *node, count uint32) error {
+	blk, off := b.inodes.pos()
+	n.ref = blk<<16 | uint64(off)
+	hdr := struct {
+		Type   uint16
+		Perm   uint16
+		UID    uint16
+		GID    uint16
+		MTime  uint32
+		Number uint32
+	}{
+		Perm:   uint16(n.mode.Perm()),
+		MTime:  n.mtime,
+		Number: n.num,
+	}
+	if n.mode&fs.ModeSetuid != 0 {
+		hdr.Perm |= 0o4000
+	}
+	if n.mode&fs.ModeSetgid != 0 {
+		hdr.Perm |= 0o2000
+	}
+	if n.mode&fs.ModeSticky != 0 {
+		hdr.Perm |= 0o1000
+	}
+	switch {
+	case n.mode.IsDir():
+		hdr.Type = typeDir
+		dblk, doff := b.dirs.pos()
+		sz := b.writeListing(n)
+		if sz+3 > math.MaxUint16 {
+			return fmt.Errorf("squashfstest: directory %q too large", n.name)
+		}
+		nlink := uint32(2)
+		for _, c := range n.children {
+			if c.mode.IsDir() {
+				nlink++
+			}
+		}
+		parent := count + 1
+		if n != root {
+			parent = findParent(root, n).num
+		}
+		binary.Write(&b.inodes, le, &hdr)
+		binary.Write(&b.inodes, le, struct {
+			Start  uint32
+			Nlink  uint32
+			Size   uint16
+			Offset uint16
+			Parent uint32
+		}{uint32(dblk), nlink, uint16(sz + 3), doff, parent})
+	case n.mode.IsRegular():
+		if len(n.contents) > math.MaxUint32 || n.start > math.MaxUint32 {
+			return fmt.Errorf("squashfstest: file %q too large", n.name)
+		}
+		hdr.Type = typeFile
+		binary.Write(&b.inodes, le, &hdr)
+		binary.Write(&b.inodes, le, struct {
+			Start  uint32
+			Frag   uint32
+			Offset uint32
+			Size   uint32
+		}{uint32(n.start), n.frag, n.fragOff, uint32(len(n.contents))})
+		binary.Write(&b.inodes, le, n.blocks)
+	default: // Symlink
+		hdr.Type = typeSymlink
+		binary.Write(&b.inodes, le, &hdr)
+		binary.Write(&b.inodes, le, struct {
+			Nlink uint32
+			Size  uint32
+		}{1, uint32(len(n.contents))})
+		b.inodes.Write(n.contents)
+	}
+	return nil
+}
+
+// WriteListing writes the directory listing for "n", returning its size.
+//
+// Entries are grouped into runs sharing an inode metadata block, with inode
+// numbers stored relative to the first entry of the run.
+func (b *builder) writeListing(n *node) int {
+	var buf bytes.Buffer
+	cs := n.children
+	for len(cs) != 0 {
+		start := cs[0].ref >> 16
+		base := cs[0].num
+		run := 1
+		for run < len(cs) && run < 256 &&
+			cs[run].ref>>16 == start &&
+			int64(cs[run].num)-int64(base) <= math.MaxInt16 &&
+			int64(cs[run].num)-int64(base) >= math.MinInt16 {
+			run++
+		}
+		binary.Write(&buf, le, struct {
+			Count  uint32
+			Start  uint32
+			Number uint32
+		}{uint32(run - 1), uint32(start), base})
+		for _, c := range cs[:run] {
+			typ := uint16(typeFile)
+			switch {
+			case c.mode.IsDir():
+				typ = typeDir
+			case c.mode&fs.ModeSymlink != 0:
+				typ = typeSymlink
+			}
+			binary.Write(&buf, le, struct {
+				Offset   uint16
+				InodeOff int16
+				Type     uint16
+				NameSize uint16
+			}{uint16(c.ref), int16(int64(c.num) - int64(base)), typ, uint16(len(c.name) - 1)})
+			buf.WriteString(c.name)
+		}
+		cs = cs[run:]
+	}
+	b.dirs.Write(buf.Bytes())
+	return buf.Len()
+}
+
+func findParent(root, n *node) *node {
+	for _, c := range root.children {
+		if c == n {
+			return root
+		}
+		if p := findParent(c, n); p != nil {
+			return p
+		}
+	}
+	return nil
+}
+
+// MetadataWriter packs data into metadata blocks.
+type metadataWriter struct {
+	out    bytes.Buffer
+	cur    []byte
+	starts []uint64
+}
+
+// Pos reports the position the next write will land at: the offset of the
+// metadata block and the offset into its uncompressed contents.
+func (m *metadataWriter) pos() (uint64, uint16) {
+	return uint64(m.out.Len()), uint16(len(m.cur))
+}
+
+// Write implements io.Writer.
+func (m *metadataWriter) Write(p []byte) (int, error) {
+	m.cur = append(m.cur, p...)
+	for len(m.cur) >= metadataSz {
+		m.flush(m.cur[:metadataSz])
+		m.cur = m.cur[metadataSz:]
+	}
+	return len(p), nil
+}
+
+func (m *metadataWriter) finish() {
+	if len(m.cur) != 0 {
+		m.flush(m.cur)
+		m.cur = nil
+	}
+}
+
+func (m *metadataWriter) flush(b []byte) {
+	m.starts = append(m.starts, uint64(m.out.Len()))
+	c, raw := compress(b)
+	hdr := uint16(len(c))
+	if raw {
+		hdr |= metadataRaw
+	}
+	binary.Write(&m.out, le, hdr)
+	m.out.Write(c)
+}
+
+// WriteTable writes the metadata blocks in "m" and an index of them to "out",
+// returning the position of the index.
+func writeTable(out *bytes.Buffer, m *metadataWriter) uint64 {
+	m.finish()
+	base := uint64(out.Len())
+	out.Write(m.out.Bytes())
+	pos := uint64(out.Len())
+	for _, s := range m.starts {
+		binary.Write(out, le, base+s)
+	}
+	return pos
+}
+
+// Compress returns the compressed form of "b", or "b" and true if compressing
+// doesn't make it smaller.
+func compress(b []byte) ([]byte, bool) {
+	var buf bytes.Buffer
+	w, _ := zlib.NewWriterLevel(&buf, zlib.BestCompression)
+	w.Write(b)
+	w.Close()
+	if buf.Len() >= len(b) {
+		return b, true
+	}
+	return buf.Bytes(), false
+}
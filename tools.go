@@ -6,4 +6,5 @@ import (
 	_ "github.com/golang/mock/mockgen"
 	_ "golang.org/x/tools/cmd/file2fuzz"
 	_ "golang.org/x/tools/cmd/stringer"
+	_ "google.golang.org/protobuf/cmd/protoc-gen-go"
 )
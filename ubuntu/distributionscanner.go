@@ -14,7 +14,6 @@ import (
 
 	"github.com/quay/claircore"
 	"github.com/quay/claircore/indexer"
-	"github.com/quay/claircore/pkg/tarfs"
 )
 
 const (
@@ -52,15 +51,11 @@ func (ds *DistributionScanner) Scan(ctx context.Context, l *claircore.Layer) ([]
 		"layer", l.Hash.String())
 	zlog.Debug(ctx).Msg("start")
 	defer zlog.Debug(ctx).Msg("done")
-	rd, err := l.Reader()
-	if err != nil {
-		return nil, fmt.Errorf("ubuntu: unable to open layer: %w", err)
-	}
-	defer rd.Close()
-	sys, err := tarfs.New(rd)
+	sys, err := l.FS()
 	if err != nil {
 		return nil, fmt.Errorf("ubuntu: unable to open layer: %w", err)
 	}
+	defer sys.Close()
 	d, err := findDist(sys)
 	if err != nil {
 		return nil, fmt.Errorf("ubuntu: %w", err)
@@ -3,13 +3,16 @@ package ubuntu
 import (
 	"context"
 
-	version "github.com/knqyf263/go-deb-version"
-
 	"github.com/quay/claircore"
+	"github.com/quay/claircore/internal/vercache"
 	"github.com/quay/claircore/libvuln/driver"
 )
 
-var _ driver.Matcher = (*Matcher)(nil)
+var (
+	_ driver.Matcher              = (*Matcher)(nil)
+	_ driver.FixedVersionComparer = (*Matcher)(nil)
+	_ driver.Scoped               = (*Matcher)(nil)
+)
 
 // Matcher is a [driver.Matcher] for Ubuntu distributions.
 type Matcher struct{}
@@ -35,6 +38,14 @@ func (*Matcher) Filter(record *claircore.IndexRecord) bool {
 	}
 }
 
+// Scope implements [driver.Scoped].
+func (*Matcher) Scope() driver.Scope {
+	return driver.Scope{
+		Distributions:     []string{"ubuntu"},
+		DistributionNames: []string{"Ubuntu"},
+	}
+}
+
 // Query implements [driver.Matcher].
 func (*Matcher) Query() []driver.MatchConstraint {
 	return []driver.MatchConstraint{
@@ -50,12 +61,12 @@ func (*Matcher) Vulnerable(ctx context.Context, record *claircore.IndexRecord, v
 		return true, nil
 	}
 
-	v1, err := version.NewVersion(record.Package.Version)
+	v1, err := vercache.Deb(ctx, record.Package.Version)
 	if err != nil {
 		return false, err
 	}
 
-	v2, err := version.NewVersion(vuln.FixedInVersion)
+	v2, err := vercache.Deb(ctx, vuln.FixedInVersion)
 	if err != nil {
 		return false, err
 	}
@@ -70,3 +81,8 @@ func (*Matcher) Vulnerable(ctx context.Context, record *claircore.IndexRecord, v
 
 	return false, nil
 }
+
+// CompareFixedVersions implements [driver.FixedVersionComparer].
+func (*Matcher) CompareFixedVersions(ctx context.Context, a, b string) int {
+	return vercache.CompareDeb(ctx, a, b)
+}
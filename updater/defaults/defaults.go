@@ -12,7 +12,9 @@ import (
 	"github.com/quay/claircore/aws"
 	"github.com/quay/claircore/debian"
 	"github.com/quay/claircore/enricher/cvss"
+	nvdenricher "github.com/quay/claircore/enricher/nvd"
 	"github.com/quay/claircore/libvuln/driver"
+	"github.com/quay/claircore/nvd"
 	"github.com/quay/claircore/oracle"
 	"github.com/quay/claircore/photon"
 	"github.com/quay/claircore/pyupio"
@@ -65,15 +67,19 @@ func inner(ctx context.Context) error {
 
 	updater.Register("osv", osv.Factory)
 	updater.Register("aws", driver.UpdaterSetFactoryFunc(aws.UpdaterSet))
+	updater.Register("nvd", &nvd.Factory{})
 	updater.Register("oracle", driver.UpdaterSetFactoryFunc(oracle.UpdaterSet))
 	updater.Register("photon", driver.UpdaterSetFactoryFunc(photon.UpdaterSet))
 	updater.Register("pyupio", driver.UpdaterSetFactoryFunc(pyupio.UpdaterSet))
 	updater.Register("suse", driver.UpdaterSetFactoryFunc(suse.UpdaterSet))
 	updater.Register("rhcc", driver.UpdaterSetFactoryFunc(rhcc.UpdaterSet))
+	updater.Register(rhel.MappingUpdaterName, driver.UpdaterSetFactoryFunc(rhel.MappingUpdaterSet))
 
 	cvssSet := driver.NewUpdaterSet()
 	cvssSet.Add(&cvss.Enricher{})
 	updater.Register("clair.cvss", driver.StaticSet(cvssSet))
 
+	updater.Register("clair.nvd", &nvdenricher.Factory{})
+
 	return nil
 }
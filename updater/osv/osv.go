@@ -24,6 +24,7 @@ import (
 
 	"github.com/quay/claircore"
 	"github.com/quay/claircore/libvuln/driver"
+	"github.com/quay/claircore/pkg/pkgname"
 	"github.com/quay/claircore/pkg/tmp"
 )
 
@@ -446,7 +447,10 @@ type ecs struct {
 }
 
 const (
+	ecosystemGo    = `Go`
 	ecosystemMaven = `Maven`
+	ecosystemPyPI  = `PyPI`
+	ecosystemNPM   = `npm`
 )
 
 func newECS(u string) ecs {
@@ -595,8 +599,14 @@ func (e *ecs) Insert(ctx context.Context, skipped *stats, name string, a *adviso
 				vs = b.String()
 			}
 			pkgName := af.Package.PURL
-			if af.Package.Ecosystem == ecosystemMaven {
+			switch af.Package.Ecosystem {
+			case ecosystemMaven, ecosystemGo:
+				// Use the name that the package scanners report.
 				pkgName = af.Package.Name
+			case ecosystemPyPI, ecosystemNPM:
+				// Use the normalized name, which is what the package
+				// scanners report.
+				pkgName = pkgname.Normalize(af.Package.Ecosystem, af.Package.Name)
 			}
 			pkg, novel := e.LookupPackage(pkgName, vs)
 			v.Package = pkg
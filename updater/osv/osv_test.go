@@ -15,9 +15,11 @@ import (
 	"os"
 	"path"
 	"path/filepath"
+	"sort"
 	"strings"
 	"testing"
 
+	"github.com/google/go-cmp/cmp"
 	"github.com/quay/zlog"
 
 	"github.com/quay/claircore/libvuln/driver"
@@ -128,3 +130,41 @@ func TestParse(t *testing.T) {
 		t.Log(buf.String())
 	}
 }
+
+// TestNames checks that the package names recorded for the ecosystems with
+// name normalization are the normalized names, which is what the package
+// scanners report.
+func TestNames(t *testing.T) {
+	ctx := zlog.Test(context.Background(), t)
+	f, err := os.Open(filepath.Join("testdata", "names.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var as []advisory
+	if err := json.NewDecoder(f).Decode(&as); err != nil {
+		t.Fatal(err)
+	}
+	e := newECS("osv")
+	var skipped stats
+	for i := range as {
+		if err := e.Insert(ctx, &skipped, "test", &as[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var got []string
+	for _, v := range e.Finalize() {
+		got = append(got, v.Package.Name)
+	}
+	sort.Strings(got)
+	want := []string{
+		"@babel/traverse",
+		"JSONStream",
+		"discord-py",
+		"django-rest-framework",
+		"pyyaml",
+	}
+	if !cmp.Equal(got, want) {
+		t.Error(cmp.Diff(got, want))
+	}
+}
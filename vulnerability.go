@@ -38,6 +38,24 @@ type Vulnerability struct {
 	// ArchOperation indicates how the affected Package's "arch" should be
 	// compared.
 	ArchOperation ArchOp `json:"arch_op,omitempty"`
+	// AlwaysAffected marks an advisory that applies regardless of the
+	// installed version, such as one for a package that should never be
+	// present. It's only honored if FixedInVersion is empty.
+	AlwaysAffected bool `json:"always_affected,omitempty"`
+	// FixState is the reason the security database gives for there being no
+	// fix, such as "Will not fix" or "Fix deferred". It's only meaningful if
+	// FixedInVersion is empty, and the values depend on the database.
+	FixState string `json:"fix_state,omitempty"`
+	// Condition is an expression describing the configuration the
+	// vulnerability is exploitable in, like a module being enabled, in the
+	// syntax of the condition package. An empty Condition always holds.
+	Condition string `json:"condition,omitempty"`
+}
+
+// VersionAgnostic reports whether the Vulnerability affects every version of
+// its package, including packages with an unknown version.
+func (v *Vulnerability) VersionAgnostic() bool {
+	return v.AlwaysAffected && v.FixedInVersion == ""
 }
 
 // CheckVulnernableFunc takes a vulnerability and an indexRecord and checks if the record is
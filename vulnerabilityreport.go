@@ -1,10 +1,17 @@
 package claircore
 
-import "encoding/json"
+import (
+	"encoding/json"
+	"sort"
+	"time"
+)
 
 // VulnerabilityReport provides a report of packages and their
 // associated vulnerabilities.
 type VulnerabilityReport struct {
+	// the version of the serialized form of the report; see
+	// ReportSchemaVersion and DecodeReport
+	SchemaVersion int `json:"schema_version"`
 	// the manifest hash this vulnerability report is describing
 	Hash Digest `json:"manifest_hash"`
 	// all discovered packages in this manifest keyed by package id
@@ -21,4 +28,223 @@ type VulnerabilityReport struct {
 	PackageVulnerabilities map[string][]string `json:"package_vulnerabilities"`
 	// a map of enrichments keyed by a type.
 	Enrichments map[string][]json.RawMessage `json:"enrichments"`
+	// the ids of vulnerabilities only found in packages provided by a base
+	// image, sorted. Only populated by MarkInherited.
+	InheritedVulnerabilities []string `json:"inherited_vulnerabilities,omitempty"`
+	// the ids of vulnerabilities that only affect the image under a
+	// condition that couldn't be confirmed from what was indexed, sorted.
+	ConditionallyAffected []string `json:"conditionally_affected,omitempty"`
+	// the ids of vulnerabilities only found in packages identified with
+	// ConfidenceLow, sorted.
+	LowConfidence []string `json:"low_confidence,omitempty"`
+	// the version each vulnerable package should be upgraded to, keyed by
+	// package id: the highest FixedInVersion of its vulnerabilities. Only
+	// populated for packages whose matcher can order fixed versions.
+	FixedVersions map[string]string `json:"fixed_versions,omitempty"`
+	// the sorted manifest hashes each vulnerability affects, keyed by
+	// vulnerability id. Only populated by MergeReports.
+	VulnerabilityManifests map[string][]string `json:"vulnerability_manifests,omitempty"`
+	// the vulnerabilities left out of this report by a ReportFilter, keyed
+	// by package id. Only populated by ReportFilter.Apply.
+	IgnoredVulnerabilities map[string][]IgnoredVulnerability `json:"ignored_vulnerabilities,omitempty"`
+	// statistics about the work done to produce this report. Only populated
+	// if requested when matching.
+	Stats *MatchStats `json:"stats,omitempty"`
+}
+
+// MatchStats are statistics about the work done to match a manifest, useful
+// for tracking the cost of matching.
+type MatchStats struct {
+	// the time spent in each matcher, including any enrichments it
+	// contributed, in nanoseconds key'd by matcher name
+	Matchers map[string]time.Duration `json:"matchers"`
+}
+
+// MergeReports unions the provided reports into an aggregate report, for
+// views spanning multiple images.
+//
+// The returned report has no Hash. VulnerabilityManifests records which of
+// the input manifests each vulnerability affects; reports that are
+// themselves aggregates contribute the manifests they recorded. Packages,
+// distributions, repositories, and vulnerabilities are deduplicated by ID,
+// which means the reports are expected to come from the same indexer and
+// matcher. Per-image information that doesn't survive aggregation, like
+// InheritedVulnerabilities, ConditionallyAffected, and LowConfidence, is
+// dropped.
+//
+// The input reports are not modified, but the returned report shares
+// pointers with them.
+func MergeReports(reports ...*VulnerabilityReport) *VulnerabilityReport {
+	out := &VulnerabilityReport{
+		SchemaVersion:          ReportSchemaVersion,
+		Packages:               make(map[string]*Package),
+		Distributions:          make(map[string]*Distribution),
+		Repositories:           make(map[string]*Repository),
+		Environments:           make(map[string][]*Environment),
+		Vulnerabilities:        make(map[string]*Vulnerability),
+		PackageVulnerabilities: make(map[string][]string),
+		Enrichments:            make(map[string][]json.RawMessage),
+		VulnerabilityManifests: make(map[string][]string),
+	}
+	type envKey struct {
+		db, layer, dist string
+	}
+	envSeen := make(map[string]map[envKey]struct{})
+	pvSeen := make(map[string]map[string]struct{})
+	vmSeen := make(map[string]map[string]struct{})
+	enSeen := make(map[string]map[string]struct{})
+	add := func(seen map[string]map[string]struct{}, dst map[string][]string, k, v string) {
+		s, ok := seen[k]
+		if !ok {
+			s = make(map[string]struct{})
+			seen[k] = s
+		}
+		if _, ok := s[v]; ok {
+			return
+		}
+		s[v] = struct{}{}
+		dst[k] = append(dst[k], v)
+	}
+
+	for _, r := range reports {
+		if r == nil {
+			continue
+		}
+		for id, p := range r.Packages {
+			out.Packages[id] = p
+		}
+		for id, d := range r.Distributions {
+			out.Distributions[id] = d
+		}
+		for id, repo := range r.Repositories {
+			out.Repositories[id] = repo
+		}
+		for id, es := range r.Environments {
+			s, ok := envSeen[id]
+			if !ok {
+				s = make(map[envKey]struct{})
+				envSeen[id] = s
+			}
+			for _, e := range es {
+				if e == nil {
+					continue
+				}
+				k := envKey{e.PackageDB, e.IntroducedIn.String(), e.DistributionID}
+				if _, ok := s[k]; ok {
+					continue
+				}
+				s[k] = struct{}{}
+				out.Environments[id] = append(out.Environments[id], e)
+			}
+		}
+		for id, v := range r.Vulnerabilities {
+			out.Vulnerabilities[id] = v
+		}
+		for id, vs := range r.PackageVulnerabilities {
+			for _, v := range vs {
+				add(pvSeen, out.PackageVulnerabilities, id, v)
+			}
+		}
+		for id, v := range r.FixedVersions {
+			if out.FixedVersions == nil {
+				out.FixedVersions = make(map[string]string)
+			}
+			if _, ok := out.FixedVersions[id]; !ok {
+				out.FixedVersions[id] = v
+			}
+		}
+		for kind, ms := range r.Enrichments {
+			s, ok := enSeen[kind]
+			if !ok {
+				s = make(map[string]struct{})
+				enSeen[kind] = s
+			}
+			for _, m := range ms {
+				if _, ok := s[string(m)]; ok {
+					continue
+				}
+				s[string(m)] = struct{}{}
+				out.Enrichments[kind] = append(out.Enrichments[kind], m)
+			}
+		}
+		// Record the manifests affected by each vulnerability.
+		if len(r.VulnerabilityManifests) != 0 {
+			for v, ms := range r.VulnerabilityManifests {
+				for _, m := range ms {
+					add(vmSeen, out.VulnerabilityManifests, v, m)
+				}
+			}
+		} else if r.Hash.Checksum() != nil {
+			hash := r.Hash.String()
+			for _, vs := range r.PackageVulnerabilities {
+				for _, v := range vs {
+					add(vmSeen, out.VulnerabilityManifests, v, hash)
+				}
+			}
+		}
+	}
+	for _, ms := range out.VulnerabilityManifests {
+		sort.Strings(ms)
+	}
+	return out
+}
+
+// MarkInherited populates InheritedVulnerabilities with the vulnerabilities
+// that only affect packages provided by the layers in "base", which are
+// usually the layers of an approved base image.
+//
+// A package is considered provided by the layer that introduced its current
+// version, which means a package upgraded in a later layer to a version that
+// is still vulnerable is attributed to the later layer. Packages without any
+// layer information are never considered inherited.
+func (vr *VulnerabilityReport) MarkInherited(base ...Digest) {
+	vr.InheritedVulnerabilities = nil
+	if len(base) == 0 {
+		return
+	}
+	inBase := make(map[string]struct{}, len(base))
+	for _, d := range base {
+		inBase[d.String()] = struct{}{}
+	}
+	// Inherited tracks whether every package a vulnerability was reported
+	// for is from the base image.
+	inherited := make(map[string]bool)
+	for id, vs := range vr.PackageVulnerabilities {
+		fromBase := vr.fromBase(id, inBase)
+		for _, v := range vs {
+			prev, seen := inherited[v]
+			inherited[v] = fromBase && (!seen || prev)
+		}
+	}
+	for v, ok := range inherited {
+		if ok {
+			vr.InheritedVulnerabilities = append(vr.InheritedVulnerabilities, v)
+		}
+	}
+	sort.Strings(vr.InheritedVulnerabilities)
+}
+
+// FromBase reports whether the package with the provided id was provided by
+// one of the layers in the "base" set.
+func (vr *VulnerabilityReport) fromBase(id string, base map[string]struct{}) bool {
+	if p, ok := vr.Packages[id]; ok && p != nil {
+		switch {
+		case p.PresentIn != nil:
+			_, ok := base[p.PresentIn.String()]
+			return ok
+		case p.IntroducedIn != nil:
+			_, ok := base[p.IntroducedIn.String()]
+			return ok
+		}
+	}
+	envs := vr.Environments[id]
+	if len(envs) == 0 {
+		return false
+	}
+	for _, env := range envs {
+		if _, ok := base[env.IntroducedIn.String()]; !ok {
+			return false
+		}
+	}
+	return true
 }
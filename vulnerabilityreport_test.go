@@ -0,0 +1,122 @@
+package claircore_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/quay/claircore"
+)
+
+func TestMarkInherited(t *testing.T) {
+	var (
+		base = claircore.MustParseDigest(`sha256:` + "1111111111111111111111111111111111111111111111111111111111111111")
+		ours = claircore.MustParseDigest(`sha256:` + "2222222222222222222222222222222222222222222222222222222222222222")
+	)
+	vr := claircore.VulnerabilityReport{
+		Packages: map[string]*claircore.Package{
+			// Only in the base image.
+			"1": {ID: "1", Name: "openssl", IntroducedIn: &base, PresentIn: &base},
+			// Added by us.
+			"2": {ID: "2", Name: "curl", IntroducedIn: &ours, PresentIn: &ours},
+			// Upgraded by us, but still vulnerable.
+			"3": {ID: "3", Name: "zlib", IntroducedIn: &base, PresentIn: &ours},
+			// No package-level layer information.
+			"4": {ID: "4", Name: "requests"},
+			"5": {ID: "5", Name: "urllib3"},
+		},
+		Environments: map[string][]*claircore.Environment{
+			"4": {{IntroducedIn: base}},
+		},
+		PackageVulnerabilities: map[string][]string{
+			"1": {"a", "b"},
+			"2": {"b", "c"},
+			"3": {"d"},
+			"4": {"e"},
+			"5": {"f"},
+		},
+	}
+	vr.MarkInherited(base)
+	want := []string{"a", "e"}
+	if got := vr.InheritedVulnerabilities; !cmp.Equal(got, want) {
+		t.Error(cmp.Diff(got, want))
+	}
+
+	vr.MarkInherited()
+	if got := vr.InheritedVulnerabilities; got != nil {
+		t.Errorf("got: %v, want: nil", got)
+	}
+}
+
+func TestMergeReports(t *testing.T) {
+	var (
+		img1  = claircore.MustParseDigest(`sha256:` + "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa")
+		img2  = claircore.MustParseDigest(`sha256:` + "bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb")
+		img3  = claircore.MustParseDigest(`sha256:` + "cccccccccccccccccccccccccccccccccccccccccccccccccccccccccccccccc")
+		layer = claircore.MustParseDigest(`sha256:` + "1111111111111111111111111111111111111111111111111111111111111111")
+	)
+	openssl := &claircore.Package{ID: "1", Name: "openssl"}
+	curl := &claircore.Package{ID: "2", Name: "curl"}
+	v := func(id string) *claircore.Vulnerability { return &claircore.Vulnerability{ID: id, Name: "CVE-" + id} }
+	r1 := &claircore.VulnerabilityReport{
+		Hash:     img1,
+		Packages: map[string]*claircore.Package{"1": openssl},
+		Environments: map[string][]*claircore.Environment{
+			"1": {{PackageDB: "var/lib/rpm", IntroducedIn: layer}},
+		},
+		Vulnerabilities:        map[string]*claircore.Vulnerability{"a": v("a"), "b": v("b")},
+		PackageVulnerabilities: map[string][]string{"1": {"a", "b"}},
+		Enrichments:            map[string][]json.RawMessage{"test": {json.RawMessage(`{"a":1}`)}},
+	}
+	r2 := &claircore.VulnerabilityReport{
+		Hash:     img2,
+		Packages: map[string]*claircore.Package{"1": openssl, "2": curl},
+		Environments: map[string][]*claircore.Environment{
+			"1": {{PackageDB: "var/lib/rpm", IntroducedIn: layer}},
+			"2": {{PackageDB: "var/lib/rpm", IntroducedIn: layer}},
+		},
+		Vulnerabilities:        map[string]*claircore.Vulnerability{"a": v("a"), "c": v("c")},
+		PackageVulnerabilities: map[string][]string{"1": {"a"}, "2": {"c"}},
+		Enrichments:            map[string][]json.RawMessage{"test": {json.RawMessage(`{"a":1}`), json.RawMessage(`{"c":1}`)}},
+	}
+	// An existing aggregate.
+	r3 := claircore.MergeReports(&claircore.VulnerabilityReport{
+		Hash:                   img3,
+		Packages:               map[string]*claircore.Package{"2": curl},
+		Vulnerabilities:        map[string]*claircore.Vulnerability{"c": v("c")},
+		PackageVulnerabilities: map[string][]string{"2": {"c"}},
+	})
+
+	got := claircore.MergeReports(r1, r2, nil, r3)
+	opt := cmp.AllowUnexported(claircore.Digest{})
+	if got, want := len(got.Vulnerabilities), 3; got != want {
+		t.Errorf("vulnerabilities: got: %d, want: %d", got, want)
+	}
+	if got, want := len(got.Environments["1"]), 1; got != want {
+		t.Errorf("environments: got: %d, want: %d", got, want)
+	}
+	wantPV := map[string][]string{"1": {"a", "b"}, "2": {"c"}}
+	if !cmp.Equal(got.PackageVulnerabilities, wantPV) {
+		t.Error(cmp.Diff(got.PackageVulnerabilities, wantPV))
+	}
+	wantVM := map[string][]string{
+		"a": {img1.String(), img2.String()},
+		"b": {img1.String()},
+		"c": {img2.String(), img3.String()},
+	}
+	if !cmp.Equal(got.VulnerabilityManifests, wantVM) {
+		t.Error(cmp.Diff(got.VulnerabilityManifests, wantVM))
+	}
+	wantEn := map[string][]json.RawMessage{"test": {json.RawMessage(`{"a":1}`), json.RawMessage(`{"c":1}`)}}
+	if !cmp.Equal(got.Enrichments, wantEn) {
+		t.Error(cmp.Diff(got.Enrichments, wantEn))
+	}
+	if !cmp.Equal(got.Hash, claircore.Digest{}, opt) {
+		t.Errorf("unexpected hash: %v", got.Hash)
+	}
+	// Inputs are left alone.
+	if got, want := r1.PackageVulnerabilities["1"], []string{"a", "b"}; !cmp.Equal(got, want) {
+		t.Error(cmp.Diff(got, want))
+	}
+}